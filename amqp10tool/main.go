@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "amqp10tool",
+		Short: "AMQP 1.0 client tester",
+		Long:  "A simple AMQP 1.0 CLI (Artemis, Qpid, Azure Service Bus) with send and serve commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// connection is an AMQP connection with the single session used by the tool.
+type connection struct {
+	conn    *amqp.Conn
+	session *amqp.Session
+}
+
+// dial opens a connection and a session. Credentials in the URL authenticate with SASL PLAIN,
+// otherwise SASL ANONYMOUS is used.
+func dial(ctx context.Context, url string) (*connection, error) {
+	conn, err := amqp.Dial(ctx, url, &amqp.ConnOptions{SASLType: amqp.SASLTypeAnonymous()})
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.NewSession(ctx, nil)
+	if err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to begin session: %w", err)
+	}
+	return &connection{conn: conn, session: session}, nil
+}
+
+// Close ends the session and the connection.
+func (c *connection) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = c.session.Close(ctx)
+	_ = c.conn.Close()
+}
+
+// messageBody returns the body of m: the concatenated data sections, or the text of an
+// amqp-value body.
+func messageBody(m *amqp.Message) []byte {
+	if len(m.Data) > 0 {
+		var body []byte
+		for _, d := range m.Data {
+			body = append(body, d...)
+		}
+		return body
+	}
+	switch v := m.Value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return []byte(fmt.Sprint(v))
+	}
+}
+
+// messageItems lists the standard header and properties of m.
+func messageItems(m *amqp.Message) []toolutil.KV {
+	var items []toolutil.KV
+	add := func(k string, v any) {
+		items = append(items, toolutil.KV{Key: k, Value: fmt.Sprint(v)})
+	}
+	if p := m.Properties; p != nil {
+		if p.MessageID != nil {
+			add("Message-ID", p.MessageID)
+		}
+		if p.CorrelationID != nil {
+			add("Correlation-ID", p.CorrelationID)
+		}
+		if p.Subject != nil {
+			add("Subject", *p.Subject)
+		}
+		if p.To != nil {
+			add("To", *p.To)
+		}
+		if p.ReplyTo != nil {
+			add("Reply-To", *p.ReplyTo)
+		}
+		if p.GroupID != nil {
+			add("Group-ID", *p.GroupID)
+		}
+		if p.CreationTime != nil {
+			add("Created", p.CreationTime.Format(time.RFC3339Nano))
+		}
+	}
+	if h := m.Header; h != nil {
+		add("Durable", h.Durable)
+		add("Priority", h.Priority)
+		if h.DeliveryCount > 0 {
+			add("Delivery-Count", strconv.FormatUint(uint64(h.DeliveryCount), 10))
+		}
+	}
+	return items
+}
+
+// propertyItems converts application properties to sorted key/value items.
+func propertyItems(props map[string]any) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		items = append(items, toolutil.KV{Key: k, Value: fmt.Sprint(props[k])})
+	}
+	return items
+}
+
+// contentType returns the content-type property of m, if any.
+func contentType(m *amqp.Message) string {
+	if m.Properties != nil && m.Properties.ContentType != nil {
+		return *m.Properties.ContentType
+	}
+	return ""
+}
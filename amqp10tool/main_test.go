@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+)
+
+func TestMessageBody(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *amqp.Message
+		want string
+	}{
+		{"data sections", &amqp.Message{Data: [][]byte{[]byte("ab"), []byte("c")}}, "abc"},
+		{"string value", &amqp.Message{Value: "text"}, "text"},
+		{"binary value", &amqp.Message{Value: []byte{'x'}}, "x"},
+		{"other value", &amqp.Message{Value: int64(42)}, "42"},
+		{"empty", &amqp.Message{}, ""},
+	}
+	for _, tt := range tests {
+		if got := string(messageBody(tt.msg)); got != tt.want {
+			t.Errorf("%s: messageBody() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMessageItems(t *testing.T) {
+	subject := "orders"
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := &amqp.Message{
+		Header:     &amqp.MessageHeader{Durable: true, Priority: 4, DeliveryCount: 2},
+		Properties: &amqp.MessageProperties{MessageID: "m-1", Subject: &subject, CreationTime: &created},
+	}
+	got := map[string]string{}
+	for _, kv := range messageItems(m) {
+		got[kv.Key] = kv.Value
+	}
+	want := map[string]string{
+		"Message-ID":     "m-1",
+		"Subject":        "orders",
+		"Created":        "2024-05-01T12:00:00Z",
+		"Durable":        "true",
+		"Priority":       "4",
+		"Delivery-Count": "2",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("messageItems() = %v", got)
+	}
+}
+
+func TestPropertyItems(t *testing.T) {
+	items := propertyItems(map[string]any{"b": int32(2), "a": "x"})
+	if len(items) != 2 || items[0].Key != "a" || items[1].Value != "2" {
+		t.Errorf("propertyItems() = %v", items)
+	}
+}
+
+func TestParseOutcome(t *testing.T) {
+	for _, s := range []string{"accept", "Reject", "release", "MODIFY"} {
+		if _, err := parseOutcome(s); err != nil {
+			t.Errorf("parseOutcome(%q): %v", s, err)
+		}
+	}
+	if _, err := parseOutcome("complete"); err == nil {
+		t.Error("parseOutcome(complete) succeeded, want error")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		sendURL        string
+		sendAddress    string
+		subject        string
+		messageID      string
+		presettled     bool
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic messages to an AMQP 1.0 address",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var senderOpts *amqp.SenderOptions
+			if presettled {
+				mode := amqp.SenderSettleModeSettled
+				senderOpts = &amqp.SenderOptions{SettlementMode: &mode}
+			}
+			var (
+				conn   *connection
+				sender *amqp.Sender
+			)
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					c, err := dial(ctx, sendURL)
+					if err != nil {
+						return err
+					}
+					s, err := c.session.NewSender(ctx, sendAddress, senderOpts)
+					if err != nil {
+						c.Close()
+						return fmt.Errorf("failed to attach sender: %w", err)
+					}
+					conn, sender = c, s
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to AMQP 1.0 endpoint: %w", err)
+			}
+			defer func() { conn.Close() }()
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				conn.Close()
+				return connect()
+			})
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			var appProps map[string]any
+			if len(headerMap) > 0 {
+				appProps = make(map[string]any, len(headerMap))
+				for k, v := range headerMap {
+					appProps[k] = v
+				}
+			}
+
+			toolutil.PrintSuccess("Connected to AMQP 1.0 endpoint")
+			toolutil.PrintKeyValue("URL", sendURL)
+			toolutil.PrintKeyValue("Address", sendAddress)
+			if presettled {
+				toolutil.PrintKeyValue("Settlement", "pre-settled")
+			}
+
+			subj := toolutil.NewDestination(subject, openDelim, closeDelim)
+			msgID := toolutil.NewDestination(messageID, openDelim, closeDelim)
+			// resolve interpolates an optional templated property, leaving it unset when the flag is empty.
+			resolve := func(raw string, d toolutil.Destination, name string) (*string, error) {
+				if raw == "" {
+					return nil, nil
+				}
+				v, err := d.Resolve()
+				if err != nil {
+					return nil, fmt.Errorf("%s build error: %w", name, err)
+				}
+				return &v, nil
+			}
+
+			send := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					toolutil.PrintError("Reconnect error: %v", err)
+					return err
+				}
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				now := time.Now()
+				props := &amqp.MessageProperties{ContentType: &ct, CreationTime: &now}
+				if props.Subject, err = resolve(subject, subj, "Subject"); err != nil {
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				id, err := resolve(messageID, msgID, "Message id")
+				if err != nil {
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				if id != nil {
+					props.MessageID = *id
+				}
+				msg := &amqp.Message{Data: [][]byte{body}, Properties: props, ApplicationProperties: appProps}
+
+				// Unsettled sends wait for the receiver's outcome; a rejection is returned as an error.
+				sendCtx, sendCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer sendCancel()
+				if err := sender.Send(sendCtx, msg, nil); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Sent %d bytes to '%s'", len(body), sendAddress)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&sendURL, "url", "amqp://localhost:5672", "AMQP 1.0 URL, amqp:// or amqps:// (user:password in the URL authenticates with SASL PLAIN)")
+	cmd.Flags().StringVar(&sendAddress, "address", "test", "Target address (queue, topic or node name)")
+	cmd.Flags().StringVar(&subject, "subject", "", "Message subject, supports template placeholders")
+	cmd.Flags().StringVar(&messageID, "message-id", "", "Message id, supports template placeholders")
+	cmd.Flags().BoolVar(&presettled, "presettled", false, "Send messages pre-settled (at-most-once) instead of waiting for the outcome")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, AMQP 1.0!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
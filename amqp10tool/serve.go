@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// outcome is how serve settles each received message.
+type outcome string
+
+const (
+	outcomeAccept  outcome = "accept"
+	outcomeReject  outcome = "reject"
+	outcomeRelease outcome = "release"
+	outcomeModify  outcome = "modify"
+)
+
+func parseOutcome(s string) (outcome, error) {
+	switch o := outcome(strings.ToLower(s)); o {
+	case outcomeAccept, outcomeReject, outcomeRelease, outcomeModify:
+		return o, nil
+	}
+	return "", fmt.Errorf("invalid --settle %q: expected accept, reject, release or modify", s)
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		subURL         string
+		subAddress     string
+		settle         string
+		presettled     bool
+		credit         int32
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Receive and log messages from an AMQP 1.0 address",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oc, err := parseOutcome(settle)
+			if err != nil {
+				return err
+			}
+			if presettled && cmd.Flags().Changed("settle") {
+				return fmt.Errorf("--settle cannot be used with --presettled")
+			}
+			if credit < 1 {
+				return fmt.Errorf("--credit must be at least 1")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			opts := &amqp.ReceiverOptions{Credit: credit}
+			if presettled {
+				mode := amqp.SenderSettleModeSettled
+				opts.RequestedSenderSettleMode = &mode
+			}
+			var (
+				conn     *connection
+				receiver *amqp.Receiver
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				c, err := dial(ctx, subURL)
+				if err != nil {
+					return err
+				}
+				r, err := c.session.NewReceiver(ctx, subAddress, opts)
+				if err != nil {
+					c.Close()
+					return fmt.Errorf("failed to attach receiver: %w", err)
+				}
+				conn, receiver = c, r
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to AMQP 1.0 endpoint: %w", err)
+			}
+			defer conn.Close()
+
+			toolutil.PrintSuccess("Receiving from AMQP 1.0 endpoint")
+			toolutil.PrintKeyValue("URL", subURL)
+			toolutil.PrintKeyValue("Address", subAddress)
+			if presettled {
+				toolutil.PrintKeyValue("Settlement", "pre-settled")
+			} else {
+				toolutil.PrintKeyValue("Settle", string(oc))
+			}
+
+			for {
+				msg, err := receiver.Receive(ctx, nil)
+				if ctx.Err() != nil {
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("receive error: %w", err)
+				}
+				printed := printMessage(subAddress, msg)
+				if presettled {
+					continue
+				}
+				msgOutcome := oc
+				if !printed {
+					// Dropped after --max-messages: hand it back for redelivery
+					msgOutcome = outcomeRelease
+				}
+				if err := settleMessage(receiver, msg, msgOutcome); err != nil {
+					toolutil.PrintError("Failed to %s message: %v", msgOutcome, err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&subURL, "url", "amqp://localhost:5672", "AMQP 1.0 URL, amqp:// or amqps:// (user:password in the URL authenticates with SASL PLAIN)")
+	cmd.Flags().StringVar(&subAddress, "address", "test", "Source address (queue, topic subscription or node name)")
+	cmd.Flags().StringVar(&settle, "settle", string(outcomeAccept), "Outcome for received messages: accept, reject (dead-letter), release (redeliver) or modify (redeliver, counted as a failed delivery)")
+	cmd.Flags().BoolVar(&presettled, "presettled", false, "Ask the sender to deliver messages pre-settled (at-most-once, nothing to settle)")
+	cmd.Flags().Int32Var(&credit, "credit", 10, "Link credit: maximum unsettled messages prefetched")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// settleMessage applies oc to msg. Settlement uses its own context so messages printed right
+// before shutdown are still settled.
+func settleMessage(receiver *amqp.Receiver, msg *amqp.Message, oc outcome) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	switch oc {
+	case outcomeReject:
+		return receiver.RejectMessage(ctx, msg, &amqp.Error{Condition: amqp.ErrCondInternalError, Description: "rejected by eventkit"})
+	case outcomeRelease:
+		return receiver.ReleaseMessage(ctx, msg)
+	case outcomeModify:
+		return receiver.ModifyMessage(ctx, msg, &amqp.ModifyMessageOptions{DeliveryFailed: true})
+	}
+	return receiver.AcceptMessage(ctx, msg)
+}
+
+// printMessage prints msg and reports whether it was printed, see toolutil.PrintColoredMessage.
+func printMessage(address string, msg *amqp.Message) bool {
+	body := messageBody(msg)
+	sections := []toolutil.MessageSection{
+		{Title: "Queue", Items: []toolutil.KV{{Key: "Name", Value: address}}},
+		{Title: "Message", Items: messageItems(msg)},
+		toolutil.HeadersSection(propertyItems(msg.ApplicationProperties)),
+	}
+	ct := contentType(msg)
+	if ct == "" {
+		ct = toolutil.GuessMIME(body)
+	}
+	return toolutil.PrintColoredMessage("AMQP 1.0", sections, body, ct)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "amqptool",
+		Short: "AMQP 0-9-1 (RabbitMQ) client tester",
+		Long:  "A simple AMQP 0-9-1 CLI with send and serve commands for RabbitMQ exchanges and queues.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// dial opens an AMQP connection, bounding the TCP and handshake phases by timeout
+// (the client default applies when --connect-timeout is disabled).
+func dial(url string, timeout time.Duration) (*amqp.Connection, error) {
+	cfg := amqp.Config{Heartbeat: 10 * time.Second, Locale: "en_US"}
+	if timeout > 0 {
+		cfg.Dial = amqp.DefaultDial(timeout)
+	}
+	return amqp.DialConfig(url, cfg)
+}
+
+// declareExchange declares exchange with kind when kind is set; otherwise the exchange must already exist.
+func declareExchange(ch *amqp.Channel, exchange, kind string) error {
+	if exchange == "" || kind == "" {
+		return nil
+	}
+	if err := ch.ExchangeDeclare(exchange, kind, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %q: %w", exchange, err)
+	}
+	return nil
+}
+
+// tableItems converts AMQP headers to sorted key/value items.
+func tableItems(t amqp.Table) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(t)) {
+		v := t[k]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		items = append(items, toolutil.KV{Key: k, Value: fmt.Sprintf("%v", v)})
+	}
+	return items
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestTableItems(t *testing.T) {
+	got := tableItems(amqp.Table{"b": int32(2), "a": "x", "c": []byte("bytes")})
+	want := []toolutil.KV{{Key: "a", Value: "x"}, {Key: "b", Value: "2"}, {Key: "c", Value: "bytes"}}
+	if len(got) != len(want) {
+		t.Fatalf("tableItems() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tableItems()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if items := tableItems(nil); len(items) != 0 {
+		t.Errorf("tableItems(nil) = %v, want empty", items)
+	}
+}
+
+func TestPropertyItems(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	got := propertyItems(amqp.Delivery{ContentType: toolutil.CTJSON, MessageId: "m1", CorrelationId: "c1", AppId: "app", Timestamp: ts})
+	want := []toolutil.KV{
+		{Key: "Content-Type", Value: toolutil.CTJSON},
+		{Key: "Message-Id", Value: "m1"},
+		{Key: "Correlation-Id", Value: "c1"},
+		{Key: "App-Id", Value: "app"},
+		{Key: "Timestamp", Value: "2026-01-02T03:04:05Z"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("propertyItems() = %v, want %v", got, want)
+	}
+	if items := propertyItems(amqp.Delivery{}); len(items) != 0 {
+		t.Errorf("propertyItems() of an empty delivery = %v, want none", items)
+	}
+}
+
+func TestDial(t *testing.T) {
+	if _, err := dial("http://localhost:5672/", time.Second); err == nil {
+		t.Error("dial() expected an error for a non-AMQP URL")
+	}
+	// A listener that never answers the AMQP handshake must not block past the timeout.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close() //nolint:errcheck
+	start := time.Now()
+	if _, err := dial("amqp://guest:guest@"+ln.Addr().String()+"/", 200*time.Millisecond); err == nil {
+		t.Error("dial() expected an error for a silent server")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("dial() took %s, want it bounded by the timeout", d)
+	}
+}
+
+func TestHandleDelivery_Ack(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	ack := &fakeAcknowledger{}
+	handleDelivery("orders", amqp.Delivery{Acknowledger: ack, DeliveryTag: 7, Headers: amqp.Table{"x": "y"}, Body: []byte("hi")})
+	if want := "[ack:7]"; fmt.Sprint(ack.calls) != want {
+		t.Errorf("acknowledgements = %v, want %s", ack.calls, want)
+	}
+	if n := toolutil.ReceivedCount(); n != 1 {
+		t.Errorf("printed %d messages, want 1", n)
+	}
+}
+
+// fakeAcknowledger records acks as "ack:tag" and nacks as "nack:tag".
+type fakeAcknowledger struct {
+	calls []string
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, _ bool) error {
+	f.calls = append(f.calls, fmt.Sprintf("ack:%d", tag))
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, _, requeue bool) error {
+	f.calls = append(f.calls, fmt.Sprintf("nack:%d:%t", tag, requeue))
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	f.calls = append(f.calls, fmt.Sprintf("reject:%d:%t", tag, requeue))
+	return nil
+}
+
+func TestHandleDelivery_MaxMessages(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{MaxMessages: 1, AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	ack := &fakeAcknowledger{}
+	for tag := uint64(1); tag <= 2; tag++ {
+		handleDelivery("orders", amqp.Delivery{Acknowledger: ack, DeliveryTag: tag, Body: []byte("{}")})
+	}
+	if want := "[ack:1 nack:2:true]"; fmt.Sprint(ack.calls) != want {
+		t.Errorf("acknowledgements = %v, want %s", ack.calls, want)
+	}
+}
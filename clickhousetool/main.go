@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "clickhousetool",
+		Short: "ClickHouse streaming insert tester",
+		Long:  "A simple ClickHouse CLI that inserts batches of templated rows over the native or HTTP protocol and tails a table by a monotonically increasing column.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// chOptions configure the server connection shared by both commands.
+type chOptions struct {
+	Addr     string
+	Protocol string
+	Database string
+	Username string
+	Password string
+	TLS      bool
+	Insecure bool
+}
+
+func addCHFlags(cmd *cobra.Command, opts *chOptions) {
+	cmd.Flags().StringVar(&opts.Addr, "addr", "localhost:9000", "Server address host:port, comma separated for several replicas (HTTP usually listens on 8123)")
+	cmd.Flags().StringVar(&opts.Protocol, "protocol", "native", "Wire protocol: native or http")
+	cmd.Flags().StringVar(&opts.Database, "database", "default", "Database of unqualified table names")
+	cmd.Flags().StringVar(&opts.Username, "username", "default", "Username")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "Password")
+	cmd.Flags().BoolVar(&opts.TLS, "tls", false, "Connect with TLS (native 9440, HTTPS 8443)")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "Skip TLS certificate verification")
+}
+
+// options converts the flags to client options.
+func (o chOptions) options(dialTimeout time.Duration) (*clickhouse.Options, error) {
+	opts := &clickhouse.Options{
+		Auth:        clickhouse.Auth{Database: o.Database, Username: o.Username, Password: o.Password},
+		DialTimeout: dialTimeout,
+	}
+	switch o.Protocol {
+	case "native":
+		opts.Protocol = clickhouse.Native
+	case "http":
+		opts.Protocol = clickhouse.HTTP
+	default:
+		return nil, fmt.Errorf("invalid --protocol %q: expected native or http", o.Protocol)
+	}
+	for _, a := range strings.Split(o.Addr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			opts.Addr = append(opts.Addr, a)
+		}
+	}
+	if len(opts.Addr) == 0 {
+		return nil, fmt.Errorf("--addr is required")
+	}
+	if o.TLS {
+		opts.TLS = &tls.Config{InsecureSkipVerify: o.Insecure} //nolint:gosec // opt-in for test servers
+	}
+	return opts, nil
+}
+
+// dial opens a connection and checks it with the server version, returned for display.
+func dial(ctx context.Context, opts *clickhouse.Options) (driver.Conn, string, error) {
+	conn, err := clickhouse.Open(opts)
+	if err != nil {
+		return nil, "", err
+	}
+	var version string
+	if err := conn.QueryRow(ctx, "SELECT version()").Scan(&version); err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, "", err
+	}
+	return conn, version, nil
+}
+
+// quoteIdent quotes a possibly database-qualified name such as db.table, part by part.
+func quoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(p) + "`"
+	}
+	return strings.Join(parts, ".")
+}
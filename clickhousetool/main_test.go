@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+func TestOptions(t *testing.T) {
+	opts, err := chOptions{Addr: "a:9000, b:9000,", Protocol: "http", Database: "db", Username: "u", Password: "p", TLS: true}.options(3 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Protocol != clickhouse.HTTP || strings.Join(opts.Addr, ",") != "a:9000,b:9000" || opts.Auth.Database != "db" || opts.TLS == nil || opts.DialTimeout != 3*time.Second {
+		t.Errorf("options = %+v", opts)
+	}
+	if opts, _ := (chOptions{Addr: "a:9000", Protocol: "native"}).options(time.Second); opts.Protocol != clickhouse.Native || opts.TLS != nil {
+		t.Errorf("native options = %+v", opts)
+	}
+	if _, err := (chOptions{Addr: "a:9000", Protocol: "grpc"}).options(time.Second); err == nil {
+		t.Error("expected invalid protocol error")
+	}
+	if _, err := (chOptions{Addr: " , ", Protocol: "native"}).options(time.Second); err == nil {
+		t.Error("expected missing address error")
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := map[string]string{
+		"events":     "`events`",
+		"db.events":  "`db`.`events`",
+		"we`ird\\id": "`we\\`ird\\\\id`",
+	}
+	for in, want := range tests {
+		if got := quoteIdent(in); got != want {
+			t.Errorf("quoteIdent(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	got := insertQuery("db.events", "JSONEachRow", [][]byte{[]byte(`{"a":1}` + "\n"), []byte(`{"a":"?"}`)})
+	want := "INSERT INTO `db`.`events` FORMAT JSONEachRow\n{\"a\":1}\n{\"a\":\"?\"}\n"
+	if got != want {
+		t.Errorf("insertQuery = %q, want %q", got, want)
+	}
+	for _, f := range []string{"JSONEachRow", "CSVWithNames", "TSV"} {
+		if !formatName.MatchString(f) {
+			t.Errorf("format %s rejected", f)
+		}
+	}
+	if formatName.MatchString("CSV; DROP TABLE x") {
+		t.Error("format with SQL accepted")
+	}
+}
+
+func TestInsertSettings(t *testing.T) {
+	if s := insertSettings(false); s["date_time_input_format"] != "best_effort" || s["async_insert"] != nil {
+		t.Errorf("sync settings = %v", s)
+	}
+	if s := insertSettings(true); s["async_insert"] != 1 || s["wait_for_async_insert"] != 1 {
+		t.Errorf("async settings = %v", s)
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	tl := &tailer{table: "events", column: "ts", size: 10, colType: "DateTime64(3)"}
+	query, args := tl.pageQuery()
+	if query != "SELECT toString(`ts`), formatRowNoNewline('JSONEachRow', *) FROM `events` ORDER BY `ts` LIMIT 10" || len(args) != 0 {
+		t.Errorf("first page = %s %v", query, args)
+	}
+
+	cursor := "2026-01-02 03:04:05.678"
+	tl.cursor, tl.where = &cursor, "level = 'error'"
+	query, args = tl.pageQuery()
+	want := "SELECT toString(`ts`), formatRowNoNewline('JSONEachRow', *) FROM `events` WHERE `ts` > CAST(?, ?) AND (level = 'error') ORDER BY `ts` LIMIT 10"
+	if query != want || len(args) != 2 || args[0] != cursor || args[1] != "DateTime64(3)" {
+		t.Errorf("next page = %s %v", query, args)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// formatName matches ClickHouse input format names such as JSONEachRow or CSVWithNames.
+var formatName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// insertQuery builds an INSERT carrying the rows inline in the given input format, which both
+// protocols send as query text; rows are separated by newlines.
+func insertQuery(table, format string, rows [][]byte) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "INSERT INTO %s FORMAT %s\n", quoteIdent(table), format)
+	for _, r := range rows {
+		buf.Write(bytes.TrimRight(r, "\r\n"))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// insertSettings are the query settings of the inserts: timestamps are parsed leniently so
+// RFC 3339 payload values fit DateTime columns.
+func insertSettings(async bool) clickhouse.Settings {
+	settings := clickhouse.Settings{"date_time_input_format": "best_effort"}
+	if async {
+		settings["async_insert"] = 1
+		settings["wait_for_async_insert"] = 1
+	}
+	return settings
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		opts           chOptions
+		table          string
+		format         string
+		batch          int
+		asyncInsert    bool
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Insert periodic batches of templated rows",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch < 1 {
+				return fmt.Errorf("--batch must be at least 1")
+			}
+			if !formatName.MatchString(format) {
+				return fmt.Errorf("invalid --format %q", format)
+			}
+			chOpts, err := opts.options(connectTimeout)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var conn driver.Conn
+			var version string
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					var err error
+					conn, version, err = dial(ctx, chOpts)
+					return err
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to ClickHouse: %w", err)
+			}
+			defer conn.Close() //nolint:errcheck
+
+			toolutil.PrintSuccess("Connected to ClickHouse %s", version)
+			toolutil.PrintKeyValue("Address", opts.Addr)
+			toolutil.PrintKeyValue("Protocol", opts.Protocol)
+			toolutil.PrintKeyValue("Table", table)
+			toolutil.PrintKeyValue("Format", format)
+			toolutil.PrintKeyValue("Batch", batch)
+
+			settings := insertSettings(asyncInsert)
+			tableDest := toolutil.NewDestination(table, openDelim, closeDelim)
+			send := func() error {
+				t, err := tableDest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Table build error: %v", err)
+					return err
+				}
+				rows := make([][]byte, 0, batch)
+				size := 0
+				for range batch {
+					body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+					if err != nil {
+						toolutil.PrintError("Payload build error: %v", err)
+						return err
+					}
+					rows = append(rows, body)
+					size += len(body)
+				}
+
+				insertCtx, insertCancel := context.WithTimeout(ctx, 30*time.Second)
+				defer insertCancel()
+				start := time.Now()
+				if err := conn.Exec(clickhouse.Context(insertCtx, clickhouse.WithSettings(settings)), insertQuery(t, format, rows)); err != nil {
+					toolutil.PrintError("Insert error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Inserted %d row(s) into %s (%d bytes) in %s", len(rows), t, size, time.Since(start).Round(time.Millisecond))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addCHFlags(cmd, &opts)
+	cmd.Flags().StringVar(&table, "table", "events", "Target table, optionally db.table, supports template placeholders")
+	cmd.Flags().StringVar(&format, "format", "JSONEachRow", "Input format of the payload rows (e.g. JSONEachRow, CSV, TSV)")
+	cmd.Flags().IntVar(&batch, "batch", 1, "Rows built from the payload and inserted per INSERT")
+	cmd.Flags().BoolVar(&asyncInsert, "async-insert", false, "Use server-side asynchronous inserts, waiting for the flush")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"timestamp":"{{nowtime}}","message":"{{sentence}}","count":{{counter}}}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// tailer reads the rows of a table whose cursor column is above the last value read. Cursor
+// values travel as strings cast back to the column type, so any ordered type works.
+type tailer struct {
+	conn    driver.Conn
+	table   string
+	column  string
+	where   string
+	size    int
+	colType string
+	cursor  *string
+}
+
+// columnType looks up the type of the cursor column in system.columns.
+func (t *tailer) columnType(ctx context.Context) (string, error) {
+	database, table := "", t.table
+	if i := strings.Index(t.table, "."); i >= 0 {
+		database, table = t.table[:i], t.table[i+1:]
+	}
+	query := "SELECT type FROM system.columns WHERE database = currentDatabase() AND table = ? AND name = ?"
+	args := []any{table, t.column}
+	if database != "" {
+		query = "SELECT type FROM system.columns WHERE database = ? AND table = ? AND name = ?"
+		args = append([]any{database}, args...)
+	}
+	var colType string
+	if err := t.conn.QueryRow(ctx, query, args...).Scan(&colType); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("column %q not found in table %s", t.column, t.table)
+		}
+		return "", err
+	}
+	return colType, nil
+}
+
+// start resolves the cursor column type and, unless existing rows are wanted, moves the cursor
+// to the current maximum.
+func (t *tailer) start(ctx context.Context, existing bool) error {
+	colType, err := t.columnType(ctx)
+	if err != nil {
+		return err
+	}
+	t.colType, t.cursor = colType, nil
+	if existing {
+		return nil
+	}
+	var count uint64
+	var maxValue string
+	query := fmt.Sprintf("SELECT count(), toString(max(%s)) FROM %s", quoteIdent(t.column), quoteIdent(t.table))
+	if t.where != "" {
+		query += " WHERE " + t.where
+	}
+	if err := t.conn.QueryRow(ctx, query).Scan(&count, &maxValue); err != nil {
+		return err
+	}
+	if count > 0 {
+		t.cursor = &maxValue
+	}
+	return nil
+}
+
+// pageQuery returns the query of the next page of rows after the cursor, with its arguments.
+func (t *tailer) pageQuery() (string, []any) {
+	col := quoteIdent(t.column)
+	var conds []string
+	var args []any
+	if t.cursor != nil {
+		conds = append(conds, col+" > CAST(?, ?)")
+		args = append(args, *t.cursor, t.colType)
+	}
+	if t.where != "" {
+		conds = append(conds, "("+t.where+")")
+	}
+	query := fmt.Sprintf("SELECT toString(%s), formatRowNoNewline('JSONEachRow', *) FROM %s", col, quoteIdent(t.table))
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return query + fmt.Sprintf(" ORDER BY %s LIMIT %d", col, t.size), args
+}
+
+// poll reports the rows inserted since the previous poll in cursor order, as JSON objects.
+func (t *tailer) poll(ctx context.Context, report func(cursor string, row []byte)) error {
+	for {
+		query, args := t.pageQuery()
+		rows, err := t.conn.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for rows.Next() {
+			var cursor, row string
+			if err := rows.Scan(&cursor, &row); err != nil {
+				rows.Close() //nolint:errcheck
+				return err
+			}
+			t.cursor = &cursor
+			report(cursor, []byte(row))
+			n++
+		}
+		err = rows.Err()
+		rows.Close() //nolint:errcheck
+		if err != nil {
+			return err
+		}
+		if n < t.size {
+			return nil
+		}
+	}
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           chOptions
+		table          string
+		column         string
+		where          string
+		size           int
+		existing       bool
+		pollInterval   time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Tail a table by a monotonically increasing column and print new rows",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if size < 1 {
+				return fmt.Errorf("--size must be at least 1")
+			}
+			chOpts, err := opts.options(connectTimeout)
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			t := &tailer{table: table, column: column, where: where, size: size}
+			var version string
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				var err error
+				if t.conn, version, err = dial(ctx, chOpts); err != nil {
+					return err
+				}
+				return t.start(ctx, existing)
+			}); err != nil {
+				return fmt.Errorf("error connecting to ClickHouse: %w", err)
+			}
+			defer t.conn.Close() //nolint:errcheck
+
+			toolutil.PrintSuccess("Tailing ClickHouse %s table", version)
+			toolutil.PrintKeyValue("Address", opts.Addr)
+			toolutil.PrintKeyValue("Table", table)
+			toolutil.PrintKeyValue("Column", fmt.Sprintf("%s (%s)", column, t.colType))
+			if t.cursor != nil {
+				toolutil.PrintKeyValue("After", *t.cursor)
+			}
+
+			report := func(cursor string, row []byte) {
+				sections := []toolutil.MessageSection{{Title: "Row", Items: []toolutil.KV{
+					{Key: "Table", Value: table},
+					{Key: column, Value: cursor},
+				}}}
+				toolutil.PrintColoredMessage("ClickHouse", sections, row, toolutil.CTJSON)
+			}
+			failing := false
+			for {
+				if err := t.poll(ctx, report); err != nil {
+					if ctx.Err() != nil {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					if !failing {
+						toolutil.PrintWarning("Poll failed: %v", err)
+						failing = true
+					}
+				} else if failing {
+					toolutil.PrintInfo("Polling resumed")
+					failing = false
+				}
+
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case <-time.After(pollInterval):
+				}
+			}
+		},
+	}
+
+	addCHFlags(cmd, &opts)
+	cmd.Flags().StringVar(&table, "table", "events", "Table to tail, optionally db.table")
+	cmd.Flags().StringVar(&column, "column", "timestamp", "Monotonically increasing column marking new rows (e.g. a timestamp or sequence)")
+	cmd.Flags().StringVar(&where, "where", "", "Additional SQL filter on the rows")
+	cmd.Flags().IntVar(&size, "size", 1000, "Rows fetched per query")
+	cmd.Flags().BoolVar(&existing, "existing", false, "Also print the rows inserted before the tail started")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 2*time.Second, "Delay between polls")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
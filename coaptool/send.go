@@ -3,11 +3,14 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"time"
 
+	"github.com/plgd-dev/go-coap/v3/options"
 	coaptcp "github.com/plgd-dev/go-coap/v3/tcp"
 	coapudp "github.com/plgd-dev/go-coap/v3/udp"
 	"github.com/sandrolain/eventkit/pkg/common"
@@ -28,9 +31,25 @@ func sendCommand() *cobra.Command {
 		openDelim      string
 		closeDelim     string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
 	)
 
 	cmd := &cobra.Command{
@@ -46,8 +65,41 @@ func sendCommand() *cobra.Command {
 			if seed != 0 {
 				testpayload.SeedRandom(seed)
 			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
 			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
 			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
 
 			_, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
 			if err != nil {
@@ -55,17 +107,46 @@ func sendCommand() *cobra.Command {
 			}
 			// Note: CoAP headers would be mapped to options in the protocol implementation
 
-			sendOnce := func() {
-				var body []byte
-				var ct string
+			dialer := options.WithDialer(&net.Dialer{Timeout: connectTimeout})
 
-				b, err := testpayload.InterpolateWithDelimiters(sendPayload, openDelim, closeDelim)
+			// Requests dial their own connection, so with --wait-for-broker the server is
+			// pinged until it answers before the first one.
+			if connectRetry.WaitForBroker {
+				err := common.ConnectWithRetry(ctx, connectRetry.Policy(), func() error {
+					return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+						switch sendProto {
+						case "udp":
+							client, err := coapudp.Dial(sendAddress, dialer)
+							if err != nil {
+								return err
+							}
+							defer client.Close() //nolint:errcheck
+							return client.Ping(ctx)
+						case "tcp":
+							client, err := coaptcp.Dial(sendAddress, dialer)
+							if err != nil {
+								return err
+							}
+							defer client.Close() //nolint:errcheck
+							return client.Ping(ctx)
+						}
+						return fmt.Errorf("unknown proto: %s (use udp or tcp)", sendProto)
+					})
+				})
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to interpolate payload: %v\n", err)
-					return
+					return fmt.Errorf("error connecting to CoAP server: %w", err)
+				}
+			}
+
+			sendOnce := func() error {
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if errors.Is(err, common.ErrStop) {
+					return err
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to build payload: %v\n", err)
+					return nil
 				}
-				body = b
-				ct = sendMIME
 
 				if ct == "" {
 					ct = toolutil.CTJSON
@@ -81,16 +162,16 @@ func sendCommand() *cobra.Command {
 
 				switch sendProto {
 				case "udp":
-					client, err := coapudp.Dial(sendAddress)
+					client, err := coapudp.Dial(sendAddress, dialer)
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "Failed to dial CoAP (udp): %v\n", err)
-						return
+						return nil
 					}
 					defer client.Close() //nolint:errcheck
 					resp, err := client.Post(ctx, sendPath, mt, bytes.NewReader(body))
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "POST error: %v\n", err)
-						return
+						return nil
 					}
 					code = resp.Code()
 					if resp.Body() != nil {
@@ -102,16 +183,16 @@ func sendCommand() *cobra.Command {
 						}
 					}
 				case "tcp":
-					client, err := coaptcp.Dial(sendAddress)
+					client, err := coaptcp.Dial(sendAddress, dialer)
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "Failed to dial CoAP (tcp): %v\n", err)
-						return
+						return nil
 					}
 					defer client.Close() //nolint:errcheck
 					resp, err := client.Post(ctx, sendPath, mt, bytes.NewReader(body))
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "POST error: %v\n", err)
-						return
+						return nil
 					}
 					code = resp.Code()
 					if resp.Body() != nil {
@@ -124,19 +205,24 @@ func sendCommand() *cobra.Command {
 					}
 				default:
 					fmt.Fprintf(os.Stderr, "Unknown proto: %s (use udp or tcp)\n", sendProto)
-					return
+					return nil
 				}
 
 				logger.Info("Response received", "code", code, "len", len(respBody))
 				if len(respBody) > 0 {
 					logger.Info("Response body", "body", string(respBody))
 				}
+				return nil
 			}
 
-			return common.RunOnceOrPeriodic(ctx, once, sendInterval, func() error {
-				sendOnce()
-				return nil
-			})
+			send := func() error {
+				return sendOnce()
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
 		},
 	}
 
@@ -145,12 +231,28 @@ func sendCommand() *cobra.Command {
 	toolutil.AddPayloadFlags(cmd, &sendPayload, "{}", &sendMIME, toolutil.CTJSON)
 	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
 	cmd.Flags().StringVar(&sendProto, "proto", "udp", "CoAP transport protocol: udp or tcp")
 	toolutil.AddHeadersFlag(cmd, &headers)
 	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
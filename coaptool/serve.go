@@ -2,12 +2,14 @@ package main
 
 import (
 	"fmt"
-	"log/slog"
-	"os"
+	"net"
 
 	coap "github.com/plgd-dev/go-coap/v3"
 	coapmux "github.com/plgd-dev/go-coap/v3/mux"
-	"github.com/sandrolain/eventkit/pkg/common"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/options"
+	coapudp "github.com/plgd-dev/go-coap/v3/udp"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
 	"github.com/spf13/cobra"
 )
 
@@ -15,17 +17,29 @@ func serveCommand() *cobra.Command {
 	var (
 		serveAddr  string
 		serveProto string
+		mcastGroup string
+		mcastIface string
+		serveOpts  toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Run a CoAP server that logs requests",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := common.SetupGracefulShutdown()
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
 			defer cancel()
 
-			logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-			logger.Info("Starting CoAP server", "proto", serveProto, "addr", serveAddr)
+			logger := toolutil.Logger()
+			if mcastGroup != "" && serveProto != "udp" {
+				return fmt.Errorf("--multicast-group requires --proto udp")
+			}
+			logger.Info("Starting CoAP server", "proto", serveProto, "addr", serveAddr, "multicast_group", mcastGroup)
 
 			router := coapmux.NewRouter()
 			if err := router.Handle("/", SimpleOKHandler(serveProto)); err != nil {
@@ -35,6 +49,10 @@ func serveCommand() *cobra.Command {
 			// Start server in goroutine
 			errChan := make(chan error, 1)
 			go func() {
+				if mcastGroup != "" {
+					errChan <- ServeMulticast(serveAddr, mcastGroup, mcastIface, router)
+					return
+				}
 				errChan <- Serve(serveProto, serveAddr, router)
 			}()
 
@@ -51,6 +69,9 @@ func serveCommand() *cobra.Command {
 
 	cmd.Flags().StringVar(&serveAddr, "address", ":5683", "Listen address (e.g.: :5683)")
 	cmd.Flags().StringVar(&serveProto, "proto", "udp", "CoAP transport protocol: udp or tcp")
+	cmd.Flags().StringVar(&mcastGroup, "multicast-group", "", "Join this UDP multicast group and log datagrams sent to it (e.g. 224.0.1.187 or ff02::fd for All CoAP Nodes)")
+	cmd.Flags().StringVar(&mcastIface, "multicast-iface", "", "Network interface used to join --multicast-group (default: all multicast-capable interfaces)")
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
@@ -64,3 +85,66 @@ func Serve(proto, addr string, router *coapmux.Router) error {
 		return fmt.Errorf("unknown mode: %s (use udp or tcp)", proto)
 	}
 }
+
+// ServeMulticast listens on addr, joins the multicast group on the given interface
+// (or on every multicast-capable interface when iface is empty) and serves router.
+func ServeMulticast(addr, group, iface string, router *coapmux.Router) error {
+	ip := net.ParseIP(group)
+	if ip == nil || !ip.IsMulticast() {
+		return fmt.Errorf("invalid multicast group: %s", group)
+	}
+	network := "udp4"
+	if ip.To4() == nil {
+		network = "udp6"
+	}
+
+	ifaces, err := multicastInterfaces(iface)
+	if err != nil {
+		return err
+	}
+
+	l, err := coapnet.NewListenUDP(network, addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	defer l.Close() //nolint:errcheck
+
+	gaddr := &net.UDPAddr{IP: ip}
+	joined := 0
+	for i := range ifaces {
+		if err := l.JoinGroup(&ifaces[i], gaddr); err != nil {
+			toolutil.PrintWarning("Failed to join %s on %s: %v", group, ifaces[i].Name, err)
+			continue
+		}
+		joined++
+	}
+	if joined == 0 {
+		return fmt.Errorf("could not join multicast group %s on any interface", group)
+	}
+
+	s := coapudp.NewServer(options.WithMux(router))
+	defer s.Stop()
+	return s.Serve(l)
+}
+
+// multicastInterfaces returns the named interface, or all up, multicast-capable interfaces.
+func multicastInterfaces(name string) ([]net.Interface, error) {
+	if name != "" {
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multicast interface %q: %w", name, err)
+		}
+		return []net.Interface{*ifi}, nil
+	}
+	all, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	var ifaces []net.Interface
+	for _, ifi := range all {
+		if ifi.Flags&net.FlagUp != 0 && ifi.Flags&net.FlagMulticast != 0 {
+			ifaces = append(ifaces, ifi)
+		}
+	}
+	return ifaces, nil
+}
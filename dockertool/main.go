@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "dockertool",
+		Short: "Docker/Podman engine event watcher",
+		Long:  "A simple Docker CLI that connects to the engine socket (Docker or Podman's compatible API) and prints container, image, network and volume events.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
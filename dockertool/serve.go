@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// objectTypes are the event types accepted by --type.
+var objectTypes = []string{"container", "image", "network", "volume", "daemon", "plugin", "service", "node", "secret", "config"}
+
+// eventFilters selects the events the engine streams.
+type eventFilters struct {
+	Types      []string
+	Actions    []string
+	Labels     []string
+	Containers []string
+	Images     []string
+}
+
+// args converts the filters to engine filter arguments.
+func (f eventFilters) args() (filters.Args, error) {
+	args := filters.NewArgs()
+	for _, t := range f.Types {
+		t = strings.ToLower(t)
+		if !slices.Contains(objectTypes, t) {
+			return args, fmt.Errorf("invalid --type %q: expected one of %s", t, strings.Join(objectTypes, ", "))
+		}
+		args.Add("type", t)
+	}
+	for _, a := range f.Actions {
+		args.Add("event", a)
+	}
+	for _, l := range f.Labels {
+		if l == "" || strings.HasPrefix(l, "=") {
+			return args, fmt.Errorf("invalid --label %q: expected KEY or KEY=VALUE", l)
+		}
+		args.Add("label", l)
+	}
+	for _, c := range f.Containers {
+		args.Add("container", c)
+	}
+	for _, i := range f.Images {
+		args.Add("image", i)
+	}
+	return args, nil
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		host           string
+		filterOpts     eventFilters
+		since          string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Stream engine events and log them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filterArgs, err := filterOpts.args()
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			clientOpts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+			if host != "" {
+				clientOpts = append(clientOpts, client.WithHost(host))
+			}
+			cli, err := client.NewClientWithOpts(clientOpts...)
+			if err != nil {
+				return fmt.Errorf("error creating Docker client: %w", err)
+			}
+			defer cli.Close() //nolint:errcheck
+
+			var osType string
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				ping, err := cli.Ping(ctx)
+				if err != nil {
+					return err
+				}
+				osType = ping.OSType
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to Docker engine: %w", err)
+			}
+
+			toolutil.PrintSuccess("Connected to Docker engine")
+			toolutil.PrintKeyValue("Host", cli.DaemonHost())
+			toolutil.PrintKeyValue("API Version", cli.ClientVersion())
+			if osType != "" {
+				toolutil.PrintKeyValue("OS", osType)
+			}
+			if filterArgs.Len() > 0 {
+				toolutil.PrintKeyValue("Filters", describeFilters(filterArgs))
+			}
+
+			err = streamEvents(ctx, cli, events.ListOptions{Since: since, Filters: filterArgs}, printEvent)
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&host, "host", "", "Engine address, e.g. unix:///run/user/1000/podman/podman.sock (default: $DOCKER_HOST or the local Docker socket)")
+	cmd.Flags().StringSliceVar(&filterOpts.Types, "type", nil, "Only events of these object types: "+strings.Join(objectTypes, ", ")+" (repeatable)")
+	cmd.Flags().StringSliceVar(&filterOpts.Actions, "event", nil, "Only these actions, e.g. start, die, pull (repeatable)")
+	cmd.Flags().StringArrayVar(&filterOpts.Labels, "label", nil, "Only objects with this label, KEY or KEY=VALUE (repeatable)")
+	cmd.Flags().StringSliceVar(&filterOpts.Containers, "container", nil, "Only events of these containers, by name or ID (repeatable)")
+	cmd.Flags().StringSliceVar(&filterOpts.Images, "image", nil, "Only events of these images (repeatable)")
+	cmd.Flags().StringVar(&since, "since", "", "Replay events since this time, a duration (10m) or timestamp")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// eventsClient is the part of the Docker client used to stream events.
+type eventsClient interface {
+	Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+}
+
+// streamEvents passes every event to handle until ctx is done or the stream fails.
+func streamEvents(ctx context.Context, cli eventsClient, opts events.ListOptions, handle func(events.Message)) error {
+	messages, errs := cli.Events(ctx, opts)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-messages:
+			handle(msg)
+		case err := <-errs:
+			if err == nil {
+				return errors.New("event stream closed by engine")
+			}
+			return fmt.Errorf("event stream error: %w", err)
+		}
+	}
+}
+
+// describeFilters renders the filter arguments as key=value pairs in a stable order.
+func describeFilters(args filters.Args) string {
+	var parts []string
+	for _, k := range slices.Sorted(slices.Values(args.Keys())) {
+		for _, v := range slices.Sorted(slices.Values(args.Get(k))) {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// actorAttributes are shown in the Actor section rather than among the other attributes.
+var actorAttributes = []string{"name", "image"}
+
+// eventSections builds the sections of a printed event: the event itself, its actor and the
+// remaining actor attributes (labels, exit codes, ...).
+func eventSections(msg events.Message) []toolutil.MessageSection {
+	meta := []toolutil.KV{
+		{Key: "Type", Value: string(msg.Type)},
+		{Key: "Action", Value: string(msg.Action)},
+	}
+	if msg.Scope != "" {
+		meta = append(meta, toolutil.KV{Key: "Scope", Value: msg.Scope})
+	}
+	if msg.TimeNano != 0 {
+		meta = append(meta, toolutil.KV{Key: "Time", Value: time.Unix(0, msg.TimeNano).UTC().Format(time.RFC3339Nano)})
+	} else if msg.Time != 0 {
+		meta = append(meta, toolutil.KV{Key: "Time", Value: time.Unix(msg.Time, 0).UTC().Format(time.RFC3339)})
+	}
+
+	actor := []toolutil.KV{{Key: "ID", Value: shortID(msg.Actor.ID)}}
+	for _, k := range actorAttributes {
+		if v := msg.Actor.Attributes[k]; v != "" {
+			actor = append(actor, toolutil.KV{Key: strings.ToUpper(k[:1]) + k[1:], Value: v})
+		}
+	}
+
+	var attrs []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(msg.Actor.Attributes)) {
+		if !slices.Contains(actorAttributes, k) {
+			attrs = append(attrs, toolutil.KV{Key: k, Value: msg.Actor.Attributes[k]})
+		}
+	}
+
+	return []toolutil.MessageSection{
+		{Title: "Event", Items: meta},
+		{Title: "Actor", Items: actor},
+		{Title: fmt.Sprintf("Attributes (%d)", len(attrs)), Items: attrs},
+	}
+}
+
+// shortID abbreviates 64-character hex IDs to 12 characters like the docker CLI; other IDs,
+// such as image references, are kept.
+func shortID(id string) string {
+	if len(id) == 64 && strings.Trim(id, "0123456789abcdef") == "" {
+		return id[:12]
+	}
+	return id
+}
+
+// printEvent prints msg with the raw event as the JSON body.
+func printEvent(msg events.Message) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		body = []byte(err.Error())
+	}
+	toolutil.PrintColoredMessage("Docker", eventSections(msg), body, toolutil.CTJSON)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/client"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestEventFiltersArgs(t *testing.T) {
+	args, err := eventFilters{
+		Types:      []string{"Container"},
+		Actions:    []string{"start", "die"},
+		Labels:     []string{"app=web"},
+		Containers: []string{"db"},
+	}.args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := describeFilters(args); got != "container=db, event=die, event=start, label=app=web, type=container" {
+		t.Errorf("describeFilters() = %q", got)
+	}
+	if _, err := (eventFilters{Types: []string{"pod"}}).args(); err == nil {
+		t.Error("args() accepted an invalid type")
+	}
+	if _, err := (eventFilters{Labels: []string{"=x"}}).args(); err == nil {
+		t.Error("args() accepted a label without a key")
+	}
+}
+
+func TestEventSections(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+	sections := eventSections(events.Message{
+		Type:   events.ContainerEventType,
+		Action: events.ActionDie,
+		Scope:  "local",
+		Actor: events.Actor{ID: id, Attributes: map[string]string{
+			"name": "web", "image": "nginx", "exitCode": "137", "app": "shop",
+		}},
+		TimeNano: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano(),
+	})
+	got := map[string][]string{}
+	for _, s := range sections {
+		for _, kv := range s.Items {
+			got[s.Title] = append(got[s.Title], kv.Key+"="+kv.Value)
+		}
+	}
+	want := map[string]string{
+		"Event":          "Type=container Action=die Scope=local Time=2025-01-02T03:04:05Z",
+		"Actor":          "ID=abababababab Name=web Image=nginx",
+		"Attributes (2)": "app=shop exitCode=137",
+	}
+	for title, items := range want {
+		if s := strings.Join(got[title], " "); s != items {
+			t.Errorf("section %s = %q, want %q", title, s, items)
+		}
+	}
+	if shortID("sha256:abc") != "sha256:abc" {
+		t.Error("shortID() shortened a non-hex ID")
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/events") {
+			http.NotFound(w, r)
+			return
+		}
+		if f := r.URL.Query().Get("filters"); !strings.Contains(f, `"type":{"container":true}`) {
+			t.Errorf("filters = %q", f)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		for _, action := range []events.Action{events.ActionCreate, events.ActionStart} {
+			_ = enc.Encode(events.Message{Type: events.ContainerEventType, Action: action, Actor: events.Actor{ID: "c1"}})
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")), client.WithVersion("1.41"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close() //nolint:errcheck
+
+	args, _ := eventFilters{Types: []string{"container"}}.args()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var actions []string
+	err = streamEvents(ctx, cli, events.ListOptions{Filters: args}, func(msg events.Message) {
+		actions = append(actions, string(msg.Action))
+		if len(actions) == 2 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("streamEvents() error = %v, want context.Canceled", err)
+	}
+	if strings.Join(actions, ",") != "create,start" {
+		t.Errorf("actions = %v", actions)
+	}
+}
+
+func TestPrintEventCounts(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	before := toolutil.ReceivedCount()
+	printEvent(events.Message{Type: events.ImageEventType, Action: events.ActionPull, Actor: events.Actor{ID: "nginx:latest"}})
+	if got := toolutil.ReceivedCount() - before; got != 1 {
+		t.Errorf("ReceivedCount() increased by %d, want 1", got)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "dynamotool",
+		Short: "AWS DynamoDB Streams tester",
+		Long:  "A simple DynamoDB CLI that puts templated items into a table and reads the table stream printing change records (LocalStack and DynamoDB Local supported via --endpoint).",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// decodeItem parses a JSON object payload, keeping numbers exact for DynamoDB N attributes.
+func decodeItem(data []byte) (map[string]interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var item map[string]interface{}
+	if err := d.Decode(&item); err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("payload is not a JSON object")
+	}
+	return item, nil
+}
+
+// marshalItem converts a decoded JSON object to DynamoDB attributes: objects become maps, arrays
+// lists, numbers N and null NULL.
+func marshalItem(item map[string]interface{}) map[string]ddbtypes.AttributeValue {
+	out := make(map[string]ddbtypes.AttributeValue, len(item))
+	for k, v := range item {
+		out[k] = marshalValue(v)
+	}
+	return out
+}
+
+func marshalValue(v interface{}) ddbtypes.AttributeValue {
+	switch v := v.(type) {
+	case string:
+		return &ddbtypes.AttributeValueMemberS{Value: v}
+	case json.Number:
+		return &ddbtypes.AttributeValueMemberN{Value: v.String()}
+	case bool:
+		return &ddbtypes.AttributeValueMemberBOOL{Value: v}
+	case map[string]interface{}:
+		return &ddbtypes.AttributeValueMemberM{Value: marshalItem(v)}
+	case []interface{}:
+		list := make([]ddbtypes.AttributeValue, len(v))
+		for i, e := range v {
+			list[i] = marshalValue(e)
+		}
+		return &ddbtypes.AttributeValueMemberL{Value: list}
+	default:
+		return &ddbtypes.AttributeValueMemberNULL{Value: true}
+	}
+}
+
+// unmarshalImage converts the attributes of a stream record to plain values that encode to
+// JSON: numbers stay exact, binaries become base64 strings and sets become arrays.
+func unmarshalImage(image map[string]streamtypes.AttributeValue) map[string]interface{} {
+	out := make(map[string]interface{}, len(image))
+	for k, v := range image {
+		out[k] = unmarshalValue(v)
+	}
+	return out
+}
+
+func unmarshalValue(v streamtypes.AttributeValue) interface{} {
+	switch v := v.(type) {
+	case *streamtypes.AttributeValueMemberS:
+		return v.Value
+	case *streamtypes.AttributeValueMemberN:
+		return json.Number(v.Value)
+	case *streamtypes.AttributeValueMemberB:
+		return v.Value
+	case *streamtypes.AttributeValueMemberBOOL:
+		return v.Value
+	case *streamtypes.AttributeValueMemberM:
+		return unmarshalImage(v.Value)
+	case *streamtypes.AttributeValueMemberL:
+		list := make([]interface{}, len(v.Value))
+		for i, e := range v.Value {
+			list[i] = unmarshalValue(e)
+		}
+		return list
+	case *streamtypes.AttributeValueMemberSS:
+		return v.Value
+	case *streamtypes.AttributeValueMemberNS:
+		nums := make([]json.Number, len(v.Value))
+		for i, n := range v.Value {
+			nums[i] = json.Number(n)
+		}
+		return nums
+	case *streamtypes.AttributeValueMemberBS:
+		return v.Value
+	default:
+		return nil
+	}
+}
+
+// formatKeys renders key attributes as "name=value" pairs sorted by name.
+func formatKeys(keys map[string]interface{}) string {
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, k := range names {
+		pairs[i] = fmt.Sprintf("%s=%v", k, keys[k])
+	}
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+)
+
+// fakeDynamo serves the DynamoDB and DynamoDB Streams JSON APIs for a table "events" with a
+// stream of two shards: the closed "parent" and its open "child".
+type fakeDynamo struct {
+	mu       sync.Mutex
+	items    []string
+	streamOn bool
+}
+
+func (f *fakeDynamo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var in map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	str := func(name string) string {
+		var s string
+		json.Unmarshal(in[name], &s) //nolint:errcheck
+		return s
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	table := func() string {
+		stream := `"StreamSpecification":{"StreamEnabled":false}`
+		if f.streamOn {
+			stream = `"StreamSpecification":{"StreamEnabled":true,"StreamViewType":"NEW_AND_OLD_IMAGES"},"LatestStreamArn":"arn:stream"`
+		}
+		return `{"TableName":"events","KeySchema":[{"AttributeName":"id","KeyType":"HASH"}],` + stream + `}`
+	}
+	switch op := r.Header.Get("X-Amz-Target"); op {
+	case "DynamoDB_20120810.DescribeTable":
+		if str("TableName") != "events" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"Requested resource not found"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"Table":%s}`, table())
+	case "DynamoDB_20120810.UpdateTable":
+		f.streamOn = strings.Contains(string(in["StreamSpecification"]), `"NEW_IMAGE"`)
+		fmt.Fprintf(w, `{"TableDescription":%s}`, table())
+	case "DynamoDB_20120810.PutItem":
+		f.items = append(f.items, string(in["Item"]))
+		fmt.Fprint(w, `{}`)
+	case "DynamoDBStreams_20120810.DescribeStream":
+		// One shard per page, to exercise pagination.
+		page := `"Shards":[{"ShardId":"parent","SequenceNumberRange":{"StartingSequenceNumber":"1","EndingSequenceNumber":"2"}}],"LastEvaluatedShardId":"parent"`
+		if str("ExclusiveStartShardId") == "parent" {
+			page = `"Shards":[{"ShardId":"child","ParentShardId":"parent","SequenceNumberRange":{"StartingSequenceNumber":"3"}}]`
+		}
+		fmt.Fprintf(w, `{"StreamDescription":{"StreamArn":%q,"TableName":"events","StreamStatus":"ENABLED","StreamViewType":"NEW_AND_OLD_IMAGES",%s}}`, str("StreamArn"), page)
+	case "DynamoDBStreams_20120810.GetShardIterator":
+		fmt.Fprintf(w, `{"ShardIterator":"%s/0"}`, str("ShardId"))
+	case "DynamoDBStreams_20120810.GetRecords":
+		switch str("ShardIterator") {
+		case "parent/0":
+			fmt.Fprint(w, `{"Records":[{"eventName":"INSERT","dynamodb":{"Keys":{"id":{"S":"1"}},"NewImage":{"id":{"S":"1"},"n":{"N":"10"}},"SequenceNumber":"1","ApproximateCreationDateTime":1700000000}}]}`)
+		case "child/0":
+			fmt.Fprint(w, `{"Records":[{"eventName":"REMOVE","dynamodb":{"Keys":{"id":{"S":"1"}},"OldImage":{"id":{"S":"1"}},"SequenceNumber":"3"},"userIdentity":{"Type":"Service","PrincipalId":"dynamodb.amazonaws.com"}}],"NextShardIterator":"child/1"}`)
+		default:
+			fmt.Fprint(w, `{"Records":[],"NextShardIterator":"child/1"}`)
+		}
+	default:
+		http.Error(w, "unsupported "+op, http.StatusBadRequest)
+	}
+}
+
+func startServer(t *testing.T, streamOn bool) (*fakeDynamo, aws.Config) {
+	t.Helper()
+	fake := &fakeDynamo{streamOn: streamOn}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	cfg, err := awsutil.LoadConfig(context.Background(), awsutil.Options{Region: "us-east-1", Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, cfg
+}
+
+func TestDecodeAndMarshalItem(t *testing.T) {
+	item, err := decodeItem([]byte(`{"id":"a","n":12345678901234567890,"ok":true,"tags":["x",1],"meta":{"z":null}}`))
+	if err != nil {
+		t.Fatalf("decodeItem() error: %v", err)
+	}
+	av := marshalItem(item)
+	if n, ok := av["n"].(*ddbtypes.AttributeValueMemberN); !ok || n.Value != "12345678901234567890" {
+		t.Errorf("n = %#v, want the exact number", av["n"])
+	}
+	if l, ok := av["tags"].(*ddbtypes.AttributeValueMemberL); !ok || len(l.Value) != 2 {
+		t.Errorf("tags = %#v", av["tags"])
+	}
+	if m, ok := av["meta"].(*ddbtypes.AttributeValueMemberM); !ok {
+		t.Errorf("meta = %#v", av["meta"])
+	} else if _, ok := m.Value["z"].(*ddbtypes.AttributeValueMemberNULL); !ok {
+		t.Errorf("meta.z = %#v, want NULL", m.Value["z"])
+	}
+	for _, bad := range []string{`[1]`, `null`, `"x"`, `{`} {
+		if _, err := decodeItem([]byte(bad)); err == nil {
+			t.Errorf("decodeItem(%s) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestUnmarshalImage(t *testing.T) {
+	image := map[string]types.AttributeValue{
+		"id":   &types.AttributeValueMemberS{Value: "a"},
+		"n":    &types.AttributeValueMemberN{Value: "1.50"},
+		"bin":  &types.AttributeValueMemberB{Value: []byte("hi")},
+		"set":  &types.AttributeValueMemberNS{Value: []string{"1", "2"}},
+		"list": &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberNULL{Value: true}, &types.AttributeValueMemberBOOL{Value: true}}},
+	}
+	data, err := json.Marshal(unmarshalImage(image))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"bin":"aGk=","id":"a","list":[null,true],"n":1.50,"set":[1,2]}`; string(data) != want {
+		t.Errorf("unmarshalImage() = %s, want %s", data, want)
+	}
+	if got := formatKeys(map[string]interface{}{"sk": json.Number("2"), "pk": "a"}); got != "pk=a, sk=2" {
+		t.Errorf("formatKeys() = %q", got)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	if got, err := parseIteratorType("trim-horizon"); err != nil || got != types.ShardIteratorTypeTrimHorizon {
+		t.Errorf("parseIteratorType(trim-horizon) = %q, %v", got, err)
+	}
+	if _, err := parseIteratorType("at-timestamp"); err == nil {
+		t.Error("parseIteratorType(at-timestamp) succeeded, want error")
+	}
+	if got, err := parseViewType("new-and-old-images"); err != nil || got != ddbtypes.StreamViewTypeNewAndOldImages {
+		t.Errorf("parseViewType(new-and-old-images) = %q, %v", got, err)
+	}
+	if _, err := parseViewType("all"); err == nil {
+		t.Error("parseViewType(all) succeeded, want error")
+	}
+}
+
+func TestLineage(t *testing.T) {
+	shard := func(id, parent string, closed bool) types.Shard {
+		s := types.Shard{ShardId: aws.String(id), SequenceNumberRange: &types.SequenceNumberRange{}}
+		if parent != "" {
+			s.ParentShardId = aws.String(parent)
+		}
+		if closed {
+			s.SequenceNumberRange.EndingSequenceNumber = aws.String("9")
+		}
+		return s
+	}
+	// Children are listed first to check that order does not matter.
+	shards := []types.Shard{shard("c", "b", false), shard("b", "a", true), shard("a", "trimmed", true)}
+
+	l := newLineage()
+	if got := strings.Join(l.ready(shards, false), " "); got != "a" {
+		t.Errorf("ready() = %q, want the oldest shard only", got)
+	}
+	l.finished["a"] = true
+	if got := strings.Join(l.ready(shards, false), " "); got != "b" {
+		t.Errorf("ready() after a = %q", got)
+	}
+	if got := strings.Join(l.ready(shards, false), " "); got != "" || l.reading() != 1 {
+		t.Errorf("ready() while b is read = %q, reading %d", got, l.reading())
+	}
+
+	l = newLineage()
+	if got := strings.Join(l.ready(shards, true), " "); got != "c" {
+		t.Errorf("ready(skipClosed) = %q, want the open shard only", got)
+	}
+}
+
+func TestSendItem(t *testing.T) {
+	fake, cfg := startServer(t, true)
+	ctx := context.Background()
+	client := dynamodb.NewFromConfig(cfg)
+	if _, err := keyAttributes(ctx, client, "missing"); err == nil {
+		t.Error("keyAttributes(missing) succeeded, want error")
+	}
+	keys, err := keyAttributes(ctx, client, "events")
+	if err != nil || len(keys) != 1 || keys[0] != "id" {
+		t.Fatalf("keyAttributes() = %v, %v", keys, err)
+	}
+	item, _ := decodeItem([]byte(`{"id":"1","n":1.50}`))
+	if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("events"), Item: marshalItem(item)}); err != nil {
+		t.Fatalf("PutItem() error: %v", err)
+	}
+	if len(fake.items) != 1 || !strings.Contains(fake.items[0], `"n":{"N":"1.50"}`) {
+		t.Errorf("stored items = %v", fake.items)
+	}
+}
+
+func TestTableStream(t *testing.T) {
+	_, cfg := startServer(t, false)
+	ctx := context.Background()
+	client := dynamodb.NewFromConfig(cfg)
+	if _, err := tableStream(ctx, client, "events", ""); err == nil || !strings.Contains(err.Error(), "--enable-stream") {
+		t.Errorf("tableStream(disabled) = %v, want a hint to enable the stream", err)
+	}
+	if arn, err := tableStream(ctx, client, "events", ddbtypes.StreamViewTypeNewImage); err != nil || arn != "arn:stream" {
+		t.Errorf("tableStream(enable) = %q, %v", arn, err)
+	}
+	if arn, err := tableStream(ctx, client, "events", ""); err != nil || arn != "arn:stream" {
+		t.Errorf("tableStream(enabled) = %q, %v", arn, err)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	_, cfg := startServer(t, true)
+	client := dynamodbstreams.NewFromConfig(cfg)
+
+	read := func(itType types.ShardIteratorType, n int) []string {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		desc, err := describeStream(ctx, client, "arn:stream")
+		if err != nil {
+			t.Fatalf("describeStream() error: %v", err)
+		}
+		if len(desc.Shards) != 2 {
+			t.Fatalf("describeStream() shards = %d, want both pages", len(desc.Shards))
+		}
+		got := make(chan string, 4)
+		go follow(ctx, client, desc, itType, 10*time.Millisecond, func(shardID string, r types.Record) {
+			got <- shardID + ":" + string(r.EventName)
+		})
+		var out []string
+		for len(out) < n {
+			select {
+			case s := <-got:
+				out = append(out, s)
+			case <-ctx.Done():
+				t.Fatalf("timed out after %v", out)
+			}
+		}
+		return out
+	}
+
+	if got := strings.Join(read(types.ShardIteratorTypeTrimHorizon, 2), " "); got != "parent:INSERT child:REMOVE" {
+		t.Errorf("trim-horizon records = %q, want parent before child", got)
+	}
+	if got := strings.Join(read(types.ShardIteratorTypeLatest, 1), " "); got != "child:REMOVE" {
+		t.Errorf("latest records = %q, want the closed parent skipped", got)
+	}
+}
+
+func TestRecordBody(t *testing.T) {
+	keys := map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}}
+	if got := string(recordBody(&types.StreamRecord{Keys: keys})); got != `{"Keys":{"id":"1"}}` {
+		t.Errorf("recordBody(keys only) = %s", got)
+	}
+	if got := string(recordBody(&types.StreamRecord{Keys: keys, NewImage: keys, OldImage: keys})); got != `{"NewImage":{"id":"1"},"OldImage":{"id":"1"}}` {
+		t.Errorf("recordBody(images) = %s", got)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// keyAttributes returns the names of the primary key attributes of table.
+func keyAttributes(ctx context.Context, client *dynamodb.Client, table string) ([]string, error) {
+	out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table %q: %w", table, err)
+	}
+	names := make([]string, 0, len(out.Table.KeySchema))
+	for _, k := range out.Table.KeySchema {
+		names = append(names, aws.ToString(k.AttributeName))
+	}
+	return names, nil
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		awsOpts        awsutil.Options
+		table          string
+		noOverwrite    bool
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Put periodic templated items into a DynamoDB table",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var (
+				client *dynamodb.Client
+				keys   []string
+			)
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					cfg, err := awsutil.LoadConfig(ctx, awsOpts)
+					if err != nil {
+						return err
+					}
+					client = dynamodb.NewFromConfig(cfg)
+					keys, err = keyAttributes(ctx, client, table)
+					return err
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to DynamoDB: %w", err)
+			}
+
+			toolutil.PrintSuccess("Connected to DynamoDB")
+			toolutil.PrintKeyValue("Table", table)
+			toolutil.PrintKeyValue("Key", keys)
+
+			// Without overwrites, a put whose key already exists fails instead of replacing the item.
+			var condition *string
+			if noOverwrite {
+				condition = aws.String("attribute_not_exists(#k)")
+			}
+
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				item, err := decodeItem(body)
+				if err != nil {
+					toolutil.PrintError("Item build error: %v", err)
+					return err
+				}
+				itemKeys := make(map[string]interface{}, len(keys))
+				for _, k := range keys {
+					v, ok := item[k]
+					if !ok {
+						err := fmt.Errorf("item is missing key attribute %q", k)
+						toolutil.PrintError("Item build error: %v", err)
+						return err
+					}
+					itemKeys[k] = v
+				}
+
+				input := &dynamodb.PutItemInput{TableName: aws.String(table), Item: marshalItem(item)}
+				if condition != nil {
+					input.ConditionExpression = condition
+					input.ExpressionAttributeNames = map[string]string{"#k": keys[0]}
+				}
+				putCtx, putCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer putCancel()
+				if _, err := client.PutItem(putCtx, input); err != nil {
+					toolutil.PrintError("Put error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Put item %s (%d bytes)", formatKeys(itemKeys), len(body))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	awsutil.AddFlags(cmd, &awsOpts)
+	cmd.Flags().StringVar(&table, "table", "test-table", "Table name")
+	cmd.Flags().BoolVar(&noOverwrite, "no-overwrite", false, "Fail puts of items whose key already exists instead of replacing them")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"id":"{{counter}}","message":"{{sentence}}","timestamp":"{{nowtime}}"}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// shardRefresh is how often the shard list is refreshed to pick up the shards DynamoDB opens
+// as it rolls the stream over.
+var shardRefresh = 30 * time.Second
+
+func serveCommand() *cobra.Command {
+	var (
+		awsOpts        awsutil.Options
+		table          string
+		streamARN      string
+		enableStream   string
+		iteratorType   string
+		pollInterval   time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Read the stream of a DynamoDB table and log change records",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			itType, err := parseIteratorType(iteratorType)
+			if err != nil {
+				return err
+			}
+			var viewType ddbtypes.StreamViewType
+			if enableStream != "" {
+				if viewType, err = parseViewType(enableStream); err != nil {
+					return err
+				}
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				client *dynamodbstreams.Client
+				desc   *types.StreamDescription
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				cfg, err := awsutil.LoadConfig(ctx, awsOpts)
+				if err != nil {
+					return err
+				}
+				arn := streamARN
+				if arn == "" {
+					if arn, err = tableStream(ctx, dynamodb.NewFromConfig(cfg), table, viewType); err != nil {
+						return err
+					}
+				}
+				client = dynamodbstreams.NewFromConfig(cfg)
+				desc, err = describeStream(ctx, client, arn)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to DynamoDB Streams: %w", err)
+			}
+
+			toolutil.PrintSuccess("Reading DynamoDB stream")
+			toolutil.PrintKeyValue("Table", aws.ToString(desc.TableName))
+			toolutil.PrintKeyValue("Stream", aws.ToString(desc.StreamArn))
+			toolutil.PrintKeyValue("View", string(desc.StreamViewType))
+			toolutil.PrintKeyValue("Shards", len(desc.Shards))
+			toolutil.PrintKeyValue("Iterator", string(itType))
+
+			follow(ctx, client, desc, itType, pollInterval, printRecord)
+			return nil
+		},
+	}
+
+	awsutil.AddFlags(cmd, &awsOpts)
+	cmd.Flags().StringVar(&table, "table", "test-table", "Table whose latest stream is read")
+	cmd.Flags().StringVar(&streamARN, "stream-arn", "", "Stream ARN to read instead of the latest stream of --table")
+	cmd.Flags().StringVar(&enableStream, "enable-stream", "", "Enable the stream of --table with this view type if disabled: keys-only, new-image, old-image or new-and-old-images")
+	cmd.Flags().StringVar(&iteratorType, "iterator-type", "latest", "Where to start reading the open shards: latest or trim-horizon")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", time.Second, "Delay between GetRecords calls on a shard with no new records")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// parseIteratorType accepts iterator types in flag style ("trim-horizon") or API style ("TRIM_HORIZON").
+func parseIteratorType(s string) (types.ShardIteratorType, error) {
+	t := types.ShardIteratorType(strings.ToUpper(strings.ReplaceAll(s, "-", "_")))
+	switch t {
+	case types.ShardIteratorTypeLatest, types.ShardIteratorTypeTrimHorizon:
+		return t, nil
+	}
+	return "", fmt.Errorf("invalid --iterator-type %q: expected latest or trim-horizon", s)
+}
+
+// parseViewType accepts stream view types in flag style ("new-and-old-images") or API style.
+func parseViewType(s string) (ddbtypes.StreamViewType, error) {
+	v := ddbtypes.StreamViewType(strings.ToUpper(strings.ReplaceAll(s, "-", "_")))
+	for _, known := range v.Values() {
+		if v == known {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --enable-stream %q: expected keys-only, new-image, old-image or new-and-old-images", s)
+}
+
+// tableStream returns the latest stream ARN of table, first enabling the stream with viewType
+// when it is disabled and viewType is set.
+func tableStream(ctx context.Context, client *dynamodb.Client, table string, viewType ddbtypes.StreamViewType) (string, error) {
+	out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table %q: %w", table, err)
+	}
+	if spec := out.Table.StreamSpecification; spec != nil && aws.ToBool(spec.StreamEnabled) {
+		return aws.ToString(out.Table.LatestStreamArn), nil
+	}
+	if viewType == "" {
+		return "", fmt.Errorf("table %q has no stream enabled; use --enable-stream new-and-old-images", table)
+	}
+	updated, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:           aws.String(table),
+		StreamSpecification: &ddbtypes.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: viewType},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enable the stream of %q: %w", table, err)
+	}
+	toolutil.PrintInfo("Enabled %s stream on table %s", viewType, table)
+	return aws.ToString(updated.TableDescription.LatestStreamArn), nil
+}
+
+// describeStream returns the stream description with the shards of every page.
+func describeStream(ctx context.Context, client *dynamodbstreams.Client, arn string) (*types.StreamDescription, error) {
+	var desc *types.StreamDescription
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(arn)}
+	for {
+		out, err := client.DescribeStream(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stream %q: %w", arn, err)
+		}
+		if desc == nil {
+			desc = out.StreamDescription
+		} else {
+			desc.Shards = append(desc.Shards, out.StreamDescription.Shards...)
+		}
+		if out.StreamDescription.LastEvaluatedShardId == nil {
+			return desc, nil
+		}
+		input.ExclusiveStartShardId = out.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// lineage tracks the shards of a stream, so a child shard is read only after its parent.
+type lineage struct {
+	started  map[string]bool
+	finished map[string]bool
+}
+
+func newLineage() *lineage {
+	return &lineage{started: map[string]bool{}, finished: map[string]bool{}}
+}
+
+// ready returns the listed shards that can be read now, marking them started. A shard waits for
+// a listed parent still being read; trimmed parents are no longer listed. With skipClosed, closed
+// shards are marked finished instead, as reading them from LATEST returns nothing.
+func (l *lineage) ready(shards []types.Shard, skipClosed bool) []string {
+	listed := make(map[string]bool, len(shards))
+	for _, s := range shards {
+		id := aws.ToString(s.ShardId)
+		listed[id] = true
+		if skipClosed && !l.started[id] && s.SequenceNumberRange != nil && s.SequenceNumberRange.EndingSequenceNumber != nil {
+			l.started[id], l.finished[id] = true, true
+		}
+	}
+	var ids []string
+	for _, s := range shards {
+		id := aws.ToString(s.ShardId)
+		if l.started[id] {
+			continue
+		}
+		if parent := aws.ToString(s.ParentShardId); parent != "" && listed[parent] && !l.finished[parent] {
+			continue
+		}
+		l.started[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// reading returns the number of shards started and not finished.
+func (l *lineage) reading() int {
+	n := 0
+	for id := range l.started {
+		if !l.finished[id] {
+			n++
+		}
+	}
+	return n
+}
+
+// follow reads the shards of the stream in lineage order until ctx ends. The initial shards start
+// at itType, the shards opened later from their first record.
+func follow(ctx context.Context, client *dynamodbstreams.Client, desc *types.StreamDescription, itType types.ShardIteratorType, pollInterval time.Duration, handle func(shardID string, r types.Record)) {
+	arn := aws.ToString(desc.StreamArn)
+	shards := newLineage()
+	done := make(chan string)
+	start := func(list []types.Shard, t types.ShardIteratorType) {
+		for _, id := range shards.ready(list, t == types.ShardIteratorTypeLatest) {
+			go func(id string) {
+				readShard(ctx, client, arn, id, t, pollInterval, handle)
+				select {
+				case done <- id:
+				case <-ctx.Done():
+				}
+			}(id)
+		}
+	}
+
+	start(desc.Shards, itType)
+	refresh := time.NewTicker(shardRefresh)
+	defer refresh.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-done:
+			shards.finished[id] = true
+		case <-refresh.C:
+		}
+		// Children of a finished shard, or shards opened since the last refresh, can start now.
+		d, err := describeStream(ctx, client, arn)
+		if err != nil {
+			if ctx.Err() == nil {
+				toolutil.PrintWarning("Shard refresh failed: %v", err)
+			}
+			continue
+		}
+		start(d.Shards, types.ShardIteratorTypeTrimHorizon)
+		if d.StreamStatus == types.StreamStatusDisabled && shards.reading() == 0 {
+			toolutil.PrintWarning("The stream is disabled and all its shards are read")
+			return
+		}
+	}
+}
+
+// readShard prints the records of one shard until ctx ends or the shard is closed and drained.
+func readShard(ctx context.Context, client *dynamodbstreams.Client, arn, shardID string, itType types.ShardIteratorType, pollInterval time.Duration, handle func(shardID string, r types.Record)) {
+	var lastSeq *string
+	iterator := func() (*string, error) {
+		input := &dynamodbstreams.GetShardIteratorInput{StreamArn: aws.String(arn), ShardId: aws.String(shardID), ShardIteratorType: itType}
+		if lastSeq != nil {
+			input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+			input.SequenceNumber = lastSeq
+		}
+		out, err := client.GetShardIterator(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		return out.ShardIterator, nil
+	}
+	wait := func(d time.Duration) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(d):
+			return true
+		}
+	}
+
+	it, err := iterator()
+	if err != nil {
+		if ctx.Err() == nil {
+			toolutil.PrintError("Failed to get iterator for shard %s: %v", shardID, err)
+		}
+		return
+	}
+	for it != nil {
+		out, err := client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{ShardIterator: it})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Iterators expire after 15 minutes; resume after the last printed record.
+			var expired *types.ExpiredIteratorException
+			if errors.As(err, &expired) {
+				if it, err = iterator(); err == nil {
+					continue
+				}
+			}
+			var throttled *types.LimitExceededException
+			if errors.As(err, &throttled) {
+				toolutil.PrintWarning("Shard %s throttled, backing off", shardID)
+			} else {
+				toolutil.PrintError("GetRecords error on shard %s: %v", shardID, err)
+			}
+			if !wait(5 * pollInterval) {
+				return
+			}
+			continue
+		}
+		for _, r := range out.Records {
+			handle(shardID, r)
+			if r.Dynamodb != nil {
+				lastSeq = r.Dynamodb.SequenceNumber
+			}
+		}
+		it = out.NextShardIterator
+		if len(out.Records) == 0 && it != nil && !wait(pollInterval) {
+			return
+		}
+	}
+}
+
+// recordBody returns the JSON body of a change record: the item images the stream view
+// carries, or the keys for KEYS_ONLY streams.
+func recordBody(r *types.StreamRecord) []byte {
+	body := map[string]interface{}{}
+	if r.NewImage != nil {
+		body["NewImage"] = unmarshalImage(r.NewImage)
+	}
+	if r.OldImage != nil {
+		body["OldImage"] = unmarshalImage(r.OldImage)
+	}
+	if len(body) == 0 {
+		body["Keys"] = unmarshalImage(r.Keys)
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
+
+func printRecord(shardID string, r types.Record) {
+	items := []toolutil.KV{
+		{Key: "Event", Value: string(r.EventName)},
+		{Key: "Shard", Value: shardID},
+	}
+	var body []byte
+	if d := r.Dynamodb; d != nil {
+		items = append(items,
+			toolutil.KV{Key: "Keys", Value: formatKeys(unmarshalImage(d.Keys))},
+			toolutil.KV{Key: "Sequence", Value: aws.ToString(d.SequenceNumber)},
+		)
+		if d.ApproximateCreationDateTime != nil {
+			items = append(items, toolutil.KV{Key: "Created", Value: d.ApproximateCreationDateTime.Format(time.RFC3339)})
+		}
+		body = recordBody(d)
+	}
+	// Deletions by Time to Live are attributed to the DynamoDB service.
+	if u := r.UserIdentity; u != nil && aws.ToString(u.Type) == "Service" {
+		items = append(items, toolutil.KV{Key: "Removed-By", Value: aws.ToString(u.PrincipalId)})
+	}
+	toolutil.PrintColoredMessage("DynamoDB", []toolutil.MessageSection{{Title: "Change Record", Items: items}}, body, toolutil.CTJSON)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "elastictool",
+		Short: "Elasticsearch and OpenSearch indexing tester",
+		Long:  "A simple Elasticsearch/OpenSearch CLI that indexes templated JSON documents and watches indices printing newly indexed documents, through the REST API shared by both engines.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// esOptions configure the cluster connection shared by both commands.
+type esOptions struct {
+	URL      string
+	Username string
+	Password string
+	APIKey   string
+	Insecure bool
+}
+
+func addESFlags(cmd *cobra.Command, opts *esOptions) {
+	cmd.Flags().StringVar(&opts.URL, "url", "http://localhost:9200", "Cluster URL")
+	cmd.Flags().StringVar(&opts.Username, "username", "", "Basic auth username")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "Basic auth password")
+	cmd.Flags().StringVar(&opts.APIKey, "api-key", "", "Elasticsearch API key (base64 id:key), sent as Authorization: ApiKey")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "Skip TLS certificate verification")
+}
+
+// esClient calls the REST API of the cluster.
+type esClient struct {
+	base string
+	opts esOptions
+	http *http.Client
+}
+
+func (o esOptions) client(timeout time.Duration) *esClient {
+	return &esClient{
+		base: strings.TrimRight(o.URL, "/"),
+		opts: o,
+		http: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: o.Insecure}, //nolint:gosec // opt-in for test clusters
+			},
+		},
+	}
+}
+
+// esError is an error response of the cluster.
+type esError struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *esError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("status %d: %s", e.Status, e.Reason)
+	}
+	return fmt.Sprintf("status %d: %s: %s", e.Status, e.Type, e.Reason)
+}
+
+// parseError extracts the error of a response body, either {"error":{"type","reason"}} or a
+// plain error string.
+func parseError(status int, body []byte) error {
+	var resp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	e := &esError{Status: status, Reason: strings.TrimSpace(string(body))}
+	if json.Unmarshal(body, &resp) == nil && len(resp.Error) > 0 {
+		var detail struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		}
+		if json.Unmarshal(resp.Error, &detail) == nil {
+			e.Type, e.Reason = detail.Type, detail.Reason
+		} else {
+			json.Unmarshal(resp.Error, &e.Reason) //nolint:errcheck
+		}
+	}
+	return e
+}
+
+// do sends a request with an optional body and decodes a successful JSON response into out.
+func (c *esClient) do(ctx context.Context, method, path string, query url.Values, body []byte, contentType string, out interface{}) error {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case c.opts.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+c.opts.APIKey)
+	case c.opts.Username != "":
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return parseError(resp.StatusCode, data)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// doJSON sends v encoded as JSON.
+func (c *esClient) doJSON(ctx context.Context, method, path string, query url.Values, v interface{}, out interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, path, query, body, "application/json", out)
+}
+
+// clusterInfo is the root endpoint response.
+type clusterInfo struct {
+	Name        string `json:"name"`
+	ClusterName string `json:"cluster_name"`
+	Version     struct {
+		Number       string `json:"number"`
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+}
+
+// product names the engine: OpenSearch reports its distribution, Elasticsearch does not.
+func (i clusterInfo) product() string {
+	if i.Version.Distribution == "opensearch" {
+		return "OpenSearch"
+	}
+	return "Elasticsearch"
+}
+
+func (c *esClient) info(ctx context.Context) (clusterInfo, error) {
+	var info clusterInfo
+	if err := c.do(ctx, http.MethodGet, "/", nil, nil, "", &info); err != nil {
+		return info, fmt.Errorf("failed to reach cluster: %w", err)
+	}
+	return info, nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCluster records requests and answers them with the handler of the path.
+type fakeCluster struct {
+	t        *testing.T
+	requests []*http.Request
+	bodies   []string
+	routes   map[string]func(r *http.Request, body []byte) (int, interface{})
+}
+
+func (f *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, string(body))
+	route, ok := f.routes[r.Method+" "+r.URL.Path]
+	if !ok {
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	status, resp := route(r, body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck
+}
+
+func newFakeCluster(t *testing.T) (*fakeCluster, *esClient) {
+	t.Helper()
+	f := &fakeCluster{t: t, routes: map[string]func(*http.Request, []byte) (int, interface{}){}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, esOptions{URL: srv.URL + "/", APIKey: "a2V5"}.client(5 * time.Second)
+}
+
+func TestInfoProduct(t *testing.T) {
+	f, c := newFakeCluster(t)
+	f.routes["GET /"] = func(*http.Request, []byte) (int, interface{}) {
+		return 200, map[string]interface{}{"cluster_name": "test", "version": map[string]string{"number": "2.11.0", "distribution": "opensearch"}}
+	}
+	info, err := c.info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ClusterName != "test" || info.product() != "OpenSearch" {
+		t.Errorf("info = %+v, product %s", info, info.product())
+	}
+	if got := f.requests[0].Header.Get("Authorization"); got != "ApiKey a2V5" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if (clusterInfo{}).product() != "Elasticsearch" {
+		t.Error("expected Elasticsearch without a distribution")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	err := parseError(404, []byte(`{"error":{"type":"index_not_found_exception","reason":"no such index [x]"},"status":404}`))
+	if err.Error() != "status 404: index_not_found_exception: no such index [x]" {
+		t.Errorf("structured error = %q", err)
+	}
+	err = parseError(400, []byte(`{"error":"bad request"}`))
+	if err.Error() != "status 400: bad request" {
+		t.Errorf("string error = %q", err)
+	}
+	err = parseError(502, []byte("Bad Gateway\n"))
+	if err.Error() != "status 502: Bad Gateway" {
+		t.Errorf("plain error = %q", err)
+	}
+}
+
+func TestIndexOne(t *testing.T) {
+	f, c := newFakeCluster(t)
+	f.routes["POST /events/_doc"] = func(*http.Request, []byte) (int, interface{}) {
+		return 201, map[string]interface{}{"_index": "events", "_id": "gen", "result": "created", "_seq_no": 3}
+	}
+	f.routes["PUT /events/_doc/a b"] = func(*http.Request, []byte) (int, interface{}) {
+		return 200, map[string]interface{}{"_index": "events", "_id": "a b", "result": "updated", "_seq_no": 4}
+	}
+
+	query := url.Values{"refresh": {"wait_for"}}
+	res, err := indexOne(context.Background(), c, document{Index: "events", Source: []byte(`{"a":1}`)}, query)
+	if err != nil || res.ID != "gen" || res.Result != "created" || res.SeqNo != 3 {
+		t.Fatalf("generated ID: %+v, %v", res, err)
+	}
+	if f.bodies[0] != `{"a":1}` || f.requests[0].URL.Query().Get("refresh") != "wait_for" {
+		t.Errorf("request = %s %s", f.requests[0].URL, f.bodies[0])
+	}
+	res, err = indexOne(context.Background(), c, document{Index: "events", ID: "a b", Source: []byte(`{}`)}, nil)
+	if err != nil || res.Result != "updated" {
+		t.Fatalf("explicit ID: %+v, %v", res, err)
+	}
+}
+
+func TestIndexBulk(t *testing.T) {
+	f, c := newFakeCluster(t)
+	failing := false
+	f.routes["POST /_bulk"] = func(r *http.Request, body []byte) (int, interface{}) {
+		if !failing {
+			return 200, map[string]interface{}{"errors": false}
+		}
+		return 200, map[string]interface{}{"errors": true, "items": []interface{}{
+			map[string]interface{}{"index": map[string]interface{}{"status": 201}},
+			map[string]interface{}{"index": map[string]interface{}{"status": 400, "error": map[string]string{"type": "mapper_parsing_exception", "reason": "bad field"}}},
+		}}
+	}
+
+	docs := []document{
+		{Index: "events", Source: []byte(`{"a":1}`)},
+		{Index: "logs", ID: "7", Source: []byte(`{"b":2}`)},
+	}
+	if err := indexBulk(context.Background(), c, docs, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"index":{"_index":"events"}}` + "\n" + `{"a":1}` + "\n" + `{"index":{"_id":"7","_index":"logs"}}` + "\n" + `{"b":2}` + "\n"
+	if f.bodies[0] != want {
+		t.Errorf("bulk body = %q, want %q", f.bodies[0], want)
+	}
+	if ct := f.requests[0].Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	failing = true
+	err := indexBulk(context.Background(), c, docs, nil)
+	var esErr *esError
+	if err == nil || !errors.As(err, &esErr) || esErr.Type != "mapper_parsing_exception" || !strings.HasPrefix(err.Error(), "1 of 2 documents failed") {
+		t.Errorf("bulk error = %v", err)
+	}
+}
+
+// fakeShard is a shard of the fake cluster holding documents in sequence order.
+type fakeShard struct {
+	index string
+	shard int
+	docs  []hit
+}
+
+func (s *fakeShard) add(id string, source string) {
+	seq := int64(len(s.docs))
+	term := int64(1)
+	s.docs = append(s.docs, hit{Index: s.index, ID: id, SeqNo: &seq, PrimaryTerm: &term, Source: json.RawMessage(source)})
+}
+
+// searchRequest is the part of a search body the fake cluster understands.
+type searchRequest struct {
+	Size  int                          `json:"size"`
+	From  int                          `json:"from"`
+	Sort  []map[string]json.RawMessage `json:"sort"`
+	Query struct {
+		Bool struct {
+			Filter []map[string]json.RawMessage `json:"filter"`
+		} `json:"bool"`
+	} `json:"query"`
+}
+
+// rangeFrom returns the lower bound of the range filter on field.
+func (s searchRequest) rangeFrom(field string) (json.RawMessage, string) {
+	for _, f := range s.Query.Bool.Filter {
+		var ranges map[string]map[string]json.RawMessage
+		json.Unmarshal(f["range"], &ranges) //nolint:errcheck
+		if r, ok := ranges[field]; ok {
+			for op, v := range r {
+				return v, op
+			}
+		}
+	}
+	return nil, ""
+}
+
+func TestSeqNoWatcher(t *testing.T) {
+	f, c := newFakeCluster(t)
+	shards := []*fakeShard{{index: "events-a", shard: 0}, {index: "events-a", shard: 1}}
+	shards[0].add("1", `{"n":1}`)
+	shards[1].add("2", `{"n":2}`)
+	f.routes["GET /_cat/shards/events-*"] = func(*http.Request, []byte) (int, interface{}) {
+		var rows []map[string]string
+		for _, s := range shards {
+			rows = append(rows,
+				map[string]string{"index": s.index, "shard": strconv.Itoa(s.shard), "prirep": "p"},
+				map[string]string{"index": s.index, "shard": strconv.Itoa(s.shard), "prirep": "r"})
+		}
+		return 200, rows
+	}
+	search := func(r *http.Request, body []byte) (int, interface{}) {
+		var req searchRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid search body: %v", err)
+			return 400, nil
+		}
+		index := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/_search")
+		pref := r.URL.Query().Get("preference")
+		var shard *fakeShard
+		for _, s := range shards {
+			if s.index == index && pref == "_shards:"+strconv.Itoa(s.shard) {
+				shard = s
+			}
+		}
+		if shard == nil {
+			t.Errorf("search of %s with preference %q", index, pref)
+			return 400, nil
+		}
+		var hits []hit
+		if string(req.Sort[0]["_seq_no"]) == `"desc"` {
+			if n := len(shard.docs); n > 0 {
+				hits = []hit{shard.docs[n-1]}
+			}
+		} else {
+			from, op := req.rangeFrom("_seq_no")
+			if op != "gt" {
+				t.Errorf("range op %q", op)
+			}
+			var gt int64
+			json.Unmarshal(from, &gt) //nolint:errcheck
+			for _, d := range shard.docs {
+				if *d.SeqNo > gt && len(hits) < req.Size {
+					hits = append(hits, d)
+				}
+			}
+		}
+		return 200, map[string]interface{}{"hits": map[string]interface{}{"hits": hits}}
+	}
+	f.routes["POST /events-a/_search"] = search
+	f.routes["POST /events-b/_search"] = search
+
+	collect := func(w *seqNoWatcher) []string {
+		var ids []string
+		if err := w.poll(context.Background(), func(h hit) { ids = append(ids, h.Index+"/"+h.ID) }); err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	w := &seqNoWatcher{client: c, index: "events-*", size: 2}
+	if err := w.start(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if ids := collect(w); len(ids) != 0 {
+		t.Errorf("documents before start reported: %v", ids)
+	}
+
+	// Paging within a shard, an update of an existing ID and a new index.
+	shards[0].add("3", `{}`)
+	shards[0].add("4", `{}`)
+	shards[0].add("1", `{"n":10}`)
+	shards = append(shards, &fakeShard{index: "events-b", shard: 0})
+	shards[2].add("5", `{}`)
+	if got, want := strings.Join(collect(w), ","), "events-a/3,events-a/4,events-a/1,events-b/5"; got != want {
+		t.Errorf("poll = %s, want %s", got, want)
+	}
+	if ids := collect(w); len(ids) != 0 {
+		t.Errorf("documents reported twice: %v", ids)
+	}
+
+	existing := &seqNoWatcher{client: c, index: "events-*", size: 100}
+	if err := existing.start(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if ids := collect(existing); len(ids) != 6 {
+		t.Errorf("existing documents = %v", ids)
+	}
+}
+
+func TestQueryWatcher(t *testing.T) {
+	f, c := newFakeCluster(t)
+	type doc struct {
+		id string
+		ts int64
+	}
+	var docs []doc
+	f.routes["POST /logs/_search"] = func(r *http.Request, body []byte) (int, interface{}) {
+		var req searchRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid search body: %v", err)
+			return 400, nil
+		}
+		if !strings.Contains(string(body), `"query_string":{"query":"level:error"}`) {
+			t.Errorf("query string filter missing: %s", body)
+		}
+		var order struct {
+			Order string `json:"order"`
+		}
+		json.Unmarshal(req.Sort[0]["ts"], &order) //nolint:errcheck
+		sorted := append([]doc(nil), docs...)
+		if order.Order == "desc" {
+			for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+				sorted[i], sorted[j] = sorted[j], sorted[i]
+			}
+		}
+		var gte int64 = -1
+		if from, op := req.rangeFrom("ts"); from != nil {
+			if op != "gte" {
+				t.Errorf("range op %q", op)
+			}
+			json.Unmarshal(from, &gte) //nolint:errcheck
+		}
+		var hits []hit
+		skipped := 0
+		for _, d := range sorted {
+			if d.ts < gte {
+				continue
+			}
+			if skipped < req.From {
+				skipped++
+				continue
+			}
+			if len(hits) < req.Size {
+				sortValue, _ := json.Marshal(d.ts)
+				hits = append(hits, hit{Index: "logs", ID: d.id, Source: json.RawMessage(`{}`), Sort: []json.RawMessage{sortValue}})
+			}
+		}
+		return 200, map[string]interface{}{"hits": map[string]interface{}{"hits": hits}}
+	}
+
+	collect := func(w *queryWatcher) string {
+		var ids []string
+		if err := w.poll(context.Background(), func(h hit) { ids = append(ids, h.ID) }); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	docs = []doc{{"a", 1}, {"b", 2}, {"c", 2}}
+	w := &queryWatcher{client: c, index: "logs", query: "level:error", field: "ts", size: 2}
+	if err := w.start(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+	if got := collect(w); got != "" {
+		t.Errorf("documents before start reported: %s", got)
+	}
+
+	// A late document sharing the last timestamp and documents spanning several pages.
+	docs = append(docs, doc{"d", 2}, doc{"e", 3}, doc{"f", 4}, doc{"g", 5})
+	if got, want := collect(w), "d,e,f,g"; got != want {
+		t.Errorf("poll = %s, want %s", got, want)
+	}
+	if got := collect(w); got != "" {
+		t.Errorf("documents reported twice: %s", got)
+	}
+
+	existing := &queryWatcher{client: c, index: "logs", query: "level:error", field: "ts", size: 100}
+	if err := existing.start(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := collect(existing), "a,b,c,d,e,f,g"; got != want {
+		t.Errorf("existing = %s, want %s", got, want)
+	}
+}
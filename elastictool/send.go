@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// document is a document to index; an empty ID lets the cluster generate one.
+type document struct {
+	Index  string
+	ID     string
+	Source []byte
+}
+
+// indexResult is the outcome of indexing one document.
+type indexResult struct {
+	Index  string `json:"_index"`
+	ID     string `json:"_id"`
+	Result string `json:"result"`
+	SeqNo  int64  `json:"_seq_no"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// indexOne indexes a single document.
+func indexOne(ctx context.Context, c *esClient, d document, query url.Values) (indexResult, error) {
+	var res indexResult
+	method, path := http.MethodPost, "/"+url.PathEscape(d.Index)+"/_doc"
+	if d.ID != "" {
+		method, path = http.MethodPut, path+"/"+url.PathEscape(d.ID)
+	}
+	err := c.do(ctx, method, path, query, d.Source, "application/json", &res)
+	return res, err
+}
+
+// bulkBody encodes documents as the NDJSON body of a _bulk request; sources must be compact JSON.
+func bulkBody(docs []document) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, d := range docs {
+		meta := map[string]string{"_index": d.Index}
+		if d.ID != "" {
+			meta["_id"] = d.ID
+		}
+		action, err := json.Marshal(map[string]interface{}{"index": meta})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(d.Source)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// indexBulk indexes documents with a single _bulk request, failing when any document is rejected.
+func indexBulk(ctx context.Context, c *esClient, docs []document, query url.Values) error {
+	body, err := bulkBody(docs)
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]indexResult
+	}
+	if err := c.do(ctx, http.MethodPost, "/_bulk", query, body, "application/x-ndjson", &res); err != nil {
+		return err
+	}
+	if !res.Errors {
+		return nil
+	}
+	failed := 0
+	var first error
+	for _, item := range res.Items {
+		for _, r := range item {
+			if r.Error != nil {
+				failed++
+				if first == nil {
+					first = &esError{Status: r.Status, Type: r.Error.Type, Reason: r.Error.Reason}
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%d of %d documents failed, first: %w", failed, len(docs), first)
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		opts           esOptions
+		index          string
+		docID          string
+		batch          int
+		refresh        string
+		pipeline       string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Index periodic templated JSON documents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch < 1 {
+				return fmt.Errorf("--batch must be at least 1")
+			}
+			switch refresh {
+			case "", "true", "false", "wait_for":
+			default:
+				return fmt.Errorf("invalid --refresh %q: expected true, false or wait_for", refresh)
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			client := opts.client(10 * time.Second)
+			var info clusterInfo
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					var err error
+					info, err = client.info(ctx)
+					return err
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to %s: %w", opts.URL, err)
+			}
+
+			toolutil.PrintSuccess("Connected to %s", info.product())
+			toolutil.PrintKeyValue("URL", opts.URL)
+			toolutil.PrintKeyValue("Cluster", info.ClusterName)
+			toolutil.PrintKeyValue("Version", info.Version.Number)
+			toolutil.PrintKeyValue("Index", index)
+			toolutil.PrintKeyValue("Batch", batch)
+
+			query := url.Values{}
+			if refresh != "" {
+				query.Set("refresh", refresh)
+			}
+			if pipeline != "" {
+				query.Set("pipeline", pipeline)
+			}
+			indexDest := toolutil.NewDestination(index, openDelim, closeDelim)
+			idDest := toolutil.NewDestination(docID, openDelim, closeDelim)
+			nextDoc := func() (document, error) {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					return document{}, fmt.Errorf("payload build error: %w", err)
+				}
+				var compact bytes.Buffer
+				if err := json.Compact(&compact, body); err != nil {
+					return document{}, fmt.Errorf("payload is not valid JSON: %w", err)
+				}
+				d := document{Source: compact.Bytes()}
+				if d.Index, err = indexDest.Resolve(); err != nil {
+					return document{}, fmt.Errorf("index build error: %w", err)
+				}
+				if d.ID, err = idDest.Resolve(); err != nil {
+					return document{}, fmt.Errorf("document ID build error: %w", err)
+				}
+				return d, nil
+			}
+
+			send := func() error {
+				reqCtx, reqCancel := context.WithTimeout(ctx, 30*time.Second)
+				defer reqCancel()
+
+				if batch == 1 {
+					d, err := nextDoc()
+					if err != nil {
+						toolutil.PrintError("%v", err)
+						return err
+					}
+					res, err := indexOne(reqCtx, client, d, query)
+					if err != nil {
+						toolutil.PrintError("Index error: %v", err)
+						return err
+					}
+					toolutil.PrintInfo("Indexed document %s in %s: %s (%d bytes, seq_no %d)", res.ID, res.Index, res.Result, len(d.Source), res.SeqNo)
+					return nil
+				}
+
+				docs := make([]document, 0, batch)
+				size := 0
+				for range batch {
+					d, err := nextDoc()
+					if err != nil {
+						toolutil.PrintError("%v", err)
+						return err
+					}
+					docs = append(docs, d)
+					size += len(d.Source)
+				}
+				if err := indexBulk(reqCtx, client, docs, query); err != nil {
+					toolutil.PrintError("Bulk error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Indexed %d documents (%d bytes)", len(docs), size)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addESFlags(cmd, &opts)
+	cmd.Flags().StringVar(&index, "index", "events", "Target index, supports template placeholders (e.g. logs-{{var:env}})")
+	cmd.Flags().StringVar(&docID, "id", "", "Document ID, supports template placeholders (default: generated by the cluster)")
+	cmd.Flags().IntVar(&batch, "batch", 1, "Documents per send; values above 1 use the _bulk API")
+	cmd.Flags().StringVar(&refresh, "refresh", "", "Refresh policy after indexing: true, false or wait_for")
+	cmd.Flags().StringVar(&pipeline, "pipeline", "", "Ingest pipeline to run the documents through")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"@timestamp":"{{nowtime}}","message":"{{sentence}}","count":{{counter}}}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
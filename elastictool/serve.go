@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// hit is a search hit.
+type hit struct {
+	Index       string            `json:"_index"`
+	ID          string            `json:"_id"`
+	SeqNo       *int64            `json:"_seq_no"`
+	PrimaryTerm *int64            `json:"_primary_term"`
+	Source      json.RawMessage   `json:"_source"`
+	Sort        []json.RawMessage `json:"sort"`
+}
+
+// search runs a search request on index, optionally restricted to shards by preference.
+func search(ctx context.Context, c *esClient, index, preference string, body map[string]interface{}) ([]hit, error) {
+	query := url.Values{}
+	if preference != "" {
+		query.Set("preference", preference)
+	}
+	var res struct {
+		Hits struct {
+			Hits []hit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", query, body, &res); err != nil {
+		return nil, err
+	}
+	return res.Hits.Hits, nil
+}
+
+// filters returns the bool filter clauses of a search: the optional query string plus extra.
+func filters(queryString string, extra ...map[string]interface{}) map[string]interface{} {
+	clauses := []interface{}{}
+	if queryString != "" {
+		clauses = append(clauses, map[string]interface{}{"query_string": map[string]interface{}{"query": queryString}})
+	}
+	for _, e := range extra {
+		clauses = append(clauses, e)
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": clauses}}
+}
+
+// shardKey identifies a primary shard.
+type shardKey struct {
+	Index string
+	Shard int
+}
+
+// seqNoWatcher finds new and updated documents by the sequence number every write gets on its
+// shard, tracking the highest one printed per primary shard.
+type seqNoWatcher struct {
+	client *esClient
+	index  string
+	query  string
+	size   int
+	last   map[shardKey]int64
+}
+
+// shards lists the primary shards of the indices matching the index pattern.
+func (w *seqNoWatcher) shards(ctx context.Context) ([]shardKey, error) {
+	var rows []struct {
+		Index  string `json:"index"`
+		Shard  string `json:"shard"`
+		PriRep string `json:"prirep"`
+	}
+	q := url.Values{"format": {"json"}, "h": {"index,shard,prirep"}}
+	if err := w.client.do(ctx, http.MethodGet, "/_cat/shards/"+url.PathEscape(w.index), q, nil, "", &rows); err != nil {
+		return nil, fmt.Errorf("failed to list shards of %q: %w", w.index, err)
+	}
+	var keys []shardKey
+	for _, r := range rows {
+		n, err := strconv.Atoi(r.Shard)
+		if r.PriRep != "p" || err != nil {
+			continue
+		}
+		keys = append(keys, shardKey{Index: r.Index, Shard: n})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Index != keys[j].Index {
+			return keys[i].Index < keys[j].Index
+		}
+		return keys[i].Shard < keys[j].Shard
+	})
+	return keys, nil
+}
+
+// start records the current position of every shard, so only later writes are reported.
+// With existing, every document already indexed is reported by the first poll.
+func (w *seqNoWatcher) start(ctx context.Context, existing bool) error {
+	w.last = map[shardKey]int64{}
+	keys, err := w.shards(ctx)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		w.last[k] = -1
+		if existing {
+			continue
+		}
+		hits, err := search(ctx, w.client, k.Index, "_shards:"+strconv.Itoa(k.Shard), map[string]interface{}{
+			"size": 1, "seq_no_primary_term": true, "_source": false,
+			"sort": []interface{}{map[string]string{"_seq_no": "desc"}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read the position of %s shard %d: %w", k.Index, k.Shard, err)
+		}
+		if len(hits) > 0 && hits[0].SeqNo != nil {
+			w.last[k] = *hits[0].SeqNo
+		}
+	}
+	return nil
+}
+
+// poll reports the documents written since the previous poll, in sequence order per shard.
+// Shards of indices created meanwhile are read from their start.
+func (w *seqNoWatcher) poll(ctx context.Context, report func(hit)) error {
+	keys, err := w.shards(ctx)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		last, ok := w.last[k]
+		if !ok {
+			last = -1
+		}
+		for {
+			hits, err := search(ctx, w.client, k.Index, "_shards:"+strconv.Itoa(k.Shard), map[string]interface{}{
+				"size": w.size, "seq_no_primary_term": true,
+				"sort":  []interface{}{map[string]string{"_seq_no": "asc"}},
+				"query": filters(w.query, map[string]interface{}{"range": map[string]interface{}{"_seq_no": map[string]int64{"gt": last}}}),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to search %s shard %d: %w", k.Index, k.Shard, err)
+			}
+			for _, h := range hits {
+				report(h)
+				if h.SeqNo != nil {
+					last = *h.SeqNo
+				}
+			}
+			w.last[k] = last
+			if len(hits) < w.size {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// queryWatcher finds new documents by an ascending sort field such as a timestamp, remembering
+// the documents seen at the highest value to report documents sharing it only once.
+type queryWatcher struct {
+	client *esClient
+	index  string
+	query  string
+	field  string
+	size   int
+	last   json.RawMessage
+	seen   map[string]bool
+}
+
+func (w *queryWatcher) sortBody(order string, from json.RawMessage, offset int) map[string]interface{} {
+	var extra []map[string]interface{}
+	if from != nil {
+		extra = append(extra, map[string]interface{}{"range": map[string]interface{}{w.field: map[string]json.RawMessage{"gte": from}}})
+	}
+	return map[string]interface{}{
+		"size":  w.size,
+		"from":  offset,
+		"sort":  []interface{}{map[string]interface{}{w.field: map[string]string{"order": order, "unmapped_type": "long"}}},
+		"query": filters(w.query, extra...),
+	}
+}
+
+// start records the highest sort value, so only later documents are reported. With existing,
+// every matching document is reported by the first poll.
+func (w *queryWatcher) start(ctx context.Context, existing bool) error {
+	w.last, w.seen = nil, map[string]bool{}
+	if existing {
+		return nil
+	}
+	hits, err := search(ctx, w.client, w.index, "", w.sortBody("desc", nil, 0))
+	if err != nil {
+		return fmt.Errorf("failed to search %q: %w", w.index, err)
+	}
+	for _, h := range hits {
+		if len(h.Sort) == 0 {
+			continue
+		}
+		if w.last == nil {
+			w.last = h.Sort[0]
+		}
+		if string(h.Sort[0]) == string(w.last) {
+			w.seen[h.Index+"/"+h.ID] = true
+		}
+	}
+	return nil
+}
+
+// poll reports the documents sorted at or after the last value that were not reported yet.
+func (w *queryWatcher) poll(ctx context.Context, report func(hit)) error {
+	offset := 0
+	for {
+		hits, err := search(ctx, w.client, w.index, "", w.sortBody("asc", w.last, offset))
+		if err != nil {
+			return fmt.Errorf("failed to search %q: %w", w.index, err)
+		}
+		reported := 0
+		for _, h := range hits {
+			key := h.Index + "/" + h.ID
+			if len(h.Sort) == 0 || (string(h.Sort[0]) == string(w.last) && w.seen[key]) {
+				continue
+			}
+			if string(h.Sort[0]) != string(w.last) {
+				w.last, w.seen = h.Sort[0], map[string]bool{}
+			}
+			w.seen[key] = true
+			report(h)
+			reported++
+		}
+		if len(hits) < w.size {
+			return nil
+		}
+		// A full page of reported documents means more than --size documents share the last
+		// value: page past them instead of searching the same range again.
+		if reported == 0 {
+			offset += len(hits)
+		} else {
+			offset = 0
+		}
+	}
+}
+
+// watcher is a polling strategy.
+type watcher interface {
+	start(ctx context.Context, existing bool) error
+	poll(ctx context.Context, report func(hit)) error
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           esOptions
+		index          string
+		mode           string
+		queryString    string
+		sortField      string
+		size           int
+		existing       bool
+		pollInterval   time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Watch indices and print newly indexed documents",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if size < 1 {
+				return fmt.Errorf("--size must be at least 1")
+			}
+			client := opts.client(30 * time.Second)
+			var w watcher
+			switch mode {
+			case "seqno":
+				w = &seqNoWatcher{client: client, index: index, query: queryString, size: size}
+			case "query":
+				w = &queryWatcher{client: client, index: index, query: queryString, field: sortField, size: size}
+			default:
+				return fmt.Errorf("invalid --mode %q: expected seqno or query", mode)
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var info clusterInfo
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				if info, err = client.info(ctx); err != nil {
+					return err
+				}
+				return w.start(ctx, existing)
+			}); err != nil {
+				return fmt.Errorf("error connecting to %s: %w", opts.URL, err)
+			}
+
+			toolutil.PrintSuccess("Watching %s index", info.product())
+			toolutil.PrintKeyValue("URL", opts.URL)
+			toolutil.PrintKeyValue("Cluster", info.ClusterName)
+			toolutil.PrintKeyValue("Index", index)
+			toolutil.PrintKeyValue("Mode", mode)
+			if queryString != "" {
+				toolutil.PrintKeyValue("Query", queryString)
+			}
+
+			report := func(h hit) { printHit(info.product(), h) }
+			failing := false
+			for {
+				if err := w.poll(ctx, report); err != nil {
+					if ctx.Err() != nil {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					if !failing {
+						toolutil.PrintWarning("Poll failed: %v", err)
+						failing = true
+					}
+				} else if failing {
+					toolutil.PrintInfo("Polling resumed")
+					failing = false
+				}
+
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case <-time.After(pollInterval):
+				}
+			}
+		},
+	}
+
+	addESFlags(cmd, &opts)
+	cmd.Flags().StringVar(&index, "index", "events", "Index, alias or pattern to watch (e.g. logs-*)")
+	cmd.Flags().StringVar(&mode, "mode", "seqno", "Change detection: seqno (per-shard sequence numbers, reports updates too) or query (ascending --sort-field)")
+	cmd.Flags().StringVar(&queryString, "query", "", "Only report documents matching this query string (Lucene syntax)")
+	cmd.Flags().StringVar(&sortField, "sort-field", "@timestamp", "Field whose increasing values mark new documents (query mode)")
+	cmd.Flags().IntVar(&size, "size", 100, "Documents fetched per search request")
+	cmd.Flags().BoolVar(&existing, "existing", false, "Also report the documents indexed before the watch started")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 2*time.Second, "Delay between polls")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+func printHit(product string, h hit) {
+	items := []toolutil.KV{
+		{Key: "Index", Value: h.Index},
+		{Key: "ID", Value: h.ID},
+	}
+	if h.SeqNo != nil {
+		items = append(items, toolutil.KV{Key: "Seq No", Value: strconv.FormatInt(*h.SeqNo, 10)})
+	}
+	if h.PrimaryTerm != nil {
+		items = append(items, toolutil.KV{Key: "Primary Term", Value: strconv.FormatInt(*h.PrimaryTerm, 10)})
+	}
+	if len(h.Sort) > 0 {
+		items = append(items, toolutil.KV{Key: "Sort", Value: string(h.Sort[0])})
+	}
+	toolutil.PrintColoredMessage(product, []toolutil.MessageSection{{Title: "Document", Items: items}}, h.Source, toolutil.CTJSON)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// publisher posts event batches to a topic endpoint.
+type publisher struct {
+	client   *http.Client
+	endpoint string
+	schema   string
+	key      string
+	tokenTTL time.Duration
+	headers  map[string]string
+}
+
+// publish posts events; CloudEvents batches use the batch content type, single CloudEvents the
+// structured one and Event Grid events are always sent as an array.
+func (p *publisher) publish(ctx context.Context, events []interface{}) error {
+	var body []byte
+	var err error
+	ct := "application/json"
+	switch {
+	case p.schema == schemaCloudEvents && len(events) == 1:
+		ct = "application/cloudevents+json; charset=utf-8"
+		body, err = json.Marshal(events[0])
+	case p.schema == schemaCloudEvents:
+		ct = "application/cloudevents-batch+json; charset=utf-8"
+		body, err = json.Marshal(events)
+	default:
+		body, err = json.Marshal(events)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", ct)
+	switch {
+	case p.key != "" && p.tokenTTL > 0:
+		token, err := sasToken(p.endpoint, p.key, time.Now().Add(p.tokenTTL))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("aeg-sas-token", token)
+	case p.key != "":
+		req.Header.Set("aeg-sas-key", p.key)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("rejected with status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		endpoint       string
+		schema         string
+		key            string
+		tokenTTL       time.Duration
+		eventType      string
+		source         string
+		subject        string
+		batch          int
+		requestTimeout time.Duration
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Publish periodic templated events to an Event Grid topic",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if schema != schemaCloudEvents && schema != schemaEventGrid {
+				return fmt.Errorf("invalid --schema %q: expected %s or %s", schema, schemaCloudEvents, schemaEventGrid)
+			}
+			if batch < 1 {
+				return fmt.Errorf("--batch must be at least 1")
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			p := &publisher{
+				client: &http.Client{
+					Timeout:   requestTimeout,
+					Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: (&net.Dialer{Timeout: connectTimeout}).DialContext},
+				},
+				endpoint: endpoint,
+				schema:   schema,
+				key:      key,
+				tokenTTL: tokenTTL,
+				headers:  headerMap,
+			}
+
+			toolutil.PrintSuccess("Starting Event Grid publisher")
+			toolutil.PrintKeyValue("Endpoint", endpoint)
+			toolutil.PrintKeyValue("Schema", schema)
+			toolutil.PrintKeyValue("Batch", batch)
+
+			typeDest := toolutil.NewDestination(eventType, openDelim, closeDelim)
+			subjectDest := toolutil.NewDestination(subject, openDelim, closeDelim)
+			send := func() error {
+				events := make([]interface{}, 0, batch)
+				size := 0
+				for range batch {
+					body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+					if err != nil {
+						toolutil.PrintError("Payload build error: %v", err)
+						return err
+					}
+					f := eventFields{ID: uuid.NewString(), Source: source, Time: time.Now()}
+					if f.Type, err = typeDest.Resolve(); err != nil {
+						toolutil.PrintError("Event type build error: %v", err)
+						return err
+					}
+					if f.Subject, err = subjectDest.Resolve(); err != nil {
+						toolutil.PrintError("Subject build error: %v", err)
+						return err
+					}
+					events = append(events, newEvent(schema, f, body, ct))
+					size += len(body)
+				}
+				if err := p.publish(ctx, events); err != nil {
+					toolutil.PrintError("Publish error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Published %d %s event(s) (%d bytes of data)", len(events), schema, size)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&endpoint, "endpoint", "http://localhost:9090/api/events", "Topic endpoint URL, e.g. https://<topic>.<region>-1.eventgrid.azure.net/api/events")
+	cmd.Flags().StringVar(&schema, "schema", schemaCloudEvents, "Event schema of the topic: cloudevents or eventgrid")
+	cmd.Flags().StringVar(&key, "key", "", "Topic access key, sent in the aeg-sas-key header")
+	cmd.Flags().DurationVar(&tokenTTL, "sas-token-ttl", 0, "Send an aeg-sas-token signed with --key and valid for this long instead of the key itself")
+	cmd.Flags().StringVar(&eventType, "type", "eventkit.test", "Event type, supports template placeholders")
+	cmd.Flags().StringVar(&source, "source", "/eventkit/eventgridtool", "CloudEvents source")
+	cmd.Flags().StringVar(&subject, "subject", "events/{{counter}}", "Event subject, supports template placeholders")
+	cmd.Flags().IntVar(&batch, "batch", 1, "Events published per request")
+	cmd.Flags().DurationVar(&requestTimeout, "request-timeout", 10*time.Second, "Timeout of each publish request")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"message":"{{sentence}}","count":{{counter}}}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
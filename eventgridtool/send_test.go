@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEvent(t *testing.T) {
+	f := eventFields{ID: "1", Type: "t", Source: "/s", Subject: "sub", Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for _, c := range []struct {
+		name, schema, data, ct, want string
+	}{
+		{"cloudevents json", schemaCloudEvents, `{"a":1}`, "application/json", `{"specversion":"1.0","id":"1","source":"/s","type":"t","subject":"sub","time":"2024-01-02T03:04:05Z","datacontenttype":"application/json","data":{"a":1}}`},
+		{"cloudevents text", schemaCloudEvents, `{"a":1}`, "text/plain", `"data":"{\"a\":1}"`},
+		{"cloudevents binary", schemaCloudEvents, "\xff\x00", "application/octet-stream", `"data_base64":"/wA="`},
+		{"eventgrid json", schemaEventGrid, `[1]`, "application/json", `{"id":"1","subject":"sub","eventType":"t","eventTime":"2024-01-02T03:04:05Z","data":[1],"dataVersion":"1.0"}`},
+		{"eventgrid binary", schemaEventGrid, "\xff", "application/octet-stream", `"data":"/w=="`},
+	} {
+		out, err := json.Marshal(newEvent(c.schema, f, []byte(c.data), c.ct))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !strings.Contains(string(out), c.want) {
+			t.Errorf("%s: newEvent() = %s, want %s", c.name, out, c.want)
+		}
+	}
+}
+
+func TestSASToken(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("secret"))
+	expiry := time.Date(2024, 3, 4, 15, 6, 7, 0, time.UTC)
+	token, err := sasToken("https://topic.example/api/events", key, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := url.ParseQuery(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Get("r") != "https://topic.example/api/events" || q.Get("e") != "3/4/2024 3:06:07 PM" {
+		t.Errorf("sasToken() = %q", token)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(token[:strings.Index(token, "&s=")]))
+	if q.Get("s") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("sasToken() signature = %q", q.Get("s"))
+	}
+	if _, err := sasToken("r", "not base64!", expiry); err == nil {
+		t.Error("sasToken(invalid key) succeeded, want error")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var got []*http.Request
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = append(got, r)
+		bodies = append(bodies, string(body))
+		if r.Header.Get("aeg-sas-key") == "bad" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	key := base64.StdEncoding.EncodeToString([]byte("k"))
+	ev := eventFields{ID: "1", Type: "t", Source: "/s"}
+	p := &publisher{client: srv.Client(), endpoint: srv.URL, schema: schemaCloudEvents, key: key, headers: map[string]string{"X-Test": "1"}}
+	ctx := context.Background()
+	if err := p.publish(ctx, []interface{}{newEvent(p.schema, ev, []byte("x"), "text/plain")}); err != nil {
+		t.Fatalf("publish(single) error: %v", err)
+	}
+	p.tokenTTL = time.Minute
+	if err := p.publish(ctx, []interface{}{newEvent(p.schema, ev, []byte("x"), "text/plain"), newEvent(p.schema, ev, []byte("y"), "text/plain")}); err != nil {
+		t.Fatalf("publish(batch) error: %v", err)
+	}
+	p.schema, p.tokenTTL = schemaEventGrid, 0
+	if err := p.publish(ctx, []interface{}{newEvent(p.schema, ev, []byte("x"), "text/plain")}); err != nil {
+		t.Fatalf("publish(eventgrid) error: %v", err)
+	}
+
+	if ct := got[0].Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/cloudevents+json") || got[0].Header.Get("aeg-sas-key") != key || got[0].Header.Get("X-Test") != "1" {
+		t.Errorf("single request headers = %v", got[0].Header)
+	}
+	if ct := got[1].Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/cloudevents-batch+json") || got[1].Header.Get("aeg-sas-token") == "" || got[1].Header.Get("aeg-sas-key") != "" {
+		t.Errorf("batch request headers = %v", got[1].Header)
+	}
+	if !strings.HasPrefix(bodies[0], "{") || !strings.HasPrefix(bodies[1], "[") || !strings.HasPrefix(bodies[2], "[") {
+		t.Errorf("bodies = %q", bodies)
+	}
+
+	p.key = "bad"
+	if err := p.publish(ctx, []interface{}{newEvent(p.schema, ev, []byte("x"), "text/plain")}); err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("publish(bad key) = %v, want the rejection", err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxDeliverySize bounds request bodies; Event Grid batches are at most 1 MB.
+const maxDeliverySize = 10 << 20
+
+// deliveryHeaders are the Event Grid headers printed with each delivered event.
+var deliveryHeaders = []string{"aeg-subscription-name", "aeg-delivery-count", "aeg-event-type", "aeg-data-version", "aeg-metadata-version"}
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr string
+		servePath string
+		serveOpts toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a webhook subscriber that validates subscriptions and logs delivered events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			mux := http.NewServeMux()
+			mux.Handle(servePath, handler{})
+			srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("Event Grid webhook listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Path", servePath)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving webhook endpoint: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:9090", "Listen address")
+	cmd.Flags().StringVar(&servePath, "path", "/api/events", "HTTP path pattern of the webhook")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// receivedEvent is a delivered event decoded in both schemas; CloudEvents have a spec version.
+type receivedEvent struct {
+	CE   cloudEvent
+	Grid gridEvent
+}
+
+// decodeEvents parses a delivery body holding a single event or an array of events.
+func decodeEvents(body []byte) ([]receivedEvent, error) {
+	var raws []json.RawMessage
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, err
+		}
+	} else {
+		raws = []json.RawMessage{trimmed}
+	}
+	events := make([]receivedEvent, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &events[i].CE); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &events[i].Grid); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// handler answers the Event Grid validation handshakes and logs delivered events.
+type handler struct{}
+
+func (handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		// CloudEvents webhook abuse protection: the origin is allowed to deliver.
+		origin := r.Header.Get("WebHook-Request-Origin")
+		if origin == "" {
+			http.Error(w, "missing WebHook-Request-Origin", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("WebHook-Allowed-Origin", origin)
+		w.Header().Set("WebHook-Allowed-Rate", "*")
+		w.WriteHeader(http.StatusOK)
+		toolutil.PrintInfo("Validated CloudEvents subscription for origin %s", origin)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDeliverySize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	events, err := decodeEvents(body)
+	if err != nil {
+		toolutil.PrintError("Invalid delivery from %s: %v", r.RemoteAddr, err)
+		http.Error(w, "invalid events: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		if e.Grid.EventType != validationEventType {
+			continue
+		}
+		var data struct {
+			ValidationCode string `json:"validationCode"`
+			ValidationURL  string `json:"validationUrl"`
+		}
+		if err := json.Unmarshal(e.Grid.Data, &data); err != nil || data.ValidationCode == "" {
+			http.Error(w, "invalid validation event", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"validationResponse": data.ValidationCode}) //nolint:errcheck
+		toolutil.PrintInfo("Validated Event Grid subscription for topic %s", e.Grid.Topic)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	var delivery []toolutil.KV
+	for _, h := range deliveryHeaders {
+		if v := r.Header.Get(h); v != "" {
+			delivery = append(delivery, toolutil.KV{Key: h, Value: v})
+		}
+	}
+	for _, e := range events {
+		printEvent(e, delivery)
+	}
+}
+
+// eventData returns the data of e decoded from its JSON string or base64 form, with its content type.
+func eventData(e receivedEvent) ([]byte, string) {
+	data := []byte(e.CE.Data)
+	if e.CE.SpecVersion == "" {
+		data = e.Grid.Data
+	}
+	ct := e.CE.DataContentType
+	if e.CE.DataBase64 != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(e.CE.DataBase64); err == nil {
+			data = decoded
+		}
+	} else if len(data) > 0 && data[0] == '"' {
+		var s string
+		if json.Unmarshal(data, &s) == nil {
+			data = []byte(s)
+		}
+	}
+	if ct == "" {
+		ct = toolutil.GuessMIME(data)
+	}
+	return data, ct
+}
+
+func printEvent(e receivedEvent, delivery []toolutil.KV) {
+	var items []toolutil.KV
+	if e.CE.SpecVersion != "" {
+		items = []toolutil.KV{
+			{Key: "Schema", Value: "CloudEvents " + e.CE.SpecVersion},
+			{Key: "ID", Value: e.CE.ID},
+			{Key: "Type", Value: e.CE.Type},
+			{Key: "Source", Value: e.CE.Source},
+		}
+		if e.CE.Subject != "" {
+			items = append(items, toolutil.KV{Key: "Subject", Value: e.CE.Subject})
+		}
+		if e.CE.Time != "" {
+			items = append(items, toolutil.KV{Key: "Time", Value: e.CE.Time})
+		}
+	} else {
+		items = []toolutil.KV{
+			{Key: "Schema", Value: "Event Grid"},
+			{Key: "ID", Value: e.Grid.ID},
+			{Key: "Type", Value: e.Grid.EventType},
+			{Key: "Topic", Value: e.Grid.Topic},
+			{Key: "Subject", Value: e.Grid.Subject},
+			{Key: "Time", Value: e.Grid.EventTime},
+			{Key: "Data Version", Value: e.Grid.DataVersion},
+		}
+	}
+	data, ct := eventData(e)
+	sections := []toolutil.MessageSection{
+		{Title: "Event", Items: items},
+		{Title: "Delivery", Items: delivery},
+	}
+	toolutil.PrintColoredMessage("Event Grid", sections, data, ct)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerHandshakes(t *testing.T) {
+	srv := httptest.NewServer(handler{})
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodOptions, srv.URL, nil)
+	req.Header.Set("WebHook-Request-Origin", "eventgrid.azure.net")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("WebHook-Allowed-Origin") != "eventgrid.azure.net" {
+		t.Errorf("OPTIONS handshake = %d %v", resp.StatusCode, resp.Header)
+	}
+
+	validation := `[{"id":"1","topic":"/subscriptions/x","subject":"","eventType":"Microsoft.EventGrid.SubscriptionValidationEvent","eventTime":"2024-01-01T00:00:00Z","data":{"validationCode":"512d38b6","validationUrl":"https://example"},"dataVersion":"2"}]`
+	resp, err = srv.Client().Post(srv.URL, "application/json", strings.NewReader(validation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != `{"validationResponse":"512d38b6"}` {
+		t.Errorf("validation event = %d %s", resp.StatusCode, body)
+	}
+
+	resp, err = srv.Client().Post(srv.URL, "application/json", strings.NewReader("not json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid delivery = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestPublishToHandler(t *testing.T) {
+	srv := httptest.NewServer(handler{})
+	defer srv.Close()
+	ev := eventFields{ID: "1", Type: "t", Source: "/s", Subject: "sub", Time: time.Now()}
+	for _, schema := range []string{schemaCloudEvents, schemaEventGrid} {
+		p := &publisher{client: srv.Client(), endpoint: srv.URL, schema: schema}
+		if err := p.publish(context.Background(), []interface{}{newEvent(schema, ev, []byte(`{"a":1}`), "application/json")}); err != nil {
+			t.Errorf("publish(%s) error: %v", schema, err)
+		}
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	events, err := decodeEvents([]byte(`{"specversion":"1.0","id":"1","source":"/s","type":"t","datacontenttype":"text/plain","data":"hello"}`))
+	if err != nil || len(events) != 1 {
+		t.Fatalf("decodeEvents(single) = %v, %v", events, err)
+	}
+	if data, ct := eventData(events[0]); string(data) != "hello" || ct != "text/plain" {
+		t.Errorf("eventData(text) = %q, %q", data, ct)
+	}
+
+	events, err = decodeEvents([]byte(` [{"specversion":"1.0","id":"1","data_base64":"aGk="},{"id":"2","eventType":"t","subject":"s","data":{"a":1},"dataVersion":"1"}]`))
+	if err != nil || len(events) != 2 {
+		t.Fatalf("decodeEvents(batch) = %v, %v", events, err)
+	}
+	if data, _ := eventData(events[0]); string(data) != "hi" {
+		t.Errorf("eventData(base64) = %q", data)
+	}
+	if data, ct := eventData(events[1]); string(data) != `{"a":1}` || ct != "application/json" || events[1].Grid.ID != "2" {
+		t.Errorf("eventData(eventgrid) = %q, %q", data, ct)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "eventhubstool",
+		Short: "Azure Event Hubs client tester",
+		Long:  "A simple Azure Event Hubs CLI with send and serve commands, connecting with a namespace or hub connection string.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// propertyItems converts application properties to sorted key/value items.
+func propertyItems(props map[string]any) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		v := props[k]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		items = append(items, toolutil.KV{Key: k, Value: fmt.Sprintf("%v", v)})
+	}
+	return items
+}
+
+// eventItems lists the system metadata of a received event.
+func eventItems(partitionID string, e *azeventhubs.ReceivedEventData) []toolutil.KV {
+	items := []toolutil.KV{
+		{Key: "Partition", Value: partitionID},
+		{Key: "Sequence", Value: strconv.FormatInt(e.SequenceNumber, 10)},
+		{Key: "Offset", Value: e.Offset},
+	}
+	add := func(key string, value *string) {
+		if value != nil && *value != "" {
+			items = append(items, toolutil.KV{Key: key, Value: *value})
+		}
+	}
+	add("Partition-Key", e.PartitionKey)
+	add("Message-Id", e.MessageID)
+	add("Content-Type", e.ContentType)
+	if e.EnqueuedTime != nil {
+		items = append(items, toolutil.KV{Key: "Enqueued", Value: e.EnqueuedTime.Format(time.RFC3339)})
+	}
+	return items
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		connString     string
+		hub            string
+		partitionKey   string
+		partitionID    string
+		batch          int
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic events to an Event Hub",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch < 1 {
+				return fmt.Errorf("--batch must be at least 1")
+			}
+			if partitionKey != "" && partitionID != "" {
+				return fmt.Errorf("--partition-key and --partition-id are mutually exclusive")
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			producer, err := azeventhubs.NewProducerClientFromConnectionString(connString, hub, nil)
+			if err != nil {
+				return fmt.Errorf("invalid Event Hubs configuration: %w", err)
+			}
+			defer func() {
+				if err := producer.Close(context.Background()); err != nil {
+					toolutil.PrintError("Failed to close Event Hubs producer: %v", err)
+				}
+			}()
+			var props azeventhubs.EventHubProperties
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					var err error
+					props, err = producer.GetEventHubProperties(ctx, nil)
+					return err
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to Event Hubs: %w", err)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			var properties map[string]any
+			if len(headerMap) > 0 {
+				properties = make(map[string]any, len(headerMap))
+				for k, v := range headerMap {
+					properties[k] = v
+				}
+			}
+
+			toolutil.PrintSuccess("Connected to Event Hubs")
+			toolutil.PrintKeyValue("Event Hub", props.Name)
+			toolutil.PrintKeyValue("Partitions", len(props.PartitionIDs))
+			toolutil.PrintKeyValue("Batch", batch)
+
+			key := toolutil.NewDestination(partitionKey, openDelim, closeDelim)
+			send := func() error {
+				opts := &azeventhubs.EventDataBatchOptions{}
+				if partitionID != "" {
+					opts.PartitionID = &partitionID
+				}
+				if partitionKey != "" {
+					k, err := key.Resolve()
+					if err != nil {
+						toolutil.PrintError("Partition key build error: %v", err)
+						return err
+					}
+					opts.PartitionKey = &k
+				}
+
+				sendCtx, sendCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer sendCancel()
+				b, err := producer.NewEventDataBatch(sendCtx, opts)
+				if err != nil {
+					toolutil.PrintError("Batch creation error: %v", err)
+					return err
+				}
+				size := 0
+				for range batch {
+					body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+					if err != nil {
+						toolutil.PrintError("Payload build error: %v", err)
+						return err
+					}
+					ev := &azeventhubs.EventData{Body: body, ContentType: &ct, Properties: properties}
+					if err := b.AddEventData(ev, nil); err != nil {
+						if errors.Is(err, azeventhubs.ErrEventDataTooLarge) {
+							err = fmt.Errorf("%d events of this size do not fit in one batch: %w", batch, err)
+						}
+						toolutil.PrintError("Batch error: %v", err)
+						return err
+					}
+					size += len(body)
+				}
+				if err := producer.SendEventDataBatch(sendCtx, b, nil); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				target := "any partition"
+				switch {
+				case opts.PartitionID != nil:
+					target = "partition " + *opts.PartitionID
+				case opts.PartitionKey != nil:
+					target = "partition key '" + *opts.PartitionKey + "'"
+				}
+				toolutil.PrintInfo("Sent %d events (%d bytes) to %s", b.NumEvents(), size, target)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&connString, "connection-string", "", "Event Hubs namespace or hub connection string")
+	cmd.Flags().StringVar(&hub, "hub", "", "Event Hub name (leave empty when the connection string has an EntityPath)")
+	cmd.Flags().StringVar(&partitionKey, "partition-key", "", "Partition key of each batch (supports template placeholders)")
+	cmd.Flags().StringVar(&partitionID, "partition-id", "", "Send to this partition instead of letting the service choose")
+	cmd.Flags().IntVar(&batch, "batch", 1, "Events per send, published as one batch")
+	_ = cmd.MarkFlagRequired("connection-string")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, Event Hubs!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2/checkpoints"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// receiveBatch is the maximum number of events requested per receive call.
+const receiveBatch = 100
+
+// receiveFunc is the ReceiveEvents method shared by PartitionClient and ProcessorPartitionClient.
+type receiveFunc func(ctx context.Context, count int, options *azeventhubs.ReceiveEventsOptions) ([]*azeventhubs.ReceivedEventData, error)
+
+// checkpointFunc is ProcessorPartitionClient.UpdateCheckpoint.
+type checkpointFunc func(ctx context.Context, latest *azeventhubs.ReceivedEventData, options *azeventhubs.UpdateCheckpointOptions) error
+
+func serveCommand() *cobra.Command {
+	var (
+		connString          string
+		hub                 string
+		consumerGroup       string
+		startPosition       string
+		checkpointStorage   string
+		checkpointContainer string
+		checkpointEvery     int
+		connectTimeout      time.Duration
+		serveOpts           toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Consume events from every partition of an Event Hub",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := parseStartPosition(startPosition)
+			if err != nil {
+				return err
+			}
+			if (checkpointStorage == "") != (checkpointContainer == "") {
+				return fmt.Errorf("--checkpoint-storage and --checkpoint-container must be used together")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			consumer, err := azeventhubs.NewConsumerClientFromConnectionString(connString, hub, consumerGroup, nil)
+			if err != nil {
+				return fmt.Errorf("invalid Event Hubs configuration: %w", err)
+			}
+			defer func() {
+				if err := consumer.Close(context.Background()); err != nil {
+					toolutil.PrintError("Failed to close Event Hubs consumer: %v", err)
+				}
+			}()
+			var props azeventhubs.EventHubProperties
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				var err error
+				props, err = consumer.GetEventHubProperties(ctx, nil)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to Event Hubs: %w", err)
+			}
+
+			toolutil.PrintSuccess("Consuming from Event Hubs")
+			toolutil.PrintKeyValue("Event Hub", props.Name)
+			toolutil.PrintKeyValue("Consumer Group", consumerGroup)
+			toolutil.PrintKeyValue("Partitions", len(props.PartitionIDs))
+
+			var wg sync.WaitGroup
+			if checkpointStorage == "" {
+				toolutil.PrintKeyValue("Start", startPosition)
+				for _, id := range props.PartitionIDs {
+					pc, err := consumer.NewPartitionClient(id, &azeventhubs.PartitionClientOptions{StartPosition: start})
+					if err != nil {
+						return fmt.Errorf("failed to open partition %s: %w", id, err)
+					}
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						defer pc.Close(context.Background()) //nolint:errcheck
+						receivePartition(ctx, props.Name, id, pc.ReceiveEvents, nil, 0)
+					}()
+				}
+				wg.Wait()
+				return nil
+			}
+
+			containerClient, err := container.NewClientFromConnectionString(checkpointStorage, checkpointContainer, nil)
+			if err != nil {
+				return fmt.Errorf("invalid checkpoint storage: %w", err)
+			}
+			store, err := checkpoints.NewBlobStore(containerClient, nil)
+			if err != nil {
+				return fmt.Errorf("failed to create checkpoint store: %w", err)
+			}
+			processor, err := azeventhubs.NewProcessor(consumer, store, &azeventhubs.ProcessorOptions{
+				StartPositions: azeventhubs.StartPositions{Default: start},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create processor: %w", err)
+			}
+			toolutil.PrintKeyValue("Checkpoints", checkpointContainer)
+			toolutil.PrintKeyValue("Start (no checkpoint)", startPosition)
+
+			// Partitions are claimed, and possibly lost, while the processor balances them with other instances.
+			go func() {
+				for {
+					pc := processor.NextPartitionClient(ctx)
+					if pc == nil {
+						return
+					}
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						defer pc.Close(context.Background()) //nolint:errcheck
+						toolutil.PrintInfo("Claimed partition %s", pc.PartitionID())
+						receivePartition(ctx, props.Name, pc.PartitionID(), pc.ReceiveEvents, pc.UpdateCheckpoint, checkpointEvery)
+					}()
+				}
+			}()
+			err = processor.Run(ctx)
+			wg.Wait()
+			if err != nil {
+				return fmt.Errorf("processor error: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&connString, "connection-string", "", "Event Hubs namespace or hub connection string")
+	cmd.Flags().StringVar(&hub, "hub", "", "Event Hub name (leave empty when the connection string has an EntityPath)")
+	cmd.Flags().StringVar(&consumerGroup, "consumer-group", azeventhubs.DefaultConsumerGroup, "Consumer group")
+	cmd.Flags().StringVar(&startPosition, "start-position", "latest", "Where to start reading partitions without a checkpoint: latest or earliest")
+	cmd.Flags().StringVar(&checkpointStorage, "checkpoint-storage", "", "Azure Storage connection string of the checkpoint store (enables checkpointing and load balancing)")
+	cmd.Flags().StringVar(&checkpointContainer, "checkpoint-container", "", "Existing blob container holding checkpoints and partition ownership")
+	cmd.Flags().IntVar(&checkpointEvery, "checkpoint-every", 1, "Update the checkpoint after this many events per partition (0 only reads checkpoints)")
+	_ = cmd.MarkFlagRequired("connection-string")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// parseStartPosition converts the --start-position flag.
+func parseStartPosition(s string) (azeventhubs.StartPosition, error) {
+	yes := true
+	switch strings.ToLower(s) {
+	case "latest":
+		return azeventhubs.StartPosition{Latest: &yes}, nil
+	case "earliest":
+		return azeventhubs.StartPosition{Earliest: &yes}, nil
+	}
+	return azeventhubs.StartPosition{}, fmt.Errorf("invalid --start-position %q: expected latest or earliest", s)
+}
+
+// receivePartition prints events from one partition until ctx ends. With a checkpoint function,
+// the checkpoint is moved to the last printed event every checkpointEvery events.
+func receivePartition(ctx context.Context, hub, partitionID string, receive receiveFunc, checkpoint checkpointFunc, checkpointEvery int) {
+	pending := 0
+	for {
+		// Bound each call so batches smaller than receiveBatch are printed promptly.
+		rctx, rcancel := context.WithTimeout(ctx, time.Second)
+		events, err := receive(rctx, receiveBatch, nil)
+		rcancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			var ehErr *azeventhubs.Error
+			if errors.As(err, &ehErr) && ehErr.Code == azeventhubs.ErrorCodeOwnershipLost {
+				toolutil.PrintWarning("Lost ownership of partition %s", partitionID)
+				return
+			}
+			toolutil.PrintError("Receive error on partition %s: %v", partitionID, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		// Events past --max-messages are not printed and must not be checkpointed
+		printed := 0
+		for _, e := range events {
+			sections := []toolutil.MessageSection{
+				{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: hub}}},
+				{Title: "Event", Items: eventItems(partitionID, e)},
+				toolutil.HeadersSection(propertyItems(e.Properties)),
+			}
+			ct := ""
+			if e.ContentType != nil {
+				ct = *e.ContentType
+			}
+			if ct == "" {
+				ct = toolutil.GuessMIME(e.Body)
+			}
+			if !toolutil.PrintColoredMessage("Event Hubs", sections, e.Body, ct) {
+				break
+			}
+			printed++
+		}
+
+		if checkpoint == nil || checkpointEvery <= 0 || printed == 0 {
+			continue
+		}
+		pending += printed
+		if pending >= checkpointEvery {
+			if err := checkpoint(ctx, events[printed-1], nil); err != nil {
+				toolutil.PrintError("Checkpoint error on partition %s: %v", partitionID, err)
+				continue
+			}
+			pending = 0
+		}
+	}
+}
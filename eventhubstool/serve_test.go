@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventhubs/v2"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestParseStartPosition(t *testing.T) {
+	p, err := parseStartPosition("Earliest")
+	if err != nil || p.Earliest == nil || !*p.Earliest {
+		t.Errorf("parseStartPosition(Earliest) = %+v, %v", p, err)
+	}
+	p, err = parseStartPosition("latest")
+	if err != nil || p.Latest == nil || !*p.Latest {
+		t.Errorf("parseStartPosition(latest) = %+v, %v", p, err)
+	}
+	if _, err := parseStartPosition("oldest"); err == nil {
+		t.Error("parseStartPosition(oldest) succeeded, want error")
+	}
+}
+
+func TestReceivePartitionCheckpoints(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Four batches of two events, then cancel.
+	batches := 0
+	receive := func(ctx context.Context, count int, _ *azeventhubs.ReceiveEventsOptions) ([]*azeventhubs.ReceivedEventData, error) {
+		batches++
+		if batches > 4 {
+			cancel()
+			return nil, ctx.Err()
+		}
+		seq := int64(batches * 2)
+		return []*azeventhubs.ReceivedEventData{
+			{EventData: azeventhubs.EventData{Body: []byte("a")}, SequenceNumber: seq - 1},
+			{EventData: azeventhubs.EventData{Body: []byte("b")}, SequenceNumber: seq},
+		}, nil
+	}
+	var checkpointed []int64
+	checkpoint := func(_ context.Context, latest *azeventhubs.ReceivedEventData, _ *azeventhubs.UpdateCheckpointOptions) error {
+		checkpointed = append(checkpointed, latest.SequenceNumber)
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		receivePartition(ctx, "hub", "0", receive, checkpoint, 3)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receivePartition did not return after cancellation")
+	}
+
+	// Every 3 events, rounded up to the batch that crosses the threshold.
+	want := []int64{4, 8}
+	if len(checkpointed) != len(want) || checkpointed[0] != want[0] || checkpointed[1] != want[1] {
+		t.Errorf("checkpoints at %v, want %v", checkpointed, want)
+	}
+}
+
+func TestReceivePartitionCheckpoints_MaxMessages(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{MaxMessages: 3, AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Three batches of two events, then cancel: only the first three events are printed.
+	batches := 0
+	receive := func(ctx context.Context, count int, _ *azeventhubs.ReceiveEventsOptions) ([]*azeventhubs.ReceivedEventData, error) {
+		batches++
+		if batches > 3 {
+			cancel()
+			return nil, ctx.Err()
+		}
+		seq := int64(batches * 2)
+		return []*azeventhubs.ReceivedEventData{
+			{EventData: azeventhubs.EventData{Body: []byte("a")}, SequenceNumber: seq - 1},
+			{EventData: azeventhubs.EventData{Body: []byte("b")}, SequenceNumber: seq},
+		}, nil
+	}
+	var checkpointed []int64
+	checkpoint := func(_ context.Context, latest *azeventhubs.ReceivedEventData, _ *azeventhubs.UpdateCheckpointOptions) error {
+		checkpointed = append(checkpointed, latest.SequenceNumber)
+		return nil
+	}
+
+	receivePartition(ctx, "hub", "0", receive, checkpoint, 1)
+
+	want := []int64{2, 3}
+	if len(checkpointed) != len(want) || checkpointed[0] != want[0] || checkpointed[1] != want[1] {
+		t.Errorf("checkpoints at %v, want %v", checkpointed, want)
+	}
+}
+
+func TestPropertyItems(t *testing.T) {
+	got := propertyItems(map[string]any{"b": int64(2), "a": []byte("x")})
+	want := []toolutil.KV{{Key: "a", Value: "x"}, {Key: "b", Value: "2"}}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("propertyItems() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"google.golang.org/api/iterator"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "firestoretool",
+		Short: "Google Cloud Firestore snapshot listener tester",
+		Long:  "A simple Firestore CLI that writes templated documents to a collection and prints the added, modified and removed documents reported by a snapshot listener, against Firestore or its emulator.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// emulatorHostEnv is read by the client to connect to the emulator without authentication.
+const emulatorHostEnv = "FIRESTORE_EMULATOR_HOST"
+
+// fsOptions configure the database shared by both commands.
+type fsOptions struct {
+	Project  string
+	Database string
+	Endpoint string
+}
+
+func addFSFlags(cmd *cobra.Command, opts *fsOptions) {
+	cmd.Flags().StringVar(&opts.Project, "project", "test-project", "Google Cloud Project ID")
+	cmd.Flags().StringVar(&opts.Database, "database", firestore.DefaultDatabaseID, "Database ID")
+	cmd.Flags().StringVar(&opts.Endpoint, "endpoint", "", "Emulator address host:port (default: $"+emulatorHostEnv+")")
+}
+
+// target describes where the client connects, for display.
+func (o fsOptions) target() string {
+	if host := os.Getenv(emulatorHostEnv); host != "" {
+		return "emulator at " + host
+	}
+	return "Firestore"
+}
+
+// client creates a client, on the emulator when an endpoint is set. The client connects lazily;
+// ping checks the connection.
+func (o fsOptions) client(ctx context.Context) (*firestore.Client, error) {
+	if o.Endpoint != "" {
+		if err := os.Setenv(emulatorHostEnv, o.Endpoint); err != nil {
+			return nil, err
+		}
+	}
+	return firestore.NewClientWithDatabase(ctx, o.Project, o.Database)
+}
+
+// ping lists the root collections, which fails on unreachable servers and invalid credentials.
+func ping(ctx context.Context, c *firestore.Client) error {
+	if _, err := c.Collections(ctx).Next(); err != nil && !errors.Is(err, iterator.Done) {
+		return err
+	}
+	return nil
+}
+
+// relativePath strips the projects/P/databases/D/documents/ prefix of a document path.
+func relativePath(path string) string {
+	if _, rel, ok := strings.Cut(path, "/documents/"); ok {
+		return rel
+	}
+	return path
+}
+
+// documentData decodes a JSON object payload into document fields. Integers stay integers,
+// which plain JSON decoding would turn into doubles.
+func documentData(body []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var data map[string]any
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("payload is not a JSON object: %w", err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("payload is not a JSON object")
+	}
+	v, err := fieldValue(data)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+// fieldValue converts the JSON numbers of a decoded value, recursively.
+func fieldValue(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case map[string]any:
+		for k, e := range v {
+			c, err := fieldValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", k, err)
+			}
+			v[k] = c
+		}
+	case []any:
+		for i, e := range v {
+			c, err := fieldValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = c
+		}
+	}
+	return v, nil
+}
+
+// jsonValue converts document field values with no JSON form of their own: timestamps become
+// RFC 3339 strings, references their path and geo points latitude/longitude objects. Bytes are
+// encoded in base64 by the JSON encoder.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *firestore.DocumentRef:
+		return v.Path
+	case *latlng.LatLng:
+		return map[string]float64{"latitude": v.GetLatitude(), "longitude": v.GetLongitude()}
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = jsonValue(e)
+		}
+		return m
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = jsonValue(e)
+		}
+		return a
+	}
+	return v
+}
+
+// filter is a --where condition.
+type filter struct {
+	Path  string
+	Op    string
+	Value any
+}
+
+// filterOps are the query operators.
+var filterOps = []string{"==", "!=", "<", "<=", ">", ">=", "array-contains", "array-contains-any", "in", "not-in"}
+
+// parseFilter parses "path op value". The value is read as JSON, falling back to a string.
+func parseFilter(s string) (filter, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), " ", 3)
+	if len(parts) != 3 {
+		return filter{}, fmt.Errorf("invalid --where %q: expected 'path op value'", s)
+	}
+	f := filter{Path: parts[0], Op: parts[1]}
+	if !slices.Contains(filterOps, f.Op) {
+		return filter{}, fmt.Errorf("invalid --where %q: unknown operator %q", s, f.Op)
+	}
+	raw := strings.TrimSpace(parts[2])
+	f.Value = raw
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		if v, err = fieldValue(v); err == nil {
+			f.Value = v
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/genproto/googleapis/type/latlng"
+)
+
+func TestDocumentData(t *testing.T) {
+	data, err := documentData([]byte(`{"count":3,"ratio":0.5,"tags":["a",2],"meta":{"n":7,"ok":true},"none":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"count": int64(3),
+		"ratio": 0.5,
+		"tags":  []any{"a", int64(2)},
+		"meta":  map[string]any{"n": int64(7), "ok": true},
+		"none":  nil,
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("documentData = %#v", data)
+	}
+	for _, bad := range []string{`[1]`, `null`, `text`} {
+		if _, err := documentData([]byte(bad)); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := map[string]filter{
+		"count > 10":                         {Path: "count", Op: ">", Value: int64(10)},
+		"status == active":                   {Path: "status", Op: "==", Value: "active"},
+		`status == "on hold"`:                {Path: "status", Op: "==", Value: "on hold"},
+		`tags array-contains-any ["a", "b"]`: {Path: "tags", Op: "array-contains-any", Value: []any{"a", "b"}},
+		"ok != true":                         {Path: "ok", Op: "!=", Value: true},
+		"note == two words":                  {Path: "note", Op: "==", Value: "two words"},
+	}
+	for in, want := range tests {
+		f, err := parseFilter(in)
+		if err != nil || !reflect.DeepEqual(f, want) {
+			t.Errorf("parseFilter(%q) = %#v, %v", in, f, err)
+		}
+	}
+	for _, bad := range []string{"count", "count >", "count ~ 3"} {
+		if _, err := parseFilter(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestJSONValue(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	v := jsonValue(map[string]any{
+		"at":    ts,
+		"where": &latlng.LatLng{Latitude: 45.5, Longitude: 9.25},
+		"ref":   &firestore.DocumentRef{Path: "projects/p/databases/(default)/documents/users/alice"},
+		"list":  []any{ts, "x"},
+	})
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"at":"2024-05-01T12:00:00Z","list":["2024-05-01T12:00:00Z","x"],"ref":"projects/p/databases/(default)/documents/users/alice","where":{"latitude":45.5,"longitude":9.25}}`
+	if string(b) != want {
+		t.Errorf("jsonValue = %s", b)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	if p := relativePath("projects/p/databases/(default)/documents/users/alice/events/e1"); p != "users/alice/events/e1" {
+		t.Errorf("relativePath = %s", p)
+	}
+	if p := relativePath("events/e1"); p != "events/e1" {
+		t.Errorf("relativePath = %s", p)
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	old := map[string]any{"a": int64(1), "b": "x", "c": []any{"y"}, "gone": true}
+	cur := map[string]any{"a": int64(2), "b": "x", "c": []any{"y"}, "new": "z"}
+	if got := changedFields(old, cur); !reflect.DeepEqual(got, []string{"a", "gone", "new"}) {
+		t.Errorf("changedFields = %v", got)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		opts            fsOptions
+		collection      string
+		docID           string
+		field           string
+		merge           bool
+		serverTimestamp string
+		sendPayload     string
+		sendMIME        string
+		sendInterval    string
+		openDelim       string
+		closeDelim      string
+		seed            int64
+		seedPerMessage  bool
+		allowFileReads  bool
+		allowEnvReads   bool
+		execOptions     toolutil.ExecOptions
+		payloadURL      string
+		strictTemplate  bool
+		templateVars    []string
+		fileRoot        string
+		cacheFiles      bool
+		dataSource      toolutil.DataSourceOptions
+		payloadSource   toolutil.PayloadSourceOptions
+		templateEngine  string
+		cloudEvents     toolutil.CloudEventsOptions
+		protoset        string
+		payloadSchema   string
+		corruptRate     float64
+		once            bool
+		printPayload    bool
+		interactive     bool
+		connectTimeout  time.Duration
+		connectRetry    toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Write periodic templated documents to a Firestore collection",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if merge && docID == "" {
+				return fmt.Errorf("--merge requires --id")
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var client *firestore.Client
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					var err error
+					if client, err = opts.client(ctx); err != nil {
+						return err
+					}
+					if err := ping(ctx, client); err != nil {
+						client.Close() //nolint:errcheck
+						return err
+					}
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to Firestore: %w", err)
+			}
+			defer client.Close() //nolint:errcheck
+
+			toolutil.PrintSuccess("Connected to %s", opts.target())
+			toolutil.PrintKeyValue("Project", opts.Project)
+			toolutil.PrintKeyValue("Database", opts.Database)
+			toolutil.PrintKeyValue("Collection", collection)
+
+			collectionDest := toolutil.NewDestination(collection, openDelim, closeDelim)
+			idDest := toolutil.NewDestination(docID, openDelim, closeDelim)
+			send := func() error {
+				c, err := collectionDest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Collection build error: %v", err)
+					return err
+				}
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				var data map[string]any
+				if field != "" {
+					data = map[string]any{field: string(body)}
+				} else if data, err = documentData(body); err != nil {
+					toolutil.PrintError("Document build error: %v", err)
+					return err
+				}
+				if serverTimestamp != "" {
+					data[serverTimestamp] = firestore.ServerTimestamp
+				}
+
+				col := client.Collection(c)
+				if col == nil {
+					err := fmt.Errorf("invalid collection path %q", c)
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				doc := col.NewDoc()
+				if docID != "" {
+					id, err := idDest.Resolve()
+					if err != nil {
+						toolutil.PrintError("ID build error: %v", err)
+						return err
+					}
+					doc = col.Doc(id)
+				}
+				var setOpts []firestore.SetOption
+				if merge {
+					setOpts = append(setOpts, firestore.MergeAll)
+				}
+				res, err := doc.Set(ctx, data, setOpts...)
+				if err != nil {
+					toolutil.PrintError("Write error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Wrote %s (%d bytes) at %s", relativePath(doc.Path), len(body), res.UpdateTime.Format(time.RFC3339Nano))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addFSFlags(cmd, &opts)
+	cmd.Flags().StringVar(&collection, "collection", "events", "Collection path, e.g. users/alice/events, supports template placeholders")
+	cmd.Flags().StringVar(&docID, "id", "", "Document ID, supports template placeholders (default: a new random ID)")
+	cmd.Flags().StringVar(&field, "field", "", "Store the whole payload in this string field instead of using the fields of a JSON object")
+	cmd.Flags().BoolVar(&merge, "merge", false, "Merge the fields into an existing document instead of replacing it (requires --id)")
+	cmd.Flags().StringVar(&serverTimestamp, "server-timestamp", "", "Field set to the server commit time")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"message":"{{sentence}}","count":{{counter}},"created_at":"{{nowtime}}"}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// changeKinds names the document change kinds.
+var changeKinds = map[firestore.DocumentChangeKind]string{
+	firestore.DocumentAdded:    "added",
+	firestore.DocumentModified: "modified",
+	firestore.DocumentRemoved:  "removed",
+}
+
+// changedFields lists the top-level fields that differ between two versions of a document.
+func changedFields(old, cur map[string]any) []string {
+	var fields []string
+	for k, v := range cur {
+		if ov, ok := old[k]; !ok || !reflect.DeepEqual(ov, v) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           fsOptions
+		collection     string
+		group          bool
+		where          []string
+		existing       bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Listen to the snapshots of a Firestore collection and print document changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filters := make([]filter, 0, len(where))
+			for _, w := range where {
+				f, err := parseFilter(w)
+				if err != nil {
+					return err
+				}
+				filters = append(filters, f)
+			}
+			if group && strings.Contains(collection, "/") {
+				return fmt.Errorf("--group takes a collection ID, not a path")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var client *firestore.Client
+			var it *firestore.QuerySnapshotIterator
+			var first *firestore.QuerySnapshot
+			// The listener is attached with the connection so that the first snapshot, the
+			// documents already matching, proves the query is valid and allowed.
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				var err error
+				if client, err = opts.client(ctx); err != nil {
+					return err
+				}
+				var q firestore.Query
+				if group {
+					q = client.CollectionGroup(collection).Query
+				} else if col := client.Collection(collection); col != nil {
+					q = col.Query
+				} else {
+					return fmt.Errorf("invalid collection path %q", collection)
+				}
+				for _, f := range filters {
+					q = q.Where(f.Path, f.Op, f.Value)
+				}
+				// The iterator outlives the connection, so it listens on the serve context.
+				it = q.Snapshots(ctx)
+				first, err = it.Next()
+				return err
+			}); err != nil {
+				return fmt.Errorf("error listening to Firestore: %w", err)
+			}
+			defer client.Close() //nolint:errcheck
+			defer it.Stop()
+
+			toolutil.PrintSuccess("Listening to %s", opts.target())
+			toolutil.PrintKeyValue("Project", opts.Project)
+			toolutil.PrintKeyValue("Database", opts.Database)
+			if group {
+				toolutil.PrintKeyValue("Collection group", collection)
+			} else {
+				toolutil.PrintKeyValue("Collection", collection)
+			}
+			for _, w := range where {
+				toolutil.PrintKeyValue("Where", w)
+			}
+			if existing {
+				printChanges(first)
+			} else {
+				toolutil.PrintKeyValue("Existing documents", first.Size)
+			}
+
+			for {
+				snap, err := it.Next()
+				if err != nil {
+					if ctx.Err() != nil {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					return fmt.Errorf("snapshot listener error: %w", err)
+				}
+				printChanges(snap)
+			}
+		},
+	}
+
+	addFSFlags(cmd, &opts)
+	cmd.Flags().StringVar(&collection, "collection", "events", "Collection path, e.g. users/alice/events, or collection ID with --group")
+	cmd.Flags().BoolVar(&group, "group", false, "Listen to all the collections with the --collection ID (collection group query)")
+	cmd.Flags().StringArrayVar(&where, "where", nil, "Filter 'path op value', e.g. 'count > 10' or 'tags array-contains \"a\"'; values are JSON or strings (repeatable)")
+	cmd.Flags().BoolVar(&existing, "existing", false, "Also print the documents matching when the listener starts, as added")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+func printChanges(snap *firestore.QuerySnapshot) {
+	for _, ch := range snap.Changes {
+		printChange(ch, snap.ReadTime)
+	}
+}
+
+func printChange(ch firestore.DocumentChange, readTime time.Time) {
+	doc := ch.Doc
+	data := doc.Data()
+	items := []toolutil.KV{
+		{Key: "Kind", Value: changeKinds[ch.Kind]},
+		{Key: "Path", Value: relativePath(doc.Ref.Path)},
+		{Key: "Read", Value: readTime.Format(time.RFC3339Nano)},
+	}
+	if ch.Kind == firestore.DocumentModified && ch.OldDoc != nil {
+		items = append(items, toolutil.KV{Key: "Fields", Value: strings.Join(changedFields(ch.OldDoc.Data(), data), ", ")})
+	}
+	if ch.Kind != firestore.DocumentRemoved {
+		items = append(items,
+			toolutil.KV{Key: "Created", Value: doc.CreateTime.Format(time.RFC3339Nano)},
+			toolutil.KV{Key: "Updated", Value: doc.UpdateTime.Format(time.RFC3339Nano)},
+		)
+	}
+
+	body, err := json.Marshal(jsonValue(data))
+	if err != nil {
+		toolutil.PrintError("Failed to encode %s: %v", doc.Ref.ID, err)
+		return
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Document", Items: []toolutil.KV{{Key: "ID", Value: doc.Ref.ID}}},
+		{Title: "Change", Items: items},
+	}
+	toolutil.PrintColoredMessage("Firestore", sections, body, toolutil.CTJSON)
+}
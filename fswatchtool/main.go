@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "fswatchtool",
+		Short: "Filesystem event source and file-drop sink",
+		Long:  "A simple filesystem CLI that writes templated files into a directory and watches a directory printing create/write/remove/rename events.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+var opsByName = map[string]fsnotify.Op{
+	"create": fsnotify.Create,
+	"write":  fsnotify.Write,
+	"remove": fsnotify.Remove,
+	"rename": fsnotify.Rename,
+	"chmod":  fsnotify.Chmod,
+}
+
+// parseOps parses a comma-separated list of operation names.
+func parseOps(s string) (fsnotify.Op, error) {
+	var ops fsnotify.Op
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			ops |= fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename | fsnotify.Chmod
+			continue
+		}
+		op, ok := opsByName[name]
+		if !ok {
+			return 0, fmt.Errorf("invalid --ops %q: expected create, write, remove, rename, chmod or all", name)
+		}
+		ops |= op
+	}
+	if ops == 0 {
+		return 0, fmt.Errorf("--ops must select at least one operation")
+	}
+	return ops, nil
+}
+
+// opNames renders op as lowercase names joined with "|", e.g. "create|write".
+func opNames(op fsnotify.Op) string {
+	var names []string
+	for _, name := range []string{"create", "write", "remove", "rename", "chmod"} {
+		if op.Has(opsByName[name]) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
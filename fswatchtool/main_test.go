@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestParseOps(t *testing.T) {
+	op, err := parseOps("create, Write")
+	if err != nil || op != fsnotify.Create|fsnotify.Write {
+		t.Errorf("parseOps() = %v, %v", op, err)
+	}
+	if got := opNames(op); got != "create|write" {
+		t.Errorf("opNames() = %q, want create|write", got)
+	}
+	if op, err := parseOps("all"); err != nil || opNames(op) != "create|write|remove|rename|chmod" {
+		t.Errorf("parseOps(all) = %v, %v", op, err)
+	}
+	for _, in := range []string{"", " , ", "delete"} {
+		if _, err := parseOps(in); err == nil {
+			t.Errorf("parseOps(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, atomic := range []bool{true, false} {
+		path := filepath.Join(dir, "sub", "f.txt")
+		if err := writeFile(path, []byte("data"), 0o600, atomic); err != nil {
+			t.Fatalf("writeFile(atomic=%v) error: %v", atomic, err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil || string(b) != "data" {
+			t.Errorf("read back %q, %v", b, err)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+			t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+		}
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "sub"))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the target file", len(entries))
+	}
+}
+
+func TestWatcherMatches(t *testing.T) {
+	w := &watcher{glob: "*.json"}
+	for path, want := range map[string]bool{
+		"/d/a.json":           true,
+		"/d/a.txt":            false,
+		"/d/.a.json.tmp-1234": false,
+	} {
+		if got := w.matches(path); got != want {
+			t.Errorf("matches(%q) = %v, want %v", path, got, want)
+		}
+	}
+	w = &watcher{includeHidden: true}
+	if !w.matches("/d/.hidden") {
+		t.Error("matches(.hidden) = false with includeHidden")
+	}
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	w := &watcher{
+		ops:      fsnotify.Create | fsnotify.Write,
+		debounce: 50 * time.Millisecond,
+		pending:  map[string]*pendingEvent{},
+	}
+	w.handle(fsnotify.Event{Name: "/missing/a", Op: fsnotify.Create})
+	w.handle(fsnotify.Event{Name: "/missing/a", Op: fsnotify.Write})
+	w.handle(fsnotify.Event{Name: "/missing/a", Op: fsnotify.Chmod})
+	w.handle(fsnotify.Event{Name: "/missing/b", Op: fsnotify.Write})
+	w.handle(fsnotify.Event{Name: "/missing/c", Op: fsnotify.Remove})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for toolutil.ReceivedCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := toolutil.ReceivedCount(); got != 2 {
+		t.Errorf("printed %d events, want 2 (a coalesced, b; c filtered out)", got)
+	}
+}
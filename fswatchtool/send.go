@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		dir            string
+		name           string
+		fileMode       string
+		noAtomic       bool
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Write periodic templated files into a directory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := strconv.ParseUint(fileMode, 8, 32)
+			if err != nil || m > 0o777 {
+				return fmt.Errorf("invalid --mode %q: expected octal permissions such as 0644", fileMode)
+			}
+			perm := fs.FileMode(m)
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("error creating directory: %w", err)
+			}
+
+			toolutil.PrintSuccess("Writing files")
+			toolutil.PrintKeyValue("Directory", dir)
+			toolutil.PrintKeyValue("Name", name)
+			toolutil.PrintKeyValue("Atomic", !noAtomic)
+
+			fileName := toolutil.NewDestination(name, openDelim, closeDelim)
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				n, err := fileName.Resolve()
+				if err != nil {
+					toolutil.PrintError("Name build error: %v", err)
+					return err
+				}
+				if !filepath.IsLocal(n) {
+					err := fmt.Errorf("file name %q must be a relative path inside the directory", n)
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				path := filepath.Join(dir, n)
+				if err := writeFile(path, body, perm, !noAtomic); err != nil {
+					toolutil.PrintError("Write error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Wrote %s (%d bytes)", path, len(body))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "Directory to write files into (created if missing)")
+	cmd.Flags().StringVar(&name, "name", "event-{{counter}}.txt", "File name relative to --dir, supports template placeholders")
+	cmd.Flags().StringVar(&fileMode, "mode", "0644", "Octal permissions of written files")
+	cmd.Flags().BoolVar(&noAtomic, "no-atomic", false, "Write files in place instead of writing a temporary file and renaming it")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, filesystem!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
+
+// writeFile writes data to path, creating parent directories. Atomic writes go to a hidden
+// temporary file in the same directory that is renamed into place, so watchers never see a
+// partially written file under the final name.
+func writeFile(path string, data []byte, perm fs.FileMode, atomic bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if !atomic {
+		if err := os.WriteFile(path, data, perm); err != nil {
+			return err
+		}
+		return os.Chmod(path, perm)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		dir           string
+		recursive     bool
+		ops           string
+		glob          string
+		includeHidden bool
+		content       bool
+		maxContent    int64
+		debounce      time.Duration
+		serveOpts     toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Watch a directory and log filesystem events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opMask, err := parseOps(ops)
+			if err != nil {
+				return err
+			}
+			if glob != "" {
+				if _, err := filepath.Match(glob, ""); err != nil {
+					return fmt.Errorf("invalid --glob %q: %w", glob, err)
+				}
+			}
+			if maxContent < 1 {
+				return fmt.Errorf("--max-content-bytes must be at least 1")
+			}
+			if debounce < 0 {
+				return fmt.Errorf("--debounce must not be negative")
+			}
+			if info, err := os.Stat(dir); err != nil {
+				return err
+			} else if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			fw, err := fsnotify.NewWatcher()
+			if err != nil {
+				return fmt.Errorf("error creating watcher: %w", err)
+			}
+			w := &watcher{
+				fw:            fw,
+				recursive:     recursive,
+				ops:           opMask,
+				glob:          glob,
+				includeHidden: includeHidden,
+				content:       content,
+				maxContent:    maxContent,
+				debounce:      debounce,
+				pending:       map[string]*pendingEvent{},
+			}
+			defer w.close()
+			if err := w.add(dir); err != nil {
+				return fmt.Errorf("error watching %s: %w", dir, err)
+			}
+
+			toolutil.PrintSuccess("Watching directory")
+			toolutil.PrintKeyValue("Directory", dir)
+			toolutil.PrintKeyValue("Recursive", recursive)
+			toolutil.PrintKeyValue("Operations", opNames(opMask))
+
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case ev, ok := <-fw.Events:
+					if !ok {
+						return nil
+					}
+					w.handle(ev)
+				case err, ok := <-fw.Errors:
+					if !ok {
+						return nil
+					}
+					toolutil.PrintWarning("Watcher error: %v", err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&dir, "dir", ".", "Directory to watch")
+	cmd.Flags().BoolVar(&recursive, "recursive", false, "Also watch subdirectories, including ones created later")
+	cmd.Flags().StringVar(&ops, "ops", "create,write,remove,rename", "Comma-separated operations to report: create, write, remove, rename, chmod or all")
+	cmd.Flags().StringVar(&glob, "glob", "", "Only report files whose base name matches this pattern (e.g. *.json)")
+	cmd.Flags().BoolVar(&includeHidden, "include-hidden", false, "Report dotfiles too, such as the temporary files of atomic writes")
+	cmd.Flags().BoolVar(&content, "content", false, "Print the contents of created and written files")
+	cmd.Flags().Int64Var(&maxContent, "max-content-bytes", 64*1024, "Maximum number of bytes printed with --content")
+	cmd.Flags().DurationVar(&debounce, "debounce", 0, "Coalesce events on the same path occurring within this duration (e.g. 100ms)")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// pendingEvent accumulates the operations on a path while debouncing.
+type pendingEvent struct {
+	op    fsnotify.Op
+	timer *time.Timer
+}
+
+// watcher filters, debounces and prints fsnotify events.
+type watcher struct {
+	fw            *fsnotify.Watcher
+	recursive     bool
+	ops           fsnotify.Op
+	glob          string
+	includeHidden bool
+	content       bool
+	maxContent    int64
+	debounce      time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingEvent
+	closed  bool
+}
+
+// add watches dir and, when recursive, every directory below it.
+func (w *watcher) add(dir string) error {
+	if !w.recursive {
+		return w.fw.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && !w.includeHidden && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.fw.Add(path)
+	})
+}
+
+func (w *watcher) handle(ev fsnotify.Event) {
+	if w.recursive && ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			if err := w.add(ev.Name); err != nil {
+				toolutil.PrintWarning("Failed to watch %s: %v", ev.Name, err)
+			}
+		}
+	}
+	op := ev.Op & w.ops
+	if op == 0 || !w.matches(ev.Name) {
+		return
+	}
+	if w.debounce <= 0 {
+		w.print(ev.Name, op)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if p, ok := w.pending[ev.Name]; ok {
+		p.op |= op
+		p.timer.Reset(w.debounce)
+		return
+	}
+	name := ev.Name
+	p := &pendingEvent{op: op}
+	p.timer = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		delete(w.pending, name)
+		op, closed := p.op, w.closed
+		w.mu.Unlock()
+		if !closed {
+			w.print(name, op)
+		}
+	})
+	w.pending[name] = p
+}
+
+// matches applies the hidden file and glob filters to the base name of path.
+func (w *watcher) matches(path string) bool {
+	base := filepath.Base(path)
+	if !w.includeHidden && strings.HasPrefix(base, ".") {
+		return false
+	}
+	if w.glob == "" {
+		return true
+	}
+	ok, _ := filepath.Match(w.glob, base)
+	return ok
+}
+
+// print reports an event with the current state of the file.
+func (w *watcher) print(path string, op fsnotify.Op) {
+	event := []toolutil.KV{
+		{Key: "Op", Value: opNames(op)},
+		{Key: "Path", Value: path},
+	}
+	var file []toolutil.KV
+	var body []byte
+	info, err := os.Stat(path)
+	if err == nil {
+		kind := "file"
+		if info.IsDir() {
+			kind = "directory"
+		}
+		file = []toolutil.KV{
+			{Key: "Type", Value: kind},
+			{Key: "Size", Value: strconv.FormatInt(info.Size(), 10)},
+			{Key: "Mode", Value: info.Mode().Perm().String()},
+			{Key: "Modified", Value: info.ModTime().Format(time.RFC3339Nano)},
+		}
+		if w.content && info.Mode().IsRegular() && op&(fsnotify.Create|fsnotify.Write) != 0 {
+			body, err = readHead(path, w.maxContent)
+			if err != nil {
+				toolutil.PrintWarning("Failed to read %s: %v", path, err)
+			}
+			if info.Size() > w.maxContent {
+				file = append(file, toolutil.KV{Key: "Truncated", Value: "true"})
+			}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		toolutil.PrintWarning("Failed to stat %s: %v", path, err)
+	}
+
+	sections := []toolutil.MessageSection{
+		{Title: "Event", Items: event},
+		{Title: "File", Items: file},
+	}
+	ct := toolutil.GuessMIME(body)
+	if byExt := mime.TypeByExtension(filepath.Ext(path)); byExt != "" && len(body) > 0 {
+		ct, _, _ = strings.Cut(byExt, ";")
+	}
+	toolutil.PrintColoredMessage("FS", sections, body, ct)
+}
+
+// readHead reads at most n bytes of the file at path.
+func readHead(path string, n int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+	return io.ReadAll(io.LimitReader(f, n))
+}
+
+// close stops pending debounce timers and the underlying watcher.
+func (w *watcher) close() {
+	w.mu.Lock()
+	w.closed = true
+	for _, p := range w.pending {
+		p.timer.Stop()
+	}
+	w.mu.Unlock()
+	w.fw.Close() //nolint:errcheck
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 
+	"github.com/sandrolain/eventkit/pkg/toolutil"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +14,7 @@ func main() {
 		Long:  "A simple Git CLI with only a send command that commits and pushes periodically.",
 	}
 
+	toolutil.AddGlobalFlags(root)
 	root.AddCommand(sendCommand())
 
 	if err := root.Execute(); err != nil {
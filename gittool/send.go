@@ -30,18 +30,31 @@ func sendCommand() *cobra.Command {
 		username       string
 		password       string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		once           bool
+		printPayload   bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "send",
 		Short: "Periodically commit and push to a git repo",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if remote == "" {
+			if remote == "" && !printPayload {
 				return fmt.Errorf("--remote is required")
 			}
 			if _, err := time.ParseDuration(interval); err != nil {
@@ -50,15 +63,48 @@ func sendCommand() *cobra.Command {
 			if seed != 0 {
 				testpayload.SeedRandom(seed)
 			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
 			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &payload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
 			// set file cache enabled
 			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&interval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
 			testpayload.SetFileRoot(fileRoot)
 			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
 			if errVars != nil {
 				return fmt.Errorf("invalid template-var: %w", errVars)
 			}
 			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(payload, mime, "{{", "}}")
+			}
 			return runGitSend(remote, branch, interval, filename, payload, mime, commitMessage, username, password, once)
 		},
 	}
@@ -67,16 +113,29 @@ func sendCommand() *cobra.Command {
 	cmd.Flags().StringVar(&branch, "branch", "main", "Branch to commit to")
 	cmd.Flags().StringVar(&interval, "interval", "10s", "Interval between commits (e.g. 10s, 1m)")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
 	cmd.Flags().StringVar(&filename, "filename", "data.txt", "File to update in the repo")
 	toolutil.AddPayloadFlags(cmd, &payload, "Automated update at {nowtime}", &mime, toolutil.CTText)
 	cmd.Flags().StringVar(&commitMessage, "message", "Automated commit", "Commit message")
 	cmd.Flags().StringVar(&username, "username", "", "Username for remote repository (optional)")
 	cmd.Flags().StringVar(&password, "password", "", "Password or token for remote repository (optional)")
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
@@ -119,7 +178,7 @@ func cloneOrInitRepo(tmpDir, remote, branch, username, password string) (*git.Re
 
 	cloneOpts := &git.CloneOptions{
 		URL:           remote,
-		Progress:      os.Stdout,
+		Progress:      os.Stderr,
 		SingleBranch:  true,
 		ReferenceName: plumbing.NewBranchReferenceName(branch),
 	}
@@ -150,7 +209,7 @@ func cloneOrInitRepo(tmpDir, remote, branch, username, password string) (*git.Re
 
 	if err.Error() == "couldn't find remote ref \"refs/heads/"+branch+"\"" {
 		logger.Info("Remote branch not found, cloning default branch and creating it locally", "branch", branch)
-		cloneOpts2 := &git.CloneOptions{URL: remote, Progress: os.Stdout}
+		cloneOpts2 := &git.CloneOptions{URL: remote, Progress: os.Stderr}
 		if username != "" && password != "" {
 			cloneOpts2.Auth = &http.BasicAuth{Username: username, Password: password}
 		}
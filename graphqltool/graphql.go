@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// request is a GraphQL operation as sent over HTTP and in subscription messages.
+type request struct {
+	Query         string          `json:"query"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	OperationName string          `json:"operationName,omitempty"`
+}
+
+// gqlError is an entry of the errors array of a GraphQL response.
+type gqlError struct {
+	Message string `json:"message"`
+}
+
+// response is a GraphQL execution result.
+type response struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Errors []gqlError      `json:"errors,omitempty"`
+}
+
+// errorMessages joins the messages of errs.
+func errorMessages(errs []gqlError) string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Subscription protocols.
+const (
+	protoTransportWS = "graphql-transport-ws" // graphql-ws library, the current standard
+	protoLegacyWS    = "graphql-ws"           // subscriptions-transport-ws (Apollo), deprecated
+)
+
+// protocol holds the message types that differ between the two subscription protocols.
+type protocol struct {
+	name      string
+	subscribe string // client starts an operation
+	next      string // server sends a result
+	stop      string // client stops an operation
+}
+
+var protocols = map[string]protocol{
+	protoTransportWS: {name: protoTransportWS, subscribe: "subscribe", next: "next", stop: "complete"},
+	protoLegacyWS:    {name: protoLegacyWS, subscribe: "start", next: "data", stop: "stop"},
+}
+
+// wsMessage is a message of either subscription protocol.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// event is a message delivered to a subscription: a result, an error or the completion.
+type event struct {
+	Type    string // "next", "error" or "complete"
+	Payload json.RawMessage
+}
+
+// subscriber is a WebSocket connection running a single subscription.
+type subscriber struct {
+	conn  *websocket.Conn
+	proto protocol
+	wmu   sync.Mutex
+}
+
+// dialSubscriber connects to url, negotiates the protocol and completes the connection_init
+// handshake with initPayload.
+func dialSubscriber(ctx context.Context, url string, proto protocol, header http.Header, initPayload json.RawMessage) (*subscriber, error) {
+	dialer := websocket.Dialer{Proxy: http.ProxyFromEnvironment, Subprotocols: []string{proto.name}}
+	conn, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("%w (HTTP %s)", err, resp.Status)
+		}
+		return nil, err
+	}
+	if conn.Subprotocol() != proto.name {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("server did not accept the %s subprotocol", proto.name)
+	}
+	s := &subscriber{conn: conn, proto: proto}
+
+	// Unblock the handshake reads when ctx ends.
+	stop := context.AfterFunc(ctx, func() { conn.Close() }) //nolint:errcheck
+	defer stop()
+	if err := s.write(wsMessage{Type: "connection_init", Payload: initPayload}); err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			conn.Close() //nolint:errcheck
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("handshake failed: %w", err)
+		}
+		switch msg.Type {
+		case "connection_ack":
+			return s, nil
+		case "connection_error":
+			conn.Close() //nolint:errcheck
+			return nil, fmt.Errorf("connection rejected: %s", msg.Payload)
+		case "ka", "ping", "pong":
+			if msg.Type == "ping" {
+				if err := s.write(wsMessage{Type: "pong"}); err != nil {
+					conn.Close() //nolint:errcheck
+					return nil, err
+				}
+			}
+		default:
+			conn.Close() //nolint:errcheck
+			return nil, fmt.Errorf("unexpected %q message during handshake", msg.Type)
+		}
+	}
+}
+
+func (s *subscriber) write(msg wsMessage) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+	return s.conn.WriteJSON(msg)
+}
+
+// Subscribe starts the operation req with the given id.
+func (s *subscriber) Subscribe(id string, req request) error {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return s.write(wsMessage{ID: id, Type: s.proto.subscribe, Payload: payload})
+}
+
+// Next returns the next event of the operation id, answering pings and skipping keep-alives.
+func (s *subscriber) Next(id string) (event, error) {
+	for {
+		var msg wsMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return event{}, err
+		}
+		switch msg.Type {
+		case "ping":
+			if err := s.write(wsMessage{Type: "pong", Payload: msg.Payload}); err != nil {
+				return event{}, err
+			}
+			continue
+		case "pong", "ka":
+			continue
+		case "connection_error":
+			return event{}, fmt.Errorf("connection error: %s", msg.Payload)
+		}
+		if msg.ID != id {
+			continue
+		}
+		switch msg.Type {
+		case s.proto.next:
+			return event{Type: "next", Payload: msg.Payload}, nil
+		case "error":
+			return event{Type: "error", Payload: msg.Payload}, nil
+		case "complete":
+			return event{Type: "complete"}, nil
+		}
+	}
+}
+
+// Close stops the operation id, says goodbye and closes the connection.
+func (s *subscriber) Close(id string) error {
+	_ = s.write(wsMessage{ID: id, Type: s.proto.stop})
+	if s.proto.name == protoLegacyWS {
+		_ = s.write(wsMessage{Type: "connection_terminate"})
+	}
+	s.wmu.Lock()
+	_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	s.wmu.Unlock()
+	return s.conn.Close()
+}
+
+// subscriptionErrors extracts the message of an error event; graphql-transport-ws sends an
+// array of errors, graphql-ws a single error object.
+func subscriptionErrors(payload json.RawMessage) error {
+	var errs []gqlError
+	if json.Unmarshal(payload, &errs) == nil && len(errs) > 0 {
+		return errors.New(errorMessages(errs))
+	}
+	var single gqlError
+	if json.Unmarshal(payload, &single) == nil && single.Message != "" {
+		return errors.New(single.Message)
+	}
+	return fmt.Errorf("%s", payload)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "graphqltool",
+		Short: "GraphQL mutation sender and subscription client",
+		Long:  "A simple GraphQL CLI that executes templated mutations over HTTP and prints the events of a subscription over graphql-transport-ws or graphql-ws.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), subscribeCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// documentFlags adds --query, --query-file and --operation-name.
+func documentFlags(cmd *cobra.Command, query, queryFile, operationName *string) {
+	cmd.Flags().StringVar(query, "query", "", "GraphQL document to execute")
+	cmd.Flags().StringVar(queryFile, "query-file", "", "Read the GraphQL document from this file (exclusive with --query)")
+	cmd.Flags().StringVar(operationName, "operation-name", "", "Operation to execute when the document defines several")
+}
+
+// loadDocument returns the document given with --query or --query-file.
+func loadDocument(query, queryFile string) (string, error) {
+	switch {
+	case query != "" && queryFile != "":
+		return "", fmt.Errorf("--query and --query-file are mutually exclusive")
+	case queryFile != "":
+		b, err := os.ReadFile(queryFile)
+		if err != nil {
+			return "", fmt.Errorf("error reading --query-file: %w", err)
+		}
+		return string(b), nil
+	case query == "":
+		return "", fmt.Errorf("--query or --query-file is required")
+	}
+	return query, nil
+}
+
+// jsonObject validates that data is a JSON object (or empty) and returns it for embedding.
+func jsonObject(name string, data []byte) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON object: %w", name, err)
+	}
+	return json.RawMessage(data), nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestLoadDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "op.graphql")
+	if err := os.WriteFile(path, []byte("subscription { ticks }"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err := loadDocument("", path); err != nil || doc != "subscription { ticks }" {
+		t.Errorf("loadDocument(file) = %q, %v", doc, err)
+	}
+	if doc, err := loadDocument("{ a }", ""); err != nil || doc != "{ a }" {
+		t.Errorf("loadDocument(query) = %q, %v", doc, err)
+	}
+	for _, c := range [][2]string{{"", ""}, {"{ a }", path}} {
+		if _, err := loadDocument(c[0], c[1]); err == nil {
+			t.Errorf("loadDocument(%q, %q) succeeded, want error", c[0], c[1])
+		}
+	}
+}
+
+func TestJSONObject(t *testing.T) {
+	if v, err := jsonObject("x", nil); err != nil || v != nil {
+		t.Errorf("jsonObject(empty) = %s, %v", v, err)
+	}
+	if v, err := jsonObject("x", []byte(`{"a":1}`)); err != nil || string(v) != `{"a":1}` {
+		t.Errorf("jsonObject(object) = %s, %v", v, err)
+	}
+	for _, in := range []string{`[1]`, `"s"`, `{`} {
+		if _, err := jsonObject("x", []byte(in)); err == nil {
+			t.Errorf("jsonObject(%s) succeeded, want error", in)
+		}
+	}
+}
+
+func TestExecute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unauthorized"}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"op":"` + req.OperationName + `","vars":` + string(req.Variables) + `}}`))
+	}))
+	defer srv.Close()
+
+	req := request{Query: "mutation Add($n: Int) { add(n: $n) }", Variables: json.RawMessage(`{"n":1}`), OperationName: "Add"}
+	res, err := execute(context.Background(), srv.Client(), srv.URL, map[string]string{"Authorization": "Bearer t"}, req)
+	if err != nil || string(res.Data) != `{"op":"Add","vars":{"n":1}}` {
+		t.Errorf("execute() = %s, %v", res.Data, err)
+	}
+	if _, err := execute(context.Background(), srv.Client(), srv.URL, nil, req); err == nil || err.Error() != "unauthorized" {
+		t.Errorf("execute(no auth) error = %v, want unauthorized", err)
+	}
+}
+
+// fakeSubscriptionServer speaks both subscription protocols: it acknowledges the connection,
+// pings (graphql-transport-ws only), sends two results for the operation and completes it.
+func fakeSubscriptionServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{Subprotocols: []string{protoTransportWS, protoLegacyWS}}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck
+		proto := protocols[conn.Subprotocol()]
+
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != "connection_init" || string(msg.Payload) != `{"token":"x"}` {
+			_ = conn.WriteJSON(wsMessage{Type: "connection_error", Payload: json.RawMessage(`{"message":"bad init"}`)})
+			return
+		}
+		_ = conn.WriteJSON(wsMessage{Type: "connection_ack"})
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != proto.subscribe {
+			t.Errorf("expected %s, got %+v, %v", proto.subscribe, msg, err)
+			return
+		}
+		var req request
+		_ = json.Unmarshal(msg.Payload, &req)
+		if !strings.HasPrefix(req.Query, "subscription") {
+			t.Errorf("query = %q", req.Query)
+		}
+		id := msg.ID
+		if proto.name == protoTransportWS {
+			_ = conn.WriteJSON(wsMessage{Type: "ping"})
+		} else {
+			_ = conn.WriteJSON(wsMessage{Type: "ka"})
+		}
+		_ = conn.WriteJSON(wsMessage{ID: id, Type: proto.next, Payload: json.RawMessage(`{"data":{"tick":1}}`)})
+		_ = conn.WriteJSON(wsMessage{ID: "other", Type: proto.next, Payload: json.RawMessage(`{"data":{"tick":99}}`)})
+		_ = conn.WriteJSON(wsMessage{ID: id, Type: proto.next, Payload: json.RawMessage(`{"data":null,"errors":[{"message":"boom"}]}`)})
+		_ = conn.WriteJSON(wsMessage{ID: id, Type: "complete"})
+
+		// Drain the client messages until it closes, checking the ping was answered.
+		ponged := false
+		for conn.ReadJSON(&msg) == nil {
+			ponged = ponged || msg.Type == "pong"
+		}
+		if proto.name == protoTransportWS && !ponged {
+			t.Error("ping was not answered with a pong")
+		}
+	}))
+}
+
+func TestSubscriber(t *testing.T) {
+	srv := fakeSubscriptionServer(t)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	for _, name := range []string{protoTransportWS, protoLegacyWS} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			sub, err := dialSubscriber(ctx, url, protocols[name], nil, json.RawMessage(`{"token":"x"}`))
+			if err != nil {
+				t.Fatalf("dialSubscriber() error: %v", err)
+			}
+			defer sub.Close(subscriptionID) //nolint:errcheck
+			if err := sub.Subscribe(subscriptionID, request{Query: "subscription { tick }"}); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for {
+				ev, err := sub.Next(subscriptionID)
+				if err != nil {
+					t.Fatalf("Next() error: %v", err)
+				}
+				got = append(got, ev.Type+" "+string(ev.Payload))
+				if ev.Type == "complete" {
+					break
+				}
+			}
+			want := []string{`next {"data":{"tick":1}}`, `next {"data":null,"errors":[{"message":"boom"}]}`, "complete "}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("events = %q, want %q", got, want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := dialSubscriber(ctx, url, protocols[protoTransportWS], nil, nil); err == nil || !strings.Contains(err.Error(), "bad init") {
+		t.Errorf("dialSubscriber(bad init) error = %v", err)
+	}
+}
+
+func TestDecodeResult(t *testing.T) {
+	res, ok := decodeResult([]byte(`{"data":null,"errors":[{"message":"boom"}]}`))
+	if !ok || res.Data != nil || errorMessages(res.Errors) != "boom" {
+		t.Errorf("decodeResult() = %+v, %v", res, ok)
+	}
+	if _, ok := decodeResult([]byte(`{"tick":1}`)); ok {
+		t.Error("decodeResult() accepted a payload without data or errors")
+	}
+	if err := subscriptionErrors([]byte(`[{"message":"a"},{"message":"b"}]`)); err == nil || err.Error() != "a; b" {
+		t.Errorf("subscriptionErrors(array) = %v", err)
+	}
+	if err := subscriptionErrors([]byte(`{"message":"legacy"}`)); err == nil || err.Error() != "legacy" {
+		t.Errorf("subscriptionErrors(object) = %v", err)
+	}
+}
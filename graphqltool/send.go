@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxResponseSize bounds the GraphQL responses read by send.
+const maxResponseSize = 10 << 20
+
+// execute posts req to url and decodes the GraphQL response. A response with errors is
+// returned together with an error describing them.
+func execute(ctx context.Context, client *http.Client, url string, headers map[string]string, req request) (response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return response{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return response{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/graphql-response+json, application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return response{}, err
+	}
+
+	var res response
+	if err := json.Unmarshal(data, &res); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return response{}, fmt.Errorf("HTTP %s", resp.Status)
+		}
+		return response{}, fmt.Errorf("invalid GraphQL response: %w", err)
+	}
+	if len(res.Errors) > 0 {
+		return res, errors.New(errorMessages(res.Errors))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return res, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return res, nil
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		url            string
+		query          string
+		queryFile      string
+		operationName  string
+		requestTimeout time.Duration
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Execute periodic GraphQL mutations over HTTP",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			document, err := loadDocument(query, queryFile)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			client := &http.Client{
+				Timeout:   requestTimeout,
+				Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: (&net.Dialer{Timeout: connectTimeout}).DialContext},
+			}
+
+			toolutil.PrintSuccess("Starting GraphQL client")
+			toolutil.PrintKeyValue("URL", url)
+			if operationName != "" {
+				toolutil.PrintKeyValue("Operation", operationName)
+			}
+
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				variables, err := jsonObject("variables payload", body)
+				if err != nil {
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				res, err := execute(ctx, client, url, headerMap, request{Query: document, Variables: variables, OperationName: operationName})
+				if err != nil {
+					toolutil.PrintError("GraphQL error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Executed with %d bytes of variables: %s", len(body), res.Data)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", "http://localhost:4000/graphql", "GraphQL HTTP endpoint")
+	documentFlags(cmd, &query, &queryFile, &operationName)
+	cmd.Flags().DurationVar(&requestTimeout, "request-timeout", 10*time.Second, "Timeout of each request")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"input":{"id":"{{counter}}","text":"{{sentence}}"}}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
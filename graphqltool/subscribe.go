@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// subscriptionID identifies the single operation run on the connection.
+const subscriptionID = "1"
+
+func subscribeCommand() *cobra.Command {
+	var (
+		url            string
+		protoName      string
+		query          string
+		queryFile      string
+		operationName  string
+		variables      string
+		initPayload    string
+		headers        []string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:     "subscribe",
+		Aliases: []string{"serve"},
+		Short:   "Run a GraphQL subscription and log its events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			proto, ok := protocols[protoName]
+			if !ok {
+				return fmt.Errorf("invalid --protocol %q: expected %s or %s", protoName, protoTransportWS, protoLegacyWS)
+			}
+			document, err := loadDocument(query, queryFile)
+			if err != nil {
+				return err
+			}
+			vars, err := jsonObject("--variables", []byte(variables))
+			if err != nil {
+				return err
+			}
+			init, err := jsonObject("--init-payload", []byte(initPayload))
+			if err != nil {
+				return err
+			}
+			headerMap, err := toolutil.ParseHeaders(headers)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			header := http.Header{}
+			for k, v := range headerMap {
+				header.Set(k, v)
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var sub *subscriber
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				s, err := dialSubscriber(ctx, url, proto, header, init)
+				if err != nil {
+					return err
+				}
+				sub = s
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to GraphQL endpoint: %w", err)
+			}
+			defer sub.Close(subscriptionID) //nolint:errcheck
+
+			if err := sub.Subscribe(subscriptionID, request{Query: document, Variables: vars, OperationName: operationName}); err != nil {
+				return fmt.Errorf("error starting subscription: %w", err)
+			}
+
+			toolutil.PrintSuccess("Subscribed to GraphQL endpoint")
+			toolutil.PrintKeyValue("URL", url)
+			toolutil.PrintKeyValue("Protocol", proto.name)
+			if operationName != "" {
+				toolutil.PrintKeyValue("Operation", operationName)
+			}
+
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- receive(sub, operationName)
+			}()
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			case err := <-errChan:
+				if err == nil {
+					toolutil.PrintInfo("Subscription completed by the server")
+				}
+				return err
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", "ws://localhost:4000/graphql", "GraphQL WebSocket endpoint (ws:// or wss://)")
+	cmd.Flags().StringVar(&protoName, "protocol", protoTransportWS, "Subscription protocol: "+protoTransportWS+" or "+protoLegacyWS+" (legacy subscriptions-transport-ws)")
+	documentFlags(cmd, &query, &queryFile, &operationName)
+	cmd.Flags().StringVar(&variables, "variables", "", "Operation variables as a JSON object")
+	cmd.Flags().StringVar(&initPayload, "init-payload", "", "connection_init payload as a JSON object, e.g. {\"authToken\":\"...\"}")
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// receive prints the events of the subscription until it completes (nil) or fails.
+func receive(sub *subscriber, operationName string) error {
+	for i := 1; ; i++ {
+		ev, err := sub.Next(subscriptionID)
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || errors.Is(err, websocket.ErrCloseSent) {
+				return errors.New("connection closed by server")
+			}
+			return err
+		}
+		switch ev.Type {
+		case "complete":
+			return nil
+		case "error":
+			return fmt.Errorf("subscription error: %w", subscriptionErrors(ev.Payload))
+		}
+		printEvent(i, operationName, ev)
+	}
+}
+
+// printEvent prints a subscription result; errors reported alongside data get their own section.
+func printEvent(index int, operationName string, ev event) {
+	sub := []toolutil.KV{{Key: "ID", Value: subscriptionID}}
+	if operationName != "" {
+		sub = append(sub, toolutil.KV{Key: "Operation", Value: operationName})
+	}
+	sub = append(sub, toolutil.KV{Key: "Event", Value: strconv.Itoa(index)})
+
+	body := []byte(ev.Payload)
+	var errItems []toolutil.KV
+	if res, ok := decodeResult(ev.Payload); ok {
+		if len(res.Data) > 0 {
+			body = res.Data
+		}
+		for i, e := range res.Errors {
+			errItems = append(errItems, toolutil.KV{Key: strconv.Itoa(i + 1), Value: e.Message})
+		}
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Subscription", Items: sub},
+		{Title: fmt.Sprintf("Errors (%d)", len(errItems)), Items: errItems},
+	}
+	toolutil.PrintColoredMessage("GraphQL", sections, body, toolutil.CTJSON)
+}
+
+// decodeResult decodes an execution result, reporting whether payload is one. A null data
+// field, sent alongside errors, is treated as absent.
+func decodeResult(payload []byte) (response, bool) {
+	var res response
+	if err := json.Unmarshal(payload, &res); err != nil {
+		return res, false
+	}
+	if string(res.Data) == "null" {
+		res.Data = nil
+	}
+	return res, len(res.Data) > 0 || len(res.Errors) > 0
+}
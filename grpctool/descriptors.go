@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// parseMethodName splits "pkg.Service/Method", "/pkg.Service/Method" or "pkg.Service.Method"
+// into the fully-qualified service name and the method name.
+func parseMethodName(name string) (service, method string, err error) {
+	name = strings.TrimPrefix(name, "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		i = strings.LastIndex(name, ".")
+	}
+	if i <= 0 || i == len(name)-1 {
+		return "", "", fmt.Errorf("invalid method %q: expected package.Service/Method", name)
+	}
+	return name[:i], name[i+1:], nil
+}
+
+// findMethod looks up the descriptor of service/method in files.
+func findMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %q not found: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method %q not found in service %q", method, service)
+	}
+	return md, nil
+}
+
+// reflectFiles fetches the file defining symbol, plus all its transitive dependencies,
+// through the server reflection service.
+func reflectFiles(ctx context.Context, conn grpc.ClientConnInterface, symbol string) (*protoregistry.Files, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection unavailable: %w", err)
+	}
+	defer stream.CloseSend() //nolint:errcheck
+
+	fetched := map[string]*descriptorpb.FileDescriptorProto{}
+	request := func(req *rpb.ServerReflectionRequest) error {
+		if err := stream.Send(req); err != nil {
+			return fmt.Errorf("reflection request failed: %w", err)
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("reflection request failed: %w", err)
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return fmt.Errorf("reflection error: %s", e.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("invalid file descriptor from reflection: %w", err)
+			}
+			fetched[fd.GetName()] = fd
+		}
+		return nil
+	}
+
+	if err := request(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}); err != nil {
+		return nil, err
+	}
+	// Servers may omit dependencies they already sent or consider well-known; fetch what's missing.
+	for {
+		missing := ""
+		for _, fd := range fetched {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := fetched[dep]; !ok {
+					missing = dep
+					break
+				}
+			}
+			if missing != "" {
+				break
+			}
+		}
+		if missing == "" {
+			break
+		}
+		if err := request(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}); err != nil {
+			return nil, err
+		}
+		if _, ok := fetched[missing]; !ok {
+			return nil, fmt.Errorf("reflection did not return dependency %q", missing)
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fetched {
+		set.File = append(set.File, fd)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from reflection: %w", err)
+	}
+	return files, nil
+}
+
+// rawCodec passes gRPC messages through as raw protobuf bytes, letting the generic
+// server handle any method without compiled message types.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("rawCodec: unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("rawCodec: unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name reports "proto" so clients sending application/grpc+proto are accepted.
+func (rawCodec) Name() string { return "proto" }
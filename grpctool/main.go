@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "grpctool",
+		Short: "gRPC client/server tester",
+		Long:  "A simple gRPC CLI with send (dynamic calls via server reflection or descriptor sets) and serve (generic logging/echo service) commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
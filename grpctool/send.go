@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// callTimeout bounds unary calls; server-streaming calls run until the stream ends.
+const callTimeout = 10 * time.Second
+
+func sendCommand() *cobra.Command {
+	var (
+		address        string
+		method         string
+		protoset       string
+		useTLS         bool
+		payload        string
+		mime           string
+		interval       string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		payloadSchema  string
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Invoke a unary or server-streaming gRPC method with JSON payloads",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &payload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&interval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(payload, mime, openDelim, closeDelim)
+			}
+
+			service, methodName, err := parseMethodName(method)
+			if err != nil {
+				return err
+			}
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+
+			creds := insecure.NewCredentials()
+			if useTLS {
+				creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+			}
+			conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+			if err != nil {
+				return fmt.Errorf("invalid gRPC target: %w", err)
+			}
+			defer func() {
+				if err := conn.Close(); err != nil {
+					toolutil.PrintError("Failed to close gRPC connection: %v", err)
+				}
+			}()
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					return waitReady(ctx, conn)
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to gRPC server: %w", err)
+			}
+
+			var files *protoregistry.Files
+			if protoset != "" {
+				files, err = testpayload.ReadProtoset(protoset)
+			} else {
+				files, err = reflectFiles(ctx, conn, service)
+			}
+			if err != nil {
+				return err
+			}
+			testpayload.SetProtoFiles(files)
+			md, err := findMethod(files, service, methodName)
+			if err != nil {
+				return err
+			}
+			if md.IsStreamingClient() {
+				return fmt.Errorf("method %s is client-streaming, only unary and server-streaming methods are supported", md.FullName())
+			}
+			fullMethod := fmt.Sprintf("/%s/%s", md.Parent().FullName(), md.Name())
+
+			toolutil.PrintSuccess("Connected to gRPC server")
+			toolutil.PrintKeyValue("Address", address)
+			toolutil.PrintKeyValue("Method", fullMethod)
+			toolutil.PrintKeyValue("Server Streaming", md.IsStreamingServer())
+
+			call := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(payload, mime, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				req := dynamicpb.NewMessage(md.Input())
+				if err := protojson.Unmarshal(body, req); err != nil {
+					toolutil.PrintError("Payload does not match %s: %v", md.Input().FullName(), err)
+					return err
+				}
+				callCtx := metadata.NewOutgoingContext(ctx, metadata.New(headerMap))
+				if md.IsStreamingServer() {
+					return callServerStream(callCtx, conn, md, fullMethod, req)
+				}
+				return callUnary(callCtx, conn, md, fullMethod, req)
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &payload, call)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, interval, call)
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "address", "localhost:50051", "gRPC server address (host:port)")
+	cmd.Flags().StringVar(&method, "method", "", "Method to invoke, e.g. package.Service/Method")
+	cmd.Flags().StringVar(&protoset, "protoset", "", "Binary FileDescriptorSet describing the service (default: use server reflection)")
+	cmd.Flags().BoolVar(&useTLS, "tls", false, "Use TLS (system roots) instead of a plaintext connection")
+	_ = cmd.MarkFlagRequired("method")
+	toolutil.AddPayloadFlags(cmd, &payload, "{}", &mime, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &interval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+
+	return cmd
+}
+
+// waitReady starts connecting and blocks until conn is ready or ctx ends.
+func waitReady(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection not ready (state %s): %w", state, ctx.Err())
+		}
+	}
+}
+
+func callUnary(ctx context.Context, conn *grpc.ClientConn, md protoreflect.MethodDescriptor, fullMethod string, req *dynamicpb.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	resp := dynamicpb.NewMessage(md.Output())
+	var header metadata.MD
+	start := time.Now()
+	if err := conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header)); err != nil {
+		toolutil.PrintError("Call failed: %v", err)
+		return err
+	}
+	printResponse(fullMethod, header, resp, time.Since(start))
+	return nil
+}
+
+func callServerStream(ctx context.Context, conn *grpc.ClientConn, md protoreflect.MethodDescriptor, fullMethod string, req *dynamicpb.Message) error {
+	start := time.Now()
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, fullMethod)
+	if err != nil {
+		toolutil.PrintError("Call failed: %v", err)
+		return err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		toolutil.PrintError("Send failed: %v", err)
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		toolutil.PrintError("Close send failed: %v", err)
+		return err
+	}
+	header, _ := stream.Header()
+	count := 0
+	for {
+		resp := dynamicpb.NewMessage(md.Output())
+		if err := stream.RecvMsg(resp); err != nil {
+			if errors.Is(err, io.EOF) {
+				toolutil.PrintInfo("Stream ended after %d messages in %s", count, time.Since(start).Round(time.Millisecond))
+				return nil
+			}
+			toolutil.PrintError("Stream error: %v", err)
+			return err
+		}
+		count++
+		printResponse(fullMethod, header, resp, time.Since(start))
+	}
+}
+
+func printResponse(fullMethod string, header metadata.MD, resp *dynamicpb.Message, elapsed time.Duration) {
+	body, err := protojson.Marshal(resp)
+	if err != nil {
+		toolutil.PrintError("Failed to encode response: %v", err)
+		return
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Method", Items: []toolutil.KV{
+			{Key: "Name", Value: fullMethod},
+			{Key: "Elapsed", Value: elapsed.Round(time.Microsecond).String()},
+		}},
+		toolutil.HeadersSection(metadataItems(header)),
+	}
+	toolutil.PrintColoredMessage("gRPC Response", sections, body, toolutil.CTJSON)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestParseMethodName(t *testing.T) {
+	tests := []struct {
+		in      string
+		service string
+		method  string
+		wantErr bool
+	}{
+		{"grpc.health.v1.Health/Check", "grpc.health.v1.Health", "Check", false},
+		{"/grpc.health.v1.Health/Check", "grpc.health.v1.Health", "Check", false},
+		{"grpc.health.v1.Health.Check", "grpc.health.v1.Health", "Check", false},
+		{"Check", "", "", true},
+		{"grpc.health.v1.Health/", "", "", true},
+	}
+	for _, tt := range tests {
+		service, method, err := parseMethodName(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMethodName(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if service != tt.service || method != tt.method {
+			t.Errorf("parseMethodName(%q) = %q, %q; want %q, %q", tt.in, service, method, tt.service, tt.method)
+		}
+	}
+}
+
+func TestSendCommand_Reflection(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+
+	for method, payload := range map[string]string{
+		"grpc.health.v1.Health/Check": `{"service": ""}`,
+		"grpc.health.v1.Health/List":  `{}`,
+	} {
+		cmd := sendCommand()
+		cmd.SetArgs([]string{"--address", lis.Addr().String(), "--method", method, "--once", "--payload", payload})
+		if err := cmd.Execute(); err != nil {
+			t.Errorf("send %s error = %v", method, err)
+		}
+	}
+
+	cmd := sendCommand()
+	cmd.SetArgs([]string{"--address", lis.Addr().String(), "--method", "grpc.health.v1.Health/Missing", "--once"})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for unknown method")
+	}
+}
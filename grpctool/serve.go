@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr string
+		protoset  string
+		echo      bool
+		serveOpts toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a generic gRPC server that logs (and optionally echoes) every call",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var files *protoregistry.Files
+			if protoset != "" {
+				if files, err = testpayload.ReadProtoset(protoset); err != nil {
+					return err
+				}
+			}
+
+			lis, err := net.Listen("tcp", serveAddr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+			}
+			srv := grpc.NewServer(
+				grpc.ForceServerCodec(rawCodec{}),
+				grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+					return handleCall(stream, files, echo)
+				}),
+			)
+
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- srv.Serve(lis)
+			}()
+
+			toolutil.PrintSuccess("gRPC server listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Echo", echo)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				srv.GracefulStop()
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving gRPC: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:50051", "gRPC listen address")
+	cmd.Flags().StringVar(&protoset, "protoset", "", "Binary FileDescriptorSet used to decode request messages as JSON (default: print raw bytes)")
+	cmd.Flags().BoolVar(&echo, "echo", false, "Reply to every request message with the same bytes (for methods whose request and response types match)")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// handleCall logs every request message of any method. Without --echo it replies with a
+// single empty message, which decodes as the default value of any response type.
+func handleCall(stream grpc.ServerStream, files *protoregistry.Files, echo bool) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	remote := ""
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		remote = p.Addr.String()
+	}
+
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		body, ct := decodeRequest(files, fullMethod, msg)
+		sections := []toolutil.MessageSection{
+			{Title: "Method", Items: []toolutil.KV{{Key: "Name", Value: fullMethod}}},
+			{Title: "Remote", Items: []toolutil.KV{{Key: "Addr", Value: remote}}},
+			toolutil.HeadersSection(metadataItems(md)),
+		}
+		toolutil.PrintColoredMessage("gRPC", sections, body, ct)
+		if echo {
+			if err := stream.SendMsg(&msg); err != nil {
+				return err
+			}
+		}
+	}
+	if !echo {
+		empty := []byte{}
+		return stream.SendMsg(&empty)
+	}
+	return nil
+}
+
+// decodeRequest renders msg as JSON when files describes fullMethod, otherwise returns it raw.
+func decodeRequest(files *protoregistry.Files, fullMethod string, msg []byte) ([]byte, string) {
+	raw := func() ([]byte, string) { return msg, "application/octet-stream" }
+	if files == nil {
+		return raw()
+	}
+	service, method, err := parseMethodName(fullMethod)
+	if err != nil {
+		return raw()
+	}
+	md, err := findMethod(files, service, method)
+	if err != nil {
+		return raw()
+	}
+	req := dynamicpb.NewMessage(md.Input())
+	if err := proto.Unmarshal(msg, req); err != nil {
+		return raw()
+	}
+	b, err := protojson.Marshal(req)
+	if err != nil {
+		return raw()
+	}
+	return b, toolutil.CTJSON
+}
+
+// metadataItems converts gRPC metadata to sorted key/value items.
+func metadataItems(md metadata.MD) []toolutil.KV {
+	var items []toolutil.KV
+	for k, v := range md {
+		items = append(items, toolutil.KV{Key: k, Value: strings.Join(v, ", ")})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 
+	"github.com/sandrolain/eventkit/pkg/toolutil"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +14,7 @@ func main() {
 		Long:  "A simple HTTP client/server CLI with send and serve commands.",
 	}
 
+	toolutil.AddGlobalFlags(root)
 	root.AddCommand(sendCommand(), serveCommand())
 
 	if err := root.Execute(); err != nil {
@@ -2,11 +2,15 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
+	neturl "net/url"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/sandrolain/eventkit/pkg/common"
 	"github.com/sandrolain/eventkit/pkg/testpayload"
@@ -24,16 +28,33 @@ func sendCommand() *cobra.Command {
 		interval       string
 		mime           string
 		headers        []string
+		noHeaderBase64 bool
 		openDelim      string
 		closeDelim     string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		files          []string
 		formFields     []string
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
 	)
 
 	cmd := &cobra.Command{
@@ -52,39 +73,102 @@ func sendCommand() *cobra.Command {
 			if seed != 0 {
 				testpayload.SeedRandom(seed)
 			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
 			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &payload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
 			testpayload.SetFileRoot(fileRoot)
 			// set cache enable
 			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&interval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply("ce-"); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
 			// parse template vars
 			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
 			if errVars != nil {
 				return fmt.Errorf("invalid template-var: %w", errVars)
 			}
 			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(payload, mime, openDelim, closeDelim)
+			}
 
 			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
 			if err != nil {
 				return fmt.Errorf("invalid headers: %w", err)
 			}
 
-			sendRequest := func() {
+			dial := func(addr string) (net.Conn, error) {
+				if connectTimeout <= 0 {
+					return fasthttp.Dial(addr)
+				}
+				return fasthttp.DialTimeout(addr, connectTimeout)
+			}
+
+			// Requests dial their own connection, so with --wait-for-broker the server is
+			// dialed until it accepts connections before the first one.
+			if connectRetry.WaitForBroker {
+				addr, err := serverAddr(address)
+				if err != nil {
+					return err
+				}
+				if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), func() error {
+					conn, err := dial(addr)
+					if err != nil {
+						return err
+					}
+					return conn.Close()
+				}); err != nil {
+					return fmt.Errorf("error connecting to HTTP server: %w", err)
+				}
+			}
+
+			sendRequest := func() error {
 				var reqBody []byte
 				var contentType string
 				var err error
 
 				// Check if we need to use multipart/form-data
 				if len(files) > 0 || len(formFields) > 0 {
+					testpayload.BeginMessage()
 					reqBody, contentType, err = buildMultipartRequest(files, formFields, openDelim, closeDelim)
 					if err != nil {
 						fmt.Fprintf(os.Stderr, "Multipart request error: %v\n", err)
-						return
+						return nil
 					}
 				} else {
 					reqBody, contentType, err = toolutil.BuildPayloadWithDelimiters(payload, mime, openDelim, closeDelim)
+					if errors.Is(err, common.ErrStop) {
+						return err
+					}
 					if err != nil {
 						fmt.Fprintln(os.Stderr, err)
-						return
+						return nil
 					}
 				}
 
@@ -100,26 +184,31 @@ func sendCommand() *cobra.Command {
 				if contentType != "" {
 					r.Header.Set("Content-Type", contentType)
 				}
-				for k, v := range headerMap {
+				for k, v := range toolutil.CloudEventHeaders(headerMap) {
 					r.Header.Set(k, v)
 				}
 				if len(reqBody) > 0 {
 					r.SetBody(reqBody)
 				}
 
-				var client fasthttp.Client
+				client := fasthttp.Client{Dial: dial}
 				if err := client.Do(r, w); err != nil {
 					fmt.Fprintf(os.Stderr, "Request error: %v\n", err)
-					return
+					return nil
 				}
 
 				printHTTPResponse(method, url, w)
+				return nil
 			}
 
-			return common.RunOnceOrPeriodic(ctx, once, interval, func() error {
-				sendRequest()
-				return nil
-			})
+			send := func() error {
+				return sendRequest()
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &payload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, interval, send)
 		},
 	}
 
@@ -129,19 +218,51 @@ func sendCommand() *cobra.Command {
 	toolutil.AddPayloadFlags(cmd, &payload, "{}", &mime, toolutil.CTJSON)
 	toolutil.AddIntervalFlag(cmd, &interval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
 	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
 	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 	cmd.Flags().StringArrayVarP(&files, "file", "f", []string{}, "File to upload in multipart/form-data format. Use name=path syntax (can be repeated)")
 	cmd.Flags().StringArrayVar(&formFields, "form-field", []string{}, "Form field in name=value format for multipart/form-data (can be repeated)")
 
 	return cmd
 }
 
+// serverAddr returns the host:port of the --address URL, with the default port of its scheme.
+func serverAddr(address string) (string, error) {
+	u, err := neturl.Parse(address)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid --address %q: expected a URL like http://localhost:8080", address)
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443"), nil
+	}
+	return net.JoinHostPort(u.Hostname(), "80"), nil
+}
+
 func printHTTPResponse(method, url string, resp *fasthttp.Response) {
 	var headerItems []toolutil.KV
 	for key, value := range resp.Header.All() {
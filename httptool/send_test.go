@@ -248,3 +248,20 @@ func TestSplitOnce(t *testing.T) {
 		})
 	}
 }
+
+func TestServerAddr(t *testing.T) {
+	tests := map[string]string{
+		"http://localhost:8080": "localhost:8080",
+		"http://example.com":    "example.com:80",
+		"https://example.com/x": "example.com:443",
+		"http://[::1]":          "[::1]:80",
+	}
+	for in, want := range tests {
+		if got, err := serverAddr(in); err != nil || got != want {
+			t.Errorf("serverAddr(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := serverAddr("localhost:8080"); err == nil {
+		t.Error("expected an error for an address without a scheme")
+	}
+}
@@ -8,20 +8,28 @@ import (
 	"mime/multipart"
 	"strings"
 
-	"github.com/sandrolain/eventkit/pkg/common"
 	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
 	"github.com/spf13/cobra"
 	"github.com/valyala/fasthttp"
 )
 
 func serveCommand() *cobra.Command {
-	var serveAddr string
+	var (
+		serveAddr string
+		serveOpts toolutil.ServeOptions
+	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Run an HTTP server that logs requests",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := common.SetupGracefulShutdown()
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
 			defer cancel()
 
 			slog.Info("Starting HTTP server", "addr", serveAddr)
@@ -39,7 +47,7 @@ func serveCommand() *cobra.Command {
 					{Title: "Request", Items: []toolutil.KV{{Key: "Method", Value: string(ctx.Method())}, {Key: "URI", Value: string(ctx.RequestURI())}}},
 					{Title: "Query", Items: queryItems},
 					{Title: "Remote", Items: []toolutil.KV{{Key: "Addr", Value: ctx.RemoteAddr().String()}}},
-					{Title: "Headers", Items: headerItems},
+					toolutil.HeadersSection(headerItems),
 				}
 
 				ct := string(ctx.Request.Header.ContentType())
@@ -80,6 +88,7 @@ func serveCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:9090", "HTTP listen address")
+	toolutil.AddServeFlags(cmd, &serveOpts)
 	return cmd
 }
 
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitHeaders(t *testing.T) {
+	md, props, err := splitHeaders(map[string]string{
+		"CorrelId":    "order-1",
+		"Persistence": "PERSISTENT",
+		"expiry":      "30s",
+		"ReplyToQ":    "DEV.QUEUE.2",
+		"region":      "eu",
+		"app.version": "2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"correlationId": "6f726465722d31" + strings.Repeat("0", 34),
+		"persistence":   "persistent",
+		"expiry":        "30000",
+		"replyTo":       "DEV.QUEUE.2",
+	}
+	for k, v := range want {
+		if md[k] != v {
+			t.Errorf("md[%s] = %q, want %q", k, md[k], v)
+		}
+	}
+	if len(props) != 2 || props[0].Name != "app.version" || props[1].Name != "region" || props[1].Value != "eu" {
+		t.Errorf("unexpected user properties: %+v", props)
+	}
+
+	for _, h := range []map[string]string{
+		{"persistence": "sometimes"},
+		{"expiry": "soon"},
+		{"correlationId": strings.Repeat("x", 25)},
+	} {
+		if _, _, err := splitHeaders(h); err == nil {
+			t.Errorf("expected an error for %v", h)
+		}
+	}
+}
+
+func TestCorrelationIDHex(t *testing.T) {
+	hexID := strings.Repeat("AB", 24)
+	if got, err := correlationIDHex(hexID); err != nil || got != strings.ToLower(hexID) {
+		t.Errorf("correlationIDHex(hex) = %q, %v", got, err)
+	}
+	got, err := correlationIDHex("a")
+	if err != nil || got != "61"+strings.Repeat("0", 46) {
+		t.Errorf("correlationIDHex(text) = %q, %v", got, err)
+	}
+}
+
+func TestProperties(t *testing.T) {
+	header := encodeProperties([]property{{Name: "region", Value: "eu"}, {Name: "note", Value: `a "quoted", text\`}})
+	if header != `region="eu", note="a \"quoted\", text\\"` {
+		t.Errorf("encodeProperties = %s", header)
+	}
+	props := parseProperties(header + `, count=42;int32, flag`)
+	want := []property{
+		{Name: "count", Value: "42", Type: "int32"},
+		{Name: "flag"},
+		{Name: "note", Value: `a "quoted", text\`, Type: "string"},
+		{Name: "region", Value: "eu", Type: "string"},
+	}
+	if len(props) != len(want) {
+		t.Fatalf("parseProperties = %+v", props)
+	}
+	for i := range want {
+		if props[i] != want[i] {
+			t.Errorf("property %d = %+v, want %+v", i, props[i], want[i])
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	err := parseError(404, []byte(`{"error":[{"type":"rest","msgId":"MQWB0009E","reasonCode":2085,"message":"MQWB0009E: Could not query the queue"}]}`))
+	var mqErr *mqError
+	if !errors.As(err, &mqErr) || mqErr.ReasonCode != 2085 || mqErr.MsgID != "MQWB0009E" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if err := parseError(502, []byte("bad gateway")); err.Error() != "status 502: bad gateway" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPutContentType(t *testing.T) {
+	if ct := putContentType("application/json", []byte(`{}`)); ct != "text/plain;charset=utf-8" {
+		t.Errorf("json: %s", ct)
+	}
+	if ct := putContentType("application/cbor", []byte{0xa0}); ct != "application/octet-stream" {
+		t.Errorf("cbor: %s", ct)
+	}
+	if ct := putContentType("text/plain", []byte{0xff, 0xfe}); ct != "application/octet-stream" {
+		t.Errorf("invalid utf-8: %s", ct)
+	}
+}
+
+// fakeQueue serves the message endpoint of one queue.
+type fakeQueue struct {
+	t        *testing.T
+	messages []*http.Request
+	bodies   [][]byte
+}
+
+func (q *fakeQueue) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/ibmmq/rest/v2/messaging/qmgr/QM1/queue/DEV.QUEUE.1/message" {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"error":[{"msgId":"MQWB0009E","reasonCode":2085,"message":"unknown queue"}]}`) //nolint:errcheck
+		return
+	}
+	if r.Header.Get(csrfHeader) == "" {
+		q.t.Errorf("missing %s header", csrfHeader)
+	}
+	if user, pass, ok := r.BasicAuth(); !ok || user != "app" || pass != "passw0rd" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		body, _ := io.ReadAll(r.Body)
+		q.messages, q.bodies = append(q.messages, r), append(q.bodies, body)
+		w.Header().Set(mdHeaderPrefix+"messageId", "414d5120514d31")
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if r.URL.Query().Get("wait") != "1000" {
+			q.t.Errorf("wait = %q", r.URL.Query().Get("wait"))
+		}
+		if len(q.messages) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		m, body := q.messages[0], q.bodies[0]
+		q.messages, q.bodies = q.messages[1:], q.bodies[1:]
+		w.Header().Set("Content-Type", m.Header.Get("Content-Type"))
+		w.Header().Set(mdHeaderPrefix+"messageId", "414d5120514d31")
+		if c := m.Header.Get(mdHeaderPrefix + "correlationId"); c != "" {
+			w.Header().Set(mdHeaderPrefix+"correlationId", c)
+		}
+		w.Header().Set(usrHeader, m.Header.Get(usrHeader))
+		w.Write(body) //nolint:errcheck
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestClientPutGet(t *testing.T) {
+	srv := httptest.NewServer(&fakeQueue{t: t})
+	defer srv.Close()
+
+	opts := mqOptions{URL: srv.URL + "/ibmmq/rest/v2/", QMgr: "QM1", Queue: "DEV.QUEUE.1", Username: "app", Password: "passw0rd"}
+	c, err := opts.client(time.Second, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	msg, err := c.get(ctx, time.Second, "")
+	if err != nil || msg != nil {
+		t.Fatalf("get on an empty queue = %v, %v", msg, err)
+	}
+
+	md := map[string]string{"correlationId": strings.Repeat("0a", 24)}
+	id, err := c.put(ctx, []byte("hello"), "text/plain;charset=utf-8", md, []property{{Name: "region", Value: "eu"}})
+	if err != nil || id != "414d5120514d31" {
+		t.Fatalf("put = %q, %v", id, err)
+	}
+
+	msg, err = c.get(ctx, time.Second, "")
+	if err != nil || msg == nil {
+		t.Fatalf("get = %v, %v", msg, err)
+	}
+	if string(msg.Body) != "hello" || !strings.HasPrefix(msg.ContentType, "text/plain") {
+		t.Errorf("unexpected message: %q (%s)", msg.Body, msg.ContentType)
+	}
+	if len(msg.MD) != 2 || msg.MD[0].Name != "correlationId" || msg.MD[1].Name != "messageId" {
+		t.Errorf("unexpected MQMD: %+v", msg.MD)
+	}
+	if len(msg.Properties) != 1 || msg.Properties[0].Name != "region" || msg.Properties[0].Value != "eu" {
+		t.Errorf("unexpected properties: %+v", msg.Properties)
+	}
+
+	opts.Queue = "MISSING"
+	c, _ = opts.client(time.Second, 5*time.Second)
+	_, err = c.put(ctx, []byte("x"), "text/plain", nil, nil)
+	var mqErr *mqError
+	if !errors.As(err, &mqErr) || mqErr.ReasonCode != 2085 {
+		t.Errorf("expected reason 2085, got %v", err)
+	}
+
+	opts.Queue, opts.Password = "DEV.QUEUE.1", "wrong"
+	c, _ = opts.client(time.Second, 5*time.Second)
+	if _, err := c.get(ctx, time.Second, ""); err == nil {
+		t.Error("expected an authentication error")
+	}
+}
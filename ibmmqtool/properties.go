@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// property is a named MQMD field or user property; Type is only set for typed user properties.
+type property struct {
+	Name  string
+	Value string
+	Type  string
+}
+
+// mdFields maps the lowercase MQMD field names and their MQI aliases to the header names of the
+// fields that can be set on a put.
+var mdFields = map[string]string{
+	"correlationid": "correlationId",
+	"correlid":      "correlationId",
+	"expiry":        "expiry",
+	"persistence":   "persistence",
+	"replyto":       "replyTo",
+	"replytoq":      "replyTo",
+}
+
+// mdResponseFields names the MQMD headers of got messages, which arrive lowercased.
+var mdResponseFields = map[string]string{
+	"messageid":     "messageId",
+	"correlationid": "correlationId",
+	"expiry":        "expiry",
+	"persistence":   "persistence",
+	"replyto":       "replyTo",
+}
+
+// mdFieldName returns the field name of a lowercase ibm-mq-md-* header suffix.
+func mdFieldName(name string) string {
+	if field, ok := mdResponseFields[name]; ok {
+		return field
+	}
+	return name
+}
+
+// correlationIDHex encodes a correlation id as the 48 hex digits of its 24 bytes. Values already
+// in that form are kept; shorter text is padded with zero bytes, like the MQI does.
+func correlationIDHex(v string) (string, error) {
+	if len(v) == 48 {
+		if _, err := hex.DecodeString(v); err == nil {
+			return strings.ToLower(v), nil
+		}
+	}
+	if len(v) > 24 {
+		return "", fmt.Errorf("correlation id %q is longer than 24 bytes", v)
+	}
+	id := make([]byte, 24)
+	copy(id, v)
+	return hex.EncodeToString(id), nil
+}
+
+// mdValue validates and normalizes the value of an MQMD field.
+func mdValue(field, v string) (string, error) {
+	switch field {
+	case "correlationId":
+		return correlationIDHex(v)
+	case "expiry":
+		// Milliseconds, a duration such as 30s, or unlimited.
+		if strings.EqualFold(v, "unlimited") {
+			return "unlimited", nil
+		}
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil && ms > 0 {
+			return v, nil
+		}
+		if d, err := time.ParseDuration(v); err == nil && d >= time.Millisecond {
+			return strconv.FormatInt(d.Milliseconds(), 10), nil
+		}
+		return "", fmt.Errorf("invalid expiry %q: expected milliseconds, a duration or unlimited", v)
+	case "persistence":
+		switch strings.ToLower(v) {
+		case "persistent":
+			return "persistent", nil
+		case "nonpersistent":
+			return "nonPersistent", nil
+		}
+		return "", fmt.Errorf("invalid persistence %q: expected persistent or nonPersistent", v)
+	}
+	return v, nil
+}
+
+// splitHeaders sorts --header values into MQMD fields, recognized by name, and user properties,
+// which the queue manager carries in the RFH2 header.
+func splitHeaders(headers map[string]string) (map[string]string, []property, error) {
+	md := map[string]string{}
+	var props []property
+	for _, k := range slices.Sorted(maps.Keys(headers)) {
+		field, ok := mdFields[strings.ToLower(k)]
+		if !ok {
+			props = append(props, property{Name: k, Value: headers[k]})
+			continue
+		}
+		v, err := mdValue(field, headers[k])
+		if err != nil {
+			return nil, nil, err
+		}
+		md[field] = v
+	}
+	return md, props, nil
+}
+
+var propertyEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// encodeProperties renders the ibm-mq-usr header: comma separated name="value" string
+// properties.
+func encodeProperties(props []property) string {
+	parts := make([]string, len(props))
+	for i, p := range props {
+		parts[i] = p.Name + `="` + propertyEscaper.Replace(p.Value) + `"`
+	}
+	return strings.Join(parts, ", ")
+}
+
+// splitQuoted splits s at the separators outside double quotes, honoring backslash escapes.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseProperties decodes an ibm-mq-usr header: comma separated name=value entries where strings
+// are quoted and other values may carry a ;type suffix. Malformed entries are kept whole.
+func parseProperties(header string) []property {
+	var props []property
+	for _, entry := range splitQuoted(header, ',') {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			props = append(props, property{Name: entry})
+			continue
+		}
+		p := property{Name: strings.TrimSpace(name)}
+		parts := splitQuoted(strings.TrimSpace(value), ';')
+		p.Value = strings.TrimSpace(parts[0])
+		if len(parts) > 1 {
+			p.Type = strings.TrimSpace(parts[1])
+		}
+		if unquoted, err := strconv.Unquote(p.Value); err == nil && strings.HasPrefix(p.Value, `"`) {
+			p.Value = unquoted
+			if p.Type == "" {
+				p.Type = "string"
+			}
+		}
+		props = append(props, p)
+	}
+	sortProperties(props)
+	return props
+}
+
+func sortProperties(props []property) {
+	slices.SortFunc(props, func(a, b property) int { return strings.Compare(a.Name, b.Name) })
+}
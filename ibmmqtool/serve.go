@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// retryDelay spaces the gets after a failed one.
+const retryDelay = 2 * time.Second
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           mqOptions
+		wait           time.Duration
+		correlationID  string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Get and log the messages of an IBM MQ queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if wait < 0 {
+				return fmt.Errorf("--wait must not be negative")
+			}
+			if correlationID != "" {
+				var err error
+				if correlationID, err = correlationIDHex(correlationID); err != nil {
+					return err
+				}
+			}
+			// Requests outlive the long poll by a margin, so an empty queue is not a timeout.
+			client, err := opts.client(connectTimeout, wait+30*time.Second)
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			toolutil.PrintSuccess("Getting messages from IBM MQ")
+			toolutil.PrintKeyValue("Endpoint", client.endpoint)
+			toolutil.PrintKeyValue("Queue manager", opts.QMgr)
+			toolutil.PrintKeyValue("Queue", opts.Queue)
+			if correlationID != "" {
+				toolutil.PrintKeyValue("Correlation id", correlationID)
+			}
+
+			failing := false
+			for {
+				msg, err := client.get(ctx, wait, correlationID)
+				if ctx.Err() != nil {
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				}
+				if err != nil {
+					if !failing {
+						toolutil.PrintWarning("Get failed: %v", err)
+						failing = true
+					}
+					select {
+					case <-ctx.Done():
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					case <-time.After(retryDelay):
+					}
+					continue
+				}
+				if failing {
+					toolutil.PrintInfo("Getting resumed")
+					failing = false
+				}
+				if msg != nil {
+					printMessage(opts, msg)
+				}
+			}
+		},
+	}
+
+	addMQFlags(cmd, &opts)
+	cmd.Flags().DurationVar(&wait, "wait", 10*time.Second, "How long each get waits for a message (long poll)")
+	cmd.Flags().StringVar(&correlationID, "correlation-id", "", "Only get messages with this correlation id (48 hex digits or up to 24 bytes of text)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// propertyItems lists properties for display, with the type of non-string user properties.
+func propertyItems(props []property) []toolutil.KV {
+	items := make([]toolutil.KV, len(props))
+	for i, p := range props {
+		value := p.Value
+		if p.Type != "" && p.Type != "string" {
+			value += " (" + p.Type + ")"
+		}
+		items[i] = toolutil.KV{Key: p.Name, Value: value}
+	}
+	return items
+}
+
+func printMessage(opts mqOptions, msg *inbound) {
+	sections := []toolutil.MessageSection{
+		{Title: "Queue", Items: []toolutil.KV{{Key: "Queue manager", Value: opts.QMgr}, {Key: "Name", Value: opts.Queue}}},
+		{Title: "MQMD", Items: propertyItems(msg.MD)},
+		toolutil.HeadersSection(propertyItems(msg.Properties)),
+	}
+	// Text messages arrive as text/plain whatever they hold, so their content is sniffed.
+	ct := msg.ContentType
+	if ct == "" || strings.HasPrefix(ct, toolutil.CTText) {
+		ct = toolutil.GuessMIME(msg.Body)
+	}
+	toolutil.PrintColoredMessage("IBM MQ", sections, msg.Body, ct)
+}
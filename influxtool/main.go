@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "influxtool",
+		Short: "InfluxDB line protocol tester",
+		Long:  "A simple InfluxDB CLI that writes templated line protocol points to InfluxDB or Telegraf and runs a write endpoint parsing and printing received points.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// precisionUnits maps the v2 write precisions to their duration.
+var precisionUnits = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// v1Precisions maps the precisions to their name in the v1 write API, which accepts more units.
+var v1Precisions = map[string]string{"ns": "ns", "us": "u", "ms": "ms", "s": "s"}
+
+// v1PrecisionUnits maps the v1 write precisions to their duration.
+var v1PrecisionUnits = map[string]time.Duration{
+	"n":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `)
+	keyEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	typedNumber        = regexp.MustCompile(`^-?[0-9]+[iu]$`)
+)
+
+// kv is an ordered key and value.
+type kv struct {
+	Key   string
+	Value string
+}
+
+// fieldLiteral renders a field value: numbers, integers with an i or u suffix and booleans are
+// kept as written, anything else becomes a quoted string.
+func fieldLiteral(v string) string {
+	if typedNumber.MatchString(v) {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil && !strings.ContainsAny(v, "xXnN_") {
+		return v
+	}
+	switch v {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return v
+	}
+	return `"` + stringEscaper.Replace(v) + `"`
+}
+
+// encodeLine builds a line protocol point; a zero timestamp is omitted so the server assigns it.
+func encodeLine(measurement string, tags, fields []kv, ts time.Time, precision time.Duration) (string, error) {
+	if measurement == "" {
+		return "", fmt.Errorf("measurement is empty")
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("at least one field is required")
+	}
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(measurement))
+	for _, t := range tags {
+		if t.Key == "" || t.Value == "" {
+			continue // empty tags are not allowed, and mean "no tag"
+		}
+		b.WriteString("," + keyEscaper.Replace(t.Key) + "=" + keyEscaper.Replace(t.Value))
+	}
+	for i, f := range fields {
+		if f.Key == "" {
+			return "", fmt.Errorf("field key is empty")
+		}
+		sep := ","
+		if i == 0 {
+			sep = " "
+		}
+		b.WriteString(sep + keyEscaper.Replace(f.Key) + "=" + fieldLiteral(f.Value))
+	}
+	if !ts.IsZero() {
+		b.WriteString(" " + strconv.FormatInt(ts.UnixNano()/int64(precision), 10))
+	}
+	return b.String(), nil
+}
+
+// field is a parsed field value with its line protocol type.
+type field struct {
+	Key   string
+	Value string
+	Type  string
+}
+
+// point is a parsed line protocol point; Timestamp is empty when the line has none.
+type point struct {
+	Line        string
+	Measurement string
+	Tags        []kv
+	Fields      []field
+	Timestamp   string
+}
+
+// splitUnescaped splits s at the separators not escaped by a backslash and, with quotes, not
+// inside double quotes; escapes are kept.
+func splitUnescaped(s string, sep byte, quotes bool, limit int) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quotes && s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted && (limit <= 0 || len(parts) < limit-1):
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescape removes the backslashes escaping the given characters.
+func unescape(s, chars string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(chars, s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseFieldValue decodes a field value literal and names its type.
+func parseFieldValue(v string) (string, string, error) {
+	switch {
+	case v == "":
+		return "", "", fmt.Errorf("missing field value")
+	case v[0] == '"':
+		if len(v) < 2 || v[len(v)-1] != '"' {
+			return "", "", fmt.Errorf("unterminated string %s", v)
+		}
+		return unescape(v[1:len(v)-1], `"\`), "string", nil
+	case strings.HasSuffix(v, "i"):
+		if _, err := strconv.ParseInt(v[:len(v)-1], 10, 64); err != nil {
+			return "", "", fmt.Errorf("invalid integer %s", v)
+		}
+		return v[:len(v)-1], "integer", nil
+	case strings.HasSuffix(v, "u"):
+		if _, err := strconv.ParseUint(v[:len(v)-1], 10, 64); err != nil {
+			return "", "", fmt.Errorf("invalid unsigned integer %s", v)
+		}
+		return v[:len(v)-1], "unsigned", nil
+	}
+	switch v {
+	case "t", "T", "true", "True", "TRUE":
+		return "true", "boolean", nil
+	case "f", "F", "false", "False", "FALSE":
+		return "false", "boolean", nil
+	}
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return "", "", fmt.Errorf("invalid field value %s", v)
+	}
+	return v, "float", nil
+}
+
+// parseLine parses a line protocol point.
+func parseLine(line string) (point, error) {
+	var p point
+	parts := splitUnescaped(line, ' ', true, 0)
+	if len(parts) < 2 || len(parts) > 3 {
+		return p, fmt.Errorf("expected measurement, fields and an optional timestamp separated by single spaces")
+	}
+
+	series := splitUnescaped(parts[0], ',', false, 0)
+	p.Measurement = unescape(series[0], `, `)
+	if p.Measurement == "" {
+		return p, fmt.Errorf("missing measurement")
+	}
+	for _, t := range series[1:] {
+		kvs := splitUnescaped(t, '=', false, 0)
+		if len(kvs) != 2 || kvs[0] == "" || kvs[1] == "" {
+			return p, fmt.Errorf("invalid tag %q", t)
+		}
+		p.Tags = append(p.Tags, kv{Key: unescape(kvs[0], `,= `), Value: unescape(kvs[1], `,= `)})
+	}
+
+	for _, f := range splitUnescaped(parts[1], ',', true, 0) {
+		kvs := splitUnescaped(f, '=', true, 2)
+		if len(kvs) != 2 || kvs[0] == "" {
+			return p, fmt.Errorf("invalid field %q", f)
+		}
+		value, typ, err := parseFieldValue(kvs[1])
+		if err != nil {
+			return p, fmt.Errorf("field %s: %w", kvs[0], err)
+		}
+		p.Fields = append(p.Fields, field{Key: unescape(kvs[0], `,= `), Value: value, Type: typ})
+	}
+
+	if len(parts) == 3 {
+		if _, err := strconv.ParseInt(parts[2], 10, 64); err != nil {
+			return p, fmt.Errorf("invalid timestamp %q", parts[2])
+		}
+		p.Timestamp = parts[2]
+	}
+	return p, nil
+}
+
+// parseLines parses a write body, skipping blank and comment lines; errors carry the line number.
+func parseLines(body string) ([]point, error) {
+	var points []point
+	for i, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		p.Line = line
+		points = append(points, p)
+	}
+	return points, nil
+}
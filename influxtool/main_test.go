@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldLiteral(t *testing.T) {
+	tests := map[string]string{
+		"1.5":     "1.5",
+		"-3":      "-3",
+		"12i":     "12i",
+		"7u":      "7u",
+		"true":    "true",
+		"F":       "F",
+		"NaN":     `"NaN"`,
+		"0x10":    `"0x10"`,
+		"hello":   `"hello"`,
+		`a "b" \`: `"a \"b\" \\"`,
+	}
+	for in, want := range tests {
+		if got := fieldLiteral(in); got != want {
+			t.Errorf("fieldLiteral(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestEncodeLine(t *testing.T) {
+	ts := time.Unix(1700000000, 123456789)
+	line, err := encodeLine("cpu load", []kv{{"host", "a,b"}, {"empty", ""}, {"k=1", "v 1"}}, []kv{{"value", "0.5"}, {"msg", "hi there"}, {"n", "3i"}}, ts, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `cpu\ load,host=a\,b,k\=1=v\ 1 value=0.5,msg="hi there",n=3i 1700000000123`
+	if line != want {
+		t.Errorf("encodeLine = %s, want %s", line, want)
+	}
+	if line, _ := encodeLine("m", nil, []kv{{"v", "1"}}, time.Time{}, time.Second); line != "m v=1" {
+		t.Errorf("without timestamp = %s", line)
+	}
+	if _, err := encodeLine("m", nil, nil, ts, time.Second); err == nil {
+		t.Error("expected error without fields")
+	}
+}
+
+func TestParseLineRoundTrip(t *testing.T) {
+	line, err := encodeLine("cpu load", []kv{{"host", "a,b"}, {"k=1", "v 1"}}, []kv{{"msg", `say "hi", ok=yes`}, {"n", "3i"}, {"u", "4u"}, {"ok", "t"}, {"f", "-1.5e3"}}, time.Unix(0, 42), time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parseLine(line)
+	if err != nil {
+		t.Fatalf("parseLine(%s): %v", line, err)
+	}
+	if p.Measurement != "cpu load" || p.Timestamp != "42" {
+		t.Errorf("point = %+v", p)
+	}
+	if len(p.Tags) != 2 || p.Tags[0] != (kv{"host", "a,b"}) || p.Tags[1] != (kv{"k=1", "v 1"}) {
+		t.Errorf("tags = %+v", p.Tags)
+	}
+	want := []field{{"msg", `say "hi", ok=yes`, "string"}, {"n", "3", "integer"}, {"u", "4", "unsigned"}, {"ok", "true", "boolean"}, {"f", "-1.5e3", "float"}}
+	if len(p.Fields) != len(want) {
+		t.Fatalf("fields = %+v", p.Fields)
+	}
+	for i := range want {
+		if p.Fields[i] != want[i] {
+			t.Errorf("field %d = %+v, want %+v", i, p.Fields[i], want[i])
+		}
+	}
+}
+
+func TestParseLinesErrors(t *testing.T) {
+	points, err := parseLines("# comment\n\nm v=1\r\nm,t=x v=2i 10\n")
+	if err != nil || len(points) != 2 || points[1].Line != "m,t=x v=2i 10" {
+		t.Fatalf("points = %+v, %v", points, err)
+	}
+	tests := map[string]string{
+		"m":                  "expected measurement",
+		"m,t v=1":            `invalid tag "t"`,
+		"m v=1 x":            "invalid timestamp",
+		"m v=abc":            "invalid field value",
+		`m v="open`:          "unterminated string",
+		"m v=1.5i":           "invalid integer",
+		"m v=-1u":            "invalid unsigned",
+		",t=1 v=1":           "missing measurement",
+		"m v=1 1 extra":      "expected measurement",
+		"m v=1\nm novalue 1": "line 2: invalid field",
+	}
+	for in, want := range tests {
+		if _, err := parseLines(in); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseLines(%q) error = %v, want %q", in, err, want)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// writeTarget addresses the write endpoint of an InfluxDB or Telegraf listener.
+type writeTarget struct {
+	URL       string
+	API       string
+	Org       string
+	Bucket    string
+	Token     string
+	DB        string
+	RP        string
+	Username  string
+	Password  string
+	Precision string
+}
+
+// endpoint returns the write URL of the target API.
+func (t writeTarget) endpoint() (string, error) {
+	base := strings.TrimRight(t.URL, "/")
+	q := url.Values{}
+	switch t.API {
+	case "v2":
+		if t.Org != "" {
+			q.Set("org", t.Org)
+		}
+		q.Set("bucket", t.Bucket)
+		q.Set("precision", t.Precision)
+		return base + "/api/v2/write?" + q.Encode(), nil
+	case "v1":
+		q.Set("db", t.DB)
+		if t.RP != "" {
+			q.Set("rp", t.RP)
+		}
+		q.Set("precision", v1Precisions[t.Precision])
+		return base + "/write?" + q.Encode(), nil
+	default:
+		return "", fmt.Errorf("invalid --api %q: expected v2 or v1", t.API)
+	}
+}
+
+// writer posts line protocol bodies.
+type writer struct {
+	client   *http.Client
+	target   writeTarget
+	endpoint string
+	gzip     bool
+	headers  map[string]string
+}
+
+// write posts lines, failing with the message of the server on a non-2xx status.
+func (w *writer) write(ctx context.Context, body []byte) error {
+	reqBody := body
+	if w.gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		reqBody = buf.Bytes()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	switch {
+	case w.target.Token != "":
+		req.Header.Set("Authorization", "Token "+w.target.Token)
+	case w.target.Username != "":
+		req.SetBasicAuth(w.target.Username, w.target.Password)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	// v2 errors carry a message, v1 errors an error.
+	var e struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(msg, &e) == nil && (e.Message != "" || e.Error != "") {
+		return fmt.Errorf("rejected with status %s: %s", resp.Status, e.Message+e.Error)
+	}
+	return fmt.Errorf("rejected with status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// parsePairs splits key=value flags, keeping their order.
+func parsePairs(flag string, pairs []string) ([]kv, error) {
+	out := make([]kv, 0, len(pairs))
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --%s %q: expected key=value", flag, p)
+		}
+		out = append(out, kv{Key: k, Value: v})
+	}
+	return out, nil
+}
+
+// pointBuilder renders points from the templated measurement, tag and field flags.
+type pointBuilder struct {
+	measurement toolutil.Destination
+	tagKeys     []string
+	tags        []toolutil.Destination
+	fieldKeys   []string
+	fields      []toolutil.Destination
+	precision   time.Duration
+}
+
+func newPointBuilder(measurement string, tags, fields []kv, precision time.Duration, openDelim, closeDelim string) *pointBuilder {
+	b := &pointBuilder{measurement: toolutil.NewDestination(measurement, openDelim, closeDelim), precision: precision}
+	for _, t := range tags {
+		b.tagKeys = append(b.tagKeys, t.Key)
+		b.tags = append(b.tags, toolutil.NewDestination(t.Value, openDelim, closeDelim))
+	}
+	for _, f := range fields {
+		b.fieldKeys = append(b.fieldKeys, f.Key)
+		b.fields = append(b.fields, toolutil.NewDestination(f.Value, openDelim, closeDelim))
+	}
+	return b
+}
+
+// resolve renders each template of dests into a key and value list.
+func resolve(keys []string, dests []toolutil.Destination) ([]kv, error) {
+	out := make([]kv, len(keys))
+	for i, d := range dests {
+		v, err := d.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keys[i], err)
+		}
+		out[i] = kv{Key: keys[i], Value: v}
+	}
+	return out, nil
+}
+
+// build renders one point stamped with the current time.
+func (b *pointBuilder) build() (string, error) {
+	testpayload.BeginMessage()
+	measurement, err := b.measurement.Resolve()
+	if err != nil {
+		return "", fmt.Errorf("measurement build error: %w", err)
+	}
+	tags, err := resolve(b.tagKeys, b.tags)
+	if err != nil {
+		return "", fmt.Errorf("tag build error: %w", err)
+	}
+	fields, err := resolve(b.fieldKeys, b.fields)
+	if err != nil {
+		return "", fmt.Errorf("field build error: %w", err)
+	}
+	return encodeLine(measurement, tags, fields, time.Now(), b.precision)
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		target         writeTarget
+		measurement    string
+		tagFlags       []string
+		fieldFlags     []string
+		batch          int
+		useGzip        bool
+		requestTimeout time.Duration
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Write periodic templated line protocol points",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			precision, ok := precisionUnits[target.Precision]
+			if !ok {
+				return fmt.Errorf("invalid --precision %q: expected ns, us, ms or s", target.Precision)
+			}
+			endpoint, err := target.endpoint()
+			if err != nil {
+				return err
+			}
+			if batch < 1 {
+				return fmt.Errorf("--batch must be at least 1")
+			}
+			tags, err := parsePairs("tag", tagFlags)
+			if err != nil {
+				return err
+			}
+			fields, err := parsePairs("field", fieldFlags)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+
+			// With fields the points are built from the flags, otherwise the payload is the line protocol.
+			builder := newPointBuilder(measurement, tags, fields, precision, openDelim, closeDelim)
+			nextLine := func() (string, error) {
+				if len(fields) > 0 {
+					return builder.build()
+				}
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					return "", fmt.Errorf("payload build error: %w", err)
+				}
+				return strings.TrimRight(string(body), "\r\n"), nil
+			}
+			if printPayload {
+				if len(fields) == 0 {
+					return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+				}
+				line, err := nextLine()
+				if err != nil {
+					return err
+				}
+				fmt.Println(line)
+				return nil
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			w := &writer{
+				client: &http.Client{
+					Timeout:   requestTimeout,
+					Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: (&net.Dialer{Timeout: connectTimeout}).DialContext},
+				},
+				target:   target,
+				endpoint: endpoint,
+				gzip:     useGzip,
+				headers:  headerMap,
+			}
+
+			toolutil.PrintSuccess("Starting line protocol writer")
+			toolutil.PrintKeyValue("Endpoint", endpoint)
+			toolutil.PrintKeyValue("Precision", target.Precision)
+			toolutil.PrintKeyValue("Batch", batch)
+
+			send := func() error {
+				lines := make([]string, 0, batch)
+				for range batch {
+					line, err := nextLine()
+					if err != nil {
+						toolutil.PrintError("%v", err)
+						return err
+					}
+					lines = append(lines, line)
+				}
+				body := []byte(strings.Join(lines, "\n") + "\n")
+				if err := w.write(ctx, body); err != nil {
+					toolutil.PrintError("Write error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Wrote %d line(s) (%d bytes)", len(lines), len(body))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&target.URL, "url", "http://localhost:8086", "InfluxDB or Telegraf listener URL")
+	cmd.Flags().StringVar(&target.API, "api", "v2", "Write API: v2 (/api/v2/write) or v1 (/write)")
+	cmd.Flags().StringVar(&target.Org, "org", "", "Organization (v2)")
+	cmd.Flags().StringVar(&target.Bucket, "bucket", "events", "Bucket (v2)")
+	cmd.Flags().StringVar(&target.Token, "token", "", "API token, sent as Authorization: Token")
+	cmd.Flags().StringVar(&target.DB, "db", "events", "Database (v1)")
+	cmd.Flags().StringVar(&target.RP, "rp", "", "Retention policy (v1)")
+	cmd.Flags().StringVar(&target.Username, "username", "", "Basic auth username (v1)")
+	cmd.Flags().StringVar(&target.Password, "password", "", "Basic auth password (v1)")
+	cmd.Flags().StringVar(&target.Precision, "precision", "ns", "Timestamp precision: ns, us, ms or s")
+	cmd.Flags().StringVar(&measurement, "measurement", "events", "Measurement of the built points, supports template placeholders")
+	cmd.Flags().StringArrayVar(&tagFlags, "tag", nil, "Tag key=value of the built points, value supports template placeholders (repeatable)")
+	cmd.Flags().StringArrayVar(&fieldFlags, "field", nil, "Field key=value building points instead of the payload; numbers, 12i integers and booleans are kept, other values quoted (repeatable, templated)")
+	cmd.Flags().IntVar(&batch, "batch", 1, "Lines written per request")
+	cmd.Flags().BoolVar(&useGzip, "gzip", false, "Gzip the request body")
+	cmd.Flags().DurationVar(&requestTimeout, "request-timeout", 10*time.Second, "Timeout of each write request")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `events,source=eventkit value={{counter}}i,message="{{sentence}}"`, &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
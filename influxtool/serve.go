@@ -0,0 +1,228 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxWriteSize bounds decoded write bodies.
+const maxWriteSize = 32 << 20
+
+// version is reported by /ping like an InfluxDB server, so clients detecting it are satisfied.
+const version = "eventkit"
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr string
+		token     string
+		serveOpts toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a line protocol write endpoint that parses and logs received points",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			srv := &http.Server{Addr: serveAddr, Handler: newHandler(token), ReadHeaderTimeout: 10 * time.Second}
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("Line protocol endpoint listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Write paths", "/api/v2/write, /write")
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving write endpoint: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:8086", "Listen address")
+	cmd.Flags().StringVar(&token, "token", "", "Require this token (Authorization: Token/Bearer, v1 password or p parameter)")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// newHandler serves the v2 and v1 write APIs plus the ping and health probes.
+func newHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/api/v2/write", writeHandler{api: "v2", token: token})
+	mux.Handle("/write", writeHandler{api: "v1", token: token})
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Influxdb-Version", version)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"name": "influxtool", "status": "pass", "version": version}) //nolint:errcheck
+	})
+	return mux
+}
+
+// writeHandler parses and prints the points of write requests.
+type writeHandler struct {
+	api   string
+	token string
+}
+
+// authorized checks the token of a request when one is required.
+func (h writeHandler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+	auth := r.Header.Get("Authorization")
+	if auth == "Token "+h.token || auth == "Bearer "+h.token {
+		return true
+	}
+	if h.api == "v1" {
+		if _, password, ok := r.BasicAuth(); ok && password == h.token {
+			return true
+		}
+		return r.URL.Query().Get("p") == h.token
+	}
+	return false
+}
+
+// fail answers with the error body of the API: v2 a code and message, v1 an error.
+func (h writeHandler) fail(w http.ResponseWriter, status int, code, msg string) {
+	body := map[string]string{"error": msg}
+	if h.api == "v2" {
+		body = map[string]string{"code": code, "message": msg}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck
+}
+
+func (h writeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.fail(w, http.StatusMethodNotAllowed, "method not allowed", "method not allowed")
+		return
+	}
+	if !h.authorized(r) {
+		h.fail(w, http.StatusUnauthorized, "unauthorized", "unauthorized access")
+		return
+	}
+
+	q := r.URL.Query()
+	precision, unit := q.Get("precision"), time.Nanosecond
+	if precision != "" {
+		units := precisionUnits
+		if h.api == "v1" {
+			units = v1PrecisionUnits
+		}
+		var ok bool
+		if unit, ok = units[precision]; !ok {
+			h.fail(w, http.StatusBadRequest, "invalid", fmt.Sprintf("invalid precision %q", precision))
+			return
+		}
+	}
+	meta := []toolutil.KV{{Key: "API", Value: h.api}}
+	for _, param := range []string{"org", "bucket", "db", "rp"} {
+		if v := q.Get(param); v != "" {
+			meta = append(meta, toolutil.KV{Key: param, Value: v})
+		}
+	}
+	if precision != "" {
+		meta = append(meta, toolutil.KV{Key: "precision", Value: precision})
+	}
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			h.fail(w, http.StatusBadRequest, "invalid", "invalid gzip body: "+err.Error())
+			return
+		}
+		defer zr.Close() //nolint:errcheck
+		body = zr
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxWriteSize+1))
+	if err != nil {
+		h.fail(w, http.StatusBadRequest, "invalid", "failed to read body: "+err.Error())
+		return
+	}
+	if len(data) > maxWriteSize {
+		h.fail(w, http.StatusRequestEntityTooLarge, "request too large", "body exceeds the write size limit")
+		return
+	}
+	points, err := parseLines(string(data))
+	if err != nil {
+		toolutil.PrintError("Invalid write from %s: %v", r.RemoteAddr, err)
+		h.fail(w, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	for _, p := range points {
+		printPoint(p, unit, meta)
+	}
+}
+
+// pointTime converts a timestamp in the write precision.
+func pointTime(ts string, unit time.Duration) (time.Time, error) {
+	n, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, 0).Add(time.Duration(n) * unit).UTC(), nil
+}
+
+func printPoint(p point, unit time.Duration, meta []toolutil.KV) {
+	items := []toolutil.KV{{Key: "Measurement", Value: p.Measurement}}
+	if p.Timestamp != "" {
+		value := p.Timestamp
+		if t, err := pointTime(p.Timestamp, unit); err == nil {
+			value = t.Format(time.RFC3339Nano)
+		}
+		items = append(items, toolutil.KV{Key: "Time", Value: value})
+	}
+	tags := make([]toolutil.KV, len(p.Tags))
+	for i, t := range p.Tags {
+		tags[i] = toolutil.KV{Key: t.Key, Value: t.Value}
+	}
+	fields := make([]toolutil.KV, len(p.Fields))
+	for i, f := range p.Fields {
+		fields[i] = toolutil.KV{Key: f.Key, Value: fmt.Sprintf("%s (%s)", f.Value, f.Type)}
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Point", Items: items},
+		{Title: "Tags", Items: tags},
+		{Title: "Fields", Items: fields},
+		{Title: "Write", Items: meta},
+	}
+	toolutil.PrintColoredMessage("Line Protocol", sections, []byte(strings.TrimSpace(p.Line)), toolutil.CTText)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpoint(t *testing.T) {
+	target := writeTarget{URL: "http://influx:8086/", API: "v2", Org: "acme", Bucket: "b", Precision: "us"}
+	if got, _ := target.endpoint(); got != "http://influx:8086/api/v2/write?bucket=b&org=acme&precision=us" {
+		t.Errorf("v2 endpoint = %s", got)
+	}
+	target = writeTarget{URL: "http://influx:8086", API: "v1", DB: "db", RP: "weekly", Precision: "us"}
+	if got, _ := target.endpoint(); got != "http://influx:8086/write?db=db&precision=u&rp=weekly" {
+		t.Errorf("v1 endpoint = %s", got)
+	}
+	if _, err := (writeTarget{API: "v3"}).endpoint(); err == nil {
+		t.Error("expected invalid API error")
+	}
+}
+
+func TestWriteToHandler(t *testing.T) {
+	srv := httptest.NewServer(newHandler("secret"))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name   string
+		target writeTarget
+		gzip   bool
+	}{
+		{"v2", writeTarget{URL: srv.URL, API: "v2", Bucket: "b", Token: "secret", Precision: "s"}, false},
+		{"v2 gzip", writeTarget{URL: srv.URL, API: "v2", Bucket: "b", Token: "secret", Precision: "ms"}, true},
+		{"v1 basic", writeTarget{URL: srv.URL, API: "v1", DB: "db", Username: "u", Password: "secret", Precision: "us"}, false},
+	} {
+		endpoint, err := tc.target.endpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := &writer{client: srv.Client(), target: tc.target, endpoint: endpoint, gzip: tc.gzip}
+		if err := w.write(context.Background(), []byte("m,host=a v=1i 1700000000\nm v=2\n")); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+
+	endpoint, _ := writeTarget{URL: srv.URL, API: "v2", Bucket: "b", Token: "wrong", Precision: "ns"}.endpoint()
+	w := &writer{client: srv.Client(), target: writeTarget{Token: "wrong"}, endpoint: endpoint}
+	if err := w.write(context.Background(), []byte("m v=1\n")); err == nil || !strings.Contains(err.Error(), "unauthorized access") {
+		t.Errorf("wrong token error = %v", err)
+	}
+	w.target.Token = "secret"
+	if err := w.write(context.Background(), []byte("m v=\n")); err == nil || !strings.Contains(err.Error(), "line 1: field v: missing field value") {
+		t.Errorf("invalid line error = %v", err)
+	}
+
+	w = &writer{client: srv.Client(), target: writeTarget{Token: "secret"}, endpoint: srv.URL + "/write?db=db&precision=w"}
+	if err := w.write(context.Background(), []byte("m v=1\n")); err == nil || !strings.Contains(err.Error(), "invalid precision") {
+		t.Errorf("invalid precision error = %v", err)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	srv := httptest.NewServer(newHandler(""))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("X-Influxdb-Version") == "" {
+		t.Errorf("ping = %s %v", resp.Status, resp.Header)
+	}
+	resp, err = srv.Client().Get(srv.URL + "/write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /write = %s", resp.Status)
+	}
+}
+
+func TestPointTime(t *testing.T) {
+	got, err := pointTime("1700000000123", time.Millisecond)
+	if err != nil || !got.Equal(time.Unix(1700000000, 123000000)) {
+		t.Errorf("pointTime = %v, %v", got, err)
+	}
+}
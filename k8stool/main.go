@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "k8stool",
+		Short: "Kubernetes event emitter and watcher",
+		Long:  "A simple Kubernetes CLI that creates synthetic Event objects and watches Events or any other resource in a namespace.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// kubeOptions select the cluster, like kubectl's flags of the same names.
+type kubeOptions struct {
+	Kubeconfig string
+	Context    string
+	Namespace  string
+}
+
+func kubeFlags(cmd *cobra.Command, opts *kubeOptions) {
+	cmd.Flags().StringVar(&opts.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config, then in-cluster config)")
+	cmd.Flags().StringVar(&opts.Context, "context", "", "Kubeconfig context to use (default: current context)")
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", "", "Namespace (default: the context namespace or \"default\")")
+}
+
+// load resolves the client configuration and the namespace to use.
+func (o kubeOptions) load() (*rest.Config, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: o.Context}
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("error loading kubeconfig: %w", err)
+	}
+	ns := o.Namespace
+	if ns == "" {
+		if ns, _, err = cc.Namespace(); err != nil {
+			return nil, "", fmt.Errorf("error resolving namespace: %w", err)
+		}
+	}
+	cfg.UserAgent = "eventkit-k8stool"
+	return cfg, ns, nil
+}
+
+// parseGVR parses a resource as VERSION/RESOURCE for the core group (e.g. v1/pods) or
+// GROUP/VERSION/RESOURCE (e.g. apps/v1/deployments).
+func parseGVR(s string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(s, "/")
+	for _, p := range parts {
+		if p == "" {
+			return schema.GroupVersionResource{}, fmt.Errorf("invalid --gvr %q: expected VERSION/RESOURCE or GROUP/VERSION/RESOURCE", s)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: strings.ToLower(parts[1])}, nil
+	case 3:
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: strings.ToLower(parts[2])}, nil
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("invalid --gvr %q: expected VERSION/RESOURCE or GROUP/VERSION/RESOURCE", s)
+}
+
+// gvrString renders gvr in the --gvr flag syntax.
+func gvrString(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Version + "/" + gvr.Resource
+	}
+	return gvr.Group + "/" + gvr.Version + "/" + gvr.Resource
+}
+
+// serverVersionOf fetches the API server version; unlike the discovery client's ServerVersion
+// it honors ctx, so it can serve as the connection probe.
+func serverVersionOf(ctx context.Context, clientset kubernetes.Interface) (string, error) {
+	raw, err := clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+	if err != nil {
+		return "", err
+	}
+	var info version.Info
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return "", fmt.Errorf("invalid /version response: %w", err)
+	}
+	return info.GitVersion, nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestParseGVR(t *testing.T) {
+	for in, want := range map[string]schema.GroupVersionResource{
+		"v1/Events":           {Version: "v1", Resource: "events"},
+		"apps/v1/deployments": {Group: "apps", Version: "v1", Resource: "deployments"},
+	} {
+		gvr, err := parseGVR(in)
+		if err != nil || gvr != want {
+			t.Errorf("parseGVR(%q) = %v, %v", in, gvr, err)
+		}
+	}
+	if got := gvrString(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}); got != "apps/v1/deployments" {
+		t.Errorf("gvrString() = %q", got)
+	}
+	for _, in := range []string{"", "events", "v1/", "/v1/pods", "a/b/c/d"} {
+		if _, err := parseGVR(in); err == nil {
+			t.Errorf("parseGVR(%q) succeeded, want error", in)
+		}
+	}
+}
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: local
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: dev
+  context:
+    cluster: local
+    namespace: team-a
+- name: bare
+  context:
+    cluster: local
+`
+
+func TestKubeOptionsLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, ns, err := kubeOptions{Kubeconfig: path}.load()
+	if err != nil || cfg.Host != "https://127.0.0.1:6443" || ns != "team-a" {
+		t.Errorf("load() = %v, %q, %v", cfg, ns, err)
+	}
+	if _, ns, err := (kubeOptions{Kubeconfig: path, Namespace: "other"}).load(); err != nil || ns != "other" {
+		t.Errorf("load(--namespace) = %q, %v", ns, err)
+	}
+	if _, ns, err := (kubeOptions{Kubeconfig: path, Context: "bare"}).load(); err != nil || ns != "default" {
+		t.Errorf("load(--context bare) = %q, %v", ns, err)
+	}
+	if _, _, err := (kubeOptions{Kubeconfig: path, Context: "missing"}).load(); err == nil {
+		t.Error("load(--context missing) succeeded, want error")
+	}
+}
+
+func TestServerVersionOf(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/version" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"32","gitVersion":"v1.32.3"}`))
+	}))
+	defer srv.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := serverVersionOf(context.Background(), clientset); err != nil || v != "v1.32.3" {
+		t.Errorf("serverVersionOf() = %q, %v", v, err)
+	}
+}
+
+func TestSyntheticEventRoundTrip(t *testing.T) {
+	ev := buildEvent(syntheticEvent{
+		Namespace:          "team-a",
+		Type:               "Warning",
+		Reason:             "Reconcile",
+		Message:            "hello",
+		InvolvedKind:       "Pod",
+		InvolvedName:       "web-0",
+		InvolvedAPIVersion: "v1",
+		Component:          "eventkit",
+		Annotations:        map[string]string{"trace": "abc"},
+		Time:               time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+	if ev.GenerateName != "eventkit-" || ev.InvolvedObject.Namespace != "team-a" {
+		t.Errorf("buildEvent() = %+v", ev)
+	}
+
+	created, err := fake.NewClientset().CoreV1().Events("team-a").Create(context.Background(), ev, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion("v1")
+	u.SetKind("Event")
+	if !isEvent(u) {
+		t.Fatal("isEvent() = false for a core/v1 Event")
+	}
+
+	items, message := eventDetails(u)
+	want := []string{"Type=Warning", "Reason=Reconcile", "Object=Pod/web-0", "Source=eventkit", "Count=1", "Last Seen=2025-01-02T03:04:05Z"}
+	if message != "hello" || !slices.Equal(kvStrings(items), want) {
+		t.Errorf("eventDetails() = %v, %q", kvStrings(items), message)
+	}
+	if got := kvStrings(mapSection("Annotations", u.GetAnnotations()).Items); !slices.Equal(got, []string{"trace=abc"}) {
+		t.Errorf("annotations = %v", got)
+	}
+}
+
+func TestEventDetailsEventsV1(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion":          "events.k8s.io/v1",
+		"kind":                "Event",
+		"type":                "Normal",
+		"reason":              "Scheduled",
+		"note":                "Successfully assigned",
+		"regarding":           map[string]any{"kind": "Pod", "name": "web-1"},
+		"reportingController": "default-scheduler",
+		"eventTime":           "2025-01-02T03:04:05.000000Z",
+	}}
+	if !isEvent(u) {
+		t.Fatal("isEvent() = false for an events.k8s.io/v1 Event")
+	}
+
+	items, message := eventDetails(u)
+	want := []string{"Type=Normal", "Reason=Scheduled", "Object=Pod/web-1", "Source=default-scheduler", "Last Seen=2025-01-02T03:04:05.000000Z"}
+	if message != "Successfully assigned" || !slices.Equal(kvStrings(items), want) {
+		t.Errorf("eventDetails() = %v, %q", kvStrings(items), message)
+	}
+
+	u.SetAPIVersion("example.com/v1")
+	if isEvent(u) {
+		t.Error("isEvent() = true for a custom Event kind")
+	}
+}
+
+func kvStrings(items []toolutil.KV) []string {
+	out := make([]string, 0, len(items))
+	for _, kv := range items {
+		out = append(out, kv.Key+"="+kv.Value)
+	}
+	return out
+}
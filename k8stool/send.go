@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// syntheticEvent describes an Event to create.
+type syntheticEvent struct {
+	Namespace          string
+	Type               string
+	Reason             string
+	Message            string
+	InvolvedKind       string
+	InvolvedName       string
+	InvolvedAPIVersion string
+	Component          string
+	Host               string
+	Annotations        map[string]string
+	Time               time.Time
+}
+
+// buildEvent returns a core/v1 Event with a generated name.
+func buildEvent(e syntheticEvent) *corev1.Event {
+	ts := metav1.NewTime(e.Time)
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "eventkit-",
+			Namespace:    e.Namespace,
+			Annotations:  e.Annotations,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       e.InvolvedKind,
+			Name:       e.InvolvedName,
+			Namespace:  e.Namespace,
+			APIVersion: e.InvolvedAPIVersion,
+		},
+		Type:           e.Type,
+		Reason:         e.Reason,
+		Message:        e.Message,
+		Source:         corev1.EventSource{Component: e.Component, Host: e.Host},
+		FirstTimestamp: ts,
+		LastTimestamp:  ts,
+		Count:          1,
+	}
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		kubeOpts           kubeOptions
+		eventType          string
+		reason             string
+		involvedKind       string
+		involvedName       string
+		involvedAPIVersion string
+		component          string
+		sendPayload        string
+		sendMIME           string
+		sendInterval       string
+		headers            []string
+		noHeaderBase64     bool
+		openDelim          string
+		closeDelim         string
+		seed               int64
+		seedPerMessage     bool
+		allowFileReads     bool
+		allowEnvReads      bool
+		execOptions        toolutil.ExecOptions
+		payloadURL         string
+		strictTemplate     bool
+		templateVars       []string
+		fileRoot           string
+		cacheFiles         bool
+		dataSource         toolutil.DataSourceOptions
+		payloadSource      toolutil.PayloadSourceOptions
+		templateEngine     string
+		cloudEvents        toolutil.CloudEventsOptions
+		protoset           string
+		payloadSchema      string
+		corruptRate        float64
+		once               bool
+		printPayload       bool
+		interactive        bool
+		connectTimeout     time.Duration
+		connectRetry       toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Create periodic synthetic Event objects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if eventType != corev1.EventTypeNormal && eventType != corev1.EventTypeWarning {
+				return fmt.Errorf("invalid --type %q: expected Normal or Warning", eventType)
+			}
+			if involvedKind == "" {
+				return fmt.Errorf("--involved-kind must not be empty")
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			cfg, namespace, err := kubeOpts.load()
+			if err != nil {
+				return err
+			}
+			clientset, err := kubernetes.NewForConfig(cfg)
+			if err != nil {
+				return fmt.Errorf("error creating Kubernetes client: %w", err)
+			}
+			var serverVersion string
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					v, err := serverVersionOf(ctx, clientset)
+					if err != nil {
+						return err
+					}
+					serverVersion = v
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to Kubernetes API: %w", err)
+			}
+
+			annotations, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			host, _ := os.Hostname()
+
+			toolutil.PrintSuccess("Connected to Kubernetes API")
+			toolutil.PrintKeyValue("Host", cfg.Host)
+			toolutil.PrintKeyValue("Server Version", serverVersion)
+			toolutil.PrintKeyValue("Namespace", namespace)
+			toolutil.PrintKeyValue("Involved Object", involvedKind+"/"+involvedName)
+
+			reasonDest := toolutil.NewDestination(reason, openDelim, closeDelim)
+			nameDest := toolutil.NewDestination(involvedName, openDelim, closeDelim)
+			events := clientset.CoreV1().Events(namespace)
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				r, err := reasonDest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Reason build error: %v", err)
+					return err
+				}
+				name, err := nameDest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Involved name build error: %v", err)
+					return err
+				}
+				ev := buildEvent(syntheticEvent{
+					Namespace:          namespace,
+					Type:               eventType,
+					Reason:             r,
+					Message:            string(body),
+					InvolvedKind:       involvedKind,
+					InvolvedName:       name,
+					InvolvedAPIVersion: involvedAPIVersion,
+					Component:          component,
+					Host:               host,
+					Annotations:        annotations,
+					Time:               time.Now(),
+				})
+
+				sendCtx, sendCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer sendCancel()
+				created, err := events.Create(sendCtx, ev, metav1.CreateOptions{})
+				if err != nil {
+					toolutil.PrintError("Create error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Created %s Event '%s' (%s) with %d bytes", eventType, created.Name, r, len(body))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	kubeFlags(cmd, &kubeOpts)
+	cmd.Flags().StringVar(&eventType, "type", corev1.EventTypeNormal, "Event type: Normal or Warning")
+	cmd.Flags().StringVar(&reason, "reason", "EventkitTest", "Event reason (supports template placeholders)")
+	cmd.Flags().StringVar(&involvedKind, "involved-kind", "Pod", "Kind of the object the Event refers to")
+	cmd.Flags().StringVar(&involvedName, "involved-name", "eventkit", "Name of the object the Event refers to (supports template placeholders)")
+	cmd.Flags().StringVar(&involvedAPIVersion, "involved-api-version", "v1", "API version of the object the Event refers to")
+	cmd.Flags().StringVar(&component, "component", "eventkit", "Source component reported by the Event")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello from eventkit {{counter}}", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		kubeOpts       kubeOptions
+		gvrFlag        string
+		allNamespaces  bool
+		labelSelector  string
+		fieldSelector  string
+		existing       bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Watch Events or other resources and log changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gvr, err := parseGVR(gvrFlag)
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			cfg, namespace, err := kubeOpts.load()
+			if err != nil {
+				return err
+			}
+			client, err := dynamic.NewForConfig(cfg)
+			if err != nil {
+				return fmt.Errorf("error creating Kubernetes client: %w", err)
+			}
+			var resource dynamic.ResourceInterface = client.Resource(gvr)
+			if !allNamespaces {
+				resource = client.Resource(gvr).Namespace(namespace)
+			}
+
+			// The initial list provides the resource version the watch starts from.
+			var list *unstructured.UnstructuredList
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				opts := metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}
+				if !existing {
+					opts.Limit = 1
+				}
+				l, err := resource.List(ctx, opts)
+				if err != nil {
+					return err
+				}
+				list = l
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error listing %s: %w", gvrString(gvr), err)
+			}
+
+			toolutil.PrintSuccess("Watching Kubernetes resources")
+			toolutil.PrintKeyValue("Host", cfg.Host)
+			toolutil.PrintKeyValue("Resource", gvrString(gvr))
+			if allNamespaces {
+				toolutil.PrintKeyValue("Namespace", "(all)")
+			} else {
+				toolutil.PrintKeyValue("Namespace", namespace)
+			}
+			if labelSelector != "" {
+				toolutil.PrintKeyValue("Label Selector", labelSelector)
+			}
+			if fieldSelector != "" {
+				toolutil.PrintKeyValue("Field Selector", fieldSelector)
+			}
+
+			if existing {
+				for i := range list.Items {
+					printObject("EXISTING", &list.Items[i])
+				}
+			}
+
+			// The retry watcher re-establishes the watch when the server closes it, resuming from
+			// the last seen resource version.
+			rw, err := watchtools.NewRetryWatcher(list.GetResourceVersion(), &cache.ListWatch{
+				WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+					opts.LabelSelector = labelSelector
+					opts.FieldSelector = fieldSelector
+					opts.AllowWatchBookmarks = true
+					return resource.Watch(ctx, opts)
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("error watching %s: %w", gvrString(gvr), err)
+			}
+			defer rw.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case ev, ok := <-rw.ResultChan():
+					if !ok {
+						return fmt.Errorf("watch of %s ended", gvrString(gvr))
+					}
+					switch ev.Type {
+					case watch.Bookmark:
+						continue
+					case watch.Error:
+						return fmt.Errorf("watch error: %w", apierrors.FromObject(ev.Object))
+					}
+					if u, ok := ev.Object.(*unstructured.Unstructured); ok {
+						printObject(string(ev.Type), u)
+					}
+				}
+			}
+		},
+	}
+
+	kubeFlags(cmd, &kubeOpts)
+	cmd.Flags().StringVar(&gvrFlag, "gvr", "v1/events", "Resource to watch as VERSION/RESOURCE or GROUP/VERSION/RESOURCE, e.g. apps/v1/deployments")
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Watch all namespaces (required for cluster-scoped resources)")
+	cmd.Flags().StringVarP(&labelSelector, "label-selector", "l", "", "Label selector, e.g. app=web")
+	cmd.Flags().StringVar(&fieldSelector, "field-selector", "", "Field selector, e.g. involvedObject.kind=Pod")
+	cmd.Flags().BoolVar(&existing, "existing", false, "Print the objects that already exist before watching for changes")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// lastAppliedAnnotation holds a full copy of the object and is left out of the output.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// printObject prints a watched object. Events are shown with their message as the body,
+// any other object as JSON without its managed fields.
+func printObject(change string, u *unstructured.Unstructured) {
+	meta := []toolutil.KV{
+		{Key: "Kind", Value: u.GetKind()},
+		{Key: "API Version", Value: u.GetAPIVersion()},
+	}
+	if ns := u.GetNamespace(); ns != "" {
+		meta = append(meta, toolutil.KV{Key: "Namespace", Value: ns})
+	}
+	meta = append(meta,
+		toolutil.KV{Key: "Name", Value: u.GetName()},
+		toolutil.KV{Key: "Resource Version", Value: u.GetResourceVersion()},
+	)
+	if ts := u.GetCreationTimestamp(); !ts.IsZero() {
+		meta = append(meta, toolutil.KV{Key: "Created", Value: ts.UTC().Format(time.RFC3339)})
+	}
+
+	annotations := u.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+	sections := []toolutil.MessageSection{
+		{Title: "Watch", Items: []toolutil.KV{{Key: "Type", Value: change}}},
+		{Title: "Object", Items: meta},
+		mapSection("Labels", u.GetLabels()),
+		mapSection("Annotations", annotations),
+	}
+
+	if isEvent(u) {
+		items, message := eventDetails(u)
+		sections = append(sections, toolutil.MessageSection{Title: "Event", Items: items})
+		toolutil.PrintColoredMessage("Kubernetes", sections, []byte(message), toolutil.CTText)
+		return
+	}
+
+	obj := u.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	body, err := json.MarshalIndent(obj.Object, "", "  ")
+	if err != nil {
+		body = []byte(err.Error())
+	}
+	toolutil.PrintColoredMessage("Kubernetes", sections, body, toolutil.CTJSON)
+}
+
+// isEvent reports whether u is a core/v1 or events.k8s.io/v1 Event.
+func isEvent(u *unstructured.Unstructured) bool {
+	gv := u.GetAPIVersion()
+	return u.GetKind() == "Event" && (gv == "v1" || strings.HasPrefix(gv, "events.k8s.io/"))
+}
+
+// eventDetails extracts the Event fields and message, reading the events.k8s.io/v1 names
+// (note, regarding, reportingController) when the core/v1 ones are absent.
+func eventDetails(u *unstructured.Unstructured) ([]toolutil.KV, string) {
+	str := func(fields ...string) string {
+		s, _, _ := unstructured.NestedString(u.Object, fields...)
+		return s
+	}
+	first := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	ref := "involvedObject"
+	if _, ok := u.Object[ref]; !ok {
+		ref = "regarding"
+	}
+	var items []toolutil.KV
+	add := func(k, v string) {
+		if v != "" {
+			items = append(items, toolutil.KV{Key: k, Value: v})
+		}
+	}
+	add("Type", str("type"))
+	add("Reason", str("reason"))
+	if kind, name := str(ref, "kind"), str(ref, "name"); kind != "" || name != "" {
+		add("Object", kind+"/"+name)
+	}
+	add("Source", first(str("source", "component"), str("reportingController")))
+	count, _, _ := unstructured.NestedInt64(u.Object, "count")
+	if count == 0 {
+		count, _, _ = unstructured.NestedInt64(u.Object, "deprecatedCount")
+	}
+	if count > 0 {
+		add("Count", strconv.FormatInt(count, 10))
+	}
+	add("Last Seen", first(str("lastTimestamp"), str("eventTime"), str("deprecatedLastTimestamp")))
+	return items, first(str("message"), str("note"))
+}
+
+// mapSection renders m as a section with keys in sorted order.
+func mapSection(title string, m map[string]string) toolutil.MessageSection {
+	items := make([]toolutil.KV, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		items = append(items, toolutil.KV{Key: k, Value: m[k]})
+	}
+	return toolutil.MessageSection{Title: fmt.Sprintf("%s (%d)", title, len(items)), Items: items}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 	"time"
 
@@ -23,14 +24,34 @@ func sendCommand() *cobra.Command {
 		sendMIME       string
 		sendInterval   string
 		headers        []string
+		noHeaderBase64 bool
 		openDelim      string
 		closeDelim     string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
+		repeatBody     int
+		repeatSep      string
 	)
 
 	cmd := &cobra.Command{
@@ -40,27 +61,83 @@ func sendCommand() *cobra.Command {
 			ctx, cancel := common.SetupGracefulShutdown()
 			defer cancel()
 
-			w := kafka.NewWriter(kafka.WriterConfig{
-				Brokers: strings.Split(sendBrokers, ","),
-				Topic:   sendTopic,
-			})
-			defer func() {
-				if err := w.Close(); err != nil {
-					slog.Error("Failed to close Kafka writer", "error", err)
-				}
-			}()
-
 			if seed != 0 {
 				testpayload.SeedRandom(seed)
 			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
 			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
 			testpayload.SetFileRoot(fileRoot)
 			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply("ce_"); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
 			if varsMap, errVars := toolutil.ParseTemplateVars(templateVars); errVars != nil {
 				return fmt.Errorf("invalid template-var: %w", errVars)
 			} else {
 				testpayload.SetTemplateVars(varsMap)
 			}
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					return pingKafka(ctx, sendBrokers)
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to Kafka: %w", err)
+			}
+
+			newWriter := func() *kafka.Writer {
+				return kafka.NewWriter(kafka.WriterConfig{
+					Brokers: strings.Split(sendBrokers, ","),
+				})
+			}
+			w := newWriter()
+			defer func() {
+				if err := w.Close(); err != nil {
+					slog.Error("Failed to close Kafka writer", "error", err)
+				}
+			}()
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				if err := w.Close(); err != nil {
+					slog.Error("Failed to close Kafka writer", "error", err)
+				}
+				if err := connect(); err != nil {
+					return err
+				}
+				w = newWriter()
+				return nil
+			})
+
 			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
 			if err != nil {
 				return fmt.Errorf("invalid headers: %w", err)
@@ -69,14 +146,24 @@ func sendCommand() *cobra.Command {
 			logger := toolutil.Logger()
 			logger.Info("Producing to Kafka", "brokers", sendBrokers, "topic", sendTopic, "interval", sendInterval)
 
+			dest := toolutil.NewDestination(sendTopic, openDelim, closeDelim)
 			produce := func() error {
-				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err := reconnector.MaybeReconnect(); err != nil {
+					logger.Error("Reconnect error", "error", err)
+					return err
+				}
+				body, _, err := toolutil.BuildRepeatedPayloadWithDelimiters(repeatBody, repeatSep, sendPayload, sendMIME, openDelim, closeDelim)
 				if err != nil {
 					logger.Error("Failed to build payload", "error", err)
 					return err
 				}
-				msg := kafka.Message{Value: body}
-				for k, v := range headerMap {
+				topic, err := dest.Resolve()
+				if err != nil {
+					logger.Error("Failed to build topic", "error", err)
+					return err
+				}
+				msg := kafka.Message{Topic: topic, Value: body}
+				for k, v := range toolutil.CloudEventHeaders(headerMap) {
 					msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
 				}
 
@@ -87,26 +174,48 @@ func sendCommand() *cobra.Command {
 					logger.Error("Failed to send message", "error", err)
 					return err
 				}
-				logger.Info("Message sent", "bytes", len(body))
+				logger.Info("Message sent", "topic", topic, "bytes", len(body), "repeat", repeatBody)
 				return nil
 			}
 
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, produce)
+			}
 			return common.RunOnceOrPeriodic(ctx, once, sendInterval, produce)
 		},
 	}
 
 	cmd.Flags().StringVar(&sendBrokers, "brokers", "localhost:9092", "Kafka brokers (comma-separated)")
-	cmd.Flags().StringVar(&sendTopic, "topic", "test", "Kafka topic")
+	cmd.Flags().StringVar(&sendTopic, "topic", "test", "Kafka topic (supports template placeholders)")
 	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, Kafka!", &sendMIME, toolutil.CTText)
 	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddRepeatBodyFlags(cmd, &repeatBody, &repeatSep)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
 	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
 	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"strconv"
 	"strings"
@@ -14,15 +16,33 @@ import (
 
 func serveCommand() *cobra.Command {
 	var (
-		subBrokers string
-		subTopic   string
-		subGroup   string
+		subBrokers     string
+		subTopic       string
+		subGroup       string
+		verbose        bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Consume messages and print them",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				return pingKafka(ctx, subBrokers)
+			}); err != nil {
+				return fmt.Errorf("error connecting to Kafka: %w", err)
+			}
+
 			r := kafka.NewReader(kafka.ReaderConfig{
 				Brokers:  strings.Split(subBrokers, ","),
 				GroupID:  subGroup,
@@ -39,16 +59,13 @@ func serveCommand() *cobra.Command {
 			logger := toolutil.Logger()
 			logger.Info("Consuming from Kafka", "brokers", subBrokers, "topic", subTopic, "group", subGroup)
 
-			ctx, cancel := common.SetupGracefulShutdown()
-			defer cancel()
-
 			for {
 				select {
 				case <-ctx.Done():
 					logger.Info("Shutting down gracefully")
 					return nil
 				default:
-					m, err := r.ReadMessage(ctx)
+					m, err := r.FetchMessage(ctx)
 					if err != nil {
 						// Check if context was cancelled (graceful shutdown)
 						if ctx.Err() != nil {
@@ -64,18 +81,37 @@ func serveCommand() *cobra.Command {
 					for _, h := range m.Headers {
 						headerItems = append(headerItems, toolutil.KV{Key: h.Key, Value: string(h.Value)})
 					}
+					wellKnown, otherHeaders := toolutil.SplitWellKnownHeaders(headerItems)
+					if verbose {
+						otherHeaders = headerItems
+					}
 					sections := []toolutil.MessageSection{
 						{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: m.Topic}}},
-						{Title: "Meta", Items: []toolutil.KV{
+					}
+					if len(wellKnown) > 0 {
+						sections = append(sections, toolutil.MessageSection{Title: "Well-Known Headers", Items: wellKnown})
+					}
+					sections = append(sections,
+						toolutil.MessageSection{Title: "Meta", Items: []toolutil.KV{
 							{Key: "Partition", Value: strconv.Itoa(m.Partition)},
 							{Key: "Offset", Value: strconv.FormatInt(m.Offset, 10)},
 							{Key: "Time", Value: m.Time.Format(time.RFC3339)},
 						}},
-						{Title: "Key", Items: []toolutil.KV{{Key: "Value", Value: string(m.Key)}}},
-						{Title: "Headers", Items: headerItems},
-					}
+						toolutil.MessageSection{Title: "Key", Items: []toolutil.KV{{Key: "Value", Value: string(m.Key)}}},
+						toolutil.HeadersSection(otherHeaders),
+					)
 					ct := toolutil.GuessMIME(m.Value)
-					toolutil.PrintColoredMessage("Kafka", sections, m.Value, ct)
+					for _, h := range wellKnown {
+						if strings.EqualFold(h.Key, "content-type") && h.Value != "" {
+							ct = h.Value
+						}
+					}
+					// Offsets of messages dropped after --max-messages are not committed
+					if toolutil.PrintColoredMessage("Kafka", sections, m.Value, ct) && subGroup != "" {
+						if err := r.CommitMessages(context.Background(), m); err != nil {
+							logger.Error("Failed to commit offset", "error", err)
+						}
+					}
 				}
 			}
 		},
@@ -84,6 +120,9 @@ func serveCommand() *cobra.Command {
 	cmd.Flags().StringVar(&subBrokers, "brokers", "localhost:9092", "Kafka brokers (comma-separated)")
 	cmd.Flags().StringVar(&subTopic, "topic", "test", "Kafka topic")
 	cmd.Flags().StringVar(&subGroup, "group", "", "Kafka consumer group")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Also list well-known headers (content-type, correlation-id, trace-id) under Headers")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// pingKafka dials the configured brokers until one accepts a connection.
+// kafka-go readers and writers connect lazily, so this surfaces unreachable brokers upfront.
+func pingKafka(ctx context.Context, brokers string) error {
+	var lastErr error
+	for _, b := range strings.Split(brokers, ",") {
+		conn, err := kafka.DialContext(ctx, "tcp", strings.TrimSpace(b))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return conn.Close()
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no brokers configured")
+	}
+	return lastErr
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "kinesistool",
+		Short: "AWS Kinesis Data Streams client tester",
+		Long:  "A simple AWS Kinesis CLI with send and serve commands (LocalStack supported via --endpoint).",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxBatch is the PutRecords limit on records per request.
+const maxBatch = 500
+
+func sendCommand() *cobra.Command {
+	var (
+		awsOpts        awsutil.Options
+		stream         string
+		partitionKey   string
+		batch          int
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Put periodic records to a Kinesis stream",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batch < 1 || batch > maxBatch {
+				return fmt.Errorf("--batch must be between 1 and %d", maxBatch)
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var client *kinesis.Client
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					cfg, err := awsutil.LoadConfig(ctx, awsOpts)
+					if err != nil {
+						return err
+					}
+					client = kinesis.NewFromConfig(cfg)
+					if _, err := client.DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{StreamName: aws.String(stream)}); err != nil {
+						return fmt.Errorf("failed to describe stream %q: %w", stream, err)
+					}
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to Kinesis: %w", err)
+			}
+
+			toolutil.PrintSuccess("Connected to Kinesis")
+			toolutil.PrintKeyValue("Stream", stream)
+			toolutil.PrintKeyValue("Batch", batch)
+
+			key := toolutil.NewDestination(partitionKey, openDelim, closeDelim)
+			nextRecord := func() ([]byte, string, error) {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					return nil, "", fmt.Errorf("payload build error: %w", err)
+				}
+				k, err := key.Resolve()
+				if err != nil {
+					return nil, "", fmt.Errorf("partition key build error: %w", err)
+				}
+				return body, k, nil
+			}
+
+			send := func() error {
+				putCtx, putCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer putCancel()
+
+				if batch == 1 {
+					body, k, err := nextRecord()
+					if err != nil {
+						toolutil.PrintError("%v", err)
+						return err
+					}
+					out, err := client.PutRecord(putCtx, &kinesis.PutRecordInput{
+						StreamName:   aws.String(stream),
+						Data:         body,
+						PartitionKey: aws.String(k),
+					})
+					if err != nil {
+						toolutil.PrintError("Put error: %v", err)
+						return err
+					}
+					toolutil.PrintInfo("Put %d bytes with partition key '%s' to shard %s (sequence %s)", len(body), k, aws.ToString(out.ShardId), aws.ToString(out.SequenceNumber))
+					return nil
+				}
+
+				entries := make([]types.PutRecordsRequestEntry, 0, batch)
+				size := 0
+				for range batch {
+					body, k, err := nextRecord()
+					if err != nil {
+						toolutil.PrintError("%v", err)
+						return err
+					}
+					entries = append(entries, types.PutRecordsRequestEntry{Data: body, PartitionKey: aws.String(k)})
+					size += len(body)
+				}
+				out, err := client.PutRecords(putCtx, &kinesis.PutRecordsInput{StreamName: aws.String(stream), Records: entries})
+				if err != nil {
+					toolutil.PrintError("Put error: %v", err)
+					return err
+				}
+				failed := aws.ToInt32(out.FailedRecordCount)
+				if failed > 0 {
+					for _, r := range out.Records {
+						if r.ErrorCode != nil {
+							toolutil.PrintWarning("Record rejected: %s: %s", aws.ToString(r.ErrorCode), aws.ToString(r.ErrorMessage))
+							break
+						}
+					}
+					err := fmt.Errorf("%d of %d records failed", failed, len(entries))
+					toolutil.PrintError("Put error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Put %d records (%d bytes)", len(entries), size)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	awsutil.AddFlags(cmd, &awsOpts)
+	cmd.Flags().StringVar(&stream, "stream", "test-stream", "Stream name")
+	cmd.Flags().StringVar(&partitionKey, "partition-key", "{{uuid}}", "Partition key (supports template placeholders, resolved per record)")
+	cmd.Flags().IntVar(&batch, "batch", 1, fmt.Sprintf("Records per send; values above 1 use PutRecords (max %d)", maxBatch))
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, Kinesis!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
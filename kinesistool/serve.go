@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		awsOpts        awsutil.Options
+		stream         string
+		iteratorType   string
+		timestamp      string
+		pollInterval   time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Read every shard of a Kinesis stream and log records",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			itType, err := parseIteratorType(iteratorType)
+			if err != nil {
+				return err
+			}
+			var at *time.Time
+			if itType == types.ShardIteratorTypeAtTimestamp {
+				t, err := time.Parse(time.RFC3339, timestamp)
+				if err != nil {
+					return fmt.Errorf("--timestamp must be an RFC3339 time with --iterator-type at-timestamp: %w", err)
+				}
+				at = &t
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				client *kinesis.Client
+				shards []types.Shard
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				cfg, err := awsutil.LoadConfig(ctx, awsOpts)
+				if err != nil {
+					return err
+				}
+				client = kinesis.NewFromConfig(cfg)
+				shards, err = listShards(ctx, client, stream)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to Kinesis: %w", err)
+			}
+
+			toolutil.PrintSuccess("Reading Kinesis stream")
+			toolutil.PrintKeyValue("Stream", stream)
+			toolutil.PrintKeyValue("Shards", len(shards))
+			toolutil.PrintKeyValue("Iterator", string(itType))
+
+			var wg sync.WaitGroup
+			for _, shard := range shards {
+				wg.Add(1)
+				go func(shardID string) {
+					defer wg.Done()
+					readShard(ctx, client, stream, shardID, itType, at, pollInterval)
+				}(aws.ToString(shard.ShardId))
+			}
+			wg.Wait()
+			if ctx.Err() == nil {
+				toolutil.PrintWarning("All shards are closed")
+			}
+			return nil
+		},
+	}
+
+	awsutil.AddFlags(cmd, &awsOpts)
+	cmd.Flags().StringVar(&stream, "stream", "test-stream", "Stream name")
+	cmd.Flags().StringVar(&iteratorType, "iterator-type", "latest", "Where to start reading each shard: latest, trim-horizon or at-timestamp")
+	cmd.Flags().StringVar(&timestamp, "timestamp", "", "Start time (RFC3339) for --iterator-type at-timestamp")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", time.Second, "Delay between GetRecords calls on a shard with no new records")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// parseIteratorType accepts iterator types in flag style ("trim-horizon") or API style ("TRIM_HORIZON").
+func parseIteratorType(s string) (types.ShardIteratorType, error) {
+	t := types.ShardIteratorType(strings.ToUpper(strings.ReplaceAll(s, "-", "_")))
+	switch t {
+	case types.ShardIteratorTypeLatest, types.ShardIteratorTypeTrimHorizon, types.ShardIteratorTypeAtTimestamp:
+		return t, nil
+	}
+	return "", fmt.Errorf("invalid --iterator-type %q: expected latest, trim-horizon or at-timestamp", s)
+}
+
+// kinesisAPI is the part of the Kinesis client used to read a stream.
+type kinesisAPI interface {
+	ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error)
+	GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error)
+}
+
+func listShards(ctx context.Context, client kinesisAPI, stream string) ([]types.Shard, error) {
+	var shards []types.Shard
+	input := &kinesis.ListShardsInput{StreamName: aws.String(stream)}
+	for {
+		out, err := client.ListShards(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list shards of %q: %w", stream, err)
+		}
+		shards = append(shards, out.Shards...)
+		if out.NextToken == nil {
+			return shards, nil
+		}
+		// Pagination calls must carry only the token.
+		input = &kinesis.ListShardsInput{NextToken: out.NextToken}
+	}
+}
+
+// readShard prints the records of one shard until ctx ends or the shard is closed by a reshard.
+// Child shards created by resharding are not followed.
+func readShard(ctx context.Context, client kinesisAPI, stream, shardID string, itType types.ShardIteratorType, at *time.Time, pollInterval time.Duration) {
+	it, err := client.GetShardIterator(ctx, &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(stream),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: itType,
+		Timestamp:         at,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			toolutil.PrintError("Failed to get iterator for shard %s: %v", shardID, err)
+		}
+		return
+	}
+	iterator := it.ShardIterator
+
+	wait := func(d time.Duration) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(d):
+			return true
+		}
+	}
+
+	for iterator != nil {
+		out, err := client.GetRecords(ctx, &kinesis.GetRecordsInput{ShardIterator: iterator})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			var throttled *types.ProvisionedThroughputExceededException
+			if errors.As(err, &throttled) {
+				toolutil.PrintWarning("Shard %s throttled, backing off", shardID)
+			} else {
+				toolutil.PrintError("GetRecords error on shard %s: %v", shardID, err)
+			}
+			if !wait(5 * pollInterval) {
+				return
+			}
+			continue
+		}
+		for _, r := range out.Records {
+			printRecord(stream, shardID, r)
+		}
+		iterator = out.NextShardIterator
+		if len(out.Records) == 0 && !wait(pollInterval) {
+			return
+		}
+	}
+	toolutil.PrintInfo("Shard %s is closed", shardID)
+}
+
+func printRecord(stream, shardID string, r types.Record) {
+	items := []toolutil.KV{
+		{Key: "Shard", Value: shardID},
+		{Key: "Sequence", Value: aws.ToString(r.SequenceNumber)},
+		{Key: "Partition-Key", Value: aws.ToString(r.PartitionKey)},
+	}
+	if r.ApproximateArrivalTimestamp != nil {
+		items = append(items, toolutil.KV{Key: "Arrival", Value: r.ApproximateArrivalTimestamp.Format(time.RFC3339)})
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Stream", Items: []toolutil.KV{{Key: "Name", Value: stream}}},
+		{Title: "Record", Items: items},
+	}
+	toolutil.PrintColoredMessage("Kinesis", sections, r.Data, toolutil.GuessMIME(r.Data))
+}
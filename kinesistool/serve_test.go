@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestParseIteratorType(t *testing.T) {
+	tests := map[string]types.ShardIteratorType{
+		"latest":       types.ShardIteratorTypeLatest,
+		"trim-horizon": types.ShardIteratorTypeTrimHorizon,
+		"TRIM_HORIZON": types.ShardIteratorTypeTrimHorizon,
+		"at-timestamp": types.ShardIteratorTypeAtTimestamp,
+		"At_Timestamp": types.ShardIteratorTypeAtTimestamp,
+	}
+	for in, want := range tests {
+		got, err := parseIteratorType(in)
+		if err != nil || got != want {
+			t.Errorf("parseIteratorType(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	// Sequence-number iterators need a position this tool does not take.
+	for _, in := range []string{"", "oldest", "at-sequence-number"} {
+		if _, err := parseIteratorType(in); err == nil {
+			t.Errorf("parseIteratorType(%q) succeeded, want error", in)
+		}
+	}
+}
+
+// fakeKinesis serves shard pages and one batch of records per iterator, iterator "N" returning
+// records[N] and leading to "N+1" until the records run out and the shard is closed.
+type fakeKinesis struct {
+	pages     [][]types.Shard
+	listCalls []*kinesis.ListShardsInput
+	records   [][]types.Record
+	throttle  bool
+}
+
+func (f *fakeKinesis) ListShards(_ context.Context, params *kinesis.ListShardsInput, _ ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+	f.listCalls = append(f.listCalls, params)
+	n := len(f.listCalls)
+	if n > len(f.pages) {
+		return nil, errors.New("no more pages")
+	}
+	out := &kinesis.ListShardsOutput{Shards: f.pages[n-1]}
+	if n < len(f.pages) {
+		out.NextToken = aws.String("next")
+	}
+	return out, nil
+}
+
+func (f *fakeKinesis) GetShardIterator(_ context.Context, params *kinesis.GetShardIteratorInput, _ ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error) {
+	if aws.ToString(params.ShardId) != "shard-0" {
+		return nil, errors.New("unknown shard")
+	}
+	return &kinesis.GetShardIteratorOutput{ShardIterator: aws.String("0")}, nil
+}
+
+func (f *fakeKinesis) GetRecords(_ context.Context, params *kinesis.GetRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error) {
+	if f.throttle {
+		f.throttle = false
+		return nil, &types.ProvisionedThroughputExceededException{}
+	}
+	i, _ := strconv.Atoi(aws.ToString(params.ShardIterator))
+	out := &kinesis.GetRecordsOutput{Records: f.records[i]}
+	if i+1 < len(f.records) {
+		out.NextShardIterator = aws.String(strconv.Itoa(i + 1))
+	}
+	return out, nil
+}
+
+func TestListShards(t *testing.T) {
+	client := &fakeKinesis{pages: [][]types.Shard{
+		{{ShardId: aws.String("shard-0")}},
+		{{ShardId: aws.String("shard-1")}, {ShardId: aws.String("shard-2")}},
+	}}
+	shards, err := listShards(context.Background(), client, "orders")
+	if err != nil {
+		t.Fatalf("listShards() error = %v", err)
+	}
+	if len(shards) != 3 || aws.ToString(shards[2].ShardId) != "shard-2" {
+		t.Errorf("listShards() = %d shards, want all 3 pages", len(shards))
+	}
+	if next := client.listCalls[1]; next.StreamName != nil || aws.ToString(next.NextToken) != "next" {
+		t.Errorf("second page request = %+v, want only the token", next)
+	}
+
+	if _, err := listShards(context.Background(), &fakeKinesis{}, "orders"); err == nil {
+		t.Error("listShards() expected an error when ListShards fails")
+	}
+}
+
+func TestReadShard(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	arrival := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := &fakeKinesis{throttle: true, records: [][]types.Record{
+		{{Data: []byte(`{"n":1}`), PartitionKey: aws.String("a"), SequenceNumber: aws.String("1"), ApproximateArrivalTimestamp: &arrival}},
+		nil,
+		{{Data: []byte("two"), PartitionKey: aws.String("b"), SequenceNumber: aws.String("2")}},
+	}}
+	done := make(chan struct{})
+	go func() {
+		readShard(context.Background(), client, "orders", "shard-0", types.ShardIteratorTypeTrimHorizon, nil, time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("readShard() did not return on a closed shard")
+	}
+	if n := toolutil.ReceivedCount(); n != 2 {
+		t.Errorf("printed %d records, want 2", n)
+	}
+
+	// A shard whose iterator cannot be obtained is skipped.
+	readShard(context.Background(), client, "orders", "shard-9", types.ShardIteratorTypeLatest, nil, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	readShard(ctx, &fakeKinesis{records: [][]types.Record{nil, nil}}, "orders", "shard-0", types.ShardIteratorTypeLatest, nil, time.Hour)
+	if n := toolutil.ReceivedCount(); n != 2 {
+		t.Errorf("printed %d records after failed reads, want 2", n)
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/simonvetter/modbus"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "modbustool",
+		Short: "Modbus TCP register writer and poller",
+		Long:  "A simple Modbus CLI that writes templated values to holding registers and coils and polls registers, coils and inputs printing their changes.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// modbusOptions configure the connection to the Modbus server shared by both commands.
+type modbusOptions struct {
+	URL          string
+	UnitID       uint8
+	Timeout      time.Duration
+	Table        string
+	Address      uint16
+	Type         string
+	LittleEndian bool
+	LowWordFirst bool
+}
+
+// addModbusFlags registers the connection and data layout flags; tables lists the
+// register tables accepted by the command.
+func addModbusFlags(cmd *cobra.Command, opts *modbusOptions, tables string) {
+	cmd.Flags().StringVar(&opts.URL, "url", "tcp://localhost:502", "Modbus server URL: tcp://HOST:PORT, tcp+tls://HOST:PORT, udp://HOST:PORT or rtu:///dev/ttyUSB0")
+	cmd.Flags().Uint8Var(&opts.UnitID, "unit-id", 1, "Unit (slave) ID")
+	cmd.Flags().DurationVar(&opts.Timeout, "request-timeout", 2*time.Second, "Timeout of each Modbus request")
+	cmd.Flags().StringVar(&opts.Table, "table", "holding", "Register table: "+tables)
+	cmd.Flags().Uint16Var(&opts.Address, "address", 0, "First register or coil address (0-based)")
+	cmd.Flags().StringVar(&opts.Type, "type", "uint16", "Register value type: uint16, int16, uint32, int32, float32, uint64, int64 or float64 (ignored for coils and discrete inputs)")
+	cmd.Flags().BoolVar(&opts.LittleEndian, "little-endian", false, "Use little-endian byte order within 16-bit registers")
+	cmd.Flags().BoolVar(&opts.LowWordFirst, "low-word-first", false, "Store the low 16-bit word first in 32/64-bit values")
+}
+
+// validate checks the table and type flags against the tables accepted by the command.
+func (o modbusOptions) validate(tables ...string) error {
+	valid := false
+	for _, t := range tables {
+		valid = valid || o.Table == t
+	}
+	if !valid {
+		return fmt.Errorf("invalid --table %q: expected %s", o.Table, strings.Join(tables, ", "))
+	}
+	if !o.isBool() {
+		if _, ok := registerTypes[o.Type]; !ok {
+			return fmt.Errorf("invalid --type %q", o.Type)
+		}
+	}
+	return nil
+}
+
+// isBool reports whether the table holds single bits instead of 16-bit registers.
+func (o modbusOptions) isBool() bool {
+	return o.Table == "coil" || o.Table == "discrete"
+}
+
+// open connects to the Modbus server.
+func (o modbusOptions) open() (*modbus.ModbusClient, error) {
+	c, err := modbus.NewClient(&modbus.ClientConfiguration{
+		URL:     o.URL,
+		Timeout: o.Timeout,
+		Logger:  log.New(io.Discard, "", 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	endianness, wordOrder := modbus.BIG_ENDIAN, modbus.HIGH_WORD_FIRST
+	if o.LittleEndian {
+		endianness = modbus.LITTLE_ENDIAN
+	}
+	if o.LowWordFirst {
+		wordOrder = modbus.LOW_WORD_FIRST
+	}
+	if err := c.SetEncoding(endianness, wordOrder); err != nil {
+		return nil, err
+	}
+	if err := c.SetUnitId(o.UnitID); err != nil {
+		return nil, err
+	}
+	if err := c.Open(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// registerTypes maps the --type names to the number of 16-bit registers a value spans.
+var registerTypes = map[string]uint16{
+	"uint16": 1, "int16": 1,
+	"uint32": 2, "int32": 2, "float32": 2,
+	"uint64": 4, "int64": 4, "float64": 4,
+}
+
+// readValues reads count values of the table and type of opts, formatted as text.
+func readValues(c *modbus.ModbusClient, opts modbusOptions, count uint16) ([]string, error) {
+	switch opts.Table {
+	case "coil", "discrete":
+		read := c.ReadCoils
+		if opts.Table == "discrete" {
+			read = c.ReadDiscreteInputs
+		}
+		bits, err := read(opts.Address, count)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, len(bits))
+		for i, b := range bits {
+			out[i] = strconv.FormatBool(b)
+		}
+		return out, nil
+	}
+
+	regType := modbus.HOLDING_REGISTER
+	if opts.Table == "input" {
+		regType = modbus.INPUT_REGISTER
+	}
+	var out []string
+	switch opts.Type {
+	case "uint16", "int16":
+		regs, err := c.ReadRegisters(opts.Address, count, regType)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range regs {
+			if opts.Type == "int16" {
+				out = append(out, strconv.FormatInt(int64(int16(v)), 10)) // #nosec G115 -- two's complement reinterpretation
+			} else {
+				out = append(out, strconv.FormatUint(uint64(v), 10))
+			}
+		}
+	case "uint32", "int32", "float32":
+		vals, err := c.ReadUint32s(opts.Address, count, regType)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			switch opts.Type {
+			case "int32":
+				out = append(out, strconv.FormatInt(int64(int32(v)), 10)) // #nosec G115 -- two's complement reinterpretation
+			case "float32":
+				out = append(out, strconv.FormatFloat(float64(math.Float32frombits(v)), 'g', -1, 32))
+			default:
+				out = append(out, strconv.FormatUint(uint64(v), 10))
+			}
+		}
+	default:
+		vals, err := c.ReadUint64s(opts.Address, count, regType)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			switch opts.Type {
+			case "int64":
+				out = append(out, strconv.FormatInt(int64(v), 10)) // #nosec G115 -- two's complement reinterpretation
+			case "float64":
+				out = append(out, strconv.FormatFloat(math.Float64frombits(v), 'g', -1, 64))
+			default:
+				out = append(out, strconv.FormatUint(v, 10))
+			}
+		}
+	}
+	return out, nil
+}
+
+// writeValues writes text values starting at the address of opts, to coils or to holding
+// registers of the type of opts. Single values use the single-write function codes.
+func writeValues(c *modbus.ModbusClient, opts modbusOptions, values []string) error {
+	if opts.isBool() {
+		coils, err := parseCoils(values)
+		if err != nil {
+			return err
+		}
+		if len(coils) == 1 {
+			return c.WriteCoil(opts.Address, coils[0])
+		}
+		return c.WriteCoils(opts.Address, coils)
+	}
+
+	// Integers are kept as their two's complement bits, truncated to the register width below.
+	size := int(registerTypes[opts.Type]) * 16
+	var (
+		ints    []uint64
+		floats  []float32
+		doubles []float64
+	)
+	for _, s := range values {
+		invalid := fmt.Errorf("invalid %s value %q", opts.Type, s)
+		switch opts.Type {
+		case "uint16", "uint32", "uint64":
+			v, err := strconv.ParseUint(s, 0, size)
+			if err != nil {
+				return invalid
+			}
+			ints = append(ints, v)
+		case "int16", "int32", "int64":
+			v, err := strconv.ParseInt(s, 0, size)
+			if err != nil {
+				return invalid
+			}
+			ints = append(ints, uint64(v)) // #nosec G115 -- two's complement reinterpretation
+		case "float32":
+			v, err := strconv.ParseFloat(s, 32)
+			if err != nil {
+				return invalid
+			}
+			floats = append(floats, float32(v))
+		case "float64":
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return invalid
+			}
+			doubles = append(doubles, v)
+		}
+	}
+
+	switch opts.Type {
+	case "uint16", "int16":
+		words := make([]uint16, len(ints))
+		for i, v := range ints {
+			words[i] = uint16(v) // #nosec G115 -- range checked by ParseUint/ParseInt
+		}
+		if len(words) == 1 {
+			return c.WriteRegister(opts.Address, words[0])
+		}
+		return c.WriteRegisters(opts.Address, words)
+	case "uint32", "int32":
+		dwords := make([]uint32, len(ints))
+		for i, v := range ints {
+			dwords[i] = uint32(v) // #nosec G115 -- range checked by ParseUint/ParseInt
+		}
+		return c.WriteUint32s(opts.Address, dwords)
+	case "uint64", "int64":
+		return c.WriteUint64s(opts.Address, ints)
+	case "float32":
+		return c.WriteFloat32s(opts.Address, floats)
+	case "float64":
+		return c.WriteFloat64s(opts.Address, doubles)
+	}
+	return fmt.Errorf("unsupported type %q", opts.Type)
+}
+
+// parseCoils converts text values to coil states.
+func parseCoils(values []string) ([]bool, error) {
+	out := make([]bool, len(values))
+	for i, s := range values {
+		switch strings.ToLower(s) {
+		case "1", "true", "on":
+			out[i] = true
+		case "0", "false", "off":
+		default:
+			return nil, fmt.Errorf("invalid coil value %q: expected 1/0, true/false or on/off", s)
+		}
+	}
+	return out, nil
+}
+
+// splitValues splits a payload into the comma or whitespace separated values it holds.
+func splitValues(payload string) []string {
+	return strings.FieldsFunc(payload, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/simonvetter/modbus"
+)
+
+// memoryHandler is an in-memory Modbus data model; input registers mirror holding
+// registers and discrete inputs mirror coils.
+type memoryHandler struct {
+	mu    sync.Mutex
+	regs  [256]uint16
+	coils [256]bool
+}
+
+func (h *memoryHandler) HandleCoils(req *modbus.CoilsRequest) ([]bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if int(req.Addr)+int(req.Quantity) > len(h.coils) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	if req.IsWrite {
+		copy(h.coils[req.Addr:], req.Args)
+	}
+	return append([]bool(nil), h.coils[req.Addr:req.Addr+req.Quantity]...), nil
+}
+
+func (h *memoryHandler) HandleDiscreteInputs(req *modbus.DiscreteInputsRequest) ([]bool, error) {
+	return h.HandleCoils(&modbus.CoilsRequest{Addr: req.Addr, Quantity: req.Quantity})
+}
+
+func (h *memoryHandler) HandleHoldingRegisters(req *modbus.HoldingRegistersRequest) ([]uint16, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if int(req.Addr)+int(req.Quantity) > len(h.regs) {
+		return nil, modbus.ErrIllegalDataAddress
+	}
+	if req.IsWrite {
+		copy(h.regs[req.Addr:], req.Args)
+	}
+	return append([]uint16(nil), h.regs[req.Addr:req.Addr+req.Quantity]...), nil
+}
+
+func (h *memoryHandler) HandleInputRegisters(req *modbus.InputRegistersRequest) ([]uint16, error) {
+	return h.HandleHoldingRegisters(&modbus.HoldingRegistersRequest{Addr: req.Addr, Quantity: req.Quantity})
+}
+
+// startServer runs an in-memory Modbus TCP server and returns its URL.
+func startServer(t *testing.T) (string, *memoryHandler) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close() //nolint:errcheck
+
+	h := &memoryHandler{}
+	srv, err := modbus.NewServer(&modbus.ServerConfiguration{
+		URL:        "tcp://" + addr,
+		Timeout:    10 * time.Second,
+		MaxClients: 4,
+		Logger:     log.New(io.Discard, "", 0),
+	}, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Stop() }) //nolint:errcheck
+	return "tcp://" + addr, h
+}
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	url, h := startServer(t)
+
+	cases := []struct {
+		table, typ string
+		lowWord    bool
+		littleEnd  bool
+		values     string
+		want       string
+	}{
+		{"holding", "uint16", false, false, "42", "42"},
+		{"holding", "uint16", false, false, "1, 2,3", "1 2 3"},
+		{"holding", "int16", false, false, "-5 7", "-5 7"},
+		{"holding", "uint32", false, false, "70000", "70000"},
+		{"holding", "int32", true, false, "-70000,12", "-70000 12"},
+		{"holding", "float32", false, true, "21.5", "21.5"},
+		{"holding", "int64", false, false, "-9000000000", "-9000000000"},
+		{"holding", "float64", true, true, "3.14159 -1e10", "3.14159 -1e+10"},
+		{"holding", "uint16", false, false, "0x10", "16"},
+		{"coil", "", false, false, "1", "true"},
+		{"coil", "", false, false, "on,off,true", "true false true"},
+	}
+	for _, c := range cases {
+		t.Run(c.table+"/"+c.typ+"/"+c.values, func(t *testing.T) {
+			opts := modbusOptions{URL: url, UnitID: 1, Timeout: time.Second, Table: c.table, Address: 10, Type: c.typ, LittleEndian: c.littleEnd, LowWordFirst: c.lowWord}
+			client, err := opts.open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close() //nolint:errcheck
+
+			values := splitValues(c.values)
+			if err := writeValues(client, opts, values); err != nil {
+				t.Fatalf("writeValues() error: %v", err)
+			}
+			got, err := readValues(client, opts, uint16(len(values)))
+			if err != nil {
+				t.Fatalf("readValues() error: %v", err)
+			}
+			if strings.Join(got, " ") != c.want {
+				t.Errorf("read %q, want %q", got, c.want)
+			}
+		})
+	}
+
+	// Input registers and discrete inputs mirror the written holding registers and coils.
+	h.mu.Lock()
+	h.regs[0], h.regs[1] = 0x4049, 0x0fdb
+	h.coils[0] = true
+	h.mu.Unlock()
+	client, err := modbusOptions{URL: url, UnitID: 1, Timeout: time.Second}.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close() //nolint:errcheck
+	if got, err := readValues(client, modbusOptions{Table: "input", Type: "float32"}, 1); err != nil || got[0] != "3.1415927" {
+		t.Errorf("readValues(input float32) = %v, %v", got, err)
+	}
+	if got, err := readValues(client, modbusOptions{Table: "discrete"}, 2); err != nil || strings.Join(got, " ") != "true false" {
+		t.Errorf("readValues(discrete) = %v, %v", got, err)
+	}
+	if _, err := readValues(client, modbusOptions{Table: "holding", Type: "uint16", Address: 250}, 10); err == nil {
+		t.Error("readValues(out of range) succeeded, want error")
+	}
+}
+
+func TestWriteValuesInvalid(t *testing.T) {
+	url, _ := startServer(t)
+	opts := modbusOptions{URL: url, UnitID: 1, Timeout: time.Second, Table: "holding", Type: "int16"}
+	client, err := opts.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close() //nolint:errcheck
+	for _, c := range []struct{ table, typ, value string }{
+		{"holding", "int16", "40000"},
+		{"holding", "uint16", "-1"},
+		{"holding", "float32", "abc"},
+		{"coil", "", "maybe"},
+	} {
+		opts.Table, opts.Type = c.table, c.typ
+		if err := writeValues(client, opts, []string{c.value}); err == nil {
+			t.Errorf("writeValues(%s %s %q) succeeded, want error", c.table, c.typ, c.value)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []struct {
+		opts modbusOptions
+		ok   bool
+	}{
+		{modbusOptions{Table: "holding", Type: "float32"}, true},
+		{modbusOptions{Table: "coil", Type: "bogus"}, true},
+		{modbusOptions{Table: "input", Type: "uint16"}, false},
+		{modbusOptions{Table: "holding", Type: "uint8"}, false},
+	} {
+		err := c.opts.validate("holding", "coil")
+		if (err == nil) != c.ok {
+			t.Errorf("validate(%+v) = %v", c.opts, err)
+		}
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	format := func(cs []change) string {
+		var parts []string
+		for _, c := range cs {
+			parts = append(parts, fmt.Sprintf("%d:%s>%s", c.Address, c.Previous, c.Value))
+		}
+		return strings.Join(parts, " ")
+	}
+	if got := format(diffValues(nil, []string{"1", "2"}, 100, 2)); got != "100:>1 102:>2" {
+		t.Errorf("diffValues(initial) = %s", got)
+	}
+	if got := format(diffValues([]string{"1", "2", "3"}, []string{"1", "5", "3"}, 0, 1)); got != "1:2>5" {
+		t.Errorf("diffValues(change) = %s", got)
+	}
+	if got := format(diffValues([]string{"1", "2"}, []string{"1", "2"}, 0, 1)); got != "" {
+		t.Errorf("diffValues(unchanged) = %s", got)
+	}
+}
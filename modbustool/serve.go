@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/simonvetter/modbus"
+	"github.com/spf13/cobra"
+)
+
+// change is a value that differs from the previous poll.
+type change struct {
+	Address  uint16
+	Value    string
+	Previous string // empty on the first poll
+}
+
+// diffValues returns the values of cur that differ from prev, with their addresses; every
+// value is a change on the first poll (prev == nil). Values span width addresses each.
+func diffValues(prev, cur []string, base, width uint16) []change {
+	var out []change
+	for i, v := range cur {
+		var old string
+		if prev != nil {
+			if i < len(prev) && prev[i] == v {
+				continue
+			}
+			if i < len(prev) {
+				old = prev[i]
+			}
+		}
+		out = append(out, change{Address: base + uint16(i)*width, Value: v, Previous: old}) // #nosec G115 -- bounded by --count
+	}
+	return out
+}
+
+// printChange prints a changed register or coil value.
+func printChange(opts modbusOptions, c change) {
+	items := []toolutil.KV{
+		{Key: "Table", Value: opts.Table},
+		{Key: "Address", Value: strconv.Itoa(int(c.Address))},
+		{Key: "Unit ID", Value: strconv.Itoa(int(opts.UnitID))},
+	}
+	if !opts.isBool() {
+		items = append(items, toolutil.KV{Key: "Type", Value: opts.Type})
+	}
+	if c.Previous != "" {
+		items = append(items, toolutil.KV{Key: "Previous", Value: c.Previous})
+	}
+	toolutil.PrintColoredMessage("Modbus", []toolutil.MessageSection{{Title: "Register", Items: items}}, []byte(c.Value), toolutil.CTText)
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           modbusOptions
+		count          uint16
+		pollInterval   time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Poll Modbus registers or coils and log their changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate("holding", "input", "coil", "discrete"); err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("--count must be positive")
+			}
+			if pollInterval <= 0 {
+				return fmt.Errorf("--poll-interval must be positive")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var client *modbus.ModbusClient
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				c, err := opts.open()
+				if err != nil {
+					return err
+				}
+				client = c
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to Modbus server: %w", err)
+			}
+			defer client.Close() //nolint:errcheck
+
+			width := uint16(1)
+			if !opts.isBool() {
+				width = registerTypes[opts.Type]
+			}
+			// Fail fast on unreadable ranges, e.g. illegal data addresses.
+			prev, err := readValues(client, opts, count)
+			if err != nil {
+				return fmt.Errorf("error reading %s address %d: %w", opts.Table, opts.Address, err)
+			}
+
+			toolutil.PrintSuccess("Polling Modbus server")
+			toolutil.PrintKeyValue("URL", opts.URL)
+			toolutil.PrintKeyValue("Unit ID", opts.UnitID)
+			toolutil.PrintKeyValue("Range", fmt.Sprintf("%s %d-%d", opts.Table, opts.Address, opts.Address+count*width-1))
+			toolutil.PrintKeyValue("Poll Interval", pollInterval)
+
+			for _, c := range diffValues(nil, prev, opts.Address, width) {
+				printChange(opts, c)
+			}
+
+			ticker := time.NewTicker(pollInterval)
+			defer ticker.Stop()
+			failing := false
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case <-ticker.C:
+				}
+				cur, err := readValues(client, opts, count)
+				if err != nil {
+					if !failing {
+						toolutil.PrintWarning("Poll failed: %v", err)
+					}
+					failing = true
+					continue
+				}
+				if failing {
+					toolutil.PrintInfo("Polling resumed")
+					failing = false
+				}
+				for _, c := range diffValues(prev, cur, opts.Address, width) {
+					printChange(opts, c)
+				}
+				prev = cur
+			}
+		},
+	}
+
+	addModbusFlags(cmd, &opts, "holding, input, coil or discrete")
+	cmd.Flags().Uint16Var(&count, "count", 1, "Number of values to poll, starting at --address")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", time.Second, "Delay between polls")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
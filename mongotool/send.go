@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/sandrolain/eventkit/pkg/common"
@@ -23,11 +24,28 @@ func sendCommand() *cobra.Command {
 		mime           string
 		interval       string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -37,43 +55,103 @@ func sendCommand() *cobra.Command {
 			ctx, cancel := common.SetupGracefulShutdown()
 			defer cancel()
 
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &payload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&interval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(payload, mime, "{{", "}}")
+			}
+
 			// Connect to MongoDB
 			clientOpts := options.Client().ApplyURI(uri)
-			client, err := mongo.Connect(ctx, clientOpts)
-			if err != nil {
-				return fmt.Errorf("failed to connect to MongoDB: %w", err)
+			if connectTimeout > 0 {
+				clientOpts.SetConnectTimeout(connectTimeout).SetServerSelectionTimeout(connectTimeout)
+			}
+			var (
+				client *mongo.Client
+				coll   *mongo.Collection
+			)
+			connect := func() error {
+				c, err := mongo.Connect(ctx, clientOpts)
+				if err != nil {
+					return fmt.Errorf("failed to connect to MongoDB: %w", err)
+				}
+				// Ping to verify connection
+				if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), func() error {
+					return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+						return c.Ping(ctx, nil)
+					})
+				}); err != nil {
+					_ = c.Disconnect(context.Background())
+					return fmt.Errorf("failed to ping MongoDB: %w", err)
+				}
+				client, coll = c, c.Database(database).Collection(collection)
+				return nil
+			}
+			if err := connect(); err != nil {
+				return err
 			}
 			defer func() {
 				if err := client.Disconnect(context.Background()); err != nil {
 					toolutil.PrintError("Failed to disconnect: %v", err)
 				}
 			}()
-
-			// Ping to verify connection
-			if err := client.Ping(ctx, nil); err != nil {
-				return fmt.Errorf("failed to ping MongoDB: %w", err)
-			}
-
-			coll := client.Database(database).Collection(collection)
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				if err := client.Disconnect(context.Background()); err != nil {
+					toolutil.PrintError("Failed to disconnect: %v", err)
+				}
+				return connect()
+			})
 
 			toolutil.PrintSuccess("Connected to MongoDB")
 			toolutil.PrintKeyValue("URI", uri)
 			toolutil.PrintKeyValue("Database", database)
 			toolutil.PrintKeyValue("Collection", collection)
 			toolutil.PrintKeyValue("Interval", interval)
-			if seed != 0 {
-				testpayload.SeedRandom(seed)
-			}
-			testpayload.SetAllowFileReads(allowFileReads)
-			testpayload.SetFileRoot(fileRoot)
-			testpayload.SetFileCacheEnabled(cacheFiles)
-			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
-			if errVars != nil {
-				return fmt.Errorf("invalid template-var: %w", errVars)
-			}
-			testpayload.SetTemplateVars(varsMap)
 
 			insert := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					toolutil.PrintError("Reconnect error: %v", err)
+					return err
+				}
 				body, _, err := toolutil.BuildPayload(payload, mime)
 				if err != nil {
 					toolutil.PrintError("Payload build error: %v", err)
@@ -103,6 +181,9 @@ func sendCommand() *cobra.Command {
 				return nil
 			}
 
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &payload, insert)
+			}
 			return common.RunOnceOrPeriodic(ctx, once, interval, insert)
 		},
 	}
@@ -113,11 +194,28 @@ func sendCommand() *cobra.Command {
 	toolutil.AddPayloadFlags(cmd, &payload, `{"message":"{sentence}","timestamp":"{nowtime}"}`, &mime, toolutil.CTJSON)
 	toolutil.AddIntervalFlag(cmd, &interval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/sandrolain/eventkit/pkg/common"
 	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
@@ -14,20 +15,31 @@ import (
 
 func serveCommand() *cobra.Command {
 	var (
-		uri        string
-		database   string
-		collection string
+		uri            string
+		database       string
+		collection     string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Watch MongoDB collection for changes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := common.SetupGracefulShutdown()
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
 			defer cancel()
 
 			// Connect to MongoDB
 			clientOpts := options.Client().ApplyURI(uri)
+			if connectTimeout > 0 {
+				clientOpts.SetConnectTimeout(connectTimeout).SetServerSelectionTimeout(connectTimeout)
+			}
 			client, err := mongo.Connect(ctx, clientOpts)
 			if err != nil {
 				return fmt.Errorf("failed to connect to MongoDB: %w", err)
@@ -39,7 +51,9 @@ func serveCommand() *cobra.Command {
 			}()
 
 			// Ping to verify connection
-			if err := client.Ping(ctx, nil); err != nil {
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				return client.Ping(ctx, nil)
+			}); err != nil {
 				return fmt.Errorf("failed to ping MongoDB: %w", err)
 			}
 
@@ -114,7 +128,7 @@ func serveCommand() *cobra.Command {
 				toolutil.PrintColoredMessage("MongoDB", sections, docData, toolutil.CTJSON)
 			}
 
-			if err := changeStream.Err(); err != nil {
+			if err := changeStream.Err(); err != nil && ctx.Err() == nil {
 				return fmt.Errorf("change stream error: %w", err)
 			}
 
@@ -125,6 +139,8 @@ func serveCommand() *cobra.Command {
 	cmd.Flags().StringVar(&uri, "uri", "mongodb://localhost:27017", "MongoDB connection URI")
 	cmd.Flags().StringVar(&database, "database", "test", "Database name")
 	cmd.Flags().StringVar(&collection, "collection", "events", "Collection name")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
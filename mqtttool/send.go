@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -32,11 +34,28 @@ func sendCommand() *cobra.Command {
 		openDelim      string
 		closeDelim     string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -46,6 +65,51 @@ func sendCommand() *cobra.Command {
 			ctx, cancel := common.SetupGracefulShutdown()
 			defer cancel()
 
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
 			if !strings.HasPrefix(sendBroker, tcpPrefix) && !strings.HasPrefix(sendBroker, sslPrefix) && !strings.HasPrefix(sendBroker, wsPrefix) {
 				sendBroker = tcpPrefix + sendBroker
 			}
@@ -53,12 +117,23 @@ func sendCommand() *cobra.Command {
 			if sendClientID == "" {
 				sendClientID = fmt.Sprintf("mqttcli-pub-%d", time.Now().UnixNano())
 			}
-			opts.SetClientID(sendClientID).SetAutoReconnect(true)
+			opts.SetClientID(sendClientID).SetAutoReconnect(true).SetConnectTimeout(connectTimeout)
 			client := mqtt.NewClient(opts)
-			if token := client.Connect(); token.Wait() && token.Error() != nil {
-				return fmt.Errorf("MQTT connection error: %w", token.Error())
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+					token := client.Connect()
+					token.Wait()
+					return token.Error()
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("MQTT connection error: %w", err)
 			}
 			defer client.Disconnect(250)
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				client.Disconnect(250)
+				return connect()
+			})
 
 			toolutil.PrintSuccess("Connected to MQTT broker")
 			toolutil.PrintKeyValue("Broker", sendBroker)
@@ -66,59 +141,77 @@ func sendCommand() *cobra.Command {
 			toolutil.PrintKeyValue("QoS", sendQoS)
 			toolutil.PrintKeyValue("Interval", sendInterval)
 
-			if seed != 0 {
-				testpayload.SeedRandom(seed)
-			}
-			testpayload.SetAllowFileReads(allowFileReads)
-			testpayload.SetFileRoot(fileRoot)
-			testpayload.SetFileCacheEnabled(cacheFiles)
-			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
-			if errVars != nil {
-				return fmt.Errorf("invalid template-var: %w", errVars)
-			}
-			testpayload.SetTemplateVars(varsMap)
-
 			_, errHeaders := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
 			if errHeaders != nil {
 				return fmt.Errorf("invalid headers: %w", errHeaders)
 			}
 			// Note: MQTT v5 user properties can be set from headers
 
+			dest := toolutil.NewDestination(sendTopic, openDelim, closeDelim)
 			publish := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					toolutil.PrintError("Reconnect error: %v", err)
+					return err
+				}
 				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
 				if err != nil {
 					toolutil.PrintError("Payload build error: %v", err)
 					return err
 				}
-				token := client.Publish(sendTopic, byte(sendQoS), sendRetain, body)
+				topic, err := dest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Topic build error: %v", err)
+					return err
+				}
+				token := client.Publish(topic, byte(sendQoS), sendRetain, body)
 				token.Wait()
 				if token.Error() != nil {
 					toolutil.PrintError("Publish error: %v", token.Error())
 					return token.Error()
 				}
-				toolutil.PrintInfo("Published %d bytes to %s", len(body), sendTopic)
+				toolutil.PrintInfo("Published %d bytes to %s", len(body), topic)
 				return nil
 			}
 
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, publish)
+			}
 			return common.RunOnceOrPeriodic(ctx, once, sendInterval, publish)
 		},
 	}
 
 	cmd.Flags().StringVar(&sendBroker, "broker", "tcp://localhost:1883", "MQTT broker URL (tcp://host:port)")
-	cmd.Flags().StringVar(&sendTopic, "topic", "test/topic", "MQTT topic to publish to")
+	cmd.Flags().StringVar(&sendTopic, "topic", "test/topic", "MQTT topic to publish to (supports template placeholders)")
 	cmd.Flags().IntVar(&sendQoS, "qos", 0, "MQTT QoS level (0,1,2)")
 	cmd.Flags().BoolVar(&sendRetain, "retain", false, "Retain messages")
 	cmd.Flags().StringVar(&sendClientID, "clientid", "", "Client ID (auto if empty)")
 	toolutil.AddPayloadFlags(cmd, &sendPayload, "{}", &sendMIME, toolutil.CTText)
 	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
 	toolutil.AddHeadersFlag(cmd, &headers)
 	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -13,16 +14,27 @@ import (
 
 func serveCommand() *cobra.Command {
 	var (
-		subBroker   string
-		subTopic    string
-		subClientID string
-		subQoS      int
+		subBroker      string
+		subTopic       string
+		subClientID    string
+		subQoS         int
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Subscribe to a topic and log messages",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
 			if !strings.HasPrefix(subBroker, tcpPrefix) && !strings.HasPrefix(subBroker, sslPrefix) && !strings.HasPrefix(subBroker, wsPrefix) {
 				subBroker = tcpPrefix + subBroker
 			}
@@ -30,10 +42,14 @@ func serveCommand() *cobra.Command {
 				subClientID = fmt.Sprintf("mqttcli-sub-%d", time.Now().UnixNano())
 			}
 
-			opts := mqtt.NewClientOptions().AddBroker(subBroker).SetClientID(subClientID)
+			opts := mqtt.NewClientOptions().AddBroker(subBroker).SetClientID(subClientID).SetConnectTimeout(connectTimeout)
 			client := mqtt.NewClient(opts)
-			if token := client.Connect(); token.Wait() && token.Error() != nil {
-				return fmt.Errorf("error connecting to MQTT broker: %w", token.Error())
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				token := client.Connect()
+				token.Wait()
+				return token.Error()
+			}); err != nil {
+				return fmt.Errorf("error connecting to MQTT broker: %w", err)
 			}
 			defer client.Disconnect(250)
 
@@ -52,7 +68,7 @@ func serveCommand() *cobra.Command {
 				return fmt.Errorf("error subscribing to topic: %w", token.Error())
 			}
 
-			common.WaitForShutdown()
+			<-ctx.Done()
 			return nil
 		},
 	}
@@ -61,6 +77,8 @@ func serveCommand() *cobra.Command {
 	cmd.Flags().StringVar(&subTopic, "topic", "test/topic", "MQTT topic to subscribe to")
 	cmd.Flags().StringVar(&subClientID, "clientid", "", "Client ID (auto if empty)")
 	cmd.Flags().IntVar(&subQoS, "qos", 0, "MQTT QoS level (0,1,2)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf16"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+// System message types closing a conversation.
+const (
+	endDialogType = "http://schemas.microsoft.com/SQL/ServiceBroker/EndDialog"
+	errorType     = "http://schemas.microsoft.com/SQL/ServiceBroker/Error"
+)
+
+// brokerMessage is a message received from a Service Broker queue.
+type brokerMessage struct {
+	Conversation mssql.UniqueIdentifier
+	Sequence     int64
+	Type         string
+	Service      string
+	Contract     string
+	Body         []byte
+}
+
+// brokerQueue receives messages from a Service Broker queue.
+type brokerQueue struct {
+	db    *sql.DB
+	queue string
+	size  int
+	wait  time.Duration
+	// maxMessages is --max-messages: RECEIVE removes messages from the queue, so it takes
+	// no more than are left to print.
+	maxMessages int
+}
+
+// start checks that the queue exists and can be received from.
+func (q *brokerQueue) start(ctx context.Context) error {
+	var enabled bool
+	err := q.db.QueryRowContext(ctx, "SELECT is_receive_enabled FROM sys.service_queues WHERE object_id = OBJECT_ID(@p1)", quoteName(q.queue)).Scan(&enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("queue %s not found", q.queue)
+	}
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return fmt.Errorf("receive is disabled on queue %s", q.queue)
+	}
+	return nil
+}
+
+// receiveQuery waits for up to size messages, fewer when --max-messages is close; RECEIVE does
+// not take its timeout as a parameter.
+func (q *brokerQueue) receiveQuery() string {
+	n := q.size
+	if q.maxMessages > 0 {
+		n = max(1, min(n, q.maxMessages-toolutil.ReceivedCount()))
+	}
+	return fmt.Sprintf(`WAITFOR (RECEIVE TOP (%d) conversation_handle, message_sequence_number, message_type_name,
+service_name, service_contract_name, message_body FROM %s), TIMEOUT %d`, n, quoteName(q.queue), q.wait.Milliseconds())
+}
+
+// poll waits for messages and reports them in queue order. Conversations ended by the other
+// side are ended here too, so the broker can clean them up. Reporting stops when report
+// returns false.
+func (q *brokerQueue) poll(ctx context.Context, report func(brokerMessage) bool) error {
+	rows, err := q.db.QueryContext(ctx, q.receiveQuery())
+	if err != nil {
+		return err
+	}
+	var msgs []brokerMessage
+	for rows.Next() {
+		var m brokerMessage
+		if err := rows.Scan(&m.Conversation, &m.Sequence, &m.Type, &m.Service, &m.Contract, &m.Body); err != nil {
+			rows.Close() //nolint:errcheck
+			return err
+		}
+		msgs = append(msgs, m)
+	}
+	err = rows.Err()
+	rows.Close() //nolint:errcheck
+	if err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		switch m.Type {
+		case errorType:
+			toolutil.PrintWarning("Conversation %s ended with an error: %s", m.Conversation, decodeBody(m.Body))
+		case endDialogType:
+			toolutil.PrintInfo("Conversation %s ended", m.Conversation)
+		default:
+			if !report(m) {
+				return nil
+			}
+			continue
+		}
+		if _, err := q.db.ExecContext(ctx, "END CONVERSATION @p1", m.Conversation); err != nil {
+			return fmt.Errorf("failed to end conversation %s: %w", m.Conversation, err)
+		}
+	}
+	return nil
+}
+
+// decodeBody converts UTF-16 bodies, what casting an nvarchar to varbinary produces, to UTF-8.
+// They are recognized by a byte order mark or an ASCII first character.
+func decodeBody(b []byte) []byte {
+	if len(b) < 2 || len(b)%2 != 0 {
+		return b
+	}
+	switch {
+	case b[0] == 0xff && b[1] == 0xfe:
+		b = b[2:]
+	case b[0] != 0 && b[0] < 0x80 && b[1] == 0:
+	default:
+		return b
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+	}
+	return []byte(string(utf16.Decode(u)))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestQuoteName(t *testing.T) {
+	tests := map[string]string{
+		"events":     "[events]",
+		"dbo.events": "[dbo].[events]",
+		"we]ird":     "[we]]ird]",
+	}
+	for in, want := range tests {
+		if got := quoteName(in); got != want {
+			t.Errorf("quoteName(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestInsertStatement(t *testing.T) {
+	query, args, err := insertStatement("dbo.events", "", []byte(`{"message":"hi","count":3,"ratio":0.5,"ok":true,"tags":["a"],"meta":{"k":"v"},"gone":null,"a.b":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "INSERT INTO [dbo].[events] ([a.b], [count], [gone], [message], [meta], [ok], [ratio], [tags]) VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8)"
+	if query != want {
+		t.Errorf("query = %s", query)
+	}
+	wantArgs := []any{int64(1), int64(3), nil, "hi", `{"k":"v"}`, true, 0.5, `["a"]`}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("args = %v", args)
+	}
+	for i := range wantArgs {
+		if args[i] != wantArgs[i] {
+			t.Errorf("arg %d = %#v, want %#v", i, args[i], wantArgs[i])
+		}
+	}
+
+	query, args, err = insertStatement("events", "body", []byte("plain text"))
+	if err != nil || query != "INSERT INTO [events] ([body]) VALUES (@p1)" || len(args) != 1 || args[0] != "plain text" {
+		t.Errorf("column insert = %s, %v, %v", query, args, err)
+	}
+
+	for _, bad := range []string{`[1,2]`, `{}`, `not json`} {
+		if _, _, err := insertStatement("events", "", []byte(bad)); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestChangesQuery(t *testing.T) {
+	c := &changeTracker{table: "dbo.orders", keys: []string{"tenant", "id"}}
+	q := c.changesQuery()
+	for _, part := range []string{
+		"(SELECT ct.[tenant], ct.[id] FOR JSON PATH, WITHOUT_ARRAY_WRAPPER)",
+		"CASE WHEN t.[tenant] IS NULL THEN NULL",
+		"FROM CHANGETABLE(CHANGES [dbo].[orders], @p1) AS ct",
+		"LEFT JOIN [dbo].[orders] AS t ON t.[tenant] = ct.[tenant] AND t.[id] = ct.[id]",
+		"ORDER BY ct.SYS_CHANGE_VERSION",
+	} {
+		if !strings.Contains(q, part) {
+			t.Errorf("query lacks %q:\n%s", part, q)
+		}
+	}
+}
+
+func TestReceiveQuery(t *testing.T) {
+	q := (&brokerQueue{queue: "dbo.EventQueue", size: 10, wait: 1500 * time.Millisecond}).receiveQuery()
+	if !strings.HasPrefix(q, "WAITFOR (RECEIVE TOP (10) ") || !strings.HasSuffix(q, "FROM [dbo].[EventQueue]), TIMEOUT 1500") {
+		t.Errorf("unexpected query: %s", q)
+	}
+}
+
+func TestReceiveQuery_MaxMessages(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{MaxMessages: 3, AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	q := &brokerQueue{queue: "q", size: 10, wait: time.Second, maxMessages: 3}
+	report := printBrokerMessage("q")
+	for _, want := range []string{"TOP (3) ", "TOP (2) ", "TOP (1) "} {
+		if got := q.receiveQuery(); !strings.Contains(got, want) {
+			t.Errorf("unexpected query: %s, want %s", got, want)
+		}
+		if !report(brokerMessage{Type: "t", Body: []byte("hi")}) {
+			t.Fatal("message dropped before --max-messages")
+		}
+	}
+	if report(brokerMessage{Type: "t", Body: []byte("hi")}) {
+		t.Error("message printed past --max-messages")
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := map[string]string{
+		"{\x00}\x00":            "{}",
+		"\xff\xfeh\x00\xe9\x00": "hé",
+		`{"a":1}`:               `{"a":1}`,
+		"x":                     "x",
+		"\x00\x01":              "\x00\x01",
+	}
+	for in, want := range tests {
+		if got := string(decodeBody([]byte(in))); got != want {
+			t.Errorf("decodeBody(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
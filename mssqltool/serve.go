@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// Serve modes.
+const (
+	modeChangeTracking = "changetracking"
+	modeServiceBroker  = "servicebroker"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		connStr        string
+		mode           string
+		table          string
+		existing       bool
+		pollInterval   time.Duration
+		queue          string
+		size           int
+		wait           time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Print table changes from change tracking or messages from a Service Broker queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var db *sql.DB
+			var start, poll func(ctx context.Context) error
+			// idle is the delay between successful polls; RECEIVE already waits for messages.
+			var idle time.Duration
+			switch mode {
+			case modeChangeTracking:
+				t := &changeTracker{table: table}
+				start = func(ctx context.Context) error {
+					t.db = db
+					return t.start(ctx, existing)
+				}
+				poll = func(ctx context.Context) error { return t.poll(ctx, printChange(table)) }
+				idle = pollInterval
+			case modeServiceBroker:
+				if size < 1 {
+					return fmt.Errorf("--size must be at least 1")
+				}
+				if wait <= 0 {
+					return fmt.Errorf("--wait must be positive")
+				}
+				q := &brokerQueue{queue: queue, size: size, wait: wait, maxMessages: serveOpts.MaxMessages}
+				start = func(ctx context.Context) error {
+					q.db = db
+					return q.start(ctx)
+				}
+				poll = func(ctx context.Context) error { return q.poll(ctx, printBrokerMessage(queue)) }
+			default:
+				return fmt.Errorf("invalid --mode %q: expected %s or %s", mode, modeChangeTracking, modeServiceBroker)
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var version string
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				var err error
+				if db, version, err = dial(ctx, connStr); err != nil {
+					return err
+				}
+				return start(ctx)
+			}); err != nil {
+				return fmt.Errorf("error connecting to SQL Server: %w", err)
+			}
+			defer db.Close() //nolint:errcheck
+
+			if mode == modeChangeTracking {
+				toolutil.PrintSuccess("Polling SQL Server %s change tracking", version)
+				toolutil.PrintKeyValue("Table", table)
+			} else {
+				toolutil.PrintSuccess("Receiving from SQL Server %s Service Broker", version)
+				toolutil.PrintKeyValue("Queue", queue)
+			}
+
+			failing := false
+			for {
+				delay := idle
+				if err := poll(ctx); err != nil {
+					if ctx.Err() != nil {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					if !failing {
+						toolutil.PrintWarning("Poll failed: %v", err)
+						failing = true
+					}
+					delay = pollInterval
+				} else if failing {
+					toolutil.PrintInfo("Polling resumed")
+					failing = false
+				}
+
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case <-time.After(delay):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&connStr, "conn", defaultConn, "SQL Server connection string")
+	cmd.Flags().StringVar(&mode, "mode", modeChangeTracking, "Change source: changetracking (poll a table) or servicebroker (receive from a queue)")
+	cmd.Flags().StringVar(&table, "table", "dbo.events", "Table with change tracking enabled, optionally schema.table (changetracking)")
+	cmd.Flags().BoolVar(&existing, "existing", false, "Also print the retained changes made before polling started (changetracking)")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 2*time.Second, "Delay between polls, and before retrying a failed receive")
+	cmd.Flags().StringVar(&queue, "queue", "dbo.EventQueue", "Service Broker queue, optionally schema.queue (servicebroker)")
+	cmd.Flags().IntVar(&size, "size", 100, "Messages received at most per RECEIVE (servicebroker)")
+	cmd.Flags().DurationVar(&wait, "wait", 5*time.Second, "How long each RECEIVE waits for messages (servicebroker)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// printChange returns a reporter printing the changes of a table.
+func printChange(table string) func(change) {
+	return func(ch change) {
+		op := operations[ch.Operation]
+		if op == "" {
+			op = ch.Operation
+		}
+		sections := []toolutil.MessageSection{{Title: "Change", Items: []toolutil.KV{
+			{Key: "Table", Value: table},
+			{Key: "Operation", Value: op},
+			{Key: "Version", Value: strconv.FormatInt(ch.Version, 10)},
+			{Key: "Key", Value: ch.Key},
+		}}}
+		toolutil.PrintColoredMessage("SQL Server", sections, ch.Row, toolutil.CTJSON)
+	}
+}
+
+// printBrokerMessage returns a reporter printing the messages of a queue.
+func printBrokerMessage(queue string) func(brokerMessage) bool {
+	return func(m brokerMessage) bool {
+		body := decodeBody(m.Body)
+		sections := []toolutil.MessageSection{
+			{Title: "Queue", Items: []toolutil.KV{{Key: "Name", Value: queue}}},
+			{Title: "Message", Items: []toolutil.KV{
+				{Key: "Type", Value: m.Type},
+				{Key: "Conversation", Value: m.Conversation.String()},
+				{Key: "Sequence", Value: strconv.FormatInt(m.Sequence, 10)},
+				{Key: "Service", Value: m.Service},
+				{Key: "Contract", Value: m.Contract},
+			}},
+		}
+		return toolutil.PrintColoredMessage("SQL Server Service Broker", sections, body, toolutil.GuessMIME(body))
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+// change is a row change reported by change tracking. Row is nil when the row no longer exists.
+type change struct {
+	Version   int64
+	Operation string
+	Key       string
+	Row       []byte
+}
+
+// operations names the SYS_CHANGE_OPERATION codes.
+var operations = map[string]string{"I": "insert", "U": "update", "D": "delete"}
+
+// changeTracker polls CHANGETABLE(CHANGES ...) of a table with change tracking enabled, joining
+// the current row values by primary key.
+type changeTracker struct {
+	db      *sql.DB
+	table   string
+	keys    []string
+	version int64
+}
+
+// primaryKey looks up the primary key columns of the table, which change tracking requires.
+func (c *changeTracker) primaryKey(ctx context.Context) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `SELECT c.name FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(@p1) AND i.is_primary_key = 1
+ORDER BY ic.key_ordinal`, quoteName(c.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s not found or has no primary key", c.table)
+	}
+	return keys, nil
+}
+
+// minValidVersion returns the oldest version changes can be read from; older changes were
+// removed by the retention cleanup.
+func (c *changeTracker) minValidVersion(ctx context.Context) (int64, error) {
+	var v sql.NullInt64
+	if err := c.db.QueryRowContext(ctx, "SELECT CHANGE_TRACKING_MIN_VALID_VERSION(OBJECT_ID(@p1))", quoteName(c.table)).Scan(&v); err != nil {
+		return 0, err
+	}
+	if !v.Valid {
+		return 0, fmt.Errorf("change tracking is not enabled on table %s", c.table)
+	}
+	return v.Int64, nil
+}
+
+// start checks the table and sets the cursor to the current version or, when existing changes
+// are wanted, to the oldest retained one.
+func (c *changeTracker) start(ctx context.Context, existing bool) error {
+	keys, err := c.primaryKey(ctx)
+	if err != nil {
+		return err
+	}
+	c.keys = keys
+	minValid, err := c.minValidVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if existing {
+		c.version = minValid
+		return nil
+	}
+	return c.db.QueryRowContext(ctx, "SELECT CHANGE_TRACKING_CURRENT_VERSION()").Scan(&c.version)
+}
+
+// changesQuery returns the query of the changes after a version, with the primary key and the
+// current row as JSON objects.
+func (c *changeTracker) changesQuery() string {
+	table := quoteName(c.table)
+	key := make([]string, len(c.keys))
+	on := make([]string, len(c.keys))
+	for i, k := range c.keys {
+		key[i] = "ct." + quoteIdent(k)
+		on[i] = "t." + quoteIdent(k) + " = ct." + quoteIdent(k)
+	}
+	return fmt.Sprintf(`SELECT ct.SYS_CHANGE_VERSION, ct.SYS_CHANGE_OPERATION,
+(SELECT %s FOR JSON PATH, WITHOUT_ARRAY_WRAPPER),
+CASE WHEN t.%s IS NULL THEN NULL ELSE (SELECT t.* FOR JSON PATH, WITHOUT_ARRAY_WRAPPER, INCLUDE_NULL_VALUES) END
+FROM CHANGETABLE(CHANGES %s, @p1) AS ct
+LEFT JOIN %s AS t ON %s
+ORDER BY ct.SYS_CHANGE_VERSION`, strings.Join(key, ", "), quoteIdent(c.keys[0]), table, table, strings.Join(on, " AND "))
+}
+
+// poll reports the changes since the previous poll in version order. Changes are net: a row
+// inserted and updated between two polls is reported once, with its latest values.
+func (c *changeTracker) poll(ctx context.Context, report func(change)) error {
+	minValid, err := c.minValidVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if minValid > c.version {
+		toolutil.PrintWarning("Changes up to version %d were cleaned up before being read; resuming from %d", minValid, minValid)
+		c.version = minValid
+	}
+
+	rows, err := c.db.QueryContext(ctx, c.changesQuery(), c.version)
+	if err != nil {
+		return err
+	}
+	defer rows.Close() //nolint:errcheck
+	for rows.Next() {
+		var ch change
+		var row sql.NullString
+		if err := rows.Scan(&ch.Version, &ch.Operation, &ch.Key, &row); err != nil {
+			return err
+		}
+		if row.Valid {
+			ch.Row = []byte(row.String)
+		}
+		c.version = ch.Version
+		report(ch)
+	}
+	return rows.Err()
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"os"
+	"time"
 
+	"github.com/nats-io/nats.go"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
 	"github.com/spf13/cobra"
 )
 
@@ -13,9 +16,19 @@ func main() {
 		Long:  "A simple NATS CLI with send and serve commands (supports JetStream).",
 	}
 
+	toolutil.AddGlobalFlags(root)
 	root.AddCommand(sendCommand(), serveCommand())
 
 	if err := root.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
+
+// connectTimeoutOrDefault returns the per-server dial timeout for nats.Connect,
+// falling back to the client default when --connect-timeout is disabled.
+func connectTimeoutOrDefault(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return nats.GetDefaultOptions().Timeout
+	}
+	return timeout
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/sandrolain/eventkit/pkg/common"
@@ -19,14 +22,32 @@ func sendCommand() *cobra.Command {
 		sendInterval   string
 		sendStream     string
 		headers        []string
+		noHeaderBase64 bool
 		openDelim      string
 		closeDelim     string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -36,24 +57,67 @@ func sendCommand() *cobra.Command {
 			ctx, cancel := common.SetupGracefulShutdown()
 			defer cancel()
 
-			nc, err := nats.Connect(sendAddr)
-			if err != nil {
-				return fmt.Errorf("error connecting to NATS: %w", err)
-			}
-			defer nc.Close()
-
-			var js nats.JetStreamContext
 			if seed != 0 {
 				testpayload.SeedRandom(seed)
 			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
 			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
 			testpayload.SetFileRoot(fileRoot)
 			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply("ce-"); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
 			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
 			if errVars != nil {
 				return fmt.Errorf("invalid template-var: %w", errVars)
 			}
 			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var nc *nats.Conn
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+					var errConn error
+					nc, errConn = nats.Connect(sendAddr, nats.Timeout(connectTimeoutOrDefault(connectTimeout)))
+					return errConn
+				})
+			}
+			err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect)
+			if err != nil {
+				return fmt.Errorf("error connecting to NATS: %w", err)
+			}
+			defer func() { nc.Close() }()
+
+			var js nats.JetStreamContext
 			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
 			if err != nil {
 				return fmt.Errorf("invalid headers: %w", err)
@@ -73,17 +137,40 @@ func sendCommand() *cobra.Command {
 				toolutil.PrintKeyValue("Subject", sendSubject)
 			}
 
+			dest := toolutil.NewDestination(sendSubject, openDelim, closeDelim)
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				nc.Close()
+				if err := connect(); err != nil {
+					return err
+				}
+				if sendStream != "" {
+					var err error
+					if js, err = nc.JetStream(); err != nil {
+						return fmt.Errorf("JetStream context error: %w", err)
+					}
+				}
+				return nil
+			})
 			publish := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					toolutil.PrintError("Reconnect error: %v", err)
+					return err
+				}
 				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
 				if err != nil {
 					toolutil.PrintError("Payload build error: %v", err)
 					return err
 				}
+				subject, err := dest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Subject build error: %v", err)
+					return err
+				}
 
 				// Build NATS message with headers
-				msg := nats.NewMsg(sendSubject)
+				msg := nats.NewMsg(subject)
 				msg.Data = body
-				for k, v := range headerMap {
+				for k, v := range toolutil.CloudEventHeaders(headerMap) {
 					msg.Header.Add(k, v)
 				}
 
@@ -104,23 +191,44 @@ func sendCommand() *cobra.Command {
 				return nil
 			}
 
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, publish)
+			}
 			return common.RunOnceOrPeriodic(ctx, once, sendInterval, publish)
 		},
 	}
 
 	cmd.Flags().StringVar(&sendAddr, "address", nats.DefaultURL, "NATS server URL")
-	cmd.Flags().StringVar(&sendSubject, "subject", "test.subject", "NATS subject")
+	cmd.Flags().StringVar(&sendSubject, "subject", "test.subject", "NATS subject (supports template placeholders)")
 	toolutil.AddPayloadFlags(cmd, &sendPayload, "{nowtime}", &sendMIME, toolutil.CTText)
 	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
 	cmd.Flags().StringVar(&sendStream, "stream", "", "JetStream stream name (if set, uses JetStream)")
 	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
 	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
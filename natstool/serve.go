@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/sandrolain/eventkit/pkg/common"
@@ -11,17 +13,44 @@ import (
 
 func serveCommand() *cobra.Command {
 	var (
-		subAddr    string
-		subSubject string
-		subStream  string
-		subDurable string
+		subAddr        string
+		subSubject     string
+		subStream      string
+		subDurable     string
+		subDeliver     string
+		subStartSeq    uint64
+		subStartTime   string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Subscribe to a subject and log messages",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			nc, err := nats.Connect(subAddr)
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			deliverOpt, err := deliverPolicyOpt(subDeliver, subStartSeq, subStartTime)
+			if err != nil {
+				return err
+			}
+			if subStream == "" && cmd.Flags().Changed("deliver") {
+				return fmt.Errorf("--deliver requires --stream (JetStream)")
+			}
+
+			var nc *nats.Conn
+			err = common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				var errConn error
+				nc, errConn = nats.Connect(subAddr, nats.Timeout(connectTimeoutOrDefault(connectTimeout)))
+				return errConn
+			})
 			if err != nil {
 				return fmt.Errorf("error connecting to NATS: %w", err)
 			}
@@ -38,10 +67,16 @@ func serveCommand() *cobra.Command {
 					for k, v := range msg.Header {
 						headerItems = append(headerItems, toolutil.KV{Key: k, Value: fmt.Sprintf("%v", v)})
 					}
-					sections = append(sections, toolutil.MessageSection{Title: "Headers", Items: headerItems})
+					sections = append(sections, toolutil.HeadersSection(headerItems))
 				}
 				ct := toolutil.GuessMIME(msg.Data)
-				toolutil.PrintColoredMessage("NATS", sections, msg.Data, ct)
+				if !toolutil.PrintColoredMessage("NATS", sections, msg.Data, ct) {
+					// Dropped after --max-messages: have JetStream redeliver it instead of auto-acking
+					if subStream != "" {
+						_ = msg.Nak()
+					}
+					return
+				}
 				if msg.Reply != "" {
 					if err := nc.Publish(msg.Reply, []byte("OK")); err != nil {
 						toolutil.PrintError("Failed to send reply: %v", err)
@@ -55,8 +90,8 @@ func serveCommand() *cobra.Command {
 				if err != nil {
 					return fmt.Errorf("JetStream context error: %w", err)
 				}
-				fmt.Printf("Listening (JetStream) on %s, subject '%s', stream '%s'\n", subAddr, subSubject, subStream)
-				opts := []nats.SubOpt{nats.BindStream(subStream), nats.DeliverNew()}
+				toolutil.PrintInfo("Listening (JetStream) on %s, subject '%s', stream '%s'", subAddr, subSubject, subStream)
+				opts := []nats.SubOpt{nats.BindStream(subStream), deliverOpt}
 				if subDurable != "" {
 					opts = append(opts, nats.Durable(subDurable))
 				}
@@ -65,7 +100,7 @@ func serveCommand() *cobra.Command {
 					return fmt.Errorf("error subscribing (JetStream): %w", err)
 				}
 			} else {
-				fmt.Printf("Listening on %s, subject '%s'\n", subAddr, subSubject)
+				toolutil.PrintInfo("Listening on %s, subject '%s'", subAddr, subSubject)
 				sub, err = nc.Subscribe(subSubject, handler)
 				if err != nil {
 					return fmt.Errorf("error subscribing to subject: %w", err)
@@ -77,13 +112,14 @@ func serveCommand() *cobra.Command {
 				toolutil.PrintKeyValue("Address", subAddr)
 				toolutil.PrintKeyValue("Subject", subSubject)
 				toolutil.PrintKeyValue("Stream", subStream)
+				toolutil.PrintKeyValue("Deliver", subDeliver)
 			} else {
 				toolutil.PrintSuccess("Subscribed to NATS")
 				toolutil.PrintKeyValue("Address", subAddr)
 				toolutil.PrintKeyValue("Subject", subSubject)
 			}
 
-			common.WaitForShutdown()
+			<-ctx.Done()
 
 			if err := sub.Drain(); err != nil {
 				toolutil.PrintError("Failed to drain subscription: %v", err)
@@ -96,6 +132,49 @@ func serveCommand() *cobra.Command {
 	cmd.Flags().StringVar(&subSubject, "subject", "test", "NATS subject to listen on")
 	cmd.Flags().StringVar(&subStream, "stream", "", "JetStream stream name (if set, uses JetStream consumer)")
 	cmd.Flags().StringVar(&subDurable, "durable", "", "JetStream durable consumer name (optional)")
+	cmd.Flags().StringVar(&subDeliver, "deliver", "new", "JetStream deliver policy: new, all, last, last-per-subject, by-start-seq, by-start-time")
+	cmd.Flags().Uint64Var(&subStartSeq, "start-seq", 0, "Stream sequence to start from (with --deliver by-start-seq)")
+	cmd.Flags().StringVar(&subStartTime, "start-time", "", "RFC3339 time to start from (with --deliver by-start-time)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
+
+// deliverPolicyOpt maps a --deliver value to the JetStream subscription option,
+// validating the companion --start-seq/--start-time values.
+func deliverPolicyOpt(policy string, startSeq uint64, startTime string) (nats.SubOpt, error) {
+	if policy != "by-start-seq" && startSeq != 0 {
+		return nil, fmt.Errorf("--start-seq requires --deliver by-start-seq")
+	}
+	if policy != "by-start-time" && startTime != "" {
+		return nil, fmt.Errorf("--start-time requires --deliver by-start-time")
+	}
+
+	switch policy {
+	case "new":
+		return nats.DeliverNew(), nil
+	case "all":
+		return nats.DeliverAll(), nil
+	case "last":
+		return nats.DeliverLast(), nil
+	case "last-per-subject":
+		return nats.DeliverLastPerSubject(), nil
+	case "by-start-seq":
+		if startSeq == 0 {
+			return nil, fmt.Errorf("--deliver by-start-seq requires --start-seq > 0")
+		}
+		return nats.StartSequence(startSeq), nil
+	case "by-start-time":
+		if startTime == "" {
+			return nil, fmt.Errorf("--deliver by-start-time requires --start-time")
+		}
+		t, err := time.Parse(time.RFC3339, startTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --start-time %q (expected RFC3339): %w", startTime, err)
+		}
+		return nats.StartTime(t), nil
+	default:
+		return nil, fmt.Errorf("invalid --deliver %q (use new, all, last, last-per-subject, by-start-seq or by-start-time)", policy)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestDeliverPolicyOpt(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		startSeq  uint64
+		startTime string
+		wantErr   bool
+	}{
+		{name: "new", policy: "new"},
+		{name: "all", policy: "all"},
+		{name: "last", policy: "last"},
+		{name: "last-per-subject", policy: "last-per-subject"},
+		{name: "by-start-seq", policy: "by-start-seq", startSeq: 42},
+		{name: "by-start-seq without seq", policy: "by-start-seq", wantErr: true},
+		{name: "by-start-time", policy: "by-start-time", startTime: "2024-01-02T15:04:05Z"},
+		{name: "by-start-time without time", policy: "by-start-time", wantErr: true},
+		{name: "by-start-time invalid time", policy: "by-start-time", startTime: "yesterday", wantErr: true},
+		{name: "start-seq with other policy", policy: "all", startSeq: 1, wantErr: true},
+		{name: "start-time with other policy", policy: "new", startTime: "2024-01-02T15:04:05Z", wantErr: true},
+		{name: "unknown policy", policy: "first", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt, err := deliverPolicyOpt(tt.policy, tt.startSeq, tt.startTime)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deliverPolicyOpt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && opt == nil {
+				t.Error("deliverPolicyOpt() returned nil option")
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// defaultNSQD is the nsqd TCP address used when no address is given.
+const defaultNSQD = "127.0.0.1:4150"
+
+func main() {
+	root := &cobra.Command{
+		Use:   "nsqtool",
+		Short: "NSQ client tester",
+		Long:  "A simple NSQ CLI with send and serve commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// nsqLogger is handed to producers and consumers; go-nsq logs connection events at info level,
+// so only warnings and errors are kept.
+var nsqLogger = log.New(os.Stderr, "nsq ", log.LstdFlags)
+
+// newConfig returns a go-nsq configuration with the dial timeout bounded by timeout.
+func newConfig(timeout time.Duration) *nsq.Config {
+	cfg := nsq.NewConfig()
+	if timeout > 0 {
+		cfg.DialTimeout = timeout
+	}
+	return cfg
+}
+
+// messageItems lists the metadata of a consumed message.
+func messageItems(m *nsq.Message) []toolutil.KV {
+	return []toolutil.KV{
+		{Key: "ID", Value: string(m.ID[:])},
+		{Key: "Timestamp", Value: time.Unix(0, m.Timestamp).Format(time.RFC3339Nano)},
+		{Key: "Attempts", Value: strconv.Itoa(int(m.Attempts))},
+		{Key: "NSQD", Value: m.NSQDAddress},
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+func TestMessageItems(t *testing.T) {
+	var id nsq.MessageID
+	copy(id[:], "0a1b2c3d4e5f6789")
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := nsq.NewMessage(id, []byte("x"))
+	m.Timestamp = ts.UnixNano()
+	m.Attempts = 3
+	m.NSQDAddress = "127.0.0.1:4150"
+
+	want := map[string]string{
+		"ID":        "0a1b2c3d4e5f6789",
+		"Timestamp": "2024-05-01T12:00:00Z",
+		"Attempts":  "3",
+		"NSQD":      "127.0.0.1:4150",
+	}
+	items := messageItems(m)
+	if len(items) != len(want) {
+		t.Fatalf("messageItems() returned %d items, want %d", len(items), len(want))
+	}
+	for _, kv := range items {
+		if want[kv.Key] != kv.Value {
+			t.Errorf("%s = %q, want %q", kv.Key, kv.Value, want[kv.Key])
+		}
+	}
+}
+
+func TestNewConfigDialTimeout(t *testing.T) {
+	if got := newConfig(3 * time.Second).DialTimeout; got != 3*time.Second {
+		t.Errorf("DialTimeout = %v, want 3s", got)
+	}
+	if got := newConfig(0).DialTimeout; got != nsq.NewConfig().DialTimeout {
+		t.Errorf("DialTimeout = %v, want library default", got)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		nsqdAddrs      []string
+		lookupdAddrs   []string
+		subTopic       string
+		subChannel     string
+		maxInFlight    int
+		requeue        bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Consume an NSQ topic through a channel and log messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if maxInFlight < 1 {
+				return fmt.Errorf("--max-in-flight must be at least 1")
+			}
+			if len(nsqdAddrs) == 0 && len(lookupdAddrs) == 0 {
+				nsqdAddrs = []string{defaultNSQD}
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			cfg := newConfig(connectTimeout)
+			cfg.MaxInFlight = maxInFlight
+			consumer, err := nsq.NewConsumer(subTopic, subChannel, cfg)
+			if err != nil {
+				return fmt.Errorf("invalid NSQ configuration: %w", err)
+			}
+			consumer.SetLogger(nsqLogger, nsq.LogLevelWarning)
+			consumer.AddHandler(nsq.HandlerFunc(func(m *nsq.Message) error {
+				sections := []toolutil.MessageSection{
+					{Title: "Topic", Items: []toolutil.KV{
+						{Key: "Name", Value: subTopic},
+						{Key: "Channel", Value: subChannel},
+					}},
+					{Title: "Message", Items: messageItems(m)},
+				}
+				// Messages dropped after --max-messages are requeued as well
+				if !toolutil.PrintColoredMessage("NSQ", sections, m.Body, toolutil.GuessMIME(m.Body)) || requeue {
+					m.RequeueWithoutBackoff(time.Second)
+				}
+				return nil
+			}))
+			defer func() {
+				consumer.Stop()
+				<-consumer.StopChan
+			}()
+
+			// nsqd connections are established synchronously; nsqlookupd is polled in the
+			// background, so lookup failures are only logged.
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				if len(lookupdAddrs) > 0 {
+					return consumer.ConnectToNSQLookupds(lookupdAddrs)
+				}
+				return consumer.ConnectToNSQDs(nsqdAddrs)
+			}); err != nil {
+				return fmt.Errorf("error connecting to NSQ: %w", err)
+			}
+
+			toolutil.PrintSuccess("Consuming from NSQ")
+			if len(lookupdAddrs) > 0 {
+				toolutil.PrintKeyValue("nsqlookupd", strings.Join(lookupdAddrs, ", "))
+			} else {
+				toolutil.PrintKeyValue("nsqd", strings.Join(nsqdAddrs, ", "))
+			}
+			toolutil.PrintKeyValue("Topic", subTopic)
+			toolutil.PrintKeyValue("Channel", subChannel)
+
+			<-ctx.Done()
+			toolutil.PrintInfo("Shutting down gracefully")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&nsqdAddrs, "nsqd", nil, "nsqd TCP address to consume from (repeatable, default "+defaultNSQD+")")
+	cmd.Flags().StringSliceVar(&lookupdAddrs, "lookupd", nil, "nsqlookupd HTTP address used to discover nsqd producers of the topic (repeatable)")
+	cmd.Flags().StringVar(&subTopic, "topic", "test", "Topic to consume")
+	cmd.Flags().StringVar(&subChannel, "channel", "eventkit", "Channel name (append #ephemeral for a channel that is deleted when the last consumer leaves)")
+	cmd.Flags().IntVar(&maxInFlight, "max-in-flight", 1, "Maximum messages in flight per consumer")
+	cmd.Flags().BoolVar(&requeue, "requeue", false, "Requeue messages instead of finishing them (they are redelivered after 1s)")
+	cmd.MarkFlagsMutuallyExclusive("nsqd", "lookupd")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// applicationURI identifies the tool to servers; it is embedded in generated certificates.
+const applicationURI = "urn:eventkit:opcuatool"
+
+func main() {
+	root := &cobra.Command{
+		Use:   "opcuatool",
+		Short: "OPC UA client tester",
+		Long:  "A simple OPC UA CLI that writes templated values to nodes and subscribes to node value changes on a server.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// opcuaOptions configure the connection and session shared by both commands.
+type opcuaOptions struct {
+	Endpoint       string
+	SecurityPolicy string
+	SecurityMode   string
+	CertFile       string
+	KeyFile        string
+	Username       string
+	Password       string
+}
+
+// addOPCUAFlags registers the endpoint, security and authentication flags.
+func addOPCUAFlags(cmd *cobra.Command, opts *opcuaOptions) {
+	cmd.Flags().StringVar(&opts.Endpoint, "endpoint", "opc.tcp://localhost:4840", "OPC UA server endpoint URL")
+	cmd.Flags().StringVar(&opts.SecurityPolicy, "security-policy", "None", "Security policy: None, Basic128Rsa15, Basic256, Basic256Sha256, Aes128Sha256RsaOaep, Aes256Sha256RsaPss or auto")
+	cmd.Flags().StringVar(&opts.SecurityMode, "security-mode", "None", "Message security mode: None, Sign, SignAndEncrypt or auto")
+	cmd.Flags().StringVar(&opts.CertFile, "cert", "", "Client certificate (PEM or DER) for secure endpoints (default: an ephemeral self-signed certificate)")
+	cmd.Flags().StringVar(&opts.KeyFile, "key", "", "Private key (PEM) of --cert")
+	cmd.Flags().StringVar(&opts.Username, "username", "", "User name for user/password authentication (default: anonymous)")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "Password for user/password authentication")
+}
+
+// validate checks the security flags.
+func (o opcuaOptions) validate() error {
+	if o.SecurityPolicy != "auto" {
+		if _, ok := ua.SecurityPolicyURIs[o.SecurityPolicy]; !ok {
+			return fmt.Errorf("invalid --security-policy %q", o.SecurityPolicy)
+		}
+	}
+	if o.SecurityMode != "auto" && ua.MessageSecurityModeFromString(o.SecurityMode) == ua.MessageSecurityModeInvalid {
+		return fmt.Errorf("invalid --security-mode %q: expected None, Sign, SignAndEncrypt or auto", o.SecurityMode)
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return fmt.Errorf("--cert and --key must be set together")
+	}
+	return nil
+}
+
+// selectEndpoint picks the server endpoint matching the security flags; "auto" leaves the
+// choice to the server's most secure endpoint.
+func (o opcuaOptions) selectEndpoint(ctx context.Context) (*ua.EndpointDescription, error) {
+	endpoints, err := opcua.GetEndpoints(ctx, o.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error reading endpoints: %w", err)
+	}
+	policy, mode := o.SecurityPolicy, ua.MessageSecurityModeFromString(o.SecurityMode)
+	if policy == "auto" {
+		policy = ""
+	}
+	ep, err := opcua.SelectEndpoint(endpoints, policy, mode)
+	if err != nil {
+		return nil, err
+	}
+	// Servers often advertise host names unreachable from the client: keep the given address.
+	ep.EndpointURL = o.Endpoint
+	return ep, nil
+}
+
+// connect opens a session on the endpoint selected by opts.
+func connect(ctx context.Context, opts opcuaOptions) (*opcua.Client, *ua.EndpointDescription, error) {
+	ep, err := opts.selectEndpoint(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clientOpts := []opcua.Option{opcua.ApplicationName("eventkit opcuatool"), opcua.ApplicationURI(applicationURI)}
+	if ep.SecurityPolicyURI != ua.SecurityPolicyURINone {
+		if opts.CertFile != "" {
+			clientOpts = append(clientOpts, opcua.CertificateFile(opts.CertFile), opcua.PrivateKeyFile(opts.KeyFile))
+		} else {
+			cert, key, err := selfSignedCertificate(applicationURI)
+			if err != nil {
+				return nil, nil, err
+			}
+			clientOpts = append(clientOpts, opcua.Certificate(cert), opcua.PrivateKey(key))
+		}
+	}
+	authType := ua.UserTokenTypeAnonymous
+	if opts.Username != "" {
+		authType = ua.UserTokenTypeUserName
+		clientOpts = append(clientOpts, opcua.AuthUsername(opts.Username, opts.Password))
+	} else {
+		clientOpts = append(clientOpts, opcua.AuthAnonymous())
+	}
+	if !slices.ContainsFunc(ep.UserIdentityTokens, func(t *ua.UserTokenPolicy) bool { return t.TokenType == authType }) {
+		return nil, nil, fmt.Errorf("endpoint does not accept %s authentication", strings.TrimPrefix(authType.String(), "UserTokenType"))
+	}
+	clientOpts = append(clientOpts, opcua.SecurityFromEndpoint(ep, authType))
+
+	c, err := opcua.NewClient(ep.EndpointURL, clientOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.Connect(ctx); err != nil {
+		return nil, nil, err
+	}
+	return c, ep, nil
+}
+
+// closeClient closes the session, bounded so an unreachable server does not block shutdown.
+func closeClient(c *opcua.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = c.Close(ctx)
+}
+
+// securityName describes the security of ep, e.g. "Basic256Sha256/SignAndEncrypt".
+func securityName(ep *ua.EndpointDescription) string {
+	mode := strings.TrimPrefix(ep.SecurityMode.String(), "MessageSecurityMode")
+	return strings.TrimPrefix(ep.SecurityPolicyURI, ua.SecurityPolicyURIPrefix) + "/" + mode
+}
+
+// selfSignedCertificate creates an RSA client certificate carrying appURI, as OPC UA requires.
+func selfSignedCertificate(appURI string) ([]byte, *rsa.PrivateKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	uri, err := url.Parse(appURI)
+	if err != nil {
+		return nil, nil, err
+	}
+	host, _ := os.Hostname()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "eventkit opcuatool"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		URIs:                  []*url.URL{uri},
+	}
+	if host != "" {
+		tmpl.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return der, key, nil
+}
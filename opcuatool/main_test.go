@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+)
+
+// startServer runs an in-process OPC UA server with writable ns=1;s=rw_int32 and
+// ns=1;s=rw_string variables and returns its endpoint.
+func startServer(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close() //nolint:errcheck
+
+	s := server.New(
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+		server.EndPoint("127.0.0.1", port),
+	)
+	rootNS, _ := s.Namespace(0)
+	ns := server.NewNodeNameSpace(s, "eventkit")
+	s.AddNamespace(ns)
+	rootNS.Objects().AddRef(ns.Objects(), id.HasComponent, true)
+	ns.Objects().AddRef(ns.AddNewVariableStringNode("rw_int32", int32(5)), id.HasComponent, true)
+	ns.Objects().AddRef(ns.AddNewVariableStringNode("rw_string", "initial"), id.HasComponent, true)
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("starting server: %v", err)
+	}
+	t.Cleanup(func() { s.Close() }) //nolint:errcheck
+	return fmt.Sprintf("opc.tcp://127.0.0.1:%d", port)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	valid := opcuaOptions{SecurityPolicy: "None", SecurityMode: "None"}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() = %v", err)
+	}
+	auto := opcuaOptions{SecurityPolicy: "auto", SecurityMode: "auto"}
+	if err := auto.validate(); err != nil {
+		t.Errorf("validate(auto) = %v", err)
+	}
+	for _, o := range []opcuaOptions{
+		{SecurityPolicy: "Basic512", SecurityMode: "None"},
+		{SecurityPolicy: "None", SecurityMode: "Encrypt"},
+		{SecurityPolicy: "None", SecurityMode: "None", CertFile: "cert.pem"},
+	} {
+		if err := o.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded, want error", o)
+		}
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	cases := []struct {
+		raw  string
+		typ  ua.TypeID
+		want interface{}
+	}{
+		{"true", ua.TypeIDBoolean, true},
+		{" 42\n", ua.TypeIDInt32, int32(42)},
+		{"7", ua.TypeIDByte, byte(7)},
+		{"1.5", ua.TypeIDFloat, float32(1.5)},
+		{"2.25", ua.TypeIDDouble, 2.25},
+		{" text ", ua.TypeIDString, " text "},
+		{"aGk=", ua.TypeIDByteString, "hi"},
+		{"raw:hi", ua.TypeIDByteString, "hi"},
+	}
+	for _, c := range cases {
+		got, err := convertValue(c.raw, c.typ)
+		if b, ok := got.([]byte); ok {
+			got = string(b)
+		}
+		if err != nil || got != c.want {
+			t.Errorf("convertValue(%q, %s) = %v (%T), %v; want %v", c.raw, typeName(c.typ), got, got, err, c.want)
+		}
+	}
+	for _, c := range []struct {
+		raw string
+		typ ua.TypeID
+	}{{"300", ua.TypeIDByte}, {"x", ua.TypeIDInt32}, {"yes?", ua.TypeIDBoolean}, {"x", ua.TypeIDGUID}} {
+		if _, err := convertValue(c.raw, c.typ); err == nil {
+			t.Errorf("convertValue(%q, %s) succeeded, want error", c.raw, typeName(c.typ))
+		}
+	}
+}
+
+func TestWriteAndMonitor(t *testing.T) {
+	endpoint := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c, ep, err := connect(ctx, opcuaOptions{Endpoint: endpoint, SecurityPolicy: "None", SecurityMode: "None"})
+	if err != nil {
+		t.Fatalf("connect() error: %v", err)
+	}
+	defer closeClient(c)
+	if got := securityName(ep); got != "None/None" {
+		t.Errorf("securityName() = %q", got)
+	}
+
+	intNode, strNode := ua.NewStringNodeID(1, "rw_int32"), ua.NewStringNodeID(1, "rw_string")
+	notifyCh := make(chan *opcua.PublishNotificationData, 16)
+	sub, err := monitor(ctx, c, []*ua.NodeID{intNode, strNode}, 50*time.Millisecond, notifyCh)
+	if err != nil {
+		t.Fatalf("monitor() error: %v", err)
+	}
+	defer sub.Cancel(context.Background()) //nolint:errcheck
+
+	// The initial values are notified first.
+	waitValues(ctx, t, notifyCh, map[uint32]interface{}{0: int32(5), 1: "initial"})
+
+	w := &writer{client: c, types: map[string]ua.TypeID{}}
+	if typ, err := w.write(ctx, intNode, "42"); err != nil || typ != ua.TypeIDInt32 {
+		t.Fatalf("write(int32) = %s, %v", typeName(typ), err)
+	}
+	if _, err := w.write(ctx, strNode, "hello"); err != nil {
+		t.Fatalf("write(string) error: %v", err)
+	}
+	if _, err := w.write(ctx, intNode, "not a number"); err == nil {
+		t.Error("write(invalid int32) succeeded, want error")
+	}
+	waitValues(ctx, t, notifyCh, map[uint32]interface{}{0: int32(42), 1: "hello"})
+}
+
+// waitValues waits for notifications of the wanted values, keyed by client handle.
+func waitValues(ctx context.Context, t *testing.T, notifyCh <-chan *opcua.PublishNotificationData, want map[uint32]interface{}) {
+	t.Helper()
+	for len(want) > 0 {
+		select {
+		case res := <-notifyCh:
+			if res.Error != nil {
+				t.Fatalf("notification error: %v", res.Error)
+			}
+			dc, ok := res.Value.(*ua.DataChangeNotification)
+			if !ok {
+				continue
+			}
+			for _, item := range dc.MonitoredItems {
+				if v, ok := want[item.ClientHandle]; ok && item.Value.Value.Value() == v {
+					delete(want, item.ClientHandle)
+				}
+			}
+		case <-ctx.Done():
+			t.Fatalf("missing value changes: %v", want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, c := range []struct {
+		v        interface{}
+		body, ct string
+	}{
+		{"text", "text", "text/plain"},
+		{int32(7), "7", "application/json"},
+		{[]float64{1, 2.5}, "[1,2.5]", "application/json"},
+	} {
+		body, ct := formatValue(ua.MustVariant(c.v))
+		if string(body) != c.body || ct != c.ct {
+			t.Errorf("formatValue(%v) = %q, %q", c.v, body, ct)
+		}
+	}
+	if body, _ := formatValue(nil); body != nil {
+		t.Errorf("formatValue(nil) = %q", body)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// valueTypes maps the --type names to the variant types written.
+var valueTypes = map[string]ua.TypeID{
+	"bool": ua.TypeIDBoolean, "sbyte": ua.TypeIDSByte, "byte": ua.TypeIDByte,
+	"int16": ua.TypeIDInt16, "uint16": ua.TypeIDUint16, "int32": ua.TypeIDInt32, "uint32": ua.TypeIDUint32,
+	"int64": ua.TypeIDInt64, "uint64": ua.TypeIDUint64, "float": ua.TypeIDFloat, "double": ua.TypeIDDouble,
+	"string": ua.TypeIDString, "datetime": ua.TypeIDDateTime, "bytestring": ua.TypeIDByteString,
+}
+
+// typeName returns the short name of a variant type, e.g. "Int32".
+func typeName(t ua.TypeID) string {
+	return strings.TrimPrefix(t.String(), "TypeID")
+}
+
+// convertValue parses the payload as a value of type t. Byte strings are base64 unless
+// prefixed with "raw:"; date times are RFC 3339.
+func convertValue(raw string, t ua.TypeID) (interface{}, error) {
+	s := strings.TrimSpace(raw)
+	switch t {
+	case ua.TypeIDBoolean:
+		return strconv.ParseBool(s)
+	case ua.TypeIDSByte:
+		v, err := strconv.ParseInt(s, 10, 8)
+		return int8(v), err
+	case ua.TypeIDByte:
+		v, err := strconv.ParseUint(s, 10, 8)
+		return byte(v), err
+	case ua.TypeIDInt16:
+		v, err := strconv.ParseInt(s, 10, 16)
+		return int16(v), err
+	case ua.TypeIDUint16:
+		v, err := strconv.ParseUint(s, 10, 16)
+		return uint16(v), err
+	case ua.TypeIDInt32:
+		v, err := strconv.ParseInt(s, 10, 32)
+		return int32(v), err
+	case ua.TypeIDUint32:
+		v, err := strconv.ParseUint(s, 10, 32)
+		return uint32(v), err
+	case ua.TypeIDInt64:
+		return strconv.ParseInt(s, 10, 64)
+	case ua.TypeIDUint64:
+		return strconv.ParseUint(s, 10, 64)
+	case ua.TypeIDFloat:
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case ua.TypeIDDouble:
+		return strconv.ParseFloat(s, 64)
+	case ua.TypeIDString:
+		return raw, nil
+	case ua.TypeIDDateTime:
+		return time.Parse(time.RFC3339Nano, s)
+	case ua.TypeIDByteString:
+		if b, ok := strings.CutPrefix(raw, "raw:"); ok {
+			return []byte(b), nil
+		}
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return nil, fmt.Errorf("unsupported value type %s", typeName(t))
+}
+
+// writer writes payloads to nodes, converting them to each node's current value type when
+// no explicit type is set.
+type writer struct {
+	client *opcua.Client
+	typ    ua.TypeID // ua.TypeIDNull detects the type from the node
+	types  map[string]ua.TypeID
+}
+
+// nodeType returns the type to write to id, reading and caching its current value type.
+func (w *writer) nodeType(ctx context.Context, id *ua.NodeID) (ua.TypeID, error) {
+	if w.typ != ua.TypeIDNull {
+		return w.typ, nil
+	}
+	if t, ok := w.types[id.String()]; ok {
+		return t, nil
+	}
+	resp, err := w.client.Read(ctx, &ua.ReadRequest{
+		NodesToRead:        []*ua.ReadValueID{{NodeID: id, AttributeID: ua.AttributeIDValue}},
+		TimestampsToReturn: ua.TimestampsToReturnNeither,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error reading node type: %w", err)
+	}
+	res := resp.Results[0]
+	if res.Status != ua.StatusOK {
+		return 0, fmt.Errorf("error reading node type: %w", res.Status)
+	}
+	if res.Value == nil || res.Value.Type() == ua.TypeIDNull {
+		return 0, fmt.Errorf("node %s has no value to detect its type from, set --type", id)
+	}
+	w.types[id.String()] = res.Value.Type()
+	return res.Value.Type(), nil
+}
+
+// write converts raw and writes it to the Value attribute of id.
+func (w *writer) write(ctx context.Context, id *ua.NodeID, raw string) (ua.TypeID, error) {
+	t, err := w.nodeType(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	v, err := convertValue(raw, t)
+	if err != nil {
+		return t, fmt.Errorf("invalid %s value %q: %w", typeName(t), raw, err)
+	}
+	variant, err := ua.NewVariant(v)
+	if err != nil {
+		return t, err
+	}
+	resp, err := w.client.Write(ctx, &ua.WriteRequest{
+		NodesToWrite: []*ua.WriteValue{{
+			NodeID:      id,
+			AttributeID: ua.AttributeIDValue,
+			Value:       &ua.DataValue{EncodingMask: ua.DataValueValue, Value: variant},
+		}},
+	})
+	if err != nil {
+		return t, err
+	}
+	if status := resp.Results[0]; status != ua.StatusOK {
+		return t, status
+	}
+	return t, nil
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		opts           opcuaOptions
+		node           string
+		valueType      string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Write periodic values to an OPC UA node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if node == "" {
+				return fmt.Errorf("--node is required")
+			}
+			typ := ua.TypeIDNull
+			if valueType != "auto" {
+				t, ok := valueTypes[strings.ToLower(valueType)]
+				if !ok {
+					return fmt.Errorf("invalid --type %q", valueType)
+				}
+				typ = t
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var (
+				client *opcua.Client
+				ep     *ua.EndpointDescription
+			)
+			dial := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					var err error
+					client, ep, err = connect(ctx, opts)
+					return err
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), dial); err != nil {
+				return fmt.Errorf("error connecting to OPC UA server: %w", err)
+			}
+			defer closeClient(client)
+
+			toolutil.PrintSuccess("Connected to OPC UA server")
+			toolutil.PrintKeyValue("Endpoint", opts.Endpoint)
+			toolutil.PrintKeyValue("Security", securityName(ep))
+			toolutil.PrintKeyValue("Node", node)
+
+			w := &writer{client: client, typ: typ, types: map[string]ua.TypeID{}}
+			nodeDest := toolutil.NewDestination(node, openDelim, closeDelim)
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				target, err := nodeDest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Node build error: %v", err)
+					return err
+				}
+				id, err := ua.ParseNodeID(target)
+				if err != nil {
+					toolutil.PrintError("Invalid node ID %q: %v", target, err)
+					return err
+				}
+				t, err := w.write(ctx, id, string(body))
+				if err != nil {
+					toolutil.PrintError("Write to %s failed: %v", target, err)
+					return err
+				}
+				toolutil.PrintInfo("Wrote %s to %s (%s)", body, target, typeName(t))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addOPCUAFlags(cmd, &opts)
+	cmd.Flags().StringVar(&node, "node", "", "Node ID to write, e.g. ns=2;s=Demo.Value (supports template placeholders)")
+	cmd.Flags().StringVar(&valueType, "type", "auto", "Value type: auto (the node's current type), bool, sbyte, byte, int16, uint16, int32, uint32, int64, uint64, float, double, string, datetime or bytestring")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "{{stream:value:intrange:0:100}}", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
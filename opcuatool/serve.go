@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// monitor subscribes to value changes of nodes on c; notifications carry the index of the
+// node as client handle.
+func monitor(ctx context.Context, c *opcua.Client, nodes []*ua.NodeID, interval time.Duration, notifyCh chan<- *opcua.PublishNotificationData) (*opcua.Subscription, error) {
+	sub, err := c.Subscribe(ctx, &opcua.SubscriptionParameters{Interval: interval}, notifyCh)
+	if err != nil {
+		return nil, fmt.Errorf("error creating subscription: %w", err)
+	}
+	items := make([]*ua.MonitoredItemCreateRequest, len(nodes))
+	for i, id := range nodes {
+		items[i] = opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, uint32(i)) // #nosec G115 -- bounded by the number of --node flags
+	}
+	res, err := sub.Monitor(ctx, ua.TimestampsToReturnBoth, items...)
+	if err == nil {
+		for i, r := range res.Results {
+			if r.StatusCode != ua.StatusOK {
+				err = fmt.Errorf("error monitoring %s: %w", nodes[i], r.StatusCode)
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = sub.Cancel(ctx)
+		return nil, err
+	}
+	return sub, nil
+}
+
+// formatValue renders a variant as the message body: strings as text, byte strings raw
+// and everything else as JSON.
+func formatValue(v *ua.Variant) ([]byte, string) {
+	if v == nil || v.Value() == nil {
+		return nil, toolutil.CTText
+	}
+	switch x := v.Value().(type) {
+	case string:
+		return []byte(x), toolutil.CTText
+	case []byte:
+		return x, toolutil.GuessMIME(x)
+	}
+	b, err := json.Marshal(v.Value())
+	if err != nil {
+		return []byte(v.String()), toolutil.CTText
+	}
+	return b, toolutil.CTJSON
+}
+
+// printChange prints the new value of a monitored node.
+func printChange(node string, dv *ua.DataValue) {
+	status := "Good"
+	if dv.Status != ua.StatusOK {
+		status = dv.Status.Error()
+	}
+	items := []toolutil.KV{{Key: "Node", Value: node}, {Key: "Status", Value: status}}
+	if dv.Value != nil {
+		items = append(items, toolutil.KV{Key: "Type", Value: typeName(dv.Value.Type())})
+	}
+	if !dv.SourceTimestamp.IsZero() {
+		items = append(items, toolutil.KV{Key: "Source Time", Value: dv.SourceTimestamp.Format(time.RFC3339Nano)})
+	}
+	if !dv.ServerTimestamp.IsZero() {
+		items = append(items, toolutil.KV{Key: "Server Time", Value: dv.ServerTimestamp.Format(time.RFC3339Nano)})
+	}
+	body, ct := formatValue(dv.Value)
+	toolutil.PrintColoredMessage("OPC UA", []toolutil.MessageSection{{Title: "Value Change", Items: items}}, body, ct)
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           opcuaOptions
+		nodes          []string
+		interval       time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Subscribe to OPC UA node value changes and log them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if len(nodes) == 0 {
+				return fmt.Errorf("at least one --node is required")
+			}
+			ids := make([]*ua.NodeID, len(nodes))
+			for i, n := range nodes {
+				id, err := ua.ParseNodeID(n)
+				if err != nil {
+					return fmt.Errorf("invalid --node %q: %w", n, err)
+				}
+				ids[i] = id
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				client *opcua.Client
+				ep     *ua.EndpointDescription
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				var err error
+				client, ep, err = connect(ctx, opts)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to OPC UA server: %w", err)
+			}
+			defer closeClient(client)
+
+			notifyCh := make(chan *opcua.PublishNotificationData, 16)
+			sub, err := monitor(ctx, client, ids, interval, notifyCh)
+			if err != nil {
+				return err
+			}
+			defer sub.Cancel(context.Background()) //nolint:errcheck
+
+			toolutil.PrintSuccess("Subscribed to OPC UA server")
+			toolutil.PrintKeyValue("Endpoint", opts.Endpoint)
+			toolutil.PrintKeyValue("Security", securityName(ep))
+			for _, n := range nodes {
+				toolutil.PrintKeyValue("Node", n)
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case res := <-notifyCh:
+					if res.Error != nil {
+						toolutil.PrintWarning("Subscription error: %v", res.Error)
+						continue
+					}
+					if x, ok := res.Value.(*ua.DataChangeNotification); ok {
+						for _, item := range x.MonitoredItems {
+							if int(item.ClientHandle) < len(nodes) && item.Value != nil {
+								printChange(nodes[item.ClientHandle], item.Value)
+							}
+						}
+					}
+				}
+			}
+		},
+	}
+
+	addOPCUAFlags(cmd, &opts)
+	cmd.Flags().StringArrayVar(&nodes, "node", nil, "Node ID to monitor, e.g. ns=2;s=Demo.Value (repeatable)")
+	cmd.Flags().DurationVar(&interval, "publishing-interval", time.Second, "Subscription publishing interval")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
@@ -0,0 +1,57 @@
+package awsutil
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/spf13/cobra"
+)
+
+// DefaultRegion is used when no region is configured by flag, environment or shared config.
+const DefaultRegion = "us-east-1"
+
+// Options holds the AWS connection flags shared by the AWS tools.
+type Options struct {
+	Region   string
+	Endpoint string
+	Profile  string
+}
+
+// AddFlags registers --region, --endpoint and --profile on cmd.
+func AddFlags(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.Region, "region", "", "AWS region (default: from environment or shared config, else "+DefaultRegion+")")
+	cmd.Flags().StringVar(&opts.Endpoint, "endpoint", "", "Custom service endpoint, e.g. http://localhost:4566 for LocalStack")
+	cmd.Flags().StringVar(&opts.Profile, "profile", "", "Shared config profile to load credentials and region from")
+}
+
+// LoadConfig resolves the AWS configuration through the default chain (environment, shared
+// config, instance roles), applying opts on top. When a custom endpoint is set and no access key
+// is present in the environment or a profile, static dummy credentials are used so emulators
+// such as LocalStack work out of the box.
+func LoadConfig(ctx context.Context, opts Options) (aws.Config, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	if opts.Profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.Endpoint != "" && opts.Profile == "" && os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test", "test", "")))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if opts.Endpoint != "" {
+		cfg.BaseEndpoint = aws.String(opts.Endpoint)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,64 @@
+package awsutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/spf13/cobra"
+)
+
+func isolateEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	for _, k := range []string{"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"} {
+		t.Setenv(k, "")
+	}
+}
+
+func TestAddFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts Options
+	AddFlags(cmd, &opts)
+	for _, name := range []string{"region", "endpoint", "profile"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("AddFlags() did not add %q flag", name)
+		}
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	isolateEnv(t)
+	cfg, err := LoadConfig(context.Background(), Options{})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Region != DefaultRegion {
+		t.Errorf("Region = %q, want %q", cfg.Region, DefaultRegion)
+	}
+	if cfg.BaseEndpoint != nil {
+		t.Errorf("BaseEndpoint = %q, want nil", *cfg.BaseEndpoint)
+	}
+}
+
+func TestLoadConfigEndpoint(t *testing.T) {
+	isolateEnv(t)
+	cfg, err := LoadConfig(context.Background(), Options{Region: "eu-west-1", Endpoint: "http://localhost:4566"})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Region != "eu-west-1" {
+		t.Errorf("Region = %q, want eu-west-1", cfg.Region)
+	}
+	if aws.ToString(cfg.BaseEndpoint) != "http://localhost:4566" {
+		t.Errorf("BaseEndpoint = %q, want http://localhost:4566", aws.ToString(cfg.BaseEndpoint))
+	}
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if creds.AccessKeyID != "test" {
+		t.Errorf("AccessKeyID = %q, want dummy credentials for custom endpoints", creds.AccessKeyID)
+	}
+}
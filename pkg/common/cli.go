@@ -2,8 +2,12 @@ package common
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,9 +24,26 @@ func ParseInterval(interval string) (time.Duration, error) {
 	return dur, nil
 }
 
+// ErrStop is returned (possibly wrapped) by a periodic task that has nothing left to do, such as
+// a send loop whose data source is exhausted. StartPeriodicTask then stops without reporting it
+// as a failure.
+var ErrStop = errors.New("nothing left to send")
+
+// drainTimeout bounds how long StartPeriodicTask waits for an in-flight task after cancellation.
+var drainTimeout = 5 * time.Second
+
+// overrunWarnEvery limits how often StartPeriodicTask warns about a task slower than its interval.
+const overrunWarnEvery = 5 * time.Second
+
 // StartPeriodicTask executes the given task function periodically at the specified interval.
-// The task runs in a goroutine on each tick. The function blocks until the context is cancelled.
-// If the context is cancelled, the ticker is stopped and the function returns nil.
+// The task runs in a goroutine on each tick, with at most one execution in flight: ticks that
+// fire while the previous execution is still running are skipped, and a warning is logged
+// (at most every 5s) when the task takes longer than the interval, meaning the target cannot
+// keep up with the requested rate. Sub-millisecond intervals (e.g. 500us) are supported.
+// The function blocks until the context is cancelled.
+// If the context is cancelled, the ticker is stopped and the function waits up to 5s for the
+// in-flight task to finish (so a final publish is not cut off) before returning nil.
+// A task returning an error wrapping ErrStop ends the loop the same way.
 func StartPeriodicTask(ctx context.Context, interval string, task func() error) error {
 	dur, err := ParseInterval(interval)
 	if err != nil {
@@ -32,20 +53,70 @@ func StartPeriodicTask(ctx context.Context, interval string, task func() error)
 	ticker := time.NewTicker(dur)
 	defer ticker.Stop()
 
+	var (
+		running  atomic.Bool
+		skipped  atomic.Int64
+		lastWarn atomic.Int64
+		wg       sync.WaitGroup
+		stopOnce sync.Once
+	)
+	stop := make(chan struct{})
+	warnOverrun := func(took time.Duration) {
+		now := time.Now().UnixNano()
+		last := lastWarn.Load()
+		if now-last < int64(overrunWarnEvery) || !lastWarn.CompareAndSwap(last, now) {
+			return
+		}
+		slog.Warn("Task is slower than the interval, the target can't keep up with the requested rate",
+			"interval", dur, "task_duration", took, "skipped_ticks", skipped.Swap(0))
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
+			waitForDrain(&wg, drainTimeout)
+			return nil
+		case <-stop:
+			wg.Wait()
 			return nil
 		case <-ticker.C:
+			if !running.CompareAndSwap(false, true) {
+				skipped.Add(1)
+				continue
+			}
+			wg.Add(1)
 			go func() {
-				if err := task(); err != nil {
+				defer wg.Done()
+				defer running.Store(false)
+				start := time.Now()
+				if err := task(); errors.Is(err, ErrStop) {
+					slog.Info("Stopping", "reason", err)
+					stopOnce.Do(func() { close(stop) })
+				} else if err != nil {
 					fmt.Fprintf(os.Stderr, "Task error: %v\n", err)
 				}
+				if took := time.Since(start); took > dur {
+					warnOverrun(took)
+				}
 			}()
 		}
 	}
 }
 
+// waitForDrain waits for wg up to timeout, logging a warning if tasks are still running.
+func waitForDrain(wg *sync.WaitGroup, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		slog.Warn("In-flight task did not finish before shutdown", "drain_timeout", timeout)
+	}
+}
+
 // RunOnce executes the task function once immediately.
 // Returns an error if the task fails.
 func RunOnce(task func() error) error {
@@ -3,6 +3,8 @@ package common
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -16,6 +18,7 @@ func TestParseInterval(t *testing.T) {
 	}{
 		{"Valid seconds", "5s", 5 * time.Second, false},
 		{"Valid milliseconds", "500ms", 500 * time.Millisecond, false},
+		{"Valid microseconds", "250us", 250 * time.Microsecond, false},
 		{"Valid minutes", "2m", 2 * time.Minute, false},
 		{"Valid hours", "1h", 1 * time.Hour, false},
 		{"Complex duration", "1h30m", 90 * time.Minute, false},
@@ -72,6 +75,103 @@ func TestStartPeriodicTask(t *testing.T) {
 		}
 	})
 
+	t.Run("Slow task does not overlap", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		var inFlight, maxInFlight atomic.Int32
+		task := func() error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			if n > maxInFlight.Load() {
+				maxInFlight.Store(n)
+			}
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		}
+
+		if err := StartPeriodicTask(ctx, "500us", task); err != nil {
+			t.Fatalf("StartPeriodicTask() error = %v", err)
+		}
+
+		if got := maxInFlight.Load(); got != 1 {
+			t.Errorf("max concurrent executions = %d, want 1", got)
+		}
+	})
+
+	t.Run("In-flight task completes before return", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var started, finished atomic.Bool
+		task := func() error {
+			if !started.CompareAndSwap(false, true) {
+				return nil
+			}
+			cancel()
+			time.Sleep(100 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		}
+
+		if err := StartPeriodicTask(ctx, "10ms", task); err != nil {
+			t.Fatalf("StartPeriodicTask() error = %v", err)
+		}
+
+		if !finished.Load() {
+			t.Error("StartPeriodicTask() returned before the in-flight task finished")
+		}
+	})
+
+	t.Run("Drain timeout bounds the wait", func(t *testing.T) {
+		prev := drainTimeout
+		drainTimeout = 50 * time.Millisecond
+		defer func() { drainTimeout = prev }()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+		defer close(release)
+
+		var started atomic.Bool
+		task := func() error {
+			if started.CompareAndSwap(false, true) {
+				cancel()
+				<-release
+			}
+			return nil
+		}
+
+		start := time.Now()
+		if err := StartPeriodicTask(ctx, "10ms", task); err != nil {
+			t.Fatalf("StartPeriodicTask() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("StartPeriodicTask() waited %s, want about the drain timeout", elapsed)
+		}
+	})
+
+	t.Run("ErrStop ends the loop", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var calls atomic.Int32
+		task := func() error {
+			if calls.Add(1) == 3 {
+				return fmt.Errorf("rows used: %w", ErrStop)
+			}
+			return nil
+		}
+
+		if err := StartPeriodicTask(ctx, "10ms", task); err != nil {
+			t.Fatalf("StartPeriodicTask() error = %v", err)
+		}
+		if ctx.Err() != nil {
+			t.Fatal("StartPeriodicTask() ran until the context deadline")
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("task ran %d times, want 3", got)
+		}
+	})
+
 	t.Run("Context cancellation stops task", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ConnectWithTimeout runs connect and returns a clear error if it does not complete within timeout.
+// connect receives a context that is cancelled when the timeout expires; client libraries that
+// cannot be cancelled are abandoned in the background. A timeout <= 0 disables the limit.
+func ConnectWithTimeout(ctx context.Context, timeout time.Duration, connect func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return connect(ctx)
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- connect(cctx)
+	}()
+
+	select {
+	case err := <-errc:
+		if err != nil && errors.Is(cctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("failed to connect within %s: %w", timeout, err)
+		}
+		return err
+	case <-cctx.Done():
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to connect within %s", timeout)
+	}
+}
+
+// maxRetryInterval caps the exponential backoff used by ConnectWithRetry.
+const maxRetryInterval = 30 * time.Second
+
+// RetryPolicy controls how ConnectWithRetry retries a failed connection.
+type RetryPolicy struct {
+	// Retries is the number of additional attempts after the first one; 0 disables retrying.
+	Retries int
+	// Interval is the delay before the first retry; it doubles after each failure up to 30s.
+	Interval time.Duration
+}
+
+// ConnectWithRetry calls fn until it succeeds, the retries in policy are exhausted, or ctx is done.
+// Each failed attempt is logged together with the delay before the next one.
+func ConnectWithRetry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	delay := policy.Interval
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.Retries {
+			if attempt > 0 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt+1, err)
+			}
+			return err
+		}
+
+		slog.Warn("Connection attempt failed, retrying",
+			"attempt", attempt+1, "of", policy.Retries+1, "retry_in", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > maxRetryInterval {
+			delay = maxRetryInterval
+		}
+	}
+}
+
+// Reconnector periodically tears down and re-establishes a connection during a run,
+// to exercise the client's reconnect handling (chaos testing).
+type Reconnector struct {
+	every     time.Duration
+	reconnect func() error
+	last      time.Time
+	count     int
+}
+
+// NewReconnector returns a Reconnector calling reconnect every interval; every <= 0 disables it.
+// reconnect must close the current connection and open a new one.
+func NewReconnector(every time.Duration, reconnect func() error) *Reconnector {
+	return &Reconnector{every: every, reconnect: reconnect, last: time.Now()}
+}
+
+// MaybeReconnect reconnects when the interval has elapsed since the last (re)connection.
+// Send tools call it before each send; each reconnect is logged with its timing.
+func (r *Reconnector) MaybeReconnect() error {
+	if r == nil || r.every <= 0 || time.Since(r.last) < r.every {
+		return nil
+	}
+	r.count++
+	slog.Info("Reconnecting", "reconnect", r.count, "connected_for", time.Since(r.last).Round(time.Millisecond))
+	start := time.Now()
+	if err := r.reconnect(); err != nil {
+		return fmt.Errorf("reconnect %d failed: %w", r.count, err)
+	}
+	r.last = time.Now()
+	slog.Info("Reconnected", "reconnect", r.count, "took", r.last.Sub(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectWithTimeout(t *testing.T) {
+	t.Run("Successful connect", func(t *testing.T) {
+		err := ConnectWithTimeout(context.Background(), time.Second, func(ctx context.Context) error {
+			return nil
+		})
+		if err != nil {
+			t.Errorf("ConnectWithTimeout() error = %v", err)
+		}
+	})
+
+	t.Run("Connect error is returned as-is", func(t *testing.T) {
+		want := errors.New("refused")
+		err := ConnectWithTimeout(context.Background(), time.Second, func(ctx context.Context) error {
+			return want
+		})
+		if !errors.Is(err, want) {
+			t.Errorf("ConnectWithTimeout() error = %v, want %v", err, want)
+		}
+	})
+
+	t.Run("Blocking connect times out", func(t *testing.T) {
+		start := time.Now()
+		err := ConnectWithTimeout(context.Background(), 50*time.Millisecond, func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), "failed to connect within 50ms") {
+			t.Errorf("ConnectWithTimeout() error = %v, want timeout error", err)
+		}
+		if time.Since(start) > 500*time.Millisecond {
+			t.Error("ConnectWithTimeout() did not return at the deadline")
+		}
+	})
+
+	t.Run("Context-aware connect reports timeout", func(t *testing.T) {
+		err := ConnectWithTimeout(context.Background(), 20*time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if err == nil || !strings.Contains(err.Error(), "failed to connect within") {
+			t.Errorf("ConnectWithTimeout() error = %v, want timeout error", err)
+		}
+	})
+
+	t.Run("Zero timeout disables limit", func(t *testing.T) {
+		called := false
+		err := ConnectWithTimeout(context.Background(), 0, func(ctx context.Context) error {
+			called = true
+			if _, ok := ctx.Deadline(); ok {
+				t.Error("unexpected deadline with zero timeout")
+			}
+			return nil
+		})
+		if err != nil || !called {
+			t.Errorf("ConnectWithTimeout() error = %v, called = %v", err, called)
+		}
+	})
+}
+
+func TestConnectWithRetry(t *testing.T) {
+	t.Run("Succeeds after failures", func(t *testing.T) {
+		calls := 0
+		err := ConnectWithRetry(context.Background(), RetryPolicy{Retries: 3, Interval: time.Millisecond}, func() error {
+			calls++
+			if calls < 3 {
+				return errors.New("refused")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("ConnectWithRetry() error = %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("ConnectWithRetry() calls = %d, want 3", calls)
+		}
+	})
+
+	t.Run("Gives up after retries", func(t *testing.T) {
+		want := errors.New("refused")
+		calls := 0
+		err := ConnectWithRetry(context.Background(), RetryPolicy{Retries: 2, Interval: time.Millisecond}, func() error {
+			calls++
+			return want
+		})
+		if !errors.Is(err, want) || !strings.Contains(err.Error(), "giving up after 3 attempts") {
+			t.Errorf("ConnectWithRetry() error = %v, want wrapped %v", err, want)
+		}
+		if calls != 3 {
+			t.Errorf("ConnectWithRetry() calls = %d, want 3", calls)
+		}
+	})
+
+	t.Run("No retries returns first error", func(t *testing.T) {
+		want := errors.New("refused")
+		calls := 0
+		err := ConnectWithRetry(context.Background(), RetryPolicy{}, func() error {
+			calls++
+			return want
+		})
+		if err != want {
+			t.Errorf("ConnectWithRetry() error = %v, want %v", err, want)
+		}
+		if calls != 1 {
+			t.Errorf("ConnectWithRetry() calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("Context cancellation stops retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := ConnectWithRetry(ctx, RetryPolicy{Retries: 5, Interval: time.Hour}, func() error {
+			return errors.New("refused")
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ConnectWithRetry() error = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestReconnector(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		calls := 0
+		r := NewReconnector(0, func() error { calls++; return nil })
+		if err := r.MaybeReconnect(); err != nil || calls != 0 {
+			t.Errorf("MaybeReconnect() = %v, calls = %d, want no reconnect", err, calls)
+		}
+	})
+
+	t.Run("Reconnects after interval", func(t *testing.T) {
+		calls := 0
+		r := NewReconnector(20*time.Millisecond, func() error { calls++; return nil })
+		if err := r.MaybeReconnect(); err != nil || calls != 0 {
+			t.Fatalf("MaybeReconnect() before interval = %v, calls = %d", err, calls)
+		}
+		time.Sleep(30 * time.Millisecond)
+		if err := r.MaybeReconnect(); err != nil || calls != 1 {
+			t.Fatalf("MaybeReconnect() after interval = %v, calls = %d", err, calls)
+		}
+		if err := r.MaybeReconnect(); err != nil || calls != 1 {
+			t.Errorf("MaybeReconnect() should wait for the next interval, calls = %d", calls)
+		}
+	})
+
+	t.Run("Error is wrapped", func(t *testing.T) {
+		want := errors.New("refused")
+		r := NewReconnector(time.Nanosecond, func() error { return want })
+		time.Sleep(time.Millisecond)
+		if err := r.MaybeReconnect(); !errors.Is(err, want) {
+			t.Errorf("MaybeReconnect() error = %v, want %v", err, want)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+// Package framing splits byte streams into frames and encodes frames for the socket tools.
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultMaxFrameSize bounds received frames when Options.MaxFrameSize is not set.
+const DefaultMaxFrameSize = 1 << 20
+
+// Supported framing modes.
+const (
+	ModeNewline   = "newline"
+	ModeLength    = "length"
+	ModeDelimiter = "delimiter"
+	ModeNone      = "none"
+)
+
+// Options holds the framing flags shared by the socket tools.
+type Options struct {
+	// Mode is newline, length, delimiter or none.
+	Mode string
+	// Delimiter is the frame terminator in delimiter mode; Go escapes such as \x00 or \r\n are accepted.
+	Delimiter string
+	// LengthSize is the size in bytes (1, 2, 4 or 8) of the big-endian length prefix in length mode.
+	LengthSize int
+	// MaxFrameSize bounds received frames; zero means DefaultMaxFrameSize.
+	MaxFrameSize int
+}
+
+// AddFlags registers --framing, --delimiter and --length-size on cmd.
+func AddFlags(cmd *cobra.Command, opts *Options) {
+	cmd.Flags().StringVar(&opts.Mode, "framing", ModeNewline, "Frame format: newline, length (length-prefixed), delimiter or none (raw reads/writes)")
+	cmd.Flags().StringVar(&opts.Delimiter, "delimiter", `\x00`, "Frame terminator for --framing delimiter (Go escapes such as \\x00 or \\r\\n are accepted)")
+	cmd.Flags().IntVar(&opts.LengthSize, "length-size", 4, "Size in bytes of the big-endian length prefix for --framing length: 1, 2, 4 or 8")
+}
+
+// Framer encodes and decodes frames in one of the supported modes.
+type Framer struct {
+	mode       string
+	delimiter  []byte
+	lengthSize int
+	maxSize    int
+}
+
+// New validates opts and returns the corresponding Framer.
+func New(opts Options) (*Framer, error) {
+	f := &Framer{mode: opts.Mode, lengthSize: opts.LengthSize, maxSize: opts.MaxFrameSize}
+	if f.maxSize == 0 {
+		f.maxSize = DefaultMaxFrameSize
+	}
+	if f.maxSize < 0 {
+		return nil, fmt.Errorf("max frame size must not be negative")
+	}
+	switch opts.Mode {
+	case ModeNewline:
+		f.delimiter = []byte{'\n'}
+	case ModeDelimiter:
+		d, err := strconv.Unquote(`"` + opts.Delimiter + `"`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --delimiter %q: %w", opts.Delimiter, err)
+		}
+		if d == "" {
+			return nil, fmt.Errorf("--delimiter must not be empty")
+		}
+		f.delimiter = []byte(d)
+	case ModeLength:
+		switch opts.LengthSize {
+		case 1, 2, 4, 8:
+		default:
+			return nil, fmt.Errorf("invalid --length-size %d: expected 1, 2, 4 or 8", opts.LengthSize)
+		}
+	case ModeNone:
+	default:
+		return nil, fmt.Errorf("invalid --framing %q: expected newline, length, delimiter or none", opts.Mode)
+	}
+	return f, nil
+}
+
+// String describes the framing, e.g. "length (4 bytes)" or `delimiter "\x00"`.
+func (f *Framer) String() string {
+	switch f.mode {
+	case ModeLength:
+		return fmt.Sprintf("length (%d bytes)", f.lengthSize)
+	case ModeDelimiter:
+		return fmt.Sprintf("delimiter %q", f.delimiter)
+	}
+	return f.mode
+}
+
+// Encode returns payload framed for the wire.
+func (f *Framer) Encode(payload []byte) ([]byte, error) {
+	switch f.mode {
+	case ModeNewline, ModeDelimiter:
+		if bytes.Contains(payload, f.delimiter) {
+			return nil, fmt.Errorf("payload contains the frame delimiter %q; use --framing length for binary or multi-line payloads", f.delimiter)
+		}
+		return append(bytes.Clone(payload), f.delimiter...), nil
+	case ModeLength:
+		n := uint64(len(payload))
+		if f.lengthSize < 8 && n >= 1<<(8*f.lengthSize) {
+			return nil, fmt.Errorf("payload of %d bytes does not fit a %d-byte length prefix", n, f.lengthSize)
+		}
+		prefix := binary.BigEndian.AppendUint64(nil, n)[8-f.lengthSize:]
+		return append(prefix, payload...), nil
+	}
+	return payload, nil
+}
+
+// NewScanner returns a scanner yielding the frames read from r.
+// A trailing frame without its delimiter is returned at EOF, while a truncated length-prefixed
+// frame is reported as io.ErrUnexpectedEOF.
+func (f *Framer) NewScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, min(64*1024, f.maxSize+f.lengthSize+len(f.delimiter))), f.maxSize+f.lengthSize+len(f.delimiter))
+	s.Split(f.split)
+	return s
+}
+
+func (f *Framer) split(data []byte, atEOF bool) (int, []byte, error) {
+	switch f.mode {
+	case ModeNewline, ModeDelimiter:
+		if i := bytes.Index(data, f.delimiter); i >= 0 {
+			token := data[:i]
+			if f.mode == ModeNewline {
+				token = bytes.TrimSuffix(token, []byte{'\r'})
+			}
+			return i + len(f.delimiter), token, nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	case ModeLength:
+		if len(data) < f.lengthSize {
+			if atEOF && len(data) > 0 {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		var buf [8]byte
+		copy(buf[8-f.lengthSize:], data[:f.lengthSize])
+		n := binary.BigEndian.Uint64(buf[:])
+		if n > uint64(f.maxSize) {
+			return 0, nil, fmt.Errorf("frame of %d bytes exceeds the %d-byte limit", n, f.maxSize)
+		}
+		end := f.lengthSize + int(n)
+		if len(data) < end {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		return end, data[f.lengthSize:end], nil
+	}
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	return len(data), data, nil
+}
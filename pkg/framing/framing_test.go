@@ -0,0 +1,118 @@
+package framing
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func scanAll(t *testing.T, f *Framer, data []byte) ([]string, error) {
+	t.Helper()
+	s := f.NewScanner(bytes.NewReader(data))
+	var frames []string
+	for s.Scan() {
+		frames = append(frames, s.Text())
+	}
+	return frames, s.Err()
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []Options{
+		{Mode: ModeNewline},
+		{Mode: ModeDelimiter, Delimiter: `\x00`},
+		{Mode: ModeDelimiter, Delimiter: `\r\n\r\n`},
+		{Mode: ModeLength, LengthSize: 1},
+		{Mode: ModeLength, LengthSize: 2},
+		{Mode: ModeLength, LengthSize: 4},
+		{Mode: ModeLength, LengthSize: 8},
+	}
+	payloads := []string{"first", "", "third frame"}
+	for _, opts := range tests {
+		f, err := New(opts)
+		if err != nil {
+			t.Fatalf("New(%+v) error: %v", opts, err)
+		}
+		var wire []byte
+		for _, p := range payloads {
+			b, err := f.Encode([]byte(p))
+			if err != nil {
+				t.Fatalf("%s: Encode(%q) error: %v", f, p, err)
+			}
+			wire = append(wire, b...)
+		}
+		got, err := scanAll(t, f, wire)
+		if err != nil {
+			t.Fatalf("%s: scan error: %v", f, err)
+		}
+		if strings.Join(got, "|") != strings.Join(payloads, "|") || len(got) != len(payloads) {
+			t.Errorf("%s: frames = %q, want %q", f, got, payloads)
+		}
+	}
+}
+
+func TestEncodeLength(t *testing.T) {
+	f, _ := New(Options{Mode: ModeLength, LengthSize: 2})
+	got, err := f.Encode([]byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 3, 'a', 'b', 'c'}; !bytes.Equal(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+	f, _ = New(Options{Mode: ModeLength, LengthSize: 1})
+	if _, err := f.Encode(make([]byte, 256)); err == nil {
+		t.Error("Encode() of 256 bytes with a 1-byte prefix succeeded, want error")
+	}
+}
+
+func TestEncodeRejectsDelimiter(t *testing.T) {
+	f, _ := New(Options{Mode: ModeNewline})
+	if _, err := f.Encode([]byte("a\nb")); err == nil {
+		t.Error("Encode() of a multi-line payload with newline framing succeeded, want error")
+	}
+}
+
+func TestScanTrailingAndCRLF(t *testing.T) {
+	f, _ := New(Options{Mode: ModeNewline})
+	got, err := scanAll(t, f, []byte("a\r\nb\nlast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "a,b,last" {
+		t.Errorf("frames = %q, want [a b last]", got)
+	}
+}
+
+func TestScanTruncatedLength(t *testing.T) {
+	f, _ := New(Options{Mode: ModeLength, LengthSize: 4})
+	if _, err := scanAll(t, f, []byte{0, 0, 0, 5, 'a'}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("scan error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestScanMaxFrameSize(t *testing.T) {
+	f, _ := New(Options{Mode: ModeLength, LengthSize: 4, MaxFrameSize: 4})
+	if _, err := scanAll(t, f, []byte{0, 0, 0, 5, 'a', 'b', 'c', 'd', 'e'}); err == nil {
+		t.Error("scan of an oversized frame succeeded, want error")
+	}
+	f, _ = New(Options{Mode: ModeNewline, MaxFrameSize: 4})
+	if _, err := scanAll(t, f, []byte("abcdefgh\n")); err == nil {
+		t.Error("scan of an oversized line succeeded, want error")
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Mode: "crlf"},
+		{Mode: ModeLength, LengthSize: 3},
+		{Mode: ModeDelimiter, Delimiter: ""},
+		{Mode: ModeDelimiter, Delimiter: `\x`},
+		{Mode: ModeNone, MaxFrameSize: -1},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) succeeded, want error", opts)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package testpayload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Payload mutations of CorruptPayload.
+const (
+	// CorruptBitFlip flips 1 to 3 random bits.
+	CorruptBitFlip = "bitflip"
+	// CorruptTruncate cuts the payload at a random length.
+	CorruptTruncate = "truncate"
+	// CorruptInvalidUTF8 inserts bytes that are never valid UTF-8.
+	CorruptInvalidUTF8 = "invalid-utf8"
+	// CorruptBrokenJSON removes a structural character (brace, bracket, quote, colon or
+	// comma) from a JSON payload.
+	CorruptBrokenJSON = "broken-json"
+)
+
+// CorruptRate is the percentage (0 to 100) of payloads MaybeCorrupt mutates, see SetCorruptRate.
+var (
+	CorruptRate      float64
+	corruptRateMutex = sync.Mutex{}
+)
+
+// SetCorruptRate sets the percentage (0 to 100) of payloads MaybeCorrupt mutates, 0 disables
+// corruption.
+func SetCorruptRate(pct float64) error {
+	if pct < 0 || pct > 100 {
+		return fmt.Errorf("invalid corrupt rate %g: expected a percentage between 0 and 100", pct)
+	}
+	corruptRateMutex.Lock()
+	defer corruptRateMutex.Unlock()
+	CorruptRate = pct
+	return nil
+}
+
+// MaybeCorrupt mutates data with CorruptPayload for CorruptRate percent of calls, returning the
+// payload and the mutation applied, empty when data is returned untouched.
+func MaybeCorrupt(data []byte) ([]byte, string) {
+	corruptRateMutex.Lock()
+	rate := CorruptRate
+	corruptRateMutex.Unlock()
+	if rate <= 0 || float64(defaultGenerator.Int63n(1_000_000)) >= rate*10_000 {
+		return data, ""
+	}
+	return CorruptPayload(data)
+}
+
+// CorruptPayload returns a copy of data with a random mutation applied, and the name of the
+// mutation: one of the Corrupt* constants, broken-json only for valid JSON. data is not
+// modified.
+func CorruptPayload(data []byte) ([]byte, string) {
+	mutations := []string{CorruptInvalidUTF8}
+	if len(data) > 0 {
+		mutations = append(mutations, CorruptBitFlip, CorruptTruncate)
+	}
+	if json.Valid(data) && bytes.ContainsAny(data, structuralJSON) {
+		mutations = append(mutations, CorruptBrokenJSON)
+	}
+	mutation := mutations[defaultGenerator.Intn(len(mutations))]
+	res := slices.Clone(data)
+	switch mutation {
+	case CorruptBitFlip:
+		for range 1 + defaultGenerator.Intn(3) {
+			res[defaultGenerator.Intn(len(res))] ^= 1 << defaultGenerator.Intn(8)
+		}
+	case CorruptTruncate:
+		res = res[:defaultGenerator.Intn(len(res))]
+	case CorruptInvalidUTF8:
+		res = slices.Insert(res, defaultGenerator.Intn(len(res)+1), 0xff, 0xfe)
+	case CorruptBrokenJSON:
+		res = breakJSON(res)
+	}
+	return res, mutation
+}
+
+// breakJSON removes a structural character from valid JSON so that it no longer parses,
+// trying the characters from a random one on (commas or quotes inside strings may not break
+// it) and truncating the payload as a last resort.
+func breakJSON(data []byte) []byte {
+	start := defaultGenerator.Intn(len(data))
+	for n := range len(data) {
+		i := (start + n) % len(data)
+		if strings.IndexByte(structuralJSON, data[i]) == -1 {
+			continue
+		}
+		if res := slices.Delete(slices.Clone(data), i, i+1); !json.Valid(res) {
+			return res
+		}
+	}
+	return data[:len(data)-1]
+}
+
+// structuralJSON are the characters breakJSON removes.
+const structuralJSON = `{}[]":,`
@@ -0,0 +1,82 @@
+package testpayload
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCorruptPayload(t *testing.T) {
+	SeedRandom(5)
+	payload := []byte(`{"id":"a,b","items":[1,2,3],"ok":true}`)
+	seen := map[string]bool{}
+	for range 200 {
+		res, mutation := CorruptPayload(payload)
+		seen[mutation] = true
+		if bytes.Equal(res, payload) && mutation != CorruptBitFlip {
+			t.Fatalf("%s left the payload unchanged", mutation)
+		}
+		switch mutation {
+		case CorruptTruncate:
+			if len(res) >= len(payload) {
+				t.Errorf("truncate kept %d of %d bytes", len(res), len(payload))
+			}
+		case CorruptInvalidUTF8:
+			if utf8.Valid(res) {
+				t.Errorf("invalid-utf8 produced valid UTF-8 %q", res)
+			}
+		case CorruptBrokenJSON:
+			if json.Valid(res) || len(res) != len(payload)-1 {
+				t.Errorf("broken-json produced %q", res)
+			}
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("mutations seen = %v, want all 4", seen)
+	}
+	if string(payload) != `{"id":"a,b","items":[1,2,3],"ok":true}` {
+		t.Errorf("CorruptPayload modified its input: %s", payload)
+	}
+
+	for range 20 {
+		if res, mutation := CorruptPayload(nil); mutation != CorruptInvalidUTF8 || len(res) != 2 {
+			t.Fatalf("CorruptPayload(nil) = %q, %s", res, mutation)
+		}
+		if _, mutation := CorruptPayload([]byte("plain text")); mutation == CorruptBrokenJSON {
+			t.Fatal("broken-json applied to a non-JSON payload")
+		}
+	}
+}
+
+func TestMaybeCorrupt(t *testing.T) {
+	defer func() { _ = SetCorruptRate(0) }()
+	for _, bad := range []float64{-1, 100.5} {
+		if err := SetCorruptRate(bad); err == nil {
+			t.Errorf("expected an error for rate %g", bad)
+		}
+	}
+
+	count := func(rate float64) int {
+		if err := SetCorruptRate(rate); err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for range 1000 {
+			if _, mutation := MaybeCorrupt([]byte("payload")); mutation != "" {
+				n++
+			}
+		}
+		return n
+	}
+	SeedRandom(9)
+	if n := count(0); n != 0 {
+		t.Errorf("rate 0 corrupted %d payloads", n)
+	}
+	if n := count(100); n != 1000 {
+		t.Errorf("rate 100 corrupted %d of 1000 payloads", n)
+	}
+	if n := count(10); n < 50 || n > 150 {
+		t.Errorf("rate 10 corrupted %d of 1000 payloads", n)
+	}
+}
@@ -0,0 +1,152 @@
+package testpayload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExecTimeout bounds {{cmd:...}} commands when ExecOptions.Timeout is not set.
+const DefaultExecTimeout = 5 * time.Second
+
+// ExecOptions configures {{cmd:...}} placeholders, see SetExecOptions.
+type ExecOptions struct {
+	// Allow enables the placeholders, disabled by default as they run arbitrary commands.
+	Allow bool
+	// Timeout kills commands running longer, DefaultExecTimeout when zero.
+	Timeout time.Duration
+	// CacheTTL reuses the output of a command for this long, zero runs it every time.
+	CacheTTL time.Duration
+}
+
+// execResult is a cached command output.
+type execResult struct {
+	out     []byte
+	expires time.Time
+}
+
+// Settings and output cache of {{cmd:...}} placeholders.
+var (
+	execOptions = ExecOptions{}
+	execCache   = map[string]execResult{}
+	execMutex   = sync.Mutex{}
+)
+
+// SetExecOptions configures {{cmd:...}} placeholders, clearing the output cache.
+func SetExecOptions(opts ExecOptions) error {
+	if opts.Timeout < 0 || opts.CacheTTL < 0 {
+		return fmt.Errorf("invalid exec options: timeout and cache TTL must not be negative")
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultExecTimeout
+	}
+	execMutex.Lock()
+	defer execMutex.Unlock()
+	execOptions = opts
+	clear(execCache)
+	return nil
+}
+
+// ExecAllowed tells whether {{cmd:...}} placeholders are enabled, see SetExecOptions.
+func ExecAllowed() bool {
+	execMutex.Lock()
+	defer execMutex.Unlock()
+	return execOptions.Allow
+}
+
+// generateCmd evaluates a cmd expression (the placeholder without the "cmd:" prefix),
+// COMMAND [ARGS...], returning the standard output of the command without its trailing
+// newlines. The command is split on whitespace, keeping single- or double-quoted text
+// together, and run without a shell; wrap it in sh -c '...' for pipes or variables. Outputs
+// are cached for ExecOptions.CacheTTL.
+func generateCmd(expr string) ([]byte, error) {
+	ph := "cmd:" + expr
+	args, err := splitCommand(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cmd placeholder %q: %w", ph, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("invalid cmd placeholder %q: expected cmd:COMMAND [ARGS...]", ph)
+	}
+
+	execMutex.Lock()
+	opts := execOptions
+	if res, ok := execCache[expr]; ok && time.Now().Before(res.expires) {
+		execMutex.Unlock()
+		return res.out, nil
+	}
+	execMutex.Unlock()
+	if !opts.Allow {
+		return nil, fmt.Errorf("command execution is disabled: to enable allow exec set testpayload.SetExecOptions(testpayload.ExecOptions{Allow: true})")
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultExecTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	// #nosec G204 -- commands are explicitly enabled by the user via --allow-exec
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("command %q timed out after %s", expr, opts.Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("command %q failed: %w: %s", expr, err, msg)
+		}
+		return nil, fmt.Errorf("command %q failed: %w", expr, err)
+	}
+	out = bytes.TrimRight(out, "\r\n")
+
+	if opts.CacheTTL > 0 {
+		execMutex.Lock()
+		execCache[expr] = execResult{out: out, expires: time.Now().Add(opts.CacheTTL)}
+		execMutex.Unlock()
+	}
+	return out, nil
+}
+
+// splitCommand splits a command line on whitespace, keeping text in single or double quotes
+// together (without the quotes).
+func splitCommand(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,92 @@
+package testpayload
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"echo hello world", []string{"echo", "hello", "world"}},
+		{"  date   -u ", []string{"date", "-u"}},
+		{`sh -c 'echo a | tr a b'`, []string{"sh", "-c", "echo a | tr a b"}},
+		{`printf "%s-%s" x ''`, []string{"printf", "%s-%s", "x", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.line)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, %v, want %q", tt.line, got, err, tt.want)
+		}
+	}
+	if _, err := splitCommand(`echo "unterminated`); err == nil {
+		t.Error("expected an error for an unterminated quote")
+	}
+}
+
+func TestInterpolateWithDelimiters_CmdPlaceholder(t *testing.T) {
+	defer func() { _ = SetExecOptions(ExecOptions{}) }()
+
+	if _, err := InterpolateWithDelimiters("{{cmd:echo hi}}", "{{", "}}"); err == nil {
+		t.Error("expected an error with command execution disabled")
+	}
+
+	if err := SetExecOptions(ExecOptions{Allow: true}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := InterpolateWithDelimiters(`{"token":"{{cmd:echo abc}}","piped":"{{cmd:sh -c 'echo x | tr x y'}}"}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if want := `{"token":"abc","piped":"y"}`; string(res) != want {
+		t.Errorf("InterpolateWithDelimiters() = %s, want %s", res, want)
+	}
+
+	if _, err := InterpolateWithDelimiters("{{cmd:sh -c 'echo oops >&2; exit 3'}}", "{{", "}}"); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected the command stderr in the error, got %v", err)
+	}
+	if _, err := InterpolateWithDelimiters("{{cmd:}}", "{{", "}}"); err == nil {
+		t.Error("expected an error for an empty command")
+	}
+
+	if err := SetExecOptions(ExecOptions{Allow: true, Timeout: 50 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InterpolateWithDelimiters("{{cmd:sleep 5}}", "{{", "}}"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout error, got %v", err)
+	}
+
+	if err := SetExecOptions(ExecOptions{Timeout: -time.Second}); err == nil {
+		t.Error("expected an error for a negative timeout")
+	}
+}
+
+func TestInterpolateWithDelimiters_CmdCache(t *testing.T) {
+	defer func() { _ = SetExecOptions(ExecOptions{}) }()
+	counterFile := filepath.Join(t.TempDir(), "runs")
+	tmpl := "{{cmd:sh -c 'echo run >> " + counterFile + "; wc -l < " + counterFile + "'}}"
+
+	if err := SetExecOptions(ExecOptions{Allow: true, CacheTTL: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		res, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+		if err != nil || strings.TrimSpace(string(res)) != "1" {
+			t.Fatalf("cached run = %q, %v", res, err)
+		}
+	}
+
+	if err := SetExecOptions(ExecOptions{Allow: true}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+	if err != nil || strings.TrimSpace(string(res)) != "2" {
+		t.Errorf("uncached run = %q, %v", res, err)
+	}
+}
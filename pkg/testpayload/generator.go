@@ -0,0 +1,183 @@
+package testpayload
+
+import (
+	"maps"
+	"math/rand"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-faker/faker/v4"
+)
+
+// Generator produces test data from its own pseudo-random source, counters and template
+// variables. A Generator is safe for concurrent use, and generators never share state, so
+// concurrent workers each using their own (see Worker) stay reproducible for a given seed.
+// The package-level functions (Interpolate, SeedRandom, GenerateCounter, ...) use
+// DefaultGenerator.
+type Generator struct {
+	mu      sync.Mutex // guards rng, seed and streams
+	rng     *rand.Rand
+	seed    int64
+	streams map[string]*Stream
+
+	counterMu sync.Mutex // guards counter and counters
+	counter   int
+	counters  map[string]*namedCounter
+
+	varsMu sync.RWMutex
+	vars   map[string]string
+
+	funcs       func() template.FuncMap
+	templates   map[string]*template.Template
+	templatesMu sync.Mutex
+}
+
+// NewGenerator returns a generator seeded with seed.
+func NewGenerator(seed int64) *Generator {
+	g := &Generator{
+		counters:  map[string]*namedCounter{},
+		vars:      map[string]string{},
+		templates: map[string]*template.Template{},
+	}
+	g.funcs = sync.OnceValue(g.templateFuncs)
+	g.Seed(seed)
+	return g
+}
+
+// defaultGenerator backs the package-level functions.
+var defaultGenerator = NewGenerator(time.Now().UnixNano())
+
+// DefaultGenerator returns the generator used by the package-level functions.
+func DefaultGenerator() *Generator {
+	return defaultGenerator
+}
+
+// Seed restarts the pseudo-random sequence of the generator, and of its streams, from seed.
+// Counters and template variables are kept.
+func (g *Generator) Seed(seed int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rng = rand.New(rand.NewSource(seed)) // #nosec G404 -- test data generator
+	g.seed = seed
+	g.streams = map[string]*Stream{}
+}
+
+// DeriveSeed returns the seed of worker index of a run seeded with seed: distinct workers get
+// unrelated sequences, and the same seed and index always the same one.
+func DeriveSeed(seed int64, index int) int64 {
+	// splitmix64 finalizer
+	z := uint64(seed) + uint64(index+1)*0x9e3779b97f4a7c15 // #nosec G115 -- bit mixing, overflow is intended
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31)) // #nosec G115 -- bit mixing, overflow is intended
+}
+
+// Worker returns a new generator for worker index, seeded with DeriveSeed of the seed g was
+// last seeded with, and starting with a copy of its template variables.
+func (g *Generator) Worker(index int) *Generator {
+	g.mu.Lock()
+	seed := g.seed
+	g.mu.Unlock()
+	w := NewGenerator(DeriveSeed(seed, index))
+	w.SetVars(g.Vars())
+	return w
+}
+
+// Intn returns a pseudo-random int in [0, n).
+func (g *Generator) Intn(n int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Intn(n)
+}
+
+// Int63n returns a pseudo-random int64 in [0, n).
+func (g *Generator) Int63n(n int64) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Int63n(n)
+}
+
+// Read fills p with pseudo-random bytes, making g an io.Reader for random IDs and content.
+func (g *Generator) Read(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Read(p)
+}
+
+// Shuffle pseudo-randomizes the order of n elements, see rand.Shuffle.
+func (g *Generator) Shuffle(n int, swap func(i, j int)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rng.Shuffle(n, swap)
+}
+
+// generatorSource adapts a Generator to a rand.Source for faker.
+type generatorSource struct {
+	g *Generator
+}
+
+func (s generatorSource) Int63() int64 {
+	s.g.mu.Lock()
+	defer s.g.mu.Unlock()
+	return s.g.rng.Int63()
+}
+
+func (s generatorSource) Seed(seed int64) {
+	s.g.Seed(seed)
+}
+
+// fakerMutex serializes faker calls, as faker draws from package-level sources.
+var fakerMutex = sync.Mutex{}
+
+// fake runs f, which calls faker, with faker drawing its random values from g.
+func (g *Generator) fake(f func()) {
+	fakerMutex.Lock()
+	defer fakerMutex.Unlock()
+	faker.SetRandomSource(generatorSource{g})
+	faker.SetCryptoSource(g)
+	f()
+}
+
+// fakeString returns the result of the faker function f, drawn from g.
+func (g *Generator) fakeString(f func() string) string {
+	var s string
+	g.fake(func() { s = f() })
+	return s
+}
+
+// Vars returns a copy of the template variables of {{var:name}} placeholders.
+func (g *Generator) Vars() map[string]string {
+	g.varsMu.RLock()
+	defer g.varsMu.RUnlock()
+	return maps.Clone(g.vars)
+}
+
+// Var returns the template variable name, empty when it is not set.
+func (g *Generator) Var(name string) string {
+	g.varsMu.RLock()
+	defer g.varsMu.RUnlock()
+	return g.vars[name]
+}
+
+// SetVars replaces the template variables of {{var:name}} placeholders.
+func (g *Generator) SetVars(vars map[string]string) {
+	g.varsMu.Lock()
+	defer g.varsMu.Unlock()
+	g.vars = maps.Clone(vars)
+	if g.vars == nil {
+		g.vars = map[string]string{}
+	}
+}
+
+// AddVar sets a single template variable.
+func (g *Generator) AddVar(name, val string) {
+	g.varsMu.Lock()
+	defer g.varsMu.Unlock()
+	g.vars[name] = val
+}
+
+// ClearVars removes all template variables.
+func (g *Generator) ClearVars() {
+	g.SetVars(nil)
+}
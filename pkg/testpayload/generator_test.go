@@ -0,0 +1,93 @@
+package testpayload
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestGenerator_Deterministic(t *testing.T) {
+	tmpl := `{"id":"{{uuid}}","name":"{{name}}","n":{{randint:1:1000}},"s":{{stream:s:intrange:1:100}},"c":{{counter}}}`
+	render := func(g *Generator) string {
+		res, err := g.Interpolate(tmpl)
+		if err != nil {
+			t.Fatalf("Interpolate() error = %v", err)
+		}
+		return string(res)
+	}
+	a, b := NewGenerator(42), NewGenerator(42)
+	for range 5 {
+		if ra, rb := render(a), render(b); ra != rb {
+			t.Fatalf("same seed produced %s and %s", ra, rb)
+		}
+	}
+	if render(NewGenerator(42)) == render(NewGenerator(43)) {
+		t.Error("different seeds produced the same payload")
+	}
+	// Using the default generator does not shift a generator's sequence
+	c := NewGenerator(42)
+	first := render(c)
+	c.Seed(42)
+	c.ResetCounters()
+	if _, err := Interpolate(tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if second := render(c); second != first {
+		t.Errorf("reseeded generator produced %s, want %s", second, first)
+	}
+}
+
+func TestGenerator_Worker(t *testing.T) {
+	if DeriveSeed(1, 0) == DeriveSeed(1, 1) || DeriveSeed(1, 0) == DeriveSeed(2, 0) {
+		t.Error("DeriveSeed should give distinct seeds to distinct workers and runs")
+	}
+	if DeriveSeed(7, 3) != DeriveSeed(7, 3) {
+		t.Error("DeriveSeed should be deterministic")
+	}
+
+	parent := NewGenerator(7)
+	parent.AddVar("region", "eu")
+	run := func() []string {
+		out := make([]string, 4)
+		var wg sync.WaitGroup
+		for i := range out {
+			wg.Add(1)
+			go func(w *Generator) {
+				defer wg.Done()
+				for range 50 {
+					res, err := w.Interpolate(`{{var:region}}-{{counter}}-{{randint:1:1000000}}-{{email}}`)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					out[i] = string(res)
+				}
+			}(parent.Worker(i))
+		}
+		wg.Wait()
+		return out
+	}
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("worker %d produced %s then %s", i, first[i], second[i])
+		}
+		if first[i][:6] != "eu-50-" {
+			t.Errorf("worker %d: expected its own counter and the parent vars, got %s", i, first[i])
+		}
+	}
+	if first[0] == first[1] {
+		t.Error("workers should draw distinct sequences")
+	}
+}
+
+func TestGenerator_RenderGoTemplate(t *testing.T) {
+	g := NewGenerator(1)
+	g.AddVar("env", "test")
+	res, err := g.RenderGoTemplate(`{{var "env"}}:{{counter}}{{counter}}`, "{{", "}}")
+	if err != nil || string(res) != "test:12" {
+		t.Errorf("RenderGoTemplate() = %q, %v", res, err)
+	}
+	if DefaultGenerator().Var("env") == "test" {
+		t.Error("generator vars leaked into the default generator")
+	}
+}
@@ -0,0 +1,130 @@
+package testpayload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// geoDecimals is the precision of generated coordinates, about 0.1 m.
+const geoDecimals = 6
+
+// geoBounds is a region of coordinates, in degrees.
+type geoBounds struct {
+	minLon, minLat, maxLon, maxLat float64
+}
+
+// worldBounds covers every valid coordinate.
+var worldBounds = geoBounds{minLon: -180, minLat: -90, maxLon: 180, maxLat: 90}
+
+// coordinate returns a random coordinate in [lo, hi] with geoDecimals decimals.
+func (g *Generator) coordinate(lo, hi float64) ([]byte, error) {
+	return g.generateRandFloat(fmt.Sprintf("%g:%g:%d", lo, hi, geoDecimals))
+}
+
+// point returns a random longitude and latitude in b.
+func (g *Generator) point(b geoBounds) (lon []byte, lat []byte, err error) {
+	if lon, err = g.coordinate(b.minLon, b.maxLon); err != nil {
+		return nil, nil, err
+	}
+	if lat, err = g.coordinate(b.minLat, b.maxLat); err != nil {
+		return nil, nil, err
+	}
+	return lon, lat, nil
+}
+
+// generateLat evaluates a lat expression (the placeholder without the "lat:" prefix),
+// MIN:MAX, returning a latitude in [MIN, MAX].
+func (g *Generator) generateLat(expr string) ([]byte, error) {
+	return g.boundedCoordinate("lat", expr, 90)
+}
+
+// generateLon evaluates a lon expression (the placeholder without the "lon:" prefix),
+// MIN:MAX, returning a longitude in [MIN, MAX].
+func (g *Generator) generateLon(expr string) ([]byte, error) {
+	return g.boundedCoordinate("lon", expr, 180)
+}
+
+// boundedCoordinate returns a coordinate in the MIN:MAX range of a lat or lon placeholder,
+// which must lie within [-limit, limit].
+func (g *Generator) boundedCoordinate(keyword, expr string, limit float64) ([]byte, error) {
+	ph := keyword + ":" + expr
+	args := strings.Split(expr, ":")
+	if len(args) != 2 {
+		return nil, fmt.Errorf("invalid %s placeholder %q: expected %s:MIN:MAX", keyword, ph, keyword)
+	}
+	lo, err := parseCoordinate(args[0], limit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s placeholder %q: %w", keyword, ph, err)
+	}
+	hi, err := parseCoordinate(args[1], limit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s placeholder %q: %w", keyword, ph, err)
+	}
+	if hi < lo {
+		return nil, fmt.Errorf("invalid %s: max %g is less than min %g", keyword, hi, lo)
+	}
+	return g.coordinate(lo, hi)
+}
+
+// parseCoordinate parses a coordinate in degrees within [-limit, limit].
+func parseCoordinate(s string, limit float64) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a coordinate", s)
+	}
+	if v < -limit || v > limit {
+		return 0, fmt.Errorf("coordinate %g is outside [-%g, %g]", v, limit, limit)
+	}
+	return v, nil
+}
+
+// generateGeoJSON evaluates a geojson expression (the placeholder without the "geojson:"
+// prefix), point or feature, optionally followed by a region
+// :MINLON,MINLAT,MAXLON,MAXLAT (the GeoJSON bbox order), returning a GeoJSON Point geometry or
+// a Feature with a Point geometry, its coordinates in the region.
+func (g *Generator) generateGeoJSON(expr string) ([]byte, error) {
+	ph := "geojson:" + expr
+	kind, bbox, hasBBox := strings.Cut(expr, ":")
+	if kind != "point" && kind != "feature" {
+		return nil, fmt.Errorf("invalid geojson placeholder %q: expected geojson:point or geojson:feature", ph)
+	}
+	bounds := worldBounds
+	if hasBBox {
+		var err error
+		if bounds, err = parseBBox(bbox); err != nil {
+			return nil, fmt.Errorf("invalid geojson placeholder %q: %w", ph, err)
+		}
+	}
+	lon, lat, err := g.point(bounds)
+	if err != nil {
+		return nil, err
+	}
+	geometry := `{"type":"Point","coordinates":[` + string(lon) + `,` + string(lat) + `]}`
+	if kind == "point" {
+		return []byte(geometry), nil
+	}
+	return []byte(`{"type":"Feature","geometry":` + geometry + `,"properties":{}}`), nil
+}
+
+// parseBBox parses a MINLON,MINLAT,MAXLON,MAXLAT region.
+func parseBBox(s string) (geoBounds, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return geoBounds{}, fmt.Errorf("expected a MINLON,MINLAT,MAXLON,MAXLAT region, got %q", s)
+	}
+	limits := []float64{180, 90, 180, 90}
+	vals := make([]float64, 4)
+	for i, p := range parts {
+		v, err := parseCoordinate(p, limits[i])
+		if err != nil {
+			return geoBounds{}, err
+		}
+		vals[i] = v
+	}
+	b := geoBounds{minLon: vals[0], minLat: vals[1], maxLon: vals[2], maxLat: vals[3]}
+	if b.maxLon < b.minLon || b.maxLat < b.minLat {
+		return geoBounds{}, fmt.Errorf("region %q has a max less than its min", s)
+	}
+	return b, nil
+}
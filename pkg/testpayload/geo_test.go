@@ -0,0 +1,77 @@
+package testpayload
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInterpolateWithDelimiters_GeoPlaceholders(t *testing.T) {
+	coord := func(tmpl string, lo, hi float64) {
+		t.Helper()
+		for range 50 {
+			res, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+			if err != nil {
+				t.Fatalf("InterpolateWithDelimiters(%s) error = %v", tmpl, err)
+			}
+			v, err := strconv.ParseFloat(string(res), 64)
+			if err != nil || v < lo || v > hi {
+				t.Fatalf("%s = %s, want a coordinate in [%g, %g]", tmpl, res, lo, hi)
+			}
+			if _, frac, _ := strings.Cut(string(res), "."); len(frac) != geoDecimals {
+				t.Fatalf("%s = %s, want %d decimals", tmpl, res, geoDecimals)
+			}
+		}
+	}
+	coord("{{lat}}", -90, 90)
+	coord("{{lon}}", -180, 180)
+	coord("{{lat:45.4:45.5}}", 45.4, 45.5)
+	coord("{{lon:-74.1:-73.9}}", -74.1, -73.9)
+
+	type point struct {
+		Type        string
+		Coordinates []float64
+	}
+	for range 50 {
+		res, err := InterpolateWithDelimiters("{{geojson:point:9.1,45.4,9.3,45.5}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		var p point
+		if err := json.Unmarshal(res, &p); err != nil {
+			t.Fatalf("invalid GeoJSON %s: %v", res, err)
+		}
+		if p.Type != "Point" || len(p.Coordinates) != 2 || p.Coordinates[0] < 9.1 || p.Coordinates[0] > 9.3 ||
+			p.Coordinates[1] < 45.4 || p.Coordinates[1] > 45.5 {
+			t.Fatalf("unexpected point %s", res)
+		}
+	}
+
+	res, err := InterpolateWithDelimiters(`{"location":{{geojson:feature}}}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	var v struct {
+		Location struct {
+			Type       string
+			Geometry   point
+			Properties map[string]any
+		}
+	}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", res, err)
+	}
+	if v.Location.Type != "Feature" || v.Location.Geometry.Type != "Point" || v.Location.Properties == nil {
+		t.Errorf("unexpected feature %s", res)
+	}
+
+	for _, bad := range []string{
+		"{{lat:91:92}}", "{{lat:10}}", "{{lat:5:1}}", "{{lon:x:1}}", "{{lon:-181:0}}",
+		"{{geojson:line}}", "{{geojson:point:1,2,3}}", "{{geojson:point:10,0,5,1}}", "{{geojson:point:0,-91,1,1}}",
+	} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
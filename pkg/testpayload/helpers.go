@@ -0,0 +1,127 @@
+package testpayload
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// helper reshapes the value of the placeholder it wraps, see helpers.
+type helper struct {
+	// arg tells whether the helper takes an argument, written {{helper:ARG:INNER}}.
+	arg bool
+	fn  func(arg string, val []byte) ([]byte, error)
+}
+
+// helpers are the string and math wrappers, chaining with the encoding wrappers: upper:,
+// lower: and trim: take no argument, trunc:N keeps the first N characters, add:N and mul:N
+// add to or multiply a number, round:D rounds a number to D decimals. For example
+// {{upper:trunc:8:uuid}} or {{add:1000:randint:1:10}}.
+var helpers = map[string]helper{
+	"upper": {fn: func(_ string, val []byte) ([]byte, error) { return bytes.ToUpper(val), nil }},
+	"lower": {fn: func(_ string, val []byte) ([]byte, error) { return bytes.ToLower(val), nil }},
+	"trim":  {fn: func(_ string, val []byte) ([]byte, error) { return bytes.TrimSpace(val), nil }},
+	"trunc": {arg: true, fn: truncateValue},
+	"add": {arg: true, fn: func(arg string, val []byte) ([]byte, error) {
+		return mathValue("add", arg, val, addInt, func(a, b float64) float64 { return a + b })
+	}},
+	"mul": {arg: true, fn: func(arg string, val []byte) ([]byte, error) {
+		return mathValue("mul", arg, val, mulInt, func(a, b float64) float64 { return a * b })
+	}},
+	"round": {arg: true, fn: roundValue},
+}
+
+// helperPrefix returns the length of the helper prefix at the start of inner, including the
+// argument of helpers taking one, or 0 when inner does not start with a helper.
+func helperPrefix(inner string) int {
+	name, rest, ok := strings.Cut(inner, ":")
+	h, known := helpers[name]
+	if !ok || !known {
+		return 0
+	}
+	if !h.arg {
+		return len(name) + 1
+	}
+	arg, _, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0
+	}
+	return len(name) + len(arg) + 2
+}
+
+// applyHelper applies the helper of a wrapper chain element, name: or name:ARG:.
+func applyHelper(w string, val []byte) ([]byte, error) {
+	name, arg, _ := strings.Cut(strings.TrimSuffix(w, ":"), ":")
+	h, ok := helpers[name]
+	if !ok {
+		return val, nil
+	}
+	return h.fn(arg, val)
+}
+
+// truncateValue keeps the first N characters (not bytes) of val.
+func truncateValue(arg string, val []byte) ([]byte, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid trunc placeholder: %q is not a character count", arg)
+	}
+	runes := bytes.Runes(val)
+	if len(runes) <= n {
+		return val, nil
+	}
+	return []byte(string(runes[:n])), nil
+}
+
+// mathValue applies a math helper to the number val and the argument: on integers when both
+// are integers and the result fits in an int64, on floats otherwise.
+func mathValue(name, arg string, val []byte, intOp func(a, b int64) (int64, bool), floatOp func(a, b float64) float64) ([]byte, error) {
+	s := strings.TrimSpace(string(val))
+	if a, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if b, err := strconv.ParseInt(arg, 10, 64); err == nil {
+			if n, ok := intOp(a, b); ok {
+				return []byte(strconv.FormatInt(n, 10)), nil
+			}
+		}
+	}
+	a, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s placeholder: value %q is not a number", name, s)
+	}
+	b, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s placeholder: argument %q is not a number", name, arg)
+	}
+	return []byte(strconv.FormatFloat(floatOp(a, b), 'f', -1, 64)), nil
+}
+
+// addInt returns a + b, or false when the sum overflows an int64.
+func addInt(a, b int64) (int64, bool) {
+	n := a + b
+	return n, (n > a) == (b > 0)
+}
+
+// mulInt returns a * b, or false when the product overflows an int64.
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	n := a * b
+	return n, n/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+}
+
+// roundValue rounds the number val to D decimals, formatted with exactly D decimals.
+func roundValue(arg string, val []byte) ([]byte, error) {
+	decimals, err := strconv.Atoi(arg)
+	if err != nil || decimals < 0 || decimals > 15 {
+		return nil, fmt.Errorf("invalid round decimals %q: expected 0 to 15", arg)
+	}
+	s := strings.TrimSpace(string(val))
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid round placeholder: value %q is not a number", s)
+	}
+	scale := math.Pow10(decimals)
+	return []byte(strconv.FormatFloat(math.Round(f*scale)/scale, 'f', decimals, 64)), nil
+}
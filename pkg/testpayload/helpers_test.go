@@ -0,0 +1,74 @@
+package testpayload
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestInterpolateWithDelimiters_Helpers(t *testing.T) {
+	AddTemplateVar("dev", "  Sensor-Ünit  ")
+	defer ClearTemplateVars()
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{"{{upper:var:dev}}", "  SENSOR-ÜNIT  "},
+		{"{{lower:trim:var:dev}}", "sensor-ünit"},
+		{"{{trunc:8:trim:var:dev}}", "Sensor-Ü"},
+		{"{{trunc:0:var:dev}}", ""},
+		{"{{add:10:raw:5}}", "15"},
+		{"{{add:-0.5:raw:2}}", "1.5"},
+		{"{{mul:3:raw:1.5}}", "4.5"},
+		{"{{mul:2:raw:9223372036854775807}}", "18446744073709552000"},
+		{"{{mul:-1:raw:-9223372036854775808}}", "9223372036854776000"},
+		{"{{add:1:raw:9223372036854775807}}", "9223372036854776000"},
+		{"{{add:-1:raw:-9223372036854775808}}", "-9223372036854776000"},
+		{"{{add:0:raw:-5}}", "-5"},
+		{"{{mul:-3:raw:4}}", "-12"},
+		{"{{round:1:raw:2.26}}", "2.3"},
+		{"{{round:2:mul:2:raw:1}}", "2.00"},
+		{`{"name":{{str:upper:raw:a"b}}}`, `{"name":"A\"B"}`},
+		{"{{hex:upper:raw:ab}}", "4142"},
+	}
+	for _, tt := range tests {
+		res, err := InterpolateWithDelimiters(tt.tmpl, "{{", "}}")
+		if err != nil {
+			t.Errorf("InterpolateWithDelimiters(%s) error = %v", tt.tmpl, err)
+			continue
+		}
+		if string(res) != tt.want {
+			t.Errorf("InterpolateWithDelimiters(%s) = %q, want %q", tt.tmpl, res, tt.want)
+		}
+	}
+
+	for range 20 {
+		res, err := InterpolateWithDelimiters("{{add:1000:randint:1:10}}", "{{", "}}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := strconv.Atoi(string(res)); err != nil || n < 1001 || n > 1010 {
+			t.Fatalf("add:1000:randint:1:10 = %s", res)
+		}
+	}
+	res, err := InterpolateWithDelimiters("{{upper:trunc:8:uuid}}", "{{", "}}")
+	if err != nil || !regexp.MustCompile(`^[0-9A-F]{8}$`).Match(res) {
+		t.Errorf("upper:trunc:8:uuid = %q, %v", res, err)
+	}
+
+	for _, bad := range []string{"{{trunc:x:uuid}}", "{{add:1:raw:abc}}", "{{mul:x:raw:1}}", "{{round:99:raw:1}}", "{{trunc:uuid}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+
+	SetStrictTemplates(true)
+	defer SetStrictTemplates(false)
+	if _, err := InterpolateWithDelimiters("{{upper:trunc:4:name}}", "{{", "}}"); err != nil {
+		t.Errorf("strict mode rejected helpers: %v", err)
+	}
+	if _, err := InterpolateWithDelimiters("{{upper:nme}}", "{{", "}}"); err == nil {
+		t.Error("strict mode should reject unknown wrapped placeholders")
+	}
+}
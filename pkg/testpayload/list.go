@@ -0,0 +1,148 @@
+package testpayload
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// List modes of {{list:PATH[:MODE]}} placeholders.
+const (
+	listSequential = "seq"
+	listRandom     = "random"
+)
+
+// valueList is a loaded {{list:PATH}} file and the index of its next sequential value.
+type valueList struct {
+	values []string
+	next   int
+}
+
+// pick returns the next value of the list in mode: in order, cycling back to the first after
+// the last, or a random one.
+func (l *valueList) pick(g *Generator, mode string) string {
+	if mode == listRandom {
+		return l.values[g.Intn(len(l.values))]
+	}
+	val := l.values[l.next]
+	l.next = (l.next + 1) % len(l.values)
+	return val
+}
+
+// Value lists of list: placeholders, by path, and the files matched by file: globs, by
+// pattern, loaded on first use.
+var (
+	valueLists      = map[string]*valueList{}
+	fileGlobs       = map[string]*valueList{}
+	valueListsMutex = sync.Mutex{}
+)
+
+// ClearLists forgets the loaded list: files and the files matched by file: globs, so they are
+// read or matched again and restart from their first value.
+func ClearLists() {
+	valueListsMutex.Lock()
+	defer valueListsMutex.Unlock()
+	clear(valueLists)
+	clear(fileGlobs)
+}
+
+// generateList evaluates a list expression (the placeholder without the "list:" prefix),
+// PATH[:MODE], returning a line of the file PATH: the next one in order, cycling back to the
+// first after the last (MODE seq, the default), or a random one (MODE random). Blank lines
+// are skipped. Like file:, list: needs AllowFileReads and honours FileRoot.
+func (g *Generator) generateList(expr string) ([]byte, error) {
+	ph := "list:" + expr
+	path, mode := listMode(expr)
+	if path == "" {
+		return nil, fmt.Errorf("invalid list placeholder %q: expected list:PATH[:seq|random]", ph)
+	}
+
+	valueListsMutex.Lock()
+	defer valueListsMutex.Unlock()
+	list, ok := valueLists[path]
+	if !ok {
+		values, err := readValueList(path)
+		if err != nil {
+			return nil, err
+		}
+		list = &valueList{values: values}
+		valueLists[path] = list
+	}
+	return []byte(list.pick(g, mode)), nil
+}
+
+// listMode splits the optional :seq or :random MODE suffix off expr.
+func listMode(expr string) (string, string) {
+	if i := strings.LastIndex(expr, ":"); i != -1 {
+		if m := expr[i+1:]; m == listSequential || m == listRandom {
+			return expr[:i], m
+		}
+	}
+	return expr, listSequential
+}
+
+// isGlob tells whether a file: path is a glob pattern.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// globFile evaluates a file: glob, PATTERN[:MODE], returning the path of a matching file: the
+// next one in lexical order, cycling back to the first after the last (MODE seq, the
+// default), or a random one (MODE random). Directories are skipped; the pattern is matched
+// once, on first use.
+func (g *Generator) globFile(expr string) (string, error) {
+	pattern, mode := listMode(expr)
+	if err := checkFileRead(pattern); err != nil {
+		return "", err
+	}
+	valueListsMutex.Lock()
+	defer valueListsMutex.Unlock()
+	files, ok := fileGlobs[pattern]
+	if !ok {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+		}
+		matches = slices.DeleteFunc(matches, func(path string) bool {
+			info, err := os.Stat(path)
+			return err != nil || info.IsDir()
+		})
+		if len(matches) == 0 {
+			return "", fmt.Errorf("no files match %s", pattern)
+		}
+		files = &valueList{values: matches}
+		fileGlobs[pattern] = files
+	}
+	return files.pick(g, mode), nil
+}
+
+// readValueList reads the non-blank lines of a list: file.
+func readValueList(path string) ([]string, error) {
+	if err := checkFileRead(path); err != nil {
+		return nil, err
+	}
+	// #nosec G304 - File path is validated and restricted by FileRoot
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list file %s: %w", path, err)
+	}
+	defer f.Close() //nolint:errcheck
+	var values []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(line) != "" {
+			values = append(values, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read list file %s: %w", path, err)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("list file %s has no values", path)
+	}
+	return values, nil
+}
@@ -0,0 +1,116 @@
+package testpayload
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInterpolateWithDelimiters_ListPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "devices.txt")
+	if err := os.WriteFile(path, []byte("dev-1\r\n\ndev-2\ndev-3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SetAllowFileReads(true)
+	defer SetAllowFileReads(false)
+	defer ClearLists()
+
+	var got []string
+	for range 4 {
+		res, err := InterpolateWithDelimiters("{{list:"+path+"}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		got = append(got, string(res))
+	}
+	if want := []string{"dev-1", "dev-2", "dev-3", "dev-1"}; !slices.Equal(got, want) {
+		t.Errorf("sequential values = %q, want %q", got, want)
+	}
+
+	res, err := InterpolateWithDelimiters("{{str:list:"+path+"}}", "{{", "}}")
+	if err != nil || string(res) != `"dev-2"` {
+		t.Errorf("InterpolateWithDelimiters() = %q, %v", res, err)
+	}
+	res, err = InterpolateWithDelimiters("{{list:"+path+":seq}}", "{{", "}}")
+	if err != nil || string(res) != "dev-3" {
+		t.Errorf("InterpolateWithDelimiters() = %q, %v", res, err)
+	}
+	for range 20 {
+		res, err := InterpolateWithDelimiters("{{list:"+path+":random}}", "{{", "}}")
+		if err != nil || !slices.Contains([]string{"dev-1", "dev-2", "dev-3"}, string(res)) {
+			t.Fatalf("random value = %q, %v", res, err)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("\n  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []string{"{{list:}}", "{{list:" + empty + "}}", "{{list:" + filepath.Join(dir, "missing.txt") + "}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+
+	ClearLists()
+	SetFileRoot(filepath.Join(dir, "sub"))
+	defer SetFileRoot("")
+	if _, err := InterpolateWithDelimiters("{{list:"+path+"}}", "{{", "}}"); err == nil {
+		t.Error("expected an error for a file outside the root")
+	}
+	SetFileRoot("")
+	SetAllowFileReads(false)
+	if _, err := InterpolateWithDelimiters("{{list:"+path+"}}", "{{", "}}"); err == nil {
+		t.Error("expected an error with file reads disabled")
+	}
+}
+
+func TestInterpolateWithDelimiters_FileGlob(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"b.json": `{"n":2}`, "a.json": `{"n":1}`, "c.txt": "skip"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "d.json"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	SetAllowFileReads(true)
+	defer SetAllowFileReads(false)
+	defer ClearLists()
+
+	pattern := filepath.Join(dir, "*.json")
+	var got []string
+	for range 3 {
+		res, err := InterpolateWithDelimiters("{{file:"+pattern+"}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		got = append(got, string(res))
+	}
+	if want := []string{`{"n":1}`, `{"n":2}`, `{"n":1}`}; !slices.Equal(got, want) {
+		t.Errorf("sequential files = %q, want %q", got, want)
+	}
+	res, err := InterpolateWithDelimiters("{{base64:file:"+pattern+":seq}}", "{{", "}}")
+	if err != nil || string(res) != "eyJuIjoyfQ==" {
+		t.Errorf("wrapped glob = %q, %v", res, err)
+	}
+	for range 20 {
+		res, err := InterpolateWithDelimiters("{{file:"+pattern+":random}}", "{{", "}}")
+		if err != nil || !slices.Contains([]string{`{"n":1}`, `{"n":2}`}, string(res)) {
+			t.Fatalf("random file = %q, %v", res, err)
+		}
+	}
+
+	for _, bad := range []string{filepath.Join(dir, "*.xml"), filepath.Join(dir, "[")} {
+		if _, err := InterpolateWithDelimiters("{{file:"+bad+"}}", "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+	SetAllowFileReads(false)
+	if _, err := InterpolateWithDelimiters("{{file:"+pattern+"}}", "{{", "}}"); err == nil {
+		t.Error("expected an error with file reads disabled")
+	}
+}
@@ -0,0 +1,164 @@
+package testpayload
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// maxProtoDepth bounds the nesting of generated protobuf messages, so recursive message types
+// terminate.
+const maxProtoDepth = 4
+
+// maxProtoRepeated is the most elements generated for a repeated or map field.
+const maxProtoRepeated = 3
+
+// ProtoFiles resolves the message types of {{proto:MESSAGE}} placeholders and EncodeProtoJSON.
+var ProtoFiles *protoregistry.Files
+
+// SetProtoFiles sets the descriptors message types are resolved from, nil disables them.
+func SetProtoFiles(files *protoregistry.Files) {
+	ProtoFiles = files
+}
+
+// ReadProtoset reads a binary FileDescriptorSet, as produced by
+// `protoc --include_imports --descriptor_set_out=FILE` or `buf build -o FILE`.
+func ReadProtoset(path string) (*protoregistry.Files, error) {
+	// #nosec G304 -- descriptor set path is intentionally provided by user via CLI flag
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protoset: %w", err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("invalid protoset %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protoset %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// findProtoMessage looks up the descriptor of the fully-qualified message name in ProtoFiles.
+func findProtoMessage(name string) (protoreflect.MessageDescriptor, error) {
+	if ProtoFiles == nil {
+		return nil, fmt.Errorf("no protoset loaded for message %q", name)
+	}
+	d, err := ProtoFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("message %q not found: %w", name, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message", name)
+	}
+	return md, nil
+}
+
+// EncodeProtoJSON converts the protobuf JSON form of a message of type name to its binary
+// encoding.
+func EncodeProtoJSON(name string, data []byte) ([]byte, error) {
+	md, err := findProtoMessage(name)
+	if err != nil {
+		return nil, err
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("payload does not match %s: %w", name, err)
+	}
+	return proto.Marshal(msg)
+}
+
+// generateProto evaluates a proto expression (the placeholder without the "proto:" prefix),
+// MESSAGE, returning a binary-encoded message of that type with random field values.
+func (g *Generator) generateProto(name string) ([]byte, error) {
+	md, err := findProtoMessage(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proto placeholder %q: %w", "proto:"+name, err)
+	}
+	msg := dynamicpb.NewMessage(md)
+	g.fillProtoMessage(msg, 0)
+	return proto.Marshal(msg)
+}
+
+// fillProtoMessage sets every field of msg, one field per oneof, to random values. Message
+// fields deeper than maxProtoDepth are left unset.
+func (g *Generator) fillProtoMessage(msg protoreflect.Message, depth int) {
+	oneofs := msg.Descriptor().Oneofs()
+	chosen := make(map[protoreflect.FullName]protoreflect.FieldDescriptor, oneofs.Len())
+	for i := range oneofs.Len() {
+		if o := oneofs.Get(i); !o.IsSynthetic() {
+			chosen[o.FullName()] = o.Fields().Get(g.Intn(o.Fields().Len()))
+		}
+	}
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if o := fd.ContainingOneof(); o != nil && !o.IsSynthetic() && chosen[o.FullName()] != fd {
+			continue
+		}
+		if fd.Message() != nil && depth >= maxProtoDepth {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			m := msg.Mutable(fd).Map()
+			for range g.Intn(maxProtoRepeated + 1) {
+				m.Set(g.randomProtoValue(fd.MapKey(), depth).MapKey(), g.randomProtoValue(fd.MapValue(), depth))
+			}
+		case fd.IsList():
+			l := msg.Mutable(fd).List()
+			for range g.Intn(maxProtoRepeated + 1) {
+				l.Append(g.randomProtoValue(fd, depth))
+			}
+		default:
+			msg.Set(fd, g.randomProtoValue(fd, depth))
+		}
+	}
+}
+
+// randomProtoValue returns a random value for a singular field, list element or map entry.
+func (g *Generator) randomProtoValue(fd protoreflect.FieldDescriptor, depth int) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(g.Intn(2) == 1)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(g.Int63n(2001) - 1000))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(g.Int63n(2_000_001) - 1_000_000)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(g.Int63n(1001)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(g.Int63n(1_000_001)))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(g.Int63n(200_001)-100_000) / 100)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(g.Int63n(2_000_001)-1_000_000) / 100)
+	case protoreflect.StringKind:
+		buf := make([]byte, 4+g.Intn(9))
+		for i := range buf {
+			buf[i] = sizeAlphabet[g.Intn(len(sizeAlphabet))]
+		}
+		return protoreflect.ValueOfString(string(buf))
+	case protoreflect.BytesKind:
+		buf := make([]byte, 4+g.Intn(13))
+		_, _ = g.Read(buf)
+		return protoreflect.ValueOfBytes(buf)
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		return protoreflect.ValueOfEnum(values.Get(g.Intn(values.Len())).Number())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		elem := dynamicpb.NewMessage(fd.Message())
+		g.fillProtoMessage(elem, depth+1)
+		return protoreflect.ValueOfMessage(elem)
+	}
+	return fd.Default()
+}
@@ -0,0 +1,136 @@
+package testpayload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// writeTestProtoset writes a descriptor set with test.Event, covering scalars, enums, nested
+// and recursive messages, repeated and map fields and a oneof.
+func writeTestProtoset(t *testing.T) string {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(num), Type: typ.Enum(), Label: label.Enum(), JsonName: proto.String(name)}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	opt, rep := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	textField, numberField := field("text", 10, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""), field("number", 11, descriptorpb.FieldDescriptorProto_TYPE_INT64, opt, "")
+	textField.OneofIndex, numberField.OneofIndex = proto.Int32(0), proto.Int32(0)
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("event.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Level"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("LEVEL_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("LEVEL_WARN"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Event"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""),
+				field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, opt, ""),
+				field("level", 3, descriptorpb.FieldDescriptorProto_TYPE_ENUM, opt, ".test.Level"),
+				field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, rep, ""),
+				field("parent", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt, ".test.Event"),
+				field("labels", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, rep, ".test.Event.LabelsEntry"),
+				field("raw", 7, descriptorpb.FieldDescriptorProto_TYPE_BYTES, opt, ""),
+				textField,
+				numberField,
+			},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("body")}},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("LabelsEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""),
+					field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, opt, ""),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "event.protoset")
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProtoPlaceholder(t *testing.T) {
+	files, err := ReadProtoset(writeTestProtoset(t))
+	if err != nil {
+		t.Fatalf("ReadProtoset() error = %v", err)
+	}
+	if _, err := InterpolateWithDelimiters("{{proto:test.Event}}", "{{", "}}"); err == nil {
+		t.Error("expected an error without a protoset")
+	}
+	SetProtoFiles(files)
+	defer SetProtoFiles(nil)
+
+	d, _ := files.FindDescriptorByName("test.Event")
+	md := d.(protoreflect.MessageDescriptor)
+	SeedRandom(5)
+	for range 20 {
+		b, err := InterpolateWithDelimiters("{{proto:test.Event}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		msg := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(b, msg); err != nil {
+			t.Fatalf("generated bytes do not decode as test.Event: %v", err)
+		}
+		if msg.Get(md.Fields().ByName("id")).String() == "" {
+			t.Errorf("id not generated in %v", msg)
+		}
+		if msg.Has(md.Fields().ByName("text")) && msg.Has(md.Fields().ByName("number")) {
+			t.Errorf("both oneof fields set in %v", msg)
+		}
+	}
+
+	for _, bad := range []string{"{{proto:test.Missing}}", "{{proto:test.Level}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestEncodeProtoJSON(t *testing.T) {
+	files, err := ReadProtoset(writeTestProtoset(t))
+	if err != nil {
+		t.Fatalf("ReadProtoset() error = %v", err)
+	}
+	SetProtoFiles(files)
+	defer SetProtoFiles(nil)
+
+	b, err := EncodeProtoJSON("test.Event", []byte(`{"id":"e1","level":"LEVEL_WARN","labels":{"a":1}}`))
+	if err != nil {
+		t.Fatalf("EncodeProtoJSON() error = %v", err)
+	}
+	d, _ := files.FindDescriptorByName("test.Event")
+	md := d.(protoreflect.MessageDescriptor)
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Get(md.Fields().ByName("id")).String() != "e1" || msg.Get(md.Fields().ByName("level")).Enum() != 1 {
+		t.Errorf("decoded %v", msg)
+	}
+	if _, err := EncodeProtoJSON("test.Event", []byte(`{"nope":1}`)); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
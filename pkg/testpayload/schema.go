@@ -0,0 +1,254 @@
+package testpayload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaNode generates one value of a payload schema: a field generator, an object with
+// ordered fields, or a list of 1 to maxSchemaList elements.
+type schemaNode struct {
+	gen    schemaGen
+	keys   []string
+	fields []*schemaNode
+	elem   *schemaNode
+}
+
+// schemaGen generates the value of a schema field from g.
+type schemaGen func(g *Generator) (any, error)
+
+// maxSchemaList is the most elements generated for a list field.
+const maxSchemaList = 3
+
+// schemaTypes are the generators of a schema besides the placeholder keywords.
+var schemaTypes = map[string]schemaGen{
+	"string": func(g *Generator) (any, error) { return g.sentence(), nil },
+	"int":    func(g *Generator) (any, error) { return g.Int63n(1001), nil },
+	"float":  func(g *Generator) (any, error) { return float64(g.Int63n(100_001)) / 100, nil },
+	"bool":   func(g *Generator) (any, error) { return g.Intn(2) == 1, nil },
+	"unix_time": func(g *Generator) (any, error) {
+		return g.Int63n(time.Now().Unix()), nil
+	},
+}
+
+// Payload schema of {{json}} and {{cbor}}, see LoadPayloadSchema.
+var (
+	payloadSchema      *schemaNode
+	payloadSchemaMutex = sync.RWMutex{}
+)
+
+// LoadPayloadSchema makes {{json}} and {{cbor}} generate objects shaped by a YAML or JSON
+// field-spec file instead of the built-in Payload struct. Each field maps to a generator: a
+// placeholder keyword such as uuid, name, email, counter or randint:1:10, one of the types
+// string, int, float, bool or unix_time, a nested object, or a one-element list whose element
+// is generated 1 to 3 times. Other scalars (numbers, booleans, null) are copied as constants.
+func LoadPayloadSchema(path string) error {
+	// #nosec G304 -- schema file explicitly given by the user
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read payload schema: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("invalid payload schema %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid payload schema %s: expected an object of fields", path)
+	}
+	schema, err := parseSchemaNode(doc.Content[0], "")
+	if err != nil {
+		return fmt.Errorf("invalid payload schema %s: %w", path, err)
+	}
+	payloadSchemaMutex.Lock()
+	defer payloadSchemaMutex.Unlock()
+	payloadSchema = schema
+	return nil
+}
+
+// ClearPayloadSchema restores the built-in Payload struct for {{json}} and {{cbor}}.
+func ClearPayloadSchema() {
+	payloadSchemaMutex.Lock()
+	defer payloadSchemaMutex.Unlock()
+	payloadSchema = nil
+}
+
+// parseSchemaNode builds the generator of a schema node; path locates it in error messages.
+func parseSchemaNode(n *yaml.Node, path string) (*schemaNode, error) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		node := &schemaNode{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			field, err := parseSchemaNode(n.Content[i+1], path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			node.keys = append(node.keys, key)
+			node.fields = append(node.fields, field)
+		}
+		return node, nil
+	case yaml.SequenceNode:
+		if len(n.Content) != 1 {
+			return nil, fmt.Errorf("field %s: a list must have exactly one element spec", path)
+		}
+		elem, err := parseSchemaNode(n.Content[0], path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		return &schemaNode{elem: elem}, nil
+	case yaml.ScalarNode:
+		if n.Tag != "!!str" {
+			var val any
+			if err := n.Decode(&val); err != nil {
+				return nil, fmt.Errorf("field %s: %w", path, err)
+			}
+			return &schemaNode{gen: func(*Generator) (any, error) { return val, nil }}, nil
+		}
+		gen, err := schemaGenerator(n.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", path, err)
+		}
+		return &schemaNode{gen: gen}, nil
+	}
+	return nil, fmt.Errorf("field %s: unsupported spec", path)
+}
+
+// schemaGenerator returns the generator of a field spec. Numeric placeholders produce
+// numbers, the others strings.
+func schemaGenerator(spec string) (schemaGen, error) {
+	if gen, ok := schemaTypes[spec]; ok {
+		return gen, nil
+	}
+	if gen, args, ok := argPlaceholder(spec); ok {
+		return func(g *Generator) (any, error) {
+			val, err := gen(g, args)
+			if err != nil {
+				return nil, err
+			}
+			return schemaValue(val), nil
+		}, nil
+	}
+	typ, ok := placeholders[spec]
+	if !ok || typ == TestPayloadJSON || typ == TestPayloadCBOR {
+		return nil, fmt.Errorf("unknown generator %q", spec)
+	}
+	return func(g *Generator) (any, error) {
+		val, err := g.Generate(typ)
+		if err != nil {
+			return nil, err
+		}
+		return schemaValue(val), nil
+	}, nil
+}
+
+// schemaValue returns generated text as a JSON number when it is one, a string otherwise.
+func schemaValue(val []byte) any {
+	if len(val) > 0 && (val[0] == '-' || val[0] >= '0' && val[0] <= '9') && json.Valid(val) {
+		return json.Number(val)
+	}
+	return string(val)
+}
+
+// generate returns a value of the node: objects are schemaObjects, keeping the field order.
+func (n *schemaNode) generate(g *Generator) (any, error) {
+	switch {
+	case n.gen != nil:
+		return n.gen(g)
+	case n.elem != nil:
+		list := make([]any, 1+g.Intn(maxSchemaList))
+		for i := range list {
+			val, err := n.elem.generate(g)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = val
+		}
+		return list, nil
+	}
+	obj := schemaObject{keys: n.keys, values: make([]any, len(n.fields))}
+	for i, field := range n.fields {
+		val, err := field.generate(g)
+		if err != nil {
+			return nil, err
+		}
+		obj.values[i] = val
+	}
+	return obj, nil
+}
+
+// schemaObject is a generated object, encoded with its fields in schema order.
+type schemaObject struct {
+	keys   []string
+	values []any
+}
+
+// MarshalJSON encodes the object with its fields in schema order.
+func (o schemaObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalCBOR encodes the object as a CBOR map, numbers as integers or floats.
+func (o schemaObject) MarshalCBOR() ([]byte, error) {
+	m := make(map[string]any, len(o.keys))
+	for i, key := range o.keys {
+		m[key] = cborValue(o.values[i])
+	}
+	return cbor.Marshal(m)
+}
+
+// cborValue converts the json.Numbers of a generated value to integers or floats.
+func cborValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		res := make([]any, len(v))
+		for i, elem := range v {
+			res[i] = cborValue(elem)
+		}
+		return res
+	}
+	return v
+}
+
+// schemaPayload returns an object of the loaded payload schema, or false without one.
+func (g *Generator) schemaPayload() (any, bool, error) {
+	payloadSchemaMutex.RLock()
+	schema := payloadSchema
+	payloadSchemaMutex.RUnlock()
+	if schema == nil {
+		return nil, false, nil
+	}
+	val, err := schema.generate(g)
+	return val, true, err
+}
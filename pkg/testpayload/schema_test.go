@@ -0,0 +1,108 @@
+package testpayload
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func writeSchema(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPayloadSchema(t *testing.T) {
+	defer ClearPayloadSchema()
+	path := writeSchema(t, `
+orderId: uuid
+customer:
+  name: name
+  email: email
+quantity: randint:1:10
+price: float
+paid: bool
+status: oneof:new|paid|shipped
+tags: [word]
+version: 2
+`)
+	if err := LoadPayloadSchema(path); err != nil {
+		t.Fatalf("LoadPayloadSchema() error = %v", err)
+	}
+
+	res, err := GenerateRandomJSON()
+	if err != nil {
+		t.Fatalf("GenerateRandomJSON() error = %v", err)
+	}
+	if !strings.HasPrefix(string(res), `{"orderId":`) || !strings.Contains(string(res), `"version":2}`) {
+		t.Errorf("fields out of schema order: %s", res)
+	}
+	var v struct {
+		OrderID  string
+		Customer struct{ Name, Email string }
+		Quantity int
+		Price    float64
+		Paid     bool
+		Status   string
+		Tags     []string
+		Version  int
+	}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", res, err)
+	}
+	if len(v.OrderID) != 36 || v.Customer.Name == "" || !strings.Contains(v.Customer.Email, "@") {
+		t.Errorf("unexpected values %s", res)
+	}
+	if v.Quantity < 1 || v.Quantity > 10 || len(v.Tags) < 1 || len(v.Tags) > maxSchemaList {
+		t.Errorf("unexpected values %s", res)
+	}
+	if v.Status != "new" && v.Status != "paid" && v.Status != "shipped" {
+		t.Errorf("unexpected status %q", v.Status)
+	}
+
+	res, err = GenerateRandomCBOR()
+	if err != nil {
+		t.Fatalf("GenerateRandomCBOR() error = %v", err)
+	}
+	var m map[string]any
+	if err := cbor.Unmarshal(res, &m); err != nil {
+		t.Fatalf("invalid CBOR: %v", err)
+	}
+	if _, ok := m["quantity"].(uint64); !ok {
+		t.Errorf("quantity = %T, want an integer", m["quantity"])
+	}
+	if _, ok := m["customer"].(map[any]any); !ok {
+		t.Errorf("customer = %T, want a map", m["customer"])
+	}
+
+	ClearPayloadSchema()
+	res, err = GenerateRandomJSON()
+	if err != nil || !strings.Contains(string(res), `"active"`) {
+		t.Errorf("GenerateRandomJSON() without schema = %s, %v", res, err)
+	}
+}
+
+func TestLoadPayloadSchema_Errors(t *testing.T) {
+	defer ClearPayloadSchema()
+	for _, content := range []string{
+		"- uuid",
+		"id: nosuchgen",
+		"data: json",
+		"tags: [word, name]",
+		"id: [",
+	} {
+		if err := LoadPayloadSchema(writeSchema(t, content)); err == nil {
+			t.Errorf("expected an error for %q", content)
+		}
+	}
+	if err := LoadPayloadSchema(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
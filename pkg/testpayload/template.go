@@ -0,0 +1,135 @@
+package testpayload
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template engines that render message payloads, see SetTemplateEngine.
+const (
+	// EngineBuiltin is the placeholder interpolator of InterpolateWithDelimiters.
+	EngineBuiltin = "builtin"
+	// EngineGo renders payloads with text/template and the functions of templateFuncs.
+	EngineGo = "go"
+)
+
+// TemplateEngine is the engine InterpolateMessage renders payloads with.
+var TemplateEngine = EngineBuiltin
+
+// SetTemplateEngine selects the engine payloads are rendered with: builtin (or empty) or go.
+// Headers and destinations always use the builtin placeholders.
+func SetTemplateEngine(name string) error {
+	switch name {
+	case "", EngineBuiltin:
+		TemplateEngine = EngineBuiltin
+	case EngineGo:
+		TemplateEngine = EngineGo
+	default:
+		return fmt.Errorf("unknown template engine %q: expected %s or %s", name, EngineBuiltin, EngineGo)
+	}
+	return nil
+}
+
+// renderPayload renders a payload template with the selected engine.
+func renderPayload(str string, openDelim string, closeDelim string) ([]byte, error) {
+	if TemplateEngine == EngineGo {
+		return RenderGoTemplate(str, openDelim, closeDelim)
+	}
+	return InterpolateWithDelimiters(str, openDelim, closeDelim)
+}
+
+// templateFuncs are the functions of the go engine, drawing from g: every simple placeholder
+// (json, name, uuid, counter, ...) plus functions for the argument-bearing ones, the string
+// helpers and the environment.
+func (g *Generator) templateFuncs() template.FuncMap {
+	funcs := template.FuncMap{}
+	for key, typ := range placeholders {
+		funcs[key] = func() (string, error) {
+			val, err := g.Generate(typ)
+			return string(val), err
+		}
+	}
+	maps.Copy(funcs, template.FuncMap{
+		"now":  time.Now,
+		"env":  func(name string) (string, error) { return fromGenerator(generateEnv(name)) },
+		"cmd":  func(command string) (string, error) { return fromGenerator(generateCmd(command)) },
+		"list": func(path string) (string, error) { return fromGenerator(g.generateList(path)) },
+		"var":  g.Var,
+		"col":  func(name string) (string, error) { return fromGenerator(generateCol(name)) },
+		"counter": func(args ...any) (string, error) {
+			if len(args) == 0 {
+				return strconv.Itoa(g.Counter()), nil
+			}
+			expr := make([]string, len(args))
+			for i, arg := range args {
+				expr[i] = fmt.Sprint(arg)
+			}
+			return fromGenerator(g.generateCounter(strings.Join(expr, ":")))
+		},
+		"randint": func(lo, hi int64) (string, error) {
+			return fromGenerator(g.generateRandInt(strconv.FormatInt(lo, 10) + ":" + strconv.FormatInt(hi, 10)))
+		},
+		"randfloat": func(lo, hi float64, decimals int) (string, error) {
+			return fromGenerator(g.generateRandFloat(fmt.Sprintf("%g:%g:%d", lo, hi, decimals)))
+		},
+		"oneof": func(options ...string) (string, error) {
+			return fromGenerator(g.generateOneOf(strings.Join(options, "|")))
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		"trunc": func(n int, s string) (string, error) {
+			return fromGenerator(truncateValue(strconv.Itoa(n), []byte(s)))
+		},
+		"size":  func(n int) (string, error) { return fromGenerator(g.generateSize(strconv.Itoa(n))) },
+		"bytes": func(n int) (string, error) { return fromGenerator(g.generateBytes(strconv.Itoa(n))) },
+	})
+	return funcs
+}
+
+// fromGenerator adapts the result of a placeholder generator to a template function result.
+func fromGenerator(val []byte, err error) (string, error) {
+	return string(val), err
+}
+
+// maxGoTemplates bounds the parsed template cache, e.g. for templated payload source lines.
+const maxGoTemplates = 256
+
+// RenderGoTemplate renders str with text/template, using openDelim and closeDelim as action
+// delimiters, so loops and conditionals can shape the payload, e.g.
+// {{range 3}}{{name}},{{end}} or {{if eq (oneof "a" "b") "a"}}...{{end}}.
+func RenderGoTemplate(str string, openDelim string, closeDelim string) ([]byte, error) {
+	return defaultGenerator.RenderGoTemplate(str, openDelim, closeDelim)
+}
+
+// RenderGoTemplate is the package-level RenderGoTemplate with template functions drawing
+// from g. Parsed templates are cached per generator, payloads are usually rendered from the
+// same few templates.
+func (g *Generator) RenderGoTemplate(str string, openDelim string, closeDelim string) ([]byte, error) {
+	key := openDelim + "\x00" + closeDelim + "\x00" + str
+	g.templatesMu.Lock()
+	tmpl, ok := g.templates[key]
+	if !ok {
+		var err error
+		tmpl, err = template.New("payload").Delims(openDelim, closeDelim).Funcs(g.funcs()).Parse(str)
+		if err != nil {
+			g.templatesMu.Unlock()
+			return nil, fmt.Errorf("invalid go template: %w", err)
+		}
+		if len(g.templates) >= maxGoTemplates {
+			clear(g.templates)
+		}
+		g.templates[key] = tmpl
+	}
+	g.templatesMu.Unlock()
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, fmt.Errorf("failed to render go template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
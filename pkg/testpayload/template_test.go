@@ -0,0 +1,90 @@
+package testpayload
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSetTemplateEngine(t *testing.T) {
+	defer func() { _ = SetTemplateEngine(EngineBuiltin) }()
+	for _, name := range []string{"", EngineBuiltin, EngineGo} {
+		if err := SetTemplateEngine(name); err != nil {
+			t.Errorf("SetTemplateEngine(%q) error = %v", name, err)
+		}
+	}
+	if err := SetTemplateEngine("jinja"); err == nil {
+		t.Error("expected an error for an unknown engine")
+	}
+}
+
+func TestRenderGoTemplate(t *testing.T) {
+	t.Setenv("EVENTKIT_REGION", "eu-west-1")
+	SetAllowEnvReads(true)
+	defer SetAllowEnvReads(false)
+	AddTemplateVar("tenant", "acme")
+	defer ClearTemplateVars()
+
+	tmpl := `{"region":"{{env "EVENTKIT_REGION"}}","tenant":"{{var "tenant"}}","id":"{{uuid}}",` +
+		`"items":[{{range $i := 3}}{{if $i}},{{end}}{"n":{{randint 1 9}},"who":"{{firstname}}"}{{end}}],` +
+		`"year":{{now.Year}},"level":"{{oneof "ok*8" "warn"}}"}`
+	res, err := RenderGoTemplate(tmpl, "{{", "}}")
+	if err != nil {
+		t.Fatalf("RenderGoTemplate() error = %v", err)
+	}
+	var v struct {
+		Region, Tenant, ID, Level string
+		Items                     []struct {
+			N   int
+			Who string
+		}
+		Year int
+	}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", res, err)
+	}
+	if v.Region != "eu-west-1" || v.Tenant != "acme" || len(v.ID) != 36 || len(v.Items) != 3 || v.Year < 2024 {
+		t.Errorf("unexpected render %s", res)
+	}
+	if v.Level != "ok" && v.Level != "warn" {
+		t.Errorf("unexpected oneof value %q", v.Level)
+	}
+
+	res, err = RenderGoTemplate("<% counter %>-<% counter %>", "<%", "%>")
+	if err != nil || !strings.Contains(string(res), "-") {
+		t.Errorf("custom delimiters = %q, %v", res, err)
+	}
+
+	res, err = RenderGoTemplate(`{{upper (trunc 3 "device")}}-{{lower "AB"}}`, "{{", "}}")
+	if err != nil || string(res) != "DEV-ab" {
+		t.Errorf("string helpers = %q, %v", res, err)
+	}
+
+	res, err = RenderGoTemplate(`{{counter "tpl" 100 5}},{{counter "tpl"}}`, "{{", "}}")
+	if err != nil || string(res) != "100,105" {
+		t.Errorf("named counter = %q, %v", res, err)
+	}
+	ResetCounter("tpl")
+
+	for _, bad := range []string{"{{if}}", "{{nosuchfunc}}", "{{randint 9 1}}"} {
+		if _, err := RenderGoTemplate(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestInterpolateMessage_GoEngine(t *testing.T) {
+	if err := SetTemplateEngine(EngineGo); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = SetTemplateEngine(EngineBuiltin) }()
+
+	res, err := InterpolateMessage(`{{range 2}}x{{end}}`, "{{", "}}")
+	if err != nil || string(res) != "xx" {
+		t.Errorf("InterpolateMessage() = %q, %v", res, err)
+	}
+	// Headers and destinations keep the builtin placeholders
+	if res, err := InterpolateWithDelimiters("{{randint:5:5}}", "{{", "}}"); err != nil || string(res) != "5" {
+		t.Errorf("InterpolateWithDelimiters() = %q, %v", res, err)
+	}
+}
@@ -1,17 +1,31 @@
 package testpayload
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/go-faker/faker/v4"
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
 )
 
 // Payload represents the predictable payload structure
@@ -26,9 +40,11 @@ type Payload struct {
 }
 
 // generates an instance of Payload with realistic random values
-func generatePredictablePayload() Payload {
+func (g *Generator) predictablePayload() Payload {
 	var p Payload
-	if err := faker.FakeData(&p); err != nil {
+	var err error
+	g.fake(func() { err = faker.FakeData(&p) })
+	if err != nil {
 		// If faker fails, return a minimal valid payload
 		p = Payload{
 			ID:     "00000000-0000-0000-0000-000000000000",
@@ -41,32 +57,86 @@ func generatePredictablePayload() Payload {
 	return p
 }
 
-// GenerateRandomJSON creates a JSON with predictable structure and random values
+// GenerateRandomJSON creates a JSON with predictable structure and random values, shaped
+// by the payload schema when one is loaded (see LoadPayloadSchema)
 func GenerateRandomJSON() ([]byte, error) {
-	return json.Marshal(generatePredictablePayload())
+	return defaultGenerator.randomJSON()
 }
 
-// GenerateRandomCBOR creates a CBOR with predictable structure and random values
+func (g *Generator) randomJSON() ([]byte, error) {
+	if val, ok, err := g.schemaPayload(); ok {
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(val)
+	}
+	return json.Marshal(g.predictablePayload())
+}
+
+// GenerateRandomCBOR creates a CBOR with predictable structure and random values, shaped
+// by the payload schema when one is loaded (see LoadPayloadSchema)
 func GenerateRandomCBOR() ([]byte, error) {
-	return cbor.Marshal(generatePredictablePayload())
+	return defaultGenerator.randomCBOR()
+}
+
+func (g *Generator) randomCBOR() ([]byte, error) {
+	if val, ok, err := g.schemaPayload(); ok {
+		if err != nil {
+			return nil, err
+		}
+		return cbor.Marshal(cborValue(val))
+	}
+	return cbor.Marshal(g.predictablePayload())
 }
 
 // GenerateSentence generates a random sentence for tests
 func GenerateSentence() string {
-	return faker.Sentence()
+	return defaultGenerator.sentence()
+}
+
+func (g *Generator) sentence() string {
+	return g.fakeString(func() string { return faker.Sentence() })
+}
+
+// GenerateCompany generates a company name, such as "Hansen Group", from a faker last name.
+func GenerateCompany() string {
+	return defaultGenerator.company()
+}
+
+func (g *Generator) company() string {
+	suffixes := []string{"Inc", "LLC", "Ltd", "Group", "Corp", "& Sons", "Partners", "Holdings"}
+	return g.fakeString(func() string { return faker.LastName() }) + " " + suffixes[g.Intn(len(suffixes))]
+}
+
+// GeneratePort generates a TCP/UDP port number in [1, 65535].
+func GeneratePort() int {
+	return defaultGenerator.port()
+}
+
+func (g *Generator) port() int {
+	return 1 + g.Intn(65535)
 }
 
 func GenerateSentimentPhrase() string {
+	return defaultGenerator.sentimentPhrase()
+}
+
+func (g *Generator) sentimentPhrase() string {
 	starts := []string{"I love", "I hate", "I think", "I feel", "I wish", "I see"}
 	adjectives := []string{"great", "terrible", "amazing", "awful", "funny", "boring"}
 	objects := []string{"this product", "the service", "the movie", "the food", "the weather", "the app"}
-	return starts[rand.Intn(len(starts))] + " " + adjectives[rand.Intn(len(adjectives))] + " " + objects[rand.Intn(len(objects))] // #nosec G404 -- test data generator
+	return starts[g.Intn(len(starts))] + " " + adjectives[g.Intn(len(adjectives))] + " " + objects[g.Intn(len(objects))]
 }
 
 func GenerateRandomDateTime() string {
+	return defaultGenerator.randomDateTime().Format(time.RFC3339Nano)
+}
+
+// randomDateTime returns a random time of the last 10 years.
+func (g *Generator) randomDateTime() time.Time {
 	// Generate a random Unix timestamp between 1 and 10 years ago
-	timestamp := rand.Int63n(10*365*24*3600) + (time.Now().Unix() - 10*365*24*3600) // #nosec G404 -- test data generator
-	return time.Unix(timestamp, 0).Format(time.RFC3339Nano)
+	timestamp := g.Int63n(10*365*24*3600) + (time.Now().Unix() - 10*365*24*3600)
+	return time.Unix(timestamp, 0)
 }
 
 func GenerateNowDateTime() string {
@@ -74,217 +144,563 @@ func GenerateNowDateTime() string {
 	return time.Now().Format(time.RFC3339Nano)
 }
 
-var counter int = 0
-var counterMutex = sync.Mutex{}
+// timeFormats are the named formats of nowtime: and datetime: placeholders, other formats are
+// Go time layouts.
+var timeFormats = map[string]func(time.Time) string{
+	"unix":        func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	"unixmilli":   func(t time.Time) string { return strconv.FormatInt(t.UnixMilli(), 10) },
+	"unixmicro":   func(t time.Time) string { return strconv.FormatInt(t.UnixMicro(), 10) },
+	"unixnano":    func(t time.Time) string { return strconv.FormatInt(t.UnixNano(), 10) },
+	"rfc3339":     func(t time.Time) string { return t.Format(time.RFC3339) },
+	"rfc3339nano": func(t time.Time) string { return t.Format(time.RFC3339Nano) },
+	"rfc1123":     func(t time.Time) string { return t.Format(time.RFC1123) },
+	"rfc1123z":    func(t time.Time) string { return t.Format(time.RFC1123Z) },
+	"date":        func(t time.Time) string { return t.Format(time.DateOnly) },
+	"time":        func(t time.Time) string { return t.Format(time.TimeOnly) },
+}
+
+// formatTime evaluates the arguments of a nowtime: or datetime: placeholder, [OFFSET][:FORMAT],
+// on t. OFFSET is a signed duration such as +5m or -36h added to t; FORMAT is one of
+// timeFormats or a Go time layout such as 2006-01-02 (which may contain colons), RFC3339Nano by
+// default. A leading utc: converts t to UTC first.
+func formatTime(keyword string, t time.Time, expr string) ([]byte, error) {
+	ph := keyword + ":" + expr
+	if rest, ok := strings.CutPrefix(expr, "utc"); ok && (rest == "" || rest[0] == ':') {
+		t, expr = t.UTC(), strings.TrimPrefix(rest, ":")
+	}
+	if expr != "" && (expr[0] == '+' || expr[0] == '-') {
+		offset, format, _ := strings.Cut(expr, ":")
+		if d, err := time.ParseDuration(offset); err == nil {
+			t, expr = t.Add(d), format
+		}
+	}
+	if expr == "" {
+		return []byte(t.Format(time.RFC3339Nano)), nil
+	}
+	if format, ok := timeFormats[strings.ToLower(expr)]; ok {
+		return []byte(format(t)), nil
+	}
+	res := t.Format(expr)
+	if res == expr {
+		return nil, fmt.Errorf("invalid %s placeholder %q: unknown format %q", keyword, ph, expr)
+	}
+	return []byte(res), nil
+}
+
+// generateNowTime evaluates a nowtime expression (the placeholder without the "nowtime:"
+// prefix), returning the current time formatted as described by formatTime.
+func generateNowTime(_ *Generator, expr string) ([]byte, error) {
+	return formatTime("nowtime", time.Now(), expr)
+}
+
+// generateDateTime evaluates a datetime expression (the placeholder without the "datetime:"
+// prefix), returning a random time of the last 10 years formatted as described by formatTime.
+func (g *Generator) generateDateTime(expr string) ([]byte, error) {
+	return formatTime("datetime", g.randomDateTime(), expr)
+}
+
+// GenerateUUID generates a random (version 4) UUID from the seedable generator, so seeded
+// runs produce the same IDs.
+func GenerateUUID() string {
+	return defaultGenerator.uuid()
+}
+
+func (g *Generator) uuid() string {
+	id, err := uuid.NewRandomFromReader(g)
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// GenerateUUIDv7 generates a time-ordered (version 7) UUID; its random bits come from the
+// seedable generator.
+func GenerateUUIDv7() string {
+	return defaultGenerator.uuidv7()
+}
+
+func (g *Generator) uuidv7() string {
+	id, err := uuid.NewV7FromReader(g)
+	if err != nil {
+		return uuid.Must(uuid.NewV7()).String()
+	}
+	return id.String()
+}
 
 func GenerateCounter() int {
-	counterMutex.Lock()
-	defer counterMutex.Unlock()
-	counter++
-	return counter
+	return defaultGenerator.Counter()
+}
+
+// Counter returns the next value of the {{counter}} placeholder of g, starting at 1.
+func (g *Generator) Counter() int {
+	g.counterMu.Lock()
+	defer g.counterMu.Unlock()
+	g.counter++
+	return g.counter
+}
+
+// namedCounter is the state of a {{counter:NAME:START:STEP}} counter.
+type namedCounter struct {
+	next int64
+	step int64
+}
+
+// GenerateNamedCounter returns the next value of the counter name, independent of
+// GenerateCounter and of the other named counters. The first call creates the counter,
+// returning start; later calls add step to the previous value, ignoring start and step.
+func GenerateNamedCounter(name string, start int64, step int64) int64 {
+	return defaultGenerator.NamedCounter(name, start, step)
+}
+
+// NamedCounter is GenerateNamedCounter for the counters of g.
+func (g *Generator) NamedCounter(name string, start int64, step int64) int64 {
+	g.counterMu.Lock()
+	defer g.counterMu.Unlock()
+	c, ok := g.counters[name]
+	if !ok {
+		c = &namedCounter{next: start, step: step}
+		g.counters[name] = c
+	}
+	val := c.next
+	c.next += c.step
+	return val
+}
+
+// ResetCounter restarts the counter name, which starts again from the START of its next use.
+func ResetCounter(name string) {
+	defaultGenerator.ResetCounter(name)
+}
+
+// ResetCounter is the package-level ResetCounter for the counters of g.
+func (g *Generator) ResetCounter(name string) {
+	g.counterMu.Lock()
+	defer g.counterMu.Unlock()
+	delete(g.counters, name)
+}
+
+// ResetCounters restarts {{counter}} from 1 and every named counter.
+func ResetCounters() {
+	defaultGenerator.ResetCounters()
+}
+
+// ResetCounters is the package-level ResetCounters for the counters of g.
+func (g *Generator) ResetCounters() {
+	g.counterMu.Lock()
+	defer g.counterMu.Unlock()
+	g.counter = 0
+	clear(g.counters)
 }
 
 func Interpolate(str string) ([]byte, error) {
-	return InterpolateWithDelimiters(str, "{{", "}}")
+	return defaultGenerator.Interpolate(str)
+}
+
+// Interpolate is the package-level Interpolate drawing from g.
+func (g *Generator) Interpolate(str string) ([]byte, error) {
+	return g.InterpolateWithDelimiters(str, "{{", "}}")
+}
+
+// placeholders maps the simple placeholder keywords to their generators.
+var placeholders = map[string]TestPayloadType{
+	"json":      TestPayloadJSON,
+	"cbor":      TestPayloadCBOR,
+	"sentiment": TestPayloadSentiment,
+	"sentence":  TestPayloadSentence,
+	"datetime":  TestPayloadDateTime,
+	"nowtime":   TestPayloadNowTime,
+	"counter":   TestPayloadCounter,
+	"uuid":      TestPayloadUUID,
+	"uuidv7":    TestPayloadUUIDv7,
+	"name":      TestPayloadName,
+	"firstname": TestPayloadFirstName,
+	"lastname":  TestPayloadLastName,
+	"email":     TestPayloadEmail,
+	"phone":     TestPayloadPhone,
+	"username":  TestPayloadUsername,
+	"url":       TestPayloadURL,
+	"domain":    TestPayloadDomain,
+	"company":   TestPayloadCompany,
+	"word":      TestPayloadWord,
+	"ipv4":      TestPayloadIPv4,
+	"ipv6":      TestPayloadIPv6,
+	"mac":       TestPayloadMAC,
+	"port":      TestPayloadPort,
+	"lat":       TestPayloadLat,
+	"lon":       TestPayloadLon,
+}
+
+// argGenerator generates the value of an argument-bearing placeholder from its ARGS.
+type argGenerator func(g *Generator, args string) ([]byte, error)
+
+// argPlaceholders maps the keywords of argument-bearing placeholders, written
+// {{keyword:ARGS}}, to their generators, which receive ARGS. Unlike the simple placeholders,
+// each occurrence is generated separately.
+var argPlaceholders = map[string]argGenerator{
+	"stream":    (*Generator).generateStream,
+	"counter":   (*Generator).generateCounter,
+	"randint":   (*Generator).generateRandInt,
+	"randfloat": (*Generator).generateRandFloat,
+	"size":      (*Generator).generateSize,
+	"bytes":     (*Generator).generateBytes,
+	"oneof":     (*Generator).generateOneOf,
+	"col":       withoutGenerator(generateCol),
+	"env":       withoutGenerator(generateEnv),
+	"cmd":       withoutGenerator(generateCmd),
+	"nowtime":   generateNowTime,
+	"datetime":  (*Generator).generateDateTime,
+	"list":      (*Generator).generateList,
+	"proto":     (*Generator).generateProto,
+	"lat":       (*Generator).generateLat,
+	"lon":       (*Generator).generateLon,
+	"geojson":   (*Generator).generateGeoJSON,
+}
+
+// withoutGenerator adapts a placeholder generator that draws no random values.
+func withoutGenerator(f func(args string) ([]byte, error)) argGenerator {
+	return func(_ *Generator, args string) ([]byte, error) { return f(args) }
+}
+
+// argPlaceholder returns the generator and arguments of an argument-bearing placeholder.
+func argPlaceholder(inner string) (argGenerator, string, bool) {
+	keyword, args, ok := strings.Cut(inner, ":")
+	if !ok {
+		return nil, "", false
+	}
+	gen, ok := argPlaceholders[keyword]
+	return gen, args, ok
 }
 
 // InterpolateWithDelimiters performs template variable interpolation with custom delimiters
-// Supports placeholders: json, cbor, sentiment, sentence, datetime, nowtime, counter, file:/path
+// Supports placeholders: json, cbor, sentiment, sentence, datetime[:ARGS], nowtime[:ARGS] (with an
+// [utc:][OFFSET][:FORMAT] such as nowtime:+5m:unix or nowtime:2006-01-02), counter, uuid, uuidv7,
+// name, firstname, lastname, email, phone, username, url, domain, company, word, ipv4, ipv6, mac, port, lat[:MIN:MAX], lon[:MIN:MAX],
+// file:/path (or file:GLOB[:seq|random]), list:/path[:seq|random], stream:NAME:intrange:MIN:MAX, counter:NAME[:START[:STEP]], randint:MIN:MAX, randfloat:MIN:MAX[:DECIMALS], size:N, bytes:N,
+// oneof:A|B|C, col:NAME, proto:MESSAGE, env:NAME, cmd:COMMAND, geojson:point|feature[:MINLON,MINLAT,MAXLON,MAXLAT]
 func InterpolateWithDelimiters(str string, openDelim string, closeDelim string) ([]byte, error) {
-	placeholders := map[string]TestPayloadType{
-		"json":      TestPayloadJSON,
-		"cbor":      TestPayloadCBOR,
-		"sentiment": TestPayloadSentiment,
-		"sentence":  TestPayloadSentence,
-		"datetime":  TestPayloadDateTime,
-		"nowtime":   TestPayloadNowTime,
-		"counter":   TestPayloadCounter,
-	}
-
-	result := str
-	// Handle `var:` placeholders first (variable substitution)
-	varPrefix := openDelim + "var:"
-	if strings.Contains(result, varPrefix) {
-		for key := range templateVars {
-			ph := openDelim + "var:" + key + closeDelim
-			if strings.Contains(result, ph) {
-				result = strings.ReplaceAll(result, ph, templateVars[key])
-			}
+	return defaultGenerator.InterpolateWithDelimiters(str, openDelim, closeDelim)
+}
+
+// InterpolateWithDelimiters is the package-level InterpolateWithDelimiters drawing its random
+// values, counters and template variables from g.
+func (g *Generator) InterpolateWithDelimiters(str string, openDelim string, closeDelim string) ([]byte, error) {
+	if StrictTemplates {
+		if err := validatePlaceholders(str, openDelim, closeDelim); err != nil {
+			return nil, err
 		}
-		// Replace any var: placeholders not found in map with empty string
-		for {
-			startIdx := strings.Index(result, varPrefix)
-			if startIdx == -1 {
-				break
-			}
-			endIdx := strings.Index(result[startIdx:], closeDelim)
-			if endIdx == -1 {
-				break
-			}
-			endIdx += startIdx
-			placeholder := result[startIdx : endIdx+len(closeDelim)]
-			result = strings.Replace(result, placeholder, "", 1)
+	}
+
+	// Placeholders are parsed once from str and resolved in phases, so the text of inserted
+	// values (CSV cells, --var values, files) is never scanned for placeholders again.
+	tokens, err := parseTemplate(str, openDelim, closeDelim)
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle `var:` placeholders first (variable substitution), unset ones become empty
+	for i := range tokens {
+		t := &tokens[i]
+		if key, ok := strings.CutPrefix(t.inner, "var:"); ok && t.placeholder && !t.done {
+			t.text, t.done = g.Var(key), true
 		}
 	}
-	// Process `raw:` and `str:` wrappers, these wrap inner placeholders or file: expressions
-	wrappers := []string{"raw:", "str:"}
+	// Process the `raw:`, `str:`, `base64:`, `hex:`, `gzip:` and `zstd:` wrappers and the string and math helpers, these wrap inner placeholders or file: expressions
 	for _, w := range wrappers {
-		prefix := openDelim + w
-		if strings.Contains(result, prefix) {
-			for {
-				startIdx := strings.Index(result, prefix)
-				if startIdx == -1 {
-					break
-				}
-				endIdx := strings.Index(result[startIdx:], closeDelim)
-				if endIdx == -1 {
-					return nil, fmt.Errorf("unclosed placeholder at position %d", startIdx)
-				}
-				endIdx += startIdx
-				inner := result[startIdx+len(prefix) : endIdx]
-				var val []byte
-				var err error
-				if strings.HasPrefix(inner, "file:") {
-					// file read
-					fp := inner[len("file:"):]
-					if fp == "" {
-						return nil, fmt.Errorf("empty file path in placeholder at position %d", startIdx)
-					}
-					if !AllowFileReads {
-						return nil, fmt.Errorf("file reads are disabled: to enable allow file reads set testpayload.SetAllowFileReads(true)")
-					}
-					if FileRoot != "" {
-						absRoot, err := filepath.Abs(FileRoot)
-						if err != nil {
-							return nil, fmt.Errorf("invalid file root: %w", err)
-						}
-						absPath, err2 := filepath.Abs(fp)
-						if err2 != nil {
-							return nil, fmt.Errorf("invalid file path: %s", fp)
-						}
-						if !strings.HasPrefix(absPath, absRoot) {
-							return nil, fmt.Errorf("file %s outside allowed root %s", fp, FileRoot)
-						}
-					}
-					// Check cache
-					if c, ok := GetFileFromCache(fp); ok {
-						val = c
-					} else {
-						// #nosec G304 - File path is validated and restricted by FileRoot
-						val, err = os.ReadFile(fp)
-						if err == nil {
-							PutFileIntoCache(fp, val)
-						}
-					}
-					if err != nil {
-						return nil, fmt.Errorf("failed to read file %s: %w", fp, err)
-					}
-				} else if strings.HasPrefix(inner, "var:") {
-					key := inner[len("var:"):]
-					val = []byte(templateVars[key])
-				} else if t, ok := placeholders[inner]; ok {
-					val, err = t.Generate()
-					if err != nil {
-						return nil, err
-					}
-				} else {
-					// Unknown inner expression, treat as raw text
-					val = []byte(inner)
-				}
-				// For str: wrapper, JSON-escape the value (including quotes)
-				if w == "str:" {
-					esc, err := json.Marshal(string(val))
-					if err != nil {
-						return nil, fmt.Errorf("failed to escape value: %w", err)
-					}
-					val = esc
+		for i := range tokens {
+			t := &tokens[i]
+			if !t.placeholder || t.done || !strings.HasPrefix(t.inner, w) {
+				continue
+			}
+			// Wrappers chain, e.g. {{base64:gzip:json}}, and apply from the innermost one
+			chain, inner := peelWrappers(nil, t.inner)
+			val, err := g.wrappedValue(inner, t.pos)
+			if err != nil {
+				return nil, err
+			}
+			for i := len(chain) - 1; i >= 0; i-- {
+				if val, err = wrapValue(chain[i], val); err != nil {
+					return nil, err
 				}
-				placeholder := result[startIdx : endIdx+len(closeDelim)]
-				result = strings.Replace(result, placeholder, string(val), 1)
 			}
+			t.text, t.done = string(val), true
 		}
 	}
 
-	for key, typ := range placeholders {
-		ph := openDelim + key + closeDelim
+	// Iterate in a stable order so seeded runs consume random values deterministically.
+	for _, key := range slices.Sorted(maps.Keys(placeholders)) {
+		typ := placeholders[key]
 
-		if str == ph {
+		if str == openDelim+key+closeDelim {
 			// If the entire string is just the placeholder, return the generated value directly
-			return typ.Generate()
+			return g.Generate(typ)
 		}
 
-		if strings.Contains(result, ph) {
-			val, err := typ.Generate()
-			if err != nil {
-				return nil, err
+		// Every occurrence of a simple placeholder shares one generated value
+		var val []byte
+		generated := false
+		for i := range tokens {
+			t := &tokens[i]
+			if !t.placeholder || t.done || t.inner != key {
+				continue
+			}
+			if !generated {
+				if val, err = g.Generate(typ); err != nil {
+					return nil, err
+				}
+				generated = true
 			}
-			result = strings.ReplaceAll(result, ph, string(val))
+			t.text, t.done = string(val), true
+		}
+	}
+
+	// Handle argument-bearing placeholders, each occurrence is generated on its own
+	for i := range tokens {
+		t := &tokens[i]
+		if !t.placeholder || t.done {
+			continue
+		}
+		gen, args, ok := argPlaceholder(t.inner)
+		if !ok {
+			continue
 		}
+		val, err := gen(g, args)
+		if err != nil {
+			return nil, err
+		}
+		t.text, t.done = string(val), true
 	}
 
 	// Handle file:// placeholder (non-wrapped form)
-	filePrefix := openDelim + "file:"
-	fileSuffix := closeDelim
-	if strings.Contains(result, filePrefix) {
-		for {
-			startIdx := strings.Index(result, filePrefix)
-			if startIdx == -1 {
-				break
-			}
-			endIdx := strings.Index(result[startIdx:], fileSuffix)
-			if endIdx == -1 {
-				return nil, fmt.Errorf("unclosed file placeholder at position %d", startIdx)
-			}
-			endIdx += startIdx
+	for i := range tokens {
+		t := &tokens[i]
+		filePath, ok := strings.CutPrefix(t.inner, "file:")
+		if !ok || !t.placeholder || t.done {
+			continue
+		}
+		if filePath == "" {
+			return nil, fmt.Errorf("empty file path in placeholder at position %d", t.pos)
+		}
+		content, err := g.fileValue(filePath)
+		if err != nil {
+			return nil, err
+		}
+		t.text, t.done = string(content), true
+	}
 
-			// Extract file path
-			filePath := result[startIdx+len(filePrefix) : endIdx]
-			if filePath == "" {
-				return nil, fmt.Errorf("empty file path in placeholder at position %d", startIdx)
-			}
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.text)
+	}
+	return []byte(b.String()), nil
+}
 
-			// Read file content
-			// File reads may be disabled by default for security in CI.
-			if !AllowFileReads {
-				return nil, fmt.Errorf("file reads are disabled: to enable allow file reads set testpayload.SetAllowFileReads(true)")
-			}
-			if FileRoot != "" {
-				absRoot, err := filepath.Abs(FileRoot)
-				if err != nil {
-					return nil, fmt.Errorf("invalid file root: %w", err)
-				}
-				absPath, err2 := filepath.Abs(filePath)
-				if err2 != nil {
-					return nil, fmt.Errorf("invalid file path: %s", filePath)
-				}
-				if !strings.HasPrefix(absPath, absRoot) {
-					return nil, fmt.Errorf("file %s outside allowed root %s", filePath, FileRoot)
-				}
-			}
-			// #nosec G304 -- reading file for test payload generation
-			// Fetch from cache or read and put into cache
-			var content []byte
-			var err error
-			if c, ok := GetFileFromCache(filePath); ok {
-				content = c
-			} else {
-				// #nosec G304 - File path is validated and restricted by FileRoot
-				content, err = os.ReadFile(filePath)
-				if err == nil {
-					PutFileIntoCache(filePath, content)
+// templateToken is a piece of a parsed template: literal text, or a placeholder whose text
+// is its resolved value once done.
+type templateToken struct {
+	text        string
+	inner       string // placeholder text between the delimiters
+	pos         int    // offset of the placeholder in the template
+	placeholder bool
+	done        bool
+}
+
+// parseTemplate splits str into literal text and placeholders. Delimited text that is not a
+// known placeholder is kept as literal text.
+func parseTemplate(str, openDelim, closeDelim string) ([]templateToken, error) {
+	var tokens []templateToken
+	lit := 0
+	for pos := 0; ; {
+		startIdx := strings.Index(str[pos:], openDelim)
+		if startIdx == -1 {
+			break
+		}
+		startIdx += pos
+		innerStart := startIdx + len(openDelim)
+		endIdx := strings.Index(str[innerStart:], closeDelim)
+		if endIdx == -1 {
+			for _, w := range append([]string{"file:"}, wrappers...) {
+				if idx := strings.Index(str[startIdx:], openDelim+w); idx != -1 {
+					if w == "file:" {
+						return nil, fmt.Errorf("unclosed file placeholder at position %d", startIdx+idx)
+					}
+					return nil, fmt.Errorf("unclosed placeholder at position %d", startIdx+idx)
 				}
 			}
-			if err != nil {
-				return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
-			}
+			break
+		}
+		endIdx += innerStart
+		inner := str[innerStart:endIdx]
+		if !isTemplatePlaceholder(inner) {
+			pos = innerStart
+			continue
+		}
+		if lit < startIdx {
+			tokens = append(tokens, templateToken{text: str[lit:startIdx]})
+		}
+		tokens = append(tokens, templateToken{inner: inner, pos: startIdx, placeholder: true})
+		pos = endIdx + len(closeDelim)
+		lit = pos
+	}
+	if lit < len(str) {
+		tokens = append(tokens, templateToken{text: str[lit:]})
+	}
+	return tokens, nil
+}
 
-			// Replace placeholder with file content
-			placeholder := result[startIdx : endIdx+len(fileSuffix)]
-			result = strings.Replace(result, placeholder, string(content), 1)
+// isTemplatePlaceholder reports whether InterpolateWithDelimiters resolves the placeholder inner.
+func isTemplatePlaceholder(inner string) bool {
+	if chain, _ := peelWrappers(nil, inner); len(chain) > 0 {
+		return true
+	}
+	if strings.HasPrefix(inner, "var:") || strings.HasPrefix(inner, "file:") {
+		return true
+	}
+	if _, ok := placeholders[inner]; ok {
+		return true
+	}
+	_, _, ok := argPlaceholder(inner)
+	return ok
+}
+
+// wrappers are the prefixes of placeholders that encode or reshape (see helpers) the value of
+// an inner placeholder, file: or var: expression, or literal text.
+var wrappers = []string{"raw:", "str:", "base64:", "hex:", "gzip:", "zstd:", "upper:", "lower:", "trim:", "trunc:", "add:", "mul:", "round:"}
+
+// zstdEncoder is shared by the zstd: wrappers, EncodeAll is safe for concurrent use.
+var zstdEncoder = sync.OnceValues(func() (*zstd.Encoder, error) {
+	return zstd.NewWriter(nil)
+})
+
+// wrapValue encodes the inner value of a wrapper: str: JSON-escapes it (including quotes),
+// base64: and hex: encode it, gzip: and zstd: compress it, raw: keeps it as is and helpers
+// reshape it.
+func wrapValue(w string, val []byte) ([]byte, error) {
+	switch w {
+	case "str:":
+		esc, err := json.Marshal(string(val))
+		if err != nil {
+			return nil, fmt.Errorf("failed to escape value: %w", err)
 		}
+		return esc, nil
+	case "base64:":
+		return []byte(base64.StdEncoding.EncodeToString(val)), nil
+	case "hex:":
+		return []byte(hex.EncodeToString(val)), nil
+	case "gzip:":
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(val); err != nil {
+			return nil, fmt.Errorf("failed to compress value: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress value: %w", err)
+		}
+		return buf.Bytes(), nil
+	case "zstd:":
+		enc, err := zstdEncoder()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		return enc.EncodeAll(val, nil), nil
 	}
+	return applyHelper(w, val)
+}
+
+// peelWrappers strips the wrapper prefixes at the start of inner, appending them to chain.
+// Helpers taking an argument are appended with it, e.g. trunc:8:.
+func peelWrappers(chain []string, inner string) ([]string, string) {
+	for {
+		if n := helperPrefix(inner); n > 0 {
+			chain = append(chain, inner[:n])
+			inner = inner[n:]
+			continue
+		}
+		i := slices.IndexFunc(wrappers, func(w string) bool { return strings.HasPrefix(inner, w) })
+		if i == -1 {
+			return chain, inner
+		}
+		chain = append(chain, wrappers[i])
+		inner = inner[len(wrappers[i]):]
+	}
+}
+
+// wrappedValue resolves the expression inside a wrapper: a file: or var: expression, a
+// placeholder, or literal text. pos is the position of the wrapper, for error messages.
+func (g *Generator) wrappedValue(inner string, pos int) ([]byte, error) {
+	var val []byte
+	var err error
+	if strings.HasPrefix(inner, "file:") {
+		// file read
+		fp := inner[len("file:"):]
+		if fp == "" {
+			return nil, fmt.Errorf("empty file path in placeholder at position %d", pos)
+		}
+		if val, err = g.fileValue(fp); err != nil {
+			return nil, err
+		}
+	} else if strings.HasPrefix(inner, "var:") {
+		key := inner[len("var:"):]
+		val = []byte(g.Var(key))
+	} else if gen, args, ok := argPlaceholder(inner); ok {
+		val, err = gen(g, args)
+		if err != nil {
+			return nil, err
+		}
+	} else if t, ok := placeholders[inner]; ok {
+		val, err = g.Generate(t)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Unknown inner expression, treat as raw text (rejected upfront in strict mode)
+		val = []byte(inner)
+	}
+	return val, nil
+}
+
+// StrictTemplates makes InterpolateWithDelimiters fail on unknown placeholder keywords
+// instead of leaving them in the output as literal text.
+// Disabled by default for backward compatibility; set via testpayload.SetStrictTemplates(true) or CLI flag.
+var StrictTemplates bool = false
+
+// SetStrictTemplates toggles strict validation of placeholder keywords.
+func SetStrictTemplates(v bool) {
+	StrictTemplates = v
+}
+
+// validatePlaceholders checks that every placeholder in str uses a known keyword.
+func validatePlaceholders(str string, openDelim string, closeDelim string) error {
+	pos := 0
+	for {
+		startIdx := strings.Index(str[pos:], openDelim)
+		if startIdx == -1 {
+			return nil
+		}
+		startIdx += pos
+		innerStart := startIdx + len(openDelim)
+		endIdx := strings.Index(str[innerStart:], closeDelim)
+		if endIdx == -1 {
+			return fmt.Errorf("unclosed placeholder at position %d", startIdx)
+		}
+		endIdx += innerStart
+		inner := str[innerStart:endIdx]
+		if !isKnownPlaceholder(inner) {
+			return fmt.Errorf("unknown placeholder %q at position %d", openDelim+inner+closeDelim, startIdx)
+		}
+		pos = endIdx + len(closeDelim)
+	}
+}
 
-	return []byte(result), nil
+// isKnownPlaceholder reports whether inner (the text between delimiters) is a recognized keyword.
+func isKnownPlaceholder(inner string) bool {
+	_, inner = peelWrappers(nil, inner)
+	if strings.HasPrefix(inner, "var:") || strings.HasPrefix(inner, "file:") {
+		return true
+	}
+	if _, _, ok := argPlaceholder(inner); ok {
+		return true
+	}
+	_, ok := placeholders[inner]
+	return ok
 }
 
 // AllowFileReads controls whether {{file:...}} placeholders are permitted.
@@ -296,35 +712,552 @@ func SetAllowFileReads(v bool) {
 	AllowFileReads = v
 }
 
-// SeedRandom seeds the global pseudo-random generator used by testpayload helpers.
+// AllowEnvReads controls whether {{env:NAME}} placeholders are permitted.
+// Disabled by default so templates (e.g. fetched with --payload-url) cannot leak secrets from
+// the environment; set via testpayload.SetAllowEnvReads(true) or CLI flag.
+var AllowEnvReads bool = false
+
+// SetAllowEnvReads toggles environment variable support for the test payload generator.
+func SetAllowEnvReads(v bool) {
+	AllowEnvReads = v
+}
+
+// generateEnv evaluates an env expression (the placeholder without the "env:" prefix), NAME,
+// returning the value of the environment variable NAME, empty when it is unset.
+func generateEnv(name string) ([]byte, error) {
+	if name == "" {
+		return nil, fmt.Errorf("invalid env placeholder %q: expected env:NAME", "env:"+name)
+	}
+	if !AllowEnvReads {
+		return nil, fmt.Errorf("env reads are disabled: to enable allow env reads set testpayload.SetAllowEnvReads(true)")
+	}
+	return []byte(os.Getenv(name)), nil
+}
+
+// SeedRandom seeds DefaultGenerator, which backs the package-level helpers including the
+// faker data behind {{json}}, {{cbor}} and {{sentence}}, and restarts its streams.
 // Useful to make generation deterministic for tests and reproducible scenarios.
 func SeedRandom(seed int64) {
-	//nolint:staticcheck // SA1019: Using deprecated rand.Seed for backward compatibility
-	rand.Seed(seed)
+	defaultGenerator.Seed(seed)
 }
 
-// Template variables for substitution using {{var:name}} placeholders
-var templateVars = map[string]string{}
+// Stream is a named pseudo-random generator derived from the seed of a Generator.
+// Each stream draws from its own sequence, so values consumed by one stream (or by the
+// generator itself) never shift the values of another: correlated fields such as a user
+// ID and its orders stay reproducible even when the rest of the template changes.
+type Stream struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
 
-// SetTemplateVars replaces the full variables map used by InterpolateWithDelimiters.
-func SetTemplateVars(vars map[string]string) {
-	templateVars = map[string]string{}
-	for k, v := range vars {
-		templateVars[k] = v
+// streamSeedFor mixes the stream name into the generator seed.
+func streamSeedFor(seed int64, name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return seed ^ int64(h.Sum64()) // #nosec G115 -- bit mixing, overflow is intended
+}
+
+// NewStream returns the named stream, creating it on first use. The stream is seeded
+// from the global SeedRandom seed combined with name, so the same seed and name always
+// yield the same sequence. SeedRandom (and thus per-message seeding) restarts all streams.
+func NewStream(name string) *Stream {
+	return defaultGenerator.Stream(name)
+}
+
+// Stream returns the named stream of g, see NewStream. Seed restarts the streams of g.
+func (g *Generator) Stream(name string) *Stream {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s, ok := g.streams[name]; ok {
+		return s
+	}
+	s := &Stream{r: rand.New(rand.NewSource(streamSeedFor(g.seed, name)))} // #nosec G404 -- test data generator
+	g.streams[name] = s
+	return s
+}
+
+// Intn returns a pseudo-random int in [0, n).
+func (s *Stream) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r.Intn(n)
+}
+
+// IntRange returns a pseudo-random int in [lo, hi], both inclusive.
+func (s *Stream) IntRange(lo, hi int) int {
+	return lo + s.Intn(hi-lo+1)
+}
+
+// generateStream evaluates a stream expression (the placeholder without the "stream:"
+// prefix), currently NAME:intrange:MIN:MAX.
+func (g *Generator) generateStream(expr string) ([]byte, error) {
+	parts := strings.Split(expr, ":")
+	if len(parts) < 2 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid stream placeholder %q: expected stream:NAME:GENERATOR", "stream:"+expr)
+	}
+	name, gen, args := parts[0], parts[1], parts[2:]
+	switch gen {
+	case "intrange":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("invalid stream placeholder %q: expected intrange:MIN:MAX", "stream:"+expr)
+		}
+		lo, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid intrange min %q: %w", args[0], err)
+		}
+		hi, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid intrange max %q: %w", args[1], err)
+		}
+		if hi < lo {
+			return nil, fmt.Errorf("invalid intrange: max %d is less than min %d", hi, lo)
+		}
+		if hi-lo+1 <= 0 {
+			return nil, fmt.Errorf("invalid stream placeholder %q: range too large", "stream:"+expr)
+		}
+		return []byte(strconv.Itoa(g.Stream(name).IntRange(lo, hi))), nil
+	}
+	return nil, fmt.Errorf("unsupported stream generator %q", gen)
+}
+
+// generateCounter evaluates a counter expression (the placeholder without the "counter:"
+// prefix), NAME[:START[:STEP]], returning the next value of the named counter, which starts
+// at START (default 1) and increases by STEP (default 1).
+func (g *Generator) generateCounter(expr string) ([]byte, error) {
+	ph := "counter:" + expr
+	args := strings.Split(expr, ":")
+	if len(args) > 3 || args[0] == "" {
+		return nil, fmt.Errorf("invalid counter placeholder %q: expected counter:NAME[:START[:STEP]]", ph)
+	}
+	start, step := int64(1), int64(1)
+	var err error
+	if len(args) > 1 {
+		if start, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid counter start %q: %w", args[1], err)
+		}
+	}
+	if len(args) > 2 {
+		if step, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid counter step %q: %w", args[2], err)
+		}
+	}
+	return []byte(strconv.FormatInt(g.NamedCounter(args[0], start, step), 10)), nil
+}
+
+// generateRandInt evaluates a randint expression (the placeholder without the "randint:"
+// prefix), MIN:MAX, returning an integer in [MIN, MAX] from the seedable generator.
+func (g *Generator) generateRandInt(expr string) ([]byte, error) {
+	lo, hi, err := parseRange("randint", expr)
+	if err != nil {
+		return nil, err
+	}
+	n := hi - lo + 1
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid randint placeholder %q: range too large", "randint:"+expr)
+	}
+	return []byte(strconv.FormatInt(lo+g.Int63n(n), 10)), nil
+}
+
+// defaultFloatDecimals is the precision of randfloat placeholders without DECIMALS.
+const defaultFloatDecimals = 2
+
+// generateRandFloat evaluates a randfloat expression (the placeholder without the "randfloat:"
+// prefix), MIN:MAX[:DECIMALS], returning a number in [MIN, MAX] with exactly DECIMALS
+// decimals (default 2). Values are drawn uniformly among the multiples of 10^-DECIMALS in the
+// range, so rounding never leaves it.
+func (g *Generator) generateRandFloat(expr string) ([]byte, error) {
+	ph := "randfloat:" + expr
+	args := strings.Split(expr, ":")
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf("invalid randfloat placeholder %q: expected randfloat:MIN:MAX[:DECIMALS]", ph)
+	}
+	lo, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid randfloat min %q: %w", args[0], err)
+	}
+	hi, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid randfloat max %q: %w", args[1], err)
+	}
+	if math.IsNaN(lo) || math.IsNaN(hi) || math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+		return nil, fmt.Errorf("invalid randfloat placeholder %q: bounds must be finite", ph)
+	}
+	if hi < lo {
+		return nil, fmt.Errorf("invalid randfloat: max %g is less than min %g", hi, lo)
+	}
+	decimals := defaultFloatDecimals
+	if len(args) == 3 {
+		if decimals, err = strconv.Atoi(args[2]); err != nil || decimals < 0 || decimals > 15 {
+			return nil, fmt.Errorf("invalid randfloat decimals %q: expected 0 to 15", args[2])
+		}
+	}
+	scale := math.Pow10(decimals)
+	first, last := math.Ceil(lo*scale), math.Floor(hi*scale)
+	if first > last {
+		return nil, fmt.Errorf("invalid randfloat placeholder %q: no value with %d decimals in range", ph, decimals)
+	}
+	if last-first >= 1<<62 {
+		return nil, fmt.Errorf("invalid randfloat placeholder %q: range too large", ph)
+	}
+	steps := first + float64(g.Int63n(int64(last-first)+1))
+	return []byte(strconv.FormatFloat(steps/scale, 'f', decimals, 64)), nil
+}
+
+// maxGeneratedSize caps the size of generated content, guarding against typos such as an
+// extra zero exhausting memory.
+const maxGeneratedSize = 64 << 20
+
+// sizeAlphabet has 64 JSON-safe printable characters, so a random byte maps to one uniformly.
+const sizeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// generateSize evaluates a size expression (the placeholder without the "size:" prefix),
+// N[:text|binary], returning exactly N random bytes: printable characters by default or any
+// byte value with binary.
+func (g *Generator) generateSize(expr string) ([]byte, error) {
+	sizeArg, mode, _ := strings.Cut(expr, ":")
+	buf, err := g.randomBytes("size", sizeArg)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "", "text":
+		for i, b := range buf {
+			buf[i] = sizeAlphabet[b&63]
+		}
+	case "binary":
+	default:
+		return nil, fmt.Errorf("invalid size placeholder %q: unknown mode %q, expected text or binary", "size:"+expr, mode)
+	}
+	return buf, nil
+}
+
+// generateBytes evaluates a bytes expression (the placeholder without the "bytes:" prefix), N,
+// returning N random bytes of any value. Wrap it in base64: or hex: for text protocols.
+func (g *Generator) generateBytes(expr string) ([]byte, error) {
+	return g.randomBytes("bytes", expr)
+}
+
+// randomBytes reads the byte count N of a placeholder and returns N bytes from the seedable
+// generator.
+func (g *Generator) randomBytes(keyword, count string) ([]byte, error) {
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid %s placeholder: %q is not a byte count", keyword, count)
+	}
+	if n > maxGeneratedSize {
+		return nil, fmt.Errorf("invalid %s placeholder: %d bytes exceeds the %d limit", keyword, n, maxGeneratedSize)
 	}
+	buf := make([]byte, n)
+	if _, err := g.Read(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// generateOneOf evaluates a oneof expression (the placeholder without the "oneof:" prefix),
+// A|B|C, returning one of the options. An option may end in *W to give it the integer weight
+// W (default 1), e.g. ok*8|warn*1|error*1 yields ok 80% of the time.
+func (g *Generator) generateOneOf(expr string) ([]byte, error) {
+	options := strings.Split(expr, "|")
+	weights := make([]int64, len(options))
+	var total int64
+	for i, opt := range options {
+		weights[i] = 1
+		if star := strings.LastIndex(opt, "*"); star != -1 {
+			w, err := strconv.ParseInt(opt[star+1:], 10, 64)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid oneof weight %q: expected a non-negative integer", opt[star+1:])
+			}
+			options[i], weights[i] = opt[:star], w
+		}
+		if total += weights[i]; total < 0 {
+			return nil, fmt.Errorf("invalid oneof placeholder %q: weights too large", "oneof:"+expr)
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("invalid oneof placeholder %q: weights sum to zero", "oneof:"+expr)
+	}
+	pick := g.Int63n(total)
+	for i, w := range weights {
+		if pick < w {
+			return []byte(options[i]), nil
+		}
+		pick -= w
+	}
+	return nil, fmt.Errorf("invalid oneof placeholder %q", "oneof:"+expr)
+}
+
+// parseRange parses the MIN:MAX integer arguments of a placeholder.
+func parseRange(keyword, expr string) (int64, int64, error) {
+	args := strings.Split(expr, ":")
+	if len(args) != 2 {
+		return 0, 0, fmt.Errorf("invalid %s placeholder %q: expected %s:MIN:MAX", keyword, keyword+":"+expr, keyword)
+	}
+	lo, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s min %q: %w", keyword, args[0], err)
+	}
+	hi, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid %s max %q: %w", keyword, args[1], err)
+	}
+	if hi < lo {
+		return 0, 0, fmt.Errorf("invalid %s: max %d is less than min %d", keyword, hi, lo)
+	}
+	return lo, hi, nil
+}
+
+// Per-message seeding state, see SetSeedPerMessage.
+var (
+	seedPerMessage bool
+	seedBase       int64
+	messageIndex   int64
+	seedMutex      = sync.Mutex{}
+)
+
+// SeedForMessage seeds the generator with base+index, so the index-th message of a run
+// always gets the same random content while differing from its neighbors.
+func SeedForMessage(base, index int64) {
+	SeedRandom(base + index)
+}
+
+// SetSeedPerMessage enables (or disables) re-seeding with SeedForMessage(base, n)
+// each time BeginMessage is called, and resets the message index.
+func SetSeedPerMessage(enabled bool, base int64) {
+	seedMutex.Lock()
+	defer seedMutex.Unlock()
+	seedPerMessage = enabled
+	seedBase = base
+	messageIndex = 0
+}
+
+// BeginMessage must be called before building each message payload.
+// It moves a loaded data or payload source to its next row or line and, when per-message
+// seeding is enabled,
+// re-seeds the generator for the next message index.
+func BeginMessage() {
+	advanceDataRow()
+	advancePayloadSource()
+	seedMutex.Lock()
+	defer seedMutex.Unlock()
+	if !seedPerMessage {
+		return
+	}
+	SeedForMessage(seedBase, messageIndex)
+	messageIndex++
+}
+
+// SetTemplateVars replaces the variables of {{var:name}} placeholders used by
+// InterpolateWithDelimiters.
+func SetTemplateVars(vars map[string]string) {
+	defaultGenerator.SetVars(vars)
 }
 
 // AddTemplateVar adds a single template variable.
 func AddTemplateVar(name, val string) {
-	if templateVars == nil {
-		templateVars = map[string]string{}
-	}
-	templateVars[name] = val
+	defaultGenerator.AddVar(name, val)
 }
 
 // ClearTemplateVars clears all configured template variables.
 func ClearTemplateVars() {
-	templateVars = map[string]string{}
+	defaultGenerator.ClearVars()
+}
+
+// ErrDataExhausted is returned by {{col:NAME}} placeholders and InterpolateMessage once every
+// row or line of a data or payload source loaded without loop has been used.
+var ErrDataExhausted = errors.New("data source exhausted")
+
+// Data source state, see LoadDataCSV.
+var (
+	dataColumns map[string]int
+	dataRows    [][]string
+	dataLoop    bool
+	dataRow     int
+	dataNext    int
+	dataMutex   = sync.Mutex{}
+)
+
+// LoadDataCSV loads the rows of a CSV file, whose first line names the columns, as the data
+// source of {{col:NAME}} placeholders. Each BeginMessage moves to the next row; after the last
+// one, it wraps around to the first with loop, otherwise the placeholders fail with
+// ErrDataExhausted.
+func LoadDataCSV(path string, loop bool) error {
+	// #nosec G304 -- data file explicitly given by the user
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open data file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read data file %s: %w", path, err)
+	}
+	if len(records) < 2 {
+		return fmt.Errorf("data file %s has no rows after the header", path)
+	}
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	dataMutex.Lock()
+	defer dataMutex.Unlock()
+	dataColumns, dataRows, dataLoop = columns, records[1:], loop
+	dataRow, dataNext = 0, 0
+	return nil
+}
+
+// ClearDataSource unloads the data source of {{col:NAME}} placeholders.
+func ClearDataSource() {
+	dataMutex.Lock()
+	defer dataMutex.Unlock()
+	dataColumns, dataRows = nil, nil
+	dataRow, dataNext = 0, 0
+}
+
+// advanceDataRow moves the data source, if any, to the row of the next message.
+func advanceDataRow() {
+	dataMutex.Lock()
+	defer dataMutex.Unlock()
+	if dataRows == nil {
+		return
+	}
+	dataRow = dataNext
+	if dataLoop {
+		dataRow %= len(dataRows)
+	}
+	dataNext++
+}
+
+// generateCol evaluates a col expression (the placeholder without the "col:" prefix), NAME,
+// returning the value of column NAME in the current row of the data source.
+func generateCol(name string) ([]byte, error) {
+	dataMutex.Lock()
+	defer dataMutex.Unlock()
+	if dataRows == nil {
+		return nil, fmt.Errorf("invalid col placeholder %q: no data source loaded", "col:"+name)
+	}
+	i, ok := dataColumns[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid col placeholder %q: unknown column", "col:"+name)
+	}
+	if dataRow >= len(dataRows) {
+		return nil, fmt.Errorf("%w after %d rows", ErrDataExhausted, len(dataRows))
+	}
+	return []byte(dataRows[dataRow][i]), nil
+}
+
+// PayloadSourceOptions configures a payload source, see LoadPayloadSource.
+type PayloadSourceOptions struct {
+	// Loop restarts from the first line after the last one instead of failing with ErrDataExhausted.
+	Loop bool
+	// Shuffle sends the lines in random order, reshuffled on every pass.
+	Shuffle bool
+	// Template interpolates placeholders in each line; lines are sent verbatim otherwise.
+	Template bool
+}
+
+// Payload source state, see LoadPayloadSource.
+var (
+	sourceLines   []string
+	sourceOrder   []int
+	sourceOptions PayloadSourceOptions
+	sourcePos     int
+	sourceNext    int
+	sourceMutex   = sync.Mutex{}
+)
+
+// maxSourceLineSize is the longest line accepted in a payload source.
+const maxSourceLineSize = 16 << 20
+
+// LoadPayloadSource loads the lines of a file, usually NDJSON captured events, as the payloads
+// returned by InterpolateMessage: each BeginMessage moves to the next line. Blank lines are
+// skipped.
+func LoadPayloadSource(path string, opts PayloadSourceOptions) error {
+	// #nosec G304 -- payload source explicitly given by the user
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open payload source: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSourceLineSize)
+	for scanner.Scan() {
+		if line := strings.TrimSuffix(scanner.Text(), "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read payload source %s: %w", path, err)
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("payload source %s has no lines", path)
+	}
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	sourceLines, sourceOptions = lines, opts
+	sourceOrder = make([]int, len(lines))
+	for i := range sourceOrder {
+		sourceOrder[i] = i
+	}
+	if opts.Shuffle {
+		shuffleSource()
+	}
+	sourcePos, sourceNext = 0, 0
+	return nil
+}
+
+// ClearPayloadSource unloads the payload source, InterpolateMessage then uses its template.
+func ClearPayloadSource() {
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	sourceLines, sourceOrder = nil, nil
+	sourcePos, sourceNext = 0, 0
+}
+
+// shuffleSource randomizes the order of the payload source lines; sourceMutex must be held.
+func shuffleSource() {
+	defaultGenerator.Shuffle(len(sourceOrder), func(i, j int) {
+		sourceOrder[i], sourceOrder[j] = sourceOrder[j], sourceOrder[i]
+	})
+}
+
+// advancePayloadSource moves the payload source, if any, to the line of the next message.
+func advancePayloadSource() {
+	sourceMutex.Lock()
+	defer sourceMutex.Unlock()
+	if sourceLines == nil {
+		return
+	}
+	sourcePos = sourceNext
+	sourceNext++
+	if sourcePos == len(sourceLines) && sourceOptions.Loop {
+		if sourceOptions.Shuffle {
+			shuffleSource()
+		}
+		sourcePos, sourceNext = 0, 1
+	}
+}
+
+// InterpolateMessage returns the payload of the current message: the current line of the
+// payload source when one is loaded (rendered with its Template option), otherwise the
+// template str rendered with the selected TemplateEngine.
+func InterpolateMessage(str string, openDelim string, closeDelim string) ([]byte, error) {
+	sourceMutex.Lock()
+	if sourceLines == nil {
+		sourceMutex.Unlock()
+		return renderPayload(str, openDelim, closeDelim)
+	}
+	if sourcePos >= len(sourceLines) {
+		sourceMutex.Unlock()
+		return nil, fmt.Errorf("%w after %d lines", ErrDataExhausted, len(sourceLines))
+	}
+	line, template := sourceLines[sourceOrder[sourcePos]], sourceOptions.Template
+	sourceMutex.Unlock()
+	if template {
+		return renderPayload(line, openDelim, closeDelim)
+	}
+	return []byte(line), nil
 }
 
 // FileRoot is the optional root path for allowed file reads; empty means no root restriction.
@@ -335,6 +1268,53 @@ func SetFileRoot(root string) {
 	FileRoot = root
 }
 
+// checkFileRead returns an error unless AllowFileReads is set and path is under FileRoot.
+// File reads may be disabled by default for security in CI.
+func checkFileRead(path string) error {
+	if !AllowFileReads {
+		return fmt.Errorf("file reads are disabled: to enable allow file reads set testpayload.SetAllowFileReads(true)")
+	}
+	if FileRoot != "" {
+		absRoot, err := filepath.Abs(FileRoot)
+		if err != nil {
+			return fmt.Errorf("invalid file root: %w", err)
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("invalid file path: %s", path)
+		}
+		if !strings.HasPrefix(absPath, absRoot) {
+			return fmt.Errorf("file %s outside allowed root %s", path, FileRoot)
+		}
+	}
+	return nil
+}
+
+// fileValue returns the content of the file of a file: expression, a PATH or a glob
+// PATTERN[:seq|random] (see globFile), from the file cache when enabled.
+func (g *Generator) fileValue(expr string) ([]byte, error) {
+	path := expr
+	if isGlob(expr) {
+		var err error
+		if path, err = g.globFile(expr); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkFileRead(path); err != nil {
+		return nil, err
+	}
+	if c, ok := GetFileFromCache(path); ok {
+		return c, nil
+	}
+	// #nosec G304 - File path is validated and restricted by FileRoot
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	PutFileIntoCache(path, content)
+	return content, nil
+}
+
 // File cache
 var fileCacheEnabled bool = false
 var fileCache = map[string][]byte{}
@@ -391,14 +1371,49 @@ const (
 	TestPayloadDateTime  TestPayloadType = "datetime" // to generate a timestamp
 	TestPayloadNowTime   TestPayloadType = "nowtime"  // to generate the current timestamp
 	TestPayloadCounter   TestPayloadType = "counter"  // to generate an incrementing counter (not implemented yet
+	TestPayloadUUID      TestPayloadType = "uuid"     // to generate a random UUID
+	TestPayloadUUIDv7    TestPayloadType = "uuidv7"   // to generate a time-ordered UUID
+	TestPayloadName      TestPayloadType = "name"
+	TestPayloadFirstName TestPayloadType = "firstname"
+	TestPayloadLastName  TestPayloadType = "lastname"
+	TestPayloadEmail     TestPayloadType = "email"
+	TestPayloadPhone     TestPayloadType = "phone"
+	TestPayloadUsername  TestPayloadType = "username"
+	TestPayloadURL       TestPayloadType = "url"
+	TestPayloadDomain    TestPayloadType = "domain"
+	TestPayloadCompany   TestPayloadType = "company"
+	TestPayloadWord      TestPayloadType = "word"
+	TestPayloadIPv4      TestPayloadType = "ipv4"
+	TestPayloadIPv6      TestPayloadType = "ipv6"
+	TestPayloadMAC       TestPayloadType = "mac"
+	TestPayloadPort      TestPayloadType = "port" // to generate a port number
+	TestPayloadLat       TestPayloadType = "lat"  // to generate a latitude
+	TestPayloadLon       TestPayloadType = "lon"  // to generate a longitude
 )
 
+// fakerFields are the text payload types backed by faker generators.
+var fakerFields = map[TestPayloadType]func() string{
+	TestPayloadName:      func() string { return faker.Name() },
+	TestPayloadFirstName: func() string { return faker.FirstName() },
+	TestPayloadLastName:  func() string { return faker.LastName() },
+	TestPayloadEmail:     func() string { return faker.Email() },
+	TestPayloadPhone:     func() string { return faker.Phonenumber() },
+	TestPayloadUsername:  func() string { return faker.Username() },
+	TestPayloadURL:       func() string { return faker.URL() },
+	TestPayloadDomain:    func() string { return faker.DomainName() },
+	TestPayloadWord:      func() string { return faker.Word() },
+	TestPayloadIPv4:      func() string { return faker.IPv4() },
+	TestPayloadIPv6:      func() string { return faker.IPv6() },
+	TestPayloadMAC:       func() string { return faker.MacAddress() },
+}
+
 func (t TestPayloadType) IsValid() bool {
 	switch t {
-	case TestPayloadJSON, TestPayloadCBOR, TestPayloadSentiment, TestPayloadSentence, TestPayloadDateTime, TestPayloadNowTime:
+	case TestPayloadJSON, TestPayloadCBOR, TestPayloadSentiment, TestPayloadSentence, TestPayloadDateTime, TestPayloadNowTime, TestPayloadUUID, TestPayloadUUIDv7, TestPayloadCompany, TestPayloadPort, TestPayloadLat, TestPayloadLon:
 		return true
 	}
-	return false
+	_, ok := fakerFields[t]
+	return ok
 }
 
 func (t TestPayloadType) GetContentType() string {
@@ -407,28 +1422,51 @@ func (t TestPayloadType) GetContentType() string {
 		return "application/json"
 	case TestPayloadCBOR:
 		return "application/cbor"
-	case TestPayloadSentiment, TestPayloadSentence, TestPayloadDateTime, TestPayloadNowTime:
+	case TestPayloadSentiment, TestPayloadSentence, TestPayloadDateTime, TestPayloadNowTime, TestPayloadUUID, TestPayloadUUIDv7, TestPayloadCompany, TestPayloadPort, TestPayloadLat, TestPayloadLon:
+		return "text/plain"
+	}
+	if _, ok := fakerFields[t]; ok {
 		return "text/plain"
 	}
 	return "application/octet-stream"
 }
 
 func (t TestPayloadType) Generate() ([]byte, error) {
+	return defaultGenerator.Generate(t)
+}
+
+// Generate returns a value of the payload type t drawn from g.
+func (g *Generator) Generate(t TestPayloadType) ([]byte, error) {
 	switch t {
 	case TestPayloadJSON:
-		return GenerateRandomJSON()
+		return g.randomJSON()
 	case TestPayloadCBOR:
-		return GenerateRandomCBOR()
+		return g.randomCBOR()
 	case TestPayloadSentiment:
-		return []byte(GenerateSentimentPhrase()), nil
+		return []byte(g.sentimentPhrase()), nil
 	case TestPayloadSentence:
-		return []byte(GenerateSentence()), nil
+		return []byte(g.sentence()), nil
 	case TestPayloadDateTime:
-		return []byte(GenerateRandomDateTime()), nil
+		return []byte(g.randomDateTime().Format(time.RFC3339Nano)), nil
 	case TestPayloadNowTime:
 		return []byte(GenerateNowDateTime()), nil
 	case TestPayloadCounter:
-		return []byte(fmt.Sprintf("%d", GenerateCounter())), nil
+		return []byte(fmt.Sprintf("%d", g.Counter())), nil
+	case TestPayloadUUID:
+		return []byte(g.uuid()), nil
+	case TestPayloadUUIDv7:
+		return []byte(g.uuidv7()), nil
+	case TestPayloadCompany:
+		return []byte(g.company()), nil
+	case TestPayloadPort:
+		return []byte(strconv.Itoa(g.port())), nil
+	case TestPayloadLat:
+		return g.coordinate(worldBounds.minLat, worldBounds.maxLat)
+	case TestPayloadLon:
+		return g.coordinate(worldBounds.minLon, worldBounds.maxLon)
+	}
+	if gen, ok := fakerFields[t]; ok {
+		return []byte(g.fakeString(gen)), nil
 	}
 	return nil, fmt.Errorf("unsupported test payload type: %s", t)
 }
@@ -1,13 +1,24 @@
 package testpayload
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fxamacker/cbor/v2"
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
 )
 
 func TestGenerateRandomJSON(t *testing.T) {
@@ -107,6 +118,115 @@ func TestGenerateCounter(t *testing.T) {
 	}
 }
 
+func TestGenerateUUID(t *testing.T) {
+	for _, tt := range []struct {
+		gen     func() string
+		version uuid.Version
+	}{{GenerateUUID, 4}, {GenerateUUIDv7, 7}} {
+		a, b := tt.gen(), tt.gen()
+		id, err := uuid.Parse(a)
+		if err != nil {
+			t.Fatalf("invalid UUID %q: %v", a, err)
+		}
+		if id.Version() != tt.version || id.Variant() != uuid.RFC4122 {
+			t.Errorf("%s: version %d, variant %s", a, id.Version(), id.Variant())
+		}
+		if a == b {
+			t.Errorf("consecutive UUIDs should differ: %s", a)
+		}
+	}
+
+	SeedRandom(7)
+	first := GenerateUUID()
+	SeedRandom(7)
+	if again := GenerateUUID(); again != first {
+		t.Errorf("seeded UUIDs differ: %s vs %s", first, again)
+	}
+
+	b, err := Interpolate(`{"id":"{{uuid}}","trace":"{{uuidv7}}"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ ID, Trace string }
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uuid.Parse(v.ID); err != nil {
+		t.Errorf("invalid id %q", v.ID)
+	}
+	if id, err := uuid.Parse(v.Trace); err != nil || id.Version() != 7 {
+		t.Errorf("invalid trace %q", v.Trace)
+	}
+}
+
+func TestInterpolate_FakerPlaceholders(t *testing.T) {
+	tmpl := `{"name":"{{name}}","first":"{{firstname}}","last":"{{lastname}}","email":"{{email}}","phone":"{{phone}}",` +
+		`"user":"{{username}}","url":"{{url}}","domain":"{{domain}}","company":"{{company}}","word":"{{word}}"}`
+	SeedRandom(42)
+	first, err := Interpolate(tmpl)
+	if err != nil {
+		t.Fatalf("Interpolate() error = %v", err)
+	}
+	var v map[string]string
+	if err := json.Unmarshal(first, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", first, err)
+	}
+	for k, val := range v {
+		if val == "" || strings.Contains(val, "{{") {
+			t.Errorf("%s not generated: %q", k, val)
+		}
+	}
+	if !strings.Contains(v["email"], "@") || !strings.Contains(v["url"], "://") || !strings.Contains(v["domain"], ".") {
+		t.Errorf("unexpected values: %s", first)
+	}
+	if !strings.Contains(v["company"], " ") {
+		t.Errorf("company should have a suffix: %q", v["company"])
+	}
+
+	SeedRandom(42)
+	if second, _ := Interpolate(tmpl); string(second) != string(first) {
+		t.Errorf("seeded output differs: %q vs %q", first, second)
+	}
+
+	SetStrictTemplates(true)
+	defer SetStrictTemplates(false)
+	if _, err := Interpolate(tmpl); err != nil {
+		t.Errorf("strict mode rejected faker placeholders: %v", err)
+	}
+}
+
+func TestInterpolate_NetworkPlaceholders(t *testing.T) {
+	SeedRandom(42)
+	first, err := Interpolate(`{"src":"{{ipv4}}","dst":"{{ipv6}}","mac":"{{mac}}","port":{{port}}}`)
+	if err != nil {
+		t.Fatalf("Interpolate() error = %v", err)
+	}
+	var v struct {
+		Src, Dst, MAC string
+		Port          int
+	}
+	if err := json.Unmarshal(first, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", first, err)
+	}
+	if ip := net.ParseIP(v.Src); ip == nil || ip.To4() == nil {
+		t.Errorf("invalid IPv4 %q", v.Src)
+	}
+	if ip := net.ParseIP(v.Dst); ip == nil || !strings.Contains(v.Dst, ":") {
+		t.Errorf("invalid IPv6 %q", v.Dst)
+	}
+	if hw, err := net.ParseMAC(v.MAC); err != nil || len(hw) != 6 {
+		t.Errorf("invalid MAC %q", v.MAC)
+	}
+	if v.Port < 1 || v.Port > 65535 {
+		t.Errorf("invalid port %d", v.Port)
+	}
+
+	SeedRandom(42)
+	if second, _ := Interpolate(`{"src":"{{ipv4}}","dst":"{{ipv6}}","mac":"{{mac}}","port":{{port}}}`); string(second) != string(first) {
+		t.Errorf("seeded output differs: %q vs %q", first, second)
+	}
+}
+
 func TestInterpolate(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -352,6 +472,45 @@ func TestInterpolateWithDelimiters_RawAndStrWrappers(t *testing.T) {
 	}
 }
 
+func TestInterpolateWithDelimiters_StrictTemplates(t *testing.T) {
+	// Lenient mode (default) keeps unknown wrapped keywords as literal text
+	res, err := InterpolateWithDelimiters("Message: {{str:sentense}}", "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if string(res) != `Message: "sentense"` {
+		t.Fatalf("expected literal fallback in lenient mode, got: %s", string(res))
+	}
+
+	SetStrictTemplates(true)
+	defer SetStrictTemplates(false)
+
+	tests := []struct {
+		name       string
+		input      string
+		openDelim  string
+		closeDelim string
+		wantErr    bool
+	}{
+		{"Known placeholders", "{{counter}} {{raw:json}} {{str:sentence}} {{var:x}}", "{{", "}}", false},
+		{"Plain text", "hello world", "{{", "}}", false},
+		{"Typo in str wrapper", "Message: {{str:sentense}}", "{{", "}}", true},
+		{"Typo in raw wrapper", "{{raw:jsn}}", "{{", "}}", true},
+		{"Unknown bare keyword", "{{uuidx}}", "{{", "}}", true},
+		{"Custom delimiters known", "<<counter>>", "<<", ">>", false},
+		{"Custom delimiters unknown", "<<countr>>", "<<", ">>", true},
+		{"Unclosed placeholder", "{{counter", "{{", "}}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := InterpolateWithDelimiters(tt.input, tt.openDelim, tt.closeDelim)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InterpolateWithDelimiters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestInterpolateWithDelimiters_FileRootSandboxing(t *testing.T) {
 	// Create two directories: tmpRoot and tmpOutside
 	tmpRoot := t.TempDir()
@@ -460,6 +619,9 @@ func TestTestPayloadType_IsValid(t *testing.T) {
 		{TestPayloadSentence, true},
 		{TestPayloadDateTime, true},
 		{TestPayloadNowTime, true},
+		{TestPayloadUUID, true},
+		{TestPayloadUUIDv7, true},
+		{TestPayloadEmail, true},
 		{"invalid", false},
 		{"", false},
 	}
@@ -485,6 +647,8 @@ func TestTestPayloadType_GetContentType(t *testing.T) {
 		{TestPayloadSentence, "text/plain"},
 		{TestPayloadDateTime, "text/plain"},
 		{TestPayloadNowTime, "text/plain"},
+		{TestPayloadUUID, "text/plain"},
+		{TestPayloadCompany, "text/plain"},
 		{"invalid", "application/octet-stream"},
 	}
 
@@ -510,6 +674,14 @@ func TestTestPayloadType_Generate(t *testing.T) {
 		{TestPayloadDateTime, false},
 		{TestPayloadNowTime, false},
 		{TestPayloadCounter, false},
+		{TestPayloadUUID, false},
+		{TestPayloadUUIDv7, false},
+		{TestPayloadName, false},
+		{TestPayloadCompany, false},
+		{TestPayloadIPv4, false},
+		{TestPayloadPort, false},
+		{TestPayloadLat, false},
+		{TestPayloadLon, false},
 		{"invalid", true},
 	}
 
@@ -526,3 +698,679 @@ func TestTestPayloadType_Generate(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSeedPerMessage(t *testing.T) {
+	defer SetSeedPerMessage(false, 0)
+
+	run := func() []string {
+		SetSeedPerMessage(true, 42)
+		var out []string
+		for i := 0; i < 5; i++ {
+			BeginMessage()
+			b, err := InterpolateWithDelimiters("{{sentiment}} {{json}}", "{{", "}}")
+			if err != nil {
+				t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+			}
+			out = append(out, string(b))
+		}
+		return out
+	}
+
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("message %d differs across runs: %q vs %q", i, first[i], second[i])
+		}
+	}
+	if first[0] == first[1] && first[1] == first[2] {
+		t.Errorf("consecutive messages should vary, got %q", first[:3])
+	}
+
+	// The Nth message matches SeedForMessage(base, N) directly.
+	SetSeedPerMessage(false, 0)
+	SeedForMessage(42, 3)
+	b, _ := InterpolateWithDelimiters("{{sentiment}} {{json}}", "{{", "}}")
+	if string(b) != first[3] {
+		t.Errorf("SeedForMessage(42, 3) = %q, want %q", b, first[3])
+	}
+}
+
+func TestNewStream(t *testing.T) {
+	draw := func(name string) []int {
+		s := NewStream(name)
+		out := make([]int, 10)
+		for i := range out {
+			out[i] = s.IntRange(1, 1000)
+		}
+		return out
+	}
+
+	SeedRandom(7)
+	users := draw("users")
+	orders := draw("orders")
+
+	// Re-seeding restarts every stream; consuming them in another order, or drawing
+	// from the global generator in between, must not change their sequences.
+	SeedRandom(7)
+	GenerateSentimentPhrase()
+	if got := draw("orders"); !slices.Equal(got, orders) {
+		t.Errorf("orders stream not reproducible: %v vs %v", got, orders)
+	}
+	if got := draw("users"); !slices.Equal(got, users) {
+		t.Errorf("users stream not reproducible: %v vs %v", got, users)
+	}
+
+	if slices.Equal(users, orders) {
+		t.Errorf("named streams should be independent, both produced %v", users)
+	}
+
+	SeedRandom(8)
+	if got := draw("users"); slices.Equal(got, users) {
+		t.Errorf("users stream should depend on the global seed, got %v for both seeds", got)
+	}
+}
+
+func TestInterpolateWithDelimiters_StreamPlaceholder(t *testing.T) {
+	tmpl := `{"user":{{stream:users:intrange:1:100}},"order":{{stream:orders:intrange:1:100}},"s":"{{sentiment}}"}`
+
+	SeedRandom(42)
+	first, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	SeedRandom(42)
+	second, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("seeded stream output differs: %q vs %q", first, second)
+	}
+
+	// The users stream yields the same value regardless of what else the template draws.
+	SeedRandom(42)
+	alone, err := InterpolateWithDelimiters("{{stream:users:intrange:1:100}}", "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if !strings.HasPrefix(string(first), `{"user":`+string(alone)+`,`) {
+		t.Errorf("users stream value changed with template, got %q alone and %q in template", alone, first)
+	}
+
+	for _, bad := range []string{
+		"{{stream:users}}",
+		"{{stream:users:intrange:1}}",
+		"{{stream:users:intrange:5:1}}",
+		"{{stream:users:intrange:a:9}}",
+		"{{stream:users:intrange:0:9223372036854775807}}",
+		"{{stream:users:intrange:-9223372036854775808:0}}",
+		"{{stream:users:unknown}}",
+	} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_RandIntPlaceholder(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		b, err := InterpolateWithDelimiters("<%randint:-2:2%>", "<%", "%>")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		n, err := strconv.Atoi(string(b))
+		if err != nil || n < -2 || n > 2 {
+			t.Fatalf("randint out of range: %q", b)
+		}
+		seen[string(b)] = true
+	}
+	if len(seen) != 5 {
+		t.Errorf("expected all values in [-2, 2], got %v", seen)
+	}
+
+	// Each occurrence is drawn separately, and seeded runs repeat.
+	tmpl := `{"a":{{randint:1:1000000000}},"b":{{randint:1:1000000000}},"s":{{str:randint:7:7}}}`
+	SeedRandom(42)
+	first, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	var v struct {
+		A, B int
+		S    string
+	}
+	if err := json.Unmarshal(first, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", first, err)
+	}
+	if v.A == v.B || v.S != "7" {
+		t.Errorf("unexpected values: %s", first)
+	}
+	SeedRandom(42)
+	if second, _ := InterpolateWithDelimiters(tmpl, "{{", "}}"); string(second) != string(first) {
+		t.Errorf("seeded output differs: %q vs %q", first, second)
+	}
+
+	SetStrictTemplates(true)
+	defer SetStrictTemplates(false)
+	if _, err := InterpolateWithDelimiters("{{randint:1:6}} {{counter}}", "{{", "}}"); err != nil {
+		t.Errorf("strict mode rejected randint: %v", err)
+	}
+	for _, bad := range []string{
+		"{{randint:1}}",
+		"{{randint:1:2:3}}",
+		"{{randint:9:1}}",
+		"{{randint:a:9}}",
+		"{{randint:-9223372036854775808:9223372036854775807}}",
+	} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_RandFloatPlaceholder(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		b, err := InterpolateWithDelimiters("{{randfloat:-10.5:35.25:1}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		s := string(b)
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < -10.5 || f > 35.25 {
+			t.Fatalf("randfloat out of range: %q", s)
+		}
+		if dot := strings.IndexByte(s, '.'); dot == -1 || len(s)-dot-1 != 1 {
+			t.Fatalf("expected one decimal, got %q", s)
+		}
+	}
+
+	// Default precision, integers, and bounds finer than the precision.
+	b, err := InterpolateWithDelimiters("{{randfloat:0:1}} {{randfloat:1:3:0}} {{randfloat:9.985:9.999:2}}", "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	parts := strings.Fields(string(b))
+	if len(parts) != 3 || len(parts[0]) != 4 || strings.Contains(parts[1], ".") || parts[2] != "9.99" {
+		t.Errorf("unexpected values: %q", b)
+	}
+
+	SeedRandom(42)
+	first, _ := InterpolateWithDelimiters(`{"t":{{randfloat:15:30:2}},"h":{{randfloat:0:100:1}}}`, "{{", "}}")
+	SeedRandom(42)
+	second, _ := InterpolateWithDelimiters(`{"t":{{randfloat:15:30:2}},"h":{{randfloat:0:100:1}}}`, "{{", "}}")
+	if string(first) != string(second) || !json.Valid(first) {
+		t.Errorf("seeded output differs or is invalid: %q vs %q", first, second)
+	}
+
+	for _, bad := range []string{
+		"{{randfloat:1}}",
+		"{{randfloat:1:2:3:4}}",
+		"{{randfloat:2:1}}",
+		"{{randfloat:x:1}}",
+		"{{randfloat:0:1:16}}",
+		"{{randfloat:0:1:-1}}",
+		"{{randfloat:0.001:0.009:2}}",
+		"{{randfloat:NaN:1}}",
+		"{{randfloat:-1e300:1e300}}",
+	} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_EncodingWrappers(t *testing.T) {
+	SetTemplateVars(map[string]string{"token": "s3cr3t"})
+	defer ClearTemplateVars()
+
+	res, err := InterpolateWithDelimiters(`{"a":"{{base64:var:token}}","b":"{{hex:var:token}}","c":"{{base64:hello world}}","d":"{{hex:randint:255:255}}"}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	want := `{"a":"czNjcjN0","b":"733363723374","c":"aGVsbG8gd29ybGQ=","d":"323535"}`
+	if string(res) != want {
+		t.Errorf("got %s, want %s", res, want)
+	}
+
+	// Binary content survives a JSON string as base64.
+	res, err = InterpolateWithDelimiters(`{"data":"<%base64:cbor%>"}`, "<%", "%>")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	var v struct{ Data []byte }
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", res, err)
+	}
+	var decoded map[string]any
+	if err := cbor.Unmarshal(v.Data, &decoded); err != nil {
+		t.Errorf("base64:cbor did not round-trip: %v", err)
+	}
+
+	dir := t.TempDir()
+	fp := filepath.Join(dir, "blob.bin")
+	if err := os.WriteFile(fp, []byte{0x00, 0xff}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SetAllowFileReads(true)
+	defer SetAllowFileReads(false)
+	if res, err := InterpolateWithDelimiters("{{hex:file:"+fp+"}}", "{{", "}}"); err != nil || string(res) != "00ff" {
+		t.Errorf("hex:file = %q, %v", res, err)
+	}
+
+	SetStrictTemplates(true)
+	defer SetStrictTemplates(false)
+	if _, err := InterpolateWithDelimiters("{{base64:json}} {{hex:uuid}}", "{{", "}}"); err != nil {
+		t.Errorf("strict mode rejected encoding wrappers: %v", err)
+	}
+	if _, err := InterpolateWithDelimiters("{{base64:jsn}}", "{{", "}}"); err == nil {
+		t.Error("strict mode should reject unknown wrapped placeholders")
+	}
+}
+
+func TestInterpolateWithDelimiters_CompressionWrappers(t *testing.T) {
+	SetTemplateVars(map[string]string{"doc": `{"a":1}`})
+	defer ClearTemplateVars()
+
+	res, err := InterpolateWithDelimiters("{{gzip:var:doc}}", "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(res))
+	if err != nil {
+		t.Fatalf("gzip: invalid stream: %v", err)
+	}
+	if out, err := io.ReadAll(zr); err != nil || string(out) != `{"a":1}` {
+		t.Errorf("gzip round-trip = %q, %v", out, err)
+	}
+
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+	res, err = InterpolateWithDelimiters("{{zstd:var:doc}}", "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if out, err := dec.DecodeAll(res, nil); err != nil || string(out) != `{"a":1}` {
+		t.Errorf("zstd round-trip = %q, %v", out, err)
+	}
+
+	// Chained wrappers apply from the innermost one.
+	res, err = InterpolateWithDelimiters(`{"body":"{{base64:zstd:var:doc}}","id":"{{hex:str:x}}"}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	var v struct {
+		Body []byte
+		ID   string
+	}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", res, err)
+	}
+	if out, err := dec.DecodeAll(v.Body, nil); err != nil || string(out) != `{"a":1}` {
+		t.Errorf("base64:zstd round-trip = %q, %v", out, err)
+	}
+	if v.ID != hex.EncodeToString([]byte(`"x"`)) {
+		t.Errorf("hex:str = %s", v.ID)
+	}
+
+	SetStrictTemplates(true)
+	defer SetStrictTemplates(false)
+	if _, err := InterpolateWithDelimiters("{{base64:gzip:json}}", "{{", "}}"); err != nil {
+		t.Errorf("strict mode rejected chained wrappers: %v", err)
+	}
+	if _, err := InterpolateWithDelimiters("{{base64:gzip:jsn}}", "{{", "}}"); err == nil {
+		t.Error("strict mode should reject unknown wrapped placeholders")
+	}
+}
+
+func TestInterpolateWithDelimiters_SizePlaceholder(t *testing.T) {
+	for _, n := range []int{0, 1, 4096} {
+		res, err := InterpolateWithDelimiters("{{size:"+strconv.Itoa(n)+"}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		if len(res) != n {
+			t.Errorf("size:%d produced %d bytes", n, len(res))
+		}
+		for _, c := range res {
+			if !strings.ContainsRune(sizeAlphabet, rune(c)) {
+				t.Fatalf("size:%d produced non-printable byte %#x", n, c)
+			}
+		}
+	}
+
+	res, err := InterpolateWithDelimiters(`{"pad":"{{size:100:text}}"}`, "{{", "}}")
+	if err != nil || len(res) != 110 || !json.Valid(res) {
+		t.Errorf("size in JSON = %q, %v", res, err)
+	}
+	res, err = InterpolateWithDelimiters("{{size:65536:binary}}", "{{", "}}")
+	if err != nil || len(res) != 65536 {
+		t.Fatalf("size:65536:binary = %d bytes, %v", len(res), err)
+	}
+	if !slices.ContainsFunc(res, func(b byte) bool { return b >= 0x80 }) {
+		t.Error("binary mode should produce bytes outside ASCII")
+	}
+
+	SeedRandom(7)
+	first, _ := InterpolateWithDelimiters("{{size:64}}", "{{", "}}")
+	SeedRandom(7)
+	if second, _ := InterpolateWithDelimiters("{{size:64}}", "{{", "}}"); string(second) != string(first) {
+		t.Errorf("seeded output differs: %q vs %q", first, second)
+	}
+
+	for _, bad := range []string{"{{size:x}}", "{{size:-1}}", "{{size:10:ascii}}", "{{size:" + strconv.Itoa(maxGeneratedSize+1) + "}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_BytesPlaceholder(t *testing.T) {
+	res, err := InterpolateWithDelimiters("{{bytes:32}}", "{{", "}}")
+	if err != nil || len(res) != 32 {
+		t.Fatalf("bytes:32 = %d bytes, %v", len(res), err)
+	}
+
+	res, err = InterpolateWithDelimiters(`{"key":"{{hex:bytes:16}}","iv":"{{base64:bytes:12}}"}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	var v struct {
+		Key string
+		IV  []byte
+	}
+	if err := json.Unmarshal(res, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", res, err)
+	}
+	if key, err := hex.DecodeString(v.Key); err != nil || len(key) != 16 || len(v.IV) != 12 {
+		t.Errorf("unexpected wrapped bytes: %s", res)
+	}
+
+	SeedRandom(3)
+	first, _ := InterpolateWithDelimiters("{{bytes:16}}", "{{", "}}")
+	SeedRandom(3)
+	if second, _ := InterpolateWithDelimiters("{{bytes:16}}", "{{", "}}"); string(second) != string(first) {
+		t.Errorf("seeded output differs: %x vs %x", first, second)
+	}
+
+	for _, bad := range []string{"{{bytes:}}", "{{bytes:-4}}", "{{bytes:1k}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_OneOfPlaceholder(t *testing.T) {
+	SeedRandom(11)
+	counts := map[string]int{}
+	for range 1000 {
+		res, err := InterpolateWithDelimiters("{{oneof:ok*8|warn*1|error*1}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+		}
+		counts[string(res)]++
+	}
+	if len(counts) != 3 || counts["ok"] < 700 || counts["warn"] == 0 || counts["error"] == 0 {
+		t.Errorf("unexpected distribution: %v", counts)
+	}
+
+	res, err := InterpolateWithDelimiters(`{"a":"{{oneof:x|y}}","b":"{{oneof:never*0|always}}"}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if s := string(res); !strings.HasSuffix(s, `"b":"always"}`) || (!strings.Contains(s, `"a":"x"`) && !strings.Contains(s, `"a":"y"`)) {
+		t.Errorf("unexpected result %s", res)
+	}
+
+	for _, bad := range []string{"{{oneof:a*x|b}}", "{{oneof:a*-1}}", "{{oneof:a*0|b*0}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_NamedCounters(t *testing.T) {
+	ResetCounters()
+	defer ResetCounters()
+
+	tmpl := "{{counter:orders:1000:10}} {{counter:items}} {{counter:orders}} {{counter:items}} {{counter}}"
+	res, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if string(res) != "1000 1 1010 2 1" {
+		t.Errorf("InterpolateWithDelimiters() = %q, want %q", res, "1000 1 1010 2 1")
+	}
+	if got := GenerateNamedCounter("down", 5, -2); got != 5 {
+		t.Errorf("GenerateNamedCounter() = %d, want 5", got)
+	}
+	if got := GenerateNamedCounter("down", 0, 0); got != 3 {
+		t.Errorf("GenerateNamedCounter() = %d, want 3", got)
+	}
+
+	ResetCounter("orders")
+	res, err = InterpolateWithDelimiters("{{counter:orders:7}} {{counter:items}}", "{{", "}}")
+	if err != nil || string(res) != "7 3" {
+		t.Errorf("after ResetCounter() = %q, %v", res, err)
+	}
+	ResetCounters()
+	if got := GenerateCounter(); got != 1 {
+		t.Errorf("GenerateCounter() after ResetCounters() = %d, want 1", got)
+	}
+
+	for _, bad := range []string{"{{counter:}}", "{{counter:a:x}}", "{{counter:a:1:x}}", "{{counter:a:1:2:3}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_EnvPlaceholder(t *testing.T) {
+	t.Setenv("EVENTKIT_BUILD", "ci-42")
+	defer SetAllowEnvReads(false)
+
+	SetAllowEnvReads(false)
+	if _, err := InterpolateWithDelimiters("{{env:EVENTKIT_BUILD}}", "{{", "}}"); err == nil {
+		t.Error("expected an error with env reads disabled")
+	}
+
+	SetAllowEnvReads(true)
+	res, err := InterpolateWithDelimiters(`{"build":"{{env:EVENTKIT_BUILD}}","unset":"{{env:EVENTKIT_UNSET_VAR}}","b64":"{{base64:env:EVENTKIT_BUILD}}"}`, "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	if want := `{"build":"ci-42","unset":"","b64":"Y2ktNDI="}`; string(res) != want {
+		t.Errorf("InterpolateWithDelimiters() = %s, want %s", res, want)
+	}
+	if _, err := InterpolateWithDelimiters("{{env:}}", "{{", "}}"); err == nil {
+		t.Error("expected an error for an empty name")
+	}
+}
+
+func TestInterpolateWithDelimiters_TimeFormats(t *testing.T) {
+	interp := func(tmpl string) string {
+		t.Helper()
+		res, err := InterpolateWithDelimiters(tmpl, "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateWithDelimiters(%q) error = %v", tmpl, err)
+		}
+		return string(res)
+	}
+
+	before := time.Now()
+	unix, err := strconv.ParseInt(interp("{{nowtime:unix}}"), 10, 64)
+	if err != nil || unix < before.Unix() || unix > time.Now().Unix() {
+		t.Errorf("nowtime:unix = %d, %v", unix, err)
+	}
+	if ms, err := strconv.ParseInt(interp("{{nowtime:unixmilli}}"), 10, 64); err != nil || ms < before.UnixMilli() {
+		t.Errorf("nowtime:unixmilli = %d, %v", ms, err)
+	}
+	if got := interp("{{nowtime:2006-01-02}}"); got != time.Now().Format(time.DateOnly) {
+		t.Errorf("nowtime:2006-01-02 = %q", got)
+	}
+	ahead, err := time.Parse(time.RFC3339Nano, interp("{{nowtime:+5m}}"))
+	if err != nil || ahead.Sub(before) < 5*time.Minute || ahead.Sub(before) > 6*time.Minute {
+		t.Errorf("nowtime:+5m = %v, %v", ahead, err)
+	}
+	back, err := time.Parse("2006-01-02 15:04:05", interp("{{nowtime:utc:-36h:2006-01-02 15:04:05}}"))
+	if err != nil || before.Sub(back) < 36*time.Hour-time.Second || before.Sub(back) > 37*time.Hour {
+		t.Errorf("nowtime:utc:-36h:... = %v, %v", back, err)
+	}
+	if got := interp("{{nowtime:utc:rfc3339}}"); !strings.HasSuffix(got, "Z") {
+		t.Errorf("nowtime:utc:rfc3339 = %q, want a UTC time", got)
+	}
+	if got := interp("{{nowtime:-0700}}"); len(got) != 5 {
+		t.Errorf("nowtime:-0700 = %q, want a zone offset", got)
+	}
+	if _, err := time.Parse(time.DateOnly, interp("{{datetime:date}}")); err != nil {
+		t.Errorf("datetime:date error = %v", err)
+	}
+	// The simple placeholders keep RFC3339Nano
+	if _, err := time.Parse(time.RFC3339Nano, interp("{{nowtime}}")); err != nil {
+		t.Errorf("nowtime error = %v", err)
+	}
+
+	for _, bad := range []string{"{{nowtime:unixms}}", "{{datetime:+1h:bogus}}"} {
+		if _, err := InterpolateWithDelimiters(bad, "{{", "}}"); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func TestInterpolateWithDelimiters_InsertedValuesVerbatim(t *testing.T) {
+	// Values taken from data sources are inserted as is, placeholders in them are not resolved
+	const value = "{{cmd:echo PWNED}} {{uuid}} {{var:v}}"
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("a\n"+value+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer ClearDataSource()
+	if err := LoadDataCSV(path, false); err != nil {
+		t.Fatalf("LoadDataCSV() error = %v", err)
+	}
+	ClearTemplateVars()
+	defer ClearTemplateVars()
+	SetTemplateVars(map[string]string{"v": value})
+
+	BeginMessage()
+	res, err := InterpolateWithDelimiters("{{raw:col:a}}|{{col:a}}|{{var:v}}|{{upper:var:v}}", "{{", "}}")
+	if err != nil {
+		t.Fatalf("InterpolateWithDelimiters() error = %v", err)
+	}
+	want := value + "|" + value + "|" + value + "|" + strings.ToUpper(value)
+	if string(res) != want {
+		t.Errorf("InterpolateWithDelimiters() = %q, want %q", res, want)
+	}
+}
+
+func TestLoadDataCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("id, status\n1,ok\n2,\"warn, low\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer ClearDataSource()
+
+	if err := LoadDataCSV(path, false); err != nil {
+		t.Fatalf("LoadDataCSV() error = %v", err)
+	}
+	for _, want := range []string{`1:"ok"`, `2:"warn, low"`} {
+		BeginMessage()
+		res, err := InterpolateWithDelimiters("{{col:id}}:{{str:col:status}}", "{{", "}}")
+		if err != nil || string(res) != want {
+			t.Errorf("row = %q, %v, want %q", res, err, want)
+		}
+	}
+	BeginMessage()
+	if _, err := InterpolateWithDelimiters("{{col:id}}", "{{", "}}"); !errors.Is(err, ErrDataExhausted) {
+		t.Errorf("expected ErrDataExhausted after the last row, got %v", err)
+	}
+
+	if err := LoadDataCSV(path, true); err != nil {
+		t.Fatalf("LoadDataCSV() error = %v", err)
+	}
+	var got []string
+	for range 3 {
+		BeginMessage()
+		res, _ := InterpolateWithDelimiters("{{col:id}}", "{{", "}}")
+		got = append(got, string(res))
+	}
+	if !slices.Equal(got, []string{"1", "2", "1"}) {
+		t.Errorf("looped rows = %v", got)
+	}
+	if _, err := InterpolateWithDelimiters("{{col:missing}}", "{{", "}}"); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+
+	ClearDataSource()
+	if _, err := InterpolateWithDelimiters("{{col:id}}", "{{", "}}"); err == nil {
+		t.Error("expected an error without a data source")
+	}
+	if err := os.WriteFile(path, []byte("id\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDataCSV(path, false); err == nil {
+		t.Error("expected an error for a file without rows")
+	}
+}
+
+func TestLoadPayloadSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	if err := os.WriteFile(path, []byte("{\"n\":1}\r\n\n{\"n\":2,\"id\":\"{{counter}}\"}\n{\"n\":3}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer ClearPayloadSource()
+
+	next := func() string {
+		t.Helper()
+		BeginMessage()
+		res, err := InterpolateMessage("{{json}}", "{{", "}}")
+		if err != nil {
+			t.Fatalf("InterpolateMessage() error = %v", err)
+		}
+		return string(res)
+	}
+
+	if err := LoadPayloadSource(path, PayloadSourceOptions{}); err != nil {
+		t.Fatalf("LoadPayloadSource() error = %v", err)
+	}
+	got := []string{next(), next(), next()}
+	if !slices.Equal(got, []string{`{"n":1}`, `{"n":2,"id":"{{counter}}"}`, `{"n":3}`}) {
+		t.Errorf("lines = %q", got)
+	}
+	BeginMessage()
+	if _, err := InterpolateMessage("", "{{", "}}"); !errors.Is(err, ErrDataExhausted) {
+		t.Errorf("expected ErrDataExhausted after the last line, got %v", err)
+	}
+
+	if err := LoadPayloadSource(path, PayloadSourceOptions{Loop: true, Template: true}); err != nil {
+		t.Fatalf("LoadPayloadSource() error = %v", err)
+	}
+	if got := []string{next(), next(), next(), next()}; got[3] != `{"n":1}` || strings.Contains(got[1], "{{") {
+		t.Errorf("looped templated lines = %q", got)
+	}
+
+	if err := LoadPayloadSource(path, PayloadSourceOptions{Loop: true, Shuffle: true}); err != nil {
+		t.Fatalf("LoadPayloadSource() error = %v", err)
+	}
+	for pass := range 2 {
+		got := []string{next(), next(), next()}
+		slices.Sort(got)
+		if !slices.Equal(got, []string{`{"n":1}`, `{"n":2,"id":"{{counter}}"}`, `{"n":3}`}) {
+			t.Errorf("shuffled pass %d = %q, want every line once", pass, got)
+		}
+	}
+
+	ClearPayloadSource()
+	if res, err := InterpolateMessage("plain", "{{", "}}"); err != nil || string(res) != "plain" {
+		t.Errorf("InterpolateMessage() without source = %q, %v", res, err)
+	}
+	if err := os.WriteFile(path, []byte("\n  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadPayloadSource(path, PayloadSourceOptions{}); err == nil {
+		t.Error("expected an error for a file without lines")
+	}
+}
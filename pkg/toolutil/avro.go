@@ -0,0 +1,107 @@
+package toolutil
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hamba/avro/v2"
+	"github.com/hamba/avro/v2/registry"
+)
+
+// avroRegistryTimeout bounds a schema lookup in the registry of --avro-registry.
+const avroRegistryTimeout = 5 * time.Second
+
+// confluentHeaderSize is the length of the Confluent wire-format header: a zero magic byte
+// followed by the big-endian schema ID.
+const confluentHeaderSize = 5
+
+// AvroDecoder decodes Avro binary bodies to JSON for display, with a schema file for plain
+// Avro bodies and/or a schema registry for Confluent wire-format bodies.
+type AvroDecoder struct {
+	schema   avro.Schema
+	registry *registry.Client
+}
+
+// LoadAvroDecoder returns a decoder using the .avsc schema file at schemaPath (plain Avro
+// binary bodies) and the Confluent-compatible schema registry at registryURL (wire-format
+// bodies, looked up by schema ID). Either may be empty, not both.
+func LoadAvroDecoder(schemaPath, registryURL string) (*AvroDecoder, error) {
+	if schemaPath == "" && registryURL == "" {
+		return nil, fmt.Errorf("an Avro schema file or registry URL is required")
+	}
+	d := &AvroDecoder{}
+	if schemaPath != "" {
+		sch, err := avro.ParseFiles(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Avro schema %s: %w", schemaPath, err)
+		}
+		d.schema = sch
+	}
+	if registryURL != "" {
+		client, err := registry.NewClient(registryURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema registry URL %q: %w", registryURL, err)
+		}
+		d.registry = client
+	}
+	return d, nil
+}
+
+// Decode converts an Avro body to JSON. With a registry, bodies in the Confluent wire format
+// are decoded with the schema of their ID; other bodies need the schema file.
+func (d *AvroDecoder) Decode(body []byte) ([]byte, error) {
+	sch, data := d.schema, body
+	if d.registry != nil && len(body) > confluentHeaderSize && body[0] == 0 {
+		id := int(binary.BigEndian.Uint32(body[1:confluentHeaderSize]))
+		ctx, cancel := context.WithTimeout(context.Background(), avroRegistryTimeout)
+		defer cancel()
+		var err error
+		if sch, err = d.registry.GetSchema(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to get schema %d: %w", id, err)
+		}
+		data = body[confluentHeaderSize:]
+	}
+	if sch == nil {
+		return nil, fmt.Errorf("body is not in the Confluent wire format")
+	}
+	var v any
+	if err := avro.Unmarshal(sch, data, &v); err != nil {
+		return nil, fmt.Errorf("invalid Avro body: %w", err)
+	}
+	return json.Marshal(v)
+}
+
+// Decoder of --avro-schema/--avro-registry, see SetupServe; nil leaves bodies as received.
+var (
+	avroDecoderMutex = sync.RWMutex{}
+	avroDecoder      *AvroDecoder
+)
+
+// setAvroDecoder sets the decoder of received Avro bodies (nil disables decoding).
+func setAvroDecoder(d *AvroDecoder) {
+	avroDecoderMutex.Lock()
+	defer avroDecoderMutex.Unlock()
+	avroDecoder = d
+}
+
+// decodeAvroBody converts a received Avro body to JSON with the --avro-schema or
+// --avro-registry decoder. Bodies declared as a textual type (see binaryBodyMIME), and bodies
+// that do not decode, are returned unchanged.
+func decodeAvroBody(body []byte, mime string) ([]byte, string) {
+	avroDecoderMutex.RLock()
+	d := avroDecoder
+	avroDecoderMutex.RUnlock()
+	if d == nil || len(body) == 0 || !binaryBodyMIME(mime) {
+		return body, mime
+	}
+	b, err := d.Decode(body)
+	if err != nil {
+		Logger().Debug("Avro decoding failed", "error", err)
+		return body, mime
+	}
+	return b, CTJSON
+}
@@ -0,0 +1,121 @@
+package toolutil
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+)
+
+const testAvroSchema = `{
+	"type": "record",
+	"name": "Order",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "total", "type": "double"},
+		{"name": "note", "type": ["null", "string"], "default": null}
+	]
+}`
+
+type testAvroOrder struct {
+	ID    string  `avro:"id"`
+	Total float64 `avro:"total"`
+	Note  *string `avro:"note"`
+}
+
+func encodeTestAvro(t *testing.T) []byte {
+	t.Helper()
+	b, err := avro.Marshal(avro.MustParse(testAvroSchema), testAvroOrder{ID: "order-1", Total: 42.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestAvroDecoder_SchemaFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.avsc")
+	if err := os.WriteFile(path, []byte(testAvroSchema), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadAvroDecoder(path, "")
+	if err != nil {
+		t.Fatalf("LoadAvroDecoder() error = %v", err)
+	}
+	got, err := d.Decode(encodeTestAvro(t))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := `{"id":"order-1","note":null,"total":42.5}`; string(got) != want {
+		t.Errorf("Decode() = %s, want %s", got, want)
+	}
+	if _, err := d.Decode([]byte{0x10}); err == nil {
+		t.Error("Decode() expected an error for a truncated body")
+	}
+
+	if _, err := LoadAvroDecoder("", ""); err == nil {
+		t.Error("LoadAvroDecoder() expected an error without a schema or registry")
+	}
+	if _, err := LoadAvroDecoder(filepath.Join(t.TempDir(), "missing.avsc"), ""); err == nil {
+		t.Error("LoadAvroDecoder() expected an error for a missing schema")
+	}
+}
+
+func TestAvroDecoder_Registry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/schemas/ids/7" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": testAvroSchema})
+	}))
+	defer srv.Close()
+
+	d, err := LoadAvroDecoder("", srv.URL)
+	if err != nil {
+		t.Fatalf("LoadAvroDecoder() error = %v", err)
+	}
+	body := append([]byte{0, 0, 0, 0, 7}, encodeTestAvro(t)...)
+	got, err := d.Decode(body)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !strings.Contains(string(got), `"id":"order-1"`) {
+		t.Errorf("Decode() = %s", got)
+	}
+	binary.BigEndian.PutUint32(body[1:5], 8)
+	if _, err := d.Decode(body); err == nil {
+		t.Error("Decode() expected an error for an unknown schema ID")
+	}
+	if _, err := d.Decode(encodeTestAvro(t)); err == nil {
+		t.Error("Decode() expected an error for a body without the wire-format header")
+	}
+}
+
+func TestSetupServe_Avro(t *testing.T) {
+	out, _ := captureStreams(t)
+	path := filepath.Join(t.TempDir(), "order.avsc")
+	if err := os.WriteFile(path, []byte(testAvroSchema), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SetupServe(&ServeOptions{AvroSchema: filepath.Join(t.TempDir(), "missing.avsc")}); err == nil {
+		t.Error("SetupServe() expected error for a missing --avro-schema")
+	}
+	cleanup, err := SetupServe(&ServeOptions{AvroSchema: path})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+	defer cleanup()
+
+	PrintColoredMessage("Event", nil, encodeTestAvro(t), "")
+	PrintColoredMessage("Event", nil, []byte("order-2"), CTText)
+	if got := out.String(); !strings.Contains(got, `"id": "order-1"`) || !strings.Contains(got, "order-2") {
+		t.Errorf("unexpected decoded output:\n%s", got)
+	}
+}
@@ -0,0 +1,194 @@
+package toolutil
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborDiagMode renders CBOR in extended diagnostic notation (EDN, RFC 8610 appendix G), with
+// each item of a CBOR sequence separated by a comma.
+var cborDiagMode, _ = cbor.DiagOptions{CBORSequence: true}.DiagMode()
+
+// EDN syntax colors, matching the colorjson defaults of JSON bodies; tags are blue.
+var (
+	ednKeyColor    = color.New(color.FgWhite)
+	ednStringColor = color.New(color.FgGreen)
+	ednBoolColor   = color.New(color.FgYellow)
+	ednNumberColor = color.New(color.FgCyan)
+	ednNullColor   = color.New(color.FgMagenta)
+	ednTagColor    = color.New(color.FgBlue)
+)
+
+// ednIndent is the indentation of nested arrays and maps.
+const ednIndent = "  "
+
+// prettyCBOR renders a CBOR body (or CBOR sequence) in diagnostic notation, indenting arrays
+// and maps like JSON bodies. Unlike a JSON conversion it keeps every CBOR detail: byte strings
+// (h'...'), tags (1(1700000000)), non-string map keys, undefined and simple values.
+func prettyCBOR(body []byte, colored bool) ([]byte, bool) {
+	edn, err := cborDiagMode.Diagnose(body)
+	if err != nil {
+		return nil, false
+	}
+	return formatEDN(edn, colored), true
+}
+
+// ednToken is a lexical element of diagnostic notation.
+type ednToken struct {
+	text string
+	// punct is one of [ ] { } ( ) , : _ for punctuation, 0 for values.
+	punct byte
+}
+
+// ednDelimiters end the unquoted values of diagnostic notation.
+const ednDelimiters = " \t\n,:[]{}()"
+
+// tokenizeEDN splits diagnostic notation, as produced by cbor.Diagnose, into tokens. A tag
+// number or simple keyword is joined with the following "(", e.g. "1(" or "simple(".
+func tokenizeEDN(edn string) []ednToken {
+	var tokens []ednToken
+	for i := 0; i < len(edn); {
+		c := edn[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.IndexByte("[]{}(),:_", c) >= 0:
+			tokens = append(tokens, ednToken{text: edn[i : i+1], punct: c})
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(edn) && edn[j] != '"' {
+				if edn[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(edn))
+			tokens = append(tokens, ednToken{text: edn[i:j]})
+			i = j
+		default:
+			j := i
+			for j < len(edn) && strings.IndexByte(ednDelimiters, edn[j]) < 0 && edn[j] != '\'' {
+				j++
+			}
+			switch {
+			case j < len(edn) && edn[j] == '\'':
+				// Byte string with an encoding prefix: h'0102', b64'AQI'
+				end := strings.IndexByte(edn[j+1:], '\'')
+				if end < 0 {
+					j = len(edn)
+				} else {
+					j += end + 2
+				}
+			case j < len(edn) && edn[j] == '(':
+				j++
+			}
+			tokens = append(tokens, ednToken{text: edn[i:j]})
+			i = j
+		}
+	}
+	return tokens
+}
+
+// formatEDN indents diagnostic notation: array and map members go on their own lines, the
+// content of tags and indefinite-length strings stays inline, and the items of a CBOR
+// sequence are separated by a line.
+func formatEDN(edn string, colored bool) []byte {
+	paint := func(c *color.Color, s string) string {
+		if !colored {
+			return s
+		}
+		return c.Sprint(s)
+	}
+	var buf bytes.Buffer
+	tokens := tokenizeEDN(edn)
+	// Open brackets: true for indented arrays and maps, false for inline parentheses
+	var blocks []bool
+	newline := func() {
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(ednIndent, len(blocks)))
+	}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		next := func() byte {
+			if i+1 < len(tokens) {
+				return tokens[i+1].punct
+			}
+			return 0
+		}
+		switch {
+		case t.punct == '[' || t.punct == '{' || t.punct == '(' || strings.HasSuffix(t.text, "("):
+			inline := t.punct != '[' && t.punct != '{'
+			if t.punct == 0 {
+				buf.WriteString(paint(ednTagColor, strings.TrimSuffix(t.text, "(")) + "(")
+			} else {
+				buf.WriteString(t.text)
+			}
+			if next() == '_' {
+				buf.WriteByte('_')
+				if inline {
+					buf.WriteByte(' ')
+				}
+				i++
+			}
+			if n := next(); n == ']' || n == '}' || n == ')' {
+				buf.WriteString(tokens[i+1].text)
+				i++
+				continue
+			}
+			blocks = append(blocks, !inline)
+			if !inline {
+				newline()
+			}
+		case t.punct == ']' || t.punct == '}' || t.punct == ')':
+			block := false
+			if len(blocks) > 0 {
+				block = blocks[len(blocks)-1]
+				blocks = blocks[:len(blocks)-1]
+			}
+			if block {
+				newline()
+			}
+			buf.WriteString(t.text)
+		case t.punct == ',':
+			buf.WriteByte(',')
+			switch {
+			case len(blocks) == 0:
+				buf.WriteByte('\n')
+			case blocks[len(blocks)-1]:
+				newline()
+			default:
+				buf.WriteByte(' ')
+			}
+		case t.punct == ':':
+			buf.WriteString(": ")
+		case t.punct == '_':
+			buf.WriteString("_ ")
+		default:
+			buf.WriteString(paint(ednValueColor(t.text, next() == ':'), t.text))
+		}
+	}
+	return buf.Bytes()
+}
+
+// ednValueColor returns the color of a value token; key tells whether it is a map key.
+func ednValueColor(text string, key bool) *color.Color {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		if key {
+			return ednKeyColor
+		}
+		return ednStringColor
+	case strings.HasSuffix(text, "'"):
+		return ednStringColor
+	case text == "true" || text == "false":
+		return ednBoolColor
+	case text == "null" || text == "undefined":
+		return ednNullColor
+	default:
+		return ednNumberColor
+	}
+}
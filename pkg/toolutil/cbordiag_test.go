@@ -0,0 +1,47 @@
+package toolutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestPrettyCBOR(t *testing.T) {
+	tagged, err := cbor.Marshal(cbor.Tag{Number: 1, Content: uint64(1700000000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		body []byte
+		want string
+	}{
+		{"map", mustEncodeCBOR(t, map[string]any{"name": "test"}), "{\n  \"name\": \"test\"\n}"},
+		{"nested", mustEncodeCBOR(t, map[string]any{"a": []any{true, nil, 1.5, map[string]any{}}}), "{\n  \"a\": [\n    true,\n    null,\n    1.5,\n    {}\n  ]\n}"},
+		{"integer keys", mustEncodeCBOR(t, map[int]string{1: "a"}), "{\n  1: \"a\"\n}"},
+		{"byte string", mustEncodeCBOR(t, []byte{0x01, 0xff}), "h'01ff'"},
+		{"tag", tagged, "1(1700000000)"},
+		{"tagged in array", mustEncodeCBOR(t, []any{cbor.Tag{Number: 32, Content: "https://example.com"}}), "[\n  32(\"https://example.com\")\n]"},
+		{"indefinite array", []byte{0x9f, 0x01, 0x02, 0xff}, "[_\n  1,\n  2\n]"},
+		{"indefinite string", []byte{0x7f, 0x61, 0x61, 0x61, 0x62, 0xff}, `(_ "a", "b")`},
+		{"escaped string", mustEncodeCBOR(t, `a "quoted", [string]`), `"a \"quoted\", [string]"`},
+		{"undefined", []byte{0xf7}, "undefined"},
+		{"sequence", append(mustEncodeCBOR(t, 1), mustEncodeCBOR(t, "two")...), "1,\n\"two\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := prettyCBOR(tt.body, false)
+			if !ok {
+				t.Fatalf("prettyCBOR(%x) failed", tt.body)
+			}
+			if !strings.Contains(string(got), tt.want) {
+				t.Errorf("prettyCBOR(%x) =\n%s\nwant\n%s", tt.body, got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := prettyCBOR([]byte{0x82, 0x01}, false); ok {
+		t.Error("prettyCBOR() expected to fail on truncated CBOR")
+	}
+}
@@ -0,0 +1,141 @@
+package toolutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	"github.com/spf13/cobra"
+)
+
+// CTCloudEventsJSON is the content type of structured-mode CloudEvents.
+const CTCloudEventsJSON = "application/cloudevents+json"
+
+// CloudEvents content modes, see AddCloudEventsFlags.
+const (
+	CloudEventsStructured = "structured"
+	CloudEventsBinary     = "binary"
+)
+
+// CloudEventsOptions holds the --cloudevents settings of send commands. ID, Source, Type and
+// Subject are templates, interpolated for every message.
+type CloudEventsOptions struct {
+	Mode    string
+	ID      string
+	Source  string
+	Type    string
+	Subject string
+	// headerPrefix names the binary-mode attribute headers, set by Apply.
+	headerPrefix string
+}
+
+// AddCloudEventsFlags adds --cloudevents, --ce-id, --ce-source, --ce-type and --ce-subject.
+func AddCloudEventsFlags(cmd *cobra.Command, opts *CloudEventsOptions) {
+	cmd.Flags().StringVar(&opts.Mode, "cloudevents", "", "Wrap payloads as CloudEvents 1.0: structured (JSON envelope) or binary (ce-* headers, where the tool sends headers)")
+	cmd.Flags().StringVar(&opts.ID, "ce-id", "{{uuid}}", "CloudEvents id attribute (supports template placeholders)")
+	cmd.Flags().StringVar(&opts.Source, "ce-source", "/eventkit", "CloudEvents source attribute (supports template placeholders)")
+	cmd.Flags().StringVar(&opts.Type, "ce-type", "com.eventkit.test", "CloudEvents type attribute (supports template placeholders)")
+	cmd.Flags().StringVar(&opts.Subject, "ce-subject", "", "Optional CloudEvents subject attribute (supports template placeholders)")
+}
+
+// Active CloudEvents settings of BuildPayloadWithDelimiters, and the binary-mode attributes
+// of the message it built last.
+var (
+	cloudEvents      CloudEventsOptions
+	cloudEventAttrs  map[string]string
+	cloudEventsMutex = sync.Mutex{}
+)
+
+// Apply enables the options for the payloads built by BuildPayloadWithDelimiters.
+// headerPrefix names the attribute headers of binary mode as the protocol binding requires
+// (e.g. "ce-" for HTTP and NATS, "ce_" for Kafka); tools without message headers pass "" and
+// only support structured mode.
+func (o CloudEventsOptions) Apply(headerPrefix string) error {
+	switch o.Mode {
+	case "", CloudEventsStructured:
+	case CloudEventsBinary:
+		if headerPrefix == "" {
+			return fmt.Errorf("--cloudevents binary needs message headers, use structured with this tool")
+		}
+	default:
+		return fmt.Errorf("invalid --cloudevents %q: expected %s or %s", o.Mode, CloudEventsStructured, CloudEventsBinary)
+	}
+	o.headerPrefix = headerPrefix
+	cloudEventsMutex.Lock()
+	defer cloudEventsMutex.Unlock()
+	cloudEvents, cloudEventAttrs = o, nil
+	return nil
+}
+
+// CloudEventHeaders returns headers plus the binary-mode CloudEvents attributes of the
+// message last built by BuildPayloadWithDelimiters, or headers itself outside binary mode.
+func CloudEventHeaders(headers map[string]string) map[string]string {
+	cloudEventsMutex.Lock()
+	defer cloudEventsMutex.Unlock()
+	if cloudEventAttrs == nil {
+		return headers
+	}
+	res := maps.Clone(headers)
+	if res == nil {
+		res = map[string]string{}
+	}
+	for name, val := range cloudEventAttrs {
+		res[cloudEvents.headerPrefix+name] = val
+	}
+	return res
+}
+
+// applyCloudEvents wraps a built payload in a structured-mode envelope, or records its
+// binary-mode attributes for CloudEventHeaders, returning the payload and its content type.
+func applyCloudEvents(data []byte, ct string, openDelim string, closeDelim string) ([]byte, string, error) {
+	cloudEventsMutex.Lock()
+	defer cloudEventsMutex.Unlock()
+	if cloudEvents.Mode == "" {
+		return data, ct, nil
+	}
+	attrs := map[string]string{"specversion": "1.0", "time": time.Now().UTC().Format(time.RFC3339Nano)}
+	for name, tmpl := range map[string]string{"id": cloudEvents.ID, "source": cloudEvents.Source, "type": cloudEvents.Type, "subject": cloudEvents.Subject} {
+		val, err := testpayload.InterpolateWithDelimiters(tmpl, openDelim, closeDelim)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to interpolate CloudEvents %s: %w", name, err)
+		}
+		if len(val) > 0 {
+			attrs[name] = string(val)
+		}
+	}
+	for _, name := range []string{"id", "source", "type"} {
+		if attrs[name] == "" {
+			return nil, "", fmt.Errorf("CloudEvents %s attribute is empty", name)
+		}
+	}
+	if cloudEvents.Mode == CloudEventsBinary {
+		cloudEventAttrs = attrs
+		return data, ct, nil
+	}
+
+	envelope := make(map[string]any, len(attrs)+2)
+	for name, val := range attrs {
+		envelope[name] = val
+	}
+	envelope["datacontenttype"] = ct
+	mediaType, _, _ := strings.Cut(ct, ";")
+	switch {
+	case (mediaType == CTJSON || strings.HasSuffix(mediaType, "+json")) && json.Valid(data):
+		envelope["data"] = json.RawMessage(data)
+	case utf8.Valid(data) && mediaType != CTCBOR && mediaType != CTProtobuf:
+		envelope["data"] = string(data)
+	default:
+		envelope["data_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+	b, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode CloudEvent: %w", err)
+	}
+	return b, CTCloudEventsJSON, nil
+}
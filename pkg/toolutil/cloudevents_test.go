@@ -0,0 +1,114 @@
+package toolutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCloudEventsStructured(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts CloudEventsOptions
+	AddCloudEventsFlags(cmd, &opts)
+	if err := cmd.Flags().Parse([]string{"--cloudevents", "structured", "--ce-type", "com.example.{{oneof:created}}", "--ce-subject", "orders"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := opts.Apply(""); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	defer func() { _ = CloudEventsOptions{}.Apply("") }()
+
+	var ids []string
+	for range 2 {
+		b, ct, err := BuildPayload(`{"n":1}`, CTJSON)
+		if err != nil || ct != CTCloudEventsJSON {
+			t.Fatalf("BuildPayload() = %q, %v", ct, err)
+		}
+		var ev map[string]any
+		if err := json.Unmarshal(b, &ev); err != nil {
+			t.Fatalf("invalid envelope %s: %v", b, err)
+		}
+		if ev["specversion"] != "1.0" || ev["source"] != "/eventkit" || ev["type"] != "com.example.created" ||
+			ev["subject"] != "orders" || ev["datacontenttype"] != CTJSON || ev["time"] == nil {
+			t.Errorf("unexpected envelope %s", b)
+		}
+		if data, ok := ev["data"].(map[string]any); !ok || data["n"] != float64(1) {
+			t.Errorf("JSON data not embedded: %s", b)
+		}
+		ids = append(ids, ev["id"].(string))
+	}
+	if ids[0] == ids[1] || len(ids[0]) != 36 {
+		t.Errorf("ids should be fresh UUIDs, got %v", ids)
+	}
+
+	b, _, err := BuildPayload("plain", CTText)
+	if err != nil || !json.Valid(b) {
+		t.Fatalf("BuildPayload() = %s, %v", b, err)
+	}
+	var ev map[string]any
+	_ = json.Unmarshal(b, &ev)
+	if ev["data"] != "plain" {
+		t.Errorf("text data = %v", ev["data"])
+	}
+	b, _, err = BuildPayload("{{cbor}}", CTCBOR)
+	if err != nil {
+		t.Fatalf("BuildPayload() error = %v", err)
+	}
+	ev = nil
+	_ = json.Unmarshal(b, &ev)
+	if ev["data_base64"] == nil || ev["data"] != nil {
+		t.Errorf("binary data should be base64: %s", b)
+	}
+}
+
+func TestCloudEventsBinary(t *testing.T) {
+	opts := CloudEventsOptions{Mode: CloudEventsBinary, ID: "evt-{{counter}}", Source: "/src", Type: "t"}
+	if err := opts.Apply(""); err == nil {
+		t.Error("expected an error for binary mode without headers")
+	}
+	if err := opts.Apply("ce-"); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	defer func() { _ = CloudEventsOptions{}.Apply("") }()
+
+	headers := map[string]string{"x-app": "demo"}
+	if got := CloudEventHeaders(headers); len(got) != 1 {
+		t.Errorf("headers before the first message = %v", got)
+	}
+	b, ct, err := BuildPayload(`{"n":1}`, CTJSON)
+	if err != nil || string(b) != `{"n":1}` || ct != CTJSON {
+		t.Fatalf("BuildPayload() = %s, %q, %v", b, ct, err)
+	}
+	got := CloudEventHeaders(headers)
+	if got["x-app"] != "demo" || got["ce-specversion"] != "1.0" || got["ce-source"] != "/src" || got["ce-type"] != "t" || got["ce-time"] == "" {
+		t.Errorf("binary headers = %v", got)
+	}
+	if len(headers) != 1 {
+		t.Errorf("CloudEventHeaders() modified its argument: %v", headers)
+	}
+
+	if err := (CloudEventsOptions{Mode: "batch"}).Apply("ce-"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestCloudEventsRepeatedPayload(t *testing.T) {
+	opts := CloudEventsOptions{Mode: CloudEventsStructured, Source: "/src", Type: "t", ID: "evt-{{counter:ce:1:1}}"}
+	if err := opts.Apply(""); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	defer func() { _ = CloudEventsOptions{}.Apply("") }()
+
+	b, ct, err := BuildRepeatedPayloadWithDelimiters(3, ",", "part", CTText, "{{", "}}")
+	if err != nil || ct != CTCloudEventsJSON {
+		t.Fatalf("BuildRepeatedPayloadWithDelimiters() = %q, %v", ct, err)
+	}
+	var ev map[string]any
+	if err := json.Unmarshal(b, &ev); err != nil {
+		t.Fatalf("repeated body is not a single envelope %s: %v", b, err)
+	}
+	if ev["data"] != "part,part,part" || ev["id"] != "evt-1" {
+		t.Errorf("unexpected envelope %s", b)
+	}
+}
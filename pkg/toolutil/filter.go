@@ -0,0 +1,103 @@
+package toolutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itchyny/gojq"
+)
+
+// filterTimeout bounds the evaluation of --filter on a message, guarding against
+// expressions that never yield, such as range(1e18) | select(false).
+const filterTimeout = time.Second
+
+// Compiled --filter expression, see SetupServe; nil prints every message.
+var (
+	filterMutex   = sync.RWMutex{}
+	messageFilter *gojq.Code
+)
+
+// CompileFilter compiles a jq expression for --filter.
+func CompileFilter(expr string) (*gojq.Code, error) {
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return code, nil
+}
+
+// setFilter sets the filter of printed messages (nil disables filtering).
+func setFilter(code *gojq.Code) {
+	filterMutex.Lock()
+	defer filterMutex.Unlock()
+	messageFilter = code
+}
+
+// matchesFilter reports whether a received message passes --filter: the expression, run on
+// the decoded JSON or CBOR body (other UTF-8 bodies as a string), yields a value other than
+// false or null. Binary bodies and evaluation errors never match.
+func matchesFilter(body []byte, mime string) bool {
+	filterMutex.RLock()
+	code := messageFilter
+	filterMutex.RUnlock()
+	if code == nil {
+		return true
+	}
+	input, ok := filterInput(body, mime)
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), filterTimeout)
+	defer cancel()
+	iter := code.RunWithContext(ctx, input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			return false
+		}
+		switch v := v.(type) {
+		case error:
+			Logger().Debug("Filter evaluation failed", "error", v)
+			return false
+		case nil:
+		case bool:
+			if v {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// filterInput decodes a body into the JSON values the filter runs on.
+func filterInput(body []byte, mime string) (any, bool) {
+	for _, m := range []string{mime, GuessMIME(body)} {
+		obj, ok := decodeStructuredBody(m, body)
+		if !ok {
+			continue
+		}
+		// Normalize to the types gojq expects, CBOR decodes to sized integers
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return nil, false
+		}
+		var v any
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, false
+		}
+		return v, true
+	}
+	if utf8.Valid(body) {
+		return string(body), true
+	}
+	return nil, false
+}
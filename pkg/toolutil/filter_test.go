@@ -0,0 +1,73 @@
+package toolutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	defer setFilter(nil)
+	cborBody, err := cbor.Marshal(map[string]any{"level": "error", "code": uint64(500)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		filter string
+		body   []byte
+		mime   string
+		want   bool
+	}{
+		{`.level == "error"`, []byte(`{"level":"error"}`), CTJSON, true},
+		{`.level == "error"`, []byte(`{"level":"info"}`), CTJSON, false},
+		{`select(.temp > 30)`, []byte(`{"temp":31.5}`), "", true},
+		{`.items[] | select(.id == 2)`, []byte(`{"items":[{"id":1},{"id":2}]}`), CTJSON, true},
+		{`.missing`, []byte(`{"a":1}`), CTJSON, false},
+		{`.code >= 500`, cborBody, CTCBOR, true},
+		{`test("timeout")`, []byte("connection timeout"), CTText, true},
+		{`.a`, []byte("plain text"), CTText, false},
+		{`true`, []byte{0xff, 0xfe}, "application/octet-stream", false},
+		{`range(1e18) | select(false)`, []byte(`{}`), CTJSON, false},
+	}
+	for _, tt := range tests {
+		code, err := CompileFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("CompileFilter(%s) error = %v", tt.filter, err)
+		}
+		setFilter(code)
+		if got := matchesFilter(tt.body, tt.mime); got != tt.want {
+			t.Errorf("filter %s on %q = %v, want %v", tt.filter, tt.body, got, tt.want)
+		}
+	}
+
+	if _, err := CompileFilter(".a |"); err == nil {
+		t.Error("CompileFilter() expected an error for an invalid expression")
+	}
+	setFilter(nil)
+	if !matchesFilter([]byte{0xff}, "") {
+		t.Error("every message should match without a filter")
+	}
+}
+
+func TestSetupServe_Filter(t *testing.T) {
+	out, _ := captureStreams(t)
+	if _, err := SetupServe(&ServeOptions{Filter: ".a ||"}); err == nil {
+		t.Error("SetupServe() expected error for an invalid --filter")
+	}
+	cleanup, err := SetupServe(&ServeOptions{Filter: `.level == "error"`})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+	defer cleanup()
+
+	PrintColoredMessage("Event", nil, []byte(`{"level":"info","id":"skipped"}`), CTJSON)
+	PrintColoredMessage("Event", nil, []byte(`{"level":"error","id":"kept"}`), CTJSON)
+	if strings.Contains(out.String(), "skipped") || !strings.Contains(out.String(), "kept") {
+		t.Errorf("unexpected filtered output:\n%s", out.String())
+	}
+	if n := ReceivedCount(); n != 1 {
+		t.Errorf("ReceivedCount() = %d, want 1", n)
+	}
+}
@@ -0,0 +1,59 @@
+package toolutil
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// GlobalOptions holds the output settings shared by every command of a tool.
+type GlobalOptions struct {
+	// Quiet hides status lines (info, success, headers) and info logs; warnings, errors and
+	// message content are still printed.
+	Quiet bool
+	// LogLevel is the minimum level of Logger() and slog records: debug, info, warn or error.
+	LogLevel string
+	// NoColor disables colored output, as does a non-empty NO_COLOR environment variable.
+	NoColor bool
+}
+
+// logLevel is the level of the loggers returned by Logger(), see GlobalOptions.LogLevel.
+var logLevel = new(slog.LevelVar)
+
+// quiet suppresses the status lines of PrintInfo, PrintSuccess, PrintHeader and PrintKeyValue.
+var quiet bool
+
+// AddGlobalFlags adds the --quiet, --log-level and --no-color flags to root and its
+// subcommands, applying them before any command runs.
+func AddGlobalFlags(root *cobra.Command) {
+	opts := &GlobalOptions{}
+	root.PersistentFlags().BoolVarP(&opts.Quiet, "quiet", "q", false, "Hide status lines and info logs, keeping warnings, errors and message content")
+	root.PersistentFlags().StringVar(&opts.LogLevel, "log-level", "", "Minimum log level: debug, info, warn or error (default info, warn with --quiet)")
+	root.PersistentFlags().BoolVar(&opts.NoColor, "no-color", false, "Disable colored output (also disabled by the NO_COLOR environment variable)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return opts.Apply()
+	}
+}
+
+// Apply configures the log level, quiet mode and colors of the process.
+func (o GlobalOptions) Apply() error {
+	level := slog.LevelInfo
+	if o.Quiet {
+		level = slog.LevelWarn
+	}
+	if o.LogLevel != "" {
+		if err := level.UnmarshalText([]byte(o.LogLevel)); err != nil {
+			return fmt.Errorf("invalid --log-level %q: expected debug, info, warn or error", o.LogLevel)
+		}
+	}
+	logLevel.Set(level)
+	slog.SetDefault(Logger())
+	quiet = o.Quiet
+	if o.NoColor || os.Getenv("NO_COLOR") != "" {
+		color.NoColor = true
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package toolutil
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+func TestGlobalOptions_Apply(t *testing.T) {
+	prevNoColor, prevDefault := color.NoColor, slog.Default()
+	t.Cleanup(func() {
+		color.NoColor = prevNoColor
+		slog.SetDefault(prevDefault)
+		_ = GlobalOptions{}.Apply()
+	})
+	_, errOut := captureStreams(t)
+
+	if err := (GlobalOptions{LogLevel: "verbose"}).Apply(); err == nil {
+		t.Error("expected an error for an unknown log level")
+	}
+
+	if err := (GlobalOptions{Quiet: true}).Apply(); err != nil {
+		t.Fatal(err)
+	}
+	PrintInfo("info line")
+	PrintSuccess("success line")
+	PrintWarning("warning line")
+	Logger().Info("info log")
+	slog.Error("error log")
+	out := errOut.String()
+	for _, hidden := range []string{"info line", "success line", "info log"} {
+		if strings.Contains(out, hidden) {
+			t.Errorf("--quiet printed %q:\n%s", hidden, out)
+		}
+	}
+	for _, shown := range []string{"warning line", "error log"} {
+		if !strings.Contains(out, shown) {
+			t.Errorf("--quiet hid %q:\n%s", shown, out)
+		}
+	}
+
+	errOut.Reset()
+	if err := (GlobalOptions{Quiet: true, LogLevel: "debug"}).Apply(); err != nil {
+		t.Fatal(err)
+	}
+	Logger().Debug("debug log")
+	if !strings.Contains(errOut.String(), "debug log") {
+		t.Errorf("--log-level debug hid debug logs:\n%s", errOut.String())
+	}
+
+	color.NoColor = false
+	t.Setenv("NO_COLOR", "1")
+	if err := (GlobalOptions{}).Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if !color.NoColor {
+		t.Error("NO_COLOR did not disable colors")
+	}
+}
+
+func TestAddGlobalFlags(t *testing.T) {
+	t.Cleanup(func() { _ = GlobalOptions{}.Apply() })
+	captureStreams(t)
+	ran := false
+	root := &cobra.Command{Use: "tool"}
+	root.AddCommand(&cobra.Command{Use: "serve", RunE: func(cmd *cobra.Command, args []string) error {
+		ran = true
+		return nil
+	}})
+	AddGlobalFlags(root)
+
+	root.SetArgs([]string{"serve", "--quiet", "--log-level", "error"})
+	if err := root.Execute(); err != nil || !ran {
+		t.Fatalf("Execute() = %v, ran %v", err, ran)
+	}
+	if !quiet || logLevel.Level() != slog.LevelError {
+		t.Errorf("flags not applied: quiet %v, level %v", quiet, logLevel.Level())
+	}
+
+	root.SetArgs([]string{"serve", "--log-level", "loud"})
+	root.SilenceErrors, root.SilenceUsage = true, true
+	if err := root.Execute(); err == nil {
+		t.Error("expected an error for an invalid --log-level")
+	}
+}
@@ -0,0 +1,83 @@
+package toolutil
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ProtoDecoder decodes binary protobuf bodies of one message type to JSON for display.
+type ProtoDecoder struct {
+	desc    protoreflect.MessageDescriptor
+	marshal protojson.MarshalOptions
+	opts    proto.UnmarshalOptions
+}
+
+// LoadProtoDecoder reads a binary FileDescriptorSet (see testpayload.ReadProtoset) and returns
+// a decoder of the fully-qualified message name, e.g. orders.v1.Order.
+func LoadProtoDecoder(descriptorSet, message string) (*ProtoDecoder, error) {
+	files, err := testpayload.ReadProtoset(descriptorSet)
+	if err != nil {
+		return nil, err
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, fmt.Errorf("message %q not found in %s: %w", message, descriptorSet, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message", message)
+	}
+	// Resolve google.protobuf.Any and extensions from the same descriptors
+	types := dynamicpb.NewTypes(files)
+	return &ProtoDecoder{
+		desc:    md,
+		marshal: protojson.MarshalOptions{Resolver: types},
+		opts:    proto.UnmarshalOptions{Resolver: types},
+	}, nil
+}
+
+// Decode converts a binary message to its protobuf JSON form.
+func (d *ProtoDecoder) Decode(body []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(d.desc)
+	if err := d.opts.Unmarshal(body, msg); err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", d.desc.FullName(), err)
+	}
+	return d.marshal.Marshal(msg)
+}
+
+// Decoder of --proto-descriptor/--proto-message, see SetupServe; nil leaves bodies as received.
+var (
+	protoDecoderMutex = sync.RWMutex{}
+	protoDecoder      *ProtoDecoder
+)
+
+// setProtoDecoder sets the decoder of received protobuf bodies (nil disables decoding).
+func setProtoDecoder(d *ProtoDecoder) {
+	protoDecoderMutex.Lock()
+	defer protoDecoderMutex.Unlock()
+	protoDecoder = d
+}
+
+// decodeProtoBody converts a received protobuf body to JSON with the --proto-message decoder.
+// Bodies declared as a textual type (see binaryBodyMIME), and bodies that do not decode as
+// the message, are returned unchanged.
+func decodeProtoBody(body []byte, mime string) ([]byte, string) {
+	protoDecoderMutex.RLock()
+	d := protoDecoder
+	protoDecoderMutex.RUnlock()
+	if d == nil || len(body) == 0 || !binaryBodyMIME(mime) {
+		return body, mime
+	}
+	b, err := d.Decode(body)
+	if err != nil {
+		Logger().Debug("Protobuf decoding failed", "error", err)
+		return body, mime
+	}
+	return b, CTJSON
+}
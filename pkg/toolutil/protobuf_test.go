@@ -0,0 +1,109 @@
+package toolutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func writeStructProtoset(t *testing.T) string {
+	t.Helper()
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(structpb.File_google_protobuf_struct_proto),
+	}}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "struct.protoset")
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDecodeProtoBody(t *testing.T) {
+	defer setProtoDecoder(nil)
+	msg, err := structpb.NewStruct(map[string]any{"id": "order-1", "total": 42.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, mime := decodeProtoBody(body, CTProtobuf); string(got) != string(body) || mime != CTProtobuf {
+		t.Error("decodeProtoBody() should not decode without --proto-message")
+	}
+
+	d, err := LoadProtoDecoder(writeStructProtoset(t), "google.protobuf.Struct")
+	if err != nil {
+		t.Fatalf("LoadProtoDecoder() error = %v", err)
+	}
+	setProtoDecoder(d)
+	for _, mime := range []string{CTProtobuf, "application/octet-stream", ""} {
+		got, gotMIME := decodeProtoBody(body, mime)
+		if gotMIME != CTJSON || !strings.Contains(string(got), `"order-1"`) || !strings.Contains(string(got), "42.5") {
+			t.Errorf("decodeProtoBody(%q) = %s, %q", mime, got, gotMIME)
+		}
+	}
+	jsonBody := []byte(`{"id":"order-1"}`)
+	if got, mime := decodeProtoBody(jsonBody, CTJSON); string(got) != string(jsonBody) || mime != CTJSON {
+		t.Errorf("decodeProtoBody() changed a JSON body: %s", got)
+	}
+	invalid := []byte{0xff, 0xff}
+	if got, mime := decodeProtoBody(invalid, CTProtobuf); string(got) != string(invalid) || mime != CTProtobuf {
+		t.Errorf("decodeProtoBody() changed an invalid body: %q, %q", got, mime)
+	}
+}
+
+func TestLoadProtoDecoder_Errors(t *testing.T) {
+	path := writeStructProtoset(t)
+	if _, err := LoadProtoDecoder(path, "google.protobuf.Missing"); err == nil {
+		t.Error("expected an error for an unknown message")
+	}
+	if _, err := LoadProtoDecoder(path, "google.protobuf.NullValue"); err == nil {
+		t.Error("expected an error for an enum")
+	}
+	if _, err := LoadProtoDecoder(filepath.Join(t.TempDir(), "missing"), "google.protobuf.Struct"); err == nil {
+		t.Error("expected an error for a missing descriptor set")
+	}
+}
+
+func TestSetupServe_ProtoMessage(t *testing.T) {
+	out, _ := captureStreams(t)
+	if _, err := SetupServe(&ServeOptions{ProtoMessage: "google.protobuf.Struct"}); err == nil {
+		t.Error("SetupServe() expected error for --proto-message without --proto-descriptor")
+	}
+	cleanup, err := SetupServe(&ServeOptions{
+		ProtoDescriptor: writeStructProtoset(t),
+		ProtoMessage:    "google.protobuf.Struct",
+		Filter:          `.level == "error"`,
+	})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+	defer cleanup()
+
+	for _, level := range []string{"info", "error"} {
+		msg, err := structpb.NewStruct(map[string]any{"level": level})
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		PrintColoredMessage("Event", nil, body, CTProtobuf)
+	}
+	if got := out.String(); strings.Contains(got, `"info"`) || !strings.Contains(got, `"level": "error"`) {
+		t.Errorf("unexpected decoded output:\n%s", got)
+	}
+}
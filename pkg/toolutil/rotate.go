@@ -0,0 +1,142 @@
+package toolutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFile is an append-only file that is rotated once it exceeds a size or has been
+// open for a duration: the current file is renamed with a timestamp suffix, e.g.
+// capture-20240102T150405.log, and a new one is created at the original path.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	interval time.Duration
+	f        *os.File
+	size     int64
+	opened   time.Time
+	now      func() time.Time
+}
+
+// OpenRotatingFile opens (appending) the file at path, rotating it after maxSize bytes and
+// every interval; zero disables either limit.
+func OpenRotatingFile(path string, maxSize int64, interval time.Duration) (*RotatingFile, error) {
+	if maxSize < 0 || interval < 0 {
+		return nil, fmt.Errorf("rotation size and interval must not be negative")
+	}
+	r := &RotatingFile{path: path, maxSize: maxSize, interval: interval, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the file at r.path for appending.
+func (r *RotatingFile) open() error {
+	// #nosec G304 -- output path is intentionally provided by user via CLI flag
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+	r.f, r.size, r.opened = f, info.Size(), r.now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first when p would exceed the size limit or the
+// interval has elapsed. A single write is never split across files.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	sizeExceeded := r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize
+	expired := r.interval > 0 && r.now().Sub(r.opened) >= r.interval
+	if sizeExceeded || expired {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate closes the current file, renames it with a timestamp suffix and opens a new one.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+	r.f = nil
+	if r.size > 0 {
+		if err := os.Rename(r.path, r.rotatedName()); err != nil {
+			return fmt.Errorf("failed to rotate output file: %w", err)
+		}
+	}
+	return r.open()
+}
+
+// rotatedName returns an unused name for the rotated file, adding a counter when several
+// rotations happen within the same second.
+func (r *RotatingFile) rotatedName() string {
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext) + "-" + r.now().Format("20060102T150405")
+	name := base + ext
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return name
+		}
+		name = base + "." + strconv.Itoa(i) + ext
+	}
+}
+
+// Close closes the current file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// byteUnits are the suffixes of ParseByteSize, binary multiples.
+var byteUnits = []struct {
+	suffix string
+	scale  int64
+}{
+	{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+	{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseByteSize parses a size such as 512, 64KB, 100MB or 1GiB; units are binary multiples
+// and case-insensitive.
+func ParseByteSize(s string) (int64, error) {
+	num, scale := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, scale = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/scale {
+		return 0, fmt.Errorf("invalid size %q: expected bytes or a number with KB, MB or GB", s)
+	}
+	return n * scale, nil
+}
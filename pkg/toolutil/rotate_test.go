@@ -0,0 +1,87 @@
+package toolutil
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_Size(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.log")
+	r, err := OpenRotatingFile(path, 10, 0)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() error = %v", err)
+	}
+	defer r.Close() //nolint:errcheck
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "a line longer than the limit\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "capture-*.log"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("rotated files = %v, %v", files, err)
+	}
+	var contents []string
+	for _, f := range append(files, path) {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(b))
+	}
+	slices.Sort(contents)
+	if strings.Join(contents, "|") != "a line longer than the limit\n|aaaa\nbbbb\n|cccc\n" {
+		t.Errorf("unexpected contents %q", contents)
+	}
+	if _, err := r.Write([]byte("x")); err == nil {
+		t.Error("Write() after Close() should fail")
+	}
+}
+
+func TestRotatingFile_Interval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.ndjson")
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	r, err := OpenRotatingFile(path, 0, time.Hour)
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() error = %v", err)
+	}
+	defer r.Close() //nolint:errcheck
+	r.now = func() time.Time { return now }
+	r.opened = now
+
+	_, _ = r.Write([]byte("first\n"))
+	now = now.Add(30 * time.Minute)
+	_, _ = r.Write([]byte("second\n"))
+	now = now.Add(30 * time.Minute)
+	_, _ = r.Write([]byte("third\n"))
+
+	rotated := filepath.Join(filepath.Dir(path), "capture-20240102T160405.ndjson")
+	if b, err := os.ReadFile(rotated); err != nil || string(b) != "first\nsecond\n" {
+		t.Errorf("rotated file = %q, %v", b, err)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "third\n" {
+		t.Errorf("current file = %q, %v", b, err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{"512": 512, "64KB": 64 << 10, "100mb": 100 << 20, "1GiB": 1 << 30, "2 M": 2 << 20, "10B": 10}
+	for in, want := range tests {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "MB", "-1KB", "1.5MB", "10TB", "9999999999GB"} {
+		if _, err := ParseByteSize(bad); err == nil {
+			t.Errorf("ParseByteSize(%q) expected an error", bad)
+		}
+	}
+}
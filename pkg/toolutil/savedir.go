@@ -0,0 +1,125 @@
+package toolutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// SaveIndexFile is the name of the NDJSON index written by --save-dir next to the bodies.
+const SaveIndexFile = "index.ndjson"
+
+// SavedMessage is a line of the --save-dir index, describing a saved body.
+type SavedMessage struct {
+	Seq      int               `json:"seq"`
+	Time     string            `json:"time"`
+	File     string            `json:"file"`
+	Size     int               `json:"size"`
+	MIME     string            `json:"mime,omitempty"`
+	Title    string            `json:"title,omitempty"`
+	Sections []MessageSection  `json:"sections,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// messageSaver writes received bodies and their index to a directory, see --save-dir.
+type messageSaver struct {
+	dir   string
+	index *os.File
+}
+
+// Directory of --save-dir, see SetupServe; nil saves nothing.
+var (
+	saveMutex = sync.Mutex{}
+	saver     *messageSaver
+)
+
+// openMessageSaver creates dir if needed and opens its index for appending.
+func openMessageSaver(dir string) (*messageSaver, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create save directory: %w", err)
+	}
+	// #nosec G304 -- save directory is intentionally provided by user via CLI flag
+	index, err := os.OpenFile(filepath.Join(dir, SaveIndexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open save index: %w", err)
+	}
+	return &messageSaver{dir: dir, index: index}, nil
+}
+
+// setSaver sets the directory received bodies are saved to (nil disables saving).
+func setSaver(s *messageSaver) {
+	saveMutex.Lock()
+	defer saveMutex.Unlock()
+	saver = s
+}
+
+// saveMessage writes the body of m as received, before --assume-mime and any protobuf or Avro
+// decoding, to the --save-dir directory and appends its metadata to the index.
+func saveMessage(m printedMessage, body []byte, mime string) {
+	saveMutex.Lock()
+	defer saveMutex.Unlock()
+	if saver == nil {
+		return
+	}
+	name := fmt.Sprintf("%s-%06d%s", m.Time.UTC().Format("20060102T150405.000000000Z"), m.Count, bodyExtension(body, mime))
+	if err := os.WriteFile(filepath.Join(saver.dir, name), body, 0600); err != nil {
+		PrintError("Failed to save message: %v", err)
+		return
+	}
+	b, err := json.Marshal(SavedMessage{
+		Seq:      m.Count,
+		Time:     m.Time.Format(time.RFC3339Nano),
+		File:     name,
+		Size:     len(body),
+		MIME:     mime,
+		Title:    m.Title,
+		Sections: m.Sections,
+		Headers:  sectionHeaders(m.Sections),
+	})
+	if err != nil {
+		PrintError("Failed to encode save index entry: %v", err)
+		return
+	}
+	if _, err := saver.index.Write(append(b, '\n')); err != nil {
+		PrintError("Failed to write save index: %v", err)
+	}
+}
+
+// close closes the index of s.
+func (s *messageSaver) close() error {
+	return s.index.Close()
+}
+
+// bodyExtension returns the file extension of a saved body, by MIME type or, without one,
+// by content.
+func bodyExtension(body []byte, mime string) string {
+	if mime == "" {
+		// GuessMIME takes some plain text for CBOR, so text wins unless it is JSON
+		mime = GuessMIME(body)
+		if mime != CTJSON && utf8.Valid(body) {
+			mime = CTText
+		} else if mime == CTText {
+			return ".bin"
+		}
+	}
+	m := strings.ToLower(mime)
+	switch {
+	case strings.Contains(m, "json"):
+		return ".json"
+	case strings.Contains(m, "cbor"):
+		return ".cbor"
+	case strings.Contains(m, "xml"):
+		return ".xml"
+	case strings.Contains(m, "text"):
+		return ".txt"
+	case strings.Contains(m, "proto"):
+		return ".pb"
+	default:
+		return ".bin"
+	}
+}
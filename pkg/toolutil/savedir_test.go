@@ -0,0 +1,80 @@
+package toolutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupServe_SaveDir(t *testing.T) {
+	captureStreams(t)
+	dir := filepath.Join(t.TempDir(), "capture")
+	cleanup, err := SetupServe(&ServeOptions{SaveDir: dir})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+
+	headers := HeadersSection([]KV{{Key: "x-id", Value: "1"}})
+	PrintColoredMessage("Event", []MessageSection{headers}, []byte(`{"id":1}`), CTJSON)
+	PrintColoredMessage("Event", nil, []byte{0x00, 0xff}, "")
+	cleanup()
+
+	f, err := os.Open(filepath.Join(dir, SaveIndexFile))
+	if err != nil {
+		t.Fatalf("index not written: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	var saved []SavedMessage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s SavedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			t.Fatalf("invalid index line %q: %v", scanner.Text(), err)
+		}
+		saved = append(saved, s)
+	}
+	if len(saved) != 2 {
+		t.Fatalf("index has %d entries, want 2", len(saved))
+	}
+	if s := saved[0]; !strings.HasSuffix(s.File, ".json") || s.MIME != CTJSON || s.Size != 8 || s.Headers["x-id"] != "1" {
+		t.Errorf("unexpected first entry %+v", s)
+	}
+	if s := saved[1]; !strings.HasSuffix(s.File, ".bin") || s.Seq <= saved[0].Seq {
+		t.Errorf("unexpected second entry %+v", s)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, saved[0].File))
+	if err != nil || string(b) != `{"id":1}` {
+		t.Errorf("saved body = %q, %v", b, err)
+	}
+	if saved[0].File >= saved[1].File {
+		t.Errorf("saved files %q and %q do not sort in arrival order", saved[0].File, saved[1].File)
+	}
+}
+
+func TestBodyExtension(t *testing.T) {
+	tests := []struct {
+		body []byte
+		mime string
+		want string
+	}{
+		{[]byte(`{}`), "application/json; charset=utf-8", ".json"},
+		{[]byte{0xa0}, CTCBOR, ".cbor"},
+		{[]byte("<a/>"), "application/xml", ".xml"},
+		{[]byte("hi"), CTText, ".txt"},
+		{[]byte{0x08}, CTProtobuf, ".pb"},
+		{[]byte("[1]"), "", ".json"},
+		{[]byte("hi"), "", ".txt"},
+		{[]byte{0xa1, 0x61, 0x61, 0x01}, "", ".cbor"},
+		{[]byte{0x00, 0xff}, "", ".bin"},
+		{[]byte("hi"), "application/octet-stream", ".bin"},
+	}
+	for _, tt := range tests {
+		if got := bodyExtension(tt.body, tt.mime); got != tt.want {
+			t.Errorf("bodyExtension(%q, %q) = %q, want %q", tt.body, tt.mime, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package toolutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of received messages, see ServeOptions.Output.
+const (
+	// OutputText prints colored, human-readable messages.
+	OutputText = "text"
+	// OutputJSON prints one MessageEvent JSON object per line (NDJSON).
+	OutputJSON = "json"
+)
+
+// ServeOptions holds the output settings shared by all serve commands.
+type ServeOptions struct {
+	// Output is the format of messages printed to stdout: OutputText (default) or OutputJSON.
+	Output string
+	// OutputFile, when set, receives the printed messages instead of stdout, without colors.
+	OutputFile string
+	// OutputMaxSize rotates OutputFile once it would exceed this size (e.g. 100MB); empty
+	// disables size rotation.
+	OutputMaxSize string
+	// OutputRotate rotates OutputFile after this duration; zero disables time rotation.
+	OutputRotate time.Duration
+	// Filter is a jq expression selecting the messages to print, see matchesFilter.
+	Filter string
+	// ValidateSchema is a JSON Schema file each decoded body is validated against; any
+	// failure makes the command exit non-zero.
+	ValidateSchema string
+	// Tee is an optional file path receiving a copy of every printed message.
+	Tee string
+	// TeeJSON writes structured JSON events (one per line) to the tee file instead of plain text.
+	TeeJSON bool
+	// SaveDir is a directory receiving every received body as a file, named by time and
+	// sequence number, plus an index.ndjson of their metadata.
+	SaveDir string
+	// ShowEmpty renders sections without items instead of omitting them.
+	ShowEmpty bool
+	// ProtoDescriptor is a binary FileDescriptorSet with the ProtoMessage type.
+	ProtoDescriptor string
+	// ProtoMessage is the fully-qualified protobuf message type received bodies are decoded
+	// from for display, e.g. orders.v1.Order.
+	ProtoMessage string
+	// AvroSchema is an .avsc schema file plain Avro binary bodies are decoded with for display.
+	AvroSchema string
+	// AvroRegistry is the URL of a Confluent-compatible schema registry, used to decode Avro
+	// bodies in the Confluent wire format by their schema ID.
+	AvroRegistry string
+	// AssumeMIME forces the MIME type used to render every received body.
+	AssumeMIME string
+	// Timeout stops serving after this duration; zero serves until interrupted.
+	Timeout time.Duration
+	// MaxMessages, when > 0, stops serving (successfully) once this many messages have been
+	// received; later messages are not printed.
+	MaxMessages int
+	// AssertCount, when >= 0 and Timeout is set, makes the command fail unless exactly
+	// this many messages are received before the timeout. The flag defaults to -1 (disabled).
+	AssertCount int
+	// Summary prints aggregate stats of the received messages at shutdown.
+	Summary bool
+}
+
+// AddServeFlags adds the flags shared by all serve commands.
+func AddServeFlags(cmd *cobra.Command, opts *ServeOptions) {
+	cmd.Flags().StringVar(&opts.Output, "output", OutputText, "Format of received messages on stdout: text (colored) or json (one JSON object per line, for jq and CI)")
+	cmd.Flags().StringVar(&opts.Filter, "filter", "", "Print only messages whose decoded body matches this jq expression (e.g. '.level == \"error\"')")
+	cmd.Flags().StringVar(&opts.ValidateSchema, "validate-schema", "", "Validate each decoded body against this JSON Schema file, printing PASS/FAIL and exiting non-zero if any message fails")
+	cmd.Flags().StringVar(&opts.OutputFile, "output-file", "", "Write received messages to this file (appends, without colors) instead of stdout")
+	cmd.Flags().StringVar(&opts.OutputMaxSize, "output-max-size", "", "Rotate --output-file once it would exceed this size (e.g. 100MB)")
+	cmd.Flags().DurationVar(&opts.OutputRotate, "output-rotate", 0, "Rotate --output-file after this duration (e.g. 1h)")
+	cmd.Flags().StringVar(&opts.Tee, "tee", "", "Also write received messages to this file (appends, without colors)")
+	cmd.Flags().BoolVar(&opts.TeeJSON, "tee-json", false, "Write structured JSON events (one per line) to the --tee file")
+	cmd.Flags().StringVar(&opts.SaveDir, "save-dir", "", "Save each received body to a timestamped, sequence-numbered file in this directory, with an index.ndjson of metadata")
+	cmd.Flags().StringVar(&opts.AssumeMIME, "assume-mime", "", "Render all received bodies as this MIME type (e.g. application/cbor), overriding detection and content-type headers")
+	cmd.Flags().StringVar(&opts.ProtoDescriptor, "proto-descriptor", "", "Binary FileDescriptorSet (protoc --include_imports --descriptor_set_out) with the --proto-message type")
+	cmd.Flags().StringVar(&opts.ProtoMessage, "proto-message", "", "Decode protobuf bodies as this fully-qualified message type (e.g. orders.v1.Order) and print them as JSON")
+	cmd.Flags().StringVar(&opts.AvroSchema, "avro-schema", "", "Decode Avro binary bodies with this .avsc schema file and print them as JSON")
+	cmd.Flags().StringVar(&opts.AvroRegistry, "avro-registry", "", "Decode Confluent wire-format Avro bodies with the schemas of this registry URL (e.g. http://localhost:8081)")
+	cmd.Flags().BoolVar(&opts.ShowEmpty, "show-empty", false, "Show message sections even when they have no items (e.g. empty Query or Headers)")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0, "Stop serving after this duration (e.g. 30s); 0 serves until interrupted")
+	cmd.Flags().IntVar(&opts.MaxMessages, "max-messages", 0, "Stop serving and exit 0 after N messages are received; 0 serves until interrupted")
+	cmd.Flags().IntVar(&opts.AssertCount, "assert-count", -1, "Exit non-zero unless exactly N messages are received within --timeout")
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("assert-count") && opts.Timeout <= 0 {
+			return fmt.Errorf("--assert-count requires --timeout")
+		}
+		if opts.MaxMessages < 0 {
+			return fmt.Errorf("--max-messages must not be negative")
+		}
+		return nil
+	}
+	cmd.Flags().BoolVar(&opts.Summary, "list-received-summary", false, "Print aggregate stats (count, bytes, sizes, rate, destinations, content types) of received messages at shutdown")
+	cmd.PostRunE = func(cmd *cobra.Command, args []string) error {
+		if opts.Summary {
+			PrintServeStats(ReceivedStats())
+		}
+		if err := checkReceived(opts); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if err := checkValidation(opts); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		return nil
+	}
+}
+
+var (
+	teeMutex  = sync.Mutex{}
+	teeWriter io.Writer
+	teeJSON   bool
+)
+
+// outputJSON makes PrintColoredMessage print MessageEvent JSON lines instead of colored text.
+var outputJSON bool
+
+// outputFile, when set, receives the messages of PrintColoredMessage instead of stdout.
+var outputFile io.Writer
+
+// setOutputFile sets the writer receiving printed messages instead of stdout (nil restores stdout).
+func setOutputFile(w io.Writer) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
+	outputFile = w
+}
+
+// setOutputJSON selects the JSON (true) or text (false) format of PrintColoredMessage.
+func setOutputJSON(v bool) {
+	printMutex.Lock()
+	defer printMutex.Unlock()
+	outputJSON = v
+}
+
+// SetupServe applies the shared serve options.
+// It returns a cleanup function that must be called (usually deferred) when the command ends.
+func SetupServe(opts *ServeOptions) (func(), error) {
+	if opts.TeeJSON && opts.Tee == "" {
+		return nil, fmt.Errorf("--tee-json requires --tee")
+	}
+	if opts.Timeout < 0 {
+		return nil, fmt.Errorf("--timeout must not be negative")
+	}
+	switch opts.Output {
+	case "", OutputText:
+		setOutputJSON(false)
+	case OutputJSON:
+		setOutputJSON(true)
+	default:
+		return nil, fmt.Errorf("invalid --output %q: expected %s or %s", opts.Output, OutputText, OutputJSON)
+	}
+	if opts.OutputFile == "" && (opts.OutputMaxSize != "" || opts.OutputRotate != 0) {
+		return nil, fmt.Errorf("--output-max-size and --output-rotate require --output-file")
+	}
+	var maxSize int64
+	if opts.OutputMaxSize != "" {
+		var err error
+		if maxSize, err = ParseByteSize(opts.OutputMaxSize); err != nil {
+			return nil, fmt.Errorf("invalid --output-max-size: %w", err)
+		}
+	}
+	if opts.OutputRotate < 0 {
+		return nil, fmt.Errorf("--output-rotate must not be negative")
+	}
+	var filter *gojq.Code
+	if opts.Filter != "" {
+		var err error
+		if filter, err = CompileFilter(opts.Filter); err != nil {
+			return nil, err
+		}
+	}
+	setFilter(filter)
+	if (opts.ProtoDescriptor == "") != (opts.ProtoMessage == "") {
+		return nil, fmt.Errorf("--proto-descriptor and --proto-message must be used together")
+	}
+	var decoder *ProtoDecoder
+	if opts.ProtoMessage != "" {
+		var err error
+		if decoder, err = LoadProtoDecoder(opts.ProtoDescriptor, opts.ProtoMessage); err != nil {
+			return nil, err
+		}
+	}
+	setProtoDecoder(decoder)
+	var avroDec *AvroDecoder
+	if opts.AvroSchema != "" || opts.AvroRegistry != "" {
+		var err error
+		if avroDec, err = LoadAvroDecoder(opts.AvroSchema, opts.AvroRegistry); err != nil {
+			return nil, err
+		}
+	}
+	setAvroDecoder(avroDec)
+	var schema *jsonschema.Schema
+	if opts.ValidateSchema != "" {
+		var err error
+		if schema, err = LoadSchema(opts.ValidateSchema); err != nil {
+			return nil, err
+		}
+	}
+	setSchema(schema)
+	SetShowEmptySections(opts.ShowEmpty)
+	SetAssumeMIME(opts.AssumeMIME)
+	resetReceived(opts)
+	cleanup := func() {}
+	if opts.OutputFile != "" {
+		f, err := OpenRotatingFile(opts.OutputFile, maxSize, opts.OutputRotate)
+		if err != nil {
+			return nil, err
+		}
+		setOutputFile(f)
+		cleanup = func() {
+			setOutputFile(nil)
+			if err := f.Close(); err != nil {
+				PrintError("Failed to close output file: %v", err)
+			}
+		}
+	}
+	if opts.Tee != "" {
+		// #nosec G304 -- output path is intentionally provided by user via CLI flag
+		f, err := os.OpenFile(opts.Tee, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to open tee file: %w", err)
+		}
+		setTee(f, opts.TeeJSON)
+		closeOutput := cleanup
+		cleanup = func() {
+			setTee(nil, false)
+			if err := f.Close(); err != nil {
+				PrintError("Failed to close tee file: %v", err)
+			}
+			closeOutput()
+		}
+	}
+	if opts.SaveDir != "" {
+		s, err := openMessageSaver(opts.SaveDir)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		setSaver(s)
+		closeTee := cleanup
+		cleanup = func() {
+			setSaver(nil)
+			if err := s.close(); err != nil {
+				PrintError("Failed to close save index: %v", err)
+			}
+			closeTee()
+		}
+	}
+	return cleanup, nil
+}
+
+// setTee configures the writer receiving a copy of printed messages (nil disables it).
+func setTee(w io.Writer, jsonMode bool) {
+	teeMutex.Lock()
+	defer teeMutex.Unlock()
+	teeWriter = w
+	teeJSON = jsonMode
+}
+
+// writeTee writes m to the configured tee writer, if any.
+func writeTee(m printedMessage) {
+	teeMutex.Lock()
+	defer teeMutex.Unlock()
+	if teeWriter == nil {
+		return
+	}
+	if !teeJSON {
+		writeMessage(teeWriter, m, false)
+		return
+	}
+	if err := writeEvent(teeWriter, m); err != nil {
+		PrintError("Failed to write tee file: %v", err)
+	}
+}
+
+// writeEvent writes m to w as a MessageEvent JSON object followed by a newline.
+func writeEvent(w io.Writer, m printedMessage) error {
+	b, err := json.Marshal(newMessageEvent(m))
+	if err != nil {
+		return fmt.Errorf("failed to encode message event: %w", err)
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// Received message accounting for --timeout, --max-messages, --assert-count and
+// --list-received-summary.
+var (
+	receivedMutex  = sync.Mutex{}
+	received       = newServeStats()
+	receivedLimit  = -1
+	receivedMax    = 0
+	receivedCancel context.CancelFunc
+)
+
+// resetReceived clears the received stats and arms the --assert-count and --max-messages limits.
+func resetReceived(opts *ServeOptions) {
+	receivedMutex.Lock()
+	defer receivedMutex.Unlock()
+	received = newServeStats()
+	receivedLimit = -1
+	if opts.Timeout > 0 && opts.AssertCount >= 0 {
+		receivedLimit = opts.AssertCount
+	}
+	receivedMax = opts.MaxMessages
+}
+
+// countReceived records a message printed by PrintColoredMessage. Serving stops once
+// --max-messages have arrived, or once more messages than --assert-count have arrived since
+// the assertion can no longer pass.
+func countReceived(m printedMessage) {
+	receivedMutex.Lock()
+	defer receivedMutex.Unlock()
+	received.add(m)
+	exceeded := receivedLimit >= 0 && received.Messages > receivedLimit
+	if (exceeded || maxReceivedLocked()) && receivedCancel != nil {
+		receivedCancel()
+	}
+}
+
+// maxReceived reports whether --max-messages have been received, after which
+// PrintColoredMessage drops the messages still arriving while serving stops.
+func maxReceived() bool {
+	receivedMutex.Lock()
+	defer receivedMutex.Unlock()
+	return maxReceivedLocked()
+}
+
+// maxReceivedLocked is maxReceived for callers holding receivedMutex.
+func maxReceivedLocked() bool {
+	return receivedMax > 0 && received.Messages >= receivedMax
+}
+
+// ReceivedCount returns the number of messages printed since SetupServe.
+func ReceivedCount() int {
+	receivedMutex.Lock()
+	defer receivedMutex.Unlock()
+	return received.Messages
+}
+
+// ReceivedStats returns a snapshot of the messages printed since SetupServe.
+func ReceivedStats() ServeStats {
+	receivedMutex.Lock()
+	defer receivedMutex.Unlock()
+	return received.clone()
+}
+
+// ServeContext returns the context serve commands run under. It is cancelled on SIGINT/SIGTERM,
+// when --timeout expires, once --max-messages have been received, or as soon as more messages
+// than --assert-count have been received.
+// The returned cancel function must be called (usually deferred) when the command ends.
+func ServeContext(opts *ServeOptions) (context.Context, context.CancelFunc) {
+	ctx, cancel := common.SetupGracefulShutdown()
+	if opts.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, opts.Timeout)
+		cancelSignal := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelSignal()
+		}
+	}
+	receivedMutex.Lock()
+	receivedCancel = cancel
+	receivedMutex.Unlock()
+	return ctx, cancel
+}
+
+// checkReceived prints the observed message count when --timeout or --max-messages is set and
+// enforces --assert-count.
+func checkReceived(opts *ServeOptions) error {
+	if opts.Timeout <= 0 && opts.MaxMessages <= 0 {
+		return nil
+	}
+	n := ReceivedCount()
+	PrintInfo("Received %d messages", n)
+	if opts.AssertCount >= 0 && n != opts.AssertCount {
+		return fmt.Errorf("expected exactly %d messages, received %d", opts.AssertCount, n)
+	}
+	return nil
+}
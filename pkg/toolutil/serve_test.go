@@ -0,0 +1,351 @@
+package toolutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestFprintColoredMessage(t *testing.T) {
+	var buf bytes.Buffer
+	sections := []MessageSection{{Title: "Topic", Items: []KV{{Key: "Name", Value: "events"}}}}
+
+	FprintColoredMessage(&buf, "Test", sections, []byte("hello"), CTText)
+
+	out := buf.String()
+	for _, want := range []string{"Message", "Test:", "Topic:", "Name: events", "Body:", "hello"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FprintColoredMessage() output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFprintColoredMessage_SkipsEmptySections(t *testing.T) {
+	var buf bytes.Buffer
+	sections := []MessageSection{
+		{Title: "Topic", Items: []KV{{Key: "Name", Value: "events"}}},
+		HeadersSection(nil),
+		HeadersSection([]KV{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}),
+	}
+
+	FprintColoredMessage(&buf, "Test", sections, []byte("hello"), CTText)
+
+	out := buf.String()
+	if strings.Contains(out, "Headers (0)") {
+		t.Errorf("FprintColoredMessage() rendered empty section:\n%s", out)
+	}
+	if !strings.Contains(out, "Headers (2):") {
+		t.Errorf("FprintColoredMessage() output missing header count:\n%s", out)
+	}
+}
+
+func TestFprintColoredMessage_ShowEmpty(t *testing.T) {
+	SetShowEmptySections(true)
+	defer SetShowEmptySections(false)
+
+	var buf bytes.Buffer
+	FprintColoredMessage(&buf, "Test", []MessageSection{{Title: "Query"}}, []byte("hello"), CTText)
+
+	if out := buf.String(); !strings.Contains(out, "Query:") {
+		t.Errorf("FprintColoredMessage() with show-empty missing empty section:\n%s", out)
+	}
+}
+
+func TestFprintColoredMessage_AssumeMIME(t *testing.T) {
+	SetAssumeMIME(CTJSON)
+	defer SetAssumeMIME("")
+
+	var buf bytes.Buffer
+	FprintColoredMessage(&buf, "Test", nil, []byte(`{"a":1}`), CTText)
+
+	if out := buf.String(); !strings.Contains(out, `"a": 1`) {
+		t.Errorf("FprintColoredMessage() did not render body as JSON:\n%s", out)
+	}
+}
+
+func TestAddServeFlags(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts ServeOptions
+	AddServeFlags(cmd, &opts)
+
+	for _, name := range []string{"tee", "tee-json", "show-empty", "assume-mime", "timeout", "assert-count", "list-received-summary"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("AddServeFlags() did not add '%s' flag", name)
+		}
+	}
+}
+
+func TestSetupServe_Tee(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee.log")
+	cleanup, err := SetupServe(&ServeOptions{Tee: path})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	PrintColoredMessage("Tee", []MessageSection{{Title: "Meta", Items: []KV{{Key: "K", Value: "V"}}}}, []byte(`{"a":1}`), CTJSON)
+	cleanup()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read tee file: %v", err)
+	}
+	out := string(b)
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("tee file should not contain color escapes:\n%q", out)
+	}
+	for _, want := range []string{"Tee:", "K: V", `"a": 1`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("tee file missing %q:\n%s", want, out)
+		}
+	}
+
+	// After cleanup nothing else is written
+	PrintColoredMessage("After", nil, []byte("x"), CTText)
+	b2, _ := os.ReadFile(path)
+	if len(b2) != len(b) {
+		t.Error("tee file written after cleanup")
+	}
+}
+
+func TestSetupServe_TeeJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee.ndjson")
+	cleanup, err := SetupServe(&ServeOptions{Tee: path, TeeJSON: true})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	PrintColoredMessage("JSON", []MessageSection{{Title: "Meta", Items: []KV{{Key: "K", Value: "V"}}}}, []byte(`{"a":1}`), CTJSON)
+	PrintColoredMessage("Text", nil, []byte("plain"), CTText)
+	PrintColoredMessage("Binary", nil, []byte{0xff, 0xfe}, "application/octet-stream")
+	cleanup()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read tee file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 JSON lines, got %d:\n%s", len(lines), string(b))
+	}
+	var events []MessageEvent
+	for _, l := range lines {
+		var ev MessageEvent
+		if err := json.Unmarshal([]byte(l), &ev); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", l, err)
+		}
+		events = append(events, ev)
+	}
+	if obj, ok := events[0].Body.(map[string]any); !ok || obj["a"] != float64(1) {
+		t.Errorf("JSON body not embedded as object: %#v", events[0].Body)
+	}
+	if len(events[0].Sections) != 1 || events[0].Sections[0].Items[0].Value != "V" {
+		t.Errorf("sections not encoded: %#v", events[0].Sections)
+	}
+	if events[1].Body != "plain" {
+		t.Errorf("text body = %#v, want plain", events[1].Body)
+	}
+	if events[2].BodyBase64 != "//4=" {
+		t.Errorf("binary body_base64 = %q, want //4=", events[2].BodyBase64)
+	}
+}
+
+func TestSetupServe_OutputJSON(t *testing.T) {
+	out, _ := captureStreams(t)
+	if _, err := SetupServe(&ServeOptions{Output: "yaml"}); err == nil {
+		t.Error("SetupServe() expected error for an unknown --output")
+	}
+	cleanup, err := SetupServe(&ServeOptions{Output: OutputJSON})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+	defer cleanup()
+
+	sections := []MessageSection{{Title: "Meta", Items: []KV{{Key: "Topic", Value: "t"}}}, HeadersSection([]KV{{Key: "x-id", Value: "7"}})}
+	PrintColoredMessage("Event", sections, []byte(`{"a":1}`), CTJSON)
+	PrintColoredMessage("Event", nil, []byte("plain"), CTText)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d:\n%s", len(lines), out.String())
+	}
+	var ev MessageEvent
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[0], err)
+	}
+	if obj, ok := ev.Body.(map[string]any); !ok || obj["a"] != float64(1) {
+		t.Errorf("JSON body not embedded as object: %#v", ev.Body)
+	}
+	if ev.Headers["x-id"] != "7" || len(ev.Headers) != 1 || len(ev.Sections) != 2 || ev.Time == "" {
+		t.Errorf("unexpected event %s", lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &ev); err != nil || ev.Body != "plain" {
+		t.Errorf("text event = %s, %v", lines[1], err)
+	}
+
+	if _, err := SetupServe(&ServeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	PrintColoredMessage("Event", nil, []byte("plain"), CTText)
+	if !strings.Contains(out.String(), "-------- Message") {
+		t.Errorf("text output not restored:\n%s", out.String())
+	}
+}
+
+func TestSetupServe_OutputFile(t *testing.T) {
+	out, _ := captureStreams(t)
+	dir := t.TempDir()
+	if _, err := SetupServe(&ServeOptions{OutputMaxSize: "1MB"}); err == nil {
+		t.Error("SetupServe() expected error for --output-max-size without --output-file")
+	}
+	if _, err := SetupServe(&ServeOptions{OutputFile: filepath.Join(dir, "x.log"), OutputMaxSize: "lots"}); err == nil {
+		t.Error("SetupServe() expected error for an invalid --output-max-size")
+	}
+
+	path := filepath.Join(dir, "capture.log")
+	cleanup, err := SetupServe(&ServeOptions{OutputFile: path, OutputMaxSize: "200B"})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	for range 3 {
+		PrintColoredMessage("Event", []MessageSection{{Title: "Meta", Items: []KV{{Key: "K", Value: "V"}}}}, []byte(`{"a":1}`), CTJSON)
+	}
+	cleanup()
+	PrintColoredMessage("After", nil, []byte("x"), CTText)
+
+	if out.Len() == 0 || strings.Contains(out.String(), "Event") {
+		t.Errorf("messages written to stdout with --output-file:\n%s", out.String())
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "capture*.log"))
+	if len(files) < 2 {
+		t.Fatalf("expected rotated files, got %v", files)
+	}
+	var all string
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Count(string(b), "-------- Message") == 0 {
+			t.Errorf("%s holds a split message:\n%s", f, b)
+		}
+		all += string(b)
+	}
+	if strings.Count(all, "-------- Message") != 3 || strings.Contains(all, "\x1b[") {
+		t.Errorf("unexpected output files content:\n%s", all)
+	}
+}
+
+func TestSetupServe_TeeJSONRequiresTee(t *testing.T) {
+	if _, err := SetupServe(&ServeOptions{TeeJSON: true}); err == nil {
+		t.Error("SetupServe() expected error for --tee-json without --tee")
+	}
+}
+
+func TestAssertCount(t *testing.T) {
+	captureStreams(t)
+	opts := &ServeOptions{Timeout: time.Minute, AssertCount: 2}
+	cleanup, err := SetupServe(opts)
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer cleanup()
+	ctx, cancel := ServeContext(opts)
+	defer cancel()
+
+	PrintColoredMessage("One", nil, []byte("1"), CTText)
+	if err := checkReceived(opts); err == nil {
+		t.Error("checkReceived() expected error for fewer messages")
+	}
+	PrintColoredMessage("Two", nil, []byte("2"), CTText)
+	if err := checkReceived(opts); err != nil {
+		t.Errorf("checkReceived() error = %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("serve context cancelled before exceeding --assert-count")
+	}
+
+	PrintColoredMessage("Three", nil, []byte("3"), CTText)
+	if ctx.Err() == nil {
+		t.Error("serve context should stop once --assert-count is exceeded")
+	}
+	if err := checkReceived(opts); err == nil {
+		t.Error("checkReceived() expected error for more messages")
+	}
+	if got := ReceivedCount(); got != 3 {
+		t.Errorf("ReceivedCount() = %d, want 3", got)
+	}
+}
+
+func TestMaxMessages(t *testing.T) {
+	out, _ := captureStreams(t)
+	opts := &ServeOptions{MaxMessages: 2, AssertCount: -1}
+	cleanup, err := SetupServe(opts)
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+	defer cleanup()
+	ctx, cancel := ServeContext(opts)
+	defer cancel()
+
+	PrintColoredMessage("One", nil, []byte("first"), CTText)
+	if ctx.Err() != nil {
+		t.Fatal("serve context cancelled before --max-messages")
+	}
+	PrintColoredMessage("Two", nil, []byte("second"), CTText)
+	if ctx.Err() == nil {
+		t.Error("serve context should stop once --max-messages are received")
+	}
+	PrintColoredMessage("Three", nil, []byte("third"), CTText)
+	if strings.Contains(out.String(), "third") {
+		t.Errorf("message printed after --max-messages:\n%s", out.String())
+	}
+	if got := ReceivedCount(); got != 2 {
+		t.Errorf("ReceivedCount() = %d, want 2", got)
+	}
+	if err := checkReceived(opts); err != nil {
+		t.Errorf("checkReceived() error = %v", err)
+	}
+}
+
+func TestServeContext_Timeout(t *testing.T) {
+	opts := &ServeOptions{Timeout: 10 * time.Millisecond, AssertCount: -1}
+	ctx, cancel := ServeContext(opts)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("serve context not cancelled after --timeout")
+	}
+}
+
+func TestAddServeFlags_AssertCountRequiresTimeout(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"--timeout", "1s"}, false},
+		{[]string{"--timeout", "1s", "--assert-count", "0"}, false},
+		{[]string{"--assert-count", "1"}, true},
+		{[]string{"--max-messages", "5"}, false},
+		{[]string{"--max-messages", "-1"}, true},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{Use: "test"}
+		var opts ServeOptions
+		AddServeFlags(cmd, &opts)
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatalf("ParseFlags(%v) error = %v", tt.args, err)
+		}
+		if err := cmd.PreRunE(cmd, nil); (err != nil) != tt.wantErr {
+			t.Errorf("PreRunE(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package toolutil
+
+import (
+	"fmt"
+	"maps"
+	"mime"
+	"slices"
+	"time"
+)
+
+// destinationSections are the section titles whose "Name" item identifies where a message was received.
+var destinationSections = []string{"Topic", "Subject", "Channel", "Stream", "Subscription", "Queue"}
+
+// ServeStats aggregates the messages received by a serve command.
+type ServeStats struct {
+	Start        time.Time
+	Messages     int
+	Bytes        int64
+	MinSize      int
+	MaxSize      int
+	Destinations map[string]int // messages per topic/subject/channel/stream
+	Keys         map[string]int // messages per message key (e.g. Kafka keys)
+	ContentTypes map[string]int // messages per body MIME type, without parameters
+}
+
+func newServeStats() ServeStats {
+	return ServeStats{
+		Start:        time.Now(),
+		Destinations: map[string]int{},
+		Keys:         map[string]int{},
+		ContentTypes: map[string]int{},
+	}
+}
+
+// add accounts for one printed message.
+func (s *ServeStats) add(m printedMessage) {
+	size := len(m.Body)
+	if s.Messages == 0 || size < s.MinSize {
+		s.MinSize = size
+	}
+	if size > s.MaxSize {
+		s.MaxSize = size
+	}
+	s.Messages++
+	s.Bytes += int64(size)
+
+	for _, sec := range m.Sections {
+		switch {
+		case slices.Contains(destinationSections, sec.Title):
+			for _, kv := range sec.Items {
+				if kv.Key == "Name" && kv.Value != "" {
+					s.Destinations[kv.Value]++
+				}
+			}
+		case sec.Title == "Key":
+			for _, kv := range sec.Items {
+				if kv.Value != "" {
+					s.Keys[kv.Value]++
+				}
+			}
+		}
+	}
+
+	ct := "unknown"
+	if m.MIME != "" {
+		ct = m.MIME
+		if mt, _, err := mime.ParseMediaType(m.MIME); err == nil {
+			ct = mt
+		}
+	}
+	s.ContentTypes[ct]++
+}
+
+func (s ServeStats) clone() ServeStats {
+	s.Destinations = maps.Clone(s.Destinations)
+	s.Keys = maps.Clone(s.Keys)
+	s.ContentTypes = maps.Clone(s.ContentTypes)
+	return s
+}
+
+// AvgSize returns the average body size in bytes.
+func (s ServeStats) AvgSize() float64 {
+	if s.Messages == 0 {
+		return 0
+	}
+	return float64(s.Bytes) / float64(s.Messages)
+}
+
+// Rate returns the received messages per second since Start.
+func (s ServeStats) Rate(now time.Time) float64 {
+	elapsed := now.Sub(s.Start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Messages) / elapsed
+}
+
+// PrintServeStats prints a summary of s to stderr.
+func PrintServeStats(s ServeStats) {
+	now := time.Now()
+	PrintHeader("Received Summary")
+	PrintKeyValue("Messages", s.Messages)
+	PrintKeyValue("Bytes", s.Bytes)
+	if s.Messages > 0 {
+		PrintKeyValue("Body size", formatSizes(s))
+	}
+	PrintKeyValue("Rate", formatRate(s, now))
+	if len(s.Destinations) > 0 {
+		PrintKeyValue("Unique destinations", len(s.Destinations))
+	}
+	if len(s.Keys) > 0 {
+		PrintKeyValue("Unique keys", len(s.Keys))
+	}
+	for _, ct := range slices.Sorted(maps.Keys(s.ContentTypes)) {
+		PrintKeyValue("Content-Type "+ct, s.ContentTypes[ct])
+	}
+}
+
+func formatSizes(s ServeStats) string {
+	return fmt.Sprintf("min %d / avg %.1f / max %d bytes", s.MinSize, s.AvgSize(), s.MaxSize)
+}
+
+func formatRate(s ServeStats, now time.Time) string {
+	return fmt.Sprintf("%.2f msg/s over %s", s.Rate(now), now.Sub(s.Start).Round(time.Millisecond))
+}
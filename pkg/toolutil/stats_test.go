@@ -0,0 +1,54 @@
+package toolutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeStats(t *testing.T) {
+	_, errOut := captureStreams(t)
+	cleanup, err := SetupServe(&ServeOptions{})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer cleanup()
+
+	topic := func(name, key string) []MessageSection {
+		return []MessageSection{
+			{Title: "Topic", Items: []KV{{Key: "Name", Value: name}}},
+			{Title: "Key", Items: []KV{{Key: "Value", Value: key}}},
+		}
+	}
+	PrintColoredMessage("Kafka", topic("orders", "a"), []byte(`{"a":1}`), CTJSON)
+	PrintColoredMessage("Kafka", topic("orders", "b"), []byte("hello world"), "text/plain; charset=utf-8")
+	PrintColoredMessage("Kafka", topic("users", "a"), []byte("x"), CTText)
+
+	s := ReceivedStats()
+	if s.Messages != 3 || s.Bytes != 19 {
+		t.Errorf("Messages, Bytes = %d, %d; want 3, 19", s.Messages, s.Bytes)
+	}
+	if s.MinSize != 1 || s.MaxSize != 11 {
+		t.Errorf("MinSize, MaxSize = %d, %d; want 1, 11", s.MinSize, s.MaxSize)
+	}
+	if len(s.Destinations) != 2 || s.Destinations["orders"] != 2 {
+		t.Errorf("Destinations = %v", s.Destinations)
+	}
+	if len(s.Keys) != 2 || s.Keys["a"] != 2 {
+		t.Errorf("Keys = %v", s.Keys)
+	}
+	if s.ContentTypes[CTText] != 2 || s.ContentTypes[CTJSON] != 1 {
+		t.Errorf("ContentTypes = %v", s.ContentTypes)
+	}
+	if r := s.Rate(s.Start.Add(2 * time.Second)); r != 1.5 {
+		t.Errorf("Rate() = %v, want 1.5", r)
+	}
+
+	PrintServeStats(s)
+	out := errOut.String()
+	for _, want := range []string{"Received Summary", "Messages: 3", "min 1 / avg 6.3 / max 11 bytes", "Unique destinations: 2", "Content-Type text/plain: 2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+}
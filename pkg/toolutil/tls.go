@@ -0,0 +1,51 @@
+package toolutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSOptions configures the client side of a TLS connection.
+type TLSOptions struct {
+	// CAFile is a PEM bundle of CA certificates trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key, both or neither.
+	CertFile string
+	KeyFile  string
+	// Insecure skips the verification of the server certificate.
+	Insecure bool
+}
+
+// Config returns the TLS client configuration of the options, requiring TLS 1.2 or later.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: o.Insecure} // #nosec G402 -- opt-in for test servers
+	if o.CAFile != "" {
+		// #nosec G304 -- the CA file is provided by the user via CLI flag
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", o.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, errors.New("a client certificate needs both a certificate and a key file")
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,29 @@
+package toolutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLSOptionsConfig(t *testing.T) {
+	cfg, err := TLSOptions{Insecure: true}.Config()
+	if err != nil || !cfg.InsecureSkipVerify || cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Errorf("Config() = %+v, %v", cfg, err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for name, o := range map[string]TLSOptions{
+		"missing CA file":  {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"CA without PEM":   {CAFile: empty},
+		"key without cert": {KeyFile: empty},
+		"bad client cert":  {CertFile: empty, KeyFile: empty},
+	} {
+		if _, err := o.Config(); err == nil {
+			t.Errorf("%s: Config() expected an error", name)
+		}
+	}
+}
@@ -1,11 +1,21 @@
 package toolutil
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +24,7 @@ import (
 	"github.com/TylerBrock/colorjson"
 	"github.com/fatih/color"
 	"github.com/fxamacker/cbor/v2"
+	"github.com/sandrolain/eventkit/pkg/common"
 	"github.com/sandrolain/eventkit/pkg/testpayload"
 	"github.com/spf13/cobra"
 )
@@ -22,8 +33,14 @@ const (
 	CTJSON = "application/json"
 	CTCBOR = "application/cbor"
 	CTText = "text/plain"
+	// CTProtobuf is the content type of payloads built with a proto:MESSAGE MIME.
+	CTProtobuf = "application/x-protobuf"
 )
 
+// protoMIMEPrefix marks a MIME of the form proto:MESSAGE: the interpolated payload is the
+// protobuf JSON form of MESSAGE and is sent binary-encoded.
+const protoMIMEPrefix = "proto:"
+
 var (
 	// Color definitions for CLI output
 	colorCyan    = color.New(color.FgCyan).SprintFunc()
@@ -33,43 +50,70 @@ var (
 	colorBold    = color.New(color.Bold).SprintFunc()
 )
 
-// PrintInfo prints an informational message with color.
+// Output streams: only message/payload content goes to stdout, while all diagnostics
+// (logs, status lines, warnings and errors) go to stderr, so that
+// `tool serve > data.log 2> errors.log` separates them cleanly.
+var (
+	stdout io.Writer = color.Output
+	stderr io.Writer = color.Error
+)
+
+// PrintInfo prints an informational message with color to stderr, unless --quiet is set.
 func PrintInfo(format string, args ...interface{}) {
-	fmt.Printf("%s %s\n", colorCyan("ℹ"), fmt.Sprintf(format, args...))
+	if quiet {
+		return
+	}
+	_, _ = fmt.Fprintf(stderr, "%s %s\n", colorCyan("ℹ"), fmt.Sprintf(format, args...))
 }
 
-// PrintSuccess prints a success message with color.
+// PrintSuccess prints a success message with color to stderr, unless --quiet is set.
 func PrintSuccess(format string, args ...interface{}) {
-	fmt.Printf("%s %s\n", colorGreen("✓"), fmt.Sprintf(format, args...))
+	if quiet {
+		return
+	}
+	_, _ = fmt.Fprintf(stderr, "%s %s\n", colorGreen("✓"), fmt.Sprintf(format, args...))
 }
 
-// PrintWarning prints a warning message with color.
+// PrintWarning prints a warning message with color to stderr.
 func PrintWarning(format string, args ...interface{}) {
-	fmt.Printf("%s %s\n", colorYellow("⚠"), fmt.Sprintf(format, args...))
+	_, _ = fmt.Fprintf(stderr, "%s %s\n", colorYellow("⚠"), fmt.Sprintf(format, args...))
 }
 
 // PrintError prints an error message with color to stderr.
 func PrintError(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "%s %s\n", color.RedString("✗"), fmt.Sprintf(format, args...))
+	_, _ = fmt.Fprintf(stderr, "%s %s\n", color.RedString("✗"), fmt.Sprintf(format, args...))
 }
 
-// PrintHeader prints a bold header message.
+// PrintHeader prints a bold header message to stderr, unless --quiet is set.
 func PrintHeader(format string, args ...interface{}) {
-	fmt.Printf("\n%s\n", colorBold(fmt.Sprintf(format, args...)))
+	if quiet {
+		return
+	}
+	_, _ = fmt.Fprintf(stderr, "\n%s\n", colorBold(fmt.Sprintf(format, args...)))
 }
 
-// PrintKeyValue prints a key-value pair with color.
+// PrintKeyValue prints a key-value pair with color to stderr, unless --quiet is set.
 func PrintKeyValue(key string, value interface{}) {
-	fmt.Printf("  %s: %v\n", colorMagenta(key), value)
+	if quiet {
+		return
+	}
+	_, _ = fmt.Fprintf(stderr, "  %s: %v\n", colorMagenta(key), value)
 }
 
-// Logger returns a slog logger to stdout.
+// Logger returns a slog logger to stderr, at the level set by --log-level (default info).
 func Logger() *slog.Logger {
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	return slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: logLevel}))
 }
 
-// PrettyBodyByMIME pretty-prints JSON/CBOR bodies based on MIME, otherwise returns original body.
+// PrettyBodyByMIME pretty-prints JSON bodies and CBOR bodies (in diagnostic notation) based on
+// MIME, otherwise returns original body.
 func PrettyBodyByMIME(mime string, body []byte) []byte {
+	return prettyBody(mime, body, true)
+}
+
+// prettyBody pretty-prints JSON bodies and CBOR bodies (see prettyCBOR), with or without syntax
+// coloring.
+func prettyBody(mime string, body []byte, colored bool) []byte {
 	if len(body) == 0 {
 		return body
 	}
@@ -80,19 +124,15 @@ func PrettyBodyByMIME(mime string, body []byte) []byte {
 		if err := json.Unmarshal(body, &obj); err == nil {
 			f := colorjson.NewFormatter()
 			f.Indent = 2
+			f.DisabledColor = !colored
 			if s, err := f.Marshal(obj); err == nil {
 				return s
 			}
 		}
 		return body
 	case strings.Contains(m, "cbor"):
-		var obj any
-		if err := cbor.Unmarshal(body, &obj); err == nil {
-			f := colorjson.NewFormatter()
-			f.Indent = 2
-			if s, err := f.Marshal(obj); err == nil {
-				return s
-			}
+		if s, ok := prettyCBOR(body, colored); ok {
+			return s
 		}
 		return body
 	default:
@@ -121,29 +161,116 @@ func BuildPayload(rawPayload string, mime string) ([]byte, string, error) {
 }
 
 // BuildPayloadWithDelimiters builds request payload with custom template delimiters.
-// Supports placeholders: {{json}}, {{cbor}}, {{sentiment}}, {{sentence}}, {{datetime}}, {{nowtime}}, {{counter}}, {{file:/path}}
+// With a --payload-source file loaded, its next line replaces rawPayload. A proto:MESSAGE mime
+// encodes the payload, written in protobuf JSON, as a binary MESSAGE (see AddProtosetFlag).
+// With --cloudevents the payload is then wrapped in a CloudEvent (see CloudEventsOptions.Apply),
+// and with --corrupt-rate a share of the final payloads is mutated (see AddCorruptRateFlag).
+// Supports placeholders: {{json}}, {{cbor}}, {{sentiment}}, {{sentence}}, {{datetime}}, {{nowtime}}, {{nowtime:[utc:][OFFSET][:FORMAT]}}, {{counter}}, {{counter:NAME:START:STEP}}, {{uuid}}, {{uuidv7}}, {{randint:MIN:MAX}}, {{randfloat:MIN:MAX:DECIMALS}}, {{size:N}}, {{bytes:N}}, {{oneof:A|B|C}}, {{col:NAME}}, {{proto:MESSAGE}}, {{env:NAME}}, {{cmd:COMMAND}},
+// {{name}}, {{firstname}}, {{lastname}}, {{email}}, {{phone}}, {{username}}, {{url}}, {{domain}}, {{company}}, {{word}},
+// {{ipv4}}, {{ipv6}}, {{mac}}, {{port}}, {{file:/path}}, {{list:/path}}
 func BuildPayloadWithDelimiters(rawPayload string, mime string, openDelim string, closeDelim string) ([]byte, string, error) {
-	b, err := testpayload.InterpolateWithDelimiters(rawPayload, openDelim, closeDelim)
+	testpayload.BeginMessage()
+	b, mime, err := interpolatePayload(rawPayload, mime, openDelim, closeDelim)
+	if err != nil {
+		return nil, "", err
+	}
+	return finalizePayload(b, mime, openDelim, closeDelim)
+}
+
+// BuildRepeatedPayloadWithDelimiters builds one message whose body is the payload interpolated
+// n times and joined with sep (see AddRepeatBodyFlags). Only the interpolation is repeated: the
+// message is begun once, and the CloudEvent envelope and --corrupt-rate apply to the joined
+// body. The content type is the one of the first repetition; n <= 1 builds a single payload.
+func BuildRepeatedPayloadWithDelimiters(n int, sep string, rawPayload string, mime string, openDelim string, closeDelim string) ([]byte, string, error) {
+	testpayload.BeginMessage()
+	bodyMIME := ""
+	b, err := RepeatBody(n, sep, func() ([]byte, error) {
+		b, m, err := interpolatePayload(rawPayload, mime, openDelim, closeDelim)
+		if bodyMIME == "" {
+			bodyMIME = m
+		}
+		return b, err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return finalizePayload(b, bodyMIME, openDelim, closeDelim)
+}
+
+// interpolatePayload interpolates rawPayload, or the current --payload-source line, and
+// encodes it for a proto:MESSAGE mime. Without a mime it guesses the content type.
+func interpolatePayload(rawPayload string, mime string, openDelim string, closeDelim string) ([]byte, string, error) {
+	b, err := testpayload.InterpolateMessage(rawPayload, openDelim, closeDelim)
+	if errors.Is(err, testpayload.ErrDataExhausted) {
+		// Ends RunOnceOrPeriodic loops once a --data-csv or --payload-source file is used up
+		return nil, "", fmt.Errorf("%w: %w", common.ErrStop, err)
+	}
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to interpolate payload: %w", err)
 	}
-	// If the caller didn't pass a MIME type (empty string), try to guess.
-	if mime == "" {
+	if msgName, ok := strings.CutPrefix(mime, protoMIMEPrefix); ok {
+		if b, err = testpayload.EncodeProtoJSON(msgName, b); err != nil {
+			return nil, "", err
+		}
+		mime = CTProtobuf
+	} else if mime == "" {
+		// If the caller didn't pass a MIME type (empty string), try to guess.
 		mime = GuessMIME(b)
 	}
 	return b, mime, nil
 }
 
-// GuessMIME tries to guess a content type from raw body.
-// It detects JSON by leading '{' or '[' and CBOR by first byte 0xA0-0xBF/0x80-0x9F/0x60-0x7F heuristics.
-// Falls back to text/plain.
+// finalizePayload applies the per-message steps to a built body: the --cloudevents envelope
+// and --corrupt-rate.
+func finalizePayload(b []byte, mime string, openDelim string, closeDelim string) ([]byte, string, error) {
+	var err error
+	if b, mime, err = applyCloudEvents(b, mime, openDelim, closeDelim); err != nil {
+		return nil, "", err
+	}
+	if corrupted, mutation := testpayload.MaybeCorrupt(b); mutation != "" {
+		// The content type is kept, so consumers see e.g. broken JSON sent as application/json
+		slog.Debug("Corrupted payload", "mutation", mutation, "size", len(b))
+		b = corrupted
+	}
+	return b, mime, nil
+}
+
+// RepeatBody calls build n times and joins the results with sep, producing one large body.
+// Each repetition is built independently; n <= 1 returns a single body. Message payloads are
+// repeated with BuildRepeatedPayloadWithDelimiters.
+func RepeatBody(n int, sep string, build func() ([]byte, error)) ([]byte, error) {
+	if n <= 1 {
+		return build()
+	}
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(sep)
+		}
+		b, err := build()
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// GuessMIME tries to guess a content type from raw body, for built payloads without a MIME and
+// received messages without a content type. Printable bodies are text/plain unless they are a
+// valid JSON object or array; only binary bodies are checked for the CBOR major types
+// map/array/text (first byte 0xA0-0xBF/0x80-0x9F/0x60-0x7F), as that heuristic alone would
+// mistake plain text for CBOR.
 func GuessMIME(body []byte) string {
 	if len(body) == 0 {
 		return CTText
 	}
-	b := strings.TrimSpace(string(body))
-	if strings.HasPrefix(b, "{") || strings.HasPrefix(b, "[") {
-		return CTJSON
+	if utf8.Valid(body) {
+		b := bytes.TrimSpace(body)
+		if (bytes.HasPrefix(b, []byte("{")) || bytes.HasPrefix(b, []byte("["))) && json.Valid(b) {
+			return CTJSON
+		}
+		return CTText
 	}
 	// Simple CBOR heuristic: detect major types for map/array/text
 	// Not perfect, but ok for debugging tool.
@@ -158,14 +285,42 @@ func GuessMIME(body []byte) string {
 
 // KV represents a single key-value pair to print under a section.
 type KV struct {
-	Key   string
-	Value string
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 // MessageSection groups related key-value pairs under a titled section.
 type MessageSection struct {
-	Title string
-	Items []KV
+	Title string `json:"title"`
+	Items []KV   `json:"items"`
+}
+
+// HeadersSection returns a "Headers (N)" section listing the given header items.
+func HeadersSection(items []KV) MessageSection {
+	return MessageSection{Title: fmt.Sprintf("Headers (%d)", len(items)), Items: items}
+}
+
+// showEmptySections disables the omission of sections without items.
+var showEmptySections bool
+
+// SetShowEmptySections controls whether sections without items are printed.
+func SetShowEmptySections(v bool) {
+	showEmptySections = v
+}
+
+// nonEmptySections drops sections without items so they are not rendered as empty titled blocks,
+// unless SetShowEmptySections(true) was called.
+func nonEmptySections(sections []MessageSection) []MessageSection {
+	if showEmptySections {
+		return sections
+	}
+	out := make([]MessageSection, 0, len(sections))
+	for _, s := range sections {
+		if len(s.Items) > 0 {
+			out = append(out, s)
+		}
+	}
+	return out
 }
 
 var printCounter int = 0
@@ -178,32 +333,259 @@ func getNextPrintCount() int {
 	return printCounter
 }
 
-// PrintColoredMessage prints a colored, consistently formatted message with sections and body.
+// printMutex serializes message output so concurrent handlers don't interleave.
+var printMutex = sync.Mutex{}
+
+// printedMessage carries everything needed to render a received message.
+type printedMessage struct {
+	Count    int
+	Time     time.Time
+	Title    string
+	Sections []MessageSection
+	Body     []byte
+	MIME     string
+}
+
+// assumeMIME, when set, replaces the MIME type passed to PrintColoredMessage.
+var assumeMIME string
+
+// SetAssumeMIME forces the MIME type used to render printed message bodies ("" restores detection).
+func SetAssumeMIME(mime string) {
+	assumeMIME = mime
+}
+
+// displayBody returns the body and MIME type a message is rendered with: --assume-mime
+// replaces the declared type, and protobuf or Avro bodies are decoded to JSON with
+// --proto-message, --avro-schema or --avro-registry.
+func displayBody(body []byte, mime string) ([]byte, string) {
+	if assumeMIME != "" {
+		mime = assumeMIME
+	}
+	body, mime = decodeProtoBody(body, mime)
+	return decodeAvroBody(body, mime)
+}
+
+// binaryBodyMIME reports whether a body of the declared MIME type may hold a binary encoding
+// such as protobuf or Avro: any type but JSON, CBOR, text and XML, or none.
+func binaryBodyMIME(mime string) bool {
+	m := strings.ToLower(mime)
+	for _, t := range []string{"json", "cbor", "text", "xml"} {
+		if strings.Contains(m, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func newPrintedMessage(title string, sections []MessageSection, body []byte, mime string) printedMessage {
+	return printedMessage{
+		Count:    getNextPrintCount(),
+		Time:     time.Now(),
+		Title:    title,
+		Sections: nonEmptySections(sections),
+		Body:     body,
+		MIME:     mime,
+	}
+}
+
+// PrintColoredMessage prints to stdout a colored, consistently formatted message with sections and body.
 // Title and section titles are highlighted; items are aligned as key: value; body is pretty-printed by MIME.
-func PrintColoredMessage(title string, sections []MessageSection, body []byte, mime string) {
-	black := color.New(color.FgBlack).Add(color.ResetUnderline).PrintfFunc()
-	blue := color.New(color.FgHiBlue).Add(color.Underline).PrintfFunc()
-	white := color.New(color.FgWhite).Add(color.ResetUnderline).PrintfFunc()
+// Sections without items are omitted unless SetShowEmptySections(true) was called.
+// With --output json (see SetupServe) it prints a MessageEvent JSON line instead, and with
+// --output-file it writes to that file instead of stdout.
+// When a tee file is configured (see SetupServe), the message is also written there, and with
+// --save-dir the received body is saved to a file.
+// With --proto-message or --avro-schema/--avro-registry protobuf or Avro bodies are decoded to
+// JSON first, so --filter, --validate-schema and the output all see the JSON form.
+// With --validate-schema a Validation section reports whether the body matches the schema.
+// Every call counts as a received message for --timeout, --max-messages, --assert-count and
+// --list-received-summary, except for messages discarded by --filter.
+// It returns false when the message was dropped because --max-messages had already been
+// received and serving is stopping: tools acknowledging messages should then leave it with the
+// broker (skip the ack or delete, or release it) so it is not lost.
+func PrintColoredMessage(title string, sections []MessageSection, body []byte, mime string) bool {
+	if maxReceived() {
+		return false
+	}
+	raw, rawMIME := body, mime
+	body, mime = displayBody(body, mime)
+	if !matchesFilter(body, mime) {
+		return true
+	}
+
+	printMutex.Lock()
+	defer printMutex.Unlock()
+	if maxReceived() {
+		return false
+	}
+	if validation, ok := validateMessage(body, mime); ok {
+		sections = append(sections[:len(sections):len(sections)], validation)
+	}
+	m := newPrintedMessage(title, sections, body, mime)
+	out, colored := stdout, true
+	if outputFile != nil {
+		out, colored = outputFile, false
+	}
+	// Render the whole message first so a rotating output file never splits it
+	var buf bytes.Buffer
+	if outputJSON {
+		if err := writeEvent(&buf, m); err != nil {
+			PrintError("Failed to write message: %v", err)
+		}
+	} else {
+		writeMessage(&buf, m, colored)
+	}
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		PrintError("Failed to write message: %v", err)
+	}
+	writeTee(m)
+	saveMessage(m, raw, rawMIME)
+	countReceived(m)
+	return true
+}
+
+// FprintColoredMessage writes a formatted message with sections and body to w.
+// Colors follow the global color settings (disabled automatically when w is not a terminal).
+func FprintColoredMessage(w io.Writer, title string, sections []MessageSection, body []byte, mime string) {
+	body, mime = displayBody(body, mime)
+	m := newPrintedMessage(title, sections, body, mime)
 
-	count := getNextPrintCount()
-	black("\n-------- Message %d --------\n", count)
-	black(time.Now().Format(time.RFC3339) + "\n")
-	if title != "" {
-		blue("%s:\n", title)
+	printMutex.Lock()
+	defer printMutex.Unlock()
+	writeMessage(w, m, true)
+}
+
+// writeMessage renders m to w; colored=false forces plain text output, as do --no-color and
+// NO_COLOR.
+func writeMessage(w io.Writer, m printedMessage, colored bool) {
+	colored = colored && !color.NoColor
+	black := color.New(color.FgBlack).Add(color.ResetUnderline)
+	blue := color.New(color.FgHiBlue).Add(color.Underline)
+	white := color.New(color.FgWhite).Add(color.ResetUnderline)
+	if !colored {
+		black.DisableColor()
+		blue.DisableColor()
+		white.DisableColor()
 	}
 
-	for _, s := range sections {
+	_, _ = black.Fprintf(w, "\n-------- Message %d --------\n", m.Count)
+	_, _ = black.Fprintf(w, "%s\n", m.Time.Format(time.RFC3339))
+	if m.Title != "" {
+		_, _ = blue.Fprintf(w, "%s:\n", m.Title)
+	}
+
+	for _, s := range m.Sections {
 		if s.Title != "" {
-			blue("%s:\n", s.Title)
+			_, _ = blue.Fprintf(w, "%s:\n", s.Title)
+		}
+		for _, kv := range s.Items {
+			_, _ = white.Fprintf(w, "  %s: %s\n", kv.Key, kv.Value)
+		}
+	}
+
+	_, _ = blue.Fprintf(w, "Body:\n")
+	pretty := prettyBody(m.MIME, m.Body, colored)
+	_, _ = white.Fprintf(w, "%s\n\n", pretty)
+}
+
+// WellKnownHeaders is the set of header names (lower-case) that serve commands
+// surface in a dedicated section instead of the generic headers list.
+var WellKnownHeaders = map[string]struct{}{
+	"content-type":   {},
+	"correlation-id": {},
+	"trace-id":       {},
+}
+
+// IsWellKnownHeader reports whether name is in WellKnownHeaders (case-insensitive).
+func IsWellKnownHeader(name string) bool {
+	_, ok := WellKnownHeaders[strings.ToLower(name)]
+	return ok
+}
+
+// SplitWellKnownHeaders splits header items into well-known and remaining headers, preserving order.
+func SplitWellKnownHeaders(items []KV) (wellKnown []KV, others []KV) {
+	for _, kv := range items {
+		if IsWellKnownHeader(kv.Key) {
+			wellKnown = append(wellKnown, kv)
+		} else {
+			others = append(others, kv)
+		}
+	}
+	return wellKnown, others
+}
+
+// MessageEvent is the structured (JSON) representation of a received message.
+type MessageEvent struct {
+	Seq        int               `json:"seq"`
+	Time       string            `json:"time"`
+	Title      string            `json:"title,omitempty"`
+	Sections   []MessageSection  `json:"sections,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	MIME       string            `json:"mime,omitempty"`
+	Body       any               `json:"body,omitempty"`
+	BodyBase64 string            `json:"body_base64,omitempty"`
+}
+
+// newMessageEvent builds a MessageEvent; JSON/CBOR bodies are embedded as decoded values,
+// other UTF-8 bodies as strings and binary bodies as base64.
+func newMessageEvent(m printedMessage) MessageEvent {
+	ev := MessageEvent{
+		Seq:      m.Count,
+		Time:     m.Time.Format(time.RFC3339Nano),
+		Title:    m.Title,
+		Sections: m.Sections,
+		Headers:  sectionHeaders(m.Sections),
+		MIME:     m.MIME,
+	}
+	if len(m.Body) == 0 {
+		return ev
+	}
+	if obj, ok := decodeStructuredBody(m.MIME, m.Body); ok {
+		ev.Body = obj
+	} else if utf8.Valid(m.Body) {
+		ev.Body = string(m.Body)
+	} else {
+		ev.BodyBase64 = base64.StdEncoding.EncodeToString(m.Body)
+	}
+	return ev
+}
+
+// sectionHeaders collects the items of the header sections ("Headers (N)", "Well-Known
+// Headers") into a map, nil without any.
+func sectionHeaders(sections []MessageSection) map[string]string {
+	var headers map[string]string
+	for _, s := range sections {
+		if !strings.Contains(s.Title, "Headers") {
+			continue
 		}
 		for _, kv := range s.Items {
-			white("  %s: %s\n", kv.Key, kv.Value)
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers[kv.Key] = kv.Value
 		}
 	}
+	return headers
+}
 
-	blue("Body:\n")
-	pretty := PrettyBodyByMIME(mime, body)
-	white("%s\n\n", pretty)
+// decodeStructuredBody decodes JSON or CBOR bodies into generic values suitable for JSON encoding.
+func decodeStructuredBody(mime string, body []byte) (any, bool) {
+	m := strings.ToLower(mime)
+	var obj any
+	switch {
+	case strings.Contains(m, "json"):
+		if err := json.Unmarshal(body, &obj); err == nil {
+			return obj, true
+		}
+	case strings.Contains(m, "cbor"):
+		dm, err := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any{})}.DecMode()
+		if err == nil && dm.Unmarshal(body, &obj) == nil {
+			if _, err := json.Marshal(obj); err == nil {
+				return obj, true
+			}
+		}
+	}
+	return nil, false
 }
 
 // --- Shared CLI flag helpers ---
@@ -238,8 +620,96 @@ func AddPayloadFlags(cmd *cobra.Command, payload *string, payloadDef string, mim
 	if mimeDef == "" {
 		mimeDef = CTJSON
 	}
-	cmd.Flags().StringVar(payload, "payload", payloadDef, "Payload to send (supports placeholders: {{json}},{{cbor}},{{sentiment}},{{sentence}},{{datetime}},{{nowtime}},{{counter}},{{file:/path}})")
-	cmd.Flags().StringVar(mime, "mime", mimeDef, "Payload MIME type (application/json, application/cbor, text/plain)")
+	cmd.Flags().StringVar(payload, "payload", payloadDef, "Payload to send (supports placeholders: {{json}},{{cbor}},{{sentiment}},{{sentence}},{{datetime}},{{nowtime}},{{nowtime:[utc:][OFFSET][:FORMAT]}},{{counter}},{{counter:NAME:START:STEP}},{{uuid}},{{uuidv7}},{{randint:MIN:MAX}},{{randfloat:MIN:MAX:DECIMALS}},{{size:N}},{{bytes:N}},{{oneof:A|B|C}},{{col:NAME}},{{proto:MESSAGE}},{{env:NAME}},{{cmd:COMMAND}},{{name}},{{firstname}},{{lastname}},{{email}},{{phone}},{{username}},{{url}},{{domain}},{{company}},{{word}},{{ipv4}},{{ipv6}},{{mac}},{{port}},{{file:/path}},{{list:/path}})")
+	cmd.Flags().StringVar(mime, "mime", mimeDef, "Payload MIME type (application/json, application/cbor, text/plain, or proto:MESSAGE to encode a protobuf JSON payload with --protoset)")
+}
+
+// payloadURLTimeout bounds the time spent fetching a --payload-url template.
+const payloadURLTimeout = 10 * time.Second
+
+// maxPayloadTemplateSize is the largest --payload-url template accepted (10 MiB).
+const maxPayloadTemplateSize = 10 << 20
+
+// AddPayloadURLFlag adds a --payload-url flag to fetch the payload template over HTTP(S).
+func AddPayloadURLFlag(cmd *cobra.Command, payloadURL *string) {
+	cmd.Flags().StringVar(payloadURL, "payload-url", "", "Fetch the payload template (up to 10 MiB) from an HTTP(S) URL once at startup (exclusive with --payload)")
+	cmd.Flags().String("payload-url-ca", "", "PEM CA bundle trusted for the --payload-url server, in addition to the system roots")
+	cmd.Flags().String("payload-url-cert", "", "PEM client certificate presented to the --payload-url server (with --payload-url-key)")
+	cmd.Flags().String("payload-url-key", "", "PEM key of the --payload-url client certificate")
+	cmd.Flags().Bool("payload-url-insecure", false, "Skip the certificate verification of the --payload-url server")
+}
+
+// payloadURLTLSOptions reads the TLS flags added by AddPayloadURLFlag.
+func payloadURLTLSOptions(cmd *cobra.Command) TLSOptions {
+	var o TLSOptions
+	o.CAFile, _ = cmd.Flags().GetString("payload-url-ca")
+	o.CertFile, _ = cmd.Flags().GetString("payload-url-cert")
+	o.KeyFile, _ = cmd.Flags().GetString("payload-url-key")
+	o.Insecure, _ = cmd.Flags().GetBool("payload-url-insecure")
+	return o
+}
+
+// ResolvePayloadURL replaces payload with the template fetched from payloadURL when it is set.
+// It returns an error if --payload was also set explicitly on the command, or if --allow-exec
+// is enabled (see ExecOptions.Apply), as a remote template could then run local commands.
+func ResolvePayloadURL(cmd *cobra.Command, payloadURL string, payload *string) error {
+	if payloadURL == "" {
+		return nil
+	}
+	if cmd.Flags().Changed("payload") {
+		return fmt.Errorf("--payload and --payload-url are mutually exclusive")
+	}
+	if testpayload.ExecAllowed() {
+		return fmt.Errorf("--allow-exec cannot be used with --payload-url: the fetched template could run commands")
+	}
+	tlsConfig, err := payloadURLTLSOptions(cmd).Config()
+	if err != nil {
+		return fmt.Errorf("invalid --payload-url TLS options: %w", err)
+	}
+	b, err := FetchPayloadTemplate(payloadURL, tlsConfig)
+	if err != nil {
+		return err
+	}
+	*payload = string(b)
+	return nil
+}
+
+// FetchPayloadTemplate downloads a raw payload template from an HTTP(S) URL, using tlsConfig
+// for https (nil for the TLSOptions defaults). Non-200 responses and templates larger than
+// 10 MiB are reported as errors.
+func FetchPayloadTemplate(payloadURL string, tlsConfig *tls.Config) ([]byte, error) {
+	u, err := url.Parse(payloadURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid payload URL '%s': expected http:// or https://", payloadURL)
+	}
+	if tlsConfig == nil {
+		if tlsConfig, err = (TLSOptions{}).Config(); err != nil {
+			return nil, err
+		}
+	}
+	client := &http.Client{
+		Timeout: payloadURLTimeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch payload from %s: %w", payloadURL, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch payload from %s: unexpected status %s", payloadURL, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxPayloadTemplateSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload from %s: %w", payloadURL, err)
+	}
+	if len(b) > maxPayloadTemplateSize {
+		return nil, fmt.Errorf("payload from %s exceeds the %d bytes limit", payloadURL, maxPayloadTemplateSize)
+	}
+	return b, nil
 }
 
 // AddTemplateDelimiterFlags adds flags for customizing template variable delimiters.
@@ -280,12 +750,76 @@ func AddFileRootFlag(cmd *cobra.Command, root *string) {
 // AddFileCacheFlag adds a --cache-files flag for enabling file content caching.
 // (no-op - helper present above)
 
+// DataSourceOptions holds the --data-csv settings of send commands.
+type DataSourceOptions struct {
+	CSV  string
+	Loop bool
+}
+
+// AddDataSourceFlags adds --data-csv and --data-loop.
+func AddDataSourceFlags(cmd *cobra.Command, opts *DataSourceOptions) {
+	cmd.Flags().StringVar(&opts.CSV, "data-csv", "", "CSV file (with a header line) whose rows fill {{col:NAME}} placeholders, one row per message")
+	cmd.Flags().BoolVar(&opts.Loop, "data-loop", false, "Restart from the first --data-csv row after the last one instead of stopping")
+}
+
+// Load loads the --data-csv file, if any, as the data source of {{col:NAME}} placeholders.
+func (o DataSourceOptions) Load() error {
+	if o.CSV == "" {
+		testpayload.ClearDataSource()
+		return nil
+	}
+	return testpayload.LoadDataCSV(o.CSV, o.Loop)
+}
+
+// PayloadSourceOptions holds the --payload-source settings of send commands.
+type PayloadSourceOptions struct {
+	File string
+	Rate float64
+	testpayload.PayloadSourceOptions
+}
+
+// AddPayloadSourceFlags adds --payload-source, --source-loop, --source-shuffle,
+// --source-template and --source-rate.
+func AddPayloadSourceFlags(cmd *cobra.Command, opts *PayloadSourceOptions) {
+	cmd.Flags().StringVar(&opts.File, "payload-source", "", "File whose lines (e.g. captured NDJSON events) are sent in sequence as payloads, one per message, instead of --payload")
+	cmd.Flags().BoolVar(&opts.Loop, "source-loop", false, "Restart from the first --payload-source line after the last one instead of stopping")
+	cmd.Flags().BoolVar(&opts.Shuffle, "source-shuffle", false, "Send the --payload-source lines in random order (reshuffled on every loop)")
+	cmd.Flags().BoolVar(&opts.Template, "source-template", false, "Interpolate template placeholders in each --payload-source line")
+	cmd.Flags().Float64Var(&opts.Rate, "source-rate", 0, "Messages per second for --payload-source, overriding --interval (0 keeps --interval)")
+}
+
+// Load loads the --payload-source file, if any, and applies --source-rate to interval.
+// --source-template is rejected with --allow-exec (see ExecOptions.Apply), as captured lines
+// could then run local commands.
+func (o PayloadSourceOptions) Load(interval *string) error {
+	if o.File == "" {
+		testpayload.ClearPayloadSource()
+		return nil
+	}
+	if o.Template && testpayload.ExecAllowed() {
+		return fmt.Errorf("--allow-exec cannot be used with --source-template: the --payload-source lines could run commands")
+	}
+	if o.Rate < 0 || math.IsNaN(o.Rate) || math.IsInf(o.Rate, 0) {
+		return fmt.Errorf("invalid --source-rate %g: expected a positive number of messages per second", o.Rate)
+	}
+	if o.Rate > 0 {
+		*interval = time.Duration(float64(time.Second) / o.Rate).String()
+	}
+	return testpayload.LoadPayloadSource(o.File, o.PayloadSourceOptions)
+}
+
 // AddSeedFlag provides a CLI flag to configure a deterministic seed for test payload
 // generation to make output deterministic during tests or reproducible runs.
 func AddSeedFlag(cmd *cobra.Command, seed *int64) {
 	cmd.Flags().Int64Var(seed, "seed", 0, "Optional deterministic seed for test data generation")
 }
 
+// AddSeedPerMessageFlag provides a CLI flag to re-seed test data generation before each
+// message with --seed plus the message index, making every message of a run reproducible.
+func AddSeedPerMessageFlag(cmd *cobra.Command, perMessage *bool) {
+	cmd.Flags().BoolVar(perMessage, "seed-per-message", false, "Re-seed random data before each message with --seed + message index (reproducible yet varying messages)")
+}
+
 // AddAllowFileReadsFlag provides a CLI flag to allow using {{file:...}} placeholders.
 // Disabled by default for safety; the CLI flag should be used with care in untrusted
 // environments.
@@ -293,6 +827,82 @@ func AddAllowFileReadsFlag(cmd *cobra.Command, allow *bool) {
 	cmd.Flags().BoolVar(allow, "allow-file-reads", false, "Allow reading files with {{file:...}} placeholder (default false)")
 }
 
+// AddAllowEnvReadsFlag provides a CLI flag to allow using {{env:NAME}} placeholders.
+// Disabled by default so templates cannot read secrets from the environment unless asked to.
+func AddAllowEnvReadsFlag(cmd *cobra.Command, allow *bool) {
+	cmd.Flags().BoolVar(allow, "allow-env", false, "Allow reading environment variables with {{env:NAME}} placeholder (default false)")
+}
+
+// ExecOptions holds the --allow-exec settings of send commands.
+type ExecOptions struct {
+	testpayload.ExecOptions
+}
+
+// AddExecFlags adds --allow-exec, --exec-timeout and --exec-cache. Command execution is
+// disabled by default; only enable it for trusted templates.
+func AddExecFlags(cmd *cobra.Command, opts *ExecOptions) {
+	cmd.Flags().BoolVar(&opts.Allow, "allow-exec", false, "Allow running commands with {{cmd:COMMAND}} placeholder, replaced by their output (default false; not allowed with --payload-url or --source-template)")
+	cmd.Flags().DurationVar(&opts.Timeout, "exec-timeout", testpayload.DefaultExecTimeout, "Timeout of {{cmd:COMMAND}} commands")
+	cmd.Flags().DurationVar(&opts.CacheTTL, "exec-cache", 0, "Reuse the output of {{cmd:COMMAND}} commands for this long (0 runs them for every message)")
+}
+
+// Apply configures {{cmd:COMMAND}} placeholders. It must run before ResolvePayloadURL and
+// PayloadSourceOptions.Load, which reject templates of untrusted origin when exec is allowed.
+func (o ExecOptions) Apply() error {
+	return testpayload.SetExecOptions(o.ExecOptions)
+}
+
+// AddStrictTemplateFlag provides a CLI flag that makes unknown placeholder keywords
+// (e.g. a typo like {{str:sentense}}) an error instead of literal text.
+func AddStrictTemplateFlag(cmd *cobra.Command, strict *bool) {
+	cmd.Flags().BoolVar(strict, "strict-template", false, "Fail on unknown template placeholders instead of keeping them as literal text")
+}
+
+// AddTemplateEngineFlag provides a CLI flag selecting the engine payloads are rendered with.
+func AddTemplateEngineFlag(cmd *cobra.Command, engine *string) {
+	cmd.Flags().StringVar(engine, "template-engine", testpayload.EngineBuiltin, "Payload template engine: builtin placeholders, or go for text/template with loops and conditionals")
+}
+
+// AddProtosetFlag adds a --protoset flag with the descriptors used by {{proto:MESSAGE}}
+// placeholders and --mime proto:MESSAGE.
+func AddProtosetFlag(cmd *cobra.Command, protoset *string) {
+	cmd.Flags().StringVar(protoset, "protoset", "", "Binary FileDescriptorSet (protoc --include_imports --descriptor_set_out) for {{proto:MESSAGE}} and --mime proto:MESSAGE")
+}
+
+// LoadProtoset loads the --protoset descriptors, if any, into testpayload.
+func LoadProtoset(path string) error {
+	if path == "" {
+		testpayload.SetProtoFiles(nil)
+		return nil
+	}
+	files, err := testpayload.ReadProtoset(path)
+	if err != nil {
+		return err
+	}
+	testpayload.SetProtoFiles(files)
+	return nil
+}
+
+// AddCorruptRateFlag adds a --corrupt-rate flag mutating a percentage of the built payloads
+// (bit flips, truncation, invalid UTF-8, broken JSON) to exercise consumers' error paths.
+func AddCorruptRateFlag(cmd *cobra.Command, rate *float64) {
+	cmd.Flags().Float64Var(rate, "corrupt-rate", 0, "Percentage (0-100) of payloads to corrupt with bit flips, truncation, invalid UTF-8 or broken JSON")
+}
+
+// AddPayloadSchemaFlag adds a --payload-schema flag shaping the objects of {{json}} and {{cbor}}.
+func AddPayloadSchemaFlag(cmd *cobra.Command, schema *string) {
+	cmd.Flags().StringVar(schema, "payload-schema", "", "YAML/JSON field spec (field: generator) shaping the objects of {{json}} and {{cbor}}")
+}
+
+// LoadPayloadSchema loads the --payload-schema file, if any, into testpayload.
+func LoadPayloadSchema(path string) error {
+	if path == "" {
+		testpayload.ClearPayloadSchema()
+		return nil
+	}
+	return testpayload.LoadPayloadSchema(path)
+}
+
 // ParseHeaders parses a slice of "key=value" strings into a map.
 // Returns an error if any header is malformed.
 // Uses default template delimiters "{{" and "}}".
@@ -300,8 +910,54 @@ func ParseHeaders(headers []string) (map[string]string, error) {
 	return ParseHeadersWithDelimiters(headers, "{{", "}}")
 }
 
+// Destination is a send target (topic, subject, channel, ...) that may contain template
+// placeholders resolved on every send, e.g. "sensors/{{counter}}", to fan out messages.
+type Destination struct {
+	raw        string
+	openDelim  string
+	closeDelim string
+	templated  bool
+}
+
+// NewDestination prepares raw for per-send resolution. Destinations without placeholders
+// are cached as-is and never interpolated.
+func NewDestination(raw string, openDelim string, closeDelim string) Destination {
+	return Destination{
+		raw:        raw,
+		openDelim:  openDelim,
+		closeDelim: closeDelim,
+		templated:  strings.Contains(raw, openDelim),
+	}
+}
+
+// Resolve returns the destination for the next send.
+func (d Destination) Resolve() (string, error) {
+	if !d.templated {
+		return d.raw, nil
+	}
+	b, err := testpayload.InterpolateWithDelimiters(d.raw, d.openDelim, d.closeDelim)
+	if err != nil {
+		return "", fmt.Errorf("failed to interpolate destination %q: %w", d.raw, err)
+	}
+	return string(b), nil
+}
+
+// HeaderBase64Prefix marks header values that were base64-encoded because they were not valid UTF-8.
+const HeaderBase64Prefix = "base64:"
+
+// headerBase64 controls the encoding of binary header values, see SetHeaderBase64.
+var headerBase64 = true
+
+// SetHeaderBase64 toggles base64 encoding of header values that are not valid UTF-8
+// after interpolation (enabled by default). When disabled, the raw bytes are used as-is.
+func SetHeaderBase64(enabled bool) {
+	headerBase64 = enabled
+}
+
 // ParseHeadersWithDelimiters parses headers with template interpolation using custom delimiters.
 // Header values support template variables like {{nowtime}}, {{counter}}, {{file:/path}}, etc.
+// Values that are not valid UTF-8 after interpolation (e.g. {{cbor}}) are base64-encoded and
+// prefixed with HeaderBase64Prefix so they survive text-only header transports.
 func ParseHeadersWithDelimiters(headers []string, openDelim string, closeDelim string) (map[string]string, error) {
 	result := make(map[string]string)
 	for _, h := range headers {
@@ -322,8 +978,8 @@ func ParseHeadersWithDelimiters(headers []string, openDelim string, closeDelim s
 		}
 
 		// If header value contains non-UTF8 or binary data, base64 encode it.
-		if !utf8.Valid(interpolatedValue) {
-			result[key] = base64.StdEncoding.EncodeToString(interpolatedValue)
+		if headerBase64 && !utf8.Valid(interpolatedValue) {
+			result[key] = HeaderBase64Prefix + base64.StdEncoding.EncodeToString(interpolatedValue)
 		} else {
 			result[key] = string(interpolatedValue)
 		}
@@ -336,12 +992,23 @@ func AddHeadersFlag(cmd *cobra.Command, headers *[]string) {
 	cmd.Flags().StringArrayVarP(headers, "header", "H", []string{}, "Metadata/header in key=value format (can be repeated)")
 }
 
+// AddNoHeaderBase64Flag adds --no-header-base64 to send binary header values unencoded.
+func AddNoHeaderBase64Flag(cmd *cobra.Command, noBase64 *bool) {
+	cmd.Flags().BoolVar(noBase64, "no-header-base64", false, "Send non-UTF8 header values as raw bytes instead of base64 with a 'base64:' prefix")
+}
+
 // AddIntervalFlag adds a common interval flag for periodic actions.
 func AddIntervalFlag(cmd *cobra.Command, interval *string, def string) {
 	if def == "" {
 		def = "5s"
 	}
-	cmd.Flags().StringVar(interval, "interval", def, "Interval between actions, e.g. 2s, 500ms, 1m")
+	cmd.Flags().StringVar(interval, "interval", def, "Interval between actions, e.g. 2s, 500ms, 250us, 1m")
+}
+
+// AddRepeatBodyFlags adds --repeat-body and --repeat-separator for building large single messages.
+func AddRepeatBodyFlags(cmd *cobra.Command, n *int, sep *string) {
+	cmd.Flags().IntVar(n, "repeat-body", 1, "Concatenate the built payload N times into a single message (re-interpolated per repetition)")
+	cmd.Flags().StringVar(sep, "repeat-separator", "", "Separator inserted between --repeat-body repetitions")
 }
 
 // AddOnceFlag adds a flag to execute the action once and exit.
@@ -349,6 +1016,113 @@ func AddOnceFlag(cmd *cobra.Command, once *bool) {
 	cmd.Flags().BoolVar(once, "once", false, "Execute once and exit (ignores --interval)")
 }
 
+// DefaultConnectTimeout is the default limit for establishing broker/server connections.
+const DefaultConnectTimeout = 10 * time.Second
+
+// AddPrintPayloadFlag adds a flag to print the interpolated payload and exit without connecting.
+func AddPrintPayloadFlag(cmd *cobra.Command, printPayload *bool) {
+	cmd.Flags().BoolVar(printPayload, "print-payload", false, "Interpolate the payload once, write the raw bytes to stdout and exit (no connection)")
+}
+
+// PrintPayload builds the payload once and writes the raw bytes to stdout, unformatted,
+// with the detected content type on stderr. It backs --print-payload.
+func PrintPayload(rawPayload string, mime string, openDelim string, closeDelim string) error {
+	b, ct, err := BuildPayloadWithDelimiters(rawPayload, mime, openDelim, closeDelim)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stderr, "Content-Type: %s (%d bytes)\n", ct, len(b))
+	if _, err := stdout.Write(b); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	return nil
+}
+
+// AddInteractiveFlag adds a flag to read payloads line by line from stdin (REPL producer).
+func AddInteractiveFlag(cmd *cobra.Command, interactive *bool) {
+	cmd.Flags().BoolVar(interactive, "interactive", false, "Read payload templates line by line from stdin and send each one (exit with Ctrl-D)")
+}
+
+// RunInteractive is a simple REPL producer: it reads lines from in, stores each one in
+// *payload (so it is interpolated like --payload) and calls send, printing the result.
+// Empty lines are skipped. It returns nil on EOF or when ctx is cancelled.
+func RunInteractive(ctx context.Context, in io.Reader, payload *string, send func() error) error {
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+		errc <- scanner.Err()
+	}()
+
+	count := 0
+	for {
+		_, _ = fmt.Fprint(stderr, "> ")
+		select {
+		case <-ctx.Done():
+			_, _ = fmt.Fprintln(stderr)
+			return nil
+		case err := <-errc:
+			_, _ = fmt.Fprintln(stderr)
+			if err != nil {
+				return fmt.Errorf("failed to read stdin: %w", err)
+			}
+			PrintInfo("Sent %d messages", count)
+			return nil
+		case line := <-lines:
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			*payload = line
+			if err := send(); err != nil {
+				PrintError("Send failed: %v", err)
+				continue
+			}
+			count++
+			PrintSuccess("Sent message %d", count)
+		}
+	}
+}
+
+// AddConnectTimeoutFlag adds a --connect-timeout flag bounding the initial connection attempt.
+func AddConnectTimeoutFlag(cmd *cobra.Command, timeout *time.Duration) {
+	cmd.Flags().DurationVar(timeout, "connect-timeout", DefaultConnectTimeout, "Maximum time to wait for the connection to be established (0 disables the limit)")
+}
+
+// ConnectRetryOptions holds the --wait-for-broker retry settings of send commands.
+type ConnectRetryOptions struct {
+	WaitForBroker bool
+	Retries       int
+	Interval      time.Duration
+}
+
+// AddConnectRetryFlags adds --wait-for-broker, --connect-retries and --connect-retry-interval.
+func AddConnectRetryFlags(cmd *cobra.Command, opts *ConnectRetryOptions) {
+	cmd.Flags().BoolVar(&opts.WaitForBroker, "wait-for-broker", false, "Retry the initial connection with backoff instead of exiting when the broker is not up yet")
+	cmd.Flags().IntVar(&opts.Retries, "connect-retries", 10, "Number of connection retries with --wait-for-broker")
+	cmd.Flags().DurationVar(&opts.Interval, "connect-retry-interval", time.Second, "Initial delay between connection retries with --wait-for-broker (doubles after each attempt, up to 30s)")
+}
+
+// Policy returns the retry policy for common.ConnectWithRetry; retries are disabled without --wait-for-broker.
+func (o ConnectRetryOptions) Policy() common.RetryPolicy {
+	if !o.WaitForBroker {
+		return common.RetryPolicy{}
+	}
+	return common.RetryPolicy{Retries: o.Retries, Interval: o.Interval}
+}
+
+// AddReconnectEveryFlag adds a --reconnect-every flag that periodically re-establishes the connection.
+func AddReconnectEveryFlag(cmd *cobra.Command, every *time.Duration) {
+	cmd.Flags().DurationVar(every, "reconnect-every", 0, "Tear down and re-establish the broker connection at this interval to test client resilience (0 disables)")
+}
+
 // AddServerFlag adds a standardized server/broker/connection flag.
 // Supports aliases for backward compatibility (e.g., --address, --broker).
 func AddServerFlag(cmd *cobra.Command, server *string, def string, aliases ...string) {
@@ -1,12 +1,28 @@
 package toolutil
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fxamacker/cbor/v2"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func TestLogger(t *testing.T) {
@@ -39,7 +55,7 @@ func TestPrettyBodyByMIME(t *testing.T) {
 			name:     "Valid CBOR",
 			mime:     "application/cbor",
 			body:     mustEncodeCBOR(t, map[string]interface{}{"name": "test"}),
-			notEmpty: false, // CBOR decoding might fail in colorjson
+			notEmpty: true,
 		},
 		{
 			name:     "Plain text",
@@ -198,7 +214,12 @@ func TestGuessMIME(t *testing.T) {
 		{
 			name: "Plain text",
 			body: []byte("hello world"),
-			want: CTCBOR, // 'h' (0x68) matches CBOR text string pattern
+			want: CTText, // 'h' (0x68) is a CBOR text string head, but the body is valid UTF-8
+		},
+		{
+			name: "Bracketed text",
+			body: []byte("[INFO] started"),
+			want: CTText,
 		},
 		{
 			name: "Empty",
@@ -269,6 +290,131 @@ func TestAddIntervalFlag(t *testing.T) {
 	}
 }
 
+func TestAddConnectTimeoutFlag(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var timeout time.Duration
+	AddConnectTimeoutFlag(cmd, &timeout)
+
+	f := cmd.Flags().Lookup("connect-timeout")
+	if f == nil {
+		t.Fatal("AddConnectTimeoutFlag() did not add 'connect-timeout' flag")
+	}
+	if f.DefValue != DefaultConnectTimeout.String() {
+		t.Errorf("connect-timeout default = %s, want %s", f.DefValue, DefaultConnectTimeout)
+	}
+}
+
+func TestDestination(t *testing.T) {
+	static := NewDestination("sensors/static", "{{", "}}")
+	if got, err := static.Resolve(); err != nil || got != "sensors/static" {
+		t.Errorf("Resolve() = %q, %v, want static destination", got, err)
+	}
+
+	templated := NewDestination("tenant-<<var:tenant>>", "<<", ">>")
+	testpayload.SetTemplateVars(map[string]string{"tenant": "acme"})
+	defer testpayload.SetTemplateVars(nil)
+	if got, err := templated.Resolve(); err != nil || got != "tenant-acme" {
+		t.Errorf("Resolve() = %q, %v, want %q", got, err, "tenant-acme")
+	}
+
+	first, _ := NewDestination("sensors/{{counter}}", "{{", "}}").Resolve()
+	second, _ := NewDestination("sensors/{{counter}}", "{{", "}}").Resolve()
+	if first == second {
+		t.Errorf("Resolve() should interpolate on every call, got %q twice", first)
+	}
+}
+
+func TestPrintPayload(t *testing.T) {
+	out, errOut := captureStreams(t)
+
+	if err := PrintPayload(`{"id":<<counter>>}`, "", "<<", ">>"); err != nil {
+		t.Fatalf("PrintPayload() error = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), `{"id":`) || strings.Contains(out.String(), "<<") {
+		t.Errorf("PrintPayload() stdout = %q, want interpolated raw payload", out.String())
+	}
+	if !strings.Contains(errOut.String(), "Content-Type: "+CTJSON) {
+		t.Errorf("PrintPayload() stderr = %q, want detected content type", errOut.String())
+	}
+}
+
+func TestRunInteractive(t *testing.T) {
+	_, errOut := captureStreams(t)
+
+	var payload string
+	var sent []string
+	in := strings.NewReader("first\n\nsecond\nfail\n")
+	err := RunInteractive(context.Background(), in, &payload, func() error {
+		if payload == "fail" {
+			return errors.New("boom")
+		}
+		sent = append(sent, payload)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInteractive() error = %v", err)
+	}
+	if len(sent) != 2 || sent[0] != "first" || sent[1] != "second" {
+		t.Errorf("RunInteractive() sent = %v, want [first second]", sent)
+	}
+	for _, want := range []string{"Sent message 2", "Send failed: boom", "Sent 2 messages"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Errorf("stderr missing %q:\n%s", want, errOut.String())
+		}
+	}
+}
+
+func TestRepeatBody(t *testing.T) {
+	calls := 0
+	build := func() ([]byte, error) {
+		calls++
+		return []byte(fmt.Sprintf("p%d", calls)), nil
+	}
+
+	got, err := RepeatBody(3, ",", build)
+	if err != nil {
+		t.Fatalf("RepeatBody() error = %v", err)
+	}
+	if string(got) != "p1,p2,p3" {
+		t.Errorf("RepeatBody() = %q, want %q", got, "p1,p2,p3")
+	}
+
+	calls = 0
+	got, err = RepeatBody(0, ",", build)
+	if err != nil || string(got) != "p1" {
+		t.Errorf("RepeatBody(0) = %q, %v, want single body", got, err)
+	}
+
+	want := errors.New("boom")
+	if _, err := RepeatBody(2, "", func() ([]byte, error) { return nil, want }); err != want {
+		t.Errorf("RepeatBody() error = %v, want %v", err, want)
+	}
+}
+
+func TestConnectRetryOptions(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts ConnectRetryOptions
+	AddConnectRetryFlags(cmd, &opts)
+
+	for _, name := range []string{"wait-for-broker", "connect-retries", "connect-retry-interval"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Fatalf("AddConnectRetryFlags() did not add '%s' flag", name)
+		}
+	}
+
+	if p := opts.Policy(); p.Retries != 0 {
+		t.Errorf("Policy() without --wait-for-broker retries = %d, want 0", p.Retries)
+	}
+
+	if err := cmd.Flags().Parse([]string{"--wait-for-broker", "--connect-retries", "3", "--connect-retry-interval", "2s"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	p := opts.Policy()
+	if p.Retries != 3 || p.Interval != 2*time.Second {
+		t.Errorf("Policy() = %+v, want 3 retries every 2s", p)
+	}
+}
+
 func TestAddServerFlag(t *testing.T) {
 	cmd := &cobra.Command{Use: "test"}
 	var server string
@@ -321,6 +467,67 @@ func TestPrintColoredMessage(t *testing.T) {
 	PrintColoredMessage("Test Title", sections, body, CTJSON)
 }
 
+// captureStreams redirects the toolutil stdout/stderr writers to buffers for the duration of the test.
+func captureStreams(t *testing.T) (out, errOut *bytes.Buffer) {
+	t.Helper()
+	out, errOut = &bytes.Buffer{}, &bytes.Buffer{}
+	prevOut, prevErr := stdout, stderr
+	stdout, stderr = out, errOut
+	t.Cleanup(func() { stdout, stderr = prevOut, prevErr })
+	return out, errOut
+}
+
+func TestOutputStreams(t *testing.T) {
+	out, errOut := captureStreams(t)
+
+	PrintInfo("info %d", 1)
+	PrintSuccess("success")
+	PrintWarning("warning")
+	PrintError("error")
+	PrintHeader("header")
+	PrintKeyValue("key", "value")
+	Logger().Info("log line")
+
+	if out.Len() != 0 {
+		t.Errorf("diagnostics written to stdout: %q", out.String())
+	}
+	for _, want := range []string{"info 1", "success", "warning", "error", "header", "key", "value", "log line"} {
+		if !strings.Contains(errOut.String(), want) {
+			t.Errorf("stderr missing %q:\n%s", want, errOut.String())
+		}
+	}
+
+	errOut.Reset()
+	PrintColoredMessage("Stream", []MessageSection{{Title: "Meta", Items: []KV{{Key: "K", Value: "V"}}}}, []byte("payload"), CTText)
+
+	if errOut.Len() != 0 {
+		t.Errorf("message content written to stderr: %q", errOut.String())
+	}
+	if !strings.Contains(out.String(), "payload") {
+		t.Errorf("stdout missing message body:\n%s", out.String())
+	}
+}
+
+func TestSplitWellKnownHeaders(t *testing.T) {
+	items := []KV{
+		{Key: "Content-Type", Value: CTJSON},
+		{Key: "x-tenant", Value: "acme"},
+		{Key: "correlation-id", Value: "c-1"},
+		{Key: "TRACE-ID", Value: "t-1"},
+		{Key: "retry", Value: "2"},
+	}
+	wellKnown, others := SplitWellKnownHeaders(items)
+	if len(wellKnown) != 3 || wellKnown[0].Key != "Content-Type" || wellKnown[1].Key != "correlation-id" || wellKnown[2].Key != "TRACE-ID" {
+		t.Errorf("SplitWellKnownHeaders() wellKnown = %v", wellKnown)
+	}
+	if len(others) != 2 || others[0].Key != "x-tenant" || others[1].Key != "retry" {
+		t.Errorf("SplitWellKnownHeaders() others = %v", others)
+	}
+	if IsWellKnownHeader("x-tenant") {
+		t.Error("IsWellKnownHeader() should be false for x-tenant")
+	}
+}
+
 func TestConstants(t *testing.T) {
 	if CTJSON != "application/json" {
 		t.Errorf("CTJSON = %v, want 'application/json'", CTJSON)
@@ -402,6 +609,35 @@ func TestBuildPayload_MimeAutoDetect(t *testing.T) {
 	}
 }
 
+func TestBuildPayload_MimeAutoDetectLiteral(t *testing.T) {
+	tests := []struct {
+		name       string
+		rawPayload string
+		want       string
+	}{
+		{"Plain text literal", "hello world", CTText},
+		{"Text starting with CBOR-like byte", "temperature reading", CTText},
+		{"JSON object literal", `{"name":"test"}`, CTJSON},
+		{"JSON array literal", ` [1,2,3] `, CTJSON},
+		{"Broken JSON-like text", `{not json`, CTText},
+		{"Bare number", "42", CTText},
+		{"Templated text", "Message: {{sentence}}", CTText},
+		{"CBOR placeholder", "{{cbor}}", CTCBOR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, contentType, err := BuildPayloadWithDelimiters(tt.rawPayload, "", "{{", "}}")
+			if err != nil {
+				t.Fatalf("BuildPayloadWithDelimiters() error = %v", err)
+			}
+			if contentType != tt.want {
+				t.Errorf("BuildPayloadWithDelimiters() contentType = %v, want %v", contentType, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseHeaders(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -589,6 +825,165 @@ func TestAddSeedFlagAndAllowFileReadsFlag(t *testing.T) {
 	}
 }
 
+func TestAddAllowEnvReadsFlag(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var allow bool
+	AddAllowEnvReadsFlag(cmd, &allow)
+	if err := cmd.ParseFlags([]string{"--allow-env"}); err != nil || !allow {
+		t.Errorf("AddAllowEnvReadsFlag() did not parse --allow-env: %v", err)
+	}
+}
+
+func TestExecOptions(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts ExecOptions
+	AddExecFlags(cmd, &opts)
+	if err := cmd.ParseFlags([]string{"--allow-exec", "--exec-cache", "1m"}); err != nil {
+		t.Fatal(err)
+	}
+	if !opts.Allow || opts.Timeout != testpayload.DefaultExecTimeout || opts.CacheTTL != time.Minute {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if err := opts.Apply(); err != nil {
+		t.Errorf("Apply() error = %v", err)
+	}
+	defer func() { _ = ExecOptions{}.Apply() }()
+
+	opts.Timeout = -time.Second
+	if err := opts.Apply(); err == nil {
+		t.Error("expected an error for a negative --exec-timeout")
+	}
+}
+
+func TestAddStrictTemplateFlag(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var strict bool
+	AddStrictTemplateFlag(cmd, &strict)
+	if cmd.Flags().Lookup("strict-template") == nil {
+		t.Error("AddStrictTemplateFlag() did not add 'strict-template' flag")
+	}
+}
+
+func TestFetchPayloadTemplate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+			return
+		case "/large":
+			_, _ = w.Write(bytes.Repeat([]byte("x"), maxPayloadTemplateSize+1))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":{{counter}}}`))
+	}))
+	defer srv.Close()
+
+	b, err := FetchPayloadTemplate(srv.URL+"/fixture.json", nil)
+	if err != nil {
+		t.Fatalf("FetchPayloadTemplate() error = %v", err)
+	}
+	if string(b) != `{"id":{{counter}}}` {
+		t.Errorf("FetchPayloadTemplate() = %s", string(b))
+	}
+
+	if _, err := FetchPayloadTemplate(srv.URL+"/missing", nil); err == nil {
+		t.Error("FetchPayloadTemplate() expected error for non-200 response")
+	}
+	if _, err := FetchPayloadTemplate(srv.URL+"/large", nil); err == nil {
+		t.Error("FetchPayloadTemplate() expected error for a template over the size limit")
+	}
+	if _, err := FetchPayloadTemplate("ftp://example.com/x", nil); err == nil {
+		t.Error("FetchPayloadTemplate() expected error for unsupported scheme")
+	}
+}
+
+func TestResolvePayloadURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("remote {{counter}}"))
+	}))
+	defer srv.Close()
+
+	newCmd := func() (*cobra.Command, *string, *string) {
+		cmd := &cobra.Command{Use: "test"}
+		var payload, mime, payloadURL string
+		AddPayloadFlags(cmd, &payload, "default", &mime, CTText)
+		AddPayloadURLFlag(cmd, &payloadURL)
+		return cmd, &payload, &payloadURL
+	}
+
+	cmd, payload, payloadURL := newCmd()
+	if err := cmd.ParseFlags([]string{"--payload-url", srv.URL}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := ResolvePayloadURL(cmd, *payloadURL, payload); err != nil {
+		t.Fatalf("ResolvePayloadURL() error = %v", err)
+	}
+	if *payload != "remote {{counter}}" {
+		t.Errorf("payload = %q, want remote template", *payload)
+	}
+
+	cmd, payload, payloadURL = newCmd()
+	if err := cmd.ParseFlags([]string{"--payload", "x", "--payload-url", srv.URL}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := ResolvePayloadURL(cmd, *payloadURL, payload); err == nil {
+		t.Error("ResolvePayloadURL() expected error when --payload is also set")
+	}
+
+	cmd, payload, payloadURL = newCmd()
+	if err := ResolvePayloadURL(cmd, *payloadURL, payload); err != nil || *payload != "default" {
+		t.Errorf("ResolvePayloadURL() without URL changed payload: %q, %v", *payload, err)
+	}
+
+	if err := (ExecOptions{testpayload.ExecOptions{Allow: true}}).Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	defer func() { _ = ExecOptions{}.Apply() }()
+	cmd, payload, payloadURL = newCmd()
+	if err := cmd.ParseFlags([]string{"--payload-url", srv.URL}); err != nil {
+		t.Fatalf("ParseFlags() error = %v", err)
+	}
+	if err := ResolvePayloadURL(cmd, *payloadURL, payload); err == nil {
+		t.Error("ResolvePayloadURL() expected error with --allow-exec")
+	}
+}
+
+func TestResolvePayloadURL_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("secure {{counter}}"))
+	}))
+	defer srv.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := func(args ...string) (string, error) {
+		cmd := &cobra.Command{Use: "test"}
+		var payload, mime, payloadURL string
+		AddPayloadFlags(cmd, &payload, "default", &mime, CTText)
+		AddPayloadURLFlag(cmd, &payloadURL)
+		if err := cmd.ParseFlags(append([]string{"--payload-url", srv.URL}, args...)); err != nil {
+			t.Fatalf("ParseFlags() error = %v", err)
+		}
+		err := ResolvePayloadURL(cmd, payloadURL, &payload)
+		return payload, err
+	}
+
+	if _, err := resolve(); err == nil {
+		t.Error("ResolvePayloadURL() expected error for an untrusted certificate")
+	}
+	for _, args := range [][]string{{"--payload-url-ca", caFile}, {"--payload-url-insecure"}} {
+		if payload, err := resolve(args...); err != nil || payload != "secure {{counter}}" {
+			t.Errorf("ResolvePayloadURL(%v) = %q, %v", args, payload, err)
+		}
+	}
+	if _, err := resolve("--payload-url-cert", caFile); err == nil {
+		t.Error("ResolvePayloadURL() expected error for a certificate without key")
+	}
+}
+
 func TestParseTemplateVars(t *testing.T) {
 	vars := []string{"a=1", "b=two", "c = three"}
 	got, err := ParseTemplateVars(vars)
@@ -609,6 +1004,141 @@ func TestAddFileRootFlag(t *testing.T) {
 	}
 }
 
+func TestDataSourceOptions(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts DataSourceOptions
+	AddDataSourceFlags(cmd, &opts)
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(path, []byte("name\nalpha\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Flags().Parse([]string{"--data-csv", path}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := opts.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	defer testpayload.ClearDataSource()
+
+	b, _, err := BuildPayload("{{col:name}}", "")
+	if err != nil || string(b) != "alpha" {
+		t.Fatalf("BuildPayload() = %q, %v", b, err)
+	}
+	if _, _, err := BuildPayload("{{col:name}}", ""); !errors.Is(err, common.ErrStop) {
+		t.Errorf("BuildPayload() after the last row = %v, want common.ErrStop", err)
+	}
+}
+
+func TestPayloadSourceOptions(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var opts PayloadSourceOptions
+	AddPayloadSourceFlags(cmd, &opts)
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	if err := os.WriteFile(path, []byte("{\"a\":1}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Flags().Parse([]string{"--payload-source", path, "--source-rate", "4"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	interval := "5s"
+	if err := opts.Load(&interval); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	defer testpayload.ClearPayloadSource()
+	if interval != "250ms" {
+		t.Errorf("--source-rate 4 set interval %q, want 250ms", interval)
+	}
+
+	b, ct, err := BuildPayload("ignored", "")
+	if err != nil || string(b) != `{"a":1}` || ct != CTJSON {
+		t.Fatalf("BuildPayload() = %q, %q, %v", b, ct, err)
+	}
+	if _, _, err := BuildPayload("ignored", ""); !errors.Is(err, common.ErrStop) {
+		t.Errorf("BuildPayload() after the last line = %v, want common.ErrStop", err)
+	}
+
+	opts.Rate = -1
+	if err := opts.Load(&interval); err == nil {
+		t.Error("expected an error for a negative --source-rate")
+	}
+
+	if err := (ExecOptions{testpayload.ExecOptions{Allow: true}}).Apply(); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	defer func() { _ = ExecOptions{}.Apply() }()
+	opts.Rate = 0
+	opts.Template = true
+	if err := opts.Load(&interval); err == nil {
+		t.Error("expected an error for --source-template with --allow-exec")
+	}
+}
+
+func TestAddTemplateEngineFlag(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	var engine string
+	AddTemplateEngineFlag(cmd, &engine)
+	if engine != testpayload.EngineBuiltin {
+		t.Errorf("default engine = %q, want %q", engine, testpayload.EngineBuiltin)
+	}
+	if err := cmd.Flags().Parse([]string{"--template-engine", "go"}); err != nil || engine != testpayload.EngineGo {
+		t.Errorf("--template-engine go = %q, %v", engine, err)
+	}
+}
+
+func TestBuildPayload_CorruptRate(t *testing.T) {
+	if err := testpayload.SetCorruptRate(100); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = testpayload.SetCorruptRate(0) }()
+
+	payload := `{"id":"abc","n":5}`
+	for range 20 {
+		b, ct, err := BuildPayload(payload, "")
+		if err != nil {
+			t.Fatalf("BuildPayload() error = %v", err)
+		}
+		if ct != CTJSON {
+			t.Errorf("content type = %q, want %q kept for the corrupted payload", ct, CTJSON)
+		}
+		if string(b) == payload {
+			t.Errorf("payload %q was not corrupted", b)
+		}
+	}
+}
+
+func TestBuildPayload_ProtoMIME(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(durationpb.File_google_protobuf_duration_proto),
+	}}
+	b, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "duration.protoset")
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadProtoset(path); err != nil {
+		t.Fatalf("LoadProtoset() error = %v", err)
+	}
+	defer func() { _ = LoadProtoset("") }()
+
+	body, ct, err := BuildPayload(`"{{randint:90:90}}s"`, "proto:google.protobuf.Duration")
+	if err != nil || ct != CTProtobuf {
+		t.Fatalf("BuildPayload() = %q, %v", ct, err)
+	}
+	var d durationpb.Duration
+	if err := proto.Unmarshal(body, &d); err != nil || d.GetSeconds() != 90 {
+		t.Errorf("decoded %v, %v", &d, err)
+	}
+	if _, _, err := BuildPayload(`"soon"`, "proto:google.protobuf.Duration"); err == nil {
+		t.Error("expected an error for a payload not matching the message")
+	}
+	if err := LoadProtoset(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing protoset")
+	}
+}
+
 func TestParseHeadersWithDelimiters(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -715,8 +1245,12 @@ func TestParseHeadersWithDelimiters(t *testing.T) {
 					t.Error("X-Bin header not found")
 					return
 				}
-				// Should be base64 string, decodeable into CBOR
-				decoded, err := base64.StdEncoding.DecodeString(val)
+				// Should be a marked base64 string, decodeable into CBOR
+				if !strings.HasPrefix(val, HeaderBase64Prefix) {
+					t.Errorf("X-Bin missing %q marker: %s", HeaderBase64Prefix, val)
+					return
+				}
+				decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(val, HeaderBase64Prefix))
 				if err != nil {
 					t.Errorf("X-Bin not base64 encoded: %v", err)
 					return
@@ -742,3 +1276,33 @@ func TestParseHeadersWithDelimiters(t *testing.T) {
 		})
 	}
 }
+
+func TestParseHeaders_MixedBinary(t *testing.T) {
+	testpayload.AddTemplateVar("bin", "\xff\xfe")
+	defer testpayload.ClearTemplateVars()
+	headers := []string{"X-Text=hello {{counter}}", "X-Bin=id-{{var:bin}}", "X-Utf8=caffè"}
+
+	got, err := ParseHeaders(headers)
+	if err != nil {
+		t.Fatalf("ParseHeaders() error = %v", err)
+	}
+	if !strings.HasPrefix(got["X-Text"], "hello ") {
+		t.Errorf("X-Text = %q, want plain text", got["X-Text"])
+	}
+	if got["X-Utf8"] != "caffè" {
+		t.Errorf("X-Utf8 = %q, valid UTF-8 should not be encoded", got["X-Utf8"])
+	}
+	if want := HeaderBase64Prefix + base64.StdEncoding.EncodeToString([]byte("id-\xff\xfe")); got["X-Bin"] != want {
+		t.Errorf("X-Bin = %q, want %q", got["X-Bin"], want)
+	}
+
+	SetHeaderBase64(false)
+	defer SetHeaderBase64(true)
+	got, err = ParseHeaders(headers)
+	if err != nil {
+		t.Fatalf("ParseHeaders() error = %v", err)
+	}
+	if got["X-Bin"] != "id-\xff\xfe" {
+		t.Errorf("X-Bin = %q, want raw bytes with base64 disabled", got["X-Bin"])
+	}
+}
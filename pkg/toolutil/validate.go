@@ -0,0 +1,145 @@
+package toolutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// ValidationSectionTitle is the title of the section added to messages by --validate-schema.
+const ValidationSectionTitle = "Validation"
+
+// Compiled --validate-schema schema, see SetupServe, and the counts of checked messages.
+var (
+	schemaMutex     = sync.Mutex{}
+	messageSchema   *jsonschema.Schema
+	validatedPassed int
+	validatedFailed int
+)
+
+// LoadSchema compiles the JSON Schema file at path for --validate-schema. Drafts 4 to
+// 2020-12 are supported, selected by $schema (default 2020-12).
+func LoadSchema(path string) (*jsonschema.Schema, error) {
+	sch, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return sch, nil
+}
+
+// setSchema sets the schema received messages are validated against (nil disables
+// validation) and resets the counts.
+func setSchema(sch *jsonschema.Schema) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	messageSchema = sch
+	validatedPassed, validatedFailed = 0, 0
+}
+
+// ValidationCounts returns the number of messages that passed and failed --validate-schema
+// since SetupServe.
+func ValidationCounts() (passed, failed int) {
+	schemaMutex.Lock()
+	defer schemaMutex.Unlock()
+	return validatedPassed, validatedFailed
+}
+
+// validateMessage validates the decoded JSON or CBOR body against --validate-schema,
+// returning a Validation section with the PASS or FAIL result followed by one item per
+// violation (instance location: error). Other bodies fail. ok is false without a schema.
+func validateMessage(body []byte, mime string) (section MessageSection, ok bool) {
+	schemaMutex.Lock()
+	sch := messageSchema
+	schemaMutex.Unlock()
+	if sch == nil {
+		return MessageSection{}, false
+	}
+	violations := schemaViolations(sch, body, mime)
+
+	schemaMutex.Lock()
+	if len(violations) == 0 {
+		validatedPassed++
+	} else {
+		validatedFailed++
+	}
+	schemaMutex.Unlock()
+
+	if len(violations) == 0 {
+		return MessageSection{Title: ValidationSectionTitle, Items: []KV{{Key: "Result", Value: "PASS"}}}, true
+	}
+	items := append([]KV{{Key: "Result", Value: "FAIL"}}, violations...)
+	return MessageSection{Title: ValidationSectionTitle, Items: items}, true
+}
+
+// schemaViolations validates a body against sch, returning its violations.
+func schemaViolations(sch *jsonschema.Schema, body []byte, mime string) []KV {
+	inst, ok := schemaInstance(body, mime)
+	if !ok {
+		return []KV{{Key: "body", Value: "not a JSON or CBOR document"}}
+	}
+	err := sch.Validate(inst)
+	if err == nil {
+		return nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return []KV{{Key: "body", Value: err.Error()}}
+	}
+	var violations []KV
+	for _, unit := range verr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		loc := unit.InstanceLocation
+		if loc == "" {
+			loc = "/"
+		}
+		violations = append(violations, KV{Key: loc, Value: unit.Error.String()})
+	}
+	if len(violations) == 0 {
+		violations = []KV{{Key: "body", Value: verr.Error()}}
+	}
+	return violations
+}
+
+// schemaInstance decodes a JSON or CBOR body into the values the validator expects, keeping
+// the precision of JSON numbers.
+func schemaInstance(body []byte, mime string) (any, bool) {
+	for _, m := range []string{mime, GuessMIME(body)} {
+		obj, ok := decodeStructuredBody(m, body)
+		if !ok {
+			continue
+		}
+		doc := body
+		if !strings.Contains(strings.ToLower(m), "json") {
+			var err error
+			if doc, err = json.Marshal(obj); err != nil {
+				return nil, false
+			}
+		}
+		inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(doc))
+		if err != nil {
+			return nil, false
+		}
+		return inst, true
+	}
+	return nil, false
+}
+
+// checkValidation prints the --validate-schema counts and fails when any message failed.
+func checkValidation(opts *ServeOptions) error {
+	if opts.ValidateSchema == "" {
+		return nil
+	}
+	passed, failed := ValidationCounts()
+	PrintInfo("Schema validation: %d passed, %d failed", passed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d messages failed schema validation", failed, passed+failed)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package toolutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["id", "temp"],
+	"properties": {
+		"id": {"type": "string"},
+		"temp": {"type": "number", "maximum": 100}
+	}
+}`
+
+func writeTestSchema(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(testSchema), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateMessage(t *testing.T) {
+	defer setSchema(nil)
+	if _, ok := validateMessage([]byte(`{}`), CTJSON); ok {
+		t.Error("validateMessage() should not validate without a schema")
+	}
+	sch, err := LoadSchema(writeTestSchema(t))
+	if err != nil {
+		t.Fatalf("LoadSchema() error = %v", err)
+	}
+	setSchema(sch)
+	cborBody, err := cbor.Marshal(map[string]any{"id": "a", "temp": 21.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		body []byte
+		mime string
+		want []string
+	}{
+		{[]byte(`{"id":"a","temp":21.5}`), CTJSON, []string{"PASS"}},
+		{cborBody, CTCBOR, []string{"PASS"}},
+		{[]byte(`{"id":1,"temp":120}`), "", []string{"FAIL", "/id", "/temp"}},
+		{[]byte(`{"id":"a"}`), CTJSON, []string{"FAIL", "/", "temp"}},
+		{[]byte("plain text"), CTText, []string{"FAIL", "body"}},
+	}
+	for _, tt := range tests {
+		section, ok := validateMessage(tt.body, tt.mime)
+		if !ok || section.Title != ValidationSectionTitle {
+			t.Fatalf("validateMessage(%q) = %+v, %v", tt.body, section, ok)
+		}
+		var got strings.Builder
+		for _, kv := range section.Items {
+			got.WriteString(kv.Key + ": " + kv.Value + "\n")
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got.String(), want) {
+				t.Errorf("validateMessage(%q) items missing %q:\n%s", tt.body, want, got.String())
+			}
+		}
+	}
+	if passed, failed := ValidationCounts(); passed != 2 || failed != 3 {
+		t.Errorf("ValidationCounts() = %d, %d, want 2, 3", passed, failed)
+	}
+}
+
+func TestSetupServe_ValidateSchema(t *testing.T) {
+	out, _ := captureStreams(t)
+	if _, err := SetupServe(&ServeOptions{ValidateSchema: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("SetupServe() expected error for a missing schema")
+	}
+	opts := &ServeOptions{ValidateSchema: writeTestSchema(t)}
+	cleanup, err := SetupServe(opts)
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = SetupServe(&ServeOptions{}) }()
+	defer cleanup()
+
+	PrintColoredMessage("Event", nil, []byte(`{"id":"a","temp":20}`), CTJSON)
+	if !strings.Contains(out.String(), "Validation:") || !strings.Contains(out.String(), "PASS") {
+		t.Errorf("expected a passing Validation section:\n%s", out.String())
+	}
+	if err := checkValidation(opts); err != nil {
+		t.Errorf("checkValidation() error = %v", err)
+	}
+	PrintColoredMessage("Event", nil, []byte(`{"id":"b"}`), CTJSON)
+	if err := checkValidation(opts); err == nil {
+		t.Error("checkValidation() expected error after a failing message")
+	}
+}
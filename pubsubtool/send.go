@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"time"
 
 	pubsub "cloud.google.com/go/pubsub/v2"
 	"github.com/sandrolain/eventkit/pkg/common"
@@ -18,12 +21,29 @@ func sendCommand() *cobra.Command {
 		sendPayload    string
 		sendMIME       string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		sendInterval   string
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -33,35 +53,93 @@ func sendCommand() *cobra.Command {
 			ctx, cancel := common.SetupGracefulShutdown()
 			defer cancel()
 
-			client, err := pubsub.NewClient(ctx, sendProject)
-			if err != nil {
-				return fmt.Errorf("Pub/Sub client error: %w", err)
-			}
-			defer func() {
-				if err := client.Close(); err != nil {
-					slog.Error("Failed to close Pub/Sub client", "error", err)
-				}
-			}()
-
-			publisher := client.Publisher(sendTopic)
-			defer publisher.Stop()
-
-			logger := toolutil.Logger()
 			if seed != 0 {
 				testpayload.SeedRandom(seed)
 			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
 			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
 			// file cache
 			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
 			testpayload.SetFileRoot(fileRoot)
 			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
 			if errVars != nil {
 				return fmt.Errorf("invalid template-var: %w", errVars)
 			}
 			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, "{{", "}}")
+			}
+
+			var (
+				client    *pubsub.Client
+				publisher *pubsub.Publisher
+			)
+			connect := func() error {
+				err := common.ConnectWithRetry(ctx, connectRetry.Policy(), func() error {
+					return common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+						var err error
+						client, err = pubsub.NewClient(ctx, sendProject)
+						return err
+					})
+				})
+				if err != nil {
+					return fmt.Errorf("Pub/Sub client error: %w", err)
+				}
+				publisher = client.Publisher(sendTopic)
+				return nil
+			}
+			disconnect := func() {
+				publisher.Stop()
+				if err := client.Close(); err != nil {
+					slog.Error("Failed to close Pub/Sub client", "error", err)
+				}
+			}
+			if err := connect(); err != nil {
+				return err
+			}
+			defer func() { disconnect() }()
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				disconnect()
+				return connect()
+			})
+
+			logger := toolutil.Logger()
 			logger.Info("Publishing to Pub/Sub", "project", sendProject, "topic", sendTopic, "interval", sendInterval)
 
-			return common.RunOnceOrPeriodic(ctx, once, sendInterval, func() error {
+			send := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					logger.Error("Reconnect error", "error", err)
+					return err
+				}
 				body, _, err := toolutil.BuildPayload(sendPayload, sendMIME)
 				if err != nil {
 					logger.Error("Failed to build payload", "error", err)
@@ -76,7 +154,12 @@ func sendCommand() *cobra.Command {
 				}
 				logger.Info("Message sent", "id", id, "bytes", len(body))
 				return nil
-			})
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
 		},
 	}
 
@@ -85,11 +168,28 @@ func sendCommand() *cobra.Command {
 	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, PubSub!", &sendMIME, toolutil.CTText)
 	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 
 	return cmd
 }
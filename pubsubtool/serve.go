@@ -14,18 +14,31 @@ import (
 
 func serveCommand() *cobra.Command {
 	var (
-		subProject string
-		subSub     string
+		subProject     string
+		subSub         string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Subscribe and log Pub/Sub messages",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := common.SetupGracefulShutdown()
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
 			defer cancel()
 
-			client, err := pubsub.NewClient(ctx, subProject)
+			var client *pubsub.Client
+			err = common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				var err error
+				client, err = pubsub.NewClient(ctx, subProject)
+				return err
+			})
 			if err != nil {
 				return fmt.Errorf("Pub/Sub client error: %w", err)
 			}
@@ -53,9 +66,12 @@ func serveCommand() *cobra.Command {
 				}
 
 				ct := toolutil.GuessMIME(m.Data)
-				toolutil.PrintColoredMessage("Pub/Sub", sections, m.Data, ct)
-
-				m.Ack()
+				// Messages dropped after --max-messages are redelivered to the next subscriber
+				if toolutil.PrintColoredMessage("Pub/Sub", sections, m.Data, ct) {
+					m.Ack()
+				} else {
+					m.Nack()
+				}
 			})
 
 			if err != nil {
@@ -68,6 +84,8 @@ func serveCommand() *cobra.Command {
 
 	cmd.Flags().StringVar(&subProject, "project", "test-project", "Google Cloud Project ID")
 	cmd.Flags().StringVar(&subSub, "subscription", "test-sub", "Pub/Sub subscription ID")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	pulsarlog "github.com/apache/pulsar-client-go/pulsar/log"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "pulsartool",
+		Short: "Apache Pulsar client tester",
+		Long:  "A simple Apache Pulsar CLI with producer send and consumer serve commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newClient creates a Pulsar client; the client library only logs warnings and errors.
+func newClient(url, token string, timeout time.Duration) (pulsar.Client, error) {
+	opts := pulsar.ClientOptions{
+		URL:    url,
+		Logger: pulsarlog.NewLoggerWithSlog(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))),
+	}
+	if timeout > 0 {
+		opts.ConnectionTimeout = timeout
+		opts.OperationTimeout = timeout
+	}
+	if token != "" {
+		opts.Authentication = pulsar.NewAuthenticationToken(token)
+	}
+	client, err := pulsar.NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Pulsar configuration: %w", err)
+	}
+	return client, nil
+}
+
+// parseSubscriptionType converts the --subscription-type flag.
+func parseSubscriptionType(s string) (pulsar.SubscriptionType, error) {
+	switch strings.ToLower(strings.ReplaceAll(s, "-", "")) {
+	case "exclusive":
+		return pulsar.Exclusive, nil
+	case "shared":
+		return pulsar.Shared, nil
+	case "failover":
+		return pulsar.Failover, nil
+	case "keyshared":
+		return pulsar.KeyShared, nil
+	}
+	return 0, fmt.Errorf("invalid --subscription-type %q: expected exclusive, shared, failover or key-shared", s)
+}
+
+// parseInitialPosition converts the --initial-position flag.
+func parseInitialPosition(s string) (pulsar.SubscriptionInitialPosition, error) {
+	switch strings.ToLower(s) {
+	case "latest":
+		return pulsar.SubscriptionPositionLatest, nil
+	case "earliest":
+		return pulsar.SubscriptionPositionEarliest, nil
+	}
+	return 0, fmt.Errorf("invalid --initial-position %q: expected latest or earliest", s)
+}
+
+// propertyItems converts message properties to sorted key/value items.
+func propertyItems(props map[string]string) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		items = append(items, toolutil.KV{Key: k, Value: props[k]})
+	}
+	return items
+}
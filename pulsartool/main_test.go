@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestParseSubscriptionType(t *testing.T) {
+	tests := map[string]pulsar.SubscriptionType{
+		"exclusive":  pulsar.Exclusive,
+		"Shared":     pulsar.Shared,
+		"failover":   pulsar.Failover,
+		"key-shared": pulsar.KeyShared,
+		"KeyShared":  pulsar.KeyShared,
+	}
+	for in, want := range tests {
+		if got, err := parseSubscriptionType(in); err != nil || got != want {
+			t.Errorf("parseSubscriptionType(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseSubscriptionType("broadcast"); err == nil {
+		t.Error("parseSubscriptionType(broadcast) succeeded, want error")
+	}
+}
+
+func TestParseInitialPosition(t *testing.T) {
+	if got, err := parseInitialPosition("earliest"); err != nil || got != pulsar.SubscriptionPositionEarliest {
+		t.Errorf("parseInitialPosition(earliest) = %v, %v", got, err)
+	}
+	if got, err := parseInitialPosition("LATEST"); err != nil || got != pulsar.SubscriptionPositionLatest {
+		t.Errorf("parseInitialPosition(LATEST) = %v, %v", got, err)
+	}
+	if _, err := parseInitialPosition("now"); err == nil {
+		t.Error("parseInitialPosition(now) succeeded, want error")
+	}
+}
+
+func TestPropertyItems(t *testing.T) {
+	got := propertyItems(map[string]string{"b": "2", "a": "1"})
+	if len(got) != 2 || got[0].Key != "a" || got[1].Value != "2" {
+		t.Errorf("propertyItems() = %v", got)
+	}
+}
+
+// fakeMessage implements the pulsar.Message methods used by printMessage.
+type fakeMessage struct {
+	pulsar.Message
+	key string
+}
+
+func (m fakeMessage) ID() pulsar.MessageID          { return pulsar.EarliestMessageID() }
+func (m fakeMessage) Topic() string                 { return "persistent://public/default/orders" }
+func (m fakeMessage) ProducerName() string          { return "producer" }
+func (m fakeMessage) PublishTime() time.Time        { return time.Now() }
+func (m fakeMessage) EventTime() time.Time          { return time.Time{} }
+func (m fakeMessage) RedeliveryCount() uint32       { return 0 }
+func (m fakeMessage) Key() string                   { return m.key }
+func (m fakeMessage) Properties() map[string]string { return nil }
+func (m fakeMessage) Payload() []byte               { return []byte(`{"id":1}`) }
+
+func TestPrintMessage_KeyStats(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	for _, key := range []string{"a", "a", "b", ""} {
+		if !printMessage(fakeMessage{key: key}) {
+			t.Fatalf("printMessage(%q) was not printed", key)
+		}
+	}
+	keys := toolutil.ReceivedStats().Keys
+	if len(keys) != 2 || keys["a"] != 2 || keys["b"] != 1 {
+		t.Errorf("key stats = %v, want a:2 b:1", keys)
+	}
+}
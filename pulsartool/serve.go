@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		subURL          string
+		token           string
+		subTopic        string
+		subscription    string
+		subType         string
+		initialPosition string
+		noAck           bool
+		connectTimeout  time.Duration
+		serveOpts       toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Subscribe to a Pulsar topic and log consumed messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, err := parseSubscriptionType(subType)
+			if err != nil {
+				return err
+			}
+			pos, err := parseInitialPosition(initialPosition)
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				client   pulsar.Client
+				consumer pulsar.Consumer
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				var err error
+				if client, err = newClient(subURL, token, connectTimeout); err != nil {
+					return err
+				}
+				consumer, err = client.Subscribe(pulsar.ConsumerOptions{
+					Topic:                       subTopic,
+					SubscriptionName:            subscription,
+					Type:                        st,
+					SubscriptionInitialPosition: pos,
+				})
+				if err != nil {
+					client.Close()
+					return fmt.Errorf("failed to subscribe: %w", err)
+				}
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to Pulsar: %w", err)
+			}
+			defer client.Close()
+			defer consumer.Close()
+
+			toolutil.PrintSuccess("Subscribed to Pulsar topic")
+			toolutil.PrintKeyValue("URL", subURL)
+			toolutil.PrintKeyValue("Topic", subTopic)
+			toolutil.PrintKeyValue("Subscription", subscription)
+			toolutil.PrintKeyValue("Type", subType)
+
+			for {
+				msg, err := consumer.Receive(ctx)
+				if err != nil {
+					if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					return fmt.Errorf("receive error: %w", err)
+				}
+				if !printMessage(msg) {
+					// Dropped after --max-messages: redeliver it to the subscription
+					consumer.Nack(msg)
+					continue
+				}
+				if noAck {
+					continue
+				}
+				if err := consumer.Ack(msg); err != nil {
+					toolutil.PrintError("Ack error: %v", err)
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&subURL, "url", "pulsar://localhost:6650", "Pulsar service URL (pulsar:// or pulsar+ssl://)")
+	cmd.Flags().StringVar(&token, "token", "", "JWT authentication token")
+	cmd.Flags().StringVar(&subTopic, "topic", "persistent://public/default/test", "Topic to consume from")
+	cmd.Flags().StringVar(&subscription, "subscription", "eventkit", "Subscription name")
+	cmd.Flags().StringVar(&subType, "subscription-type", "exclusive", "Subscription type: exclusive, shared, failover or key-shared")
+	cmd.Flags().StringVar(&initialPosition, "initial-position", "latest", "Start position of a new subscription: latest or earliest")
+	cmd.Flags().BoolVar(&noAck, "no-ack", false, "Do not acknowledge messages (they are redelivered to the subscription later)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// printMessage prints msg and reports whether it was printed, see toolutil.PrintColoredMessage.
+func printMessage(msg pulsar.Message) bool {
+	meta := []toolutil.KV{
+		{Key: "ID", Value: msg.ID().String()},
+		{Key: "Producer", Value: msg.ProducerName()},
+		{Key: "Publish", Value: msg.PublishTime().Format(time.RFC3339)},
+	}
+	if !msg.EventTime().IsZero() {
+		meta = append(meta, toolutil.KV{Key: "Event", Value: msg.EventTime().Format(time.RFC3339)})
+	}
+	if n := msg.RedeliveryCount(); n > 0 {
+		meta = append(meta, toolutil.KV{Key: "Redelivery", Value: strconv.FormatUint(uint64(n), 10)})
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: msg.Topic()}}},
+		{Title: "Message", Items: meta},
+	}
+	if msg.Key() != "" {
+		// A "Key" section, like kafkatool, so --list-received-summary counts messages per key
+		sections = append(sections, toolutil.MessageSection{Title: "Key", Items: []toolutil.KV{{Key: "Value", Value: msg.Key()}}})
+	}
+	sections = append(sections, toolutil.HeadersSection(propertyItems(msg.Properties())))
+	return toolutil.PrintColoredMessage("Pulsar", sections, msg.Payload(), toolutil.GuessMIME(msg.Payload()))
+}
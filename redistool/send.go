@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/sandrolain/eventkit/pkg/common"
@@ -19,13 +22,32 @@ func sendCommand() *cobra.Command {
 		sendPayload    string
 		sendMIME       string
 		seed           int64
+		seedPerMessage bool
 		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
 		templateVars   []string
 		fileRoot       string
 		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
 		sendInterval   string
 		sendDataKey    string
 		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
+		repeatBody     int
+		repeatSep      string
 	)
 
 	cmd := &cobra.Command{
@@ -35,34 +57,93 @@ func sendCommand() *cobra.Command {
 			ctx, cancel := common.SetupGracefulShutdown()
 			defer cancel()
 
-			rdb := redis.NewClient(&redis.Options{Addr: sendAddr})
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, "{{", "}}")
+			}
+
+			newClient := func() *redis.Client {
+				return redis.NewClient(&redis.Options{Addr: sendAddr, DialTimeout: connectTimeout})
+			}
+			rdb := newClient()
 			defer func() {
 				if err := rdb.Close(); err != nil {
 					slog.Error("Failed to close Redis client", "error", err)
 				}
 			}()
 
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					return rdb.Ping(ctx).Err()
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("failed to connect to Redis: %w", err)
+			}
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				if err := rdb.Close(); err != nil {
+					slog.Error("Failed to close Redis client", "error", err)
+				}
+				rdb = newClient()
+				return connect()
+			})
+
 			mode := "channel"
 			if sendStream != "" {
 				mode = "stream"
 			}
 
 			logger := toolutil.Logger()
-			if seed != 0 {
-				testpayload.SeedRandom(seed)
-			}
-			testpayload.SetAllowFileReads(allowFileReads)
-			testpayload.SetFileRoot(fileRoot)
-			testpayload.SetFileCacheEnabled(cacheFiles)
-			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
-			if errVars != nil {
-				return fmt.Errorf("invalid template-var: %w", errVars)
-			}
-			testpayload.SetTemplateVars(varsMap)
 			logger.Info("Sending to Redis", "address", sendAddr, "mode", mode, "interval", sendInterval)
 
-			return common.RunOnceOrPeriodic(ctx, once, sendInterval, func() error {
-				body, _, err := toolutil.BuildPayload(sendPayload, sendMIME)
+			streamDest := toolutil.NewDestination(sendStream, "{{", "}}")
+			channelDest := toolutil.NewDestination(sendChannel, "{{", "}}")
+			send := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					logger.Error("Reconnect error", "error", err)
+					return err
+				}
+				body, _, err := toolutil.BuildRepeatedPayloadWithDelimiters(repeatBody, repeatSep, sendPayload, sendMIME, "{{", "}}")
 				if err != nil {
 					logger.Error("Failed to build payload", "error", err)
 					return err
@@ -70,34 +151,67 @@ func sendCommand() *cobra.Command {
 				switch mode {
 				case "stream":
 					fields := map[string]interface{}{sendDataKey: body}
-					res := rdb.XAdd(ctx, &redis.XAddArgs{Stream: sendStream, Values: fields})
+					stream, err := streamDest.Resolve()
+					if err != nil {
+						logger.Error("Failed to build stream name", "error", err)
+						return err
+					}
+					res := rdb.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: fields})
 					if err := res.Err(); err != nil {
 						logger.Error("XAdd error", "error", err)
 						return err
 					}
-					logger.Info("Message sent to stream", "stream", sendStream, "id", res.Val())
+					logger.Info("Message sent to stream", "stream", stream, "id", res.Val(), "bytes", len(body), "repeat", repeatBody)
 				default: // channel
-					if err := rdb.Publish(ctx, sendChannel, body).Err(); err != nil {
+					channel, err := channelDest.Resolve()
+					if err != nil {
+						logger.Error("Failed to build channel name", "error", err)
+						return err
+					}
+					if err := rdb.Publish(ctx, channel, body).Err(); err != nil {
 						logger.Error("Publish error", "error", err)
 						return err
 					}
-					logger.Info("Message sent to channel", "channel", sendChannel, "bytes", len(body))
+					logger.Info("Message sent to channel", "channel", channel, "bytes", len(body), "repeat", repeatBody)
 				}
 				return nil
-			})
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
 		},
 	}
 
 	cmd.Flags().StringVar(&sendAddr, "address", "localhost:6379", "Redis address")
-	cmd.Flags().StringVar(&sendChannel, "channel", "test", "Redis channel (for pub-sub mode)")
-	cmd.Flags().StringVar(&sendStream, "stream", "", "Redis stream (if set, sends to stream)")
+	cmd.Flags().StringVar(&sendChannel, "channel", "test", "Redis channel (for pub-sub mode, supports template placeholders)")
+	cmd.Flags().StringVar(&sendStream, "stream", "", "Redis stream (if set, sends to stream; supports template placeholders)")
 	cmd.Flags().StringVar(&sendDataKey, "dataKey", "data", "Field name holding data in stream messages")
 	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, Redis!", &sendMIME, toolutil.CTText)
 	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
 	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddRepeatBodyFlags(cmd, &repeatBody, &repeatSep)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
 	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
 	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
 	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
 	toolutil.AddTemplateVarFlag(cmd, &templateVars)
 	toolutil.AddFileRootFlag(cmd, &fileRoot)
 
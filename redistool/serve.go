@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"time"
@@ -13,28 +14,42 @@ import (
 
 func serveCommand() *cobra.Command {
 	var (
-		subAddr     string
-		subChannel  string
-		subStream   string
-		subGroup    string
-		subConsumer string
-		subDataKey  string
+		subAddr        string
+		subChannel     string
+		subStream      string
+		subGroup       string
+		subConsumer    string
+		subDataKey     string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Subscribe to a channel or consume a stream and log messages",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx, cancel := common.SetupGracefulShutdown()
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
 			defer cancel()
 
-			rdb := redis.NewClient(&redis.Options{Addr: subAddr})
+			rdb := redis.NewClient(&redis.Options{Addr: subAddr, DialTimeout: connectTimeout})
 			defer func() {
 				if err := rdb.Close(); err != nil {
 					slog.Error("Failed to close Redis client", "error", err)
 				}
 			}()
 
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				return rdb.Ping(ctx).Err()
+			}); err != nil {
+				return fmt.Errorf("failed to connect to Redis: %w", err)
+			}
+
 			logger := toolutil.Logger()
 
 			if subStream != "" {
@@ -109,9 +124,13 @@ func serveCommand() *cobra.Command {
 								}
 
 								ct := toolutil.GuessMIME(data)
-								toolutil.PrintColoredMessage("Redis Stream", sections, data, ct)
+								printed := toolutil.PrintColoredMessage("Redis Stream", sections, data, ct)
 
 								if useGroup {
+									if !printed {
+										// Dropped after --max-messages: keep it pending in the group
+										continue
+									}
 									if err := rdb.XAck(ctx, subStream, subGroup, xmsg.ID).Err(); err != nil {
 										logger.Error("Failed to ack message", "error", err)
 									}
@@ -159,6 +178,8 @@ func serveCommand() *cobra.Command {
 	cmd.Flags().StringVar(&subGroup, "group", "", "Redis consumer group (stream mode)")
 	cmd.Flags().StringVar(&subConsumer, "consumer", "", "Redis consumer name (stream mode)")
 	cmd.Flags().StringVar(&subDataKey, "dataKey", "data", "Field name holding data in stream messages")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
 
 	return cmd
 }
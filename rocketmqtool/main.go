@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/apache/rocketmq-client-go/v2/rlog"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "rocketmqtool",
+		Short: "Apache RocketMQ producer/consumer tester",
+		Long:  "A simple Apache RocketMQ CLI that produces templated messages with tags and keys and consumes topics in a consumer group, clustering or broadcasting.",
+	}
+
+	rlog.SetLogger(clientLogger{logger: toolutil.Logger()})
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// clientLogger routes the client logs to the kit logger. The client logs every route and
+// rebalance update, and warns on each retry of a route lookup, so only errors are kept.
+type clientLogger struct {
+	logger *slog.Logger
+}
+
+func (l clientLogger) attrs(fields map[string]interface{}) []any {
+	args := make([]any, 0, 2*len(fields))
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		args = append(args, k, fields[k])
+	}
+	return args
+}
+
+func (l clientLogger) Debug(string, map[string]interface{})   {}
+func (l clientLogger) Info(string, map[string]interface{})    {}
+func (l clientLogger) Warning(string, map[string]interface{}) {}
+func (l clientLogger) Error(msg string, fields map[string]interface{}) {
+	l.logger.Error("rocketmq: "+msg, l.attrs(fields)...)
+}
+func (l clientLogger) Fatal(msg string, fields map[string]interface{}) {
+	l.logger.Error("rocketmq: "+msg, l.attrs(fields)...)
+}
+func (l clientLogger) Level(string)            {}
+func (l clientLogger) OutputPath(string) error { return nil }
+
+// rmqOptions configure the name servers and credentials shared by both commands.
+type rmqOptions struct {
+	NameServer string
+	Namespace  string
+	AccessKey  string
+	SecretKey  string
+}
+
+func addRMQFlags(cmd *cobra.Command, opts *rmqOptions) {
+	cmd.Flags().StringVar(&opts.NameServer, "nameserver", "127.0.0.1:9876", "Name server addresses host:port (comma or semicolon separated)")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", "", "Namespace prefixed to topics and groups")
+	cmd.Flags().StringVar(&opts.AccessKey, "access-key", "", "ACL access key")
+	cmd.Flags().StringVar(&opts.SecretKey, "secret-key", "", "ACL secret key")
+}
+
+// nameServers parses the name server list.
+func (o rmqOptions) nameServers() (primitive.NamesrvAddr, error) {
+	var addrs []string
+	for _, a := range strings.FieldsFunc(o.NameServer, func(r rune) bool { return r == ',' || r == ';' }) {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	ns, err := primitive.NewNamesrvAddr(addrs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --nameserver %q: %w", o.NameServer, err)
+	}
+	return ns, nil
+}
+
+// checkNameServers dials the name servers, succeeding when one accepts connections; clients
+// start without contacting them and would only fail on the first request.
+func checkNameServers(ctx context.Context, ns primitive.NamesrvAddr) error {
+	var d net.Dialer
+	var err error
+	for _, addr := range ns {
+		var conn net.Conn
+		if conn, err = d.DialContext(ctx, "tcp", addr); err == nil {
+			return conn.Close()
+		}
+	}
+	return fmt.Errorf("no name server reachable: %w", err)
+}
+
+// credentials returns the ACL credentials, if any.
+func (o rmqOptions) credentials() (primitive.Credentials, bool) {
+	return primitive.Credentials{AccessKey: o.AccessKey, SecretKey: o.SecretKey}, o.AccessKey != ""
+}
+
+// splitKeys parses a comma or space separated list of message keys.
+func splitKeys(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// systemProperties are set by the client and broker; they are shown as message fields, not
+// as user properties.
+var systemProperties = map[string]bool{
+	primitive.PropertyKeys:                          true,
+	primitive.PropertyTags:                          true,
+	primitive.PropertyWaitStoreMsgOk:                true,
+	primitive.PropertyDelayTimeLevel:                true,
+	primitive.PropertyRetryTopic:                    true,
+	primitive.PropertyRealTopic:                     true,
+	primitive.PropertyRealQueueId:                   true,
+	primitive.PropertyTransactionPrepared:           true,
+	primitive.PropertyProducerGroup:                 true,
+	primitive.PropertyMinOffset:                     true,
+	primitive.PropertyMaxOffset:                     true,
+	primitive.PropertyReconsumeTime:                 true,
+	primitive.PropertyMsgRegion:                     true,
+	primitive.PropertyTraceSwitch:                   true,
+	primitive.PropertyUniqueClientMessageIdKeyIndex: true,
+	primitive.PropertyMaxReconsumeTimes:             true,
+	primitive.PropertyConsumeStartTime:              true,
+	primitive.PropertyShardingKey:                   true,
+	primitive.PropertyCluster:                       true,
+}
+
+// contentTypeProperty carries the MIME type of produced messages, since RocketMQ has no
+// content type of its own.
+const contentTypeProperty = "content-type"
+
+// messageItems lists the metadata of a consumed message.
+func messageItems(m *primitive.MessageExt) []toolutil.KV {
+	items := []toolutil.KV{{Key: "Message ID", Value: m.MsgId}}
+	if tags := m.GetTags(); tags != "" {
+		items = append(items, toolutil.KV{Key: "Tags", Value: tags})
+	}
+	if keys := m.GetKeys(); keys != "" {
+		items = append(items, toolutil.KV{Key: "Keys", Value: keys})
+	}
+	if sk := m.GetShardingKey(); sk != "" {
+		items = append(items, toolutil.KV{Key: "Sharding key", Value: sk})
+	}
+	if m.Queue != nil {
+		items = append(items, toolutil.KV{Key: "Broker", Value: m.Queue.BrokerName}, toolutil.KV{Key: "Queue", Value: strconv.Itoa(m.Queue.QueueId)})
+	}
+	items = append(items, toolutil.KV{Key: "Offset", Value: strconv.FormatInt(m.QueueOffset, 10)})
+	if m.BornTimestamp > 0 {
+		items = append(items, toolutil.KV{Key: "Born", Value: time.UnixMilli(m.BornTimestamp).Format(time.RFC3339Nano)})
+	}
+	if m.BornHost != "" {
+		items = append(items, toolutil.KV{Key: "Born host", Value: m.BornHost})
+	}
+	if m.ReconsumeTimes > 0 {
+		items = append(items, toolutil.KV{Key: "Reconsumed", Value: strconv.Itoa(int(m.ReconsumeTimes))})
+	}
+	return items
+}
+
+// propertyItems lists the user properties of a message, sorted by key.
+func propertyItems(props map[string]string) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		if !systemProperties[k] && k != contentTypeProperty {
+			items = append(items, toolutil.KV{Key: k, Value: props[k]})
+		}
+	}
+	return items
+}
+
+// sendStatuses names the send statuses other than SendOK: the message was sent but not stored
+// as durably as requested.
+var sendStatuses = map[primitive.SendStatus]string{
+	primitive.SendFlushDiskTimeout:  "flush disk timeout",
+	primitive.SendFlushSlaveTimeout: "flush slave timeout",
+	primitive.SendSlaveNotAvailable: "slave not available",
+	primitive.SendUnknownError:      "unknown error",
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/apache/rocketmq-client-go/v2/consumer"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+)
+
+func TestNameServers(t *testing.T) {
+	ns, err := rmqOptions{NameServer: "10.0.0.1:9876; 10.0.0.2:9876,"}.nameServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ns) != 2 || ns[0] != "10.0.0.1:9876" || ns[1] != "10.0.0.2:9876" {
+		t.Errorf("unexpected name servers: %v", ns)
+	}
+	for _, bad := range []string{"", "localhost"} {
+		if _, err := (rmqOptions{NameServer: bad}).nameServers(); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestCheckNameServers(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close() //nolint:errcheck
+	if err := checkNameServers(context.Background(), primitive.NamesrvAddr{addr}); err == nil {
+		t.Fatal("expected an error for a closed port")
+	}
+
+	l, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close() //nolint:errcheck
+	if err := checkNameServers(context.Background(), primitive.NamesrvAddr{addr, l.Addr().String()}); err != nil {
+		t.Errorf("expected the second name server to be reachable: %v", err)
+	}
+}
+
+func TestSplitKeys(t *testing.T) {
+	keys := splitKeys("order-1, user-2 ,,trace-3")
+	if len(keys) != 3 || keys[0] != "order-1" || keys[1] != "user-2" || keys[2] != "trace-3" {
+		t.Errorf("unexpected keys: %q", keys)
+	}
+}
+
+func TestMessageItems(t *testing.T) {
+	m := &primitive.MessageExt{MsgId: "AC11000100002A9F0000000000000001", QueueOffset: 42, BornHost: "10.0.0.3:50000", ReconsumeTimes: 2}
+	m.Topic = "orders"
+	m.Queue = &primitive.MessageQueue{Topic: "orders", BrokerName: "broker-a", QueueId: 3}
+	m.WithTag("created")
+	m.WithKeys([]string{"order-1", "user-2"})
+	m.WithProperty("region", "eu")
+	m.WithProperty(contentTypeProperty, "application/json")
+
+	want := map[string]string{
+		"Message ID": "AC11000100002A9F0000000000000001",
+		"Tags":       "created",
+		"Keys":       "order-1 user-2",
+		"Broker":     "broker-a",
+		"Queue":      "3",
+		"Offset":     "42",
+		"Born host":  "10.0.0.3:50000",
+		"Reconsumed": "2",
+	}
+	items := messageItems(m)
+	if len(items) != len(want) {
+		t.Errorf("got %d items, want %d: %v", len(items), len(want), items)
+	}
+	for _, it := range items {
+		if want[it.Key] != it.Value {
+			t.Errorf("%s = %q, want %q", it.Key, it.Value, want[it.Key])
+		}
+	}
+
+	props := propertyItems(m.GetProperties())
+	if len(props) != 1 || props[0].Key != "region" || props[0].Value != "eu" {
+		t.Errorf("system properties should be hidden: %v", props)
+	}
+}
+
+func TestServeOptions(t *testing.T) {
+	if m, err := parseModel("broadcasting"); err != nil || m != consumer.BroadCasting {
+		t.Errorf("parseModel = %v, %v", m, err)
+	}
+	if _, err := parseModel("fanout"); err == nil {
+		t.Error("expected an error for an unknown model")
+	}
+	if f, err := parseFrom("first"); err != nil || f != consumer.ConsumeFromFirstOffset {
+		t.Errorf("parseFrom = %v, %v", f, err)
+	}
+	if _, err := parseFrom("timestamp"); err == nil {
+		t.Error("expected an error for an unknown start")
+	}
+	if s := selector("a || b", ""); s.Type != consumer.TAG || s.Expression != "a || b" {
+		t.Errorf("unexpected tag selector: %+v", s)
+	}
+	if s := selector("*", "region = 'eu'"); s.Type != consumer.SQL92 || s.Expression != "region = 'eu'" {
+		t.Errorf("unexpected SQL selector: %+v", s)
+	}
+}
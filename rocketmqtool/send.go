@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/apache/rocketmq-client-go/v2"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/apache/rocketmq-client-go/v2/producer"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		opts           rmqOptions
+		topic          string
+		tag            string
+		keys           string
+		group          string
+		delayLevel     int
+		oneway         bool
+		sendTimeout    time.Duration
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Produce periodic messages to a RocketMQ topic",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if delayLevel < 0 || delayLevel > 18 {
+				return fmt.Errorf("--delay-level must be between 0 and 18")
+			}
+			ns, err := opts.nameServers()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+
+			producerOpts := []producer.Option{
+				producer.WithNameServer(ns),
+				producer.WithGroupName(group),
+				producer.WithSendMsgTimeout(sendTimeout),
+				producer.WithRetry(2),
+			}
+			if opts.Namespace != "" {
+				producerOpts = append(producerOpts, producer.WithNamespace(opts.Namespace))
+			}
+			if creds, ok := opts.credentials(); ok {
+				producerOpts = append(producerOpts, producer.WithCredentials(creds))
+			}
+			var p rocketmq.Producer
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					if err := checkNameServers(ctx, ns); err != nil {
+						return err
+					}
+					var err error
+					if p, err = rocketmq.NewProducer(producerOpts...); err != nil {
+						return err
+					}
+					return p.Start()
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error starting RocketMQ producer: %w", err)
+			}
+			defer p.Shutdown() //nolint:errcheck
+
+			toolutil.PrintSuccess("RocketMQ producer started")
+			toolutil.PrintKeyValue("Name server", opts.NameServer)
+			toolutil.PrintKeyValue("Topic", topic)
+			toolutil.PrintKeyValue("Group", group)
+			if tag != "" {
+				toolutil.PrintKeyValue("Tag", tag)
+			}
+			if delayLevel > 0 {
+				toolutil.PrintKeyValue("Delay level", delayLevel)
+			}
+
+			topicDest := toolutil.NewDestination(topic, openDelim, closeDelim)
+			tagDest := toolutil.NewDestination(tag, openDelim, closeDelim)
+			keysDest := toolutil.NewDestination(keys, openDelim, closeDelim)
+
+			send := func() error {
+				t, err := topicDest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Topic build error: %v", err)
+					return err
+				}
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				msg := primitive.NewMessage(t, body)
+				for k, v := range headerMap {
+					msg.WithProperty(k, v)
+				}
+				msg.WithProperty(contentTypeProperty, ct)
+				if tag != "" {
+					v, err := tagDest.Resolve()
+					if err != nil {
+						toolutil.PrintError("Tag build error: %v", err)
+						return err
+					}
+					msg.WithTag(v)
+				}
+				if keys != "" {
+					v, err := keysDest.Resolve()
+					if err != nil {
+						toolutil.PrintError("Keys build error: %v", err)
+						return err
+					}
+					if k := splitKeys(v); len(k) > 0 {
+						msg.WithKeys(k)
+					}
+				}
+				if delayLevel > 0 {
+					msg.WithDelayTimeLevel(delayLevel)
+				}
+
+				if oneway {
+					if err := p.SendOneWay(ctx, msg); err != nil {
+						toolutil.PrintError("Send error: %v", err)
+						return err
+					}
+					toolutil.PrintInfo("Sent %d bytes to '%s' (one-way)", len(body), t)
+					return nil
+				}
+				res, err := p.SendSync(ctx, msg)
+				if err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				if status, ok := sendStatuses[res.Status]; ok {
+					toolutil.PrintWarning("Message %s sent to '%s' with status: %s", res.MsgID, t, status)
+					return nil
+				}
+				queue := ""
+				if res.MessageQueue != nil {
+					queue = fmt.Sprintf(", %s queue %d", res.MessageQueue.BrokerName, res.MessageQueue.QueueId)
+				}
+				toolutil.PrintInfo("Sent %d bytes to '%s' (id %s%s, offset %d)", len(body), t, res.MsgID, queue, res.QueueOffset)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addRMQFlags(cmd, &opts)
+	cmd.Flags().StringVar(&topic, "topic", "eventkit-test", "Topic, supports template placeholders")
+	cmd.Flags().StringVar(&tag, "tag", "", "Message tag consumers can filter on, supports template placeholders")
+	cmd.Flags().StringVar(&keys, "keys", "", "Message keys for lookups, comma separated, supports template placeholders")
+	cmd.Flags().StringVar(&group, "group", "eventkit-producer", "Producer group")
+	cmd.Flags().IntVar(&delayLevel, "delay-level", 0, "Delay level of scheduled messages, 1 (1s) to 18 (2h); 0 delivers immediately")
+	cmd.Flags().BoolVar(&oneway, "oneway", false, "Send one-way, without waiting for the broker result")
+	cmd.Flags().DurationVar(&sendTimeout, "send-timeout", 3*time.Second, "Timeout of each send")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, RocketMQ!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
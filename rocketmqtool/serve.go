@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/rocketmq-client-go/v2"
+	"github.com/apache/rocketmq-client-go/v2/consumer"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// parseModel validates a --model value.
+func parseModel(s string) (consumer.MessageModel, error) {
+	switch s {
+	case "clustering":
+		return consumer.Clustering, nil
+	case "broadcasting":
+		return consumer.BroadCasting, nil
+	}
+	return 0, fmt.Errorf("invalid --model %q: expected clustering or broadcasting", s)
+}
+
+// parseFrom validates a --from value.
+func parseFrom(s string) (consumer.ConsumeFromWhere, error) {
+	switch s {
+	case "last":
+		return consumer.ConsumeFromLastOffset, nil
+	case "first":
+		return consumer.ConsumeFromFirstOffset, nil
+	}
+	return 0, fmt.Errorf("invalid --from %q: expected last or first", s)
+}
+
+// selector filters by SQL92 expression when one is given, otherwise by tag expression.
+func selector(tag, sql string) consumer.MessageSelector {
+	if sql != "" {
+		return consumer.MessageSelector{Type: consumer.SQL92, Expression: sql}
+	}
+	return consumer.MessageSelector{Type: consumer.TAG, Expression: tag}
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           rmqOptions
+		topic          string
+		group          string
+		model          string
+		tag            string
+		sql            string
+		from           string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Consume and log messages from a RocketMQ topic",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			msgModel, err := parseModel(model)
+			if err != nil {
+				return err
+			}
+			fromWhere, err := parseFrom(from)
+			if err != nil {
+				return err
+			}
+			if sql != "" && cmd.Flags().Changed("tag") {
+				return fmt.Errorf("--tag and --sql are mutually exclusive")
+			}
+			ns, err := opts.nameServers()
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			consumerOpts := []consumer.Option{
+				consumer.WithNameServer(ns),
+				consumer.WithGroupName(group),
+				consumer.WithConsumerModel(msgModel),
+				consumer.WithConsumeFromWhere(fromWhere),
+			}
+			if opts.Namespace != "" {
+				consumerOpts = append(consumerOpts, consumer.WithNamespace(opts.Namespace))
+			}
+			if creds, ok := opts.credentials(); ok {
+				consumerOpts = append(consumerOpts, consumer.WithCredentials(creds))
+			}
+
+			// Messages are delivered concurrently; printing is serialized so they do not interleave.
+			var printMu sync.Mutex
+			handle := func(_ context.Context, msgs ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
+				printMu.Lock()
+				defer printMu.Unlock()
+				for _, m := range msgs {
+					if !printMessage(m) {
+						// Dropped after --max-messages: leave the batch for redelivery
+						return consumer.ConsumeRetryLater, nil
+					}
+				}
+				return consumer.ConsumeSuccess, nil
+			}
+
+			var c rocketmq.PushConsumer
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				if err := checkNameServers(ctx, ns); err != nil {
+					return err
+				}
+				var err error
+				if c, err = rocketmq.NewPushConsumer(consumerOpts...); err != nil {
+					return err
+				}
+				if err := c.Subscribe(topic, selector(tag, sql), handle); err != nil {
+					return fmt.Errorf("failed to subscribe: %w", err)
+				}
+				return c.Start()
+			}); err != nil {
+				return fmt.Errorf("error starting RocketMQ consumer: %w", err)
+			}
+			defer c.Shutdown() //nolint:errcheck
+
+			toolutil.PrintSuccess("RocketMQ consumer started")
+			toolutil.PrintKeyValue("Name server", opts.NameServer)
+			toolutil.PrintKeyValue("Topic", topic)
+			toolutil.PrintKeyValue("Group", group)
+			toolutil.PrintKeyValue("Model", model)
+			if sql != "" {
+				toolutil.PrintKeyValue("SQL", sql)
+			} else {
+				toolutil.PrintKeyValue("Tag", tag)
+			}
+
+			<-ctx.Done()
+			toolutil.PrintInfo("Shutting down gracefully")
+			return nil
+		},
+	}
+
+	addRMQFlags(cmd, &opts)
+	cmd.Flags().StringVar(&topic, "topic", "eventkit-test", "Topic to consume")
+	cmd.Flags().StringVar(&group, "group", "eventkit-consumer", "Consumer group")
+	cmd.Flags().StringVar(&model, "model", "clustering", "Message model: clustering (the group shares the messages) or broadcasting (every consumer gets all of them)")
+	cmd.Flags().StringVar(&tag, "tag", "*", "Tag expression, e.g. 'a || b'; * matches all")
+	cmd.Flags().StringVar(&sql, "sql", "", "SQL92 filter on the message properties instead of tags (needs enablePropertyFilter on the broker)")
+	cmd.Flags().StringVar(&from, "from", "last", "Where a new group starts: last or first offset")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// printMessage prints m and reports whether it was printed, see toolutil.PrintColoredMessage.
+func printMessage(m *primitive.MessageExt) bool {
+	props := m.GetProperties()
+	sections := []toolutil.MessageSection{
+		{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: m.Topic}}},
+		{Title: "Message", Items: messageItems(m)},
+		toolutil.HeadersSection(propertyItems(props)),
+	}
+	ct := props[contentTypeProperty]
+	if ct == "" {
+		ct = toolutil.GuessMIME(m.Body)
+	}
+	return toolutil.PrintColoredMessage("RocketMQ", sections, m.Body, ct)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// s3Event is a record of an S3 event notification, as streamed by MinIO.
+type s3Event struct {
+	EventName string `json:"eventName"`
+	EventTime string `json:"eventTime"`
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key         string `json:"key"` // URL-encoded
+			Size        int64  `json:"size"`
+			ETag        string `json:"eTag"`
+			ContentType string `json:"contentType"`
+			VersionID   string `json:"versionId"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// emptyPayloadHash is the SHA-256 of an empty request body.
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// listenFilter selects the notifications streamed by listen.
+type listenFilter struct {
+	Events []string
+	Prefix string
+	Suffix string
+}
+
+// listen streams the notifications of bucket through the MinIO ListenBucketNotification
+// API, passing every record to handle until ctx is done or the stream fails. The API is a
+// MinIO extension: AWS S3 delivers notifications to SNS, SQS or Lambda instead.
+func listen(ctx context.Context, cfg aws.Config, endpoint, bucket string, filter listenFilter, handle func(s3Event)) error {
+	q := url.Values{}
+	for _, e := range filter.Events {
+		q.Add("events", e)
+	}
+	q.Set("prefix", filter.Prefix)
+	q.Set("suffix", filter.Suffix)
+	u := strings.TrimRight(endpoint, "/") + "/" + url.PathEscape(bucket) + "?" + q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("error retrieving credentials: %w", err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, emptyPayloadHash, "s3", cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("error signing request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("listen request failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// The stream holds one JSON document per line, with blank lines as keep-alives.
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var info struct {
+			Records []s3Event `json:"Records"`
+		}
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			return fmt.Errorf("invalid notification: %w", err)
+		}
+		for _, r := range info.Records {
+			handle(r)
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
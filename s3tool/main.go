@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "s3tool",
+		Short: "S3/MinIO object storage event tester",
+		Long:  "A simple S3 CLI that puts templated objects into a bucket and prints object-created events from MinIO bucket notifications or by polling the bucket listing (MinIO and LocalStack supported via --endpoint).",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// s3Options configure the bucket and the S3 client shared by both commands.
+type s3Options struct {
+	AWS       awsutil.Options
+	Bucket    string
+	PathStyle bool
+	AccessKey string
+	SecretKey string
+}
+
+func addS3Flags(cmd *cobra.Command, opts *s3Options) {
+	awsutil.AddFlags(cmd, &opts.AWS)
+	cmd.Flags().StringVar(&opts.Bucket, "bucket", "test-bucket", "Bucket name")
+	cmd.Flags().BoolVar(&opts.PathStyle, "path-style", false, "Use path-style addressing (endpoint/bucket/key), usually required by MinIO")
+	cmd.Flags().StringVar(&opts.AccessKey, "access-key", "", "Access key ID, overriding the default credential chain (requires --secret-key)")
+	cmd.Flags().StringVar(&opts.SecretKey, "secret-key", "", "Secret access key (requires --access-key)")
+}
+
+func (o s3Options) validate() error {
+	if o.Bucket == "" {
+		return fmt.Errorf("--bucket is required")
+	}
+	if (o.AccessKey == "") != (o.SecretKey == "") {
+		return fmt.Errorf("--access-key and --secret-key must be set together")
+	}
+	return nil
+}
+
+// config loads the AWS configuration, applying the static credentials of the flags.
+func (o s3Options) config(ctx context.Context) (aws.Config, error) {
+	cfg, err := awsutil.LoadConfig(ctx, o.AWS)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if o.AccessKey != "" {
+		cfg.Credentials = aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(o.AccessKey, o.SecretKey, ""))
+	}
+	return cfg, nil
+}
+
+// connect creates the S3 client and checks that the bucket exists and is accessible.
+func (o s3Options) connect(ctx context.Context) (*s3.Client, aws.Config, error) {
+	cfg, err := o.config(ctx)
+	if err != nil {
+		return nil, aws.Config{}, err
+	}
+	client := s3.NewFromConfig(cfg, func(so *s3.Options) {
+		so.UsePathStyle = o.PathStyle
+	})
+	if _, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(o.Bucket)}); err != nil {
+		return nil, aws.Config{}, fmt.Errorf("bucket %s: %w", o.Bucket, err)
+	}
+	return client, cfg, nil
+}
+
+// parseSince parses a duration before now (10m) or an RFC3339 timestamp.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: expected a duration (10m) or an RFC3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// objectURI formats bucket and key as an s3:// URI.
+func objectURI(bucket, key string) string {
+	return "s3://" + bucket + "/" + strings.TrimPrefix(key, "/")
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" // #nosec G501 -- S3 ETags are MD5 digests
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+)
+
+// fakeS3 is a path-style S3 endpoint holding the objects of a single bucket, which also
+// streams a fixed MinIO notification on listen requests.
+type fakeS3 struct {
+	bucket  string
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodHead && key == "":
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		type content struct {
+			Key          string
+			LastModified string
+			ETag         string
+			Size         int
+		}
+		var result struct {
+			XMLName  xml.Name `xml:"ListBucketResult"`
+			Contents []content
+		}
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, r.URL.Query().Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, content{Key: k, LastModified: time.Now().UTC().Format(time.RFC3339), ETag: etag(f.objects[k]), Size: len(f.objects[k])})
+		}
+		xml.NewEncoder(w).Encode(result) //nolint:errcheck
+	case r.Method == http.MethodGet && r.URL.Query().Has("events"):
+		q := r.URL.Query()
+		fmt.Fprintf(w, " \n\n{\"Records\":[{\"eventName\":\"s3:ObjectCreated:Put\",\"eventTime\":\"2024-01-01T00:00:00Z\",\"s3\":{\"bucket\":{\"name\":%q},\"object\":{\"key\":\"in%%2Fa+b.json\",\"size\":2,\"eTag\":\"abc\",\"contentType\":\"application/json\"}}}]}\n", bucket)
+		fmt.Fprintf(w, "{\"Records\":[{\"eventName\":%q,\"s3\":{\"bucket\":{\"name\":%q},\"object\":{\"key\":%q}}}]}\n", q["events"][len(q["events"])-1], bucket, q.Get("prefix")+"x"+q.Get("suffix"))
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil && end < len(data) {
+			data = data[start : end+1]
+		}
+		w.Write(data) //nolint:errcheck
+	default:
+		http.Error(w, "unsupported", http.StatusMethodNotAllowed)
+	}
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data) // #nosec G401 -- S3 ETags are MD5 digests
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func startServer(t *testing.T) s3Options {
+	t.Helper()
+	srv := httptest.NewServer(&fakeS3{bucket: "test-bucket", objects: map[string][]byte{}})
+	t.Cleanup(srv.Close)
+	return s3Options{
+		AWS:       awsutil.Options{Endpoint: srv.URL, Region: "us-east-1"},
+		Bucket:    "test-bucket",
+		PathStyle: true,
+		AccessKey: "AKID",
+		SecretKey: "SECRET",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []struct {
+		opts s3Options
+		ok   bool
+	}{
+		{s3Options{Bucket: "b"}, true},
+		{s3Options{Bucket: "b", AccessKey: "a", SecretKey: "s"}, true},
+		{s3Options{}, false},
+		{s3Options{Bucket: "b", AccessKey: "a"}, false},
+	} {
+		if err := c.opts.validate(); (err == nil) != c.ok {
+			t.Errorf("validate(%+v) = %v", c.opts, err)
+		}
+	}
+}
+
+func TestPutListAndRead(t *testing.T) {
+	opts := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, _, err := (s3Options{AWS: opts.AWS, Bucket: "missing", PathStyle: true, AccessKey: "AKID", SecretKey: "SECRET"}).connect(ctx); err == nil {
+		t.Error("connect(missing bucket) succeeded, want error")
+	}
+	client, _, err := opts.connect(ctx)
+	if err != nil {
+		t.Fatalf("connect() error: %v", err)
+	}
+	for _, k := range []string{"in/a.json", "in/b.txt", "out/c.json"} {
+		if _, err := client.PutObject(ctx, &s3.PutObjectInput{Bucket: aws.String(opts.Bucket), Key: aws.String(k), Body: bytes.NewReader([]byte("data of " + k))}); err != nil {
+			t.Fatalf("PutObject(%s) error: %v", k, err)
+		}
+	}
+
+	objects, err := listObjects(ctx, client, opts.Bucket, "in/", ".json")
+	if err != nil {
+		t.Fatalf("listObjects() error: %v", err)
+	}
+	if len(objects) != 1 || aws.ToString(objects[0].Key) != "in/a.json" || aws.ToInt64(objects[0].Size) != 17 {
+		t.Errorf("listObjects() = %+v", objects)
+	}
+	if head, err := readHead(ctx, client, opts.Bucket, "in/a.json", 4); err != nil || string(head) != "data" {
+		t.Errorf("readHead() = %q, %v", head, err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	opts := startServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cfg, err := opts.config(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []objectEvent
+	filter := listenFilter{Events: []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}, Prefix: "in/", Suffix: ".json"}
+	if err := listen(ctx, cfg, opts.AWS.Endpoint, opts.Bucket, filter, func(r s3Event) {
+		got = append(got, fromNotification(r))
+	}); err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("listen() events = %+v", got)
+	}
+	if ev := got[0]; ev.Name != "s3:ObjectCreated:Put" || ev.Key != "in/a b.json" || ev.Size != 2 || ev.ContentType != "application/json" {
+		t.Errorf("first event = %+v", ev)
+	}
+	if ev := got[1]; ev.Name != "s3:ObjectRemoved:*" || ev.Key != "in/x.json" {
+		t.Errorf("second event = %+v, want the filter echoed", ev)
+	}
+
+	if err := listen(ctx, cfg, opts.AWS.Endpoint, "missing", filter, func(s3Event) {}); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("listen(missing bucket) = %v, want 404 error", err)
+	}
+}
+
+func TestObjectPoller(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	obj := func(key, etag string, mod time.Time) types.Object {
+		return types.Object{Key: aws.String(key), ETag: aws.String(etag), LastModified: aws.Time(mod)}
+	}
+	keys := func(objs []types.Object) string {
+		var out []string
+		for _, o := range objs {
+			out = append(out, aws.ToString(o.Key))
+		}
+		return strings.Join(out, " ")
+	}
+
+	p := &objectPoller{since: t0}
+	if got := keys(p.scan([]types.Object{obj("old", "1", t0.Add(-time.Minute)), obj("b", "1", t0.Add(2*time.Second)), obj("a", "1", t0.Add(time.Second))})); got != "a b" {
+		t.Errorf("first scan = %q, want objects after the since marker", got)
+	}
+	// New keys and overwritten objects are reported whatever their modification time.
+	if got := keys(p.scan([]types.Object{obj("old", "2", t0.Add(-time.Minute)), obj("a", "1", t0.Add(time.Second)), obj("c", "1", t0)})); got != "old c" {
+		t.Errorf("second scan = %q", got)
+	}
+	if got := keys(p.scan([]types.Object{obj("old", "2", t0.Add(-time.Minute))})); got != "" {
+		t.Errorf("third scan = %q, want removals ignored", got)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got, err := parseSince("10m", now); err != nil || !got.Equal(now.Add(-10*time.Minute)) {
+		t.Errorf("parseSince(10m) = %v, %v", got, err)
+	}
+	if got, err := parseSince("2024-01-01T10:00:00Z", now); err != nil || got.Hour() != 10 {
+		t.Errorf("parseSince(RFC3339) = %v, %v", got, err)
+	}
+	if _, err := parseSince("yesterday", now); err == nil {
+		t.Error("parseSince(yesterday) succeeded, want error")
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// objectEvent is an object notification of either serve mode.
+type objectEvent struct {
+	Name        string
+	Time        string
+	Bucket      string
+	Key         string
+	Size        int64
+	ETag        string
+	ContentType string
+	VersionID   string
+}
+
+// fromNotification converts a MinIO notification record.
+func fromNotification(r s3Event) objectEvent {
+	key, err := url.QueryUnescape(r.S3.Object.Key)
+	if err != nil {
+		key = r.S3.Object.Key
+	}
+	return objectEvent{
+		Name:        r.EventName,
+		Time:        r.EventTime,
+		Bucket:      r.S3.Bucket.Name,
+		Key:         key,
+		Size:        r.S3.Object.Size,
+		ETag:        r.S3.Object.ETag,
+		ContentType: r.S3.Object.ContentType,
+		VersionID:   r.S3.Object.VersionID,
+	}
+}
+
+// objectPoller detects created and overwritten objects between bucket listings.
+type objectPoller struct {
+	since time.Time
+	seen  map[string]string // key -> ETag, nil before the first scan
+}
+
+// scan returns the objects created or overwritten since the previous listing, oldest
+// first. On the first listing it returns the objects modified after the since marker.
+func (p *objectPoller) scan(objects []types.Object) []types.Object {
+	next := make(map[string]string, len(objects))
+	var out []types.Object
+	for _, o := range objects {
+		key, etag := aws.ToString(o.Key), aws.ToString(o.ETag)
+		next[key] = etag
+		if p.seen == nil {
+			if aws.ToTime(o.LastModified).After(p.since) {
+				out = append(out, o)
+			}
+			continue
+		}
+		if old, ok := p.seen[key]; !ok || old != etag {
+			out = append(out, o)
+		}
+	}
+	p.seen = next
+	sort.SliceStable(out, func(i, j int) bool {
+		ti, tj := aws.ToTime(out[i].LastModified), aws.ToTime(out[j].LastModified)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return aws.ToString(out[i].Key) < aws.ToString(out[j].Key)
+	})
+	return out
+}
+
+// listObjects lists every object of bucket under prefix whose key ends with suffix.
+func listObjects(ctx context.Context, client *s3.Client, bucket, prefix, suffix string) ([]types.Object, error) {
+	var out []types.Object
+	pages := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket), Prefix: aws.String(prefix)})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page.Contents {
+			if strings.HasSuffix(aws.ToString(o.Key), suffix) {
+				out = append(out, o)
+			}
+		}
+	}
+	return out, nil
+}
+
+// readHead downloads at most n bytes of an object.
+func readHead(ctx context.Context, client *s3.Client, bucket, key string, n int64) ([]byte, error) {
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close() //nolint:errcheck
+	return io.ReadAll(io.LimitReader(out.Body, n))
+}
+
+// printEvent prints an object event, with the head of the object when maxContent > 0.
+func printEvent(ctx context.Context, client *s3.Client, ev objectEvent, maxContent int64) {
+	event := []toolutil.KV{{Key: "Name", Value: ev.Name}}
+	if ev.Time != "" {
+		event = append(event, toolutil.KV{Key: "Time", Value: ev.Time})
+	}
+	object := []toolutil.KV{
+		{Key: "Bucket", Value: ev.Bucket},
+		{Key: "Key", Value: ev.Key},
+		{Key: "Size", Value: strconv.FormatInt(ev.Size, 10)},
+	}
+	for _, kv := range []toolutil.KV{{Key: "ETag", Value: ev.ETag}, {Key: "Content Type", Value: ev.ContentType}, {Key: "Version ID", Value: ev.VersionID}} {
+		if kv.Value != "" {
+			object = append(object, kv)
+		}
+	}
+
+	var body []byte
+	ct := ev.ContentType
+	if maxContent > 0 && ev.Size > 0 && strings.HasPrefix(ev.Name, "s3:ObjectCreated") {
+		var err error
+		body, err = readHead(ctx, client, ev.Bucket, ev.Key, maxContent)
+		if err != nil {
+			toolutil.PrintWarning("Failed to read %s: %v", objectURI(ev.Bucket, ev.Key), err)
+		}
+		if ev.Size > maxContent {
+			object = append(object, toolutil.KV{Key: "Truncated", Value: "true"})
+		}
+		if ct == "" || ct == "binary/octet-stream" {
+			ct = toolutil.GuessMIME(body)
+		}
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Event", Items: event},
+		{Title: "Object", Items: object},
+	}
+	toolutil.PrintColoredMessage("S3", sections, body, ct)
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           s3Options
+		mode           string
+		prefix         string
+		suffix         string
+		events         []string
+		since          string
+		pollInterval   time.Duration
+		content        bool
+		maxContent     int64
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Print object-created events from MinIO notifications or bucket polling",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			switch mode {
+			case "poll":
+				if pollInterval <= 0 {
+					return fmt.Errorf("--poll-interval must be positive")
+				}
+			case "listen":
+				if opts.AWS.Endpoint == "" {
+					return fmt.Errorf("--mode listen requires the --endpoint of a MinIO server")
+				}
+				if since != "" {
+					return fmt.Errorf("--since only applies to --mode poll")
+				}
+			default:
+				return fmt.Errorf("invalid --mode %q: expected poll or listen", mode)
+			}
+			sinceTime := time.Now()
+			if since != "" {
+				t, err := parseSince(since, sinceTime)
+				if err != nil {
+					return err
+				}
+				sinceTime = t
+			}
+			if maxContent < 1 {
+				return fmt.Errorf("--max-content-bytes must be at least 1")
+			}
+			if !content {
+				maxContent = 0
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				client *s3.Client
+				cfg    aws.Config
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				c, conf, err := opts.connect(ctx)
+				if err != nil {
+					return err
+				}
+				client, cfg = c, conf
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to S3: %w", err)
+			}
+
+			if mode == "listen" {
+				toolutil.PrintSuccess("Listening to bucket notifications")
+				toolutil.PrintKeyValue("Endpoint", opts.AWS.Endpoint)
+				toolutil.PrintKeyValue("Bucket", opts.Bucket)
+				toolutil.PrintKeyValue("Events", strings.Join(events, ", "))
+				filter := listenFilter{Events: events, Prefix: prefix, Suffix: suffix}
+				for {
+					err := listen(ctx, cfg, opts.AWS.Endpoint, opts.Bucket, filter, func(r s3Event) {
+						printEvent(ctx, client, fromNotification(r), maxContent)
+					})
+					if ctx.Err() != nil {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					if err == nil {
+						err = fmt.Errorf("stream closed by the server")
+					}
+					toolutil.PrintWarning("Notification stream interrupted: %v; reconnecting", err)
+					select {
+					case <-ctx.Done():
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					case <-time.After(time.Second):
+					}
+				}
+			}
+
+			poller := &objectPoller{since: sinceTime}
+			report := func(objects []types.Object) {
+				for _, o := range poller.scan(objects) {
+					printEvent(ctx, client, objectEvent{
+						Name:   "s3:ObjectCreated",
+						Time:   aws.ToTime(o.LastModified).Format(time.RFC3339),
+						Bucket: opts.Bucket,
+						Key:    aws.ToString(o.Key),
+						Size:   aws.ToInt64(o.Size),
+						ETag:   aws.ToString(o.ETag),
+					}, maxContent)
+				}
+			}
+			// Fail fast on unreadable buckets.
+			objects, err := listObjects(ctx, client, opts.Bucket, prefix, suffix)
+			if err != nil {
+				return fmt.Errorf("error listing bucket %s: %w", opts.Bucket, err)
+			}
+
+			toolutil.PrintSuccess("Polling bucket")
+			toolutil.PrintKeyValue("Bucket", opts.Bucket)
+			toolutil.PrintKeyValue("Since", sinceTime.Format(time.RFC3339))
+			toolutil.PrintKeyValue("Poll Interval", pollInterval)
+			report(objects)
+
+			ticker := time.NewTicker(pollInterval)
+			defer ticker.Stop()
+			failing := false
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case <-ticker.C:
+				}
+				objects, err := listObjects(ctx, client, opts.Bucket, prefix, suffix)
+				if err != nil {
+					if ctx.Err() != nil {
+						continue
+					}
+					if !failing {
+						toolutil.PrintWarning("Poll failed: %v", err)
+					}
+					failing = true
+					continue
+				}
+				if failing {
+					toolutil.PrintInfo("Polling resumed")
+					failing = false
+				}
+				report(objects)
+			}
+		},
+	}
+
+	addS3Flags(cmd, &opts)
+	cmd.Flags().StringVar(&mode, "mode", "poll", "Event source: poll (list the bucket periodically, any S3 service) or listen (MinIO bucket notifications)")
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Only report objects whose key starts with this prefix")
+	cmd.Flags().StringVar(&suffix, "suffix", "", "Only report objects whose key ends with this suffix (e.g. .json)")
+	cmd.Flags().StringSliceVar(&events, "event", []string{"s3:ObjectCreated:*"}, "MinIO event types to listen to, e.g. s3:ObjectRemoved:* (listen mode, repeatable)")
+	cmd.Flags().StringVar(&since, "since", "", "Also report objects modified since this time, a duration (10m) or RFC3339 timestamp (poll mode, default: now)")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 5*time.Second, "Delay between bucket listings (poll mode)")
+	cmd.Flags().BoolVar(&content, "content", false, "Download and print the contents of created objects")
+	cmd.Flags().Int64Var(&maxContent, "max-content-bytes", 64*1024, "Maximum number of bytes printed with --content")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
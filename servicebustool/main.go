@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "servicebustool",
+		Short: "Azure Service Bus client tester",
+		Long:  "A simple Azure Service Bus CLI with send and serve commands for queues, topics and subscriptions.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// propertyItems converts application properties to sorted key/value items.
+func propertyItems(props map[string]any) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		v := props[k]
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		items = append(items, toolutil.KV{Key: k, Value: fmt.Sprintf("%v", v)})
+	}
+	return items
+}
+
+// messageItems lists the non-empty broker and user properties of m.
+func messageItems(m *azservicebus.ReceivedMessage) []toolutil.KV {
+	items := []toolutil.KV{{Key: "ID", Value: m.MessageID}}
+	add := func(key string, value *string) {
+		if value != nil && *value != "" {
+			items = append(items, toolutil.KV{Key: key, Value: *value})
+		}
+	}
+	if m.SequenceNumber != nil {
+		items = append(items, toolutil.KV{Key: "Sequence", Value: strconv.FormatInt(*m.SequenceNumber, 10)})
+	}
+	items = append(items, toolutil.KV{Key: "Delivery-Count", Value: strconv.FormatUint(uint64(m.DeliveryCount), 10)})
+	if m.EnqueuedTime != nil {
+		items = append(items, toolutil.KV{Key: "Enqueued", Value: m.EnqueuedTime.Format(time.RFC3339)})
+	}
+	add("Subject", m.Subject)
+	add("Content-Type", m.ContentType)
+	add("Correlation-Id", m.CorrelationID)
+	add("Session-Id", m.SessionID)
+	add("Reply-To", m.ReplyTo)
+	add("Dead-Letter-Reason", m.DeadLetterReason)
+	add("Dead-Letter-Description", m.DeadLetterErrorDescription)
+	return items
+}
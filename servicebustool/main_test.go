@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestParseSettleMode(t *testing.T) {
+	for _, s := range []string{"complete", "Abandon", "dead-letter", "none", "receive-and-delete"} {
+		if _, err := parseSettleMode(s); err != nil {
+			t.Errorf("parseSettleMode(%q) error = %v", s, err)
+		}
+	}
+	if _, err := parseSettleMode("defer"); err == nil {
+		t.Error("parseSettleMode(defer) succeeded, want error")
+	}
+}
+
+func TestMessageItems(t *testing.T) {
+	seq := int64(7)
+	enqueued := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	subject := "orders"
+	empty := ""
+	m := &azservicebus.ReceivedMessage{
+		MessageID:      "m1",
+		SequenceNumber: &seq,
+		DeliveryCount:  2,
+		EnqueuedTime:   &enqueued,
+		Subject:        &subject,
+		ContentType:    &empty,
+	}
+	got := messageItems(m)
+	want := []string{"ID=m1", "Sequence=7", "Delivery-Count=2", "Enqueued=2024-01-02T03:04:05Z", "Subject=orders"}
+	if len(got) != len(want) {
+		t.Fatalf("messageItems() = %v, want %v", got, want)
+	}
+	for i, kv := range got {
+		if kv.Key+"="+kv.Value != want[i] {
+			t.Errorf("messageItems()[%d] = %s=%s, want %s", i, kv.Key, kv.Value, want[i])
+		}
+	}
+}
+
+// fakeSettler records the outcome of each settled message as "outcome:ID".
+type fakeSettler struct {
+	settled []string
+}
+
+func (f *fakeSettler) CompleteMessage(_ context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.CompleteMessageOptions) error {
+	f.settled = append(f.settled, "complete:"+m.MessageID)
+	return nil
+}
+
+func (f *fakeSettler) AbandonMessage(_ context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.AbandonMessageOptions) error {
+	f.settled = append(f.settled, "abandon:"+m.MessageID)
+	return nil
+}
+
+func (f *fakeSettler) DeadLetterMessage(_ context.Context, m *azservicebus.ReceivedMessage, _ *azservicebus.DeadLetterOptions) error {
+	f.settled = append(f.settled, "dead-letter:"+m.MessageID)
+	return nil
+}
+
+func TestHandleMessages_MaxMessages(t *testing.T) {
+	tests := []struct {
+		mode settleMode
+		want []string
+	}{
+		{settleComplete, []string{"complete:m1", "abandon:m2", "abandon:m3"}},
+		{settleDeadLetter, []string{"dead-letter:m1", "abandon:m2", "abandon:m3"}},
+		{settleNone, nil},
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	for _, tt := range tests {
+		cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{MaxMessages: 1, AssertCount: -1})
+		if err != nil {
+			t.Fatalf("SetupServe() error = %v", err)
+		}
+		var messages []*azservicebus.ReceivedMessage
+		for _, id := range []string{"m1", "m2", "m3"} {
+			messages = append(messages, &azservicebus.ReceivedMessage{MessageID: id, Body: []byte("{}")})
+		}
+		receiver := &fakeSettler{}
+		handleMessages(receiver, toolutil.MessageSection{Title: "Queue"}, messages, tt.mode, "test")
+		cleanup()
+		if len(receiver.settled) != len(tt.want) {
+			t.Fatalf("%s: settled %v, want %v", tt.mode, receiver.settled, tt.want)
+		}
+		for i := range tt.want {
+			if receiver.settled[i] != tt.want[i] {
+				t.Errorf("%s: settled %v, want %v", tt.mode, receiver.settled, tt.want)
+				break
+			}
+		}
+	}
+}
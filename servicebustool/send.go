@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		connString     string
+		queue          string
+		topic          string
+		subject        string
+		messageID      string
+		sessionID      string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic messages to a Service Bus queue or topic",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			entity := queue
+			if topic != "" {
+				entity = topic
+			}
+
+			// The client connects lazily, on the first operation.
+			client, err := azservicebus.NewClientFromConnectionString(connString, nil)
+			if err != nil {
+				return fmt.Errorf("invalid Service Bus configuration: %w", err)
+			}
+			defer func() {
+				if err := client.Close(context.Background()); err != nil {
+					toolutil.PrintError("Failed to close Service Bus client: %v", err)
+				}
+			}()
+			sender, err := client.NewSender(entity, nil)
+			if err != nil {
+				return fmt.Errorf("failed to create sender: %w", err)
+			}
+			defer sender.Close(context.Background()) //nolint:errcheck
+			// Creating a batch opens the link, so an unreachable namespace fails here.
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				_, err := sender.NewMessageBatch(ctx, nil)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to Service Bus: %w", err)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			var properties map[string]any
+			if len(headerMap) > 0 {
+				properties = make(map[string]any, len(headerMap))
+				for k, v := range headerMap {
+					properties[k] = v
+				}
+			}
+
+			toolutil.PrintSuccess("Service Bus sender ready")
+			if topic != "" {
+				toolutil.PrintKeyValue("Topic", topic)
+			} else {
+				toolutil.PrintKeyValue("Queue", queue)
+			}
+
+			subj := toolutil.NewDestination(subject, openDelim, closeDelim)
+			msgID := toolutil.NewDestination(messageID, openDelim, closeDelim)
+			session := toolutil.NewDestination(sessionID, openDelim, closeDelim)
+			// resolve interpolates an optional templated property, leaving it unset when the flag is empty.
+			resolve := func(raw string, d toolutil.Destination, name string) (*string, error) {
+				if raw == "" {
+					return nil, nil
+				}
+				v, err := d.Resolve()
+				if err != nil {
+					return nil, fmt.Errorf("%s build error: %w", name, err)
+				}
+				return &v, nil
+			}
+
+			send := func() error {
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				msg := &azservicebus.Message{Body: body, ContentType: &ct, ApplicationProperties: properties}
+				if msg.Subject, err = resolve(subject, subj, "Subject"); err != nil {
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				if msg.MessageID, err = resolve(messageID, msgID, "Message id"); err != nil {
+					toolutil.PrintError("%v", err)
+					return err
+				}
+				if msg.SessionID, err = resolve(sessionID, session, "Session id"); err != nil {
+					toolutil.PrintError("%v", err)
+					return err
+				}
+
+				sendCtx, sendCancel := context.WithTimeout(ctx, 30*time.Second)
+				defer sendCancel()
+				if err := sender.SendMessage(sendCtx, msg, nil); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Sent %d bytes to '%s'", len(body), entity)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&connString, "connection-string", "", "Service Bus namespace connection string")
+	cmd.Flags().StringVar(&queue, "queue", "", "Queue to send to")
+	cmd.Flags().StringVar(&topic, "topic", "", "Topic to send to")
+	cmd.Flags().StringVar(&subject, "subject", "", "Message subject (label), supports template placeholders")
+	cmd.Flags().StringVar(&messageID, "message-id", "", "Message id, used for duplicate detection (supports template placeholders)")
+	cmd.Flags().StringVar(&sessionID, "session-id", "", "Session id, required by session-enabled entities (supports template placeholders)")
+	_ = cmd.MarkFlagRequired("connection-string")
+	cmd.MarkFlagsOneRequired("queue", "topic")
+	cmd.MarkFlagsMutuallyExclusive("queue", "topic")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, Service Bus!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
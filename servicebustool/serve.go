@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// settleMode is how serve settles each received message.
+type settleMode string
+
+const (
+	settleComplete   settleMode = "complete"
+	settleAbandon    settleMode = "abandon"
+	settleDeadLetter settleMode = "dead-letter"
+	settleNone       settleMode = "none"
+	// settleDelete receives in ReceiveAndDelete mode, so there is nothing to settle.
+	settleDelete settleMode = "receive-and-delete"
+)
+
+func parseSettleMode(s string) (settleMode, error) {
+	switch m := settleMode(strings.ToLower(s)); m {
+	case settleComplete, settleAbandon, settleDeadLetter, settleNone, settleDelete:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid --settle %q: expected complete, abandon, dead-letter, none or receive-and-delete", s)
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		connString       string
+		queue            string
+		topic            string
+		subscription     string
+		settle           string
+		deadLetterReason string
+		deadLetterQueue  bool
+		batchSize        int
+		connectTimeout   time.Duration
+		serveOpts        toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Receive and log messages from a Service Bus queue or subscription",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mode, err := parseSettleMode(settle)
+			if err != nil {
+				return err
+			}
+			if topic != "" && subscription == "" {
+				return fmt.Errorf("--subscription is required with --topic")
+			}
+			if batchSize < 1 {
+				return fmt.Errorf("--batch-size must be at least 1")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			client, err := azservicebus.NewClientFromConnectionString(connString, nil)
+			if err != nil {
+				return fmt.Errorf("invalid Service Bus configuration: %w", err)
+			}
+			defer func() {
+				if err := client.Close(context.Background()); err != nil {
+					toolutil.PrintError("Failed to close Service Bus client: %v", err)
+				}
+			}()
+
+			opts := &azservicebus.ReceiverOptions{}
+			if mode == settleDelete {
+				opts.ReceiveMode = azservicebus.ReceiveModeReceiveAndDelete
+			}
+			if deadLetterQueue {
+				opts.SubQueue = azservicebus.SubQueueDeadLetter
+			}
+			var (
+				receiver *azservicebus.Receiver
+				source   toolutil.MessageSection
+			)
+			if topic != "" {
+				receiver, err = client.NewReceiverForSubscription(topic, subscription, opts)
+				source = toolutil.MessageSection{Title: "Subscription", Items: []toolutil.KV{
+					{Key: "Name", Value: subscription},
+					{Key: "Topic", Value: topic},
+				}}
+			} else {
+				receiver, err = client.NewReceiverForQueue(queue, opts)
+				source = toolutil.MessageSection{Title: "Queue", Items: []toolutil.KV{{Key: "Name", Value: queue}}}
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create receiver: %w", err)
+			}
+			defer receiver.Close(context.Background()) //nolint:errcheck
+			// The client connects lazily; peeking opens the connection without taking messages.
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				_, err := receiver.PeekMessages(ctx, 1, nil)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to Service Bus: %w", err)
+			}
+			if deadLetterQueue {
+				source.Items = append(source.Items, toolutil.KV{Key: "Sub-Queue", Value: "dead-letter"})
+			}
+
+			toolutil.PrintSuccess("Receiving from Service Bus")
+			for _, kv := range source.Items {
+				toolutil.PrintKeyValue(kv.Key, kv.Value)
+			}
+			toolutil.PrintKeyValue("Settle", string(mode))
+
+			for {
+				n := batchSize
+				if mode == settleDelete && serveOpts.MaxMessages > 0 {
+					// Messages are deleted as they are received, so never take more than can still be printed
+					n = max(1, min(n, serveOpts.MaxMessages-toolutil.ReceivedCount()))
+				}
+				messages, err := receiver.ReceiveMessages(ctx, n, nil)
+				if ctx.Err() != nil {
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				}
+				if err != nil {
+					var sbErr *azservicebus.Error
+					if errors.As(err, &sbErr) && sbErr.Code == azservicebus.CodeUnauthorizedAccess {
+						return fmt.Errorf("receive error: %w", err)
+					}
+					toolutil.PrintError("Receive error: %v", err)
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-time.After(time.Second):
+					}
+					continue
+				}
+				handleMessages(receiver, source, messages, mode, deadLetterReason)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&connString, "connection-string", "", "Service Bus namespace connection string")
+	cmd.Flags().StringVar(&queue, "queue", "", "Queue to receive from")
+	cmd.Flags().StringVar(&topic, "topic", "", "Topic of --subscription")
+	cmd.Flags().StringVar(&subscription, "subscription", "", "Subscription to receive from (with --topic)")
+	cmd.Flags().StringVar(&settle, "settle", string(settleComplete), "How to settle received messages: complete, abandon, dead-letter, none (lock expires) or receive-and-delete")
+	cmd.Flags().StringVar(&deadLetterReason, "dead-letter-reason", "eventkit", "Reason recorded with --settle dead-letter")
+	cmd.Flags().BoolVar(&deadLetterQueue, "dead-letter-queue", false, "Receive from the dead-letter sub-queue")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 10, "Maximum messages per receive call")
+	_ = cmd.MarkFlagRequired("connection-string")
+	cmd.MarkFlagsOneRequired("queue", "topic")
+	cmd.MarkFlagsMutuallyExclusive("queue", "topic")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// settler is the part of the Service Bus receiver used to settle messages.
+type settler interface {
+	CompleteMessage(ctx context.Context, m *azservicebus.ReceivedMessage, opts *azservicebus.CompleteMessageOptions) error
+	AbandonMessage(ctx context.Context, m *azservicebus.ReceivedMessage, opts *azservicebus.AbandonMessageOptions) error
+	DeadLetterMessage(ctx context.Context, m *azservicebus.ReceivedMessage, opts *azservicebus.DeadLetterOptions) error
+}
+
+// handleMessages prints a received batch and settles each message with mode. Messages not
+// printed because --max-messages was reached are abandoned instead, so they are redelivered.
+func handleMessages(receiver settler, source toolutil.MessageSection, messages []*azservicebus.ReceivedMessage, mode settleMode, reason string) {
+	for _, m := range messages {
+		ct := ""
+		if m.ContentType != nil {
+			ct = *m.ContentType
+		}
+		if ct == "" {
+			ct = toolutil.GuessMIME(m.Body)
+		}
+		sections := []toolutil.MessageSection{
+			source,
+			{Title: "Message", Items: messageItems(m)},
+			toolutil.HeadersSection(propertyItems(m.ApplicationProperties)),
+		}
+		outcome := mode
+		if !toolutil.PrintColoredMessage("Service Bus", sections, m.Body, ct) && mode != settleNone && mode != settleDelete {
+			outcome = settleAbandon
+		}
+		if err := settleMessage(receiver, m, outcome, reason); err != nil {
+			toolutil.PrintError("Failed to %s message %s: %v", outcome, m.MessageID, err)
+		}
+	}
+}
+
+// settleMessage applies mode to m. Settlement uses its own context so messages printed right
+// before shutdown are still settled.
+func settleMessage(receiver settler, m *azservicebus.ReceivedMessage, mode settleMode, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	switch mode {
+	case settleComplete:
+		return receiver.CompleteMessage(ctx, m, nil)
+	case settleAbandon:
+		return receiver.AbandonMessage(ctx, m, nil)
+	case settleDeadLetter:
+		return receiver.DeadLetterMessage(ctx, m, &azservicebus.DeadLetterOptions{Reason: &reason})
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// ftpFS is a remoteFS backed by an FTP control connection.
+type ftpFS struct {
+	conn *ftp.ServerConn
+}
+
+// dialFTP connects and logs in to an FTP server, upgrading the control connection with
+// AUTH TLS for ftps URLs. Without a user the anonymous login is used.
+func dialFTP(ctx context.Context, u *url.URL, opts remoteOptions) (remoteFS, error) {
+	dialOpts := []ftp.DialOption{ftp.DialWithContext(ctx)}
+	if u.Scheme == "ftps" {
+		dialOpts = append(dialOpts, ftp.DialWithExplicitTLS(&tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: opts.Insecure})) //nolint:gosec // opt-in for test servers
+	}
+	conn, err := ftp.Dial(hostPort(u), dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	user, password := u.User.Username(), opts.password(u)
+	if user == "" {
+		user, password = "anonymous", "anonymous"
+	}
+	if err := conn.Login(user, password); err != nil {
+		conn.Quit() //nolint:errcheck
+		return nil, err
+	}
+	return &ftpFS{conn: conn}, nil
+}
+
+func (f *ftpFS) Upload(p string, data []byte, atomic bool) error {
+	f.mkdirAll(path.Dir(p))
+	if !atomic {
+		return f.conn.Stor(p, bytes.NewReader(data))
+	}
+
+	tmp := tempName(p)
+	if err := f.conn.Stor(tmp, bytes.NewReader(data)); err != nil {
+		f.conn.Delete(tmp) //nolint:errcheck
+		return err
+	}
+	if err := f.conn.Rename(tmp, p); err != nil {
+		// Some servers refuse to rename over an existing file.
+		f.conn.Delete(p) //nolint:errcheck
+		if err := f.conn.Rename(tmp, p); err != nil {
+			f.conn.Delete(tmp) //nolint:errcheck
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdirAll creates dir and its parents. Errors are ignored since FTP has no portable way
+// to tell existing directories apart; a missing directory makes the upload fail instead.
+func (f *ftpFS) mkdirAll(dir string) {
+	if dir == "." || dir == "/" {
+		return
+	}
+	prefix := ""
+	if strings.HasPrefix(dir, "/") {
+		prefix = "/"
+	}
+	for _, part := range strings.Split(strings.Trim(dir, "/"), "/") {
+		prefix = path.Join(prefix, part)
+		f.conn.MakeDir(prefix) //nolint:errcheck
+	}
+}
+
+func (f *ftpFS) List(dir string) ([]remoteFile, error) {
+	entries, err := f.conn.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []remoteFile
+	for _, e := range entries {
+		if e.Type == ftp.EntryTypeFile {
+			files = append(files, remoteFile{Name: path.Base(e.Name), Size: int64(e.Size), ModTime: e.Time}) // #nosec G115 -- file sizes fit in int64
+		}
+	}
+	return files, nil
+}
+
+func (f *ftpFS) ReadHead(p string, n int64) ([]byte, error) {
+	r, err := f.conn.Retr(p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() //nolint:errcheck // aborted transfers of truncated reads report an error
+	return io.ReadAll(io.LimitReader(r, n))
+}
+
+func (f *ftpFS) Close() error {
+	return f.conn.Quit()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "sftptool",
+		Short: "SFTP/FTP file upload source and directory poller",
+		Long:  "A simple file-transfer CLI that uploads templated files to a remote SFTP or FTP directory and polls a remote directory printing new, changed and removed files.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// remoteOptions configure the connection to the SFTP or FTP server shared by both commands.
+type remoteOptions struct {
+	URL        string
+	Password   string
+	Identity   string
+	KnownHosts string
+	Insecure   bool
+}
+
+func addRemoteFlags(cmd *cobra.Command, opts *remoteOptions) {
+	cmd.Flags().StringVar(&opts.URL, "url", "sftp://user@localhost:22", "Server URL: sftp://USER@HOST:PORT, ftp://[USER@]HOST:PORT or ftps://[USER@]HOST:PORT (explicit TLS)")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "Password, overriding the one in --url")
+	cmd.Flags().StringVar(&opts.Identity, "identity", "", "SFTP private key file (unencrypted PEM or OpenSSH format)")
+	cmd.Flags().StringVar(&opts.KnownHosts, "known-hosts", "", "SFTP known_hosts file used to verify the server key (default ~/.ssh/known_hosts)")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "Skip SFTP host key and FTPS certificate verification")
+}
+
+// parse validates the flags and returns the parsed server URL.
+func (o remoteOptions) parse() (*url.URL, error) {
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --url: %w", err)
+	}
+	switch u.Scheme {
+	case "sftp":
+		if u.User.Username() == "" {
+			return nil, fmt.Errorf("invalid --url %q: sftp requires a user, e.g. sftp://user@host", o.URL)
+		}
+	case "ftp", "ftps":
+		if o.Identity != "" {
+			return nil, fmt.Errorf("--identity is only supported with sftp")
+		}
+	default:
+		return nil, fmt.Errorf("invalid --url %q: expected an sftp, ftp or ftps URL", o.URL)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid --url %q: missing host", o.URL)
+	}
+	if u.Path != "" && u.Path != "/" {
+		return nil, fmt.Errorf("invalid --url %q: use --dir to select the remote directory", o.URL)
+	}
+	return u, nil
+}
+
+// password returns the --password flag or, when unset, the password of the URL.
+func (o remoteOptions) password(u *url.URL) string {
+	if o.Password != "" {
+		return o.Password
+	}
+	p, _ := u.User.Password()
+	return p
+}
+
+// remoteFile describes a regular file of a remote directory.
+type remoteFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// remoteFS is the subset of file operations provided by both SFTP and FTP servers.
+// Paths are slash-separated and relative to the login directory unless absolute.
+type remoteFS interface {
+	// Upload writes data to p, creating parent directories. Atomic uploads go to a hidden
+	// temporary file that is renamed into place once complete.
+	Upload(p string, data []byte, atomic bool) error
+	// List returns the regular files of dir.
+	List(dir string) ([]remoteFile, error)
+	// ReadHead reads at most n bytes of the file at p.
+	ReadHead(p string, n int64) ([]byte, error)
+	Close() error
+}
+
+// dial connects and authenticates to the server of opts.
+func dial(ctx context.Context, opts remoteOptions) (remoteFS, error) {
+	u, err := opts.parse()
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "sftp" {
+		return dialSFTP(ctx, u, opts)
+	}
+	return dialFTP(ctx, u, opts)
+}
+
+// hostPort returns the host:port of u, using the default port of the scheme if missing.
+func hostPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "21"
+	if u.Scheme == "sftp" {
+		port = "22"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// tempName returns the hidden temporary name atomic uploads of p are written to.
+func tempName(p string) string {
+	return path.Join(path.Dir(p), fmt.Sprintf(".%s.tmp-%d", path.Base(p), time.Now().UnixNano()))
+}
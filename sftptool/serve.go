@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// fileEvent is a difference between two listings of the polled directory.
+type fileEvent struct {
+	Op   string // create, write or remove
+	File remoteFile
+}
+
+// diffFiles compares a listing with the files of the previous poll, keyed by name, and
+// returns the events sorted by name together with the index of the new listing.
+func diffFiles(prev map[string]remoteFile, cur []remoteFile) ([]fileEvent, map[string]remoteFile) {
+	next := make(map[string]remoteFile, len(cur))
+	var events []fileEvent
+	for _, f := range cur {
+		next[f.Name] = f
+		old, ok := prev[f.Name]
+		switch {
+		case !ok:
+			events = append(events, fileEvent{Op: "create", File: f})
+		case old.Size != f.Size || !old.ModTime.Equal(f.ModTime):
+			events = append(events, fileEvent{Op: "write", File: f})
+		}
+	}
+	for name, f := range prev {
+		if _, ok := next[name]; !ok {
+			events = append(events, fileEvent{Op: "remove", File: f})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].File.Name < events[j].File.Name })
+	return events, next
+}
+
+// poller lists a remote directory and prints the files matching its filters.
+type poller struct {
+	fs            remoteFS
+	title         string
+	dir           string
+	glob          string
+	includeHidden bool
+	content       bool
+	maxContent    int64
+}
+
+// list returns the files of the directory that pass the hidden file and glob filters.
+func (p *poller) list() ([]remoteFile, error) {
+	files, err := p.fs.List(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	out := files[:0]
+	for _, f := range files {
+		if !p.includeHidden && strings.HasPrefix(f.Name, ".") {
+			continue
+		}
+		if p.glob != "" {
+			if ok, _ := path.Match(p.glob, f.Name); !ok {
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// print reports an event, with the head of the file contents when enabled.
+func (p *poller) print(ev fileEvent) {
+	filePath := path.Join(p.dir, ev.File.Name)
+	var file []toolutil.KV
+	var body []byte
+	if ev.Op != "remove" {
+		file = []toolutil.KV{
+			{Key: "Size", Value: strconv.FormatInt(ev.File.Size, 10)},
+			{Key: "Modified", Value: ev.File.ModTime.Format(time.RFC3339Nano)},
+		}
+		if p.content {
+			var err error
+			body, err = p.fs.ReadHead(filePath, p.maxContent)
+			if err != nil {
+				toolutil.PrintWarning("Failed to read %s: %v", filePath, err)
+			}
+			if ev.File.Size > p.maxContent {
+				file = append(file, toolutil.KV{Key: "Truncated", Value: "true"})
+			}
+		}
+	}
+
+	sections := []toolutil.MessageSection{
+		{Title: "Event", Items: []toolutil.KV{{Key: "Op", Value: ev.Op}, {Key: "Path", Value: filePath}}},
+		{Title: "File", Items: file},
+	}
+	ct := toolutil.GuessMIME(body)
+	if byExt := mime.TypeByExtension(path.Ext(filePath)); byExt != "" && len(body) > 0 {
+		ct, _, _ = strings.Cut(byExt, ";")
+	}
+	toolutil.PrintColoredMessage(p.title, sections, body, ct)
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           remoteOptions
+		dir            string
+		glob           string
+		includeHidden  bool
+		existing       bool
+		content        bool
+		maxContent     int64
+		pollInterval   time.Duration
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Poll a remote directory and log new, changed and removed files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			u, err := opts.parse()
+			if err != nil {
+				return err
+			}
+			if glob != "" {
+				if _, err := path.Match(glob, ""); err != nil {
+					return fmt.Errorf("invalid --glob %q: %w", glob, err)
+				}
+			}
+			if maxContent < 1 {
+				return fmt.Errorf("--max-content-bytes must be at least 1")
+			}
+			if pollInterval <= 0 {
+				return fmt.Errorf("--poll-interval must be positive")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var fs remoteFS
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				c, err := dial(ctx, opts)
+				if err != nil {
+					return err
+				}
+				fs = c
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to server: %w", err)
+			}
+			defer fs.Close() //nolint:errcheck
+
+			p := &poller{
+				fs:            fs,
+				title:         strings.ToUpper(u.Scheme),
+				dir:           dir,
+				glob:          glob,
+				includeHidden: includeHidden,
+				content:       content,
+				maxContent:    maxContent,
+			}
+			// Fail fast on unreadable directories.
+			files, err := p.list()
+			if err != nil {
+				return fmt.Errorf("error listing %s: %w", dir, err)
+			}
+			var known map[string]remoteFile
+			if !existing {
+				_, known = diffFiles(nil, files)
+			}
+
+			toolutil.PrintSuccess("Polling remote directory")
+			toolutil.PrintKeyValue("URL", opts.URL)
+			toolutil.PrintKeyValue("Directory", dir)
+			toolutil.PrintKeyValue("Poll Interval", pollInterval)
+
+			poll := func(files []remoteFile) {
+				var events []fileEvent
+				events, known = diffFiles(known, files)
+				for _, ev := range events {
+					p.print(ev)
+				}
+			}
+			if existing {
+				poll(files)
+			}
+
+			ticker := time.NewTicker(pollInterval)
+			defer ticker.Stop()
+			failing := false
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case <-ticker.C:
+				}
+				files, err := p.list()
+				if err != nil {
+					if !failing {
+						toolutil.PrintWarning("Poll failed: %v", err)
+					}
+					failing = true
+					continue
+				}
+				if failing {
+					toolutil.PrintInfo("Polling resumed")
+					failing = false
+				}
+				poll(files)
+			}
+		},
+	}
+
+	addRemoteFlags(cmd, &opts)
+	cmd.Flags().StringVar(&dir, "dir", ".", "Remote directory to poll")
+	cmd.Flags().StringVar(&glob, "glob", "", "Only report files whose name matches this pattern (e.g. *.json)")
+	cmd.Flags().BoolVar(&includeHidden, "include-hidden", false, "Report dotfiles too, such as the temporary files of atomic uploads")
+	cmd.Flags().BoolVar(&existing, "existing", false, "Report the files already present at startup as created")
+	cmd.Flags().BoolVar(&content, "content", false, "Print the contents of created and written files")
+	cmd.Flags().Int64Var(&maxContent, "max-content-bytes", 64*1024, "Maximum number of bytes printed with --content")
+	cmd.Flags().DurationVar(&pollInterval, "poll-interval", 5*time.Second, "Delay between directory listings")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// attachment is a file attached to an outgoing message.
+type attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// loadAttachment reads path, guessing the content type from its extension.
+func loadAttachment(path string) (attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return attachment{}, err
+	}
+	ct := mime.TypeByExtension(filepath.Ext(path))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	return attachment{Name: filepath.Base(path), ContentType: ct, Data: data}, nil
+}
+
+// outgoing describes a message to compose.
+type outgoing struct {
+	From        string
+	To          []string
+	Subject     string
+	Headers     map[string]string
+	Body        []byte
+	ContentType string
+	Attachments []attachment
+	Date        time.Time
+	MessageID   string
+}
+
+// buildMessage composes an RFC 5322 message. Without attachments the body is the whole message,
+// otherwise it is the first part of a multipart/mixed message.
+func buildMessage(m outgoing) ([]byte, error) {
+	var b bytes.Buffer
+	writeHeader := func(k, v string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	writeHeader("From", m.From)
+	writeHeader("To", strings.Join(m.To, ", "))
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader("Date", m.Date.Format(time.RFC1123Z))
+	writeHeader("Message-ID", m.MessageID)
+	writeHeader("MIME-Version", "1.0")
+	for _, k := range slices.Sorted(maps.Keys(m.Headers)) {
+		writeHeader(textproto.CanonicalMIMEHeaderKey(k), mime.QEncoding.Encode("utf-8", m.Headers[k]))
+	}
+
+	if len(m.Attachments) == 0 {
+		if err := writePart(&b, partHeader(m.ContentType, ""), m.Body); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	writeHeader("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	b.WriteString("\r\n")
+	addPart := func(h textproto.MIMEHeader, body []byte) error {
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		return writeBody(w, h.Get("Content-Transfer-Encoding"), body)
+	}
+	if err := addPart(partHeader(m.ContentType, ""), m.Body); err != nil {
+		return nil, err
+	}
+	for _, a := range m.Attachments {
+		if err := addPart(partHeader(a.ContentType, a.Name), a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// partHeader returns the MIME headers of a body part; a non-empty filename makes it an attachment.
+// Text is sent quoted-printable and everything else base64.
+func partHeader(contentType, filename string) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", nil
+	}
+	if strings.HasPrefix(mediaType, "text/") && params["charset"] == "" {
+		if params == nil {
+			params = map[string]string{}
+		}
+		params["charset"] = "utf-8"
+	}
+	h.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	if strings.HasPrefix(mediaType, "text/") && filename == "" {
+		h.Set("Content-Transfer-Encoding", "quoted-printable")
+	} else {
+		h.Set("Content-Transfer-Encoding", "base64")
+	}
+	if filename != "" {
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	}
+	return h
+}
+
+// writePart writes the headers of a single-part message followed by its encoded body.
+func writePart(b *bytes.Buffer, h textproto.MIMEHeader, body []byte) error {
+	for _, k := range slices.Sorted(maps.Keys(h)) {
+		fmt.Fprintf(b, "%s: %s\r\n", k, h.Get(k))
+	}
+	b.WriteString("\r\n")
+	return writeBody(b, h.Get("Content-Transfer-Encoding"), body)
+}
+
+// writeBody encodes body with the given transfer encoding.
+func writeBody(w io.Writer, encoding string, body []byte) error {
+	if encoding == "quoted-printable" {
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write(body); err != nil {
+			return err
+		}
+		return qp.Close()
+	}
+	enc := base64.StdEncoding.EncodeToString(body)
+	for len(enc) > 76 {
+		if _, err := io.WriteString(w, enc[:76]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err := io.WriteString(w, enc+"\r\n")
+	return err
+}
+
+// mailPart is a decoded leaf MIME part of a received message.
+type mailPart struct {
+	ContentType string
+	Filename    string
+	Body        []byte
+}
+
+// parsedMail is a received message with its decoded leaf parts in document order.
+type parsedMail struct {
+	Header mail.Header
+	Parts  []mailPart
+}
+
+// parseMail parses a message, flattening nested multiparts into their leaf parts.
+func parseMail(r io.Reader) (parsedMail, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return parsedMail{}, err
+	}
+	pm := parsedMail{Header: msg.Header}
+	err = collectParts(textproto.MIMEHeader(msg.Header), msg.Body, &pm.Parts)
+	return pm, err
+}
+
+func collectParts(h textproto.MIMEHeader, body io.Reader, parts *[]mailPart) error {
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := collectParts(p.Header, p, parts); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeBody(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+	part := mailPart{ContentType: ct, Body: data}
+	if _, dparams, err := mime.ParseMediaType(h.Get("Content-Disposition")); err == nil {
+		part.Filename = dparams["filename"]
+	}
+	if part.Filename == "" && params != nil {
+		part.Filename = params["name"]
+	}
+	*parts = append(*parts, part)
+	return nil
+}
+
+func decodeBody(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		// The decoder skips the line breaks of wrapped base64 bodies.
+		return base64.NewDecoder(base64.StdEncoding, r)
+	}
+	return r
+}
+
+// displayPart picks the part shown as the message body: the first inline text/plain part,
+// then the first inline text/html part, then the first part.
+func (pm parsedMail) displayPart() (mailPart, bool) {
+	for _, prefix := range []string{"text/plain", "text/html"} {
+		for _, p := range pm.Parts {
+			if p.Filename == "" && strings.HasPrefix(p.ContentType, prefix) {
+				return p, true
+			}
+		}
+	}
+	if len(pm.Parts) > 0 {
+		return pm.Parts[0], true
+	}
+	return mailPart{}, false
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-smtp"
+)
+
+func testMessage() outgoing {
+	return outgoing{
+		From:        "eventkit@localhost",
+		To:          []string{"a@localhost", "b@localhost"},
+		Subject:     "Ciao è",
+		Headers:     map[string]string{"x-run": "42"},
+		Body:        []byte(strings.Repeat("long line ", 12) + "è"),
+		ContentType: "text/plain",
+		Date:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		MessageID:   "<id@eventkit>",
+	}
+}
+
+func TestBuildMessageSinglePart(t *testing.T) {
+	m := testMessage()
+	raw, err := buildMessage(m)
+	if err != nil {
+		t.Fatalf("buildMessage() error: %v", err)
+	}
+	pm, err := parseMail(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parseMail() error: %v", err)
+	}
+	if got := decodeHeader(pm.Header.Get("Subject")); got != m.Subject {
+		t.Errorf("Subject = %q, want %q", got, m.Subject)
+	}
+	if got := pm.Header.Get("X-Run"); got != "42" {
+		t.Errorf("X-Run = %q, want 42", got)
+	}
+	if got := pm.Header.Get("To"); got != "a@localhost, b@localhost" {
+		t.Errorf("To = %q", got)
+	}
+	if len(pm.Parts) != 1 {
+		t.Fatalf("got %d parts, want 1", len(pm.Parts))
+	}
+	if got := string(pm.Parts[0].Body); got != string(m.Body) {
+		t.Errorf("body = %q, want %q", got, m.Body)
+	}
+	if got := pm.Parts[0].ContentType; got != "text/plain; charset=utf-8" {
+		t.Errorf("content type = %q", got)
+	}
+}
+
+func TestBuildMessageAttachments(t *testing.T) {
+	m := testMessage()
+	data := bytes.Repeat([]byte{0, 1, 2, 0xff}, 50)
+	m.Attachments = []attachment{{Name: "data.bin", ContentType: "application/octet-stream", Data: data}}
+	raw, err := buildMessage(m)
+	if err != nil {
+		t.Fatalf("buildMessage() error: %v", err)
+	}
+	pm, err := parseMail(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("parseMail() error: %v", err)
+	}
+	if len(pm.Parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(pm.Parts))
+	}
+	if att := pm.Parts[1]; att.Filename != "data.bin" || !bytes.Equal(att.Body, data) {
+		t.Errorf("attachment = %q with %d bytes, want data.bin with %d bytes", att.Filename, len(att.Body), len(data))
+	}
+	p, ok := pm.displayPart()
+	if !ok || string(p.Body) != string(m.Body) {
+		t.Errorf("displayPart() = %q, want the text body", p.Body)
+	}
+}
+
+func TestDisplayPart(t *testing.T) {
+	pm := parsedMail{Parts: []mailPart{
+		{ContentType: "text/plain", Filename: "notes.txt", Body: []byte("attached")},
+		{ContentType: "text/html", Body: []byte("<p>html</p>")},
+		{ContentType: "text/plain; charset=utf-8", Body: []byte("plain")},
+	}}
+	if p, _ := pm.displayPart(); string(p.Body) != "plain" {
+		t.Errorf("displayPart() = %q, want plain", p.Body)
+	}
+	pm.Parts = pm.Parts[:2]
+	if p, _ := pm.displayPart(); string(p.Body) != "<p>html</p>" {
+		t.Errorf("displayPart() = %q, want html", p.Body)
+	}
+	if _, ok := (parsedMail{}).displayPart(); ok {
+		t.Error("displayPart() of a message without parts reported a part")
+	}
+}
+
+func TestClientOptionsValidate(t *testing.T) {
+	for _, mode := range []string{"none", "starttls", "tls"} {
+		if err := (clientOptions{TLS: mode}).validate(); err != nil {
+			t.Errorf("validate(%q) error: %v", mode, err)
+		}
+	}
+	if err := (clientOptions{TLS: "ssl"}).validate(); err == nil {
+		t.Error("validate(ssl) succeeded, want error")
+	}
+}
+
+func TestSendToServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := smtp.NewServer(backend{})
+	srv.AllowInsecureAuth = true
+	go srv.Serve(ln)  //nolint:errcheck
+	defer srv.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := dial(ctx, clientOptions{Address: ln.Addr().String(), TLS: "none", Username: "user", Password: "secret"})
+	if err != nil {
+		t.Fatalf("dial() error: %v", err)
+	}
+	defer c.Close() //nolint:errcheck
+	m := testMessage()
+	raw, err := buildMessage(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendMail(m.From, m.To, bytes.NewReader(raw)); err != nil {
+		t.Fatalf("SendMail() error: %v", err)
+	}
+	if err := c.SendMail(m.From, m.To, strings.NewReader("not a message")); err == nil {
+		t.Error("SendMail() of a malformed message succeeded, want error")
+	}
+}
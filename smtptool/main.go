@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "smtptool",
+		Short: "SMTP client and server tester",
+		Long:  "A simple SMTP CLI that sends templated email and runs an embedded SMTP server printing received mail.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// clientOptions configure the connection to the SMTP server.
+type clientOptions struct {
+	Address  string
+	TLS      string
+	Insecure bool
+	Username string
+	Password string
+}
+
+// validate checks the --tls flag.
+func (o clientOptions) validate() error {
+	switch o.TLS {
+	case "none", "starttls", "tls":
+		return nil
+	}
+	return fmt.Errorf("invalid --tls %q: expected none, starttls or tls", o.TLS)
+}
+
+// dial connects to the SMTP server, negotiates TLS and authenticates, ready for MAIL FROM.
+// The greeting and EHLO are exchanged on the first command.
+func dial(ctx context.Context, opts clientOptions) (*smtp.Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", opts.Address)
+	if err != nil {
+		return nil, err
+	}
+	host, _, _ := strings.Cut(opts.Address, ":")
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: opts.Insecure} //nolint:gosec // opt-in for test servers
+
+	var c *smtp.Client
+	switch opts.TLS {
+	case "tls":
+		c = smtp.NewClient(tls.Client(conn, tlsConfig))
+	case "starttls":
+		if c, err = smtp.NewClientStartTLS(conn, tlsConfig); err != nil {
+			return nil, err
+		}
+	default:
+		c = smtp.NewClient(conn)
+	}
+	if opts.Username != "" {
+		if err := c.Auth(sasl.NewPlainClient("", opts.Username, opts.Password)); err != nil {
+			c.Close() //nolint:errcheck
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+	return c, nil
+}
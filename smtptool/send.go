@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		clientOpts     clientOptions
+		from           string
+		to             []string
+		subject        string
+		attachments    []string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic templated email through an SMTP server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := clientOpts.validate(); err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var files []attachment
+			for _, path := range attachments {
+				a, err := loadAttachment(path)
+				if err != nil {
+					return fmt.Errorf("invalid attachment: %w", err)
+				}
+				files = append(files, a)
+			}
+
+			// Each message uses its own connection; the first one only checks that the server is reachable.
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					c, err := dial(ctx, clientOpts)
+					if err != nil {
+						return err
+					}
+					defer c.Close() //nolint:errcheck
+					if err := c.Noop(); err != nil {
+						return err
+					}
+					return c.Quit()
+				})
+			}); err != nil {
+				return fmt.Errorf("error connecting to SMTP server: %w", err)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+
+			toolutil.PrintSuccess("SMTP server reachable")
+			toolutil.PrintKeyValue("Address", clientOpts.Address)
+			toolutil.PrintKeyValue("From", from)
+			toolutil.PrintKeyValue("To", to)
+
+			subj := toolutil.NewDestination(subject, openDelim, closeDelim)
+			send := func() error {
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				s, err := subj.Resolve()
+				if err != nil {
+					toolutil.PrintError("Subject build error: %v", err)
+					return err
+				}
+				msg, err := buildMessage(outgoing{
+					From:        from,
+					To:          to,
+					Subject:     s,
+					Headers:     headerMap,
+					Body:        body,
+					ContentType: ct,
+					Attachments: files,
+					Date:        time.Now(),
+					MessageID:   "<" + uuid.NewString() + "@eventkit>",
+				})
+				if err != nil {
+					toolutil.PrintError("Message build error: %v", err)
+					return err
+				}
+
+				sendCtx, sendCancel := context.WithTimeout(ctx, 30*time.Second)
+				defer sendCancel()
+				c, err := dial(sendCtx, clientOpts)
+				if err != nil {
+					toolutil.PrintError("Connection error: %v", err)
+					return err
+				}
+				defer c.Close() //nolint:errcheck
+				if err := c.SendMail(from, to, bytes.NewReader(msg)); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				_ = c.Quit()
+				toolutil.PrintInfo("Sent %d bytes to %d recipient(s): %s", len(msg), len(to), s)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&clientOpts.Address, "address", "localhost:1025", "SMTP server address (host:port)")
+	cmd.Flags().StringVar(&clientOpts.TLS, "tls", "none", "Transport security: none, starttls or tls (implicit TLS, e.g. port 465)")
+	cmd.Flags().BoolVar(&clientOpts.Insecure, "insecure", false, "Skip TLS certificate verification")
+	cmd.Flags().StringVar(&clientOpts.Username, "username", "", "Username for AUTH PLAIN")
+	cmd.Flags().StringVar(&clientOpts.Password, "password", "", "Password for AUTH PLAIN")
+	cmd.Flags().StringVar(&from, "from", "eventkit@localhost", "Sender address (envelope and From header)")
+	cmd.Flags().StringSliceVar(&to, "to", []string{"test@localhost"}, "Recipient address (repeatable)")
+	cmd.Flags().StringVar(&subject, "subject", "eventkit test message", "Subject, supports template placeholders")
+	cmd.Flags().StringArrayVar(&attachments, "attach", nil, "File to attach (repeatable)")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello from eventkit at {{nowtime}}", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr       string
+		domain          string
+		maxMessageBytes int64
+		serveOpts       toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an embedded SMTP server that logs received mail",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if maxMessageBytes < 1 {
+				return fmt.Errorf("--max-message-bytes must be at least 1")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			srv := smtp.NewServer(backend{})
+			srv.Addr = serveAddr
+			srv.Domain = domain
+			srv.MaxMessageBytes = maxMessageBytes
+			srv.ReadTimeout = 30 * time.Second
+			srv.WriteTimeout = 30 * time.Second
+			// Credentials are accepted but not checked; AUTH is only advertised so clients configured with it work.
+			srv.AllowInsecureAuth = true
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, smtp.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("SMTP server listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Domain", domain)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving SMTP: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:1025", "Listen address")
+	cmd.Flags().StringVar(&domain, "domain", "localhost", "Server domain announced in the greeting")
+	cmd.Flags().Int64Var(&maxMessageBytes, "max-message-bytes", 10<<20, "Maximum accepted message size in bytes")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// backend creates a session for every SMTP connection.
+type backend struct{}
+
+func (backend) NewSession(_ *smtp.Conn) (smtp.Session, error) {
+	return &session{}, nil
+}
+
+// session records the envelope of the current transaction and prints the message on DATA.
+type session struct {
+	from string
+	to   []string
+}
+
+// AuthMechanisms advertises PLAIN so authenticating clients can deliver; any credentials are accepted.
+func (s *session) AuthMechanisms() []string {
+	return []string{"PLAIN"}
+}
+
+func (s *session) Auth(string) (sasl.Server, error) {
+	return sasl.NewPlainServer(func(string, string, string) error { return nil }), nil
+}
+
+func (s *session) Mail(from string, _ *smtp.MailOptions) error {
+	s.from = from
+	return nil
+}
+
+func (s *session) Rcpt(to string, _ *smtp.RcptOptions) error {
+	s.to = append(s.to, to)
+	return nil
+}
+
+func (s *session) Data(r io.Reader) error {
+	pm, err := parseMail(r)
+	if err != nil {
+		toolutil.PrintError("Failed to parse message: %v", err)
+		return &smtp.SMTPError{Code: 554, EnhancedCode: smtp.EnhancedCode{5, 6, 0}, Message: "Malformed message"}
+	}
+	printMail(s.from, s.to, pm)
+	return nil
+}
+
+func (s *session) Reset() {
+	s.from, s.to = "", nil
+}
+
+func (s *session) Logout() error {
+	return nil
+}
+
+var wordDecoder = mime.WordDecoder{}
+
+// decodeHeader decodes RFC 2047 encoded words, keeping the raw value when it is malformed.
+func decodeHeader(v string) string {
+	if d, err := wordDecoder.DecodeHeader(v); err == nil {
+		return d
+	}
+	return v
+}
+
+func printMail(from string, to []string, pm parsedMail) {
+	envelope := []toolutil.KV{
+		{Key: "From", Value: from},
+		{Key: "To", Value: strings.Join(to, ", ")},
+	}
+	var headers []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(pm.Header)) {
+		for _, v := range pm.Header[k] {
+			headers = append(headers, toolutil.KV{Key: k, Value: decodeHeader(v)})
+		}
+	}
+	var parts []toolutil.KV
+	for i, p := range pm.Parts {
+		desc := fmt.Sprintf("%s, %d bytes", p.ContentType, len(p.Body))
+		if p.Filename != "" {
+			desc = p.Filename + " (" + desc + ")"
+		}
+		parts = append(parts, toolutil.KV{Key: strconv.Itoa(i + 1), Value: desc})
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Envelope", Items: envelope},
+		toolutil.HeadersSection(headers),
+		{Title: fmt.Sprintf("Parts (%d)", len(pm.Parts)), Items: parts},
+	}
+
+	var (
+		body []byte
+		ct   string
+	)
+	if p, ok := pm.displayPart(); ok {
+		body, ct = p.Body, p.ContentType
+	}
+	if mediaType, _, err := mime.ParseMediaType(ct); err == nil {
+		ct = mediaType
+	} else {
+		ct = toolutil.GuessMIME(body)
+	}
+	toolutil.PrintColoredMessage("SMTP", sections, body, ct)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// snmpTrapOID is the varbind carrying the notification OID of SNMPv2 traps.
+const snmpTrapOID = "1.3.6.1.6.3.1.1.4.1.0"
+
+// sysUpTime is the varbind carrying the uptime of the sender, first in SNMPv2 traps.
+const sysUpTime = "1.3.6.1.2.1.1.3.0"
+
+func main() {
+	root := &cobra.Command{
+		Use:   "snmptool",
+		Short: "SNMP trap sender and receiver",
+		Long:  "A simple SNMP CLI that sends v2c/v3 traps with templated varbinds and runs a trap receiver printing decoded OIDs and values.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// snmpOptions are the version and security settings shared by both commands.
+type snmpOptions struct {
+	Version        string
+	Community      string
+	User           string
+	AuthProtocol   string
+	AuthPassphrase string
+	PrivProtocol   string
+	PrivPassphrase string
+	EngineID       string
+}
+
+// addSNMPFlags registers the version and security flags.
+func addSNMPFlags(cmd *cobra.Command, opts *snmpOptions) {
+	cmd.Flags().StringVar(&opts.Version, "version", "2c", "SNMP version: 2c or 3")
+	cmd.Flags().StringVar(&opts.Community, "community", "public", "Community string (v2c)")
+	cmd.Flags().StringVar(&opts.User, "user", "", "USM user name (v3)")
+	cmd.Flags().StringVar(&opts.AuthProtocol, "auth-protocol", "none", "Authentication protocol (v3): none, MD5, SHA, SHA224, SHA256, SHA384 or SHA512")
+	cmd.Flags().StringVar(&opts.AuthPassphrase, "auth-passphrase", "", "Authentication passphrase (v3)")
+	cmd.Flags().StringVar(&opts.PrivProtocol, "priv-protocol", "none", "Privacy protocol (v3): none, DES, AES, AES192, AES256, AES192C or AES256C")
+	cmd.Flags().StringVar(&opts.PrivPassphrase, "priv-passphrase", "", "Privacy passphrase (v3)")
+	// Trap senders are authoritative, so the receiver needs the same engine ID to localize keys.
+	cmd.Flags().StringVar(&opts.EngineID, "engine-id", "80000000046576656e746b6974", "Hex authoritative engine ID of the trap sender (v3)")
+}
+
+var authProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"none": gosnmp.NoAuth, "md5": gosnmp.MD5, "sha": gosnmp.SHA, "sha224": gosnmp.SHA224,
+	"sha256": gosnmp.SHA256, "sha384": gosnmp.SHA384, "sha512": gosnmp.SHA512,
+}
+
+var privProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"none": gosnmp.NoPriv, "des": gosnmp.DES, "aes": gosnmp.AES, "aes192": gosnmp.AES192,
+	"aes256": gosnmp.AES256, "aes192c": gosnmp.AES192C, "aes256c": gosnmp.AES256C,
+}
+
+// params returns the gosnmp settings for opts; the caller sets the target.
+func (opts snmpOptions) params() (*gosnmp.GoSNMP, error) {
+	g := &gosnmp.GoSNMP{
+		Transport: "udp",
+		Community: opts.Community,
+		Timeout:   5 * time.Second,
+		Retries:   1,
+		MaxOids:   gosnmp.MaxOids,
+	}
+	switch opts.Version {
+	case "2c":
+		g.Version = gosnmp.Version2c
+		return g, nil
+	case "3":
+		g.Version = gosnmp.Version3
+	default:
+		return nil, fmt.Errorf("invalid --version %q: expected 2c or 3", opts.Version)
+	}
+
+	if opts.User == "" {
+		return nil, fmt.Errorf("--version 3 requires --user")
+	}
+	auth, ok := authProtocols[strings.ToLower(opts.AuthProtocol)]
+	if !ok {
+		return nil, fmt.Errorf("invalid --auth-protocol %q", opts.AuthProtocol)
+	}
+	priv, ok := privProtocols[strings.ToLower(opts.PrivProtocol)]
+	if !ok {
+		return nil, fmt.Errorf("invalid --priv-protocol %q", opts.PrivProtocol)
+	}
+	engineID, err := hex.DecodeString(opts.EngineID)
+	if err != nil || len(engineID) < 5 || len(engineID) > 32 {
+		return nil, fmt.Errorf("invalid --engine-id %q: expected 5 to 32 hex-encoded bytes", opts.EngineID)
+	}
+
+	g.SecurityModel = gosnmp.UserSecurityModel
+	switch {
+	case priv != gosnmp.NoPriv:
+		if auth == gosnmp.NoAuth {
+			return nil, fmt.Errorf("--priv-protocol requires --auth-protocol")
+		}
+		g.MsgFlags = gosnmp.AuthPriv
+	case auth != gosnmp.NoAuth:
+		g.MsgFlags = gosnmp.AuthNoPriv
+	default:
+		g.MsgFlags = gosnmp.NoAuthNoPriv
+	}
+	if auth != gosnmp.NoAuth && len(opts.AuthPassphrase) < 8 {
+		return nil, fmt.Errorf("--auth-passphrase must be at least 8 characters")
+	}
+	if priv != gosnmp.NoPriv && len(opts.PrivPassphrase) < 8 {
+		return nil, fmt.Errorf("--priv-passphrase must be at least 8 characters")
+	}
+	g.SecurityParameters = &gosnmp.UsmSecurityParameters{
+		UserName:                 opts.User,
+		AuthoritativeEngineID:    string(engineID),
+		AuthoritativeEngineBoots: 1,
+		AuthenticationProtocol:   auth,
+		AuthenticationPassphrase: opts.AuthPassphrase,
+		PrivacyProtocol:          priv,
+		PrivacyPassphrase:        opts.PrivPassphrase,
+	}
+	return g, nil
+}
+
+// varbindTypes maps the net-snmp type letters to BER types.
+var varbindTypes = map[string]gosnmp.Asn1BER{
+	"s": gosnmp.OctetString,
+	"x": gosnmp.OctetString,
+	"i": gosnmp.Integer,
+	"u": gosnmp.Gauge32,
+	"c": gosnmp.Counter32,
+	"C": gosnmp.Counter64,
+	"t": gosnmp.TimeTicks,
+	"o": gosnmp.ObjectIdentifier,
+	"a": gosnmp.IPAddress,
+}
+
+// varbindSpec is a --varbind flag: the value is a template resolved on every send.
+type varbindSpec struct {
+	OID   string
+	Type  string
+	Value string
+}
+
+// parseVarbind parses OID=TYPE:VALUE, where TYPE is a net-snmp type letter.
+func parseVarbind(s string) (varbindSpec, error) {
+	oid, rest, ok := strings.Cut(s, "=")
+	typ, value, ok2 := strings.Cut(rest, ":")
+	if !ok || !ok2 {
+		return varbindSpec{}, fmt.Errorf("invalid varbind %q: expected OID=TYPE:VALUE", s)
+	}
+	oid = trimDot(strings.TrimSpace(oid))
+	if err := validateOID(oid); err != nil {
+		return varbindSpec{}, fmt.Errorf("invalid varbind %q: %w", s, err)
+	}
+	if _, ok := varbindTypes[typ]; !ok {
+		return varbindSpec{}, fmt.Errorf("invalid varbind %q: unknown type %q (expected s, x, i, u, c, C, t, o or a)", s, typ)
+	}
+	return varbindSpec{OID: oid, Type: typ, Value: value}, nil
+}
+
+// trimDot removes the leading dot of an absolute OID.
+func trimDot(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
+
+// validateOID checks that oid is a dotted sequence of numbers.
+func validateOID(oid string) error {
+	parts := strings.Split(trimDot(oid), ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("OID %q must have at least two components", oid)
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 32); err != nil {
+			return fmt.Errorf("invalid OID %q", oid)
+		}
+	}
+	return nil
+}
+
+// pdu converts an interpolated varbind value to a PDU of the spec's type.
+func (v varbindSpec) pdu(value string) (gosnmp.SnmpPDU, error) {
+	p := gosnmp.SnmpPDU{Name: "." + v.OID, Type: varbindTypes[v.Type]}
+	var err error
+	switch v.Type {
+	case "s":
+		p.Value = value
+	case "x":
+		var b []byte
+		b, err = hex.DecodeString(strings.NewReplacer(" ", "", ":", "").Replace(value))
+		p.Value = b
+	case "i":
+		var n int64
+		n, err = strconv.ParseInt(value, 10, 32)
+		p.Value = int(n)
+	case "u", "c", "t":
+		var n uint64
+		n, err = strconv.ParseUint(value, 10, 32)
+		p.Value = uint32(n)
+	case "C":
+		p.Value, err = strconv.ParseUint(value, 10, 64)
+	case "o":
+		err = validateOID(value)
+		p.Value = "." + trimDot(value)
+	case "a":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			err = fmt.Errorf("not an IPv4 address")
+		}
+		p.Value = value
+	}
+	if err != nil {
+		return p, fmt.Errorf("invalid %s value %q for %s: %w", p.Type, value, v.OID, err)
+	}
+	return p, nil
+}
+
+// formatValue renders a received varbind value; octet strings that are not valid text are shown as hex.
+func formatValue(p gosnmp.SnmpPDU) string {
+	switch v := p.Value.(type) {
+	case []byte:
+		if utf8.Valid(v) && !strings.ContainsFunc(string(v), func(r rune) bool { return r < 32 && r != '\n' && r != '\t' }) {
+			return string(v)
+		}
+		return "0x" + hex.EncodeToString(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
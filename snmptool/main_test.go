@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestParseVarbind(t *testing.T) {
+	spec, err := parseVarbind(".1.3.6.1.4.1.32473.1=s:a:b")
+	if err != nil {
+		t.Fatalf("parseVarbind() error: %v", err)
+	}
+	if spec.OID != "1.3.6.1.4.1.32473.1" || spec.Type != "s" || spec.Value != "a:b" {
+		t.Errorf("parseVarbind() = %+v", spec)
+	}
+	for _, in := range []string{"1.3.6=s", "1.3.6:s:x", "1.x.6=s:v", "1=s:v", "1.3.6=z:v"} {
+		if _, err := parseVarbind(in); err == nil {
+			t.Errorf("parseVarbind(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestVarbindPDU(t *testing.T) {
+	tests := []struct {
+		typ   string
+		value string
+		want  any
+	}{
+		{"s", "text", "text"},
+		{"i", "-42", -42},
+		{"u", "7", uint32(7)},
+		{"c", "8", uint32(8)},
+		{"t", "100", uint32(100)},
+		{"C", "18446744073709551615", uint64(18446744073709551615)},
+		{"o", "1.3.6.1", ".1.3.6.1"},
+		{"a", "10.0.0.1", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		p, err := varbindSpec{OID: "1.3.6.1.4.1.32473.1", Type: tt.typ}.pdu(tt.value)
+		if err != nil {
+			t.Errorf("pdu(%s, %q) error: %v", tt.typ, tt.value, err)
+			continue
+		}
+		if p.Value != tt.want || p.Type != varbindTypes[tt.typ] || p.Name != ".1.3.6.1.4.1.32473.1" {
+			t.Errorf("pdu(%s, %q) = %+v, want value %v", tt.typ, tt.value, p, tt.want)
+		}
+	}
+	p, err := varbindSpec{OID: "1.3.6.1", Type: "x"}.pdu("de:ad be ef")
+	if err != nil || string(p.Value.([]byte)) != "\xde\xad\xbe\xef" {
+		t.Errorf("pdu(x) = %v, %v", p.Value, err)
+	}
+	for _, tt := range [][2]string{{"i", "x"}, {"u", "-1"}, {"t", "4294967296"}, {"a", "::1"}, {"o", "foo"}, {"x", "zz"}} {
+		if _, err := (varbindSpec{OID: "1.3.6.1", Type: tt[0]}).pdu(tt[1]); err == nil {
+			t.Errorf("pdu(%s, %q) succeeded, want error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestParams(t *testing.T) {
+	if g, err := (snmpOptions{Version: "2c", Community: "c"}).params(); err != nil || g.Version != gosnmp.Version2c {
+		t.Errorf("params(2c) = %v, %v", g, err)
+	}
+	invalid := []snmpOptions{
+		{Version: "1"},
+		{Version: "3"},
+		{Version: "3", User: "u", AuthProtocol: "foo", PrivProtocol: "none", EngineID: "8000000001"},
+		{Version: "3", User: "u", AuthProtocol: "none", PrivProtocol: "aes", EngineID: "8000000001"},
+		{Version: "3", User: "u", AuthProtocol: "sha", AuthPassphrase: "short", PrivProtocol: "none", EngineID: "8000000001"},
+		{Version: "3", User: "u", AuthProtocol: "none", PrivProtocol: "none", EngineID: "80"},
+	}
+	for _, opts := range invalid {
+		if _, err := opts.params(); err == nil {
+			t.Errorf("params(%+v) succeeded, want error", opts)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{[]byte("text"), "text"},
+		{[]byte{0, 1}, "0x0001"},
+		{uint(5), "5"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := formatValue(gosnmp.SnmpPDU{Value: tt.value}); got != tt.want {
+			t.Errorf("formatValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestV3TrapLoopback(t *testing.T) {
+	opts := snmpOptions{
+		Version:        "3",
+		User:           "bob",
+		AuthProtocol:   "SHA256",
+		AuthPassphrase: "authpass1",
+		PrivProtocol:   "AES",
+		PrivPassphrase: "privpass1",
+		EngineID:       "80000000046576656e746b6974",
+	}
+	listenParams, err := opts.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The receiving socket binds :0 itself and decodes the trap as TrapListener does, so no
+	// port is released and re-bound.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() //nolint:errcheck
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	received := make(chan *gosnmp.SnmpPacket, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		p, err := listenParams.UnmarshalTrap(buf[:n], false)
+		if err != nil {
+			t.Errorf("UnmarshalTrap() error: %v", err)
+			return
+		}
+		received <- p
+	}()
+
+	g, err := opts.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Target, g.Port = "127.0.0.1", uint16(addr.Port) //nolint:gosec
+	if err := g.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Conn.Close() //nolint:errcheck
+	payload := gosnmp.SnmpPDU{Name: ".1.3.6.1.4.1.32473.1.1", Type: gosnmp.OctetString, Value: []byte("secret")}
+	if _, err := g.SendTrap(gosnmp.SnmpTrap{Variables: []gosnmp.SnmpPDU{payload}}); err != nil {
+		t.Fatalf("SendTrap() error: %v", err)
+	}
+
+	select {
+	case p := <-received:
+		if len(p.Variables) != 2 || formatValue(p.Variables[1]) != "secret" {
+			t.Errorf("received varbinds %+v, want uptime and the payload", p.Variables)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trap received")
+	}
+}
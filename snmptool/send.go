@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		target         string
+		snmpOpts       snmpOptions
+		trapOID        string
+		payloadOID     string
+		varbinds       []string
+		inform         bool
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic SNMP traps with templated varbinds",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := snmpOpts.params()
+			if err != nil {
+				return err
+			}
+			host, portStr, err := net.SplitHostPort(target)
+			if err != nil {
+				return fmt.Errorf("invalid --target %q: %w", target, err)
+			}
+			port, err := strconv.ParseUint(portStr, 10, 16)
+			if err != nil {
+				return fmt.Errorf("invalid --target port %q", portStr)
+			}
+			g.Target, g.Port = host, uint16(port)
+			trapOID = "." + trimDot(trapOID)
+			if err := validateOID(trapOID); err != nil {
+				return fmt.Errorf("invalid --trap-oid: %w", err)
+			}
+			if payloadOID != "" {
+				if err := validateOID(payloadOID); err != nil {
+					return fmt.Errorf("invalid --payload-oid: %w", err)
+				}
+			}
+			specs := make([]varbindSpec, 0, len(varbinds))
+			for _, vb := range varbinds {
+				spec, err := parseVarbind(vb)
+				if err != nil {
+					return err
+				}
+				specs = append(specs, spec)
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+					return g.Connect()
+				})
+			}); err != nil {
+				return fmt.Errorf("error connecting to trap receiver: %w", err)
+			}
+			defer g.Conn.Close() //nolint:errcheck
+
+			toolutil.PrintSuccess("Ready to send SNMP traps")
+			toolutil.PrintKeyValue("Target", target)
+			toolutil.PrintKeyValue("Version", g.Version)
+			toolutil.PrintKeyValue("Trap OID", trapOID)
+
+			values := make([]toolutil.Destination, len(specs))
+			for i, spec := range specs {
+				values[i] = toolutil.NewDestination(spec.Value, openDelim, closeDelim)
+			}
+			start := time.Now()
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				pdus := []gosnmp.SnmpPDU{
+					{Name: sysUpTime, Type: gosnmp.TimeTicks, Value: uint32(time.Since(start) / (10 * time.Millisecond))}, //nolint:gosec // wraps like sysUpTime
+					{Name: snmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: trapOID},
+				}
+				if payloadOID != "" {
+					pdus = append(pdus, gosnmp.SnmpPDU{Name: "." + trimDot(payloadOID), Type: gosnmp.OctetString, Value: body})
+				}
+				for i, spec := range specs {
+					v, err := values[i].Resolve()
+					if err != nil {
+						toolutil.PrintError("Varbind build error: %v", err)
+						return err
+					}
+					p, err := spec.pdu(v)
+					if err != nil {
+						toolutil.PrintError("Varbind error: %v", err)
+						return err
+					}
+					pdus = append(pdus, p)
+				}
+				if sp, ok := g.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+					sp.AuthoritativeEngineTime = uint32(time.Since(start).Seconds())
+				}
+
+				if _, err := g.SendTrap(gosnmp.SnmpTrap{Variables: pdus, IsInform: inform}); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				kind := "trap"
+				if inform {
+					kind = "inform (acknowledged)"
+				}
+				toolutil.PrintInfo("Sent %s with %d varbinds", kind, len(pdus))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "target", "localhost:9162", "Trap receiver address (host:port)")
+	addSNMPFlags(cmd, &snmpOpts)
+	cmd.Flags().StringVar(&trapOID, "trap-oid", "1.3.6.1.4.1.32473.0.1", "Notification OID sent as snmpTrapOID.0")
+	cmd.Flags().StringVar(&payloadOID, "payload-oid", "1.3.6.1.4.1.32473.1.1", "OID of the OctetString varbind carrying the payload; empty to omit it")
+	cmd.Flags().StringArrayVar(&varbinds, "varbind", nil, "Extra varbind OID=TYPE:VALUE with a net-snmp type letter (s, x, i, u, c, C, t, o, a); the value supports template placeholders (repeatable)")
+	cmd.Flags().BoolVar(&inform, "inform", false, "Send InformRequests and wait for the receiver's acknowledgement")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, SNMP!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
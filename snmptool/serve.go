@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		address    string
+		snmpOpts   snmpOptions
+		payloadOID string
+		serveOpts  toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an SNMP trap receiver that logs decoded traps",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			g, err := snmpOpts.params()
+			if err != nil {
+				return err
+			}
+			if payloadOID != "" {
+				if err := validateOID(payloadOID); err != nil {
+					return fmt.Errorf("invalid --payload-oid: %w", err)
+				}
+				payloadOID = "." + trimDot(payloadOID)
+			}
+			// Traps of any community are accepted unless one is requested explicitly.
+			community := ""
+			if cmd.Flags().Changed("community") {
+				community = snmpOpts.Community
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			tl := gosnmp.NewTrapListener()
+			tl.Params = g
+			tl.OnNewTrap = func(p *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+				if community != "" && p.Version != gosnmp.Version3 && p.Community != community {
+					toolutil.PrintWarning("Dropped trap from %s with community %q", addr, p.Community)
+					return
+				}
+				printTrap(p, addr, payloadOID)
+			}
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- tl.Listen(address)
+			}()
+			select {
+			case <-tl.Listening():
+			case err := <-errChan:
+				return fmt.Errorf("error listening on %s: %w", address, err)
+			}
+
+			toolutil.PrintSuccess("SNMP trap receiver listening")
+			toolutil.PrintKeyValue("Address", address)
+			toolutil.PrintKeyValue("Version", g.Version)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				tl.Close()
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error receiving traps: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&address, "address", "0.0.0.0:9162", "Listen address (UDP)")
+	addSNMPFlags(cmd, &snmpOpts)
+	cmd.Flags().StringVar(&payloadOID, "payload-oid", "1.3.6.1.4.1.32473.1.1", "OID of the varbind shown as the message body; empty to list every varbind")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// printTrap prints a received trap. sysUpTime and snmpTrapOID are shown with the trap details
+// and the payload varbind as the body; other varbinds are listed in order.
+func printTrap(p *gosnmp.SnmpPacket, addr *net.UDPAddr, payloadOID string) {
+	trap := []toolutil.KV{
+		{Key: "Version", Value: p.Version.String()},
+		{Key: "PDU", Value: p.PDUType.String()},
+	}
+	if p.Version == gosnmp.Version3 {
+		if sp, ok := p.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+			trap = append(trap, toolutil.KV{Key: "User", Value: sp.UserName})
+		}
+	} else {
+		trap = append(trap, toolutil.KV{Key: "Community", Value: p.Community})
+	}
+	if p.PDUType == gosnmp.Trap {
+		trap = append(trap,
+			toolutil.KV{Key: "Enterprise", Value: p.Enterprise},
+			toolutil.KV{Key: "Agent", Value: p.AgentAddress},
+			toolutil.KV{Key: "Generic", Value: fmt.Sprint(p.GenericTrap)},
+			toolutil.KV{Key: "Specific", Value: fmt.Sprint(p.SpecificTrap)},
+		)
+	}
+
+	var (
+		varbinds []toolutil.KV
+		body     []byte
+	)
+	for _, v := range p.Variables {
+		switch {
+		case v.Name == "."+snmpTrapOID:
+			trap = append(trap, toolutil.KV{Key: "Trap OID", Value: formatValue(v)})
+		case v.Name == "."+sysUpTime:
+			if ticks, ok := v.Value.(uint32); ok {
+				trap = append(trap, toolutil.KV{Key: "Uptime", Value: (time.Duration(ticks) * 10 * time.Millisecond).String()})
+			}
+		case payloadOID != "" && v.Name == payloadOID && body == nil:
+			if b, ok := v.Value.([]byte); ok {
+				body = b
+				continue
+			}
+			fallthrough
+		default:
+			varbinds = append(varbinds, toolutil.KV{Key: v.Name, Value: v.Type.String() + ": " + formatValue(v)})
+		}
+	}
+
+	sections := []toolutil.MessageSection{
+		{Title: "Peer", Items: []toolutil.KV{{Key: "Remote", Value: addr.String()}}},
+		{Title: "Trap", Items: trap},
+		{Title: fmt.Sprintf("Varbinds (%d)", len(varbinds)), Items: varbinds},
+	}
+	toolutil.PrintColoredMessage("SNMP", sections, body, toolutil.GuessMIME(body))
+}
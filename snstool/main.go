@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "snstool",
+		Short: "AWS SNS client tester",
+		Long:  "A simple AWS SNS CLI: send publishes to a topic, serve is an HTTP(S) subscription endpoint that logs notifications.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// isFIFO reports whether topicARN points to a FIFO topic, whose names end in ".fifo".
+func isFIFO(topicARN string) bool {
+	return strings.HasSuffix(topicARN, ".fifo")
+}
+
+// stringAttributes converts headers to String message attributes.
+func stringAttributes(headers map[string]string) map[string]types.MessageAttributeValue {
+	if len(headers) == 0 {
+		return nil
+	}
+	attrs := make(map[string]types.MessageAttributeValue, len(headers))
+	for k, v := range headers {
+		attrs[k] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+	}
+	return attrs
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		awsOpts        awsutil.Options
+		topicARN       string
+		subject        string
+		groupID        string
+		dedupID        string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Publish periodic messages to an SNS topic",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			fifo := isFIFO(topicARN)
+			if fifo && groupID == "" {
+				return fmt.Errorf("--group-id is required for FIFO topics")
+			}
+			if !fifo && (groupID != "" || dedupID != "") {
+				toolutil.PrintWarning("--group-id and --dedup-id only apply to FIFO topics and are ignored")
+			}
+
+			var client *sns.Client
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					cfg, err := awsutil.LoadConfig(ctx, awsOpts)
+					if err != nil {
+						return err
+					}
+					client = sns.NewFromConfig(cfg)
+					// Fail early on a wrong ARN, credentials or endpoint instead of on the first publish.
+					if _, err := client.GetTopicAttributes(ctx, &sns.GetTopicAttributesInput{TopicArn: aws.String(topicARN)}); err != nil {
+						return fmt.Errorf("failed to get topic %q: %w", topicARN, err)
+					}
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to SNS: %w", err)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			attrs := stringAttributes(headerMap)
+
+			toolutil.PrintSuccess("Connected to SNS")
+			toolutil.PrintKeyValue("Topic ARN", topicARN)
+			toolutil.PrintKeyValue("FIFO", fifo)
+
+			subj := toolutil.NewDestination(subject, openDelim, closeDelim)
+			group := toolutil.NewDestination(groupID, openDelim, closeDelim)
+			dedup := toolutil.NewDestination(dedupID, openDelim, closeDelim)
+			publish := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				input := &sns.PublishInput{
+					TopicArn:          aws.String(topicARN),
+					Message:           aws.String(string(body)),
+					MessageAttributes: attrs,
+				}
+				if subject != "" {
+					s, err := subj.Resolve()
+					if err != nil {
+						toolutil.PrintError("Subject build error: %v", err)
+						return err
+					}
+					input.Subject = aws.String(s)
+				}
+				if fifo {
+					g, err := group.Resolve()
+					if err != nil {
+						toolutil.PrintError("Group id build error: %v", err)
+						return err
+					}
+					input.MessageGroupId = aws.String(g)
+					if dedupID != "" {
+						d, err := dedup.Resolve()
+						if err != nil {
+							toolutil.PrintError("Deduplication id build error: %v", err)
+							return err
+						}
+						input.MessageDeduplicationId = aws.String(d)
+					}
+				}
+
+				pubCtx, pubCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer pubCancel()
+				out, err := client.Publish(pubCtx, input)
+				if err != nil {
+					toolutil.PrintError("Publish error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Published %d bytes (message id %s)", len(body), aws.ToString(out.MessageId))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, publish)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, publish)
+		},
+	}
+
+	awsutil.AddFlags(cmd, &awsOpts)
+	cmd.Flags().StringVar(&topicARN, "topic-arn", "", "ARN of the topic to publish to")
+	cmd.Flags().StringVar(&subject, "subject", "", "Message subject, used by email endpoints (supports template placeholders)")
+	cmd.Flags().StringVar(&groupID, "group-id", "", "Message group id for FIFO topics (supports template placeholders)")
+	cmd.Flags().StringVar(&dedupID, "dedup-id", "", "Message deduplication id for FIFO topics without content-based deduplication (supports template placeholders)")
+	_ = cmd.MarkFlagRequired("topic-arn")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, SNS!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
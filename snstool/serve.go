@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxNotificationSize bounds request bodies; SNS messages are at most 256 KiB plus the JSON envelope.
+const maxNotificationSize = 1 << 20
+
+// envelope is the JSON document SNS posts to HTTP(S) subscriptions without raw message delivery.
+type envelope struct {
+	Type              string
+	MessageID         string `json:"MessageId"`
+	TopicArn          string
+	Subject           string
+	Message           string
+	Timestamp         string
+	SubscribeURL      string
+	MessageAttributes map[string]struct{ Type, Value string }
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr string
+		servePath string
+		noConfirm bool
+		endpoint  string
+		serveOpts toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an HTTP subscription endpoint that confirms subscriptions and logs notifications",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			h := &handler{confirm: !noConfirm, client: &http.Client{Timeout: 10 * time.Second}}
+			if endpoint != "" {
+				u, err := url.Parse(endpoint)
+				if err != nil || u.Host == "" {
+					return fmt.Errorf("invalid --endpoint %q", endpoint)
+				}
+				h.endpointHost = u.Host
+			}
+			mux := http.NewServeMux()
+			mux.Handle(servePath, h)
+			srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("SNS endpoint listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Path", servePath)
+			toolutil.PrintKeyValue("Auto-confirm", !noConfirm)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving SNS endpoint: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:8083", "Listen address")
+	cmd.Flags().StringVar(&servePath, "path", "/", "HTTP path subscribed to the topic")
+	cmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "Log subscription confirmations without visiting their SubscribeURL")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "Custom SNS endpoint, e.g. http://localhost:4566 for LocalStack: SubscribeURLs are only visited on its host (default: https://sns.REGION.amazonaws.com of the topic)")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// handler receives SNS deliveries: subscription confirmations, notifications (enveloped or raw)
+// and unsubscribe confirmations.
+type handler struct {
+	confirm bool
+	// endpointHost is the host (and port) of --endpoint; SubscribeURLs elsewhere are not visited.
+	endpointHost string
+	client       *http.Client
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxNotificationSize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil || env.Type == "" {
+		// Raw message delivery: the body is the message, the metadata travels in headers.
+		sections := []toolutil.MessageSection{
+			{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: r.Header.Get("x-amz-sns-topic-arn")}}},
+			{Title: "Meta", Items: []toolutil.KV{
+				{Key: "ID", Value: r.Header.Get("x-amz-sns-message-id")},
+				{Key: "Raw", Value: "true"},
+			}},
+		}
+		toolutil.PrintColoredMessage("SNS", sections, body, toolutil.GuessMIME(body))
+		return
+	}
+
+	switch env.Type {
+	case "SubscriptionConfirmation":
+		toolutil.PrintInfo("Subscription confirmation for %s", env.TopicArn)
+		if !h.confirm {
+			toolutil.PrintKeyValue("SubscribeURL", env.SubscribeURL)
+			return
+		}
+		if err := h.checkSubscribeURL(env.SubscribeURL, env.TopicArn); err != nil {
+			toolutil.PrintError("Not confirming subscription: %v", err)
+			toolutil.PrintKeyValue("SubscribeURL", env.SubscribeURL)
+			return
+		}
+		if err := h.visit(r.Context(), env.SubscribeURL); err != nil {
+			toolutil.PrintError("Failed to confirm subscription: %v", err)
+			return
+		}
+		toolutil.PrintSuccess("Subscription confirmed for %s", env.TopicArn)
+	case "UnsubscribeConfirmation":
+		toolutil.PrintWarning("Unsubscribed from %s", env.TopicArn)
+	case "Notification":
+		printNotification(env)
+	default:
+		toolutil.PrintWarning("Unknown SNS message type %q", env.Type)
+	}
+}
+
+// checkSubscribeURL makes sure a SubscribeURL points at SNS before it is visited, so anyone able
+// to post to the endpoint cannot make it request arbitrary URLs: the host must be the --endpoint
+// host or, without one, https://sns.REGION.amazonaws.com for the region of the topic ARN.
+func (h *handler) checkSubscribeURL(subscribeURL, topicArn string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil {
+		return fmt.Errorf("invalid SubscribeURL: %w", err)
+	}
+	if h.endpointHost != "" {
+		if u.Host != h.endpointHost {
+			return fmt.Errorf("SubscribeURL host %q is not the --endpoint host %q", u.Host, h.endpointHost)
+		}
+		return nil
+	}
+	// arn:PARTITION:sns:REGION:ACCOUNT:TOPIC
+	parts := strings.Split(topicArn, ":")
+	if len(parts) != 6 || parts[2] != "sns" || parts[3] == "" {
+		return fmt.Errorf("invalid topic ARN %q", topicArn)
+	}
+	want := "sns." + parts[3] + ".amazonaws.com"
+	if parts[1] == "aws-cn" {
+		want += ".cn"
+	}
+	if u.Scheme != "https" || u.Host != want {
+		return fmt.Errorf("SubscribeURL is not on https://%s", want)
+	}
+	return nil
+}
+
+// visit performs the GET on url that confirms a subscription.
+func (h *handler) visit(ctx context.Context, url string) error {
+	if url == "" {
+		return fmt.Errorf("missing SubscribeURL")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func printNotification(env envelope) {
+	meta := []toolutil.KV{{Key: "ID", Value: env.MessageID}}
+	if env.Subject != "" {
+		meta = append(meta, toolutil.KV{Key: "Subject", Value: env.Subject})
+	}
+	if env.Timestamp != "" {
+		meta = append(meta, toolutil.KV{Key: "Timestamp", Value: env.Timestamp})
+	}
+	var attrs []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(env.MessageAttributes)) {
+		attrs = append(attrs, toolutil.KV{Key: k, Value: env.MessageAttributes[k].Value})
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: env.TopicArn}}},
+		{Title: "Meta", Items: meta},
+		toolutil.HeadersSection(attrs),
+	}
+	body := []byte(env.Message)
+	toolutil.PrintColoredMessage("SNS", sections, body, toolutil.GuessMIME(body))
+}
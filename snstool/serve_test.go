@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, h http.Handler, body string) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	return rec.Code
+}
+
+func TestHandlerConfirmsSubscription(t *testing.T) {
+	var visited atomic.Bool
+	confirm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		visited.Store(r.URL.Query().Get("Token") == "abc")
+	}))
+	defer confirm.Close()
+
+	h := &handler{confirm: true, endpointHost: strings.TrimPrefix(confirm.URL, "http://"), client: &http.Client{Timeout: 5 * time.Second}}
+	body := `{"Type":"SubscriptionConfirmation","TopicArn":"arn:aws:sns:us-east-1:000000000000:t","SubscribeURL":"` + confirm.URL + `/?Token=abc"}`
+	if code := post(t, h, body); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if !visited.Load() {
+		t.Error("SubscribeURL was not visited")
+	}
+
+	visited.Store(false)
+	h.endpointHost = "localhost:4566"
+	post(t, h, body)
+	if visited.Load() {
+		t.Error("SubscribeURL visited outside the --endpoint host")
+	}
+
+	h.endpointHost = strings.TrimPrefix(confirm.URL, "http://")
+	h.confirm = false
+	post(t, h, body)
+	if visited.Load() {
+		t.Error("SubscribeURL visited with confirmation disabled")
+	}
+}
+
+func TestCheckSubscribeURL(t *testing.T) {
+	h := &handler{}
+	tests := []struct {
+		url, arn string
+		ok       bool
+	}{
+		{"https://sns.eu-west-1.amazonaws.com/?Action=ConfirmSubscription", "arn:aws:sns:eu-west-1:123456789012:orders", true},
+		{"https://sns.cn-north-1.amazonaws.com.cn/?Action=ConfirmSubscription", "arn:aws-cn:sns:cn-north-1:123456789012:orders", true},
+		{"http://sns.eu-west-1.amazonaws.com/", "arn:aws:sns:eu-west-1:123456789012:orders", false},
+		{"https://sns.us-east-1.amazonaws.com/", "arn:aws:sns:eu-west-1:123456789012:orders", false},
+		{"https://sns.eu-west-1.amazonaws.com.evil.example/", "arn:aws:sns:eu-west-1:123456789012:orders", false},
+		{"http://169.254.169.254/latest/meta-data/", "arn:aws:sns:eu-west-1:123456789012:orders", false},
+		{"https://sns.eu-west-1.amazonaws.com/", "orders", false},
+	}
+	for _, tt := range tests {
+		if err := h.checkSubscribeURL(tt.url, tt.arn); (err == nil) != tt.ok {
+			t.Errorf("checkSubscribeURL(%q, %q) error = %v, want ok %v", tt.url, tt.arn, err, tt.ok)
+		}
+	}
+
+	h.endpointHost = "localhost:4566"
+	if err := h.checkSubscribeURL("http://localhost:4566/?Action=ConfirmSubscription", "arn:aws:sns:us-east-1:000000000000:t"); err != nil {
+		t.Errorf("checkSubscribeURL() on the --endpoint host: %v", err)
+	}
+	if err := h.checkSubscribeURL("http://127.0.0.1:9000/", "arn:aws:sns:us-east-1:000000000000:t"); err == nil {
+		t.Error("checkSubscribeURL() accepted a host other than --endpoint")
+	}
+}
+
+func TestHandlerNotifications(t *testing.T) {
+	h := &handler{client: http.DefaultClient}
+	notification := `{"Type":"Notification","MessageId":"1","TopicArn":"arn:t","Message":"{\"a\":1}",` +
+		`"MessageAttributes":{"source":{"Type":"String","Value":"eventkit"}}}`
+	for name, body := range map[string]string{"enveloped": notification, "raw": `{"a":1}`} {
+		if code := post(t, h, body); code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", name, code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want 405", rec.Code)
+	}
+}
+
+func TestIsFIFO(t *testing.T) {
+	if !isFIFO("arn:aws:sns:us-east-1:123456789012:orders.fifo") {
+		t.Error("isFIFO() = false for a FIFO topic")
+	}
+	if isFIFO("arn:aws:sns:us-east-1:123456789012:orders") {
+		t.Error("isFIFO() = true for a standard topic")
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sandrolain/eventkit/pkg/framing"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "sockettool",
+		Short: "Raw TCP/UDP socket tester",
+		Long:  "A simple TCP/UDP CLI that sends framed templated payloads and runs a listener printing received frames.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// validateNetwork checks the --network flag.
+func validateNetwork(network string) error {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		return nil
+	}
+	return fmt.Errorf("invalid --network %q: expected tcp or udp (optionally suffixed with 4 or 6)", network)
+}
+
+// isUDP reports whether network is datagram based.
+func isUDP(network string) bool {
+	return network == "udp" || network == "udp4" || network == "udp6"
+}
+
+// printFrame prints a received frame with the peer it came from.
+func printFrame(network, remote string, framer *framing.Framer, index int, frame []byte) {
+	sections := []toolutil.MessageSection{
+		{Title: "Peer", Items: []toolutil.KV{
+			{Key: "Network", Value: network},
+			{Key: "Remote", Value: remote},
+		}},
+		{Title: "Frame", Items: []toolutil.KV{
+			{Key: "Framing", Value: framer.String()},
+			{Key: "Index", Value: strconv.Itoa(index)},
+			{Key: "Size", Value: strconv.Itoa(len(frame))},
+		}},
+	}
+	toolutil.PrintColoredMessage("Socket", sections, frame, toolutil.GuessMIME(frame))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/framing"
+)
+
+func TestValidateNetwork(t *testing.T) {
+	for _, n := range []string{"tcp", "tcp6", "udp", "udp4"} {
+		if err := validateNetwork(n); err != nil {
+			t.Errorf("validateNetwork(%q) error: %v", n, err)
+		}
+	}
+	for _, n := range []string{"unix", "ip", ""} {
+		if err := validateNetwork(n); err == nil {
+			t.Errorf("validateNetwork(%q) succeeded, want error", n)
+		}
+	}
+}
+
+func TestServeTCPStopsOnCancel(t *testing.T) {
+	framer, err := framing.New(framing.Options{Mode: framing.ModeNewline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveTCP(ctx, ln, "tcp", framer) }()
+
+	// An idle client connection must not keep the server from stopping.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() //nolint:errcheck
+	if _, err := conn.Write([]byte("one\ntwo\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveTCP() error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveTCP() did not return after cancel")
+	}
+}
+
+func TestServeUDPStopsOnCancel(t *testing.T) {
+	framer, err := framing.New(framing.Options{Mode: framing.ModeNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveUDP(ctx, pc, "udp", framer) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveUDP() error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveUDP() did not return after cancel")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sandrolain/eventkit/pkg/framing"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxDatagramSize is the largest UDP payload.
+const maxDatagramSize = 65535
+
+func serveCommand() *cobra.Command {
+	var (
+		network     string
+		address     string
+		framingOpts framing.Options
+		serveOpts   toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Listen on a TCP or UDP socket and log received frames",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateNetwork(network); err != nil {
+				return err
+			}
+			framer, err := framing.New(framingOpts)
+			if err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var lc net.ListenConfig
+			if isUDP(network) {
+				pc, err := lc.ListenPacket(ctx, network, address)
+				if err != nil {
+					return fmt.Errorf("error listening on %s: %w", address, err)
+				}
+				printListening(network, pc.LocalAddr(), framer)
+				return serveUDP(ctx, pc, network, framer)
+			}
+			ln, err := lc.Listen(ctx, network, address)
+			if err != nil {
+				return fmt.Errorf("error listening on %s: %w", address, err)
+			}
+			printListening(network, ln.Addr(), framer)
+			return serveTCP(ctx, ln, network, framer)
+		},
+	}
+
+	cmd.Flags().StringVar(&network, "network", "tcp", "Network: tcp or udp (optionally suffixed with 4 or 6)")
+	cmd.Flags().StringVar(&address, "address", "0.0.0.0:9000", "Listen address")
+	framing.AddFlags(cmd, &framingOpts)
+	cmd.Flags().IntVar(&framingOpts.MaxFrameSize, "max-frame-size", framing.DefaultMaxFrameSize, "Maximum size in bytes of a received frame")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+func printListening(network string, addr net.Addr, framer *framing.Framer) {
+	toolutil.PrintSuccess("Listening on %s socket", strings.ToUpper(network))
+	toolutil.PrintKeyValue("Address", addr)
+	toolutil.PrintKeyValue("Framing", framer)
+}
+
+// serveTCP accepts connections until ctx is done, printing the frames of each connection.
+func serveTCP(ctx context.Context, ln net.Listener, network string, framer *framing.Framer) error {
+	var (
+		mu    sync.Mutex
+		conns = map[net.Conn]struct{}{}
+		wg    sync.WaitGroup
+	)
+	go func() {
+		<-ctx.Done()
+		ln.Close() //nolint:errcheck
+		mu.Lock()
+		for c := range conns {
+			c.Close() //nolint:errcheck
+		}
+		mu.Unlock()
+	}()
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return fmt.Errorf("error accepting connection: %w", err)
+		}
+		mu.Lock()
+		if ctx.Err() != nil {
+			mu.Unlock()
+			conn.Close() //nolint:errcheck
+			continue
+		}
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close() //nolint:errcheck
+			}()
+			remote := conn.RemoteAddr().String()
+			toolutil.PrintInfo("Connection from %s", remote)
+			s := framer.NewScanner(conn)
+			index := 0
+			for s.Scan() {
+				index++
+				printFrame(network, remote, framer, index, s.Bytes())
+			}
+			if err := s.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+				toolutil.PrintWarning("Connection from %s ended: %v", remote, err)
+				return
+			}
+			toolutil.PrintInfo("Connection from %s closed after %d frame(s)", remote, index)
+		}()
+	}
+}
+
+// serveUDP reads datagrams until ctx is done; each datagram holds one or more frames.
+func serveUDP(ctx context.Context, pc net.PacketConn, network string, framer *framing.Framer) error {
+	go func() {
+		<-ctx.Done()
+		pc.Close() //nolint:errcheck
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return fmt.Errorf("error reading datagram: %w", err)
+		}
+		s := framer.NewScanner(bytes.NewReader(buf[:n]))
+		index := 0
+		for s.Scan() {
+			index++
+			printFrame(network, addr.String(), framer, index, s.Bytes())
+		}
+		if err := s.Err(); err != nil {
+			toolutil.PrintWarning("Malformed datagram from %s: %v", addr, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"solace.dev/go/messaging/pkg/solace/config"
+	"solace.dev/go/messaging/pkg/solace/message/sdt"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "solacetool",
+		Short: "Solace PubSub+ messaging tester",
+		Long:  "A simple Solace PubSub+ CLI that publishes templated messages to topics or queues with direct or guaranteed delivery and consumes from topic subscriptions or queues.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// Delivery modes: direct messages are fire and forget, persistent (guaranteed) messages are
+// spooled by the broker and acknowledged.
+const (
+	deliveryDirect     = "direct"
+	deliveryPersistent = "persistent"
+)
+
+// solaceOptions configure the broker connection shared by both commands.
+type solaceOptions struct {
+	Host     string
+	VPN      string
+	Username string
+	Password string
+	Insecure bool
+}
+
+func addSolaceFlags(cmd *cobra.Command, opts *solaceOptions) {
+	cmd.Flags().StringVar(&opts.Host, "host", "tcp://localhost:55555", "Broker host, tcp:// or tcps:// (comma separated for a host list)")
+	cmd.Flags().StringVar(&opts.VPN, "vpn", "default", "Message VPN")
+	cmd.Flags().StringVar(&opts.Username, "username", "default", "Client username")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "Client password")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "Skip TLS certificate validation for tcps:// hosts")
+}
+
+// properties converts the flags to messaging service properties.
+func (o solaceOptions) properties() (config.ServicePropertyMap, error) {
+	if strings.TrimSpace(o.Host) == "" {
+		return nil, fmt.Errorf("--host is required")
+	}
+	props := config.ServicePropertyMap{
+		config.TransportLayerPropertyHost:                o.Host,
+		config.ServicePropertyVPNName:                    o.VPN,
+		config.AuthenticationPropertySchemeBasicUserName: o.Username,
+		config.AuthenticationPropertySchemeBasicPassword: o.Password,
+	}
+	if o.Insecure {
+		props[config.TransportLayerSecurityPropertyCertValidated] = false
+	}
+	return props, nil
+}
+
+// parseDelivery validates a --delivery value.
+func parseDelivery(s string) (string, error) {
+	switch s {
+	case deliveryDirect, deliveryPersistent:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid --delivery %q: expected %s or %s", s, deliveryDirect, deliveryPersistent)
+}
+
+// queueTopic is the topic the broker routes to a queue: publishing to it delivers to that queue only.
+func queueTopic(queue string) string {
+	return "#P2P/QUE/" + queue
+}
+
+// propertyItems converts user properties to header items sorted by key; byte array values are
+// shown as text.
+func propertyItems(props sdt.Map) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(props)) {
+		value := fmt.Sprint(props[k])
+		if b, ok := props[k].([]byte); ok {
+			value = string(b)
+		}
+		items = append(items, toolutil.KV{Key: k, Value: value})
+	}
+	return items
+}
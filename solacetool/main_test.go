@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"solace.dev/go/messaging/pkg/solace/config"
+	"solace.dev/go/messaging/pkg/solace/message/sdt"
+)
+
+func TestProperties(t *testing.T) {
+	opts := solaceOptions{Host: "tcps://broker:55443", VPN: "vpn", Username: "user", Password: "secret"}
+	props, err := opts.properties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props[config.TransportLayerPropertyHost] != "tcps://broker:55443" || props[config.ServicePropertyVPNName] != "vpn" {
+		t.Errorf("unexpected properties: %v", props)
+	}
+	if props[config.AuthenticationPropertySchemeBasicUserName] != "user" || props[config.AuthenticationPropertySchemeBasicPassword] != "secret" {
+		t.Errorf("unexpected credentials: %v", props)
+	}
+	if _, ok := props[config.TransportLayerSecurityPropertyCertValidated]; ok {
+		t.Error("certificate validation should keep its default")
+	}
+
+	opts.Insecure = true
+	if props, _ = opts.properties(); props[config.TransportLayerSecurityPropertyCertValidated] != false {
+		t.Error("--insecure should disable certificate validation")
+	}
+
+	if _, err := (solaceOptions{Host: " "}).properties(); err == nil {
+		t.Error("expected an error for an empty host")
+	}
+}
+
+func TestParseDelivery(t *testing.T) {
+	for _, d := range []string{deliveryDirect, deliveryPersistent} {
+		if got, err := parseDelivery(d); err != nil || got != d {
+			t.Errorf("parseDelivery(%q) = %q, %v", d, got, err)
+		}
+	}
+	if _, err := parseDelivery("guaranteed"); err == nil {
+		t.Error("expected an error for an unknown delivery mode")
+	}
+}
+
+func TestQueueTopic(t *testing.T) {
+	if got := queueTopic("orders"); got != "#P2P/QUE/orders" {
+		t.Errorf("queueTopic = %q", got)
+	}
+}
+
+func TestPropertyItems(t *testing.T) {
+	items := propertyItems(sdt.Map{"b": int32(2), "a": "x", "c": []byte("raw")})
+	want := [][2]string{{"a", "x"}, {"b", "2"}, {"c", "raw"}}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		if items[i].Key != w[0] || items[i].Value != w[1] {
+			t.Errorf("item %d = %s=%s, want %s=%s", i, items[i].Key, items[i].Value, w[0], w[1])
+		}
+	}
+	if items := propertyItems(nil); len(items) != 0 {
+		t.Errorf("expected no items, got %v", items)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !cgo
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// The Solace Go API wraps the native C client, so without cgo the commands only report it.
+
+func errNoCgo() error {
+	return fmt.Errorf("solacetool requires cgo: rebuild with CGO_ENABLED=1")
+}
+
+func sendCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "send",
+		Short: "Publish periodic messages to a Solace topic or queue (requires cgo)",
+		RunE:  func(cmd *cobra.Command, args []string) error { return errNoCgo() },
+	}
+}
+
+func serveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Consume and log messages from Solace topics or a queue (requires cgo)",
+		RunE:  func(cmd *cobra.Command, args []string) error { return errNoCgo() },
+	}
+}
@@ -0,0 +1,278 @@
+//go:build cgo
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"solace.dev/go/messaging/pkg/solace"
+	"solace.dev/go/messaging/pkg/solace/config"
+	"solace.dev/go/messaging/pkg/solace/message"
+	"solace.dev/go/messaging/pkg/solace/resource"
+)
+
+// terminateGrace bounds the wait for in-flight messages when publishers and receivers stop.
+const terminateGrace = 5 * time.Second
+
+func sendCommand() *cobra.Command {
+	var (
+		opts           solaceOptions
+		topic          string
+		queue          string
+		delivery       string
+		messageID      string
+		ackTimeout     time.Duration
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Publish periodic messages to a Solace topic or queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if queue != "" && cmd.Flags().Changed("topic") {
+				return fmt.Errorf("--topic and --queue are mutually exclusive")
+			}
+			if queue != "" && !cmd.Flags().Changed("delivery") {
+				delivery = deliveryPersistent
+			}
+			if _, err := parseDelivery(delivery); err != nil {
+				return err
+			}
+			props, err := opts.properties()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+
+			var (
+				svc       solace.MessagingService
+				publish   func(msg message.OutboundMessage, dest *resource.Topic) error
+				terminate func(time.Duration) error
+			)
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					var err error
+					if svc, err = connectService(ctx, props); err != nil {
+						return err
+					}
+					if delivery == deliveryPersistent {
+						p, err := svc.CreatePersistentMessagePublisherBuilder().Build()
+						if err == nil {
+							err = p.Start()
+						}
+						if err != nil {
+							svc.Disconnect() //nolint:errcheck
+							return fmt.Errorf("failed to start persistent publisher: %w", err)
+						}
+						// Guaranteed messages wait for the broker acknowledgement; a rejection is returned.
+						publish = func(msg message.OutboundMessage, dest *resource.Topic) error {
+							return p.PublishAwaitAcknowledgement(msg, dest, ackTimeout, nil)
+						}
+						terminate = p.Terminate
+						return nil
+					}
+					p, err := svc.CreateDirectMessagePublisherBuilder().Build()
+					if err == nil {
+						err = p.Start()
+					}
+					if err != nil {
+						svc.Disconnect() //nolint:errcheck
+						return fmt.Errorf("failed to start direct publisher: %w", err)
+					}
+					publish, terminate = p.Publish, p.Terminate
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to Solace: %w", err)
+			}
+			defer func() {
+				terminate(terminateGrace) //nolint:errcheck
+				svc.Disconnect()          //nolint:errcheck
+			}()
+
+			toolutil.PrintSuccess("Connected to Solace PubSub+")
+			toolutil.PrintKeyValue("Host", opts.Host)
+			toolutil.PrintKeyValue("VPN", opts.VPN)
+			if queue != "" {
+				toolutil.PrintKeyValue("Queue", queue)
+			} else {
+				toolutil.PrintKeyValue("Topic", topic)
+			}
+			toolutil.PrintKeyValue("Delivery", delivery)
+
+			dest := toolutil.NewDestination(topic, openDelim, closeDelim)
+			msgID := toolutil.NewDestination(messageID, openDelim, closeDelim)
+
+			send := func() error {
+				name := queue
+				target := queueTopic(queue)
+				if queue == "" {
+					var err error
+					if target, err = dest.Resolve(); err != nil {
+						toolutil.PrintError("Topic build error: %v", err)
+						return err
+					}
+					name = target
+				}
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				builder := svc.MessageBuilder().WithHTTPContentHeader(ct, "")
+				for k, v := range headerMap {
+					builder = builder.WithProperty(config.MessageProperty(k), v)
+				}
+				if messageID != "" {
+					id, err := msgID.Resolve()
+					if err != nil {
+						toolutil.PrintError("Message id build error: %v", err)
+						return err
+					}
+					builder = builder.WithApplicationMessageID(id)
+				}
+				msg, err := builder.BuildWithByteArrayPayload(body)
+				if err != nil {
+					toolutil.PrintError("Message build error: %v", err)
+					return err
+				}
+				if err := publish(msg, resource.TopicOf(target)); err != nil {
+					toolutil.PrintError("Publish error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Published %d bytes to '%s'", len(body), name)
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addSolaceFlags(cmd, &opts)
+	cmd.Flags().StringVar(&topic, "topic", "eventkit/test", "Topic to publish to, supports template placeholders")
+	cmd.Flags().StringVar(&queue, "queue", "", "Publish straight to this queue instead of a topic (guaranteed delivery unless --delivery is set)")
+	cmd.Flags().StringVar(&delivery, "delivery", deliveryDirect, "Delivery mode: direct (at-most-once) or persistent (guaranteed, acknowledged by the broker)")
+	cmd.Flags().StringVar(&messageID, "message-id", "", "Application message id, supports template placeholders")
+	cmd.Flags().DurationVar(&ackTimeout, "ack-timeout", 10*time.Second, "Wait for the broker acknowledgement of persistent messages")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, Solace!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,241 @@
+//go:build cgo
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"solace.dev/go/messaging/pkg/solace"
+	"solace.dev/go/messaging/pkg/solace/config"
+	"solace.dev/go/messaging/pkg/solace/message"
+	"solace.dev/go/messaging/pkg/solace/resource"
+)
+
+// receiver is the part shared by direct and persistent receivers.
+type receiver interface {
+	Start() error
+	ReceiveAsync(callback solace.MessageHandler) error
+	Terminate(gracePeriod time.Duration) error
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           solaceOptions
+		topics         []string
+		queue          string
+		delivery       string
+		selector       string
+		createQueue    bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Consume and log messages from Solace topic subscriptions or a queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if queue != "" && !cmd.Flags().Changed("delivery") {
+				delivery = deliveryPersistent
+			}
+			if _, err := parseDelivery(delivery); err != nil {
+				return err
+			}
+			if delivery == deliveryDirect && (queue != "" || selector != "" || createQueue) {
+				return fmt.Errorf("--queue, --selector and --create-queue require --delivery %s", deliveryPersistent)
+			}
+			if createQueue && queue == "" {
+				return fmt.Errorf("--create-queue requires --queue")
+			}
+			if queue != "" && cmd.Flags().Changed("topic") && !createQueue {
+				return fmt.Errorf("--topic subscriptions on a queue require --create-queue")
+			}
+			if queue != "" && !cmd.Flags().Changed("topic") {
+				topics = nil // bind to the queue as configured
+			}
+			if queue == "" && len(topics) == 0 {
+				return fmt.Errorf("at least one --topic is required without --queue")
+			}
+			props, err := opts.properties()
+			if err != nil {
+				return err
+			}
+			subs := make([]resource.Subscription, len(topics))
+			for i, t := range topics {
+				subs[i] = resource.TopicSubscriptionOf(t)
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				svc  solace.MessagingService
+				recv receiver
+				ack  func(message.InboundMessage) error
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				var err error
+				if svc, err = connectService(ctx, props); err != nil {
+					return err
+				}
+				if delivery == deliveryDirect {
+					recv, err = svc.CreateDirectMessageReceiverBuilder().WithSubscriptions(subs...).Build()
+				} else {
+					builder := svc.CreatePersistentMessageReceiverBuilder().WithMessageClientAcknowledgement().WithSubscriptions(subs...)
+					if selector != "" {
+						builder = builder.WithMessageSelector(selector)
+					}
+					// Without a queue the topics are attracted to a temporary queue removed on disconnect.
+					q := resource.QueueNonDurableExclusiveAnonymous()
+					if queue != "" {
+						q = resource.QueueDurableExclusive(queue)
+					}
+					if createQueue {
+						builder = builder.WithMissingResourcesCreationStrategy(config.PersistentReceiverCreateOnStartMissingResources)
+					}
+					var p solace.PersistentMessageReceiver
+					if p, err = builder.Build(q); err == nil {
+						recv, ack = p, p.Ack
+					}
+				}
+				if err == nil {
+					err = recv.Start()
+				}
+				if err != nil {
+					svc.Disconnect() //nolint:errcheck
+					return fmt.Errorf("failed to start receiver: %w", err)
+				}
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to Solace: %w", err)
+			}
+			defer svc.Disconnect() //nolint:errcheck
+
+			interrupted := make(chan error, 1)
+			svc.AddServiceInterruptionListener(func(e solace.ServiceEvent) {
+				select {
+				case interrupted <- e.GetCause():
+				default:
+				}
+			})
+			messages := make(chan message.InboundMessage)
+			done := make(chan struct{})
+			if err := recv.ReceiveAsync(func(msg message.InboundMessage) {
+				select {
+				case messages <- msg:
+				case <-done:
+				}
+			}); err != nil {
+				recv.Terminate(0) //nolint:errcheck
+				return fmt.Errorf("failed to receive: %w", err)
+			}
+			defer recv.Terminate(terminateGrace) //nolint:errcheck
+			defer close(done)                    // unblock pending callbacks before terminating
+
+			toolutil.PrintSuccess("Consuming from Solace PubSub+")
+			toolutil.PrintKeyValue("Host", opts.Host)
+			toolutil.PrintKeyValue("VPN", opts.VPN)
+			if queue != "" {
+				toolutil.PrintKeyValue("Queue", queue)
+			}
+			for _, t := range topics {
+				toolutil.PrintKeyValue("Subscription", t)
+			}
+			if selector != "" {
+				toolutil.PrintKeyValue("Selector", selector)
+			}
+			toolutil.PrintKeyValue("Delivery", delivery)
+
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case err := <-interrupted:
+					return fmt.Errorf("connection lost: %w", err)
+				case msg := <-messages:
+					// Messages dropped after --max-messages stay unacknowledged on the queue
+					if !printMessage(queue, msg) || ack == nil {
+						continue
+					}
+					if err := ack(msg); err != nil {
+						toolutil.PrintError("Failed to acknowledge message: %v", err)
+					}
+				}
+			}
+		},
+	}
+
+	addSolaceFlags(cmd, &opts)
+	cmd.Flags().StringArrayVar(&topics, "topic", []string{"eventkit/>"}, "Topic subscription, * and > wildcards allowed (repeatable)")
+	cmd.Flags().StringVar(&queue, "queue", "", "Durable queue to consume from (guaranteed delivery)")
+	cmd.Flags().StringVar(&delivery, "delivery", deliveryDirect, "Delivery mode: direct (topic subscriptions only) or persistent (a queue, temporary when --queue is unset)")
+	cmd.Flags().StringVar(&selector, "selector", "", "SQL-92 message selector on the user properties (persistent delivery)")
+	cmd.Flags().BoolVar(&createQueue, "create-queue", false, "Create the queue when missing and add the --topic subscriptions to it")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// messageItems lists the header fields set on msg.
+func messageItems(msg message.InboundMessage) []toolutil.KV {
+	var items []toolutil.KV
+	add := func(key, value string, ok bool) {
+		if ok && value != "" {
+			items = append(items, toolutil.KV{Key: key, Value: value})
+		}
+	}
+	id, ok := msg.GetApplicationMessageID()
+	add("Message ID", id, ok)
+	typ, ok := msg.GetApplicationMessageType()
+	add("Type", typ, ok)
+	corr, ok := msg.GetCorrelationID()
+	add("Correlation ID", corr, ok)
+	sender, ok := msg.GetSenderID()
+	add("Sender", sender, ok)
+	if ts, ok := msg.GetSenderTimestamp(); ok {
+		add("Sent", ts.Format(time.RFC3339Nano), true)
+	}
+	if prio, ok := msg.GetPriority(); ok {
+		add("Priority", strconv.Itoa(prio), true)
+	}
+	if msg.IsRedelivered() {
+		add("Redelivered", "true", true)
+	}
+	return items
+}
+
+// printMessage prints msg and reports whether it was printed, see toolutil.PrintColoredMessage.
+func printMessage(queue string, msg message.InboundMessage) bool {
+	body, ok := msg.GetPayloadAsBytes()
+	if !ok {
+		s, _ := msg.GetPayloadAsString()
+		body = []byte(s)
+	}
+	dest := []toolutil.KV{{Key: "Topic", Value: msg.GetDestinationName()}}
+	if queue != "" {
+		dest = append(dest, toolutil.KV{Key: "Queue", Value: queue})
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Destination", Items: dest},
+		{Title: "Message", Items: messageItems(msg)},
+		toolutil.HeadersSection(propertyItems(msg.GetProperties())),
+	}
+	ct, ok := msg.GetHTTPContentType()
+	if !ok || ct == "" {
+		ct = toolutil.GuessMIME(body)
+	}
+	return toolutil.PrintColoredMessage("Solace", sections, body, ct)
+}
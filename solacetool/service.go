@@ -0,0 +1,29 @@
+//go:build cgo
+
+package main
+
+import (
+	"context"
+
+	"solace.dev/go/messaging"
+	"solace.dev/go/messaging/pkg/solace"
+	"solace.dev/go/messaging/pkg/solace/config"
+)
+
+// connectService builds a messaging service from props and connects it, giving up when ctx ends.
+func connectService(ctx context.Context, props config.ServicePropertyMap) (solace.MessagingService, error) {
+	svc, err := messaging.NewMessagingServiceBuilder().FromConfigurationProvider(props).Build()
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case err := <-svc.ConnectAsync():
+		if err != nil {
+			return nil, err
+		}
+		return svc, nil
+	case <-ctx.Done():
+		go svc.Disconnect() //nolint:errcheck
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "sqstool",
+		Short: "AWS SQS client tester",
+		Long:  "A simple AWS SQS CLI with send and serve commands for standard and FIFO queues (LocalStack supported via --endpoint).",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// resolveQueueURL returns queue as-is when it is already a URL, otherwise looks it up by name.
+func resolveQueueURL(ctx context.Context, client *sqs.Client, queue string) (string, error) {
+	if strings.HasPrefix(queue, "http://") || strings.HasPrefix(queue, "https://") {
+		return queue, nil
+	}
+	out, err := client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String(queue)})
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve queue %q: %w", queue, err)
+	}
+	return aws.ToString(out.QueueUrl), nil
+}
+
+// queueName extracts the queue name, the last path segment, from a queue URL.
+func queueName(queueURL string) string {
+	return queueURL[strings.LastIndex(queueURL, "/")+1:]
+}
+
+// isFIFO reports whether queueURL points to a FIFO queue, whose names end in ".fifo".
+func isFIFO(queueURL string) bool {
+	return strings.HasSuffix(queueURL, ".fifo")
+}
+
+// stringAttributes converts headers to String message attributes.
+func stringAttributes(headers map[string]string) map[string]types.MessageAttributeValue {
+	if len(headers) == 0 {
+		return nil
+	}
+	attrs := make(map[string]types.MessageAttributeValue, len(headers))
+	for k, v := range headers {
+		attrs[k] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+	}
+	return attrs
+}
+
+// attributeItems converts message attributes to sorted key/value items; binary values are shown base64-encoded.
+func attributeItems(attrs map[string]types.MessageAttributeValue) []toolutil.KV {
+	var items []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(attrs)) {
+		v := attrs[k]
+		value := aws.ToString(v.StringValue)
+		if v.BinaryValue != nil {
+			value = toolutil.HeaderBase64Prefix + base64.StdEncoding.EncodeToString(v.BinaryValue)
+		}
+		items = append(items, toolutil.KV{Key: k, Value: value})
+	}
+	return items
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestQueueURLHelpers(t *testing.T) {
+	url := "http://localhost:4566/000000000000/orders.fifo"
+	if got := queueName(url); got != "orders.fifo" {
+		t.Errorf("queueName() = %q, want orders.fifo", got)
+	}
+	if !isFIFO(url) {
+		t.Errorf("isFIFO(%q) = false, want true", url)
+	}
+	if isFIFO("https://sqs.us-east-1.amazonaws.com/123456789012/orders") {
+		t.Error("isFIFO() = true for a standard queue")
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	if attrs := stringAttributes(nil); attrs != nil {
+		t.Errorf("stringAttributes(nil) = %v, want nil", attrs)
+	}
+	attrs := stringAttributes(map[string]string{"b": "2", "a": "1"})
+	attrs["c"] = types.MessageAttributeValue{DataType: aws.String("Binary"), BinaryValue: []byte{0xff}}
+
+	got := attributeItems(attrs)
+	want := []toolutil.KV{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}, {Key: "c", Value: "base64:/w=="}}
+	if len(got) != len(want) {
+		t.Fatalf("attributeItems() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("attributeItems()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+type fakeDeleter struct {
+	deleted []string
+}
+
+func (f *fakeDeleter) DeleteMessage(_ context.Context, params *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	f.deleted = append(f.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestHandleMessages_MaxMessages(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{MaxMessages: 1, AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	var msgs []types.Message
+	for _, id := range []string{"r1", "r2", "r3"} {
+		msgs = append(msgs, types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String(id), Body: aws.String("{}")})
+	}
+	client := &fakeDeleter{}
+	handleMessages(client, "http://localhost:4566/000000000000/orders", "orders", msgs, false)
+	if len(client.deleted) != 1 || client.deleted[0] != "r1" {
+		t.Errorf("deleted %v, want only the printed message r1", client.deleted)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/sandrolain/eventkit/pkg/awsutil"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		awsOpts           awsutil.Options
+		subQueue          string
+		waitTime          time.Duration
+		batchSize         int32
+		visibilityTimeout time.Duration
+		noDelete          bool
+		connectTimeout    time.Duration
+		serveOpts         toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Long-poll an SQS queue, log received messages and delete them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if waitTime < 0 || waitTime > 20*time.Second {
+				return fmt.Errorf("--wait-time must be between 0s and 20s")
+			}
+			if batchSize < 1 || batchSize > 10 {
+				return fmt.Errorf("--batch-size must be between 1 and 10")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var (
+				client   *sqs.Client
+				queueURL string
+			)
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				cfg, err := awsutil.LoadConfig(ctx, awsOpts)
+				if err != nil {
+					return err
+				}
+				client = sqs.NewFromConfig(cfg)
+				queueURL, err = resolveQueueURL(ctx, client, subQueue)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error connecting to SQS: %w", err)
+			}
+			name := queueName(queueURL)
+
+			toolutil.PrintSuccess("Polling SQS queue")
+			toolutil.PrintKeyValue("Queue URL", queueURL)
+			toolutil.PrintKeyValue("Delete", !noDelete)
+
+			input := &sqs.ReceiveMessageInput{
+				QueueUrl:                    aws.String(queueURL),
+				MaxNumberOfMessages:         batchSize,
+				WaitTimeSeconds:             int32(waitTime / time.Second),
+				MessageAttributeNames:       []string{"All"},
+				MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+			}
+			if visibilityTimeout > 0 {
+				input.VisibilityTimeout = int32(visibilityTimeout / time.Second)
+			}
+
+			for {
+				out, err := client.ReceiveMessage(ctx, input)
+				if err != nil {
+					if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					toolutil.PrintError("Receive error: %v", err)
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-time.After(time.Second):
+					}
+					continue
+				}
+				handleMessages(client, queueURL, name, out.Messages, noDelete)
+			}
+		},
+	}
+
+	awsutil.AddFlags(cmd, &awsOpts)
+	cmd.Flags().StringVar(&subQueue, "queue", "test-queue", "Queue name or URL")
+	cmd.Flags().DurationVar(&waitTime, "wait-time", 20*time.Second, "Long-poll wait time per receive call (0s-20s)")
+	cmd.Flags().Int32Var(&batchSize, "batch-size", 10, "Maximum messages per receive call (1-10)")
+	cmd.Flags().DurationVar(&visibilityTimeout, "visibility-timeout", 0, "Visibility timeout of received messages (default: the queue setting)")
+	cmd.Flags().BoolVar(&noDelete, "no-delete", false, "Leave messages on the queue after printing them (they become visible again after the visibility timeout)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// deleteMessageAPI is the part of the SQS client used to delete printed messages.
+type deleteMessageAPI interface {
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+// handleMessages prints a received batch, deleting each printed message unless noDelete is set.
+// Messages not printed because --max-messages was reached are left on the queue, so they become
+// visible again after the visibility timeout.
+func handleMessages(client deleteMessageAPI, queueURL, queue string, msgs []types.Message, noDelete bool) {
+	for _, m := range msgs {
+		if !printMessage(queue, m) || noDelete {
+			continue
+		}
+		// Delete with a fresh context so shutdown does not leave printed messages on the queue.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(queueURL),
+			ReceiptHandle: m.ReceiptHandle,
+		})
+		cancel()
+		if err != nil {
+			toolutil.PrintError("Delete error for message %s: %v", aws.ToString(m.MessageId), err)
+		}
+	}
+}
+
+// printMessage prints m, reporting whether it was printed (see toolutil.PrintColoredMessage).
+func printMessage(queue string, m types.Message) bool {
+	meta := []toolutil.KV{{Key: "ID", Value: aws.ToString(m.MessageId)}}
+	for _, a := range []struct {
+		key  string
+		attr types.MessageSystemAttributeName
+	}{
+		{"Group-Id", types.MessageSystemAttributeNameMessageGroupId},
+		{"Dedup-Id", types.MessageSystemAttributeNameMessageDeduplicationId},
+		{"Sequence", types.MessageSystemAttributeNameSequenceNumber},
+		{"Receive-Count", types.MessageSystemAttributeNameApproximateReceiveCount},
+	} {
+		if v, ok := m.Attributes[string(a.attr)]; ok {
+			meta = append(meta, toolutil.KV{Key: a.key, Value: v})
+		}
+	}
+	if v, ok := m.Attributes[string(types.MessageSystemAttributeNameSentTimestamp)]; ok {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			meta = append(meta, toolutil.KV{Key: "Sent", Value: time.UnixMilli(ms).Format(time.RFC3339)})
+		}
+	}
+
+	sections := []toolutil.MessageSection{
+		{Title: "Queue", Items: []toolutil.KV{{Key: "Name", Value: queue}}},
+		{Title: "Meta", Items: meta},
+		toolutil.HeadersSection(attributeItems(m.MessageAttributes)),
+	}
+	body := []byte(aws.ToString(m.Body))
+	return toolutil.PrintColoredMessage("SQS", sections, body, toolutil.GuessMIME(body))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "ssetool",
+		Short: "Server-Sent Events tester",
+		Long:  "A simple Server-Sent Events CLI: serve emits templated events, subscribe renders events from a stream.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(serveCommand(), subscribeCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
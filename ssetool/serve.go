@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr      string
+		servePath      string
+		eventName      string
+		retry          time.Duration
+		payload        string
+		mime           string
+		interval       string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Expose an SSE endpoint emitting templated events at an interval",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &payload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&interval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+
+			b := newBroadcaster()
+			mux := http.NewServeMux()
+			mux.HandleFunc(servePath, b.handle)
+			srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("SSE server listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Path", servePath)
+			toolutil.PrintKeyValue("Interval", interval)
+
+			name := toolutil.NewDestination(eventName, openDelim, closeDelim)
+			var seq int64
+			emit := func() error {
+				if b.clients() == 0 {
+					return nil
+				}
+				body, _, err := toolutil.BuildPayloadWithDelimiters(payload, mime, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				evName, err := name.Resolve()
+				if err != nil {
+					toolutil.PrintError("Event name build error: %v", err)
+					return err
+				}
+				seq++
+				var buf bytes.Buffer
+				if err := writeEvent(&buf, event{Name: evName, ID: strconv.FormatInt(seq, 10), Retry: retry, Data: string(body)}); err != nil {
+					return err
+				}
+				n := b.broadcast(buf.Bytes())
+				toolutil.PrintInfo("Emitted event %d (%d bytes) to %d subscribers", seq, len(body), n)
+				return nil
+			}
+
+			go func() {
+				if err := common.StartPeriodicTask(ctx, interval, emit); err != nil {
+					errChan <- err
+				}
+			}()
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				b.close()
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving SSE: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:8082", "Listen address")
+	cmd.Flags().StringVar(&servePath, "path", "/events", "HTTP path of the event stream")
+	cmd.Flags().StringVar(&eventName, "event", "", "Event name (supports template placeholders; empty sends unnamed 'message' events)")
+	cmd.Flags().DurationVar(&retry, "retry", 0, "Reconnection time advertised to clients via the retry field (0 omits it)")
+	toolutil.AddPayloadFlags(cmd, &payload, "{{json}}", &mime, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &interval, "1s")
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
+
+// broadcaster fans encoded events out to every connected client.
+type broadcaster struct {
+	mu     sync.Mutex
+	subs   map[chan []byte]struct{}
+	closed chan struct{}
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{subs: map[chan []byte]struct{}{}, closed: make(chan struct{})}
+}
+
+func (b *broadcaster) clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// broadcast queues msg for every client, dropping it for clients that are too slow to keep up.
+func (b *broadcaster) broadcast(msg []byte) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- msg:
+		default:
+			toolutil.PrintWarning("Subscriber too slow, event dropped")
+		}
+	}
+	return len(b.subs)
+}
+
+func (b *broadcaster) close() {
+	close(b.closed)
+}
+
+func (b *broadcaster) handle(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := make(chan []byte, 16)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}()
+
+	toolutil.PrintInfo("Subscriber connected: %s (Last-Event-ID %q)", r.RemoteAddr, r.Header.Get("Last-Event-ID"))
+	for {
+		select {
+		case <-r.Context().Done():
+			toolutil.PrintInfo("Subscriber disconnected: %s", r.RemoteAddr)
+			return
+		case <-b.closed:
+			return
+		case msg := <-ch:
+			if _, err := w.Write(msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
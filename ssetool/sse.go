@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// event is a Server-Sent Event as defined by the HTML Living Standard.
+type event struct {
+	Name  string
+	ID    string
+	Retry time.Duration
+	Data  string
+}
+
+// readEvents parses an event stream from r, calling fn for every dispatched event.
+// Comment lines are ignored and events without data are not dispatched, per the spec.
+func readEvents(r io.Reader, fn func(event)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	var (
+		ev      event
+		data    []string
+		hasData bool
+	)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if hasData {
+				ev.Data = strings.Join(data, "\n")
+				fn(ev)
+			}
+			ev = event{ID: ev.ID, Retry: ev.Retry}
+			data, hasData = nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Name = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				ev.ID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	return sc.Err()
+}
+
+// writeEvent encodes ev in the event stream format; multi-line data is split across data fields.
+func writeEvent(w io.Writer, ev event) error {
+	var b strings.Builder
+	if ev.Name != "" {
+		fmt.Fprintf(&b, "event: %s\n", ev.Name)
+	}
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", ev.ID)
+	}
+	if ev.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(ev.Data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func collect(t *testing.T, stream string) []event {
+	t.Helper()
+	var got []event
+	if err := readEvents(strings.NewReader(stream), func(ev event) { got = append(got, ev) }); err != nil {
+		t.Fatalf("readEvents: %v", err)
+	}
+	return got
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := ": keep-alive\n" +
+		"event: update\nid: 1\nretry: 1500\ndata: first\ndata: second\n\n" +
+		"data:no space\n\n" +
+		"id: 2\n\n" +
+		"event: ignored-without-data\n\n" +
+		"data: last\n"
+
+	got := collect(t, stream)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(got), got)
+	}
+	want := event{Name: "update", ID: "1", Retry: 1500 * time.Millisecond, Data: "first\nsecond"}
+	if got[0] != want {
+		t.Errorf("event 0 = %+v, want %+v", got[0], want)
+	}
+	// id and retry persist across events, the name does not.
+	want = event{ID: "1", Retry: 1500 * time.Millisecond, Data: "no space"}
+	if got[1] != want {
+		t.Errorf("event 1 = %+v, want %+v", got[1], want)
+	}
+}
+
+func TestWriteEventRoundTrip(t *testing.T) {
+	in := event{Name: "tick", ID: "42", Retry: 2 * time.Second, Data: "{\n  \"a\": 1\n}"}
+	var buf bytes.Buffer
+	if err := writeEvent(&buf, in); err != nil {
+		t.Fatalf("writeEvent: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "event: tick\nid: 42\nretry: 2000\ndata: {\n") {
+		t.Errorf("unexpected encoding: %q", buf.String())
+	}
+	got := collect(t, buf.String())
+	if len(got) != 1 || got[0] != in {
+		t.Errorf("round trip = %+v, want %+v", got, in)
+	}
+}
+
+func TestWriteEventOmitsEmptyFields(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeEvent(&buf, event{Data: "x"}); err != nil {
+		t.Fatalf("writeEvent: %v", err)
+	}
+	if buf.String() != "data: x\n\n" {
+		t.Errorf("unexpected encoding: %q", buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// defaultRetry is the reconnection delay used until the server sends a retry field.
+const defaultRetry = 3 * time.Second
+
+func subscribeCommand() *cobra.Command {
+	var (
+		url            string
+		headers        []string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "subscribe",
+		Short: "Connect to an SSE stream and print received events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			headerMap, err := toolutil.ParseHeaders(headers)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+
+			// --connect-timeout bounds each connection until the response headers; the stream
+			// itself is read without a deadline.
+			client := &http.Client{Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: connectTimeout}).DialContext,
+				TLSHandshakeTimeout:   connectTimeout,
+				ResponseHeaderTimeout: connectTimeout,
+			}}
+
+			toolutil.PrintInfo("Subscribing to %s", url)
+
+			// Like EventSource, reconnect whenever the stream ends and resume from the last seen id.
+			var lastID string
+			retry := defaultRetry
+			for {
+				err := subscribe(ctx, client, url, headerMap, lastID, func(ev event) {
+					lastID = ev.ID
+					if ev.Retry > 0 {
+						retry = ev.Retry
+					}
+					printEvent(url, ev)
+				})
+				if ctx.Err() != nil {
+					return nil
+				}
+				if err != nil {
+					toolutil.PrintWarning("Stream error: %v", err)
+				} else {
+					toolutil.PrintWarning("Stream closed by server")
+				}
+				toolutil.PrintInfo("Reconnecting in %s", retry)
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(retry):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", "http://localhost:8082/events", "URL of the event stream")
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// subscribe opens one connection to the stream and reads events until it ends.
+func subscribe(ctx context.Context, client *http.Client, url string, headers map[string]string, lastID string, fn func(event)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	toolutil.PrintSuccess("Connected to event stream")
+
+	err = readEvents(resp.Body, fn)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func printEvent(url string, ev event) {
+	name := ev.Name
+	if name == "" {
+		name = "message"
+	}
+	items := []toolutil.KV{{Key: "Name", Value: name}}
+	if ev.ID != "" {
+		items = append(items, toolutil.KV{Key: "ID", Value: ev.ID})
+	}
+	if ev.Retry > 0 {
+		items = append(items, toolutil.KV{Key: "Retry", Value: ev.Retry.String()})
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Stream", Items: []toolutil.KV{{Key: "URL", Value: url}}},
+		{Title: "Event", Items: items},
+	}
+	data := []byte(ev.Data)
+	toolutil.PrintColoredMessage("SSE", sections, data, toolutil.GuessMIME(data))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "stomptool",
+		Short: "STOMP client tester",
+		Long:  "A simple STOMP 1.1/1.2 CLI (ActiveMQ, Artemis, RabbitMQ STOMP plugin) with send and serve commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// connectionFlags registers the broker address and CONNECT options shared by send and serve.
+func connectionFlags(cmd *cobra.Command, addr *string, opts *connectOptions) {
+	cmd.Flags().StringVar(addr, "address", "localhost:61613", "STOMP broker address (host:port)")
+	cmd.Flags().StringVar(&opts.Login, "login", "", "Login user")
+	cmd.Flags().StringVar(&opts.Passcode, "passcode", "", "Login passcode")
+	cmd.Flags().StringVar(&opts.Host, "vhost", "", "Virtual host sent in the CONNECT frame (default: the address host)")
+}
+
+// parseAckMode validates the --ack flag.
+func parseAckMode(s string) (string, error) {
+	mode := strings.ToLower(s)
+	switch mode {
+	case "auto", "client", "client-individual":
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --ack %q: expected auto, client or client-individual", s)
+}
+
+// messageHeaders are MESSAGE frame headers shown in the Message section rather than as headers.
+var messageHeaders = []string{"destination", "message-id", "subscription", "ack", "content-type", "content-length"}
+
+// headerItems returns the application headers of a MESSAGE frame.
+func headerItems(f frame) []toolutil.KV {
+	var items []toolutil.KV
+	for _, h := range f.Headers {
+		if !slices.Contains(messageHeaders, h.Key) {
+			items = append(items, toolutil.KV{Key: h.Key, Value: h.Value})
+		}
+	}
+	return items
+}
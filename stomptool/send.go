@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		sendAddr        string
+		connOpts        connectOptions
+		sendDestination string
+		receipt         bool
+		sendPayload     string
+		sendMIME        string
+		sendInterval    string
+		headers         []string
+		noHeaderBase64  bool
+		openDelim       string
+		closeDelim      string
+		seed            int64
+		seedPerMessage  bool
+		allowFileReads  bool
+		allowEnvReads   bool
+		execOptions     toolutil.ExecOptions
+		payloadURL      string
+		strictTemplate  bool
+		templateVars    []string
+		fileRoot        string
+		cacheFiles      bool
+		dataSource      toolutil.DataSourceOptions
+		payloadSource   toolutil.PayloadSourceOptions
+		templateEngine  string
+		cloudEvents     toolutil.CloudEventsOptions
+		protoset        string
+		payloadSchema   string
+		corruptRate     float64
+		once            bool
+		printPayload    bool
+		interactive     bool
+		connectTimeout  time.Duration
+		connectRetry    toolutil.ConnectRetryOptions
+		reconnectEvery  time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic messages to a STOMP destination",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var conn *client
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					c, err := dial(ctx, sendAddr, connOpts)
+					if err != nil {
+						return err
+					}
+					conn = c
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to STOMP broker: %w", err)
+			}
+			defer func() { conn.Close() }() //nolint:errcheck
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				conn.Close() //nolint:errcheck
+				return connect()
+			})
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+
+			toolutil.PrintSuccess("Connected to STOMP broker")
+			toolutil.PrintKeyValue("Address", sendAddr)
+			toolutil.PrintKeyValue("Server", conn.server)
+			toolutil.PrintKeyValue("Version", conn.version)
+			toolutil.PrintKeyValue("Destination", sendDestination)
+
+			dest := toolutil.NewDestination(sendDestination, openDelim, closeDelim)
+			send := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					toolutil.PrintError("Reconnect error: %v", err)
+					return err
+				}
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				destination, err := dest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Destination build error: %v", err)
+					return err
+				}
+				frameHeaders := map[string]string{"content-type": ct}
+				for k, v := range headerMap {
+					frameHeaders[k] = v
+				}
+
+				sendCtx, sendCancel := context.WithTimeout(ctx, 10*time.Second)
+				defer sendCancel()
+				if err := conn.Send(sendCtx, destination, frameHeaders, body, receipt); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				if receipt {
+					toolutil.PrintInfo("Sent %d bytes to '%s' (receipt confirmed)", len(body), destination)
+				} else {
+					toolutil.PrintInfo("Sent %d bytes to '%s'", len(body), destination)
+				}
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	connectionFlags(cmd, &sendAddr, &connOpts)
+	cmd.Flags().StringVar(&sendDestination, "destination", "/queue/test", "Destination to send to, e.g. /queue/NAME or /topic/NAME (supports template placeholders)")
+	cmd.Flags().BoolVar(&receipt, "receipt", false, "Request a receipt for every message and wait for the broker to confirm it")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, STOMP!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		subAddr        string
+		connOpts       connectOptions
+		subDestination string
+		ackMode        string
+		nack           bool
+		receipt        bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Subscribe to a STOMP destination and log received messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mode, err := parseAckMode(ackMode)
+			if err != nil {
+				return err
+			}
+			if nack && mode == "auto" {
+				return fmt.Errorf("--nack requires --ack client or client-individual")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var conn *client
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				c, err := dial(ctx, subAddr, connOpts)
+				if err != nil {
+					return err
+				}
+				// With a receipt, a rejected subscription (e.g. unauthorized) fails here.
+				if err := c.Subscribe(ctx, "0", subDestination, mode, nil, receipt); err != nil {
+					c.Close() //nolint:errcheck
+					return fmt.Errorf("subscribe failed: %w", err)
+				}
+				conn = c
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to STOMP broker: %w", err)
+			}
+			defer conn.Close() //nolint:errcheck
+
+			toolutil.PrintSuccess("Subscribed to STOMP destination")
+			toolutil.PrintKeyValue("Address", subAddr)
+			toolutil.PrintKeyValue("Server", conn.server)
+			toolutil.PrintKeyValue("Version", conn.version)
+			toolutil.PrintKeyValue("Destination", subDestination)
+			toolutil.PrintKeyValue("Ack", mode)
+
+			ackCommand := "ACK"
+			if nack {
+				ackCommand = "NACK"
+			}
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case msg, ok := <-conn.Messages():
+					if !ok {
+						if err := conn.Err(); err != nil {
+							return err
+						}
+						return errors.New("connection closed by broker")
+					}
+					// Messages dropped after --max-messages are left unacknowledged for redelivery
+					if !printMessage(msg) || mode == "auto" {
+						continue
+					}
+					// Acknowledge with a fresh context so messages printed right before shutdown are still settled.
+					ackCtx, ackCancel := context.WithTimeout(context.Background(), 10*time.Second)
+					if err := conn.Ack(ackCtx, ackCommand, msg, receipt); err != nil {
+						toolutil.PrintError("%s error: %v", ackCommand, err)
+					}
+					ackCancel()
+				}
+			}
+		},
+	}
+
+	connectionFlags(cmd, &subAddr, &connOpts)
+	cmd.Flags().StringVar(&subDestination, "destination", "/queue/test", "Destination to subscribe to, e.g. /queue/NAME or /topic/NAME")
+	cmd.Flags().StringVar(&ackMode, "ack", "auto", "Ack mode: auto, client (cumulative) or client-individual")
+	cmd.Flags().BoolVar(&nack, "nack", false, "Reject messages with NACK instead of acknowledging them (client ack modes)")
+	cmd.Flags().BoolVar(&receipt, "receipt", false, "Request receipts for the subscription and every ACK/NACK, reporting failures")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// printMessage prints msg and reports whether it was printed, see toolutil.PrintColoredMessage.
+func printMessage(msg frame) bool {
+	meta := []toolutil.KV{
+		{Key: "ID", Value: msg.get("message-id")},
+		{Key: "Subscription", Value: msg.get("subscription")},
+	}
+	if ack := msg.get("ack"); ack != "" {
+		meta = append(meta, toolutil.KV{Key: "Ack", Value: ack})
+	}
+	sections := []toolutil.MessageSection{
+		destinationSection(msg.get("destination")),
+		{Title: "Message", Items: meta},
+		toolutil.HeadersSection(headerItems(msg)),
+	}
+	ct := msg.get("content-type")
+	if ct == "" {
+		ct = toolutil.GuessMIME(msg.Body)
+	}
+	return toolutil.PrintColoredMessage("STOMP", sections, msg.Body, ct)
+}
+
+// destinationSection names the section after the destination type, /topic/ or anything else.
+func destinationSection(destination string) toolutil.MessageSection {
+	title := "Queue"
+	if strings.HasPrefix(destination, "/topic/") {
+		title = "Topic"
+	}
+	return toolutil.MessageSection{Title: title, Items: []toolutil.KV{{Key: "Name", Value: destination}}}
+}
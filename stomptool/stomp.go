@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFrameSize bounds the size of a received frame body and of each header line.
+const maxFrameSize = 64 * 1024 * 1024
+
+// header is a single STOMP header; frames keep headers in wire order because, for repeated
+// headers, only the first occurrence is significant.
+type header struct {
+	Key   string
+	Value string
+}
+
+// frame is a STOMP frame.
+type frame struct {
+	Command string
+	Headers []header
+	Body    []byte
+}
+
+// get returns the first value of the header named key.
+func (f frame) get(key string) string {
+	for _, h := range f.Headers {
+		if h.Key == key {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// set appends a header.
+func (f *frame) set(key, value string) {
+	f.Headers = append(f.Headers, header{Key: key, Value: value})
+}
+
+var (
+	headerEscaper   = strings.NewReplacer(`\`, `\\`, "\r", `\r`, "\n", `\n`, ":", `\c`)
+	headerUnescaper = strings.NewReplacer(`\\`, `\`, `\r`, "\r", `\n`, "\n", `\c`, ":")
+)
+
+// escaped reports whether header values of the frame are escaped; STOMP 1.2 exempts the
+// CONNECT and CONNECTED frames for backwards compatibility.
+func escaped(command string) bool {
+	return command != "CONNECT" && command != "STOMP" && command != "CONNECTED"
+}
+
+// writeFrame encodes f. A content-length header is added to frames with a body so the body
+// may contain NUL bytes.
+func writeFrame(w io.Writer, f frame) error {
+	var b bytes.Buffer
+	b.WriteString(f.Command)
+	b.WriteByte('\n')
+	for _, h := range f.Headers {
+		k, v := h.Key, h.Value
+		if escaped(f.Command) {
+			k, v = headerEscaper.Replace(k), headerEscaper.Replace(v)
+		}
+		fmt.Fprintf(&b, "%s:%s\n", k, v)
+	}
+	if len(f.Body) > 0 && f.get("content-length") == "" {
+		fmt.Fprintf(&b, "content-length:%d\n", len(f.Body))
+	}
+	b.WriteByte('\n')
+	b.Write(f.Body)
+	b.WriteByte(0)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// readFrame decodes the next frame from r, skipping heart-beat end-of-lines.
+func readFrame(r *bufio.Reader) (frame, error) {
+	var f frame
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return f, err
+		}
+		if line != "" {
+			f.Command = line
+			break
+		}
+	}
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return f, err
+		}
+		if line == "" {
+			break
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return f, fmt.Errorf("malformed header %q", line)
+		}
+		if escaped(f.Command) {
+			k, v = headerUnescaper.Replace(k), headerUnescaper.Replace(v)
+		}
+		f.set(k, v)
+	}
+
+	if cl := f.get("content-length"); cl != "" {
+		n, err := strconv.Atoi(cl)
+		if err != nil || n < 0 || n > maxFrameSize {
+			return f, fmt.Errorf("invalid content-length %q", cl)
+		}
+		f.Body = make([]byte, n)
+		if _, err := io.ReadFull(r, f.Body); err != nil {
+			return f, err
+		}
+		nul, err := r.ReadByte()
+		if err != nil {
+			return f, err
+		}
+		if nul != 0 {
+			return f, errors.New("frame body not terminated by NUL")
+		}
+		return f, nil
+	}
+	body, err := readUntil(r, 0, maxFrameSize)
+	if err != nil {
+		return f, err
+	}
+	f.Body = body[:len(body)-1]
+	return f, nil
+}
+
+// readLine reads a line terminated by LF or CRLF, without the terminator.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := readUntil(r, '\n', maxFrameSize)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
+
+// readUntil reads up to and including delim, failing as soon as more than limit bytes are read
+// instead of buffering an unterminated frame from a misbehaving peer.
+func readUntil(r *bufio.Reader, delim byte, limit int) ([]byte, error) {
+	var b []byte
+	for {
+		chunk, err := r.ReadSlice(delim)
+		if len(b)+len(chunk) > limit {
+			return nil, errors.New("frame too large")
+		}
+		b = append(b, chunk...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return b, err
+		}
+	}
+}
+
+// connectOptions are the credentials and virtual host sent in the CONNECT frame.
+type connectOptions struct {
+	Login    string
+	Passcode string
+	Host     string
+}
+
+// client is a minimal STOMP 1.1/1.2 client. Heart-beating is not negotiated.
+type client struct {
+	nc      net.Conn
+	version string
+	server  string
+
+	wmu sync.Mutex // serializes frame writes
+
+	mu       sync.Mutex
+	receipts map[string]chan struct{}
+	nextID   int
+	err      error // set when the connection ends
+
+	messages  chan frame
+	done      chan struct{} // closed when readLoop exits
+	closing   chan struct{} // closed by Close
+	closeOnce sync.Once
+}
+
+// dial connects to the broker at addr and performs the STOMP handshake.
+func dial(ctx context.Context, addr string, opts connectOptions) (*client, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = nc.SetDeadline(deadline)
+	}
+
+	host := opts.Host
+	if host == "" {
+		host, _, _ = net.SplitHostPort(addr)
+	}
+	connect := frame{Command: "CONNECT"}
+	connect.set("accept-version", "1.1,1.2")
+	connect.set("host", host)
+	connect.set("heart-beat", "0,0")
+	if opts.Login != "" {
+		connect.set("login", opts.Login)
+		connect.set("passcode", opts.Passcode)
+	}
+	if err := writeFrame(nc, connect); err != nil {
+		nc.Close() //nolint:errcheck
+		return nil, err
+	}
+	r := bufio.NewReader(nc)
+	resp, err := readFrame(r)
+	if err != nil {
+		nc.Close() //nolint:errcheck
+		return nil, err
+	}
+	if resp.Command == "ERROR" {
+		nc.Close() //nolint:errcheck
+		return nil, frameError(resp)
+	}
+	if resp.Command != "CONNECTED" {
+		nc.Close() //nolint:errcheck
+		return nil, fmt.Errorf("unexpected %s frame during handshake", resp.Command)
+	}
+	_ = nc.SetDeadline(time.Time{})
+
+	c := &client{
+		nc:       nc,
+		version:  resp.get("version"),
+		server:   resp.get("server"),
+		receipts: make(map[string]chan struct{}),
+		messages: make(chan frame, 64),
+		done:     make(chan struct{}),
+		closing:  make(chan struct{}),
+	}
+	if c.version == "" {
+		c.version = "1.0"
+	}
+	go c.readLoop(r)
+	return c, nil
+}
+
+// frameError converts an ERROR frame to an error.
+func frameError(f frame) error {
+	msg := f.get("message")
+	if body := strings.TrimSpace(string(f.Body)); body != "" {
+		if msg == "" {
+			msg = body
+		} else {
+			msg += ": " + body
+		}
+	}
+	return fmt.Errorf("broker error: %s", msg)
+}
+
+// readLoop dispatches received frames until the connection ends.
+func (c *client) readLoop(r *bufio.Reader) {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		close(c.messages)
+		close(c.done)
+	}()
+	for {
+		var f frame
+		if f, err = readFrame(r); err != nil {
+			return
+		}
+		switch f.Command {
+		case "MESSAGE":
+			select {
+			case c.messages <- f:
+			case <-c.closing:
+				// Messages arriving during shutdown are dropped; keep reading for the receipt.
+			}
+		case "RECEIPT":
+			c.mu.Lock()
+			ch := c.receipts[f.get("receipt-id")]
+			delete(c.receipts, f.get("receipt-id"))
+			c.mu.Unlock()
+			if ch != nil {
+				close(ch)
+			}
+		case "ERROR":
+			// The broker closes the connection after an ERROR frame.
+			err = frameError(f)
+			return
+		}
+	}
+}
+
+// Err returns why the connection ended, once the messages channel is closed.
+func (c *client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err == nil || errors.Is(c.err, io.EOF) || errors.Is(c.err, net.ErrClosed) {
+		return nil
+	}
+	return c.err
+}
+
+// Messages returns the channel of received MESSAGE frames; it is closed when the connection ends.
+func (c *client) Messages() <-chan frame {
+	return c.messages
+}
+
+// request writes f. With receipt, it adds a receipt header and waits until the broker confirms
+// the frame was processed.
+func (c *client) request(ctx context.Context, f frame, receipt bool) error {
+	var ch chan struct{}
+	if receipt {
+		c.mu.Lock()
+		c.nextID++
+		id := "receipt-" + strconv.Itoa(c.nextID)
+		ch = make(chan struct{})
+		c.receipts[id] = ch
+		c.mu.Unlock()
+		f.set("receipt", id)
+	}
+
+	c.wmu.Lock()
+	err := writeFrame(c.nc, f)
+	c.wmu.Unlock()
+	if err != nil || ch == nil {
+		return err
+	}
+
+	select {
+	case <-ch:
+		return nil
+	case <-c.done:
+		if err := c.Err(); err != nil {
+			return err
+		}
+		return errors.New("connection closed before receipt")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Send sends body to destination with the given headers.
+func (c *client) Send(ctx context.Context, destination string, headers map[string]string, body []byte, receipt bool) error {
+	f := frame{Command: "SEND", Body: body}
+	f.set("destination", destination)
+	for k, v := range headers {
+		f.set(k, v)
+	}
+	return c.request(ctx, f, receipt)
+}
+
+// Subscribe subscribes to destination with the given subscription id and ack mode.
+func (c *client) Subscribe(ctx context.Context, id, destination, ack string, headers map[string]string, receipt bool) error {
+	f := frame{Command: "SUBSCRIBE"}
+	f.set("id", id)
+	f.set("destination", destination)
+	f.set("ack", ack)
+	for k, v := range headers {
+		f.set(k, v)
+	}
+	return c.request(ctx, f, receipt)
+}
+
+// Ack acknowledges msg (command "ACK") or rejects it (command "NACK").
+func (c *client) Ack(ctx context.Context, command string, msg frame, receipt bool) error {
+	f := frame{Command: command}
+	if c.version == "1.2" {
+		f.set("id", msg.get("ack"))
+	} else {
+		f.set("message-id", msg.get("message-id"))
+		f.set("subscription", msg.get("subscription"))
+	}
+	return c.request(ctx, f, receipt)
+}
+
+// Close sends DISCONNECT, waiting briefly for its receipt so pending frames are processed,
+// and closes the connection.
+func (c *client) Close() error {
+	c.closeOnce.Do(func() { close(c.closing) })
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = c.request(ctx, frame{Command: "DISCONNECT"}, true)
+	return c.nc.Close()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	in := frame{Command: "SEND", Body: []byte("a\x00b")}
+	in.set("destination", "/queue/a:b")
+	in.set("x-multi", "line1\nline2\\")
+
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, in); err != nil {
+		t.Fatalf("writeFrame: %v", err)
+	}
+	if !strings.Contains(buf.String(), "destination:/queue/a\\cb\n") {
+		t.Errorf("header not escaped: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "content-length:3\n") {
+		t.Errorf("content-length missing: %q", buf.String())
+	}
+
+	// Heart-beat end-of-lines before the frame are skipped.
+	out, err := readFrame(bufio.NewReader(io.MultiReader(strings.NewReader("\n\r\n"), &buf)))
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if out.Command != "SEND" || string(out.Body) != "a\x00b" {
+		t.Errorf("got %q %q", out.Command, out.Body)
+	}
+	if out.get("destination") != "/queue/a:b" || out.get("x-multi") != "line1\nline2\\" {
+		t.Errorf("headers not unescaped: %+v", out.Headers)
+	}
+}
+
+func TestReadFrameWithoutContentLength(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("MESSAGE\r\ndestination:/topic/t\r\nfoo:1\r\nfoo:2\r\n\r\nhello\x00"))
+	f, err := readFrame(r)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if string(f.Body) != "hello" {
+		t.Errorf("body = %q", f.Body)
+	}
+	// Only the first occurrence of a repeated header is significant.
+	if f.get("foo") != "1" {
+		t.Errorf("foo = %q, want 1", f.get("foo"))
+	}
+}
+
+func TestReadUntilLimit(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("hello\x00world"), 16)
+	b, err := readUntil(r, 0, 8)
+	if err != nil || string(b) != "hello\x00" {
+		t.Errorf("readUntil() = %q, %v", b, err)
+	}
+
+	// An unterminated body fails once it exceeds the limit, without reading the rest.
+	src := strings.NewReader(strings.Repeat("x", 1<<20) + "\x00")
+	r = bufio.NewReaderSize(src, 16)
+	if _, err := readUntil(r, 0, 64); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("readUntil() error = %v, want frame too large", err)
+	}
+	if read := src.Size() - int64(src.Len()); read > 64+16 {
+		t.Errorf("read %d bytes for a 64 bytes limit", read)
+	}
+}
+
+func TestConnectHeadersAreNotEscaped(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("CONNECTED\nserver:broker\\c1\n\n\x00"))
+	f, err := readFrame(r)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if f.get("server") != `broker\c1` {
+		t.Errorf("server = %q", f.get("server"))
+	}
+}
+
+func TestHeaderItems(t *testing.T) {
+	f := frame{Command: "MESSAGE"}
+	f.set("destination", "/queue/q")
+	f.set("message-id", "1")
+	f.set("priority", "4")
+	items := headerItems(f)
+	if len(items) != 1 || items[0].Key != "priority" {
+		t.Errorf("headerItems() = %v", items)
+	}
+}
+
+func TestParseAckMode(t *testing.T) {
+	for _, s := range []string{"auto", "CLIENT", "client-individual"} {
+		if _, err := parseAckMode(s); err != nil {
+			t.Errorf("parseAckMode(%q): %v", s, err)
+		}
+	}
+	if _, err := parseAckMode("manual"); err == nil {
+		t.Error("parseAckMode(manual) succeeded, want error")
+	}
+}
+
+// fakeBroker accepts one connection and answers frames with handle.
+func fakeBroker(t *testing.T, handle func(w net.Conn, f frame)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		nc, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer nc.Close()
+		r := bufio.NewReader(nc)
+		for {
+			f, err := readFrame(r)
+			if err != nil {
+				return
+			}
+			handle(nc, f)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func reply(w net.Conn, command string, headers ...string) {
+	f := frame{Command: command}
+	for i := 0; i+1 < len(headers); i += 2 {
+		f.set(headers[i], headers[i+1])
+	}
+	_ = writeFrame(w, f)
+}
+
+func TestClientSendSubscribeAck(t *testing.T) {
+	acked := make(chan frame, 1)
+	addr := fakeBroker(t, func(w net.Conn, f frame) {
+		switch f.Command {
+		case "CONNECT":
+			if f.get("login") != "guest" || f.get("accept-version") != "1.1,1.2" {
+				reply(w, "ERROR", "message", "bad login")
+				return
+			}
+			reply(w, "CONNECTED", "version", "1.2", "server", "fake/1.0")
+		case "SEND":
+			msg := frame{Command: "MESSAGE", Body: f.Body}
+			msg.set("destination", f.get("destination"))
+			msg.set("message-id", "m1")
+			msg.set("subscription", "0")
+			msg.set("ack", "a1")
+			_ = writeFrame(w, msg)
+		case "ACK":
+			acked <- f
+		}
+		if id := f.get("receipt"); id != "" {
+			reply(w, "RECEIPT", "receipt-id", id)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := dial(ctx, addr, connectOptions{Login: "guest", Passcode: "guest"})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	if c.version != "1.2" || c.server != "fake/1.0" {
+		t.Errorf("version/server = %q/%q", c.version, c.server)
+	}
+	if err := c.Subscribe(ctx, "0", "/queue/q", "client-individual", nil, true); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := c.Send(ctx, "/queue/q", map[string]string{"content-type": "text/plain"}, []byte("hi"), true); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msg := <-c.Messages()
+	if string(msg.Body) != "hi" || msg.get("destination") != "/queue/q" {
+		t.Fatalf("message = %+v", msg)
+	}
+	if err := c.Ack(ctx, "ACK", msg, true); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if f := <-acked; f.get("id") != "a1" {
+		t.Errorf("ACK id = %q, want a1", f.get("id"))
+	}
+}
+
+func TestDialBrokerError(t *testing.T) {
+	addr := fakeBroker(t, func(w net.Conn, f frame) {
+		reply(w, "ERROR", "message", "access refused")
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := dial(ctx, addr, connectOptions{})
+	if err == nil || !strings.Contains(err.Error(), "access refused") {
+		t.Fatalf("dial error = %v, want access refused", err)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "syslogtool",
+		Short: "Syslog sender and collector",
+		Long:  "A simple syslog CLI that sends templated RFC 5424/RFC 3164 messages over UDP, TCP or TLS and runs a collector printing parsed messages.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// validateTransport checks the --network and --tcp-framing flags.
+func validateTransport(network, framing string) error {
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		return fmt.Errorf("invalid --network %q: expected udp, tcp or tls", network)
+	}
+	switch framing {
+	case framingOctetCounting, framingNonTransparent:
+		return nil
+	}
+	return fmt.Errorf("invalid --tcp-framing %q: expected %s or %s", framing, framingOctetCounting, framingNonTransparent)
+}
+
+// dial connects to a collector; "tls" is TCP with TLS.
+func dial(ctx context.Context, network, address string, insecure bool) (net.Conn, error) {
+	var d net.Dialer
+	if network != "tls" {
+		return d.DialContext(ctx, network, address)
+	}
+	host, _, _ := net.SplitHostPort(address)
+	td := tls.Dialer{NetDialer: &d, Config: &tls.Config{ServerName: host, InsecureSkipVerify: insecure}} //nolint:gosec // opt-in for test collectors
+	return td.DialContext(ctx, "tcp", address)
+}
+
+// printMessage prints a parsed syslog message received from remote.
+func printMessage(network, remote string, m message) {
+	header := []toolutil.KV{
+		{Key: "Format", Value: m.Format},
+		{Key: "Facility", Value: fmt.Sprintf("%s (%d)", codeName(m.Facility, facilityNames), m.Facility)},
+		{Key: "Severity", Value: fmt.Sprintf("%s (%d)", codeName(m.Severity, severityNames), m.Severity)},
+	}
+	if !m.Timestamp.IsZero() {
+		header = append(header, toolutil.KV{Key: "Timestamp", Value: m.Timestamp.Format("2006-01-02T15:04:05.000000Z07:00")})
+	}
+	for _, kv := range []toolutil.KV{
+		{Key: "Hostname", Value: m.Hostname},
+		{Key: "App", Value: m.AppName},
+		{Key: "ProcID", Value: m.ProcID},
+		{Key: "MsgID", Value: m.MsgID},
+	} {
+		if kv.Value != "" {
+			header = append(header, kv)
+		}
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Peer", Items: []toolutil.KV{{Key: "Network", Value: network}, {Key: "Remote", Value: remote}}},
+		{Title: "Syslog", Items: header},
+	}
+	for _, e := range m.StructuredData {
+		items := make([]toolutil.KV, 0, len(e.Params))
+		for _, p := range e.Params {
+			items = append(items, toolutil.KV{Key: p.Name, Value: p.Value})
+		}
+		sections = append(sections, toolutil.MessageSection{Title: "SD " + e.ID + " (" + strconv.Itoa(len(items)) + ")", Items: items})
+	}
+	toolutil.PrintColoredMessage("Syslog", sections, m.Message, toolutil.GuessMIME(m.Message))
+}
+
+// printRaw prints a message that could not be parsed.
+func printRaw(network, remote string, data []byte, err error) {
+	toolutil.PrintWarning("Unparseable message from %s: %v", remote, err)
+	sections := []toolutil.MessageSection{
+		{Title: "Peer", Items: []toolutil.KV{{Key: "Network", Value: network}, {Key: "Remote", Value: remote}}},
+		{Title: "Syslog", Items: []toolutil.KV{{Key: "Format", Value: "unknown"}}},
+	}
+	data = []byte(strings.TrimRight(string(data), "\r\n"))
+	toolutil.PrintColoredMessage("Syslog", sections, data, toolutil.GuessMIME(data))
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		network        string
+		address        string
+		insecure       bool
+		tcpFraming     string
+		format         string
+		facility       string
+		severity       string
+		hostname       string
+		appName        string
+		procID         string
+		msgID          string
+		sdID           string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		headers        []string
+		noHeaderBase64 bool
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+		reconnectEvery time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic templated syslog messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateTransport(network, tcpFraming); err != nil {
+				return err
+			}
+			if format != formatRFC5424 && format != formatRFC3164 {
+				return fmt.Errorf("invalid --format %q: expected %s or %s", format, formatRFC5424, formatRFC3164)
+			}
+			fac, err := parseCode("facility", facility, facilityNames)
+			if err != nil {
+				return err
+			}
+			sev, err := parseCode("severity", severity, severityNames)
+			if err != nil {
+				return err
+			}
+			if hostname == "" {
+				hostname, _ = os.Hostname()
+			}
+			if procID == "" {
+				procID = strconv.Itoa(os.Getpid())
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			var sd []sdElement
+			if len(headerMap) > 0 {
+				if format == formatRFC3164 {
+					return fmt.Errorf("--header requires --format %s: RFC 3164 has no structured data", formatRFC5424)
+				}
+				e := sdElement{ID: sdID}
+				for _, k := range slices.Sorted(maps.Keys(headerMap)) {
+					e.Params = append(e.Params, sdParam{Name: k, Value: headerMap[k]})
+				}
+				sd = []sdElement{e}
+			}
+
+			var conn net.Conn
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					c, err := dial(ctx, network, address, insecure)
+					if err != nil {
+						return err
+					}
+					conn = c
+					return nil
+				})
+			}
+			closeConn := func() {
+				if err := conn.Close(); err != nil {
+					toolutil.PrintError("Failed to close connection: %v", err)
+				}
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to syslog collector: %w", err)
+			}
+			defer func() { closeConn() }()
+			reconnector := common.NewReconnector(reconnectEvery, func() error {
+				closeConn()
+				return connect()
+			})
+
+			toolutil.PrintSuccess("Connected to syslog collector")
+			toolutil.PrintKeyValue("Address", address)
+			toolutil.PrintKeyValue("Network", network)
+			toolutil.PrintKeyValue("Format", format)
+			toolutil.PrintKeyValue("Priority", fmt.Sprintf("%s.%s", facilityNames[fac], severityNames[sev]))
+
+			app := toolutil.NewDestination(appName, openDelim, closeDelim)
+			mid := toolutil.NewDestination(msgID, openDelim, closeDelim)
+			send := func() error {
+				if err := reconnector.MaybeReconnect(); err != nil {
+					toolutil.PrintError("Reconnect error: %v", err)
+					return err
+				}
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				a, err := app.Resolve()
+				if err != nil {
+					toolutil.PrintError("App name build error: %v", err)
+					return err
+				}
+				id, err := mid.Resolve()
+				if err != nil {
+					toolutil.PrintError("MSGID build error: %v", err)
+					return err
+				}
+				m := message{
+					Format:         format,
+					Facility:       fac,
+					Severity:       sev,
+					Timestamp:      time.Now(),
+					Hostname:       hostname,
+					AppName:        a,
+					ProcID:         procID,
+					MsgID:          id,
+					StructuredData: sd,
+					Message:        body,
+				}
+				if err := m.validate(); err != nil {
+					toolutil.PrintError("Invalid message: %v", err)
+					return err
+				}
+				line := m.encode()
+
+				_ = conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if network == "udp" {
+					_, err = conn.Write(line)
+				} else {
+					err = writeFramed(conn, tcpFraming, line)
+				}
+				if err != nil {
+					toolutil.PrintError("Write error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Sent %d bytes: %s", len(line), truncate(string(line), 80))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&network, "network", "udp", "Transport: udp, tcp or tls")
+	cmd.Flags().StringVar(&address, "address", "localhost:5514", "Collector address (host:port)")
+	cmd.Flags().BoolVar(&insecure, "insecure", false, "Skip TLS certificate verification")
+	cmd.Flags().StringVar(&tcpFraming, "tcp-framing", framingOctetCounting, "Stream framing for tcp and tls: octet-counting or non-transparent (newline-terminated)")
+	cmd.Flags().StringVar(&format, "format", formatRFC5424, "Message format: rfc5424 or rfc3164")
+	cmd.Flags().StringVar(&facility, "facility", "user", "Facility name (e.g. user, daemon, local0) or number")
+	cmd.Flags().StringVar(&severity, "severity", "info", "Severity name (e.g. err, warning, info, debug) or number")
+	cmd.Flags().StringVar(&hostname, "hostname", "", "HOSTNAME field (default: the local host name)")
+	cmd.Flags().StringVar(&appName, "app-name", "eventkit", "APP-NAME (RFC 3164 tag), supports template placeholders")
+	cmd.Flags().StringVar(&procID, "procid", "", "PROCID field (default: the process ID)")
+	cmd.Flags().StringVar(&msgID, "msgid", "", "MSGID field (RFC 5424), supports template placeholders")
+	cmd.Flags().StringVar(&sdID, "sd-id", "eventkit@32473", "Structured data element ID holding the --header parameters (RFC 5424)")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, syslog!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddReconnectEveryFlag(cmd, &reconnectEvery)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
+
+// truncate shortens s to at most n runes for log lines.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n])) + "…"
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxDatagramSize is the largest UDP payload.
+const maxDatagramSize = 65535
+
+func serveCommand() *cobra.Command {
+	var (
+		network   string
+		address   string
+		tlsCert   string
+		tlsKey    string
+		serveOpts toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a syslog collector that logs parsed messages",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateTransport(network, framingOctetCounting); err != nil {
+				return err
+			}
+			var tlsConfig *tls.Config
+			if network == "tls" {
+				if tlsCert == "" || tlsKey == "" {
+					return fmt.Errorf("--network tls requires --tls-cert and --tls-key")
+				}
+				cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
+				if err != nil {
+					return fmt.Errorf("error loading TLS certificate: %w", err)
+				}
+				tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var lc net.ListenConfig
+			if network == "udp" {
+				pc, err := lc.ListenPacket(ctx, "udp", address)
+				if err != nil {
+					return fmt.Errorf("error listening on %s: %w", address, err)
+				}
+				printListening(network, pc.LocalAddr())
+				return serveUDP(ctx, pc)
+			}
+			ln, err := lc.Listen(ctx, "tcp", address)
+			if err != nil {
+				return fmt.Errorf("error listening on %s: %w", address, err)
+			}
+			if tlsConfig != nil {
+				ln = tls.NewListener(ln, tlsConfig)
+			}
+			printListening(network, ln.Addr())
+			return serveStream(ctx, ln, network)
+		},
+	}
+
+	cmd.Flags().StringVar(&network, "network", "udp", "Transport: udp, tcp or tls")
+	cmd.Flags().StringVar(&address, "address", "0.0.0.0:5514", "Listen address")
+	cmd.Flags().StringVar(&tlsCert, "tls-cert", "", "PEM certificate file for --network tls")
+	cmd.Flags().StringVar(&tlsKey, "tls-key", "", "PEM private key file for --network tls")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+func printListening(network string, addr net.Addr) {
+	toolutil.PrintSuccess("Syslog collector listening")
+	toolutil.PrintKeyValue("Address", addr)
+	toolutil.PrintKeyValue("Network", network)
+}
+
+// handle parses and prints a received message.
+func handle(network, remote string, data []byte) {
+	m, err := parseMessage(data)
+	if err != nil {
+		printRaw(network, remote, data, err)
+		return
+	}
+	printMessage(network, remote, m)
+}
+
+// serveUDP reads one message per datagram until ctx is done.
+func serveUDP(ctx context.Context, pc net.PacketConn) error {
+	go func() {
+		<-ctx.Done()
+		pc.Close() //nolint:errcheck
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return fmt.Errorf("error reading datagram: %w", err)
+		}
+		handle("udp", addr.String(), buf[:n])
+	}
+}
+
+// serveStream accepts TCP or TLS connections until ctx is done; each connection may mix
+// octet-counted and newline-terminated messages.
+func serveStream(ctx context.Context, ln net.Listener, network string) error {
+	var (
+		mu    sync.Mutex
+		conns = map[net.Conn]struct{}{}
+		wg    sync.WaitGroup
+	)
+	go func() {
+		<-ctx.Done()
+		ln.Close() //nolint:errcheck
+		mu.Lock()
+		for c := range conns {
+			c.Close() //nolint:errcheck
+		}
+		mu.Unlock()
+	}()
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return fmt.Errorf("error accepting connection: %w", err)
+		}
+		mu.Lock()
+		if ctx.Err() != nil {
+			mu.Unlock()
+			conn.Close() //nolint:errcheck
+			continue
+		}
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close() //nolint:errcheck
+			}()
+			remote := conn.RemoteAddr().String()
+			r := bufio.NewReader(conn)
+			for {
+				msg, err := readFramed(r)
+				if err != nil {
+					if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+						toolutil.PrintWarning("Connection from %s ended: %v", remote, err)
+					}
+					return
+				}
+				handle(network, remote, msg)
+			}
+		}()
+	}
+}
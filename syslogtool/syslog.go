@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message formats.
+const (
+	formatRFC5424 = "rfc5424"
+	formatRFC3164 = "rfc3164"
+)
+
+// maxMessageSize bounds octet-counted and newline-terminated messages read from streams.
+const maxMessageSize = 1 << 20
+
+// nilValue stands for an empty RFC 5424 header field or structured data.
+const nilValue = "-"
+
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var severityNames = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// parseCode resolves a facility or severity given by name or number.
+func parseCode(kind, s string, names []string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(names) {
+		return n, nil
+	}
+	for i, name := range names {
+		if name == s {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid %s %q: expected 0-%d or one of %s", kind, s, len(names)-1, strings.Join(names, ", "))
+}
+
+// codeName returns the name of a facility or severity code.
+func codeName(code int, names []string) string {
+	if code >= 0 && code < len(names) {
+		return names[code]
+	}
+	return strconv.Itoa(code)
+}
+
+// sdParam is a structured data parameter.
+type sdParam struct {
+	Name  string
+	Value string
+}
+
+// sdElement is an RFC 5424 structured data element.
+type sdElement struct {
+	ID     string
+	Params []sdParam
+}
+
+// message is a syslog message. Timestamp is zero when the received message had none.
+type message struct {
+	Format         string
+	Facility       int
+	Severity       int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData []sdElement
+	Message        []byte
+}
+
+// priority returns the PRI value.
+func (m message) priority() int {
+	return m.Facility*8 + m.Severity
+}
+
+var sdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// validate checks that header fields fit the format; RFC 5424 fields are printable ASCII without spaces.
+func (m message) validate() error {
+	if m.Format == formatRFC3164 {
+		if strings.ContainsAny(m.Hostname+m.AppName+m.ProcID, " []:") {
+			return errors.New("hostname, app name and proc id must not contain spaces, brackets or colons")
+		}
+		return nil
+	}
+	fields := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"hostname", m.Hostname, 255},
+		{"app name", m.AppName, 48},
+		{"proc id", m.ProcID, 128},
+		{"msgid", m.MsgID, 32},
+	}
+	for _, f := range fields {
+		if len(f.value) > f.max {
+			return fmt.Errorf("%s %q is longer than %d characters", f.name, f.value, f.max)
+		}
+		if !printASCII(f.value) {
+			return fmt.Errorf("%s %q must be printable ASCII without spaces", f.name, f.value)
+		}
+	}
+	for _, e := range m.StructuredData {
+		if !sdName(e.ID) {
+			return fmt.Errorf("invalid structured data ID %q", e.ID)
+		}
+		for _, p := range e.Params {
+			if !sdName(p.Name) {
+				return fmt.Errorf("invalid structured data parameter name %q", p.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func printASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 33 || s[i] > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// sdName reports whether s is a valid SD-NAME: 1-32 printable ASCII characters except '=', ']' and '"'.
+func sdName(s string) bool {
+	return s != "" && len(s) <= 32 && printASCII(s) && !strings.ContainsAny(s, `="]`)
+}
+
+// encode formats the message as a single syslog line, without transport framing.
+func (m message) encode() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>", m.priority())
+	if m.Format == formatRFC3164 {
+		b.WriteString(m.Timestamp.Format(time.Stamp))
+		b.WriteByte(' ')
+		b.WriteString(orDefault(m.Hostname, "localhost"))
+		b.WriteByte(' ')
+		if m.AppName != "" {
+			b.WriteString(m.AppName)
+			if m.ProcID != "" {
+				fmt.Fprintf(&b, "[%s]", m.ProcID)
+			}
+			b.WriteString(": ")
+		}
+		b.Write(m.Message)
+		return b.Bytes()
+	}
+
+	b.WriteString("1 ")
+	if m.Timestamp.IsZero() {
+		b.WriteString(nilValue)
+	} else {
+		b.WriteString(m.Timestamp.Format("2006-01-02T15:04:05.000000Z07:00"))
+	}
+	for _, f := range []string{m.Hostname, m.AppName, m.ProcID, m.MsgID} {
+		b.WriteByte(' ')
+		b.WriteString(orDefault(f, nilValue))
+	}
+	b.WriteByte(' ')
+	if len(m.StructuredData) == 0 {
+		b.WriteString(nilValue)
+	}
+	for _, e := range m.StructuredData {
+		b.WriteByte('[')
+		b.WriteString(e.ID)
+		for _, p := range e.Params {
+			fmt.Fprintf(&b, ` %s="%s"`, p.Name, sdValueEscaper.Replace(p.Value))
+		}
+		b.WriteByte(']')
+	}
+	if len(m.Message) > 0 {
+		b.WriteByte(' ')
+		b.Write(m.Message)
+	}
+	return b.Bytes()
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// utf8BOM may prefix the MSG part of RFC 5424 messages.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// parseMessage parses an RFC 5424 message, falling back to RFC 3164 for anything else.
+func parseMessage(data []byte) (message, error) {
+	data = bytes.TrimRight(data, "\r\n\x00")
+	if len(data) < 3 || data[0] != '<' {
+		return message{}, errors.New("missing PRI")
+	}
+	end := bytes.IndexByte(data, '>')
+	if end < 2 || end > 4 {
+		return message{}, errors.New("malformed PRI")
+	}
+	pri, err := strconv.Atoi(string(data[1:end]))
+	if err != nil || pri > 191 {
+		return message{}, fmt.Errorf("invalid PRI %q", data[1:end])
+	}
+	m := message{Facility: pri / 8, Severity: pri % 8}
+	rest := data[end+1:]
+	if bytes.HasPrefix(rest, []byte("1 ")) {
+		m.Format = formatRFC5424
+		return m, parse5424(&m, rest[2:])
+	}
+	m.Format = formatRFC3164
+	parse3164(&m, rest)
+	return m, nil
+}
+
+func parse5424(m *message, rest []byte) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		sp := bytes.IndexByte(rest, ' ')
+		if sp < 0 {
+			return errors.New("truncated RFC 5424 header")
+		}
+		fields[i], rest = string(rest[:sp]), rest[sp+1:]
+		if fields[i] == nilValue {
+			fields[i] = ""
+		}
+	}
+	if fields[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid timestamp %q", fields[0])
+		}
+		m.Timestamp = ts
+	}
+	m.Hostname, m.AppName, m.ProcID, m.MsgID = fields[1], fields[2], fields[3], fields[4]
+
+	if bytes.HasPrefix(rest, []byte(nilValue)) {
+		rest = rest[1:]
+	} else {
+		var err error
+		if m.StructuredData, rest, err = parseStructuredData(rest); err != nil {
+			return err
+		}
+	}
+	if len(rest) > 0 {
+		if rest[0] != ' ' {
+			return errors.New("missing space before MSG")
+		}
+		m.Message = bytes.TrimPrefix(rest[1:], utf8BOM)
+	}
+	return nil
+}
+
+// parseStructuredData parses consecutive SD-ELEMENTs, returning the remaining input.
+func parseStructuredData(rest []byte) ([]sdElement, []byte, error) {
+	var elements []sdElement
+	for len(rest) > 0 && rest[0] == '[' {
+		rest = rest[1:]
+		i := bytes.IndexAny(rest, " ]")
+		if i < 1 {
+			return nil, nil, errors.New("malformed structured data ID")
+		}
+		e := sdElement{ID: string(rest[:i])}
+		rest = rest[i:]
+		for len(rest) > 0 && rest[0] == ' ' {
+			rest = rest[1:]
+			eq := bytes.IndexByte(rest, '=')
+			if eq < 1 || len(rest) < eq+2 || rest[eq+1] != '"' {
+				return nil, nil, errors.New("malformed structured data parameter")
+			}
+			p := sdParam{Name: string(rest[:eq])}
+			rest = rest[eq+2:]
+			var v strings.Builder
+			closed := false
+			for len(rest) > 0 && !closed {
+				c := rest[0]
+				rest = rest[1:]
+				switch {
+				case c == '\\' && len(rest) > 0 && (rest[0] == '"' || rest[0] == '\\' || rest[0] == ']'):
+					v.WriteByte(rest[0])
+					rest = rest[1:]
+				case c == '"':
+					closed = true
+				default:
+					v.WriteByte(c)
+				}
+			}
+			if !closed {
+				return nil, nil, errors.New("unterminated structured data value")
+			}
+			p.Value = v.String()
+			e.Params = append(e.Params, p)
+		}
+		if len(rest) == 0 || rest[0] != ']' {
+			return nil, nil, errors.New("unterminated structured data element")
+		}
+		rest = rest[1:]
+		elements = append(elements, e)
+	}
+	if elements == nil {
+		return nil, nil, errors.New("malformed structured data")
+	}
+	return elements, rest, nil
+}
+
+// parse3164 parses the BSD syslog format leniently: fields that do not match are left in the message.
+func parse3164(m *message, rest []byte) {
+	if len(rest) >= len(time.Stamp)+1 && rest[len(time.Stamp)] == ' ' {
+		if ts, err := time.Parse(time.Stamp, string(rest[:len(time.Stamp)])); err == nil {
+			// The format has no year; assume the current one.
+			now := time.Now()
+			m.Timestamp = time.Date(now.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.Local)
+			rest = rest[len(time.Stamp)+1:]
+			if sp := bytes.IndexByte(rest, ' '); sp > 0 {
+				m.Hostname, rest = string(rest[:sp]), rest[sp+1:]
+			}
+		}
+	}
+	// TAG is alphanumeric, optionally followed by [PID], and terminated by a colon.
+	if colon := bytes.Index(rest, []byte(": ")); colon > 0 && !bytes.ContainsAny(rest[:colon], " ") {
+		tag := string(rest[:colon])
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			m.ProcID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		m.AppName = tag
+		rest = rest[colon+2:]
+	}
+	m.Message = rest
+}
+
+// Stream framing modes for TCP and TLS (RFC 6587).
+const (
+	framingOctetCounting  = "octet-counting"
+	framingNonTransparent = "non-transparent"
+)
+
+// writeFramed writes msg to a stream with the given framing.
+func writeFramed(w io.Writer, framing string, msg []byte) error {
+	var b bytes.Buffer
+	if framing == framingOctetCounting {
+		fmt.Fprintf(&b, "%d ", len(msg))
+		b.Write(msg)
+	} else {
+		b.Write(msg)
+		b.WriteByte('\n')
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// readFramed reads the next message from a stream, detecting the framing of each message:
+// octet-counted messages start with a digit, newline-terminated ones with '<'.
+func readFramed(r *bufio.Reader) ([]byte, error) {
+	c, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if c[0] >= '0' && c[0] <= '9' {
+		lenStr, err := r.ReadString(' ')
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(lenStr, " "))
+		if err != nil || n < 1 || n > maxMessageSize {
+			return nil, fmt.Errorf("invalid message length %q", strings.TrimSpace(lenStr))
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return msg, nil
+	}
+
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxMessageSize {
+			return nil, errors.New("message too long")
+		}
+		if err == nil {
+			return bytes.TrimRight(line, "\r\n"), nil
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return line, nil
+		}
+		return nil, err
+	}
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
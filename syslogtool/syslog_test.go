@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRFC5424RoundTrip(t *testing.T) {
+	m := message{
+		Format:    formatRFC5424,
+		Facility:  16,
+		Severity:  3,
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC),
+		Hostname:  "host",
+		AppName:   "app",
+		MsgID:     "ID1",
+		StructuredData: []sdElement{
+			{ID: "meta@1", Params: []sdParam{{Name: "q", Value: `a"b]c\d`}, {Name: "n", Value: ""}}},
+			{ID: "empty@1"},
+		},
+		Message: []byte("hello world"),
+	}
+	if err := m.validate(); err != nil {
+		t.Fatalf("validate() error: %v", err)
+	}
+	line := m.encode()
+	want := `<131>1 2024-05-01T12:00:00.123456Z host app - ID1 [meta@1 q="a\"b\]c\\d" n=""][empty@1] hello world`
+	if string(line) != want {
+		t.Errorf("encode() = %s\nwant       %s", line, want)
+	}
+	got, err := parseMessage(line)
+	if err != nil {
+		t.Fatalf("parseMessage() error: %v", err)
+	}
+	if got.Format != formatRFC5424 || got.Facility != 16 || got.Severity != 3 || got.ProcID != "" || got.MsgID != "ID1" {
+		t.Errorf("parseMessage() header = %+v", got)
+	}
+	if !got.Timestamp.Equal(m.Timestamp) {
+		t.Errorf("timestamp = %v, want %v", got.Timestamp, m.Timestamp)
+	}
+	if len(got.StructuredData) != 2 || got.StructuredData[0].Params[0].Value != `a"b]c\d` || len(got.StructuredData[1].Params) != 0 {
+		t.Errorf("structured data = %+v", got.StructuredData)
+	}
+	if string(got.Message) != "hello world" {
+		t.Errorf("message = %q", got.Message)
+	}
+}
+
+func TestParseRFC5424NilFields(t *testing.T) {
+	m, err := parseMessage([]byte("<14>1 - - - - - -\n"))
+	if err != nil {
+		t.Fatalf("parseMessage() error: %v", err)
+	}
+	if !m.Timestamp.IsZero() || m.Hostname != "" || m.StructuredData != nil || len(m.Message) != 0 {
+		t.Errorf("parseMessage() = %+v, want empty fields", m)
+	}
+	m, err = parseMessage([]byte("<14>1 - h a p m - \xEF\xBB\xBFbom"))
+	if err != nil || string(m.Message) != "bom" {
+		t.Errorf("parseMessage() message = %q, %v, want BOM stripped", m.Message, err)
+	}
+}
+
+func TestRFC3164RoundTrip(t *testing.T) {
+	m := message{
+		Format:    formatRFC3164,
+		Facility:  1,
+		Severity:  6,
+		Timestamp: time.Date(time.Now().Year(), 3, 7, 8, 9, 10, 0, time.Local),
+		Hostname:  "host",
+		AppName:   "app",
+		ProcID:    "42",
+		Message:   []byte("a: message"),
+	}
+	line := m.encode()
+	if want := "<14>Mar  7 08:09:10 host app[42]: a: message"; string(line) != want {
+		t.Errorf("encode() = %q, want %q", line, want)
+	}
+	got, err := parseMessage(line)
+	if err != nil {
+		t.Fatalf("parseMessage() error: %v", err)
+	}
+	if got.Format != formatRFC3164 || got.Hostname != "host" || got.AppName != "app" || got.ProcID != "42" || string(got.Message) != "a: message" {
+		t.Errorf("parseMessage() = %+v", got)
+	}
+	if !got.Timestamp.Equal(m.Timestamp) {
+		t.Errorf("timestamp = %v, want %v", got.Timestamp, m.Timestamp)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, in := range []string{"", "no pri", "<>1 x", "<999>1 - - - - - -", "<14>1 - - - - - [unterminated", "<14>1 bad-time - - - - -"} {
+		if _, err := parseMessage([]byte(in)); err == nil {
+			t.Errorf("parseMessage(%q) succeeded, want error", in)
+		}
+	}
+	m, err := parseMessage([]byte("<13>just text"))
+	if err != nil || m.Format != formatRFC3164 || string(m.Message) != "just text" {
+		t.Errorf("parseMessage(bare) = %+v, %v", m, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, m := range []message{
+		{Format: formatRFC5424, AppName: "has space"},
+		{Format: formatRFC5424, MsgID: strings.Repeat("x", 33)},
+		{Format: formatRFC5424, StructuredData: []sdElement{{ID: "bad=id"}}},
+		{Format: formatRFC3164, AppName: "a:b"},
+	} {
+		if err := m.validate(); err == nil {
+			t.Errorf("validate(%+v) succeeded, want error", m)
+		}
+	}
+}
+
+func TestReadFramed(t *testing.T) {
+	var b bytes.Buffer
+	for _, f := range []string{framingOctetCounting, framingNonTransparent, framingOctetCounting} {
+		if err := writeFramed(&b, f, []byte("<14>1 - - - - - - multi\nline")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := bufio.NewReader(&b)
+	want := []string{"<14>1 - - - - - - multi\nline", "<14>1 - - - - - - multi", "line", "<14>1 - - - - - - multi\nline"}
+	for _, w := range want {
+		got, err := readFramed(r)
+		if err != nil {
+			t.Fatalf("readFramed() error: %v", err)
+		}
+		if string(got) != w {
+			t.Errorf("readFramed() = %q, want %q", got, w)
+		}
+	}
+	if _, err := readFramed(r); !errors.Is(err, io.EOF) {
+		t.Errorf("readFramed() at end = %v, want io.EOF", err)
+	}
+	if _, err := readFramed(bufio.NewReader(strings.NewReader("10 short"))); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("readFramed(truncated) = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestParseCode(t *testing.T) {
+	if n, err := parseCode("facility", "local0", facilityNames); err != nil || n != 16 {
+		t.Errorf("parseCode(local0) = %d, %v", n, err)
+	}
+	if n, err := parseCode("severity", "3", severityNames); err != nil || n != 3 {
+		t.Errorf("parseCode(3) = %d, %v", n, err)
+	}
+	for _, in := range []string{"8", "-1", "nope"} {
+		if _, err := parseCode("severity", in, severityNames); err == nil {
+			t.Errorf("parseCode(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sandrolain/eventkit/pkg/framing"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// defaultSocketPath is used by both commands so they work together without flags.
+const defaultSocketPath = "/tmp/eventkit.sock"
+
+func main() {
+	root := &cobra.Command{
+		Use:   "unixsocktool",
+		Short: "Unix domain socket tester",
+		Long:  "A simple Unix domain socket CLI that sends framed templated payloads and listens on a socket path printing received frames.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// validateNetwork checks the --network flag. unixpacket is not supported as it only exists on some platforms.
+func validateNetwork(network string) error {
+	switch network {
+	case "unix", "unixgram":
+		return nil
+	}
+	return fmt.Errorf("invalid --network %q: expected unix or unixgram", network)
+}
+
+// peerName returns the address of a peer; clients rarely bind their end of the socket.
+func peerName(addr fmt.Stringer) string {
+	if addr == nil {
+		return "(unnamed)"
+	}
+	if s := addr.String(); s != "" && s != "@" && s != "<nil>" {
+		return s
+	}
+	return "(unnamed)"
+}
+
+// printFrame prints a received frame with the socket it arrived on.
+func printFrame(network, path, peer string, framer *framing.Framer, index int, frame []byte) {
+	sections := []toolutil.MessageSection{
+		{Title: "Socket", Items: []toolutil.KV{
+			{Key: "Network", Value: network},
+			{Key: "Path", Value: path},
+			{Key: "Peer", Value: peer},
+		}},
+		{Title: "Frame", Items: []toolutil.KV{
+			{Key: "Framing", Value: framer.String()},
+			{Key: "Index", Value: strconv.Itoa(index)},
+			{Key: "Size", Value: strconv.Itoa(len(frame))},
+		}},
+	}
+	toolutil.PrintColoredMessage("Unix Socket", sections, frame, toolutil.GuessMIME(frame))
+}
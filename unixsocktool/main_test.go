@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/framing"
+)
+
+func TestPrepareSocketPath(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := filepath.Join(dir, "missing.sock")
+	if err := prepareSocketPath("unix", missing); err != nil {
+		t.Errorf("prepareSocketPath(missing) error: %v", err)
+	}
+
+	regular := filepath.Join(dir, "file")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := prepareSocketPath("unix", regular); err == nil {
+		t.Error("prepareSocketPath(regular file) succeeded, want error")
+	}
+
+	live := filepath.Join(dir, "live.sock")
+	ln, err := net.Listen("unix", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close() //nolint:errcheck
+	if err := prepareSocketPath("unix", live); err == nil {
+		t.Error("prepareSocketPath(socket in use) succeeded, want error")
+	}
+
+	stale := filepath.Join(dir, "stale.sock")
+	sl, err := net.ListenUnix("unix", &net.UnixAddr{Name: stale, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl.SetUnlinkOnClose(false)
+	sl.Close() //nolint:errcheck
+	if err := prepareSocketPath("unix", stale); err != nil {
+		t.Fatalf("prepareSocketPath(stale socket) error: %v", err)
+	}
+	if _, err := os.Lstat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale socket not removed: %v", err)
+	}
+}
+
+func TestServeStreamStopsOnCancel(t *testing.T) {
+	framer, err := framing.New(framing.Options{Mode: framing.ModeNewline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "s.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- serveStream(ctx, ln, path, framer) }()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close() //nolint:errcheck
+	if _, err := conn.Write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveStream() error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveStream() did not return after cancel")
+	}
+}
+
+func TestPeerName(t *testing.T) {
+	var unnamed *net.UnixAddr
+	tests := []struct {
+		addr net.Addr
+		want string
+	}{
+		{nil, "(unnamed)"},
+		{unnamed, "(unnamed)"},
+		{&net.UnixAddr{Net: "unixgram"}, "(unnamed)"},
+		{&net.UnixAddr{Name: "/run/client.sock", Net: "unixgram"}, "/run/client.sock"},
+	}
+	for _, tt := range tests {
+		if got := peerName(tt.addr); got != tt.want {
+			t.Errorf("peerName(%v) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/framing"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		network     string
+		path        string
+		socketMode  string
+		framingOpts framing.Options
+		serveOpts   toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Listen on a Unix domain socket path and log received frames",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateNetwork(network); err != nil {
+				return err
+			}
+			framer, err := framing.New(framingOpts)
+			if err != nil {
+				return err
+			}
+			var perm fs.FileMode
+			if socketMode != "" {
+				m, err := strconv.ParseUint(socketMode, 8, 32)
+				if err != nil || m > 0o777 {
+					return fmt.Errorf("invalid --socket-mode %q: expected octal permissions such as 0660", socketMode)
+				}
+				perm = fs.FileMode(m)
+			}
+			if err := prepareSocketPath(network, path); err != nil {
+				return err
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var lc net.ListenConfig
+			var serve func() error
+			if network == "unixgram" {
+				pc, err := lc.ListenPacket(ctx, network, path)
+				if err != nil {
+					return fmt.Errorf("error listening on %s: %w", path, err)
+				}
+				serve = func() error { return serveDatagrams(ctx, pc, path, framer, framingOpts.MaxFrameSize) }
+			} else {
+				ln, err := lc.Listen(ctx, network, path)
+				if err != nil {
+					return fmt.Errorf("error listening on %s: %w", path, err)
+				}
+				serve = func() error { return serveStream(ctx, ln, path, framer) }
+			}
+			// Stream listeners unlink their path on close, datagram sockets do not.
+			defer func() {
+				if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					toolutil.PrintWarning("Failed to remove socket %s: %v", path, err)
+				}
+			}()
+			if perm != 0 {
+				if err := os.Chmod(path, perm); err != nil {
+					return fmt.Errorf("error setting socket permissions: %w", err)
+				}
+			}
+
+			toolutil.PrintSuccess("Listening on Unix socket")
+			toolutil.PrintKeyValue("Path", path)
+			toolutil.PrintKeyValue("Network", network)
+			toolutil.PrintKeyValue("Framing", framer)
+			return serve()
+		},
+	}
+
+	cmd.Flags().StringVar(&network, "network", "unix", "Socket type: unix (stream) or unixgram (datagram)")
+	cmd.Flags().StringVar(&path, "path", defaultSocketPath, "Socket path to create")
+	cmd.Flags().StringVar(&socketMode, "socket-mode", "", "Octal permissions applied to the socket file (e.g. 0660); default follows the umask")
+	framing.AddFlags(cmd, &framingOpts)
+	cmd.Flags().IntVar(&framingOpts.MaxFrameSize, "max-frame-size", framing.DefaultMaxFrameSize, "Maximum size in bytes of a received frame (and of a unixgram datagram)")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// prepareSocketPath removes a stale socket left at path by a previous run. It refuses to remove
+// other kinds of files and sockets another process is still listening on.
+func prepareSocketPath(network, path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout(network, path, time.Second); err == nil {
+		conn.Close() //nolint:errcheck
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
+
+// serveStream accepts connections until ctx is done, printing the frames of each connection.
+func serveStream(ctx context.Context, ln net.Listener, path string, framer *framing.Framer) error {
+	var (
+		mu    sync.Mutex
+		conns = map[net.Conn]struct{}{}
+		wg    sync.WaitGroup
+		next  int
+	)
+	go func() {
+		<-ctx.Done()
+		ln.Close() //nolint:errcheck
+		mu.Lock()
+		for c := range conns {
+			c.Close() //nolint:errcheck
+		}
+		mu.Unlock()
+	}()
+	defer wg.Wait()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return fmt.Errorf("error accepting connection: %w", err)
+		}
+		mu.Lock()
+		if ctx.Err() != nil {
+			mu.Unlock()
+			conn.Close() //nolint:errcheck
+			continue
+		}
+		conns[conn] = struct{}{}
+		next++
+		// Stream clients are usually unnamed, so connections are told apart by their sequence number.
+		peer := fmt.Sprintf("#%d %s", next, peerName(conn.RemoteAddr()))
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close() //nolint:errcheck
+			}()
+			toolutil.PrintInfo("Connection %s opened", peer)
+			s := framer.NewScanner(conn)
+			index := 0
+			for s.Scan() {
+				index++
+				printFrame("unix", path, peer, framer, index, s.Bytes())
+			}
+			if err := s.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+				toolutil.PrintWarning("Connection %s ended: %v", peer, err)
+				return
+			}
+			toolutil.PrintInfo("Connection %s closed after %d frame(s)", peer, index)
+		}()
+	}
+}
+
+// serveDatagrams reads datagrams until ctx is done; each datagram holds one or more frames.
+func serveDatagrams(ctx context.Context, pc net.PacketConn, path string, framer *framing.Framer, maxSize int) error {
+	go func() {
+		<-ctx.Done()
+		pc.Close() //nolint:errcheck
+	}()
+
+	if maxSize <= 0 {
+		maxSize = framing.DefaultMaxFrameSize
+	}
+	buf := make([]byte, maxSize)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				toolutil.PrintInfo("Shutting down gracefully")
+				return nil
+			}
+			return fmt.Errorf("error reading datagram: %w", err)
+		}
+		peer := peerName(addr)
+		s := framer.NewScanner(bytes.NewReader(buf[:n]))
+		index := 0
+		for s.Scan() {
+			index++
+			printFrame("unixgram", path, peer, framer, index, s.Bytes())
+		}
+		if err := s.Err(); err != nil {
+			toolutil.PrintWarning("Malformed datagram from %s: %v", peer, err)
+		}
+	}
+}
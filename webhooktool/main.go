@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "webhooktool",
+		Short: "Signed webhook emitter and verifier",
+		Long:  "A simple webhook CLI that sends signed, retried webhooks with idempotency keys and runs an endpoint that verifies their signatures.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// signatureFlags adds the flags shared by send and serve to select the signature scheme.
+func signatureFlags(cmd *cobra.Command, scheme, secret, header *string) {
+	cmd.Flags().StringVar(scheme, "scheme", schemeHMAC, "Signature scheme: "+strings.Join(schemes, ", "))
+	cmd.Flags().StringVar(secret, "secret", "", "Shared signing secret (standard scheme: whsec_<base64> or raw)")
+	cmd.Flags().StringVar(header, "signature-header", "", "Signature header name (default: X-Signature, X-Hub-Signature-256 or Stripe-Signature by scheme)")
+}
+
+// idempotencyFlag adds --idempotency-header.
+func idempotencyFlag(cmd *cobra.Command, header *string) {
+	cmd.Flags().StringVar(header, "idempotency-header", "Idempotency-Key", "Header carrying the idempotency key (empty disables it)")
+}
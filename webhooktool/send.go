@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxBackoff caps the delay between delivery attempts, including delays requested with Retry-After.
+const maxBackoff = 30 * time.Second
+
+// delivery is a webhook to send; it is re-signed but otherwise unchanged on every attempt.
+type delivery struct {
+	Method         string
+	URL            string
+	Headers        map[string]string
+	Body           []byte
+	ContentType    string
+	ID             string // Standard Webhooks message ID
+	IdempotencyKey string
+}
+
+// retryPolicy controls redelivery of failed webhooks.
+type retryPolicy struct {
+	Retries int
+	Backoff time.Duration // delay before the first retry, doubled after each failure
+}
+
+// deliveryResult is the outcome of the last attempt.
+type deliveryResult struct {
+	Status   int
+	Attempts int
+	Body     []byte
+}
+
+// retryable reports whether a response status warrants another attempt.
+func retryable(status int) bool {
+	return status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+}
+
+// retryAfter parses a Retry-After header given in seconds; HTTP dates are ignored.
+func retryAfter(h http.Header) time.Duration {
+	secs, err := strconv.Atoi(h.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// deliver sends d, retrying network errors and 408, 429 and 5xx responses with exponential
+// backoff. Every attempt is signed anew so timestamped signatures stay fresh.
+func deliver(ctx context.Context, client *http.Client, s *signer, idempotencyHeader string, d delivery, policy retryPolicy) (deliveryResult, error) {
+	delay := policy.Backoff
+	var res deliveryResult
+	for attempt := 1; ; attempt++ {
+		res = deliveryResult{Attempts: attempt}
+		req, err := http.NewRequestWithContext(ctx, d.Method, d.URL, bytes.NewReader(d.Body))
+		if err != nil {
+			return res, err
+		}
+		for k, v := range d.Headers {
+			req.Header.Set(k, v)
+		}
+		if d.ContentType != "" {
+			req.Header.Set("Content-Type", d.ContentType)
+		}
+		if idempotencyHeader != "" && d.IdempotencyKey != "" {
+			req.Header.Set(idempotencyHeader, d.IdempotencyKey)
+		}
+		s.sign(req.Header, d.ID, d.Body, time.Now())
+
+		var wait time.Duration
+		resp, err := client.Do(req)
+		if err == nil {
+			res.Status = resp.StatusCode
+			res.Body, _ = io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close() //nolint:errcheck
+			if !retryable(resp.StatusCode) {
+				if resp.StatusCode >= 400 {
+					return res, fmt.Errorf("rejected with status %s", resp.Status)
+				}
+				return res, nil
+			}
+			err = fmt.Errorf("status %s", resp.Status)
+			wait = retryAfter(resp.Header)
+		}
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		if attempt > policy.Retries {
+			if attempt > 1 {
+				return res, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return res, err
+		}
+
+		wait = min(max(wait, delay), maxBackoff)
+		toolutil.PrintWarning("Attempt %d failed: %v; retrying in %s", attempt, err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return res, ctx.Err()
+		case <-timer.C:
+		}
+		delay = min(delay*2, maxBackoff)
+	}
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		url               string
+		method            string
+		scheme            string
+		secret            string
+		signatureHeader   string
+		idempotencyHeader string
+		idempotencyKey    string
+		policy            retryPolicy
+		requestTimeout    time.Duration
+		sendPayload       string
+		sendMIME          string
+		sendInterval      string
+		headers           []string
+		noHeaderBase64    bool
+		openDelim         string
+		closeDelim        string
+		seed              int64
+		seedPerMessage    bool
+		allowFileReads    bool
+		allowEnvReads     bool
+		execOptions       toolutil.ExecOptions
+		payloadURL        string
+		strictTemplate    bool
+		templateVars      []string
+		fileRoot          string
+		cacheFiles        bool
+		dataSource        toolutil.DataSourceOptions
+		payloadSource     toolutil.PayloadSourceOptions
+		templateEngine    string
+		cloudEvents       toolutil.CloudEventsOptions
+		protoset          string
+		payloadSchema     string
+		corruptRate       float64
+		once              bool
+		printPayload      bool
+		interactive       bool
+		connectTimeout    time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic signed webhooks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := newSigner(scheme, secret, signatureHeader)
+			if err != nil {
+				return err
+			}
+			if policy.Retries < 0 {
+				return fmt.Errorf("--retries must not be negative")
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			toolutil.SetHeaderBase64(!noHeaderBase64)
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			headerMap, err := toolutil.ParseHeadersWithDelimiters(headers, openDelim, closeDelim)
+			if err != nil {
+				return fmt.Errorf("invalid headers: %w", err)
+			}
+			client := &http.Client{
+				Timeout:   requestTimeout,
+				Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, DialContext: (&net.Dialer{Timeout: connectTimeout}).DialContext},
+			}
+
+			toolutil.PrintSuccess("Starting webhook sender")
+			toolutil.PrintKeyValue("URL", url)
+			toolutil.PrintKeyValue("Scheme", scheme)
+			toolutil.PrintKeyValue("Signature Header", s.header)
+			toolutil.PrintKeyValue("Retries", policy.Retries)
+
+			keyDest := toolutil.NewDestination(idempotencyKey, openDelim, closeDelim)
+			send := func() error {
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				d := delivery{Method: method, URL: url, Headers: headerMap, Body: body, ContentType: ct}
+				// The Standard Webhooks message ID doubles as the idempotency key.
+				d.ID = "msg_" + uuid.NewString()
+				if idempotencyHeader != "" {
+					d.IdempotencyKey = uuid.NewString()
+					if idempotencyKey != "" {
+						if d.IdempotencyKey, err = keyDest.Resolve(); err != nil {
+							toolutil.PrintError("Idempotency key build error: %v", err)
+							return err
+						}
+					}
+					d.ID = d.IdempotencyKey
+				}
+
+				res, err := deliver(ctx, client, s, idempotencyHeader, d, policy)
+				if err != nil {
+					toolutil.PrintError("Delivery failed: %v", err)
+					return err
+				}
+				if d.IdempotencyKey != "" {
+					toolutil.PrintInfo("Delivered %d bytes to %s: %d %s (attempt %d, key %s)", len(body), url, res.Status, http.StatusText(res.Status), res.Attempts, d.IdempotencyKey)
+				} else {
+					toolutil.PrintInfo("Delivered %d bytes to %s: %d %s (attempt %d)", len(body), url, res.Status, http.StatusText(res.Status), res.Attempts)
+				}
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&url, "url", "http://localhost:9090/webhook", "Webhook endpoint URL")
+	toolutil.AddMethodFlag(cmd, &method, "POST", "")
+	signatureFlags(cmd, &scheme, &secret, &signatureHeader)
+	idempotencyFlag(cmd, &idempotencyHeader)
+	cmd.Flags().StringVar(&idempotencyKey, "idempotency-key", "", "Idempotency key, kept across the retries of a webhook (default: a random UUID per webhook; supports template placeholders)")
+	cmd.Flags().IntVar(&policy.Retries, "retries", 3, "Redeliveries after network errors and 408, 429 or 5xx responses")
+	cmd.Flags().DurationVar(&policy.Backoff, "retry-backoff", time.Second, "Delay before the first redelivery (doubles after each attempt, up to 30s; Retry-After is honored)")
+	cmd.Flags().DurationVar(&requestTimeout, "request-timeout", 10*time.Second, "Timeout of each delivery attempt")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, `{"event":"ping","count":{{counter}},"time":"{{nowtime}}"}`, &sendMIME, toolutil.CTJSON)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddHeadersFlag(cmd, &headers)
+	toolutil.AddNoHeaderBase64Flag(cmd, &noHeaderBase64)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
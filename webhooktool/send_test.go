@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeliverRetriesUntilAccepted(t *testing.T) {
+	s, _ := newSigner(schemeStripe, "s3cret", "")
+	h := newHandler(s, time.Minute, true, "Idempotency-Key", 2)
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		mu.Unlock()
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	d := delivery{Method: http.MethodPost, URL: srv.URL, Body: []byte(`{"a":1}`), ContentType: "application/json", IdempotencyKey: "key-1"}
+	res, err := deliver(context.Background(), srv.Client(), s, "Idempotency-Key", d, retryPolicy{Retries: 3, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("deliver() error: %v", err)
+	}
+	if res.Status != http.StatusOK || res.Attempts != 3 {
+		t.Errorf("deliver() = status %d after %d attempts, want 200 after 3", res.Status, res.Attempts)
+	}
+	if strings.Join(keys, ",") != "key-1,key-1,key-1" {
+		t.Errorf("idempotency keys = %v, want the same key on every attempt", keys)
+	}
+
+	// A fresh key fails twice more; with a single retry the sender gives up.
+	d.IdempotencyKey = "key-2"
+	res, err = deliver(context.Background(), srv.Client(), s, "Idempotency-Key", d, retryPolicy{Retries: 1, Backoff: time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "giving up after 2 attempts") || res.Status != http.StatusServiceUnavailable {
+		t.Errorf("deliver() = %+v, %v", res, err)
+	}
+}
+
+func TestHandlerFailFirstWithoutIdempotencyKey(t *testing.T) {
+	s, _ := newSigner(schemeGitHub, "s3cret", "")
+	h := newHandler(s, 0, false, "Idempotency-Key", 1)
+	status := func(header, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a":1}`))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Webhooks without any key are never failed.
+	for range 2 {
+		if got := status("", ""); got != http.StatusOK {
+			t.Errorf("unkeyed delivery status = %d, want 200", got)
+		}
+	}
+	// GitHub deliveries are recognized by X-GitHub-Delivery.
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusOK} {
+		if got := status("X-GitHub-Delivery", "d-1"); got != want {
+			t.Errorf("X-GitHub-Delivery delivery status = %d, want %d", got, want)
+		}
+	}
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	good, _ := newSigner(schemeGitHub, "right", "")
+	bad, _ := newSigner(schemeGitHub, "wrong", "")
+	srv := httptest.NewServer(newHandler(good, 0, true, "", 0))
+	defer srv.Close()
+
+	d := delivery{Method: http.MethodPost, URL: srv.URL, Body: []byte("x")}
+	res, err := deliver(context.Background(), srv.Client(), bad, "", d, retryPolicy{Retries: 3, Backoff: time.Millisecond})
+	if err == nil || res.Status != http.StatusUnauthorized || res.Attempts != 1 {
+		t.Errorf("deliver() = %+v, %v; want a single rejected attempt", res, err)
+	}
+	if res, err := deliver(context.Background(), srv.Client(), good, "", d, retryPolicy{}); err != nil || res.Status != http.StatusOK {
+		t.Errorf("deliver(valid signature) = %+v, %v", res, err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for value, want := range map[string]time.Duration{"": 0, "3": 3 * time.Second, "-1": 0, "Wed, 21 Oct 2015 07:28:00 GMT": 0} {
+		h := http.Header{}
+		h.Set("Retry-After", value)
+		if got := retryAfter(h); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestHandlerTrackEvictsOldestKeys(t *testing.T) {
+	h := newHandler(nil, 0, false, "Idempotency-Key", 0)
+	h.track("first")
+	for i := range maxTrackedKeys {
+		h.track(strconv.Itoa(i))
+	}
+	if n := h.track("first"); n != 1 {
+		t.Errorf("track(first) = %d after eviction, want 1", n)
+	}
+	if len(h.seen) != maxTrackedKeys {
+		t.Errorf("tracked %d keys, want %d", len(h.seen), maxTrackedKeys)
+	}
+}
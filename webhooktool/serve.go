@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+// maxWebhookSize bounds request bodies.
+const maxWebhookSize = 10 << 20
+
+// maxTrackedKeys bounds the idempotency keys remembered for duplicate detection; the oldest
+// keys are forgotten first.
+const maxTrackedKeys = 10000
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr         string
+		servePath         string
+		scheme            string
+		secret            string
+		signatureHeader   string
+		idempotencyHeader string
+		tolerance         time.Duration
+		rejectInvalid     bool
+		failFirst         int
+		serveOpts         toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a webhook endpoint that verifies signatures and logs deliveries",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := newSigner(scheme, secret, signatureHeader)
+			if err != nil {
+				return err
+			}
+			if failFirst < 0 {
+				return fmt.Errorf("--fail-first must not be negative")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			h := newHandler(s, tolerance, rejectInvalid, idempotencyHeader, failFirst)
+			mux := http.NewServeMux()
+			mux.Handle(servePath, h)
+			srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("Webhook endpoint listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Path", servePath)
+			toolutil.PrintKeyValue("Scheme", scheme)
+			toolutil.PrintKeyValue("Reject Invalid", rejectInvalid)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving webhook endpoint: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:9090", "Listen address")
+	cmd.Flags().StringVar(&servePath, "path", "/", "HTTP path pattern of the endpoint")
+	signatureFlags(cmd, &scheme, &secret, &signatureHeader)
+	idempotencyFlag(cmd, &idempotencyHeader)
+	cmd.Flags().DurationVar(&tolerance, "tolerance", 5*time.Minute, "Maximum signature age for the stripe and standard schemes (0 disables the check)")
+	cmd.Flags().BoolVar(&rejectInvalid, "reject-invalid", false, "Answer 401 to webhooks with an invalid signature instead of accepting and reporting them")
+	cmd.Flags().IntVar(&failFirst, "fail-first", 0, "Answer 503 to the first N deliveries of each idempotency key (or X-GitHub-Delivery / webhook-id delivery ID), to exercise sender retries")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// handler verifies and logs incoming webhooks.
+type handler struct {
+	signer            *signer
+	tolerance         time.Duration
+	rejectInvalid     bool
+	idempotencyHeader string
+	failFirst         int
+
+	mu    sync.Mutex
+	seen  map[string]int // deliveries per idempotency key
+	order []string       // keys in first-seen order, for eviction
+}
+
+func newHandler(s *signer, tolerance time.Duration, rejectInvalid bool, idempotencyHeader string, failFirst int) *handler {
+	return &handler{
+		signer:            s,
+		tolerance:         tolerance,
+		rejectInvalid:     rejectInvalid,
+		idempotencyHeader: idempotencyHeader,
+		failFirst:         failFirst,
+		seen:              make(map[string]int),
+	}
+}
+
+// track records a delivery of key and returns how many deliveries of it were seen, this one included.
+func (h *handler) track(key string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.seen[key]; !ok {
+		if len(h.order) >= maxTrackedKeys {
+			delete(h.seen, h.order[0])
+			h.order = h.order[1:]
+		}
+		h.order = append(h.order, key)
+	}
+	h.seen[key]++
+	return h.seen[key]
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	v := h.signer.verify(r.Header, body, time.Now(), h.tolerance)
+	sig := []toolutil.KV{{Key: "Scheme", Value: h.signer.scheme}}
+	if v.Err == nil {
+		sig = append(sig, toolutil.KV{Key: "Result", Value: "valid"})
+	} else {
+		sig = append(sig, toolutil.KV{Key: "Result", Value: "invalid: " + v.Err.Error()})
+	}
+	if !v.Timestamp.IsZero() {
+		sig = append(sig, toolutil.KV{Key: "Signed At", Value: v.Timestamp.UTC().Format(time.RFC3339)})
+	}
+
+	// Without an idempotency key the delivery ID of the scheme identifies redeliveries, and
+	// unidentified webhooks are never failed by --fail-first.
+	var idem []toolutil.KV
+	deliveries := 0
+	key := ""
+	if h.idempotencyHeader != "" {
+		key = r.Header.Get(h.idempotencyHeader)
+		if key == "" && h.signer.deliveryHeader() != "" {
+			key = r.Header.Get(h.signer.deliveryHeader())
+		}
+	}
+	if key != "" {
+		deliveries = h.track(key)
+		idem = []toolutil.KV{
+			{Key: "Key", Value: key},
+			{Key: "Delivery", Value: strconv.Itoa(deliveries)},
+		}
+		if deliveries > 1 {
+			idem = append(idem, toolutil.KV{Key: "Duplicate", Value: "true"})
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case deliveries > 0 && deliveries <= h.failFirst:
+		status = http.StatusServiceUnavailable
+	case v.Err != nil && h.rejectInvalid:
+		status = http.StatusUnauthorized
+	}
+	w.WriteHeader(status)
+
+	var headerItems []toolutil.KV
+	for _, k := range slices.Sorted(maps.Keys(r.Header)) {
+		for _, value := range r.Header[k] {
+			headerItems = append(headerItems, toolutil.KV{Key: k, Value: value})
+		}
+	}
+	sections := []toolutil.MessageSection{
+		{Title: "Request", Items: []toolutil.KV{
+			{Key: "Method", Value: r.Method},
+			{Key: "URI", Value: r.RequestURI},
+			{Key: "Remote", Value: r.RemoteAddr},
+		}},
+		{Title: "Signature", Items: sig},
+		{Title: "Idempotency", Items: idem},
+		{Title: "Response", Items: []toolutil.KV{{Key: "Status", Value: fmt.Sprintf("%d %s", status, http.StatusText(status))}}},
+		toolutil.HeadersSection(headerItems),
+	}
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		ct = toolutil.GuessMIME(body)
+	}
+	toolutil.PrintColoredMessage("Webhook", sections, body, ct)
+}
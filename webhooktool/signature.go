@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature schemes.
+const (
+	schemeHMAC     = "hmac-sha256" // hex HMAC-SHA256 of the body in a configurable header
+	schemeGitHub   = "github"      // X-Hub-Signature-256: sha256=<hex HMAC of the body>
+	schemeStripe   = "stripe"      // Stripe-Signature: t=<unix>,v1=<hex HMAC of "t.body">
+	schemeStandard = "standard"    // Standard Webhooks: webhook-id/-timestamp/-signature, v1,<base64 HMAC of "id.t.body">
+)
+
+var schemes = []string{schemeHMAC, schemeGitHub, schemeStripe, schemeStandard}
+
+// Standard Webhooks headers.
+const (
+	headerWebhookID        = "Webhook-Id"
+	headerWebhookTimestamp = "Webhook-Timestamp"
+	headerWebhookSignature = "Webhook-Signature"
+)
+
+// signer signs outgoing webhooks and verifies incoming ones with one scheme.
+type signer struct {
+	scheme string
+	key    []byte
+	header string
+}
+
+// newSigner validates the scheme and derives the HMAC key from secret. A Standard Webhooks
+// secret with the whsec_ prefix is base64-decoded, as the specification requires.
+func newSigner(scheme, secret, header string) (*signer, error) {
+	if secret == "" {
+		return nil, errors.New("--secret must not be empty")
+	}
+	s := &signer{scheme: scheme, key: []byte(secret), header: header}
+	switch scheme {
+	case schemeHMAC:
+		if s.header == "" {
+			s.header = "X-Signature"
+		}
+	case schemeGitHub:
+		if s.header == "" {
+			s.header = "X-Hub-Signature-256"
+		}
+	case schemeStripe:
+		if s.header == "" {
+			s.header = "Stripe-Signature"
+		}
+	case schemeStandard:
+		if header != "" {
+			return nil, errors.New("--signature-header cannot be changed for the standard scheme")
+		}
+		s.header = headerWebhookSignature
+		if enc, ok := strings.CutPrefix(secret, "whsec_"); ok {
+			key, err := base64.StdEncoding.DecodeString(enc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid whsec_ secret: %w", err)
+			}
+			s.key = key
+		}
+	default:
+		return nil, fmt.Errorf("invalid --scheme %q: expected %s", scheme, strings.Join(schemes, ", "))
+	}
+	return s, nil
+}
+
+// deliveryHeader returns the header in which the scheme carries a delivery ID that stays the
+// same across redeliveries, empty when it has none.
+func (s *signer) deliveryHeader() string {
+	switch s.scheme {
+	case schemeGitHub:
+		return "X-GitHub-Delivery"
+	case schemeStandard:
+		return headerWebhookID
+	}
+	return ""
+}
+
+func (s *signer) mac(parts ...[]byte) []byte {
+	m := hmac.New(sha256.New, s.key)
+	for _, p := range parts {
+		m.Write(p)
+	}
+	return m.Sum(nil)
+}
+
+// sign adds the signature headers for body. id is the message ID of the standard scheme,
+// now the signing time of the timestamped schemes.
+func (s *signer) sign(h http.Header, id string, body []byte, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	switch s.scheme {
+	case schemeHMAC:
+		h.Set(s.header, hex.EncodeToString(s.mac(body)))
+	case schemeGitHub:
+		h.Set(s.header, "sha256="+hex.EncodeToString(s.mac(body)))
+	case schemeStripe:
+		h.Set(s.header, "t="+ts+",v1="+hex.EncodeToString(s.mac([]byte(ts+"."), body)))
+	case schemeStandard:
+		h.Set(headerWebhookID, id)
+		h.Set(headerWebhookTimestamp, ts)
+		h.Set(headerWebhookSignature, "v1,"+base64.StdEncoding.EncodeToString(s.mac([]byte(id+"."+ts+"."), body)))
+	}
+}
+
+// verification is the outcome of checking an incoming signature.
+type verification struct {
+	// Timestamp is the signing time of the timestamped schemes.
+	Timestamp time.Time
+	// Err is nil when the signature is valid.
+	Err error
+}
+
+// verify checks the signature headers of an incoming webhook. Timestamped schemes reject
+// signatures older or newer than tolerance (0 disables the check).
+func (s *signer) verify(h http.Header, body []byte, now time.Time, tolerance time.Duration) verification {
+	value := h.Get(s.header)
+	if value == "" {
+		return verification{Err: fmt.Errorf("missing %s header", s.header)}
+	}
+
+	switch s.scheme {
+	case schemeHMAC, schemeGitHub:
+		hexSig := value
+		if s.scheme == schemeGitHub {
+			var ok bool
+			if hexSig, ok = strings.CutPrefix(value, "sha256="); !ok {
+				return verification{Err: errors.New("signature lacks the sha256= prefix")}
+			}
+		}
+		sig, err := hex.DecodeString(hexSig)
+		if err != nil {
+			return verification{Err: errors.New("signature is not hex")}
+		}
+		if !hmac.Equal(sig, s.mac(body)) {
+			return verification{Err: errors.New("signature mismatch")}
+		}
+		return verification{}
+
+	case schemeStripe:
+		var ts string
+		var sigs [][]byte
+		for _, item := range strings.Split(value, ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(item), "=")
+			switch k {
+			case "t":
+				ts = v
+			case "v1":
+				if sig, err := hex.DecodeString(v); err == nil {
+					sigs = append(sigs, sig)
+				}
+			}
+		}
+		if ts == "" || len(sigs) == 0 {
+			return verification{Err: errors.New("malformed signature header")}
+		}
+		return s.check(ts, now, tolerance, sigs, s.mac([]byte(ts+"."), body))
+
+	case schemeStandard:
+		id, ts := h.Get(headerWebhookID), h.Get(headerWebhookTimestamp)
+		if id == "" || ts == "" {
+			return verification{Err: fmt.Errorf("missing %s or %s header", headerWebhookID, headerWebhookTimestamp)}
+		}
+		// Several space-separated signatures may be sent during secret rotation.
+		var sigs [][]byte
+		for _, item := range strings.Fields(value) {
+			if enc, ok := strings.CutPrefix(item, "v1,"); ok {
+				if sig, err := base64.StdEncoding.DecodeString(enc); err == nil {
+					sigs = append(sigs, sig)
+				}
+			}
+		}
+		if len(sigs) == 0 {
+			return verification{Err: errors.New("no v1 signature")}
+		}
+		return s.check(ts, now, tolerance, sigs, s.mac([]byte(id+"."+ts+"."), body))
+	}
+	return verification{Err: fmt.Errorf("unsupported scheme %q", s.scheme)}
+}
+
+// check validates the timestamp ts and accepts the message if any of sigs equals want.
+func (s *signer) check(ts string, now time.Time, tolerance time.Duration, sigs [][]byte, want []byte) verification {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return verification{Err: fmt.Errorf("invalid timestamp %q", ts)}
+	}
+	v := verification{Timestamp: time.Unix(sec, 0)}
+	valid := false
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		v.Err = errors.New("signature mismatch")
+		return v
+	}
+	if age := now.Sub(v.Timestamp); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		v.Err = fmt.Errorf("timestamp outside the %s tolerance (age %s)", tolerance, age.Round(time.Second))
+	}
+	return v
+}
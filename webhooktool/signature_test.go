@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignatureVectors(t *testing.T) {
+	// Example from GitHub's webhook validation documentation.
+	s, err := newSigner(schemeGitHub, "It's a Secret to Everybody", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := http.Header{}
+	s.sign(h, "", []byte("Hello, World!"), time.Now())
+	if got := h.Get("X-Hub-Signature-256"); got != "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17" {
+		t.Errorf("github signature = %q", got)
+	}
+
+	// Example from the Standard Webhooks reference implementation tests.
+	s, err = newSigner(schemeStandard, "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = http.Header{}
+	s.sign(h, "msg_p5jXN8AQM9LWM0D4loKWxJek", []byte(`{"test": 2432232314}`), time.Unix(1614265330, 0))
+	if got := h.Get(headerWebhookSignature); got != "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=" {
+		t.Errorf("standard signature = %q", got)
+	}
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"ping"}`)
+	for _, scheme := range schemes {
+		t.Run(scheme, func(t *testing.T) {
+			s, err := newSigner(scheme, "s3cret", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := http.Header{}
+			s.sign(h, "msg_1", body, now)
+			if v := s.verify(h, body, now.Add(time.Minute), 5*time.Minute); v.Err != nil {
+				t.Errorf("verify() = %v", v.Err)
+			}
+			if v := s.verify(h, []byte(`{"event":"pong"}`), now, 0); v.Err == nil {
+				t.Error("verify() accepted a tampered body")
+			}
+			other, _ := newSigner(scheme, "other", "")
+			if v := other.verify(h, body, now, 0); v.Err == nil {
+				t.Error("verify() accepted a signature made with another secret")
+			}
+			if v := s.verify(http.Header{}, body, now, 0); v.Err == nil || !strings.Contains(v.Err.Error(), "missing") {
+				t.Errorf("verify(no headers) = %v", v.Err)
+			}
+
+			timestamped := scheme == schemeStripe || scheme == schemeStandard
+			v := s.verify(h, body, now.Add(time.Hour), 5*time.Minute)
+			if timestamped != (v.Err != nil) {
+				t.Errorf("verify(1h later) = %v, timestamped scheme: %v", v.Err, timestamped)
+			}
+			if timestamped && !v.Timestamp.Equal(now) {
+				t.Errorf("Timestamp = %v, want %v", v.Timestamp, now)
+			}
+		})
+	}
+}
+
+func TestSignatureHeaderOverride(t *testing.T) {
+	s, err := newSigner(schemeHMAC, "k", "X-Custom-Sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := http.Header{}
+	s.sign(h, "", []byte("x"), time.Now())
+	if h.Get("X-Custom-Sig") == "" || h.Get("X-Signature") != "" {
+		t.Errorf("headers = %v", h)
+	}
+	if _, err := newSigner(schemeStandard, "k", "X-Custom-Sig"); err == nil {
+		t.Error("newSigner() accepted a custom header for the standard scheme")
+	}
+	if _, err := newSigner("md5", "k", ""); err == nil {
+		t.Error("newSigner() accepted an unknown scheme")
+	}
+	if _, err := newSigner(schemeHMAC, "", ""); err == nil {
+		t.Error("newSigner() accepted an empty secret")
+	}
+}
+
+func TestStripeMultipleSignatures(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s, _ := newSigner(schemeStripe, "new", "")
+	old, _ := newSigner(schemeStripe, "old", "")
+	h, oh := http.Header{}, http.Header{}
+	s.sign(h, "", []byte("b"), now)
+	old.sign(oh, "", []byte("b"), now)
+	// During secret rotation Stripe sends one v1 entry per active secret.
+	_, newSig, _ := strings.Cut(h.Get("Stripe-Signature"), ",")
+	_, oldSig, _ := strings.Cut(oh.Get("Stripe-Signature"), ",")
+	h.Set("Stripe-Signature", "t=1700000000,"+oldSig+","+newSig)
+	if v := s.verify(h, []byte("b"), now, time.Minute); v.Err != nil {
+		t.Errorf("verify() = %v", v.Err)
+	}
+}
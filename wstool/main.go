@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"unicode/utf8"
+
+	"github.com/gorilla/websocket"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "wstool",
+		Short: "WebSocket client/server tester",
+		Long:  "A simple WebSocket CLI with send and serve commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// frameType picks the WebSocket frame type for a payload: text for valid UTF-8
+// non-CBOR content, binary otherwise (text frames must carry UTF-8).
+func frameType(body []byte, mime string) int {
+	if mime == toolutil.CTCBOR || !utf8.Valid(body) {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
+
+// frameTypeName returns a readable name for a WebSocket frame type.
+func frameTypeName(t int) string {
+	switch t {
+	case websocket.TextMessage:
+		return "text"
+	case websocket.BinaryMessage:
+		return "binary"
+	case websocket.PingMessage:
+		return "ping"
+	case websocket.PongMessage:
+		return "pong"
+	case websocket.CloseMessage:
+		return "close"
+	}
+	return "unknown"
+}
+
+// isClosedConnError reports whether err comes from using a connection we closed ourselves.
+func isClosedConnError(err error) bool {
+	return errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+)
+
+func TestFrameType(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		mime string
+		want int
+	}{
+		{"text", []byte("hello"), toolutil.CTText, websocket.TextMessage},
+		{"json", []byte(`{"a":1}`), toolutil.CTJSON, websocket.TextMessage},
+		{"cbor", []byte{0xa1, 0x61, 0x61, 0x01}, toolutil.CTCBOR, websocket.BinaryMessage},
+		{"invalid utf8", []byte{0xff, 0xfe}, toolutil.CTText, websocket.BinaryMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frameType(tt.body, tt.mime); got != tt.want {
+				t.Errorf("frameType() = %s, want %s", frameTypeName(got), frameTypeName(tt.want))
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.ndjson")
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{Output: toolutil.OutputJSON, OutputFile: out, AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+
+	srv := httptest.NewServer(newHandler(true))
+	defer srv.Close()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/events", nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	defer conn.Close() //nolint:errcheck
+
+	frames := []struct {
+		typ  int
+		data []byte
+	}{
+		{websocket.TextMessage, []byte(`{"a":1}`)},
+		{websocket.BinaryMessage, []byte{0x00, 0xff}},
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for _, f := range frames {
+		if err := conn.WriteMessage(f.typ, f.data); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+		if typ != f.typ || string(data) != string(f.data) {
+			t.Errorf("echo = %s %q, want %s %q", frameTypeName(typ), data, frameTypeName(f.typ), f.data)
+		}
+	}
+	cleanup()
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck
+	var events []toolutil.MessageEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev toolutil.MessageEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 2 {
+		t.Fatalf("printed %d messages, want 2", len(events))
+	}
+	for i, want := range []string{"text", "binary"} {
+		ev := events[i]
+		if ev.Title != "WebSocket" || len(ev.Sections) != 2 || ev.Sections[1].Items[0].Value != want {
+			t.Errorf("event %d = %+v, want a %s frame", i, ev, want)
+		}
+		if ev.Sections[0].Items[1].Value != "/events" {
+			t.Errorf("event %d path = %q, want /events", i, ev.Sections[0].Items[1].Value)
+		}
+	}
+	if body, ok := events[0].Body.(map[string]any); !ok || body["a"] != float64(1) {
+		t.Errorf("JSON frame body = %v", events[0].Body)
+	}
+	if events[1].BodyBase64 != "AP8=" {
+		t.Errorf("binary frame body_base64 = %q", events[1].BodyBase64)
+	}
+}
+
+func TestHandlerWithoutEcho(t *testing.T) {
+	cleanup, err := toolutil.SetupServe(&toolutil.ServeOptions{OutputFile: filepath.Join(t.TempDir(), "out.txt"), AssertCount: -1})
+	if err != nil {
+		t.Fatalf("SetupServe() error = %v", err)
+	}
+	defer func() { _, _ = toolutil.SetupServe(&toolutil.ServeOptions{AssertCount: -1}) }()
+	defer cleanup()
+
+	srv := httptest.NewServer(newHandler(false))
+	defer srv.Close()
+	rec := httptest.NewRecorder()
+	newHandler(false).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("plain GET status = %d, want 400", rec.Code)
+	}
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	defer conn.Close() //nolint:errcheck
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if _, data, err := conn.ReadMessage(); err == nil {
+		t.Errorf("unexpected echo %q without --echo", data)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for toolutil.ReceivedCount() != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := toolutil.ReceivedCount(); n != 1 {
+		t.Errorf("received %d messages, want 1", n)
+	}
+}
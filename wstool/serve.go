@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		serveAddr string
+		servePath string
+		echo      bool
+		serveOpts toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Accept WebSocket connections and log inbound frames",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			mux := http.NewServeMux()
+			mux.Handle(servePath, newHandler(echo))
+
+			srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+			errChan := make(chan error, 1)
+			go func() {
+				if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					errChan <- err
+				}
+			}()
+
+			toolutil.PrintSuccess("WebSocket server listening")
+			toolutil.PrintKeyValue("Address", serveAddr)
+			toolutil.PrintKeyValue("Path", servePath)
+
+			select {
+			case <-ctx.Done():
+				toolutil.PrintInfo("Shutting down gracefully")
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancelShutdown()
+				// Hijacked WebSocket connections are not tracked by Shutdown; they end with the process.
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					toolutil.PrintError("Failed to shut down server: %v", err)
+				}
+				return nil
+			case err := <-errChan:
+				return fmt.Errorf("error serving WebSocket: %w", err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "address", "0.0.0.0:8081", "Listen address")
+	cmd.Flags().StringVar(&servePath, "path", "/", "HTTP path accepting WebSocket upgrades")
+	cmd.Flags().BoolVar(&echo, "echo", false, "Echo every received frame back to the client")
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// handler upgrades requests to WebSocket and logs every inbound frame, echoing it back with
+// --echo.
+type handler struct {
+	upgrader websocket.Upgrader
+	echo     bool
+}
+
+func newHandler(echo bool) *handler {
+	return &handler{
+		upgrader: websocket.Upgrader{
+			// Accept any origin: this is a test endpoint, not a browser-facing service.
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		echo: echo,
+	}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		toolutil.PrintError("Upgrade failed from %s: %v", r.RemoteAddr, err)
+		return
+	}
+	defer conn.Close() //nolint:errcheck
+	toolutil.PrintInfo("Client connected: %s", r.RemoteAddr)
+
+	for {
+		t, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) || isClosedConnError(err) {
+				toolutil.PrintInfo("Client disconnected: %s", r.RemoteAddr)
+			} else {
+				toolutil.PrintWarning("Read error from %s: %v", r.RemoteAddr, err)
+			}
+			return
+		}
+		sections := []toolutil.MessageSection{
+			{Title: "Connection", Items: []toolutil.KV{
+				{Key: "Remote", Value: r.RemoteAddr},
+				{Key: "Path", Value: r.URL.Path},
+			}},
+			{Title: "Frame", Items: []toolutil.KV{{Key: "Type", Value: frameTypeName(t)}}},
+		}
+		toolutil.PrintColoredMessage("WebSocket", sections, data, toolutil.GuessMIME(data))
+		if h.echo {
+			if err := conn.WriteMessage(t, data); err != nil {
+				toolutil.PrintError("Echo failed: %v", err)
+				return
+			}
+		}
+	}
+}
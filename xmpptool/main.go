@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"github.com/xmppo/go-xmpp"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "xmpptool",
+		Short: "XMPP message and pubsub tester",
+		Long:  "A simple XMPP CLI that sends templated chat messages or publishes pubsub/PEP items, and logs in to print incoming messages, pubsub events and presences.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// xmppOptions configure the client login shared by both commands.
+type xmppOptions struct {
+	JID      string
+	Password string
+	Server   string
+	TLS      string
+	Insecure bool
+}
+
+func addXMPPFlags(cmd *cobra.Command, opts *xmppOptions) {
+	cmd.Flags().StringVar(&opts.JID, "jid", "test@localhost", "Account JID to log in with, optionally with a /resource")
+	cmd.Flags().StringVar(&opts.Password, "password", "", "Account password")
+	cmd.Flags().StringVar(&opts.Server, "server", "", "Server HOST:PORT (default: DNS SRV lookup of the JID domain, else DOMAIN:5222)")
+	cmd.Flags().StringVar(&opts.TLS, "tls", "starttls", "TLS mode: starttls, direct (implicit TLS, usually port 5223) or none (plaintext, test servers only)")
+	cmd.Flags().BoolVar(&opts.Insecure, "insecure", false, "Skip TLS certificate verification")
+}
+
+// split returns the bare JID and the resource of the --jid flag.
+func (o xmppOptions) split() (bare, resource string) {
+	bare, resource, _ = strings.Cut(o.JID, "/")
+	return bare, resource
+}
+
+func (o xmppOptions) validate() error {
+	bare, _ := o.split()
+	if local, domain, ok := strings.Cut(bare, "@"); !ok || local == "" || domain == "" {
+		return fmt.Errorf("invalid --jid %q: expected user@domain[/resource]", o.JID)
+	}
+	switch o.TLS {
+	case "starttls", "direct", "none":
+	default:
+		return fmt.Errorf("invalid --tls %q: expected starttls, direct or none", o.TLS)
+	}
+	return nil
+}
+
+// login logs in to the server. The TLS certificate is verified against the JID domain,
+// as XMPP servers present certificates for the domains they host.
+func login(opts xmppOptions, timeout time.Duration) (*xmpp.Client, error) {
+	bare, resource := opts.split()
+	_, domain, _ := strings.Cut(bare, "@")
+	return xmpp.Options{
+		Host:                         opts.Server,
+		User:                         bare,
+		Password:                     opts.Password,
+		Resource:                     resource,
+		DialTimeout:                  timeout,
+		NoTLS:                        opts.TLS != "direct",
+		StartTLS:                     opts.TLS == "starttls",
+		InsecureAllowUnencryptedAuth: opts.TLS == "none",
+		TLSConfig:                    &tls.Config{ServerName: domain, InsecureSkipVerify: opts.Insecure}, //nolint:gosec // opt-in for test servers
+	}.NewClient()
+}
+
+// closeStream ends the stream and closes the connection once done reports that the receive
+// loop, the only reader of the connection, saw the server close its side. A server that does
+// not answer within timeout is left to the process exit, as Close would read concurrently.
+func closeStream(client *xmpp.Client, done <-chan struct{}, timeout time.Duration) {
+	if _, err := client.SendOrg("</stream:stream>"); err != nil {
+		return
+	}
+	select {
+	case <-done:
+		client.Close() //nolint:errcheck
+	case <-time.After(timeout):
+	}
+}
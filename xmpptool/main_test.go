@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/xmppo/go-xmpp"
+)
+
+// stanza is a top-level element sent by the client.
+type stanza struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+func (s stanza) attr(name string) string {
+	for _, a := range s.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// fakeServer accepts a single plaintext PLAIN login, then forwards the client stanzas to
+// stanzas and lets the test write raw stanzas back.
+type fakeServer struct {
+	addr    string
+	stanzas chan stanza
+	conns   chan net.Conn
+}
+
+func startServer(t *testing.T) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() }) //nolint:errcheck
+	s := &fakeServer{addr: ln.Addr().String(), stanzas: make(chan stanza, 16), conns: make(chan net.Conn, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	d := xml.NewDecoder(conn)
+	header := "<?xml version='1.0'?><stream:stream xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' id='1' from='localhost' version='1.0'>"
+	// streamStart waits for the client stream header, skipping the XML declaration.
+	streamStart := func() error {
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return err
+			}
+			if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "stream" {
+				return nil
+			}
+		}
+	}
+	next := func() (stanza, error) {
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return stanza{}, err
+			}
+			if ee, ok := tok.(xml.EndElement); ok && ee.Name.Local == "stream" {
+				fmt.Fprint(conn, "</stream:stream>")
+				conn.Close() //nolint:errcheck
+				return stanza{}, io.EOF
+			}
+			if se, ok := tok.(xml.StartElement); ok {
+				var st stanza
+				err := d.DecodeElement(&st, &se)
+				return st, err
+			}
+		}
+	}
+
+	if streamStart() != nil {
+		return
+	}
+	fmt.Fprint(conn, header+"<stream:features><mechanisms xmlns='urn:ietf:params:xml:ns:xmpp-sasl'><mechanism>PLAIN</mechanism></mechanisms></stream:features>")
+	if auth, err := next(); err != nil || auth.XMLName.Local != "auth" {
+		return
+	}
+	fmt.Fprint(conn, "<success xmlns='urn:ietf:params:xml:ns:xmpp-sasl'/>")
+	if streamStart() != nil {
+		return
+	}
+	fmt.Fprint(conn, header+"<stream:features><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'/></stream:features>")
+	bind, err := next()
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(conn, "<iq type='result' id='%s'><bind xmlns='urn:ietf:params:xml:ns:xmpp-bind'><jid>test@localhost/eventkit</jid></bind></iq>", bind.attr("id"))
+	s.conns <- conn
+	for {
+		st, err := next()
+		if err != nil {
+			close(s.stanzas)
+			return
+		}
+		s.stanzas <- st
+	}
+}
+
+func (s *fakeServer) next(t *testing.T) stanza {
+	t.Helper()
+	select {
+	case st, ok := <-s.stanzas:
+		if !ok {
+			t.Fatal("connection closed")
+		}
+		return st
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stanza")
+	}
+	return stanza{}
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []struct {
+		opts xmppOptions
+		ok   bool
+	}{
+		{xmppOptions{JID: "test@localhost", TLS: "starttls"}, true},
+		{xmppOptions{JID: "test@localhost/res", TLS: "none"}, true},
+		{xmppOptions{JID: "localhost", TLS: "starttls"}, false},
+		{xmppOptions{JID: "@localhost", TLS: "direct"}, false},
+		{xmppOptions{JID: "test@localhost", TLS: "ssl"}, false},
+	} {
+		if err := c.opts.validate(); (err == nil) != c.ok {
+			t.Errorf("validate(%+v) = %v", c.opts, err)
+		}
+	}
+}
+
+func TestItemPayload(t *testing.T) {
+	for _, c := range []struct {
+		body, ct, want string
+	}{
+		{" <event xmlns='urn:x'>1</event>\n", toolutil.CTText, "<event xmlns='urn:x'>1</event>"},
+		{`{"a":"<b>"}`, toolutil.CTJSON, `<json xmlns='urn:xmpp:json:0'>{&#34;a&#34;:&#34;&lt;b&gt;&#34;}</json>`},
+		{"<a/><b/>", toolutil.CTText, "<payload xmlns='urn:eventkit:payload'>&lt;a/&gt;&lt;b/&gt;</payload>"},
+		{"hello", toolutil.CTText, "<payload xmlns='urn:eventkit:payload'>hello</payload>"},
+	} {
+		if got := itemPayload([]byte(c.body), c.ct); got != c.want {
+			t.Errorf("itemPayload(%q) = %q, want %q", c.body, got, c.want)
+		}
+	}
+	if got := publishStanza("a'b", "1", "<x/>"); !strings.Contains(got, "<publish node='a&#39;b'><item id='1'><x/></item></publish>") {
+		t.Errorf("publishStanza() = %q", got)
+	}
+}
+
+func TestSendAndReceive(t *testing.T) {
+	t.Setenv("NO_PROXY", "*")
+	srv := startServer(t)
+	client, err := login(xmppOptions{JID: "test@localhost/eventkit", Password: "secret", Server: srv.addr, TLS: "none"}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("login() error: %v", err)
+	}
+	done := make(chan struct{})
+	defer closeStream(client, done, time.Second)
+	if client.JID() != "test@localhost/eventkit" {
+		t.Errorf("JID() = %q", client.JID())
+	}
+	if st := srv.next(t); st.XMLName.Local != "presence" {
+		t.Errorf("first stanza = %s, want the initial presence", st.XMLName.Local)
+	}
+
+	if _, err := client.Send(xmpp.Chat{Remote: "peer@localhost", Type: "headline", Subject: "s", Text: "hi <there>"}); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	msg := srv.next(t)
+	if msg.XMLName.Local != "message" || msg.attr("to") != "peer@localhost" || msg.attr("type") != "headline" || !strings.Contains(msg.Inner, "hi &lt;there&gt;") {
+		t.Errorf("message = %+v", msg)
+	}
+
+	if _, err := client.RawInformation(client.JID(), "test@localhost", "i1", "set", publishStanza("events", "i1", itemPayload([]byte("x"), toolutil.CTText))); err != nil {
+		t.Fatalf("RawInformation() error: %v", err)
+	}
+	iq := srv.next(t)
+	if iq.XMLName.Local != "iq" || iq.attr("to") != "test@localhost" || !strings.Contains(iq.Inner, "<publish node='events'><item id='i1'>") {
+		t.Errorf("publish iq = %+v", iq)
+	}
+
+	conn := <-srv.conns
+	fmt.Fprint(conn, "<message from='peer@localhost/r' type='chat'><body>{&quot;a&quot;:1}</body></message>")
+	fmt.Fprint(conn, "<message from='pubsub.localhost'><event xmlns='http://jabber.org/protocol/pubsub#event'><items node='events'><item id='i2'><entry xmlns='urn:x'>v</entry></item></items></event></message>")
+	var got []interface{}
+	for len(got) < 2 {
+		st, err := client.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error: %v", err)
+		}
+		if printStanza(st, false) {
+			got = append(got, st)
+		}
+	}
+	go func() {
+		defer close(done)
+		for {
+			if _, err := client.Recv(); err != nil {
+				return
+			}
+		}
+	}()
+	if chat, ok := got[0].(xmpp.Chat); !ok || chat.Remote != "peer@localhost/r" || chat.Text != `{"a":1}` {
+		t.Errorf("first stanza = %#v", got[0])
+	}
+	if ev, ok := got[1].(xmpp.PubsubEvent); !ok || ev.Node != "events" || len(ev.Items) != 1 || ev.Items[0].ID != "i2" || !strings.Contains(string(ev.Items[0].InnerXML), "<entry") {
+		t.Errorf("second stanza = %#v", got[1])
+	}
+}
+
+func TestPrintStanzaFilters(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		stanza   interface{}
+		presence bool
+		want     bool
+	}{
+		{"roster", xmpp.Chat{Type: "roster"}, false, false},
+		{"chat state", xmpp.Chat{Remote: "a@b", Type: "chat"}, false, false},
+		{"presence", xmpp.Presence{From: "a@b"}, false, false},
+		{"presence enabled", xmpp.Presence{From: "a@b"}, true, true},
+		{"iq error", xmpp.IQ{ID: "1", Type: "error"}, false, false},
+	} {
+		if got := printStanza(c.stanza, c.presence); got != c.want {
+			t.Errorf("%s: printStanza() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
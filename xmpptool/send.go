@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"github.com/xmppo/go-xmpp"
+)
+
+// messageTypes are the accepted message stanza types.
+var messageTypes = []string{"chat", "normal", "headline", "groupchat"}
+
+// isXML reports whether payload is a single well-formed XML element.
+func isXML(payload []byte) bool {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '<' {
+		return false
+	}
+	d := xml.NewDecoder(bytes.NewReader(trimmed))
+	var el struct {
+		XMLName xml.Name
+	}
+	if err := d.Decode(&el); err != nil {
+		return false
+	}
+	_, err := d.Token()
+	return errors.Is(err, io.EOF)
+}
+
+// itemPayload returns the XML element published as a pubsub item: XML payloads as-is, JSON
+// in an XEP-0335 <json/> container and anything else as escaped text.
+func itemPayload(body []byte, contentType string) string {
+	if isXML(body) {
+		return string(bytes.TrimSpace(body))
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, body) //nolint:errcheck // strings.Builder never fails
+	if contentType == toolutil.CTJSON {
+		return "<json xmlns='urn:xmpp:json:0'>" + escaped.String() + "</json>"
+	}
+	return "<payload xmlns='urn:eventkit:payload'>" + escaped.String() + "</payload>"
+}
+
+// publishStanza builds the XEP-0060 publish request of an item to node.
+func publishStanza(node, itemID, payload string) string {
+	var n, id strings.Builder
+	xml.EscapeText(&n, []byte(node))    //nolint:errcheck
+	xml.EscapeText(&id, []byte(itemID)) //nolint:errcheck
+	return fmt.Sprintf("<pubsub xmlns='%s'><publish node='%s'><item id='%s'>%s</item></publish></pubsub>",
+		xmpp.XMPPNS_PUBSUB, n.String(), id.String(), payload)
+}
+
+func sendCommand() *cobra.Command {
+	var (
+		opts           xmppOptions
+		to             string
+		msgType        string
+		subject        string
+		node           string
+		nick           string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic templated messages or publish pubsub items",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			validType := false
+			for _, t := range messageTypes {
+				validType = validType || msgType == t
+			}
+			if !validType {
+				return fmt.Errorf("invalid --type %q: expected %s", msgType, strings.Join(messageTypes, ", "))
+			}
+			if node == "" && to == "" {
+				return fmt.Errorf("--to is required unless publishing to a --node")
+			}
+			if node != "" && (msgType == "groupchat" || subject != "") {
+				return fmt.Errorf("--type groupchat and --subject do not apply to pubsub items")
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var client *xmpp.Client
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+					c, err := login(opts, connectTimeout)
+					if err != nil {
+						return err
+					}
+					client = c
+					return nil
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error connecting to XMPP server: %w", err)
+			}
+
+			toolutil.PrintSuccess("Connected to XMPP server")
+			toolutil.PrintKeyValue("JID", client.JID())
+			if node != "" {
+				toolutil.PrintKeyValue("Node", node)
+			}
+			if to != "" {
+				toolutil.PrintKeyValue("To", to)
+			}
+
+			// Drain incoming stanzas, so the server never blocks on a full connection, and
+			// report the errors bounced for sent stanzas.
+			done := make(chan struct{})
+			defer closeStream(client, done, time.Second)
+			go func() {
+				defer close(done)
+				for {
+					stanza, err := client.Recv()
+					if err != nil {
+						return
+					}
+					switch v := stanza.(type) {
+					case xmpp.IQ:
+						if v.Type == "error" {
+							toolutil.PrintError("Request %s failed: %s", v.ID, v.Query)
+						}
+					case xmpp.Chat:
+						if v.Type == "error" {
+							toolutil.PrintError("Message to %s bounced", v.Remote)
+						}
+					}
+				}
+			}()
+
+			bare, _ := opts.split()
+			joined := map[string]bool{}
+			dest := toolutil.NewDestination(to, openDelim, closeDelim)
+			send := func() error {
+				body, ct, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				target, err := dest.Resolve()
+				if err != nil {
+					toolutil.PrintError("Destination build error: %v", err)
+					return err
+				}
+
+				if node != "" {
+					// Without --to, items go to the personal eventing (PEP) node of the account.
+					service := target
+					if service == "" {
+						service = bare
+					}
+					itemID := strconv.FormatInt(time.Now().UnixNano(), 36)
+					if _, err := client.RawInformation(client.JID(), service, itemID, "set", publishStanza(node, itemID, itemPayload(body, ct))); err != nil {
+						toolutil.PrintError("Publish error: %v", err)
+						return err
+					}
+					toolutil.PrintInfo("Published item %s to %s node %s (%d bytes)", itemID, service, node, len(body))
+					return nil
+				}
+
+				if msgType == "groupchat" && !joined[target] {
+					if _, err := client.JoinMUCNoHistory(target, nick); err != nil {
+						toolutil.PrintError("Join error: %v", err)
+						return err
+					}
+					joined[target] = true
+				}
+				if _, err := client.Send(xmpp.Chat{Remote: target, Type: msgType, Subject: subject, Text: string(body)}); err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Sent %s message to %s (%d bytes)", msgType, target, len(body))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	addXMPPFlags(cmd, &opts)
+	cmd.Flags().StringVar(&to, "to", "", "Recipient JID or MUC room, or pubsub service with --node (default: the account PEP service); supports template placeholders")
+	cmd.Flags().StringVar(&msgType, "type", "chat", "Message type: "+strings.Join(messageTypes, ", ")+" (groupchat joins the --to room first)")
+	cmd.Flags().StringVar(&subject, "subject", "", "Message subject")
+	cmd.Flags().StringVar(&node, "node", "", "Publish items to this pubsub/PEP node instead of sending messages")
+	cmd.Flags().StringVar(&nick, "nick", "eventkit", "Nickname used to join MUC rooms")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, XMPP!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
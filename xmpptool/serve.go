@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+	"github.com/xmppo/go-xmpp"
+)
+
+// printStanza prints a received stanza; it reports whether the stanza was printed.
+func printStanza(stanza interface{}, presence bool) bool {
+	switch v := stanza.(type) {
+	case xmpp.Chat:
+		if v.Type == "roster" || (v.Text == "" && v.Subject == "") {
+			return false
+		}
+		items := []toolutil.KV{
+			{Key: "From", Value: v.Remote},
+			{Key: "Type", Value: v.Type},
+		}
+		for _, kv := range []toolutil.KV{{Key: "Subject", Value: v.Subject}, {Key: "Thread", Value: v.Thread}, {Key: "ID", Value: v.OriginID}} {
+			if kv.Value != "" {
+				items = append(items, kv)
+			}
+		}
+		if !v.Stamp.IsZero() {
+			items = append(items, toolutil.KV{Key: "Delayed", Value: v.Stamp.Format(time.RFC3339)})
+		}
+		toolutil.PrintColoredMessage("XMPP", []toolutil.MessageSection{{Title: "Message", Items: items}}, []byte(v.Text), toolutil.GuessMIME([]byte(v.Text)))
+	case xmpp.PubsubEvent:
+		for _, item := range v.Items {
+			items := []toolutil.KV{{Key: "Node", Value: v.Node}, {Key: "Item ID", Value: item.ID}}
+			toolutil.PrintColoredMessage("XMPP", []toolutil.MessageSection{{Title: "PubSub Event", Items: items}}, item.InnerXML, "application/xml")
+		}
+	case xmpp.Presence:
+		if !presence {
+			return false
+		}
+		items := []toolutil.KV{{Key: "From", Value: v.From}}
+		for _, kv := range []toolutil.KV{{Key: "Type", Value: v.Type}, {Key: "Show", Value: v.Show}, {Key: "Status", Value: v.Status}} {
+			if kv.Value != "" {
+				items = append(items, kv)
+			}
+		}
+		toolutil.PrintColoredMessage("XMPP", []toolutil.MessageSection{{Title: "Presence", Items: items}}, nil, toolutil.CTText)
+	case xmpp.PubsubSubscription:
+		if len(v.Errors) > 0 {
+			toolutil.PrintWarning("Subscription failed: %v", v.Errors)
+		} else {
+			toolutil.PrintInfo("Subscribed to node %s", v.Node)
+		}
+	case xmpp.IQ:
+		if v.Type == "error" {
+			toolutil.PrintWarning("Request %s failed: %s", v.ID, v.Query)
+		}
+		return false
+	default:
+		return false
+	}
+	return true
+}
+
+func serveCommand() *cobra.Command {
+	var (
+		opts           xmppOptions
+		rooms          []string
+		nick           string
+		nodes          []string
+		pubsubService  string
+		presence       bool
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Log in and print incoming messages, pubsub events and presences",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			if len(nodes) > 0 && pubsubService == "" {
+				return fmt.Errorf("--subscribe requires --pubsub-service")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var client *xmpp.Client
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(ctx context.Context) error {
+				c, err := login(opts, connectTimeout)
+				if err != nil {
+					return err
+				}
+				client = c
+				return nil
+			}); err != nil {
+				return fmt.Errorf("error connecting to XMPP server: %w", err)
+			}
+
+			for _, room := range rooms {
+				if _, err := client.JoinMUCNoHistory(room, nick); err != nil {
+					return fmt.Errorf("error joining %s: %w", room, err)
+				}
+			}
+			for _, node := range nodes {
+				if err := client.PubsubSubscribeNode(node, pubsubService); err != nil {
+					return fmt.Errorf("error subscribing to %s: %w", node, err)
+				}
+			}
+
+			toolutil.PrintSuccess("Logged in to XMPP server")
+			toolutil.PrintKeyValue("JID", client.JID())
+			if len(rooms) > 0 {
+				toolutil.PrintKeyValue("Rooms", rooms)
+			}
+			if len(nodes) > 0 {
+				toolutil.PrintKeyValue("Nodes", nodes)
+				toolutil.PrintKeyValue("PubSub Service", pubsubService)
+			}
+
+			stanzas := make(chan interface{})
+			errCh := make(chan error, 1)
+			done := make(chan struct{})
+			defer closeStream(client, done, time.Second)
+			go func() {
+				defer close(done)
+				for {
+					stanza, err := client.Recv()
+					if err != nil {
+						errCh <- err
+						return
+					}
+					select {
+					case stanzas <- stanza:
+					case <-ctx.Done():
+					}
+				}
+			}()
+
+			for {
+				select {
+				case <-ctx.Done():
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				case err := <-errCh:
+					if ctx.Err() != nil {
+						toolutil.PrintInfo("Shutting down gracefully")
+						return nil
+					}
+					return fmt.Errorf("connection closed: %w", err)
+				case stanza := <-stanzas:
+					printStanza(stanza, presence)
+				}
+			}
+		},
+	}
+
+	addXMPPFlags(cmd, &opts)
+	cmd.Flags().StringArrayVar(&rooms, "join", nil, "MUC room JID to join and print messages of (repeatable)")
+	cmd.Flags().StringVar(&nick, "nick", "eventkit", "Nickname used to join MUC rooms")
+	cmd.Flags().StringArrayVar(&nodes, "subscribe", nil, "Pubsub node to subscribe to on --pubsub-service (repeatable)")
+	cmd.Flags().StringVar(&pubsubService, "pubsub-service", "", "Pubsub service JID of --subscribe nodes, e.g. pubsub.example.com")
+	cmd.Flags().BoolVar(&presence, "presence", false, "Also print presence stanzas")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
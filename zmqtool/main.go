@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-zeromq/zmq4"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	root := &cobra.Command{
+		Use:   "zmqtool",
+		Short: "ZeroMQ client tester",
+		Long:  "A simple ZeroMQ CLI for PUB/SUB and PUSH/PULL sockets with send and serve commands.",
+	}
+
+	toolutil.AddGlobalFlags(root)
+	root.AddCommand(sendCommand(), serveCommand())
+
+	if err := root.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// openSocket creates a socket of the given pattern (pub, sub, push or pull) and binds it to
+// endpoint or connects it, depending on mode. The socket lives until ctx is cancelled or it is closed.
+func openSocket(ctx context.Context, pattern, mode, endpoint string, timeout time.Duration) (zmq4.Socket, error) {
+	// Connection retries are left to --wait-for-broker; a lost peer is reconnected automatically.
+	opts := []zmq4.Option{zmq4.WithDialerMaxRetries(0), zmq4.WithAutomaticReconnect(true)}
+	if timeout > 0 {
+		opts = append(opts, zmq4.WithDialerTimeout(timeout))
+	}
+	var sock zmq4.Socket
+	switch strings.ToLower(pattern) {
+	case "pub":
+		sock = zmq4.NewPub(ctx, opts...)
+	case "sub":
+		sock = zmq4.NewSub(ctx, opts...)
+	case "push":
+		sock = zmq4.NewPush(ctx, opts...)
+	case "pull":
+		sock = zmq4.NewPull(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported socket pattern %q", pattern)
+	}
+
+	var err error
+	switch strings.ToLower(mode) {
+	case "bind":
+		err = sock.Listen(endpoint)
+	case "connect":
+		err = sock.Dial(endpoint)
+	default:
+		err = fmt.Errorf("invalid --mode %q: expected bind or connect", mode)
+	}
+	if err != nil {
+		sock.Close() //nolint:errcheck
+		return nil, err
+	}
+	return sock, nil
+}
+
+// validateSocket checks the --pattern and --mode flags before anything is opened.
+func validateSocket(pattern string, patterns []string, mode string) error {
+	if !slices.Contains(patterns, strings.ToLower(pattern)) {
+		return fmt.Errorf("invalid --pattern %q: expected %s", pattern, strings.Join(patterns, " or "))
+	}
+	if m := strings.ToLower(mode); m != "bind" && m != "connect" {
+		return fmt.Errorf("invalid --mode %q: expected bind or connect", mode)
+	}
+	return nil
+}
+
+// formatFrame renders a frame as text when it is printable UTF-8, and base64-encoded otherwise.
+func formatFrame(b []byte) string {
+	if utf8.Valid(b) && strings.IndexFunc(string(b), func(r rune) bool {
+		return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+	}) < 0 {
+		return string(b)
+	}
+	return toolutil.HeaderBase64Prefix + base64.StdEncoding.EncodeToString(b)
+}
+
+// frameItems lists the leading frames of a multipart message, numbered from 1.
+func frameItems(frames [][]byte) []toolutil.KV {
+	items := make([]toolutil.KV, 0, len(frames))
+	for i, f := range frames {
+		items = append(items, toolutil.KV{Key: "#" + strconv.Itoa(i+1), Value: formatFrame(f)})
+	}
+	return items
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-zeromq/zmq4"
+)
+
+func TestFormatFrame(t *testing.T) {
+	tests := map[string]string{
+		"hello":              "hello",
+		"multi\nline":        "multi\nline",
+		"\x00\x01":           "base64:AAE=",
+		string([]byte{0xff}): "base64:/w==",
+	}
+	for in, want := range tests {
+		if got := formatFrame([]byte(in)); got != want {
+			t.Errorf("formatFrame(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFrameItems(t *testing.T) {
+	items := frameItems([][]byte{[]byte("a"), []byte("b")})
+	if len(items) != 2 || items[0].Key != "#1" || items[1].Value != "b" {
+		t.Errorf("frameItems() = %v", items)
+	}
+}
+
+func TestValidateSocket(t *testing.T) {
+	if err := validateSocket("PUB", []string{"pub", "push"}, "bind"); err != nil {
+		t.Errorf("validateSocket(PUB, bind): %v", err)
+	}
+	if err := validateSocket("sub", []string{"pub", "push"}, "bind"); err == nil {
+		t.Error("validateSocket(sub) for send succeeded, want error")
+	}
+	if err := validateSocket("pull", []string{"sub", "pull"}, "listen"); err == nil {
+		t.Error("validateSocket(mode listen) succeeded, want error")
+	}
+}
+
+func TestPushPullMultipart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pull, err := openSocket(ctx, "pull", "bind", "tcp://127.0.0.1:0", time.Second)
+	if err != nil {
+		t.Fatalf("bind pull: %v", err)
+	}
+	defer pull.Close()
+	push, err := openSocket(ctx, "push", "connect", "tcp://"+pull.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatalf("connect push: %v", err)
+	}
+	defer push.Close()
+
+	if err := push.SendMulti(zmq4.NewMsgFrom([]byte("key"), []byte("body"))); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	msg, err := pull.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if len(msg.Frames) != 2 || string(msg.Frames[0]) != "key" || string(msg.Frames[1]) != "body" {
+		t.Errorf("frames = %q", msg.Frames)
+	}
+}
+
+func TestConnectFailsWithoutPeer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := openSocket(ctx, "push", "connect", "tcp://127.0.0.1:1", time.Second); err == nil {
+		t.Error("connect to a closed port succeeded, want error")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-zeromq/zmq4"
+	"github.com/sandrolain/eventkit/pkg/common"
+	"github.com/sandrolain/eventkit/pkg/testpayload"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func sendCommand() *cobra.Command {
+	var (
+		pattern        string
+		mode           string
+		endpoint       string
+		sendTopic      string
+		extraFrames    []string
+		sendPayload    string
+		sendMIME       string
+		sendInterval   string
+		openDelim      string
+		closeDelim     string
+		seed           int64
+		seedPerMessage bool
+		allowFileReads bool
+		allowEnvReads  bool
+		execOptions    toolutil.ExecOptions
+		payloadURL     string
+		strictTemplate bool
+		templateVars   []string
+		fileRoot       string
+		cacheFiles     bool
+		dataSource     toolutil.DataSourceOptions
+		payloadSource  toolutil.PayloadSourceOptions
+		templateEngine string
+		cloudEvents    toolutil.CloudEventsOptions
+		protoset       string
+		payloadSchema  string
+		corruptRate    float64
+		once           bool
+		printPayload   bool
+		interactive    bool
+		connectTimeout time.Duration
+		connectRetry   toolutil.ConnectRetryOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send periodic messages from a PUB or PUSH socket",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateSocket(pattern, []string{"pub", "push"}, mode); err != nil {
+				return err
+			}
+
+			ctx, cancel := common.SetupGracefulShutdown()
+			defer cancel()
+
+			if seed != 0 {
+				testpayload.SeedRandom(seed)
+			}
+			testpayload.SetSeedPerMessage(seedPerMessage, seed)
+			testpayload.SetAllowFileReads(allowFileReads)
+			testpayload.SetAllowEnvReads(allowEnvReads)
+			if err := execOptions.Apply(); err != nil {
+				return err
+			}
+			if err := toolutil.ResolvePayloadURL(cmd, payloadURL, &sendPayload); err != nil {
+				return err
+			}
+			testpayload.SetStrictTemplates(strictTemplate)
+			testpayload.SetFileRoot(fileRoot)
+			testpayload.SetFileCacheEnabled(cacheFiles)
+			if err := dataSource.Load(); err != nil {
+				return err
+			}
+			if err := payloadSource.Load(&sendInterval); err != nil {
+				return err
+			}
+			if err := testpayload.SetTemplateEngine(templateEngine); err != nil {
+				return err
+			}
+			if err := cloudEvents.Apply(""); err != nil {
+				return err
+			}
+			if err := toolutil.LoadProtoset(protoset); err != nil {
+				return err
+			}
+			if err := toolutil.LoadPayloadSchema(payloadSchema); err != nil {
+				return err
+			}
+			if err := testpayload.SetCorruptRate(corruptRate); err != nil {
+				return err
+			}
+			varsMap, errVars := toolutil.ParseTemplateVars(templateVars)
+			if errVars != nil {
+				return fmt.Errorf("invalid template-var: %w", errVars)
+			}
+			testpayload.SetTemplateVars(varsMap)
+			if printPayload {
+				return toolutil.PrintPayload(sendPayload, sendMIME, openDelim, closeDelim)
+			}
+
+			var sock zmq4.Socket
+			connect := func() error {
+				return common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+					var err error
+					sock, err = openSocket(ctx, pattern, mode, endpoint, connectTimeout)
+					return err
+				})
+			}
+			if err := common.ConnectWithRetry(ctx, connectRetry.Policy(), connect); err != nil {
+				return fmt.Errorf("error opening ZeroMQ socket: %w", err)
+			}
+			defer sock.Close() //nolint:errcheck
+
+			toolutil.PrintSuccess("ZeroMQ socket ready")
+			toolutil.PrintKeyValue("Pattern", sock.Type())
+			toolutil.PrintKeyValue("Mode", mode)
+			toolutil.PrintKeyValue("Endpoint", endpoint)
+			if sendTopic != "" {
+				toolutil.PrintKeyValue("Topic", sendTopic)
+			}
+
+			topic := toolutil.NewDestination(sendTopic, openDelim, closeDelim)
+			send := func() error {
+				body, _, err := toolutil.BuildPayloadWithDelimiters(sendPayload, sendMIME, openDelim, closeDelim)
+				if err != nil {
+					toolutil.PrintError("Payload build error: %v", err)
+					return err
+				}
+				var frames [][]byte
+				if sendTopic != "" {
+					t, err := topic.Resolve()
+					if err != nil {
+						toolutil.PrintError("Topic build error: %v", err)
+						return err
+					}
+					frames = append(frames, []byte(t))
+				}
+				for _, raw := range extraFrames {
+					f, err := testpayload.InterpolateWithDelimiters(raw, openDelim, closeDelim)
+					if err != nil {
+						toolutil.PrintError("Frame build error: %v", err)
+						return err
+					}
+					frames = append(frames, f)
+				}
+				frames = append(frames, body)
+
+				msg := zmq4.NewMsgFrom(frames...)
+				if len(frames) > 1 {
+					err = sock.SendMulti(msg)
+				} else {
+					err = sock.Send(msg)
+				}
+				if err != nil {
+					toolutil.PrintError("Send error: %v", err)
+					return err
+				}
+				toolutil.PrintInfo("Sent %d frame(s), %d payload bytes", len(frames), len(body))
+				return nil
+			}
+
+			if interactive {
+				return toolutil.RunInteractive(ctx, os.Stdin, &sendPayload, send)
+			}
+			return common.RunOnceOrPeriodic(ctx, once, sendInterval, send)
+		},
+	}
+
+	cmd.Flags().StringVar(&pattern, "pattern", "pub", "Socket pattern: pub or push")
+	cmd.Flags().StringVar(&mode, "mode", "bind", "Bind the endpoint or connect to it: bind or connect")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "tcp://127.0.0.1:5555", "ZeroMQ endpoint (tcp://HOST:PORT or ipc://PATH)")
+	cmd.Flags().StringVar(&sendTopic, "topic", "", "Topic sent as the first frame, matched by SUB prefix filters (supports template placeholders)")
+	cmd.Flags().StringArrayVar(&extraFrames, "frame", nil, "Extra frame sent between the topic and the payload, which is always the last frame (repeatable, supports template placeholders)")
+	toolutil.AddPayloadFlags(cmd, &sendPayload, "Hello, ZeroMQ!", &sendMIME, toolutil.CTText)
+	toolutil.AddIntervalFlag(cmd, &sendInterval, "5s")
+	toolutil.AddOnceFlag(cmd, &once)
+	toolutil.AddPrintPayloadFlag(cmd, &printPayload)
+	toolutil.AddInteractiveFlag(cmd, &interactive)
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddConnectRetryFlags(cmd, &connectRetry)
+	toolutil.AddTemplateDelimiterFlags(cmd, &openDelim, &closeDelim)
+	toolutil.AddSeedFlag(cmd, &seed)
+	toolutil.AddSeedPerMessageFlag(cmd, &seedPerMessage)
+	toolutil.AddAllowFileReadsFlag(cmd, &allowFileReads)
+	toolutil.AddAllowEnvReadsFlag(cmd, &allowEnvReads)
+	toolutil.AddExecFlags(cmd, &execOptions)
+	toolutil.AddPayloadURLFlag(cmd, &payloadURL)
+	toolutil.AddStrictTemplateFlag(cmd, &strictTemplate)
+	toolutil.AddTemplateVarFlag(cmd, &templateVars)
+	toolutil.AddFileRootFlag(cmd, &fileRoot)
+	toolutil.AddFileCacheFlag(cmd, &cacheFiles)
+	toolutil.AddDataSourceFlags(cmd, &dataSource)
+	toolutil.AddPayloadSourceFlags(cmd, &payloadSource)
+	toolutil.AddTemplateEngineFlag(cmd, &templateEngine)
+	toolutil.AddCloudEventsFlags(cmd, &cloudEvents)
+	toolutil.AddProtosetFlag(cmd, &protoset)
+	toolutil.AddPayloadSchemaFlag(cmd, &payloadSchema)
+	toolutil.AddCorruptRateFlag(cmd, &corruptRate)
+
+	return cmd
+}
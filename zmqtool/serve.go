@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-zeromq/zmq4"
+	"github.com/sandrolain/eventkit/pkg/common"
+	toolutil "github.com/sandrolain/eventkit/pkg/toolutil"
+	"github.com/spf13/cobra"
+)
+
+func serveCommand() *cobra.Command {
+	var (
+		pattern        string
+		mode           string
+		endpoint       string
+		subscriptions  []string
+		connectTimeout time.Duration
+		serveOpts      toolutil.ServeOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Receive and log messages on a SUB or PULL socket",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateSocket(pattern, []string{"sub", "pull"}, mode); err != nil {
+				return err
+			}
+			isSub := strings.ToLower(pattern) == "sub"
+			if !isSub && cmd.Flags().Changed("subscribe") {
+				return fmt.Errorf("--subscribe requires --pattern sub")
+			}
+
+			cleanup, err := toolutil.SetupServe(&serveOpts)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			ctx, cancel := toolutil.ServeContext(&serveOpts)
+			defer cancel()
+
+			var sock zmq4.Socket
+			if err := common.ConnectWithTimeout(ctx, connectTimeout, func(context.Context) error {
+				var err error
+				sock, err = openSocket(ctx, pattern, mode, endpoint, connectTimeout)
+				return err
+			}); err != nil {
+				return fmt.Errorf("error opening ZeroMQ socket: %w", err)
+			}
+			defer sock.Close() //nolint:errcheck
+
+			if isSub {
+				for _, prefix := range subscriptions {
+					if err := sock.SetOption(zmq4.OptionSubscribe, prefix); err != nil {
+						return fmt.Errorf("failed to subscribe to %q: %w", prefix, err)
+					}
+				}
+			}
+
+			toolutil.PrintSuccess("ZeroMQ socket ready")
+			toolutil.PrintKeyValue("Pattern", sock.Type())
+			toolutil.PrintKeyValue("Mode", mode)
+			toolutil.PrintKeyValue("Endpoint", endpoint)
+			if isSub {
+				toolutil.PrintKeyValue("Subscriptions", strings.Join(quoteAll(subscriptions), ", "))
+			}
+
+			for {
+				msg, err := sock.Recv()
+				if ctx.Err() != nil {
+					toolutil.PrintInfo("Shutting down gracefully")
+					return nil
+				}
+				if err != nil {
+					return fmt.Errorf("receive error: %w", err)
+				}
+				printMessage(msg.Frames, isSub)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&pattern, "pattern", "sub", "Socket pattern: sub or pull")
+	cmd.Flags().StringVar(&mode, "mode", "connect", "Bind the endpoint or connect to it: bind or connect")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "tcp://127.0.0.1:5555", "ZeroMQ endpoint (tcp://HOST:PORT or ipc://PATH)")
+	cmd.Flags().StringArrayVar(&subscriptions, "subscribe", []string{""}, "SUB prefix filter (repeatable, default: every message)")
+	toolutil.AddConnectTimeoutFlag(cmd, &connectTimeout)
+	toolutil.AddServeFlags(cmd, &serveOpts)
+
+	return cmd
+}
+
+// quoteAll quotes prefixes so the empty subscription is visible.
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return quoted
+}
+
+// printMessage prints a multipart message. The last frame is the body; on SUB sockets the first
+// frame of a multipart message is shown as the topic, and any frames in between are listed.
+func printMessage(frames [][]byte, isSub bool) {
+	if len(frames) == 0 {
+		return
+	}
+	body := frames[len(frames)-1]
+	leading := frames[:len(frames)-1]
+	var sections []toolutil.MessageSection
+	if isSub && len(leading) > 0 {
+		sections = append(sections, toolutil.MessageSection{Title: "Topic", Items: []toolutil.KV{{Key: "Name", Value: formatFrame(leading[0])}}})
+		leading = leading[1:]
+	}
+	if len(leading) > 0 {
+		sections = append(sections, toolutil.MessageSection{Title: fmt.Sprintf("Frames (%d)", len(leading)), Items: frameItems(leading)})
+	}
+	toolutil.PrintColoredMessage("ZeroMQ", sections, body, toolutil.GuessMIME(body))
+}